@@ -8,6 +8,7 @@ package app
 
 import (
 	"fmt"
+	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/renderer"
 	"github.com/g3n/engine/window"
 	"syscall/js"
@@ -21,6 +22,7 @@ const canvasId = "g3n-canvas"
 type Application struct {
 	window.IWindow                    // Embedded WebGLCanvas
 	keyState       *window.KeyState   // Keep track of keyboard state
+	commands       *core.CommandQueue // Deferred scene graph mutations
 	renderer       *renderer.Renderer // Renderer object
 	startTime      time.Time          // Application start time
 	frameStart     time.Time          // Frame start time
@@ -45,6 +47,7 @@ func App() *Application {
 	a.IWindow = window.Get()
 	// TODO audio setup here
 	a.keyState = window.NewKeyState(a) // Create KeyState
+	a.commands = core.NewCommandQueue()
 	// Create renderer and add default shaders
 	a.renderer = renderer.NewRenderer(a.Gls())
 	err = a.renderer.AddDefaultShaders()
@@ -72,6 +75,10 @@ func (a *Application) Run(update func(rend *renderer.Renderer, deltaTime time.Du
 		now := time.Now()
 		a.frameDelta = now.Sub(a.frameStart)
 		a.frameStart = now
+		// Poll gamepads, dispatching joystick events
+		a.IWindow.(*window.WebGlCanvas).PollJoysticks()
+		// Execute scene graph mutations posted by other goroutines
+		a.commands.Flush()
 		// Call user's update function
 		update(a.renderer, a.frameDelta)
 		// Set up new callback if not exiting
@@ -106,6 +113,14 @@ func (a *Application) Renderer() *renderer.Renderer {
 	return a.renderer
 }
 
+// Commands returns the application's command queue.
+// Other goroutines use it to post scene graph mutations, which are
+// executed at the start of each frame before the user's update function.
+func (a *Application) Commands() *core.CommandQueue {
+
+	return a.commands
+}
+
 // KeyState returns the application's KeyState.
 func (a *Application) KeyState() *window.KeyState {
 
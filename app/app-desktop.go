@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"github.com/g3n/engine/audio/al"
 	"github.com/g3n/engine/audio/vorbis"
+	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/renderer"
 	"github.com/g3n/engine/window"
 	"time"
@@ -26,6 +27,7 @@ const (
 type Application struct {
 	window.IWindow                    // Embedded GlfwWindow
 	keyState       *window.KeyState   // Keep track of keyboard state
+	commands       *core.CommandQueue // Deferred scene graph mutations
 	renderer       *renderer.Renderer // Renderer object
 	audioDev       *al.Device         // Default audio device
 	startTime      time.Time          // Application start time
@@ -49,6 +51,7 @@ func App() *Application {
 	a.IWindow = window.Get()
 	a.openDefaultAudioDevice()         // Set up audio
 	a.keyState = window.NewKeyState(a) // Create KeyState
+	a.commands = core.NewCommandQueue()
 	// Create renderer and add default shaders
 	a.renderer = renderer.NewRenderer(a.Gls())
 	err = a.renderer.AddDefaultShaders()
@@ -83,6 +86,8 @@ func (a *Application) Run(update func(rend *renderer.Renderer, deltaTime time.Du
 		now := time.Now()
 		a.frameDelta = now.Sub(a.frameStart)
 		a.frameStart = now
+		// Execute scene graph mutations posted by other goroutines
+		a.commands.Flush()
 		// Call user's update function
 		update(a.renderer, a.frameDelta)
 		// Swap buffers and poll events
@@ -112,6 +117,14 @@ func (a *Application) Renderer() *renderer.Renderer {
 	return a.renderer
 }
 
+// Commands returns the application's command queue.
+// Other goroutines use it to post scene graph mutations, which are
+// executed at the start of each frame before the user's update function.
+func (a *Application) Commands() *core.CommandQueue {
+
+	return a.commands
+}
+
 // KeyState returns the application's KeyState.
 func (a *Application) KeyState() *window.KeyState {
 
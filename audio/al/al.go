@@ -425,6 +425,35 @@ func CtxGetString(dev *Device, param uint) string {
 	return C.GoString((*C.char)(cstr))
 }
 
+// CtxGetStringList returns the list of strings of the specified parameter,
+// such as the names of the available devices, which OpenAL returns
+// separated by nulls and terminated by two nulls.
+func CtxGetStringList(dev *Device, param uint) []string {
+
+	var cdev *C.ALCdevice = nil
+	if dev != nil {
+		cdev = dev.cdev
+	}
+	cstr := C.alcGetString(cdev, C.ALCenum(param))
+	var list []string
+	if cstr == nil {
+		return list
+	}
+	buf := (*[1 << 20]byte)(unsafe.Pointer(cstr))
+	start := 0
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != 0 {
+			continue
+		}
+		if i == start {
+			break
+		}
+		list = append(list, string(buf[start:i]))
+		start = i + 1
+	}
+	return list
+}
+
 func CtxGetIntegerv(dev *Device, param uint32, values []int32) {
 
 	C.alcGetIntegerv(dev.cdev, C.ALCenum(param), C.ALCsizei(len(values)), (*C.ALCint)(unsafe.Pointer(&values[0])))
@@ -447,6 +476,7 @@ func CaptureCloseDevice(dev *Device) error {
 
 	cres := C.alcCaptureCloseDevice(dev.cdev)
 	if cres == C.AL_TRUE {
+		delete(mapDevice, dev.cdev)
 		return nil
 	}
 	return fmt.Errorf("%s", errCodes[uint(C.alGetError())])
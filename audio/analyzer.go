@@ -0,0 +1,336 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package audio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Analyzer is an analysis tap which computes the frequency spectrum, levels
+// and beats of an audio stream, for driving audio reactive visuals.
+// Samples are written by a Player or Capture the analyzer is attached to, or directly
+// with Write, and are consumed at real time pace by Update, which should be called
+// every frame. Since players decode ahead of playback, this keeps the analysis
+// approximately in sync with what is heard.
+type Analyzer struct {
+	Smoothing       float32 // Spectrum smoothing between updates, from 0 (none) to 1 (default is 0.8)
+	Sensitivity     float32 // Ratio of the instant to the average energy to detect a beat (default is 1.4)
+	MinBeatInterval float32 // Minimum time between beats in seconds (default is 0.25)
+	BeatLow         float32 // Lower frequency of the band used for beat detection in Hz (default is 20)
+	BeatHigh        float32 // Higher frequency of the band used for beat detection in Hz (default is 150)
+
+	mu       sync.Mutex // Protects pending and rate, which are written by other goroutines
+	pending  []float32  // Written samples not yet consumed
+	rate     int        // Sample rate of the written samples
+	size     int        // FFT size
+	window   []float32  // Last size samples analyzed
+	hann     []float32  // Hann window coefficients
+	re       []float32  // FFT real parts
+	im       []float32  // FFT imaginary parts
+	spectrum []float32  // Smoothed magnitude spectrum
+	rms      float32    // RMS level of the analysis window
+	peak     float32    // Peak level of the analysis window
+	history  []float32  // Beat band energy history
+	hpos     int        // Next position in the energy history
+	hcount   int        // Number of valid energy history entries
+	sinceBt  float32    // Time since the last beat in seconds
+	beat     bool       // Whether a beat was detected in the last update
+	carry    float32    // Fractional samples to consume in the next update
+}
+
+// NewAnalyzer creates and returns a pointer to a new analyzer
+// with the specified FFT size, which is rounded up to a power of two of at least 2.
+func NewAnalyzer(fftSize int) *Analyzer {
+
+	size := 2
+	for size < fftSize {
+		size <<= 1
+	}
+	a := new(Analyzer)
+	a.Smoothing = 0.8
+	a.Sensitivity = 1.4
+	a.MinBeatInterval = 0.25
+	a.BeatLow = 20
+	a.BeatHigh = 150
+	a.size = size
+	a.window = make([]float32, size)
+	a.hann = make([]float32, size)
+	for i := range a.hann {
+		a.hann[i] = float32(0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size-1)))
+	}
+	a.re = make([]float32, size)
+	a.im = make([]float32, size)
+	a.spectrum = make([]float32, size/2)
+	a.history = make([]float32, 43)
+	a.rate = 44100
+	return a
+}
+
+// Write appends mono samples, between -1 and 1, at the specified sample rate.
+// Returns an error if the sample rate is not positive.
+func (a *Analyzer) Write(samples []float32, sampleRate int) error {
+
+	if sampleRate <= 0 {
+		return fmt.Errorf("invalid sample rate:%d", sampleRate)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rate = sampleRate
+	a.pending = append(a.pending, samples...)
+	a.limit()
+	return nil
+}
+
+// WritePCM appends interleaved PCM data with the specified number of channels
+// and bits per sample, mixing the channels to mono.
+// Returns an error if the number of channels or the sample rate is not positive,
+// or the bits per sample are not 8 or 16.
+func (a *Analyzer) WritePCM(data []byte, channels, bitsSample, sampleRate int) error {
+
+	if channels < 1 {
+		return fmt.Errorf("invalid number of channels:%d", channels)
+	}
+	if bitsSample != 8 && bitsSample != 16 {
+		return fmt.Errorf("unsupported bits per sample:%d", bitsSample)
+	}
+	if sampleRate <= 0 {
+		return fmt.Errorf("invalid sample rate:%d", sampleRate)
+	}
+	bytes := bitsSample / 8
+	frame := bytes * channels
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rate = sampleRate
+	// Mixes the samples directly into the pending samples, whose storage is reused
+	for off := 0; off+frame <= len(data); off += frame {
+		var sum float32
+		for c := off; c < off+frame; c += bytes {
+			if bytes == 2 {
+				sum += float32(int16(uint16(data[c])|uint16(data[c+1])<<8)) / 32768
+			} else {
+				sum += (float32(data[c]) - 128) / 128
+			}
+		}
+		a.pending = append(a.pending, sum/float32(channels))
+	}
+	a.limit()
+	return nil
+}
+
+// limit drops the oldest pending samples to limit the latency to two seconds.
+// It must be called with the mutex locked.
+func (a *Analyzer) limit() {
+
+	if max := 2 * a.rate; len(a.pending) > max {
+		a.pending = append(a.pending[:0], a.pending[len(a.pending)-max:]...)
+	}
+}
+
+// Reset discards all pending samples and analysis results.
+func (a *Analyzer) Reset() {
+
+	a.mu.Lock()
+	a.pending = a.pending[:0]
+	a.mu.Unlock()
+	for i := range a.window {
+		a.window[i] = 0
+	}
+	for i := range a.spectrum {
+		a.spectrum[i] = 0
+	}
+	a.rms, a.peak = 0, 0
+	a.hcount, a.hpos = 0, 0
+	a.beat = false
+	a.carry = 0
+}
+
+// Update consumes the samples corresponding to the specified elapsed time in seconds
+// and updates the spectrum, levels and beat detection.
+func (a *Analyzer) Update(deltaTime float32) {
+
+	// Consumes the samples played during the elapsed time
+	a.mu.Lock()
+	rate := a.rate
+	want := float32(rate)*deltaTime + a.carry
+	n := int(want)
+	a.carry = want - float32(n)
+	if n > len(a.pending) {
+		n = len(a.pending)
+		a.carry = 0
+	}
+	consumed := a.pending[:n]
+	if n >= a.size {
+		copy(a.window, consumed[n-a.size:])
+	} else {
+		copy(a.window, a.window[n:])
+		copy(a.window[a.size-n:], consumed)
+	}
+	a.pending = append(a.pending[:0], a.pending[n:]...)
+	a.mu.Unlock()
+
+	// Levels
+	var sum, peak float32
+	for _, s := range a.window {
+		sum += s * s
+		if s < 0 {
+			s = -s
+		}
+		if s > peak {
+			peak = s
+		}
+	}
+	a.rms = float32(math.Sqrt(float64(sum / float32(a.size))))
+	a.peak = peak
+
+	// Spectrum
+	for i, s := range a.window {
+		a.re[i] = s * a.hann[i]
+		a.im[i] = 0
+	}
+	fft(a.re, a.im)
+	scale := 2 / float32(a.size)
+	for i := range a.spectrum {
+		mag := float32(math.Sqrt(float64(a.re[i]*a.re[i]+a.im[i]*a.im[i]))) * scale
+		a.spectrum[i] = a.spectrum[i]*a.Smoothing + mag*(1-a.Smoothing)
+	}
+
+	// Beat detection comparing the instant energy of the beat band with its recent average
+	a.beat = false
+	a.sinceBt += deltaTime
+	if n == 0 {
+		return
+	}
+	energy := a.bandEnergy(rate, a.BeatLow, a.BeatHigh)
+	if a.hcount == len(a.history) {
+		var avg float32
+		for _, e := range a.history {
+			avg += e
+		}
+		avg /= float32(len(a.history))
+		if energy > avg*a.Sensitivity && energy > 1e-6 && a.sinceBt >= a.MinBeatInterval {
+			a.beat = true
+			a.sinceBt = 0
+		}
+	} else {
+		a.hcount++
+	}
+	a.history[a.hpos] = energy
+	a.hpos = (a.hpos + 1) % len(a.history)
+}
+
+// bandEnergy returns the energy of the unsmoothed spectrum between the specified frequencies.
+func (a *Analyzer) bandEnergy(rate int, low, high float32) float32 {
+
+	var e float32
+	i0, i1 := a.bins(rate, low, high)
+	for i := i0; i <= i1; i++ {
+		e += a.re[i]*a.re[i] + a.im[i]*a.im[i]
+	}
+	return e / float32(a.size)
+}
+
+// bins returns the range of spectrum bins between the specified frequencies.
+func (a *Analyzer) bins(rate int, low, high float32) (int, int) {
+
+	binHz := float32(rate) / float32(a.size)
+	i0 := int(low / binHz)
+	i1 := int(high / binHz)
+	if i0 < 0 {
+		i0 = 0
+	}
+	if i0 >= len(a.spectrum) {
+		i0 = len(a.spectrum) - 1
+	}
+	if i1 >= len(a.spectrum) {
+		i1 = len(a.spectrum) - 1
+	}
+	if i1 < i0 {
+		i1 = i0
+	}
+	return i0, i1
+}
+
+// Spectrum returns the smoothed magnitude spectrum.
+// Bin i corresponds to the frequency i*SampleRate/FFTSize.
+// The returned slice is owned by the analyzer and changes on each Update.
+func (a *Analyzer) Spectrum() []float32 {
+
+	return a.spectrum
+}
+
+// Band returns the average smoothed magnitude of the spectrum between the specified frequencies in Hz.
+func (a *Analyzer) Band(low, high float32) float32 {
+
+	a.mu.Lock()
+	rate := a.rate
+	a.mu.Unlock()
+	i0, i1 := a.bins(rate, low, high)
+	var sum float32
+	for i := i0; i <= i1; i++ {
+		sum += a.spectrum[i]
+	}
+	return sum / float32(i1-i0+1)
+}
+
+// FFTSize returns the FFT size.
+func (a *Analyzer) FFTSize() int {
+
+	return a.size
+}
+
+// RMS returns the RMS level of the last analysis window, between 0 and 1.
+func (a *Analyzer) RMS() float32 {
+
+	return a.rms
+}
+
+// Peak returns the peak level of the last analysis window, between 0 and 1.
+func (a *Analyzer) Peak() float32 {
+
+	return a.peak
+}
+
+// Beat returns whether a beat was detected in the last Update.
+func (a *Analyzer) Beat() bool {
+
+	return a.beat
+}
+
+// fft computes in place the discrete Fourier transform of the specified
+// complex values using the iterative radix-2 algorithm. The length must be a power of two.
+func fft(re, im []float32) {
+
+	n := len(re)
+	// Bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			re[i], re[j] = re[j], re[i]
+			im[i], im[j] = im[j], im[i]
+		}
+	}
+	// Butterflies
+	for size := 2; size <= n; size <<= 1 {
+		ang := -2 * math.Pi / float64(size)
+		wr, wi := float32(math.Cos(ang)), float32(math.Sin(ang))
+		for start := 0; start < n; start += size {
+			cr, ci := float32(1), float32(0)
+			for k := 0; k < size/2; k++ {
+				a, b := start+k, start+k+size/2
+				tr := re[b]*cr - im[b]*ci
+				ti := re[b]*ci + im[b]*cr
+				re[b], im[b] = re[a]-tr, im[a]-ti
+				re[a], im[a] = re[a]+tr, im[a]+ti
+				cr, ci = cr*wr-ci*wi, cr*wi+ci*wr
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caption
+
+import (
+	"strings"
+	"time"
+
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/math32"
+)
+
+// TimeSource is the interface for objects which report a playback time, such as audio.Player.
+type TimeSource interface {
+	CurrentTime() float64 // Current playback time in seconds
+}
+
+// Display is a GUI panel which shows the cues of a track active at the current time
+// of a time source. The panel is hidden while there are no active cues and the text
+// is centered horizontally in the panel width.
+type Display struct {
+	*gui.Panel               // Embedded panel
+	Offset     time.Duration // Offset added to the source time, to compensate for latency
+	label      *gui.Label    // Label with the cue text
+	track      *Track        // Displayed track
+	source     TimeSource    // Time source
+	text       string        // Current text
+	padding    float32       // Padding around the text
+}
+
+// NewDisplay creates and returns a pointer to a new caption display with the specified width.
+func NewDisplay(width float32) *Display {
+
+	d := new(Display)
+	d.Panel = gui.NewPanel(width, 0)
+	d.Panel.SetColor4(&math32.Color4{R: 0, G: 0, B: 0, A: 0.6})
+	d.label = gui.NewLabel("")
+	d.label.SetColor(&math32.Color{R: 1, G: 1, B: 1})
+	d.label.SetFontSize(20)
+	d.Panel.Add(d.label)
+	d.padding = 6
+	d.Panel.Subscribe(gui.OnResize, func(evname string, ev interface{}) { d.recalc() })
+	d.Panel.SetVisible(false)
+	return d
+}
+
+// SetTrack sets the track whose cues are displayed.
+func (d *Display) SetTrack(track *Track) {
+
+	d.track = track
+	d.setText("")
+}
+
+// Track returns the displayed track.
+func (d *Display) Track() *Track {
+
+	return d.track
+}
+
+// SetSource sets the time source the cues are synchronized to, usually an audio.Player.
+func (d *Display) SetSource(source TimeSource) {
+
+	d.source = source
+}
+
+// Label returns the label used to display the text, so its font and colors can be configured.
+func (d *Display) Label() *gui.Label {
+
+	return d.label
+}
+
+// SetPadding sets the padding between the text and the panel borders.
+func (d *Display) SetPadding(padding float32) {
+
+	d.padding = padding
+	d.recalc()
+}
+
+// Update updates the displayed text from the current time of the source.
+// It should be called every frame.
+func (d *Display) Update() {
+
+	if d.track == nil || d.source == nil {
+		d.setText("")
+		return
+	}
+	d.ShowAt(time.Duration(d.source.CurrentTime()*float64(time.Second)) + d.Offset)
+}
+
+// ShowAt displays the cues of the track active at the specified time.
+func (d *Display) ShowAt(at time.Duration) {
+
+	if d.track == nil {
+		d.setText("")
+		return
+	}
+	cues := d.track.At(at)
+	lines := make([]string, len(cues))
+	for i := range cues {
+		lines[i] = cues[i].Text
+	}
+	d.setText(strings.Join(lines, "\n"))
+}
+
+// setText sets the displayed text, hiding the panel if it is empty.
+func (d *Display) setText(text string) {
+
+	if text == d.text {
+		return
+	}
+	d.text = text
+	d.label.SetText(text)
+	d.Panel.SetVisible(text != "")
+	d.recalc()
+}
+
+// recalc recalculates the panel height and the text position.
+func (d *Display) recalc() {
+
+	d.Panel.SetContentHeight(d.label.Height() + 2*d.padding)
+	x := (d.Panel.ContentWidth() - d.label.Width()) / 2
+	d.label.SetPosition(math32.Max(0, x), d.padding)
+}
@@ -0,0 +1,183 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caption
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Matches markup tags such as <i>, </b> or <v Speaker>
+var reTags = regexp.MustCompile(`<[^>]*>`)
+
+// Load loads a track from the specified SRT (.srt) or WebVTT (.vtt) file.
+func Load(path string) (*Track, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		return ParseSRT(f)
+	case ".vtt":
+		return ParseVTT(f)
+	}
+	return nil, fmt.Errorf("unsupported caption format: %s", path)
+}
+
+// ParseSRT parses a track in SubRip (SRT) format.
+func ParseSRT(r io.Reader) (*Track, error) {
+
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+	var cues []Cue
+	for _, b := range blocks {
+		// Optional numeric identifier before the timing line
+		if !strings.Contains(b.lines[0], "-->") {
+			b.lines = b.lines[1:]
+			b.line++
+		}
+		if len(b.lines) == 0 {
+			continue
+		}
+		cue, err := parseCue(b.lines)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", b.line, err)
+		}
+		cues = append(cues, cue)
+	}
+	return NewTrack(cues), nil
+}
+
+// ParseVTT parses a track in WebVTT format.
+// Comments, styles and regions are ignored, as are cue settings and markup.
+func ParseVTT(r io.Reader) (*Track, error) {
+
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0].lines[0], "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT header")
+	}
+	var cues []Cue
+	for _, b := range blocks[1:] {
+		first := b.lines[0]
+		if strings.HasPrefix(first, "NOTE") || first == "STYLE" || first == "REGION" {
+			continue
+		}
+		// Optional cue identifier before the timing line
+		if !strings.Contains(first, "-->") {
+			b.lines = b.lines[1:]
+			b.line++
+		}
+		if len(b.lines) == 0 {
+			continue
+		}
+		cue, err := parseCue(b.lines)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", b.line, err)
+		}
+		cues = append(cues, cue)
+	}
+	return NewTrack(cues), nil
+}
+
+// block is a group of non empty lines.
+type block struct {
+	line  int      // Line number of the first line
+	lines []string // Lines of the block
+}
+
+// readBlocks reads the specified text and returns its groups of lines separated by empty lines.
+func readBlocks(r io.Reader) ([]block, error) {
+
+	var blocks []block
+	var cur *block
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if strings.TrimSpace(line) == "" {
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			blocks = append(blocks, block{line: n})
+			cur = &blocks[len(blocks)-1]
+		}
+		cur.lines = append(cur.lines, line)
+	}
+	return blocks, scanner.Err()
+}
+
+// parseCue parses a cue from its timing line followed by its text lines.
+func parseCue(lines []string) (Cue, error) {
+
+	var cue Cue
+	parts := strings.SplitN(lines[0], "-->", 2)
+	if len(parts) != 2 {
+		return cue, fmt.Errorf("invalid timing: %q", lines[0])
+	}
+	var err error
+	cue.Start, err = parseTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return cue, err
+	}
+	// Cue settings may follow the end time
+	end := strings.Fields(parts[1])
+	if len(end) == 0 {
+		return cue, fmt.Errorf("invalid timing: %q", lines[0])
+	}
+	cue.End, err = parseTimestamp(end[0])
+	if err != nil {
+		return cue, err
+	}
+	text := strings.Join(lines[1:], "\n")
+	cue.Text = reTags.ReplaceAllString(text, "")
+	return cue, nil
+}
+
+// parseTimestamp parses a timestamp in the [hh:]mm:ss,mmm or [hh:]mm:ss.mmm formats.
+func parseTimestamp(s string) (time.Duration, error) {
+
+	s = strings.Replace(s, ",", ".", 1)
+	fields := strings.Split(s, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %q", s)
+	}
+	// Hours and minutes
+	whole := 0
+	for _, f := range fields[:len(fields)-1] {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp: %q", s)
+		}
+		whole = whole*60 + v
+	}
+	// Seconds with fraction, rounded to milliseconds
+	secs, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp: %q", s)
+	}
+	ms := int64(whole)*60000 + int64(math.Round(secs*1000))
+	return time.Duration(ms) * time.Millisecond, nil
+}
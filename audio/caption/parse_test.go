@@ -0,0 +1,60 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caption
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSRT(t *testing.T) {
+
+	src := "1\r\n00:00:01,000 --> 00:00:04,500\r\nHello <i>world</i>\r\n\r\n" +
+		"2\n00:00:04,000 --> 00:00:06,123\nSecond\nline\n"
+	track, err := ParseSRT(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(track.Cues) != 2 {
+		t.Fatalf("cues: %d", len(track.Cues))
+	}
+	c := track.Cues[0]
+	if c.Start != time.Second || c.End != 4500*time.Millisecond || c.Text != "Hello world" {
+		t.Errorf("cue 0: %+v", c)
+	}
+	c = track.Cues[1]
+	if c.End != 6123*time.Millisecond || c.Text != "Second\nline" {
+		t.Errorf("cue 1: %+v", c)
+	}
+	if n := len(track.At(4200 * time.Millisecond)); n != 2 {
+		t.Errorf("overlapping cues: %d", n)
+	}
+	if n := len(track.At(7 * time.Second)); n != 0 {
+		t.Errorf("cues after end: %d", n)
+	}
+}
+
+func TestParseVTT(t *testing.T) {
+
+	src := "WEBVTT - Example\n\nNOTE a comment\n\nintro\n00:01.000 --> 00:02.000 align:start\n<v Bob>Hi\n\n" +
+		"01:00:00.250 --> 01:00:01.000\nLater\n"
+	track, err := ParseVTT(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(track.Cues) != 2 {
+		t.Fatalf("cues: %d", len(track.Cues))
+	}
+	if c := track.Cues[0]; c.Start != time.Second || c.End != 2*time.Second || c.Text != "Hi" {
+		t.Errorf("cue 0: %+v", c)
+	}
+	if c := track.Cues[1]; c.Start != time.Hour+250*time.Millisecond {
+		t.Errorf("cue 1: %+v", c)
+	}
+	if _, err := ParseVTT(strings.NewReader("00:01.000 --> 00:02.000\nx\n")); err == nil {
+		t.Error("missing header not detected")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package caption implements timed text tracks (subtitles and captions)
+// loaded from SRT or WebVTT files and a GUI widget which displays the
+// cues of a track in sync with an audio source.
+package caption
+
+import (
+	"sort"
+	"time"
+)
+
+// Cue is a text displayed during an interval of time.
+type Cue struct {
+	Start time.Duration // Time the cue is shown
+	End   time.Duration // Time the cue is hidden
+	Text  string        // Text, possibly with multiple lines
+}
+
+// Track is a sequence of cues sorted by start time.
+type Track struct {
+	Language string // Optional language of the track
+	Label    string // Optional human readable label
+	Cues     []Cue  // Cues sorted by start time
+}
+
+// NewTrack creates and returns a pointer to a new track with the specified cues.
+func NewTrack(cues []Cue) *Track {
+
+	t := new(Track)
+	t.Cues = cues
+	t.Sort()
+	return t
+}
+
+// Sort sorts the cues by start time. It must be called if cues are modified directly.
+func (t *Track) Sort() {
+
+	sort.SliceStable(t.Cues, func(i, j int) bool {
+		return t.Cues[i].Start < t.Cues[j].Start
+	})
+}
+
+// At returns the cues active at the specified time.
+func (t *Track) At(at time.Duration) []Cue {
+
+	// Cues starting after the specified time cannot be active
+	n := sort.Search(len(t.Cues), func(i int) bool {
+		return t.Cues[i].Start > at
+	})
+	var active []Cue
+	for i := 0; i < n; i++ {
+		if at < t.Cues[i].End {
+			active = append(active, t.Cues[i])
+		}
+	}
+	return active
+}
+
+// Duration returns the end time of the last cue.
+func (t *Track) Duration() time.Duration {
+
+	var d time.Duration
+	for i := range t.Cues {
+		if t.Cues[i].End > d {
+			d = t.Cues[i].End
+		}
+	}
+	return d
+}
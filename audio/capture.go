@@ -0,0 +1,249 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !wasm
+
+package audio
+
+import (
+	"math"
+	"sync"
+
+	"github.com/g3n/engine/audio/al"
+)
+
+// CaptureDevices returns the names of the available audio capture devices, such as microphones.
+func CaptureDevices() []string {
+
+	return al.CtxGetStringList(nil, al.CaptureDeviceSpecifier)
+}
+
+// DefaultCaptureDevice returns the name of the default audio capture device.
+func DefaultCaptureDevice() string {
+
+	return al.CtxGetString(nil, al.CaptureDefaultDeviceSpecifier)
+}
+
+// Capture captures mono 16 bit PCM samples from an audio capture device, such as a microphone.
+// Captured samples are transferred from the device by Poll, which should be called
+// regularly (for example every frame), and are stored in a ring buffer from which
+// they are consumed by Read. When the ring buffer is full the oldest samples are dropped.
+// The methods of a capture may be called from different goroutines, such as Poll from
+// a capture goroutine and the others from the goroutine running the application.
+type Capture struct {
+	rate   int        // Sample rate in Hz
+	dmu    sync.Mutex // Protects the device fields below, used by Poll
+	dev    *al.Device // OpenAL capture device
+	raw    []byte     // Buffer for samples read from the device
+	active bool       // Whether capture is started
+	anl    *Analyzer  // Optional analysis tap
+	mu     sync.Mutex // Protects the ring buffer fields below, used by Poll and Read
+	ring   []int16    // Ring buffer of captured samples
+	rpos   int        // Ring buffer read position
+	count  int        // Number of samples in the ring buffer
+	rms    float32    // RMS level of the last polled block (0 to 1)
+	peak   float32    // Peak level of the last polled block (0 to 1)
+}
+
+// NewCapture opens the specified capture device, or the default device if the name is empty,
+// to capture at the specified sample rate in Hz. The ring buffer holds the specified number of
+// seconds of audio. Capture must be started with Start.
+func NewCapture(device string, sampleRate int, seconds float32) (*Capture, error) {
+
+	size := int(float32(sampleRate) * seconds)
+	if size < 1 {
+		size = sampleRate
+	}
+	dev, err := al.CaptureOpenDevice(device, uint32(sampleRate), al.FormatMono16, uint32(size))
+	if err != nil {
+		return nil, err
+	}
+	c := new(Capture)
+	c.dev = dev
+	c.rate = sampleRate
+	c.ring = make([]int16, size)
+	return c, nil
+}
+
+// SampleRate returns the sample rate in Hz.
+func (c *Capture) SampleRate() int {
+
+	return c.rate
+}
+
+// Start starts capturing samples.
+func (c *Capture) Start() {
+
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	if c.active {
+		return
+	}
+	al.CaptureStart(c.dev)
+	c.active = true
+}
+
+// Stop stops capturing samples. Samples in the ring buffer can still be read.
+func (c *Capture) Stop() {
+
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	c.stop()
+}
+
+// stop stops capturing samples. It must be called with the device mutex locked.
+func (c *Capture) stop() {
+
+	if !c.active {
+		return
+	}
+	al.CaptureStop(c.dev)
+	c.active = false
+}
+
+// Active returns whether capture is started.
+func (c *Capture) Active() bool {
+
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	return c.active
+}
+
+// Close stops capturing and closes the capture device.
+func (c *Capture) Close() error {
+
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	c.stop()
+	return al.CaptureCloseDevice(c.dev)
+}
+
+// Poll transfers the samples captured by the device since the last poll
+// to the ring buffer, updates the levels and returns the number of new samples.
+func (c *Capture) Poll() int {
+
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	if !c.active {
+		return 0
+	}
+	var n [1]int32
+	al.CtxGetIntegerv(c.dev, al.CtxCaptureSamples, n[:])
+	count := int(n[0])
+	if count <= 0 {
+		return 0
+	}
+	if cap(c.raw) < count*2 {
+		c.raw = make([]byte, count*2)
+	}
+	raw := c.raw[:count*2]
+	al.CaptureSamples(c.dev, raw, uint(count))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var sum float64
+	var peak int
+	for i := 0; i < count; i++ {
+		s := int16(uint16(raw[2*i]) | uint16(raw[2*i+1])<<8)
+		c.write(s)
+		sum += float64(s) * float64(s)
+		a := int(s)
+		if a < 0 {
+			a = -a
+		}
+		if a > peak {
+			peak = a
+		}
+	}
+	c.rms = float32(math.Sqrt(sum/float64(count)) / 32768)
+	c.peak = float32(peak) / 32768
+	if c.anl != nil {
+		c.anl.WritePCM(raw, 1, 16, c.rate)
+	}
+	return count
+}
+
+// SetAnalyzer sets the analyzer which receives the captured samples
+// for spectrum, level and beat analysis. Use nil to remove it.
+func (c *Capture) SetAnalyzer(a *Analyzer) {
+
+	c.dmu.Lock()
+	c.anl = a
+	c.dmu.Unlock()
+}
+
+// Analyzer returns the analyzer of this capture or nil.
+func (c *Capture) Analyzer() *Analyzer {
+
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	return c.anl
+}
+
+// write appends a sample to the ring buffer, dropping the oldest sample if it is full.
+func (c *Capture) write(s int16) {
+
+	size := len(c.ring)
+	c.ring[(c.rpos+c.count)%size] = s
+	if c.count < size {
+		c.count++
+		return
+	}
+	c.rpos = (c.rpos + 1) % size
+}
+
+// Available returns the number of samples in the ring buffer which can be read.
+func (c *Capture) Available() int {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+// Read moves up to len(dst) samples from the ring buffer to dst
+// and returns the number of samples read.
+func (c *Capture) Read(dst []int16) int {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(dst)
+	if n > c.count {
+		n = c.count
+	}
+	size := len(c.ring)
+	first := n
+	if c.rpos+first > size {
+		first = size - c.rpos
+	}
+	copy(dst, c.ring[c.rpos:c.rpos+first])
+	copy(dst[first:n], c.ring[:n-first])
+	c.rpos = (c.rpos + n) % size
+	c.count -= n
+	return n
+}
+
+// Discard removes all samples from the ring buffer.
+func (c *Capture) Discard() {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rpos = 0
+	c.count = 0
+}
+
+// Level returns the RMS and peak levels, between 0 and 1, of the samples
+// transferred by the last Poll which returned samples.
+func (c *Capture) Level() (rms, peak float32) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rms, c.peak
+}
+
+// LevelDB converts the specified level to decibels relative to full scale.
+// Silence returns negative infinity.
+func LevelDB(level float32) float32 {
+
+	return float32(20 * math.Log10(float64(level)))
+}
@@ -7,15 +7,28 @@
 package audio
 
 import (
+	"time"
+
 	"github.com/g3n/engine/audio/al"
 	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
 )
 
-// Listener is an audio listener positioned in space
+// Listener is an audio listener positioned in space.
+// By default the listener uses its own world transform, so it is usually added
+// to the scene or to a camera. Alternatively it can follow any node, such as the
+// active camera, without being part of the scene, and estimate its own velocity
+// from the followed motion for the doppler effect.
 type Listener struct {
 	core.Node
+	FollowForward math32.Vector3 // Local forward direction of the followed node (default is Z-, as cameras)
+	FollowUp      math32.Vector3 // Local up direction of the followed node (default is Y+, as cameras)
+	follow        core.INode     // Followed node, if any
+	autoVelocity  bool           // Whether the velocity is estimated from the motion
+	prevPos       math32.Vector3 // World position at the previous update
+	prevTime      time.Time      // Time of the previous update from Render
+	hasPrev       bool           // Whether prevPos is valid
 }
 
 // NewListener returns a pointer to a new Listener object.
@@ -23,9 +36,83 @@ func NewListener() *Listener {
 
 	l := new(Listener)
 	l.Node.Init(l)
+	l.FollowForward.Set(0, 0, -1)
+	l.FollowUp.Set(0, 1, 0)
 	return l
 }
 
+// SetFollow sets the node whose world position and orientation are used
+// for the listener at each update. Use nil to use the listener's own transform.
+func (l *Listener) SetFollow(inode core.INode) {
+
+	l.follow = inode
+	l.hasPrev = false
+}
+
+// Follow returns the node followed by the listener or nil.
+func (l *Listener) Follow() core.INode {
+
+	return l.follow
+}
+
+// SetAutoVelocity sets whether the listener velocity is estimated at each update
+// from the change of its world position, for the doppler effect.
+func (l *Listener) SetAutoVelocity(state bool) {
+
+	l.autoVelocity = state
+	l.hasPrev = false
+}
+
+// AutoVelocity returns whether the listener velocity is estimated automatically.
+func (l *Listener) AutoVelocity() bool {
+
+	return l.autoVelocity
+}
+
+// Update updates the OpenAL position, orientation and, if enabled, velocity of the listener
+// using the specified elapsed time in seconds since the previous update.
+// The orientation is the world orientation of the followed node, with its local FollowForward
+// and FollowUp directions, or of the listener, with its local direction and the engine up
+// direction (see core.EngineCoords) as its local up direction.
+// It should be called every frame if the listener is not part of the rendered scene.
+func (l *Listener) Update(deltaTime float32) {
+
+	var wpos, vdir, vup math32.Vector3
+	var quat math32.Quaternion
+	if l.follow != nil {
+		// Orientation from the followed node rotation
+		fn := l.follow.GetNode()
+		fn.UpdateMatrixWorld()
+		fn.WorldPosition(&wpos)
+		fn.WorldQuaternion(&quat)
+		vdir = l.FollowForward
+		vup = l.FollowUp
+	} else {
+		l.WorldPosition(&wpos)
+		l.WorldQuaternion(&quat)
+		vdir = l.Direction()
+		vup = core.EngineCoords().UpVector()
+	}
+	vdir.ApplyQuaternion(&quat)
+	vup.ApplyQuaternion(&quat)
+
+	// Sets the listener world position and orientation
+	al.Listener3f(al.Position, wpos.X, wpos.Y, wpos.Z)
+	orientation := []float32{vdir.X, vdir.Y, vdir.Z, vup.X, vup.Y, vup.Z}
+	al.Listenerfv(al.Orientation, orientation)
+
+	// Estimates the velocity from the position change
+	if l.autoVelocity {
+		if l.hasPrev && deltaTime > 0 {
+			vel := wpos
+			vel.Sub(&l.prevPos).DivideScalar(deltaTime)
+			l.SetVelocityVec(&vel)
+		}
+		l.prevPos = wpos
+		l.hasPrev = true
+	}
+}
+
 // SetVelocity sets the velocity of the listener with x, y, z components
 func (l *Listener) SetVelocity(vx, vy, vz float32) {
 
@@ -64,25 +151,14 @@ func (l *Listener) Gain() float32 {
 }
 
 // Render is called by the renderer at each frame
-// Updates the OpenAL position and orientation of this listener
+// Updates the OpenAL position, orientation and velocity of this listener
 func (l *Listener) Render(gl *gls.GLS) {
 
-	// Sets the listener source world position
-	var wpos math32.Vector3
-	l.WorldPosition(&wpos)
-	al.Listener3f(al.Position, wpos.X, wpos.Y, wpos.Z)
-
-	// Get listener current world direction
-	var vdir math32.Vector3
-	l.WorldDirection(&vdir)
-
-	// Assumes initial UP vector and recalculates current up vector
-	vup := math32.Vector3{0, 1, 0}
-	var vright math32.Vector3
-	vright.CrossVectors(&vdir, &vup)
-	vup.CrossVectors(&vright, &vdir)
-
-	// Sets the listener orientation
-	orientation := []float32{vdir.X, vdir.Y, vdir.Z, vup.X, vup.Y, vup.Z}
-	al.Listenerfv(al.Orientation, orientation)
+	now := time.Now()
+	var dt float32
+	if !l.prevTime.IsZero() {
+		dt = float32(now.Sub(l.prevTime).Seconds())
+	}
+	l.prevTime = now
+	l.Update(dt)
 }
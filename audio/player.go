@@ -15,6 +15,7 @@ import (
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
 	"io"
+	"sync"
 	"time"
 	"unsafe"
 )
@@ -35,6 +36,8 @@ type Player struct {
 	pdata     unsafe.Pointer // Pointer to C allocated storage
 	disposed  bool           // Disposed flag
 	gchan     chan (string)  // Channel for informing of goroutine end
+	mu        sync.Mutex     // Protects analyzer, which is read by the streaming goroutine
+	analyzer  *Analyzer      // Optional analysis tap
 }
 
 // NewPlayer creates and returns a pointer to a new audio player object
@@ -114,6 +117,9 @@ func (p *Player) Play() error {
 		if err != nil {
 			return err
 		}
+		if a := p.Analyzer(); a != nil {
+			a.Reset()
+		}
 
 		// Fill buffers with decoded data
 		for i := 0; i < playerBufferCount; i++ {
@@ -266,7 +272,7 @@ func (p *Player) SetVelocity(vx, vy, vz float32) {
 
 // SetVelocityVec sets the velocity of this player from the specified vector
 // It is used to calculate Doppler effects
-func (p Player) SetVelocityVec(v *math32.Vector3) {
+func (p *Player) SetVelocityVec(v *math32.Vector3) {
 
 	al.Source3f(p.source, al.Velocity, v.X, v.Y, v.Z)
 }
@@ -291,6 +297,24 @@ func (p *Player) SetRolloffFactor(rfactor float32) {
 	al.Sourcef(p.source, al.RolloffFactor, rfactor)
 }
 
+// SetAnalyzer sets the analyzer which receives the decoded audio of this player
+// for spectrum, level and beat analysis. Use nil to remove it.
+// Audio with more than 16 bits per sample is not analyzed.
+func (p *Player) SetAnalyzer(a *Analyzer) {
+
+	p.mu.Lock()
+	p.analyzer = a
+	p.mu.Unlock()
+}
+
+// Analyzer returns the analyzer of this player or nil.
+func (p *Player) Analyzer() *Analyzer {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.analyzer
+}
+
 // Render satisfies the INode interface.
 // It is called by renderer at every frame and is used to
 // update the audio source position and direction
@@ -359,6 +383,13 @@ func (p *Player) fillBuffer(buf uint32) error {
 	if err != nil {
 		return err
 	}
+	// Sends data to the analysis tap, which copies it, so the C storage is used directly.
+	// Unsupported formats are not analyzed but still played.
+	if a := p.Analyzer(); a != nil {
+		info := &p.af.info
+		data := (*[playerBufferSize]byte)(p.pdata)[:n:n]
+		a.WritePCM(data, info.Channels, info.BitsSample, info.SampleRate)
+	}
 	// Sends data to buffer
 	//log.Debug("BufferData:%v format:%x n:%v rate:%v", buf, p.af.info.Format, n, p.af.info.SampleRate)
 	al.BufferData(buf, uint32(p.af.info.Format), p.pdata, uint32(n), uint32(p.af.info.SampleRate))
@@ -0,0 +1,184 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// OnBlendEnd is dispatched by a Blender when a blend finishes and the destination camera
+// takes over. Data is the destination *Camera.
+const OnBlendEnd = "camera.OnBlendEnd"
+
+// Blender transitions smoothly between two cameras instead of cutting from one to the other.
+// While blending, it drives its own output camera through the interpolated position,
+// orientation, field of view, orthographic size and near and far planes of the source and
+// destination cameras, in world coordinates, so the destination can keep moving during the
+// blend. The application renders the camera returned by Camera every frame.
+type Blender struct {
+	core.Dispatcher                   // Embedded event dispatcher
+	Ease            math32.EasingFunc // Easing of the blend (default is math32.EaseSmoothstep)
+	out             *Camera           // Camera driven during the blend
+	from            *Camera           // Source camera
+	to              *Camera           // Destination camera
+	ctrl            IControl          // Control of the destination camera, activated when the blend ends
+	elapsed         float32           // Elapsed time in seconds
+	duration        float32           // Duration of the blend in seconds
+	blending        bool              // Whether a blend is running
+}
+
+// NewBlender creates and returns a pointer to a new blender for the specified initial camera.
+// Its output camera has the aspect ratio and axis of the initial camera, and should be
+// kept out of the scene graph or added to its root.
+func NewBlender(cam *Camera) *Blender {
+
+	b := new(Blender)
+	b.Dispatcher.Initialize()
+	b.Ease = math32.EaseSmoothstep
+	b.out = NewPerspective(cam.Aspect(), cam.Near(), cam.Far(), cam.Fov(), cam.Axis())
+	b.to = cam
+	return b
+}
+
+// Camera returns the camera to render: the output camera while blending and the
+// destination camera of the last blend otherwise.
+func (b *Blender) Camera() *Camera {
+
+	if b.blending {
+		return b.out
+	}
+	return b.to
+}
+
+// Output returns the camera driven during blends, whose aspect ratio should be
+// updated with the other cameras when the window is resized.
+func (b *Blender) Output() *Camera {
+
+	return b.out
+}
+
+// Blending returns whether a blend is running.
+func (b *Blender) Blending() bool {
+
+	return b.blending
+}
+
+// Progress returns the eased progress of the running blend, from 0 to 1.
+func (b *Blender) Progress() float32 {
+
+	if !b.blending {
+		return 1
+	}
+	return b.ease(b.elapsed / b.duration)
+}
+
+// Blend starts a blend of the specified duration in seconds from the current camera to
+// the specified camera, which is live immediately if the duration is not positive.
+// The specified control of the destination camera, if not nil, is deactivated during
+// the blend and activated when it ends, while the control of the current camera should
+// be deactivated by the caller. A blend started while blending starts from the
+// blended pose.
+func (b *Blender) Blend(to *Camera, ctrl IControl, duration float32) {
+
+	if b.blending {
+		// Freezes the current blended pose as the new source
+		b.from = NewPerspective(b.out.Aspect(), b.out.Near(), b.out.Far(), b.out.Fov(), b.out.Axis())
+		b.from.SetProjection(b.out.Projection())
+		b.from.SetSize(b.out.Size())
+		pos := b.out.Position()
+		rot := b.out.Quaternion()
+		b.from.SetPositionVec(&pos)
+		b.from.SetQuaternionQuat(&rot)
+	} else {
+		b.from = b.to
+	}
+	b.to = to
+	b.ctrl = ctrl
+	b.elapsed = 0
+	b.duration = duration
+	if duration <= 0 {
+		b.finish()
+		return
+	}
+	if ctrl != nil {
+		ctrl.SetActive(false)
+	}
+	b.blending = true
+	b.apply(0)
+}
+
+// Finish ends the running blend immediately, making the destination camera live.
+func (b *Blender) Finish() {
+
+	if b.blending {
+		b.finish()
+	}
+}
+
+// Update advances the running blend by the specified elapsed time in seconds.
+// It should be called every frame, after the destination camera is moved.
+func (b *Blender) Update(deltaTime float32) {
+
+	if !b.blending {
+		return
+	}
+	b.elapsed += deltaTime
+	if b.elapsed >= b.duration {
+		b.finish()
+		return
+	}
+	b.apply(b.ease(b.elapsed / b.duration))
+}
+
+// finish makes the destination camera live and activates its control.
+func (b *Blender) finish() {
+
+	b.blending = false
+	b.from = nil
+	if b.ctrl != nil {
+		b.ctrl.SetActive(true)
+	}
+	b.Dispatch(OnBlendEnd, b.to)
+}
+
+// ease returns the eased value of the specified linear progress.
+func (b *Blender) ease(t float32) float32 {
+
+	if b.Ease == nil {
+		return t
+	}
+	return b.Ease(t)
+}
+
+// apply sets the output camera to the blend of the source and destination cameras
+// for the specified eased progress.
+func (b *Blender) apply(alpha float32) {
+
+	var fromPos, toPos math32.Vector3
+	var fromRot, toRot math32.Quaternion
+	b.from.UpdateMatrixWorld()
+	b.to.UpdateMatrixWorld()
+	b.from.WorldPosition(&fromPos)
+	b.to.WorldPosition(&toPos)
+	b.from.WorldQuaternion(&fromRot)
+	b.to.WorldQuaternion(&toRot)
+	fromPos.Lerp(&toPos, alpha)
+	fromRot.Slerp(&toRot, alpha)
+	b.out.SetPositionVec(&fromPos)
+	b.out.SetQuaternionQuat(&fromRot)
+
+	// The projection switches halfway between perspective and orthographic cameras
+	proj := b.to.Projection()
+	if alpha < 0.5 {
+		proj = b.from.Projection()
+	}
+	b.out.SetProjection(proj)
+	b.out.SetFov(b.from.Fov() + (b.to.Fov()-b.from.Fov())*alpha)
+	b.out.SetSize(b.from.Size() + (b.to.Size()-b.from.Size())*alpha)
+	b.out.SetNear(b.from.Near() + (b.to.Near()-b.from.Near())*alpha)
+	b.out.SetFar(b.from.Far() + (b.to.Far()-b.from.Far())*alpha)
+	b.out.UpdateMatrixWorld()
+}
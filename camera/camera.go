@@ -225,6 +225,134 @@ func (c *Camera) UpdateSize(targetDist float32) {
 	}
 }
 
+// FitDistance returns the distance from the center of the specified box at which the
+// camera must be placed so that the whole box is visible, with the specified margin
+// added as a fraction of the box size (e.g. 0.1 for 10%).
+func (c *Camera) FitDistance(box *math32.Box3, margin float32) float32 {
+
+	var sphere math32.Sphere
+	box.GetBoundingSphere(&sphere)
+	radius := math32.Max(sphere.Radius*(1+margin), c.near)
+
+	// Calculate the half field-of-view angles along both axes
+	half := c.fov * math32.Pi / 360
+	var halfV, halfH float32
+	if c.axis == Vertical {
+		halfV = half
+		halfH = math32.Atan(math32.Tan(half) * c.aspect)
+	} else {
+		halfH = half
+		halfV = math32.Atan(math32.Tan(half) / c.aspect)
+	}
+	return radius / math32.Sin(math32.Min(halfV, halfH))
+}
+
+// FitToBox moves the camera along its current viewing direction so that the bounding sphere
+// of the specified box, in the coordinates of the camera's parent, fills the view with the
+// specified margin added as a fraction of the box size. For orthographic cameras the size is also updated.
+// Returns the new distance from the camera to the center of the box.
+func (c *Camera) FitToBox(box *math32.Box3, margin float32) float32 {
+
+	return c.placeAt(box, c.FitDistance(box, margin), c.fitSize(box, margin))
+}
+
+// FrameMargin is the margin used by FrameBox, as a fraction of the box size.
+const FrameMargin = 0.1
+
+// FrameBox moves and aims the camera at the center of the specified box, in the coordinates
+// of the camera's parent, keeping its viewing direction, so that the box fills the view with
+// FrameMargin, as the "focus selected" command of editors. Unlike FitToBox, which fits the
+// bounding sphere of the box, the corners of the box are fitted, which frames flat and long
+// boxes tightly. For orthographic cameras the size is also updated.
+// Returns the new distance from the camera to the center of the box.
+func (c *Camera) FrameBox(bbox *math32.Box3) float32 {
+
+	dist, size := c.fitCorners(bbox, FrameMargin)
+	return c.placeAt(bbox, dist, size)
+}
+
+// placeAt moves the camera along its current viewing direction to the specified distance
+// from the center of the specified box and sets the orthographic size of orthographic cameras.
+// Returns the distance.
+func (c *Camera) placeAt(box *math32.Box3, dist, size float32) float32 {
+
+	var center math32.Vector3
+	box.Center(&center)
+
+	// Camera looks along its local negative Z axis
+	quat := c.Quaternion()
+	dir := math32.NewVector3(0, 0, -1).ApplyQuaternion(&quat)
+	pos := center
+	pos.Add(dir.MultiplyScalar(-dist))
+	c.SetPositionVec(&pos)
+
+	if c.proj == Orthographic {
+		c.SetSize(size)
+	}
+	return dist
+}
+
+// fitSize returns the orthographic size which makes the specified box
+// fit the view with the specified margin.
+func (c *Camera) fitSize(box *math32.Box3, margin float32) float32 {
+
+	var sphere math32.Sphere
+	box.GetBoundingSphere(&sphere)
+	diam := 2 * sphere.Radius * (1 + margin)
+	if c.axis == Vertical {
+		return diam * math32.Max(1, 1/c.aspect)
+	}
+	return diam * math32.Max(1, c.aspect)
+}
+
+// fitCorners returns the distance from the center of the specified box at which the perspective
+// camera, with its current orientation, sees all the corners of the box scaled by the specified
+// margin, and the orthographic size which fits them.
+func (c *Camera) fitCorners(box *math32.Box3, margin float32) (dist, size float32) {
+
+	// Calculate the tangents of the half field-of-view angles along both axes
+	tan := math32.Tan(c.fov * math32.Pi / 360)
+	tanV, tanH := tan, tan
+	if c.axis == Vertical {
+		tanH *= c.aspect
+	} else {
+		tanV /= c.aspect
+	}
+
+	var center math32.Vector3
+	box.Center(&center)
+	inv := c.Quaternion()
+	inv.Conjugate()
+	var halfW, halfH float32
+	for i := 0; i < 8; i++ {
+		corner := box.Min
+		if i&1 != 0 {
+			corner.X = box.Max.X
+		}
+		if i&2 != 0 {
+			corner.Y = box.Max.Y
+		}
+		if i&4 != 0 {
+			corner.Z = box.Max.Z
+		}
+		// Corner in camera axes relative to the center, with Z pointing toward the camera
+		corner.Sub(&center).MultiplyScalar(1 + margin).ApplyQuaternion(&inv)
+		dx := math32.Abs(corner.X)
+		dy := math32.Abs(corner.Y)
+		dist = math32.Max(dist, math32.Max(dx/tanH, dy/tanV)+corner.Z)
+		dist = math32.Max(dist, corner.Z+c.near)
+		halfW = math32.Max(halfW, dx)
+		halfH = math32.Max(halfH, dy)
+	}
+
+	if c.axis == Vertical {
+		size = 2 * math32.Max(halfH, halfW/c.aspect)
+	} else {
+		size = 2 * math32.Max(halfW, halfH*c.aspect)
+	}
+	return dist, size
+}
+
 // ViewMatrix returns the view matrix of the camera.
 func (c *Camera) ViewMatrix(m *math32.Matrix4) {
 
@@ -296,3 +424,26 @@ func (c *Camera) Unproject(v *math32.Vector3) *math32.Vector3 {
 	v.ApplyProjection(invViewMat.Multiply(&invProjMat))
 	return v
 }
+
+// UnprojectRay sets the specified ray to the ray in world coordinates which starts at the
+// camera and passes through the specified point in normalized device coordinates (-1 to 1).
+func (c *Camera) UnprojectRay(x, y float32, ray *math32.Ray) {
+
+	c.UpdateMatrixWorld()
+	var origin, dir math32.Vector3
+	if c.proj == Perspective {
+		c.WorldPosition(&origin)
+		dir.Set(x, y, 0.5)
+		c.Unproject(&dir)
+		dir.Sub(&origin)
+	} else {
+		origin.Set(x, y, -1)
+		c.Unproject(&origin)
+		var quat math32.Quaternion
+		c.WorldQuaternion(&quat)
+		dir.Set(0, 0, -1)
+		dir.ApplyQuaternion(&quat)
+	}
+	dir.Normalize()
+	ray.Set(&origin, &dir)
+}
@@ -0,0 +1,252 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// OnControlChange is dispatched by a ControlManager when the active control changes.
+// Data is the name of the new active control.
+const OnControlChange = "camera.OnControlChange"
+
+// IControl is the interface for camera controls which can be managed by a ControlManager.
+type IControl interface {
+	Active() bool
+	SetActive(active bool)
+	Update(deltaTime float32)
+	Dispose()
+}
+
+// IPoseControl is the interface for controls which know the pose they drive the camera to,
+// such as event-driven controls which only move the camera on user input. When activating
+// other controls, the ControlManager updates them once to find the pose to switch to.
+type IPoseControl interface {
+	IControl
+	ControlPose() Pose
+}
+
+// ControlManager owns several controls of the same camera and keeps exactly one of them
+// active, so only the active control receives user input and is updated.
+// When switching controls the camera can blend smoothly from its previous pose
+// to the pose driven by the new control.
+type ControlManager struct {
+	core.Dispatcher                     // Embedded event dispatcher
+	cam             *Camera             // Controlled camera
+	controls        map[string]IControl // Controls by name
+	names           []string            // Control names in insertion order
+	active          string              // Name of the active control
+	blend           controlBlend        // Current blend between controls
+}
+
+// controlBlend describes a blend of the camera pose when switching controls.
+type controlBlend struct {
+	active   bool              // Whether the blend is running
+	elapsed  float32           // Elapsed time in seconds
+	duration float32           // Total duration in seconds
+	fromPos  math32.Vector3    // Camera position when the switch started
+	fromRot  math32.Quaternion // Camera orientation when the switch started
+	toPos    math32.Vector3    // Camera position driven by the new control
+	toRot    math32.Quaternion // Camera orientation driven by the new control
+	lastPos  math32.Vector3    // Last blended camera position
+	lastRot  math32.Quaternion // Last blended camera orientation
+}
+
+// NewControlManager creates and returns a pointer to a new control manager for the specified camera.
+func NewControlManager(cam *Camera) *ControlManager {
+
+	cm := new(ControlManager)
+	cm.Dispatcher.Initialize()
+	cm.cam = cam
+	cm.controls = make(map[string]IControl)
+	return cm
+}
+
+// Add adds a control with the specified name, replacing and disposing any control with the same name.
+// The first control added becomes the active one; the others are deactivated.
+func (cm *ControlManager) Add(name string, ctrl IControl) {
+
+	if old, ok := cm.controls[name]; ok {
+		old.Dispose()
+	} else {
+		cm.names = append(cm.names, name)
+	}
+	cm.controls[name] = ctrl
+	if cm.active == "" || cm.active == name {
+		cm.active = name
+		ctrl.SetActive(true)
+		return
+	}
+	ctrl.SetActive(false)
+}
+
+// Remove removes the control with the specified name, without disposing it, and returns it.
+// If it was the active control, it is deactivated and the first remaining control becomes active.
+// Returns nil if there is no control with the specified name.
+func (cm *ControlManager) Remove(name string) IControl {
+
+	ctrl, ok := cm.controls[name]
+	if !ok {
+		return nil
+	}
+	delete(cm.controls, name)
+	for i, n := range cm.names {
+		if n == name {
+			cm.names = append(cm.names[:i], cm.names[i+1:]...)
+			break
+		}
+	}
+	if cm.active == name {
+		ctrl.SetActive(false)
+		cm.active = ""
+		cm.blend.active = false
+		if len(cm.names) > 0 {
+			cm.active = cm.names[0]
+			cm.controls[cm.active].SetActive(true)
+		}
+		cm.Dispatch(OnControlChange, cm.active)
+	}
+	return ctrl
+}
+
+// Control returns the control with the specified name or nil if not found.
+func (cm *ControlManager) Control(name string) IControl {
+
+	return cm.controls[name]
+}
+
+// Names returns the names of the managed controls in the order they were added.
+func (cm *ControlManager) Names() []string {
+
+	return append([]string(nil), cm.names...)
+}
+
+// Active returns the name of the active control or an empty string if there are no controls.
+func (cm *ControlManager) Active() string {
+
+	return cm.active
+}
+
+// ActiveControl returns the active control or nil if there are no controls.
+func (cm *ControlManager) ActiveControl() IControl {
+
+	return cm.controls[cm.active]
+}
+
+// Activate makes the control with the specified name the only active control and moves
+// the camera to the pose driven by the control (see IPoseControl).
+// If duration (in seconds) is positive the camera blends from its current pose
+// to the pose driven by the new control over that time. The blend is cancelled
+// if the camera is moved by other means, such as user input, while it runs.
+func (cm *ControlManager) Activate(name string, duration float32) error {
+
+	ctrl, ok := cm.controls[name]
+	if !ok {
+		return fmt.Errorf("camera control not found: %s", name)
+	}
+	if name == cm.active {
+		return nil
+	}
+	if prev := cm.controls[cm.active]; prev != nil {
+		prev.SetActive(false)
+	}
+	cm.active = name
+	ctrl.SetActive(true)
+
+	// Blends from the current camera pose, which is the blended pose if a blend was running
+	b := &cm.blend
+	b.fromPos = cm.cam.Position()
+	b.fromRot = cm.cam.Quaternion()
+	if pc, ok := ctrl.(IPoseControl); ok {
+		pose := pc.ControlPose()
+		b.toPos = pose.Position
+		b.toRot = pose.Rotation
+	} else {
+		ctrl.Update(0)
+		b.toPos = cm.cam.Position()
+		b.toRot = cm.cam.Quaternion()
+	}
+	b.active = duration > 0
+	if !b.active {
+		cm.cam.SetPositionVec(&b.toPos)
+		cm.cam.SetQuaternionQuat(&b.toRot)
+	} else {
+		cm.cam.SetPositionVec(&b.fromPos)
+		cm.cam.SetQuaternionQuat(&b.fromRot)
+		b.lastPos = b.fromPos
+		b.lastRot = b.fromRot
+		b.elapsed = 0
+		b.duration = duration
+	}
+	cm.Dispatch(OnControlChange, name)
+	return nil
+}
+
+// Blending returns whether the camera is blending between controls.
+func (cm *ControlManager) Blending() bool {
+
+	return cm.blend.active
+}
+
+// Update updates the active control by the specified elapsed time in seconds
+// and advances the blend between controls. It should be called every frame.
+func (cm *ControlManager) Update(deltaTime float32) {
+
+	ctrl := cm.controls[cm.active]
+	if ctrl == nil {
+		return
+	}
+	b := &cm.blend
+	if b.active {
+		pos := cm.cam.Position()
+		rot := cm.cam.Quaternion()
+		if !pos.Equals(&b.lastPos) || !rot.Equals(&b.lastRot) {
+			b.active = false
+		}
+	}
+	if !b.active {
+		ctrl.Update(deltaTime)
+		return
+	}
+
+	// Let the control update from the pose it drives, not from the blended one
+	cm.cam.SetPositionVec(&b.toPos)
+	cm.cam.SetQuaternionQuat(&b.toRot)
+	ctrl.Update(deltaTime)
+	b.toPos = cm.cam.Position()
+	b.toRot = cm.cam.Quaternion()
+
+	b.elapsed += deltaTime
+	if b.elapsed >= b.duration {
+		b.active = false
+		return
+	}
+	// Smoothstep easing
+	x := b.elapsed / b.duration
+	alpha := x * x * (3 - 2*x)
+	pos := b.fromPos
+	pos.Lerp(&b.toPos, alpha)
+	rot := b.fromRot
+	rot.Slerp(&b.toRot, alpha)
+	cm.cam.SetPositionVec(&pos)
+	cm.cam.SetQuaternionQuat(&rot)
+	b.lastPos = pos
+	b.lastRot = rot
+}
+
+// Dispose disposes all managed controls.
+func (cm *ControlManager) Dispose() {
+
+	for _, name := range cm.names {
+		cm.controls[name].Dispose()
+	}
+	cm.controls = make(map[string]IControl)
+	cm.names = nil
+	cm.active = ""
+	cm.blend.active = false
+}
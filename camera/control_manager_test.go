@@ -0,0 +1,86 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/math32"
+)
+
+// posedControl is an event-driven control which does not move the camera when updated.
+type posedControl struct {
+	active bool
+	pose   Pose
+}
+
+func (c *posedControl) Active() bool          { return c.active }
+func (c *posedControl) SetActive(active bool) { c.active = active }
+func (c *posedControl) Update(float32)        {}
+func (c *posedControl) Dispose()              {}
+func (c *posedControl) ControlPose() Pose     { return c.pose }
+
+// drivingControl is a control which moves the camera to its position when updated.
+type drivingControl struct {
+	active bool
+	cam    *Camera
+	pos    math32.Vector3
+}
+
+func (c *drivingControl) Active() bool          { return c.active }
+func (c *drivingControl) SetActive(active bool) { c.active = active }
+func (c *drivingControl) Update(float32)        { c.cam.SetPositionVec(&c.pos) }
+func (c *drivingControl) Dispose()              {}
+
+// TestControlManagerBlend checks that switching controls blends to the pose of the new control.
+func TestControlManagerBlend(t *testing.T) {
+
+	cam := New(1)
+	posed := &posedControl{pose: Pose{Position: math32.Vector3{X: 10}}}
+	posed.pose.Rotation.SetFromAxisAngle(&math32.Vector3{Y: 1}, math32.Pi/2)
+	driving := &drivingControl{cam: cam, pos: math32.Vector3{Z: 10}}
+	cm := NewControlManager(cam)
+	cm.Add("driving", driving)
+	cm.Add("posed", posed)
+	cm.Update(0.1)
+	if pos := cam.Position(); !pos.Equals(&driving.pos) {
+		t.Fatalf("camera at %v instead of %v", pos, driving.pos)
+	}
+
+	// Blends to the pose of the event-driven control
+	cm.Activate("posed", 1)
+	if pos := cam.Position(); !pos.Equals(&driving.pos) {
+		t.Fatalf("camera moved to %v when the blend started", pos)
+	}
+	cm.Update(0.5)
+	pos := cam.Position()
+	if !cm.Blending() || pos.Equals(&driving.pos) || pos.Equals(&posed.pose.Position) {
+		t.Fatalf("camera at %v halfway through the blend", pos)
+	}
+	cm.Update(0.6)
+	pos = cam.Position()
+	rot := cam.Quaternion()
+	if cm.Blending() || !pos.Equals(&posed.pose.Position) || !rot.Equals(&posed.pose.Rotation) {
+		t.Fatalf("camera at %v %v after the blend instead of %v %v", pos, rot, posed.pose.Position, posed.pose.Rotation)
+	}
+
+	// Blends back to the pose the other control drives the camera to
+	cm.Activate("driving", 1)
+	cm.Update(0.5)
+	pos = cam.Position()
+	if !cm.Blending() || pos.Equals(&driving.pos) || pos.Equals(&posed.pose.Position) {
+		t.Fatalf("camera at %v halfway through the blend", pos)
+	}
+	cm.Update(0.6)
+	if pos = cam.Position(); cm.Blending() || !pos.Equals(&driving.pos) {
+		t.Fatalf("camera at %v after the blend instead of %v", pos, driving.pos)
+	}
+
+	// Switches immediately without duration
+	cm.Activate("posed", 0)
+	if pos = cam.Position(); !pos.Equals(&posed.pose.Position) {
+		t.Fatalf("camera at %v instead of %v", pos, posed.pose.Position)
+	}
+}
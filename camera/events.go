@@ -0,0 +1,114 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// Camera control events.
+// They are dispatched by camera controls, such as OrbitControl, through their embedded
+// Dispatcher after the controlled camera changes, so dependent systems do not need to poll it.
+// The event data is reused by the next events of the same control, so handlers must copy it to keep it.
+const (
+	OnCameraMove   = "camera.OnCameraMove"   // Camera position or target changed. Data is *MoveEvent
+	OnCameraRotate = "camera.OnCameraRotate" // Camera orientation changed. Data is *RotateEvent
+	OnZoomChange   = "camera.OnZoomChange"   // Distance to the target or orthographic size changed. Data is *ZoomEvent
+	OnSpeedChange  = "camera.OnSpeedChange"  // Movement speed of the control changed, such as to show it. Data is *SpeedEvent
+)
+
+// MoveEvent describes a change of the camera position and/or target.
+type MoveEvent struct {
+	OldPosition math32.Vector3
+	NewPosition math32.Vector3
+	OldTarget   math32.Vector3
+	NewTarget   math32.Vector3
+}
+
+// RotateEvent describes a change of the camera orientation.
+type RotateEvent struct {
+	OldRotation math32.Quaternion
+	NewRotation math32.Quaternion
+}
+
+// ZoomEvent describes a change of the distance from the camera to its target
+// and/or of the camera orthographic size.
+type ZoomEvent struct {
+	OldDistance float32
+	NewDistance float32
+	OldSize     float32
+	NewSize     float32
+}
+
+// SpeedEvent describes a change of the movement speed of a control.
+type SpeedEvent struct {
+	OldSpeed float32
+	NewSpeed float32
+}
+
+// cameraState is a snapshot of the camera state used to detect and report changes.
+type cameraState struct {
+	position math32.Vector3
+	target   math32.Vector3
+	rotation math32.Quaternion
+	distance float32
+	size     float32
+}
+
+// capture saves the state of the specified camera and target.
+func (s *cameraState) capture(cam *Camera, target *math32.Vector3) {
+
+	s.position = cam.Position()
+	s.target = *target
+	s.rotation = cam.Quaternion()
+	s.distance = s.position.DistanceTo(target)
+	s.size = cam.Size()
+}
+
+// changeNotifier dispatches camera control events for the changes between the state
+// captured when the outermost begin is called and the state when the matching end is called,
+// so nested operations report a single set of events.
+type changeNotifier struct {
+	depth  int         // Nesting depth of begin/end calls
+	prev   cameraState // State captured by the outermost begin
+	move   MoveEvent   // Reused data of OnCameraMove events
+	rotate RotateEvent // Reused data of OnCameraRotate events
+	zoom   ZoomEvent   // Reused data of OnZoomChange events
+}
+
+// begin starts a change, capturing the state if it is the outermost one.
+func (cn *changeNotifier) begin(cam *Camera, target *math32.Vector3) {
+
+	if cn.depth == 0 {
+		cn.prev.capture(cam, target)
+	}
+	cn.depth++
+}
+
+// end finishes a change and, if it is the outermost one, dispatches
+// the events for what changed using the specified dispatcher.
+func (cn *changeNotifier) end(d core.IDispatcher, cam *Camera, target *math32.Vector3) {
+
+	cn.depth--
+	if cn.depth > 0 {
+		return
+	}
+	var cur cameraState
+	cur.capture(cam, target)
+	old := &cn.prev
+	if !old.position.Equals(&cur.position) || !old.target.Equals(&cur.target) {
+		cn.move = MoveEvent{old.position, cur.position, old.target, cur.target}
+		d.Dispatch(OnCameraMove, &cn.move)
+	}
+	if !old.rotation.Equals(&cur.rotation) {
+		cn.rotate = RotateEvent{old.rotation, cur.rotation}
+		d.Dispatch(OnCameraRotate, &cn.rotate)
+	}
+	if old.distance != cur.distance || old.size != cur.size {
+		cn.zoom = ZoomEvent{old.distance, cur.distance, old.size, cur.size}
+		d.Dispatch(OnZoomChange, &cn.zoom)
+	}
+}
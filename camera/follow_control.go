@@ -0,0 +1,209 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// FollowControl is the standard third person camera control, which keeps the camera
+// at an offset from a target node, such as a character or a vehicle, and looks at it.
+// The offset and the look point are in the target space, rotated by the heading of the
+// target, so the camera stays behind the target as it turns. Position lag and rotational
+// damping smooth the camera motion, and look-ahead moves the look point in the direction
+// the target is moving. It satisfies the IControl interface; the control does not use
+// user input, so it is updated whether it is active or not.
+// The camera is expected to be in world space, such as a child of the scene root.
+type FollowControl struct {
+	core.Dispatcher                // Embedded event dispatcher
+	cam             *Camera        // Controlled camera
+	target          core.INode     // Followed node
+	active          bool           // Whether the control is active
+	Offset          math32.Vector3 // Camera position relative to the target in the target space (default is 0, 2, -5)
+	LookOffset      math32.Vector3 // Point looked at relative to the target in the target space (default is 0, 1, 0)
+	LookAhead       float32        // Seconds of target velocity added to the look point (default is 0.25)
+	PositionLag     float32        // Smoothing time constant of the camera position in seconds (default is 0.2, 0 is rigid)
+	RotationDamping float32        // Smoothing time constant of the heading and orientation in seconds (default is 0.3, 0 is rigid)
+	YawOnly         bool           // Whether only the heading of the target around Up rotates the offset (default is true)
+	Up              math32.Vector3 // Up direction of the camera and the heading (default is the engine up direction)
+
+	started  bool              // Whether the smoothed state was initialized
+	heading  math32.Quaternion // Smoothed rotation of the target
+	pos      math32.Vector3    // Smoothed camera position
+	rot      math32.Quaternion // Smoothed camera orientation
+	look     math32.Vector3    // Current look point
+	lastPos  math32.Vector3    // Target position in the last update
+	velocity math32.Vector3    // Smoothed target velocity
+	notify   changeNotifier    // Dispatches camera control events
+}
+
+// NewFollowControl creates and returns a pointer to a new follow control
+// for the specified camera and target, which may be nil.
+func NewFollowControl(cam *Camera, target core.INode) *FollowControl {
+
+	fc := new(FollowControl)
+	fc.Dispatcher.Initialize()
+	fc.cam = cam
+	fc.target = target
+	fc.active = true
+	fc.Offset.Set(0, 2, -5)
+	fc.LookOffset.Set(0, 1, 0)
+	fc.LookAhead = 0.25
+	fc.PositionLag = 0.2
+	fc.RotationDamping = 0.3
+	fc.YawOnly = true
+	fc.Up = core.EngineCoords().UpVector()
+	fc.heading.SetIdentity()
+	fc.rot.SetIdentity()
+	return fc
+}
+
+// SetTarget sets the followed node. The camera moves smoothly to the new target.
+func (fc *FollowControl) SetTarget(target core.INode) {
+
+	fc.target = target
+	if target != nil {
+		target.UpdateMatrixWorld()
+		target.GetNode().WorldPosition(&fc.lastPos)
+	}
+	fc.velocity.Zero()
+}
+
+// Target returns the followed node.
+func (fc *FollowControl) Target() core.INode {
+
+	return fc.target
+}
+
+// LookPoint returns the point the camera looks at, in world coordinates, from the last update.
+func (fc *FollowControl) LookPoint() math32.Vector3 {
+
+	return fc.look
+}
+
+// Reset makes the next update snap the camera to its desired pose instead of smoothing towards it,
+// for example after the target teleports.
+func (fc *FollowControl) Reset() {
+
+	fc.started = false
+}
+
+// Active returns whether the control is active.
+func (fc *FollowControl) Active() bool {
+
+	return fc.active
+}
+
+// SetActive sets whether the control is active. The control has no user input to
+// subscribe to, so it only records the state for the ControlManager.
+func (fc *FollowControl) SetActive(active bool) {
+
+	fc.active = active
+}
+
+// Dispose satisfies the IControl interface. The control holds no resources.
+func (fc *FollowControl) Dispose() {
+
+	fc.SetActive(false)
+}
+
+// Update moves the camera towards its pose behind the target for the specified
+// elapsed time in seconds. It should be called every frame.
+func (fc *FollowControl) Update(deltaTime float32) {
+
+	if fc.target == nil {
+		return
+	}
+	fc.notify.begin(fc.cam, &fc.look)
+	defer fc.notify.end(fc, fc.cam, &fc.look)
+
+	tnode := fc.target.GetNode()
+	fc.target.UpdateMatrixWorld()
+	var tpos math32.Vector3
+	var trot math32.Quaternion
+	tnode.WorldPosition(&tpos)
+	tnode.WorldQuaternion(&trot)
+	if fc.YawOnly {
+		trot = fc.yaw(&trot)
+	}
+
+	if !fc.started {
+		fc.heading = trot
+		fc.velocity.Zero()
+	} else {
+		fc.heading.Slerp(&trot, smoothing(deltaTime, fc.RotationDamping))
+		if deltaTime > 0 {
+			// Velocity smoothed over the position lag so look-ahead does not jitter
+			var v math32.Vector3
+			v.SubVectors(&tpos, &fc.lastPos).MultiplyScalar(1 / deltaTime)
+			fc.velocity.Lerp(&v, smoothing(deltaTime, fc.PositionLag))
+		}
+	}
+	fc.lastPos = tpos
+
+	// Desired camera position and look point
+	desired := fc.Offset
+	desired.ApplyQuaternion(&fc.heading).Add(&tpos)
+	fc.look = fc.LookOffset
+	fc.look.ApplyQuaternion(&fc.heading).Add(&tpos)
+	ahead := fc.velocity
+	fc.look.Add(ahead.MultiplyScalar(fc.LookAhead))
+
+	if !fc.started {
+		fc.pos = desired
+	} else {
+		fc.pos.Lerp(&desired, smoothing(deltaTime, fc.PositionLag))
+	}
+	var m math32.Matrix4
+	var rot math32.Quaternion
+	m.LookAt(&fc.pos, &fc.look, &fc.Up)
+	rot.SetFromRotationMatrix(&m)
+	if !fc.started {
+		fc.rot = rot
+		fc.started = true
+	} else {
+		fc.rot.Slerp(&rot, smoothing(deltaTime, fc.RotationDamping))
+	}
+	fc.cam.SetPositionVec(&fc.pos)
+	fc.cam.SetQuaternionQuat(&fc.rot)
+}
+
+// yaw returns the rotation around Up with the heading of the specified rotation,
+// measured from the Z axis projected onto the plane perpendicular to Up.
+func (fc *FollowControl) yaw(rot *math32.Quaternion) math32.Quaternion {
+
+	up := fc.Up
+	up.Normalize()
+	forward := math32.Vector3{Z: 1}
+	forward.ApplyQuaternion(rot)
+	ref := math32.Vector3{Z: 1}
+	// Removes the components along Up, keeping the last heading when looking straight up or down
+	along := up
+	forward.Sub(along.MultiplyScalar(forward.Dot(&up)))
+	along = up
+	ref.Sub(along.MultiplyScalar(ref.Dot(&up)))
+	if forward.LengthSq() < 1e-8 {
+		return fc.heading
+	}
+	if ref.LengthSq() < 1e-8 {
+		ref.Set(1, 0, 0)
+	}
+	var cross math32.Vector3
+	cross.CrossVectors(&ref, &forward)
+	var q math32.Quaternion
+	q.SetFromAxisAngle(&up, math32.Atan2(cross.Dot(&up), ref.Dot(&forward)))
+	return q
+}
+
+// smoothing returns the interpolation factor of an exponential smoothing with the
+// specified time constant over the specified elapsed time. A zero time constant is rigid.
+func smoothing(deltaTime, timeConstant float32) float32 {
+
+	if timeConstant <= 0 {
+		return 1
+	}
+	return 1 - math32.Exp(-deltaTime/timeConstant)
+}
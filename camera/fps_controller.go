@@ -0,0 +1,1018 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// Key actions of the FPSController.
+const (
+	FPSKeyForward = "forward"
+	FPSKeyBack    = "back"
+	FPSKeyLeft    = "left"
+	FPSKeyRight   = "right"
+	FPSKeyJump    = "jump"
+	FPSKeyRun     = "run"
+)
+
+// Gamepad axis actions of the FPSController.
+const (
+	FPSAxisMoveX = "moveX" // Strafes right for positive values
+	FPSAxisMoveY = "moveY" // Walks back for positive values
+	FPSAxisLookX = "lookX" // Turns right for positive values
+	FPSAxisLookY = "lookY" // Looks down for positive values
+)
+
+// CapsuleCollider tests a capsule, the segment from a to b swept by a sphere of the specified
+// radius, against the world. If the capsule penetrates an obstacle it sets push to the smallest
+// translation which separates the capsule from the deepest obstacle and returns true.
+type CapsuleCollider func(a, b *math32.Vector3, radius float32, push *math32.Vector3) bool
+
+// HeightCollider returns a CapsuleCollider for the ground described by the specified function,
+// such as a terrain height lookup, which returns the height of the ground at the
+// specified horizontal position. The ground is expected to be a height field along the Y axis;
+// the capsule is pushed straight up, so all slopes are walkable.
+func HeightCollider(heightAt func(x, z float32) float32) CapsuleCollider {
+
+	return func(a, b *math32.Vector3, radius float32, push *math32.Vector3) bool {
+		low := a
+		if b.Y < a.Y {
+			low = b
+		}
+		depth := heightAt(low.X, low.Z) + radius - low.Y
+		if depth <= 0 {
+			return false
+		}
+		push.Set(0, depth, 0)
+		return true
+	}
+}
+
+// FPSController is a first person camera control which walks the camera on the world like
+// a character, instead of flying it freely. The character is a vertical capsule with the
+// camera at its eye height. It is moved by the keys bound in Keys, looks around while the
+// LookButton is dragged, or whenever the cursor moves if CaptureLook is set, and is pulled
+// by gravity, jumps, climbs steps up to StepHeight and walks slopes up to MaxSlope.
+// Collisions are resolved with the Collide function, such as the CollideCapsule method of the
+// experimental physics simulation or a HeightCollider following a terrain. It satisfies the
+// IControl interface; an inactive controller ignores user input but keeps falling and
+// colliding when updated.
+// The camera is expected to be in world space, such as a child of the scene root.
+type FPSController struct {
+	core.Dispatcher                    // Embedded event dispatcher
+	cam             *Camera            // Controlled camera
+	active          bool               // Whether the control is subscribed to user input
+	Up              math32.Vector3     // Up direction of the character (default is the engine up direction)
+	Height          float32            // Height of the capsule (default is 1.8)
+	Radius          float32            // Radius of the capsule (default is 0.3)
+	EyeHeight       float32            // Height of the camera above the feet (default is 1.6)
+	StepHeight      float32            // Maximum height of the steps climbed while walking (default is 0.35)
+	MaxSlope        float32            // Maximum walkable slope in radians (default is the equivalent of 45 degrees)
+	WalkSpeed       float32            // Walking speed in units per second (default is 4)
+	RunFactor       float32            // Speed factor while the run key is pressed (default is 2)
+	JumpSpeed       float32            // Initial upward speed of jumps in units per second (default is 5)
+	Gravity         float32            // Gravity acceleration in units per second squared (default is 9.8)
+	AirControl      float32            // Fraction of the walking acceleration available in the air (default is 0.3)
+	Acceleration    float32            // Rate per second the velocity approaches the walking velocity (default is 12)
+	Deceleration    float32            // Rate per second the velocity decreases when no movement input is given, lower to glide to a stop (default is 12)
+	LookSpeed       float32            // Look rotation in radians per pixel of cursor motion (default is 0.003)
+	LookSpeedY      float32            // Vertical look rotation in radians per pixel of cursor motion, if different from LookSpeed (default is 0, same)
+	InvertPitch     bool               // Whether moving the cursor or look stick up looks down (default is false)
+	MaxPitch        float32            // Maximum pitch up and down in radians (default is the equivalent of 89 degrees)
+	LookSpring      float32            // Rate per second the pitch returns to the horizon while walking forward or back without dragging the look button (default is 0, disabled)
+	LookSmoothing   float32            // Time in seconds the view takes to follow about 63% of the cursor motion (default is 0, unsmoothed)
+	LockBlend       float32            // Time in seconds the view takes to turn about 63% of the way to a locked target (default is 0.2)
+	LookButton      window.MouseButton // Mouse button dragged to look around (default is the left button)
+	CaptureLook     bool               // Whether every cursor motion looks around, for disabled cursor modes (default is false)
+	CaptureCursor   bool               // Whether pressing the LookButton disables the cursor to look around with every motion until Escape is pressed (default is false)
+	ScrollFactor    float32            // Factor WalkSpeed is multiplied by for each scroll step while looking around (default is 0, disabled)
+	BobAmplitude    float32            // Height of the head bob while walking on the ground (default is 0, disabled)
+	BobStride       float32            // Distance walked during each head bob cycle (default is 1.5)
+
+	// Keys maps action names (FPSKey* constants) to key bindings.
+	// Modifiers are ignored, so the keys can be held in any combination.
+	Keys map[string]KeyBinding
+
+	// SpeedModifiers maps modifier keys to the factors applied to the walking speed while they
+	// are held, in addition to the run key. Factors of combined modifiers apply when all of
+	// them are held. The default slows down to half speed while control is held.
+	SpeedModifiers map[window.ModifierKey]float32
+
+	// GamepadAxes maps axis actions (FPSAxis* constants) and GamepadButtons maps key actions
+	// (FPSKey* constants) to the axes and buttons of the connected gamepads, which are
+	// polled on each update while the control is active.
+	GamepadAxes      map[string]GamepadAxis
+	GamepadButtons   map[string]int
+	GamepadLookSpeed float32 // Look rotation in radians per second with a look axis at its end (default is 3)
+
+	// Collide resolves the collisions of the character capsule. If nil, the ground is
+	// the plane through the origin perpendicular to Up.
+	Collide CapsuleCollider
+
+	feet     math32.Vector3  // Position of the bottom of the capsule
+	velocity math32.Vector3  // Velocity of the character
+	yaw      float32         // Rotation around Up in radians
+	pitch    float32         // Rotation above the horizon in radians
+	aimYaw   float32         // Yaw the smoothed view approaches
+	aimPitch float32         // Pitch the smoothed view approaches
+	grounded bool            // Whether the character stands on walkable ground
+	jump     bool            // Whether a jump was requested
+	pressed  map[string]bool // Pressed key actions
+	modKeys  uint8           // Held modifier keys, one bit for each of fpsModifierKeys
+	looking  bool            // Whether the look button is pressed
+	cursor   math32.Vector2  // Last cursor position
+	cursorOK bool            // Whether the last cursor position is known
+	look     math32.Vector3  // Current look point
+	bobPhase float32         // Phase of the head bob in radians
+	bobBlend float32         // Fraction of the head bob amplitude, to start and stop it smoothly
+	padJump  bool            // Whether the gamepad jump button was pressed at the last update
+	record   *FPSRecording   // Recording in progress or nil
+	replay   *FPSRecording   // Recording being replayed or nil
+	replayAt int             // Index of the next replayed input
+	lockNode core.INode      // Node the view is locked on or nil
+	captured bool            // Whether the control disabled the cursor
+	notify   changeNotifier  // Dispatches camera control events
+	speedEv  SpeedEvent      // Reused data of OnSpeedChange events
+}
+
+// Modifier keys tracked for the speed modifiers of the FPSController
+var fpsModifierKeys = []struct {
+	key window.Key
+	mod window.ModifierKey
+}{
+	{window.KeyLeftShift, window.ModShift},
+	{window.KeyRightShift, window.ModShift},
+	{window.KeyLeftControl, window.ModControl},
+	{window.KeyRightControl, window.ModControl},
+	{window.KeyLeftAlt, window.ModAlt},
+	{window.KeyRightAlt, window.ModAlt},
+	{window.KeyLeftSuper, window.ModSuper},
+	{window.KeyRightSuper, window.ModSuper},
+}
+
+// Parameters of the collision resolution of the FPSController.
+const (
+	fpsMaxIterations   = 4    // Maximum number of collision resolutions of each move
+	fpsSweepIterations = 10   // Number of bisections of the sweeps which find the first contact
+	fpsSkin            = 0.01 // Distance the character is pushed into the obstacles it lands on
+)
+
+// NewFPSController creates and returns a pointer to a new first person controller for the
+// specified camera, with the character standing below the current camera position and
+// looking in the current camera direction.
+func NewFPSController(cam *Camera) *FPSController {
+
+	fc := new(FPSController)
+	fc.Dispatcher.Initialize()
+	fc.cam = cam
+	fc.Up = core.EngineCoords().UpVector()
+	fc.Height = 1.8
+	fc.Radius = 0.3
+	fc.EyeHeight = 1.6
+	fc.StepHeight = 0.35
+	fc.MaxSlope = 45 * math32.Pi / 180
+	fc.WalkSpeed = 4
+	fc.RunFactor = 2
+	fc.JumpSpeed = 5
+	fc.Gravity = 9.8
+	fc.AirControl = 0.3
+	fc.Acceleration = 12
+	fc.Deceleration = 12
+	fc.LookSpeed = 0.003
+	fc.MaxPitch = 89 * math32.Pi / 180
+	fc.LookButton = window.MouseButtonLeft
+	fc.BobStride = 1.5
+	fc.LockBlend = 0.2
+	fc.Keys = DefaultFPSKeys()
+	fc.GamepadAxes = DefaultFPSGamepadAxes()
+	fc.GamepadButtons = map[string]int{FPSKeyJump: GamepadButtonA}
+	fc.GamepadLookSpeed = 3
+	fc.SpeedModifiers = map[window.ModifierKey]float32{window.ModControl: 0.5}
+	fc.pressed = make(map[string]bool)
+
+	// Starts from the current camera pose
+	cam.UpdateMatrixWorld()
+	var eye, dir math32.Vector3
+	cam.WorldPosition(&eye)
+	cam.WorldDirection(&dir)
+	fc.feet = eye
+	fc.feet.Sub(fc.Up.Clone().MultiplyScalar(fc.EyeHeight))
+	fc.SetDirection(&dir)
+
+	window.Get().SubscribeID(window.OnCursor, fc, fc.onCursor)
+	fc.SetActive(true)
+	return fc
+}
+
+// DefaultFPSKeys returns the default key bindings of the FPSController:
+// the WASD keys at their physical positions, space to jump and left shift to run.
+func DefaultFPSKeys() map[string]KeyBinding {
+
+	return map[string]KeyBinding{
+		FPSKeyForward: ScancodeBinding(window.KeyW, 0),
+		FPSKeyBack:    ScancodeBinding(window.KeyS, 0),
+		FPSKeyLeft:    ScancodeBinding(window.KeyA, 0),
+		FPSKeyRight:   ScancodeBinding(window.KeyD, 0),
+		FPSKeyJump:    {Key: window.KeySpace},
+		FPSKeyRun:     {Key: window.KeyLeftShift},
+	}
+}
+
+// DefaultFPSGamepadAxes returns the default gamepad axis bindings of the FPSController:
+// the left stick walks and the right stick looks around.
+func DefaultFPSGamepadAxes() map[string]GamepadAxis {
+
+	return map[string]GamepadAxis{
+		FPSAxisMoveX: {Index: GamepadLeftX, Deadzone: 0.15},
+		FPSAxisMoveY: {Index: GamepadLeftY, Deadzone: 0.15},
+		FPSAxisLookX: {Index: GamepadRightX, Deadzone: 0.15, Curve: 2},
+		FPSAxisLookY: {Index: GamepadRightY, Deadzone: 0.15, Curve: 2},
+	}
+}
+
+// Dispose unsubscribes from all events.
+func (fc *FPSController) Dispose() {
+
+	fc.SetActive(false)
+	window.Get().UnsubscribeID(window.OnCursor, fc)
+}
+
+// Active returns whether the control is receiving user input.
+func (fc *FPSController) Active() bool {
+
+	return fc.active
+}
+
+// SetActive sets whether the control receives user input.
+// Deactivating the control releases the pressed keys.
+func (fc *FPSController) SetActive(active bool) {
+
+	if active == fc.active {
+		return
+	}
+	fc.active = active
+	if active {
+		gui.Manager().SubscribeID(window.OnMouseUp, fc, fc.onMouse)
+		gui.Manager().SubscribeID(window.OnMouseDown, fc, fc.onMouse)
+		gui.Manager().SubscribeID(window.OnKeyDown, fc, fc.onKey)
+		gui.Manager().SubscribeID(window.OnKeyUp, fc, fc.onKey)
+		gui.Manager().SubscribeID(window.OnScroll, fc, fc.onScroll)
+		return
+	}
+	gui.Manager().UnsubscribeID(window.OnMouseUp, fc)
+	gui.Manager().UnsubscribeID(window.OnMouseDown, fc)
+	gui.Manager().UnsubscribeID(window.OnKeyDown, fc)
+	gui.Manager().UnsubscribeID(window.OnKeyUp, fc)
+	gui.Manager().UnsubscribeID(window.OnScroll, fc)
+	fc.pressed = make(map[string]bool)
+	fc.modKeys = 0
+	fc.SetCursorCaptured(false)
+	fc.looking = false
+	fc.jump = false
+	fc.padJump = false
+}
+
+// Position returns the position of the feet of the character.
+func (fc *FPSController) Position() math32.Vector3 {
+
+	return fc.feet
+}
+
+// SetPosition teleports the character so its feet are at the specified position.
+func (fc *FPSController) SetPosition(pos *math32.Vector3) {
+
+	fc.feet = *pos
+	fc.grounded = false
+	fc.apply()
+}
+
+// Velocity returns the current velocity of the character.
+func (fc *FPSController) Velocity() math32.Vector3 {
+
+	return fc.velocity
+}
+
+// SetVelocity sets the velocity of the character, such as to push it or launch it.
+func (fc *FPSController) SetVelocity(vel *math32.Vector3) {
+
+	fc.velocity = *vel
+}
+
+// SetWalkSpeed sets the walking speed in units per second and dispatches OnSpeedChange if it changed.
+func (fc *FPSController) SetWalkSpeed(speed float32) {
+
+	if speed == fc.WalkSpeed {
+		return
+	}
+	fc.speedEv = SpeedEvent{fc.WalkSpeed, speed}
+	fc.WalkSpeed = speed
+	fc.Dispatch(OnSpeedChange, &fc.speedEv)
+}
+
+// Grounded returns whether the character stands on walkable ground.
+func (fc *FPSController) Grounded() bool {
+
+	return fc.grounded
+}
+
+// Look returns the yaw around Up and the pitch above the horizon of the view in radians.
+// A zero yaw looks in the direction of the engine forward vector rotated into the Up frame.
+// The view is stored as these two angles, with the pitch limited by MaxPitch and no roll,
+// so it has no gimbal lock and does not drift.
+func (fc *FPSController) Look() (yaw, pitch float32) {
+
+	return fc.yaw, fc.pitch
+}
+
+// SetLook sets the yaw around Up and the pitch above the horizon of the view in radians.
+func (fc *FPSController) SetLook(yaw, pitch float32) {
+
+	fc.yaw = yaw
+	fc.pitch = math32.Clamp(pitch, -fc.MaxPitch, fc.MaxPitch)
+	fc.aimYaw = fc.yaw
+	fc.aimPitch = fc.pitch
+	fc.apply()
+}
+
+// Orientation returns the orientation of the camera as a quaternion, derived from the
+// yaw and pitch of the view.
+func (fc *FPSController) Orientation() math32.Quaternion {
+
+	return fc.ControlPose().Rotation
+}
+
+// SetOrientation sets the view to look in the direction of the camera with the specified
+// orientation. The view never rolls, so any roll of the orientation is dropped.
+func (fc *FPSController) SetOrientation(q *math32.Quaternion) {
+
+	dir := fc.cam.Direction()
+	dir.ApplyQuaternion(q)
+	fc.SetDirection(&dir)
+}
+
+// SetDirection sets the view to look in the specified direction.
+func (fc *FPSController) SetDirection(dir *math32.Vector3) {
+
+	d := fc.toFrame(dir)
+	if d.LengthSq() == 0 {
+		return
+	}
+	d.Normalize()
+	fc.SetLook(math32.Atan2(-d.X, -d.Z), math32.Asin(math32.Clamp(d.Y, -1, 1)))
+}
+
+// LockTarget locks the view on the specified node, which it turns to smoothly and then tracks
+// while the character moves, so strafing circles around it. Looking around is disabled while locked.
+func (fc *FPSController) LockTarget(node core.INode) {
+
+	fc.lockNode = node
+}
+
+// Unlock releases the view from its locked target, leaving it where it was looking.
+func (fc *FPSController) Unlock() {
+
+	fc.lockNode = nil
+	fc.aimYaw, fc.aimPitch = fc.yaw, fc.pitch
+}
+
+// LockedTarget returns the node the view is locked on, or nil.
+func (fc *FPSController) LockedTarget() core.INode {
+
+	return fc.lockNode
+}
+
+// Jump makes the character jump on the next update if it stands on the ground.
+func (fc *FPSController) Jump() {
+
+	fc.jump = true
+}
+
+// Update updates the character and the camera by the specified time step in seconds.
+func (fc *FPSController) Update(deltaTime float32) {
+
+	if deltaTime <= 0 {
+		return
+	}
+	deltaTime = math32.Min(deltaTime, 0.1)
+	up := fc.Up
+	up.Normalize()
+
+	// Takes the input from the user or from the replayed recording
+	var wish math32.Vector3
+	if in := fc.nextReplay(); in != nil {
+		deltaTime = in.DeltaTime
+		fc.yaw, fc.pitch = in.Yaw, in.Pitch
+		fc.aimYaw, fc.aimPitch = in.Yaw, in.Pitch
+		wish = in.Wish
+		fc.jump = in.Jump
+	} else {
+		wish = fc.input(deltaTime)
+	}
+	if fc.record != nil {
+		fc.record.Inputs = append(fc.record.Inputs, FPSInput{deltaTime, fc.yaw, fc.pitch, wish, fc.jump})
+	}
+
+	// Approaches the walking velocity, with less control in the air
+	vertical := fc.velocity.Dot(&up)
+	horizontal := fc.velocity
+	horizontal.Sub(up.Clone().MultiplyScalar(vertical))
+	accel := fc.Acceleration
+	if wish.LengthSq() == 0 {
+		accel = fc.Deceleration
+	}
+	if !fc.grounded {
+		accel *= fc.AirControl
+	}
+	k := math32.Min(1, accel*deltaTime)
+	horizontal.Lerp(&wish, k)
+
+	// Jumps and falls
+	if fc.jump && fc.grounded {
+		vertical = fc.JumpSpeed
+		fc.grounded = false
+	}
+	fc.jump = false
+	vertical -= fc.Gravity * deltaTime
+	fc.velocity = horizontal
+	fc.velocity.Add(up.Clone().MultiplyScalar(vertical))
+
+	// Moves horizontally, climbing steps, then vertically
+	wasGrounded := fc.grounded
+	fc.grounded = false
+	var move math32.Vector3
+	move.Copy(&horizontal).MultiplyScalar(deltaTime)
+	if move.LengthSq() > 0 {
+		fc.walk(&move, wasGrounded)
+	}
+	move.Copy(&up).MultiplyScalar(vertical * deltaTime)
+	fc.moveAndSlide(&move)
+
+	// Keeps walking down slopes and steps instead of flying off them
+	if wasGrounded && !fc.grounded && vertical <= 0 && fc.StepHeight > 0 {
+		saved := fc.feet
+		savedVel := fc.velocity
+		if !fc.land(fc.StepHeight) {
+			fc.feet = saved
+			fc.velocity = savedVel
+		}
+	}
+	if fc.grounded && fc.velocity.Dot(&up) < 0 {
+		fc.velocity.Sub(up.MultiplyScalar(fc.velocity.Dot(&up)))
+	}
+	fc.updateBob(horizontal.Length(), deltaTime)
+	fc.apply()
+}
+
+// updateBob advances the head bob by the specified horizontal speed and time step.
+func (fc *FPSController) updateBob(speed, deltaTime float32) {
+
+	if fc.BobAmplitude <= 0 || fc.BobStride <= 0 {
+		fc.bobBlend = 0
+		return
+	}
+	target := float32(0)
+	if fc.grounded && speed > 0.1 {
+		target = 1
+		fc.bobPhase = math32.Mod(fc.bobPhase+2*math32.Pi*speed*deltaTime/fc.BobStride, 2*math32.Pi)
+	}
+	fc.bobBlend += (target - fc.bobBlend) * math32.Min(1, 8*deltaTime)
+}
+
+// speedModifier returns the product of the SpeedModifiers factors of the held modifier keys.
+func (fc *FPSController) speedModifier() float32 {
+
+	var held window.ModifierKey
+	for i, mk := range fpsModifierKeys {
+		if fc.modKeys&(1<<uint(i)) != 0 {
+			held |= mk.mod
+		}
+	}
+	factor := float32(1)
+	for mods, f := range fc.SpeedModifiers {
+		if mods != 0 && held&mods == mods {
+			factor *= f
+		}
+	}
+	return factor
+}
+
+// gamepadAxis returns the value of the gamepad axis bound to the specified action, or 0 if none.
+func (fc *FPSController) gamepadAxis(joysticks []*window.Joystick, action string) float32 {
+
+	ga, ok := fc.GamepadAxes[action]
+	if !ok {
+		return 0
+	}
+	return ga.Value(joysticks)
+}
+
+// gamepadButton returns whether the gamepad button bound to the specified action is pressed.
+func (fc *FPSController) gamepadButton(joysticks []*window.Joystick, action string) bool {
+
+	index, ok := fc.GamepadButtons[action]
+	return ok && gamepadButton(joysticks, index)
+}
+
+// input applies the look input of the user and returns the walking velocity
+// it requests, for the specified time step in seconds.
+func (fc *FPSController) input(deltaTime float32) math32.Vector3 {
+
+	// Smoothed view follows the cursor motion
+	if fc.yaw != fc.aimYaw || fc.pitch != fc.aimPitch {
+		k := float32(1)
+		if fc.LookSmoothing > 0 {
+			k = 1 - math32.Exp(-deltaTime/fc.LookSmoothing)
+		}
+		fc.yaw += (fc.aimYaw - fc.yaw) * k
+		fc.pitch += (fc.aimPitch - fc.pitch) * k
+	}
+
+	// Turns by the gamepad look axes
+	var joysticks []*window.Joystick
+	if fc.active && (len(fc.GamepadAxes) > 0 || len(fc.GamepadButtons) > 0) {
+		joysticks = window.Joysticks()
+	}
+	if len(joysticks) > 0 {
+		turn := fc.GamepadLookSpeed * deltaTime
+		fc.aimYaw -= fc.gamepadAxis(joysticks, FPSAxisLookX) * turn
+		fc.aimPitch -= fc.pitchSign() * fc.gamepadAxis(joysticks, FPSAxisLookY) * turn
+		fc.aimPitch = math32.Clamp(fc.aimPitch, -fc.MaxPitch, fc.MaxPitch)
+		if fc.LookSmoothing <= 0 {
+			fc.yaw, fc.pitch = fc.aimYaw, fc.aimPitch
+		}
+		jump := fc.gamepadButton(joysticks, FPSKeyJump)
+		if jump && !fc.padJump {
+			fc.jump = true
+		}
+		fc.padJump = jump
+	}
+
+	if fc.lockNode != nil {
+		fc.trackTarget(deltaTime)
+	}
+
+	// Computes the walking velocity on the horizontal plane
+	var wish math32.Vector3
+	fwd, right := fc.walkAxes()
+	if fc.pressed[FPSKeyForward] {
+		wish.Add(&fwd)
+	}
+	if fc.pressed[FPSKeyBack] {
+		wish.Sub(&fwd)
+	}
+	if fc.pressed[FPSKeyRight] {
+		wish.Add(&right)
+	}
+	if fc.pressed[FPSKeyLeft] {
+		wish.Sub(&right)
+	}
+	if wish.LengthSq() > 0 {
+		wish.Normalize()
+	}
+	walking := fc.pressed[FPSKeyForward] != fc.pressed[FPSKeyBack]
+	if len(joysticks) > 0 {
+		// Walks at the speed given by the deflection of the stick
+		moveY := fc.gamepadAxis(joysticks, FPSAxisMoveY)
+		walking = walking || moveY != 0
+		fwd.MultiplyScalar(moveY)
+		right.MultiplyScalar(fc.gamepadAxis(joysticks, FPSAxisMoveX))
+		wish.Sub(&fwd).Add(&right)
+		if l := wish.Length(); l > 1 {
+			wish.MultiplyScalar(1 / l)
+		}
+	}
+	if wish.LengthSq() > 0 {
+		speed := fc.WalkSpeed
+		if fc.pressed[FPSKeyRun] || fc.gamepadButton(joysticks, FPSKeyRun) {
+			speed *= fc.RunFactor
+		}
+		speed *= fc.speedModifier()
+		wish.MultiplyScalar(speed)
+	}
+
+	// Springs the view back to the horizon while walking forward or back
+	if fc.LookSpring > 0 && walking && !fc.looking && fc.lockNode == nil {
+		k := math32.Exp(-fc.LookSpring * deltaTime)
+		fc.pitch *= k
+		fc.aimPitch *= k
+	}
+	return wish
+}
+
+// trackTarget turns the view towards the locked target during the specified time step.
+func (fc *FPSController) trackTarget(deltaTime float32) {
+
+	var target math32.Vector3
+	fc.lockNode.GetNode().WorldPosition(&target)
+	eye, _ := fc.view()
+	dir := fc.toFrame(target.Sub(&eye))
+	if dir.LengthSq() == 0 {
+		return
+	}
+	dir.Normalize()
+	yaw := math32.Atan2(-dir.X, -dir.Z)
+	pitch := math32.Clamp(math32.Asin(math32.Clamp(dir.Y, -1, 1)), -fc.MaxPitch, fc.MaxPitch)
+	k := float32(1)
+	if fc.LockBlend > 0 {
+		k = 1 - math32.Exp(-deltaTime/fc.LockBlend)
+	}
+	// Turns the shortest way around
+	dyaw := yaw - fc.yaw
+	dyaw = math32.Atan2(math32.Sin(dyaw), math32.Cos(dyaw))
+	fc.yaw += dyaw * k
+	fc.pitch += (pitch - fc.pitch) * k
+	fc.aimYaw, fc.aimPitch = fc.yaw, fc.pitch
+}
+
+// walk moves the character by the specified horizontal displacement, stepping up
+// obstacles lower than StepHeight if it is on the ground.
+func (fc *FPSController) walk(move *math32.Vector3, grounded bool) {
+
+	start := fc.feet
+	startVel := fc.velocity
+	fc.moveAndSlide(move)
+	if !grounded || fc.StepHeight <= 0 {
+		return
+	}
+	var moved math32.Vector3
+	moved.SubVectors(&fc.feet, &start)
+	if moved.DistanceToSquared(move) < 1e-8 {
+		return
+	}
+
+	// Blocked: tries again raised by the step height, then lowers the character back
+	plain := fc.feet
+	plainVel := fc.velocity
+	plainGrounded := fc.grounded
+	fc.feet = start
+	fc.velocity = startVel
+	up := fc.Up
+	up.Normalize()
+	var step math32.Vector3
+	step.Copy(&up).MultiplyScalar(fc.StepHeight)
+	fc.moveAndSlide(&step)
+	raised := fc.feet.Clone().Sub(&start).Dot(&up)
+	fc.moveAndSlide(move)
+	fc.grounded = false
+	fc.land(raised + fpsSkin)
+	var stepped math32.Vector3
+	stepped.SubVectors(&fc.feet, &start)
+	stepped.Sub(up.Clone().MultiplyScalar(stepped.Dot(&up)))
+	moved.Sub(up.Clone().MultiplyScalar(moved.Dot(&up)))
+	if fc.grounded && stepped.LengthSq() > moved.LengthSq() {
+		return
+	}
+	fc.feet = plain
+	fc.velocity = plainVel
+	fc.grounded = plainGrounded
+}
+
+// land lowers the character up to the specified distance until it touches an obstacle,
+// then settles it on the obstacle. It returns whether the character is on walkable ground.
+func (fc *FPSController) land(distance float32) bool {
+
+	up := fc.Up
+	up.Normalize()
+	var move math32.Vector3
+	move.Copy(&up).MultiplyScalar(-distance)
+	if !fc.sweep(&move) {
+		return false
+	}
+	move.Copy(&up).MultiplyScalar(-fpsSkin)
+	fc.feet.Add(&move)
+	fc.resolve()
+	return fc.grounded
+}
+
+// sweep moves the character by the largest fraction of the specified displacement
+// which does not penetrate any obstacle, and returns whether an obstacle was hit.
+func (fc *FPSController) sweep(move *math32.Vector3) bool {
+
+	var pos math32.Vector3
+	pos.AddVectors(&fc.feet, move)
+	if !fc.penetrates(&pos) {
+		fc.feet = pos
+		return false
+	}
+	lo, hi := float32(0), float32(1)
+	for i := 0; i < fpsSweepIterations; i++ {
+		mid := (lo + hi) / 2
+		pos.Copy(move).MultiplyScalar(mid).Add(&fc.feet)
+		if fc.penetrates(&pos) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	pos.Copy(move).MultiplyScalar(lo)
+	fc.feet.Add(&pos)
+	return true
+}
+
+// penetrates returns whether the character with the feet at the specified position penetrates an obstacle.
+func (fc *FPSController) penetrates(feet *math32.Vector3) bool {
+
+	var a, b, push math32.Vector3
+	fc.capsule(feet, &a, &b)
+	return fc.collide(&a, &b, fc.Radius, &push)
+}
+
+// capsule sets a and b to the ends of the segment of the capsule of the character
+// with the feet at the specified position.
+func (fc *FPSController) capsule(feet, a, b *math32.Vector3) {
+
+	up := fc.Up
+	up.Normalize()
+	a.Copy(&up).MultiplyScalar(fc.Radius).Add(feet)
+	b.Copy(&up).MultiplyScalar(math32.Max(fc.Radius, fc.Height-fc.Radius)).Add(feet)
+}
+
+// moveAndSlide moves the character by the specified displacement in steps no longer than its
+// radius, resolving the collisions after each of them. Collisions remove the velocity into
+// the obstacles and walkable ground sets the grounded state.
+func (fc *FPSController) moveAndSlide(move *math32.Vector3) {
+
+	length := move.Length()
+	if length == 0 {
+		return
+	}
+	steps := 1
+	if fc.Radius > 0 {
+		steps = int(math32.Ceil(length / fc.Radius))
+	}
+	var delta math32.Vector3
+	delta.Copy(move).MultiplyScalar(1 / float32(steps))
+	for i := 0; i < steps; i++ {
+		fc.feet.Add(&delta)
+		fc.resolve()
+	}
+}
+
+// resolve pushes the character out of the obstacles it penetrates.
+func (fc *FPSController) resolve() {
+
+	up := fc.Up
+	up.Normalize()
+	minGround := math32.Cos(fc.MaxSlope)
+	for i := 0; i < fpsMaxIterations; i++ {
+		var a, b, push math32.Vector3
+		fc.capsule(&fc.feet, &a, &b)
+		if !fc.collide(&a, &b, fc.Radius, &push) {
+			return
+		}
+		length := push.Length()
+		if length == 0 {
+			return
+		}
+		fc.feet.Add(&push)
+		n := push.MultiplyScalar(1 / length)
+		if n.Dot(&up) >= minGround {
+			fc.grounded = true
+		}
+		if vn := fc.velocity.Dot(n); vn < 0 {
+			fc.velocity.Sub(n.MultiplyScalar(vn))
+		}
+	}
+}
+
+// collide tests the capsule with the Collide function or against the default ground plane.
+func (fc *FPSController) collide(a, b *math32.Vector3, radius float32, push *math32.Vector3) bool {
+
+	if fc.Collide != nil {
+		return fc.Collide(a, b, radius, push)
+	}
+	up := fc.Up
+	up.Normalize()
+	depth := radius - math32.Min(a.Dot(&up), b.Dot(&up))
+	if depth <= 0 {
+		return false
+	}
+	push.Copy(&up).MultiplyScalar(depth)
+	return true
+}
+
+// walkAxes returns the horizontal forward and right directions of the view.
+func (fc *FPSController) walkAxes() (fwd, right math32.Vector3) {
+
+	fwd.Set(-math32.Sin(fc.yaw), 0, -math32.Cos(fc.yaw))
+	right.Set(math32.Cos(fc.yaw), 0, -math32.Sin(fc.yaw))
+	frame := fc.frame()
+	frame.Conjugate()
+	fwd.ApplyQuaternion(&frame)
+	right.ApplyQuaternion(&frame)
+	return fwd, right
+}
+
+// frame returns the rotation from the world to the frame where Up is the Y axis.
+func (fc *FPSController) frame() math32.Quaternion {
+
+	var q math32.Quaternion
+	up := fc.Up
+	q.SetFromUnitVectors(up.Normalize(), &math32.Vector3{Y: 1})
+	return q
+}
+
+// toFrame returns the specified world direction in the frame where Up is the Y axis.
+func (fc *FPSController) toFrame(dir *math32.Vector3) math32.Vector3 {
+
+	q := fc.frame()
+	d := *dir
+	d.ApplyQuaternion(&q)
+	return d
+}
+
+// ControlPose returns the pose the control drives the camera to, at the eyes of the character
+// looking in the direction of the view angles. Satisfies the IPoseControl interface.
+func (fc *FPSController) ControlPose() Pose {
+
+	eye, look := fc.view()
+	up := fc.Up
+	up.Normalize()
+	var m math32.Matrix4
+	var p Pose
+	m.LookAt(&eye, &look, &up)
+	p.Position = eye
+	p.Rotation.SetFromRotationMatrix(&m)
+	return p
+}
+
+// view returns the position of the eyes of the character and the point they look at.
+func (fc *FPSController) view() (eye, look math32.Vector3) {
+
+	up := fc.Up
+	up.Normalize()
+	var dir math32.Vector3
+	bob := fc.BobAmplitude * fc.bobBlend * math32.Sin(fc.bobPhase)
+	eye.Copy(&up).MultiplyScalar(fc.EyeHeight + bob).Add(&fc.feet)
+	cp := math32.Cos(fc.pitch)
+	dir.Set(-math32.Sin(fc.yaw)*cp, math32.Sin(fc.pitch), -math32.Cos(fc.yaw)*cp)
+	frame := fc.frame()
+	dir.ApplyQuaternion(frame.Conjugate())
+	look = eye
+	look.Add(&dir)
+	return eye, look
+}
+
+// apply sets the camera pose from the character position and the view angles.
+func (fc *FPSController) apply() {
+
+	fc.notify.begin(fc.cam, &fc.look)
+	defer fc.notify.end(fc, fc.cam, &fc.look)
+	up := fc.Up
+	up.Normalize()
+	var eye math32.Vector3
+	eye, fc.look = fc.view()
+	fc.cam.SetPositionVec(&eye)
+	fc.cam.LookAt(&fc.look, &up)
+}
+
+// cursorModeSetter is implemented by the windows which can disable the cursor.
+type cursorModeSetter interface {
+	SetCursorMode(mode window.CursorMode)
+}
+
+// SetCursorCaptured disables the cursor of the window, so every cursor motion looks around
+// without being clamped at the edges of the screen, or restores it. It has no effect on
+// windows which can not disable the cursor, such as browser canvases.
+func (fc *FPSController) SetCursorCaptured(captured bool) {
+
+	if captured == fc.captured {
+		return
+	}
+	w, ok := window.Get().(cursorModeSetter)
+	if !ok {
+		return
+	}
+	if captured {
+		w.SetCursorMode(window.CursorDisabled)
+	} else {
+		w.SetCursorMode(window.CursorNormal)
+	}
+	fc.captured = captured
+	// The position of the disabled cursor is virtual, so the motion restarts from its next event
+	fc.cursorOK = false
+}
+
+// CursorCaptured returns whether the control disabled the cursor of the window.
+func (fc *FPSController) CursorCaptured() bool {
+
+	return fc.captured
+}
+
+// onMouse is called when an OnMouseDown/OnMouseUp event is received.
+func (fc *FPSController) onMouse(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	if mev.Button != fc.LookButton {
+		return
+	}
+	switch evname {
+	case window.OnMouseDown:
+		if fc.CaptureCursor {
+			fc.SetCursorCaptured(true)
+			if fc.captured {
+				return
+			}
+		}
+		gui.Manager().SetCursorFocus(fc)
+		fc.looking = true
+		fc.cursor.Set(mev.Xpos, mev.Ypos)
+		fc.cursorOK = true
+	case window.OnMouseUp:
+		gui.Manager().SetCursorFocus(nil)
+		fc.looking = false
+	}
+}
+
+// onCursor is called when an OnCursor event is received from the window.
+func (fc *FPSController) onCursor(evname string, ev interface{}) {
+
+	cev := ev.(*window.CursorEvent)
+	if fc.active && fc.cursorOK && (fc.looking || fc.CaptureLook || fc.captured) {
+		dx := cev.Xpos - fc.cursor.X
+		dy := cev.Ypos - fc.cursor.Y
+		fc.lookBy(dx, dy)
+	}
+	fc.cursor.Set(cev.Xpos, cev.Ypos)
+	fc.cursorOK = true
+}
+
+// onScroll is called when an OnScroll event is received.
+// While looking around, scrolling adjusts the walking speed by ScrollFactor.
+func (fc *FPSController) onScroll(evname string, ev interface{}) {
+
+	sev := ev.(*window.ScrollEvent)
+	if fc.ScrollFactor <= 0 || sev.Yoffset == 0 || !(fc.looking || fc.CaptureLook || fc.captured) {
+		return
+	}
+	fc.SetWalkSpeed(fc.WalkSpeed * math32.Pow(fc.ScrollFactor, sev.Yoffset))
+}
+
+// lookBy turns the view by the specified cursor motion in pixels, immediately
+// or, if LookSmoothing is set, during the next updates.
+func (fc *FPSController) lookBy(dx, dy float32) {
+
+	if fc.replay != nil || fc.lockNode != nil {
+		return
+	}
+	yaw := fc.aimYaw - dx*fc.LookSpeed
+	pitch := fc.aimPitch - fc.pitchSign()*dy*fc.lookSpeedY()
+	if fc.LookSmoothing <= 0 {
+		fc.SetLook(yaw, pitch)
+		return
+	}
+	fc.aimYaw = yaw
+	fc.aimPitch = math32.Clamp(pitch, -fc.MaxPitch, fc.MaxPitch)
+}
+
+// lookSpeedY returns the vertical look rotation in radians per pixel of cursor motion.
+func (fc *FPSController) lookSpeedY() float32 {
+
+	if fc.LookSpeedY != 0 {
+		return fc.LookSpeedY
+	}
+	return fc.LookSpeed
+}
+
+// pitchSign returns -1 if the pitch is inverted and 1 otherwise.
+func (fc *FPSController) pitchSign() float32 {
+
+	if fc.InvertPitch {
+		return -1
+	}
+	return 1
+}
+
+// onKey is called when an OnKeyDown/OnKeyUp event is received.
+func (fc *FPSController) onKey(evname string, ev interface{}) {
+
+	kev := ev.(*window.KeyEvent)
+	if fc.captured && kev.Key == window.KeyEscape && evname == window.OnKeyDown {
+		fc.SetCursorCaptured(false)
+	}
+	for i, mk := range fpsModifierKeys {
+		if kev.Key == mk.key {
+			if evname == window.OnKeyDown {
+				fc.modKeys |= 1 << uint(i)
+			} else {
+				fc.modKeys &^= 1 << uint(i)
+			}
+		}
+	}
+	for action, kb := range fc.Keys {
+		if (kb.Scancode != 0 && kb.Scancode != kev.Scancode) || (kb.Scancode == 0 && kb.Key != kev.Key) {
+			continue
+		}
+		down := evname == window.OnKeyDown
+		if action == FPSKeyJump && down && !fc.pressed[action] {
+			fc.jump = true
+		}
+		fc.pressed[action] = down
+	}
+}
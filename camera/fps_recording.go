@@ -0,0 +1,103 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/math32"
+)
+
+// FPSInput is the input of an FPSController during one update.
+// It holds the values the input resolved to, so replaying it does not depend on
+// the bindings, the input devices or the smoothing settings.
+type FPSInput struct {
+	DeltaTime float32        `json:"dt"`             // Time step of the update in seconds
+	Yaw       float32        `json:"yaw"`            // Yaw of the view
+	Pitch     float32        `json:"pitch"`          // Pitch of the view
+	Wish      math32.Vector3 `json:"wish"`           // Requested walking velocity
+	Jump      bool           `json:"jump,omitempty"` // Whether a jump was requested
+}
+
+// FPSRecording is a sequence of inputs of an FPSController with the state of the character
+// when the recording started. It can be serialized to save demos or rendering tests.
+type FPSRecording struct {
+	Position math32.Vector3 `json:"position"` // Position of the feet
+	Velocity math32.Vector3 `json:"velocity"` // Velocity of the character
+	Yaw      float32        `json:"yaw"`      // Yaw of the view
+	Pitch    float32        `json:"pitch"`    // Pitch of the view
+	Grounded bool           `json:"grounded"` // Whether the character stood on the ground
+	Inputs   []FPSInput     `json:"inputs"`   // Inputs of the updates
+}
+
+// Duration returns the total time of the recorded updates in seconds.
+func (r *FPSRecording) Duration() float32 {
+
+	var d float32
+	for i := range r.Inputs {
+		d += r.Inputs[i].DeltaTime
+	}
+	return d
+}
+
+// StartRecording starts recording the input of the next updates, from the current state.
+func (fc *FPSController) StartRecording() {
+
+	fc.record = &FPSRecording{
+		Position: fc.feet,
+		Velocity: fc.velocity,
+		Yaw:      fc.yaw,
+		Pitch:    fc.pitch,
+		Grounded: fc.grounded,
+	}
+}
+
+// StopRecording stops recording and returns the recording, or nil if there was none.
+func (fc *FPSController) StopRecording() *FPSRecording {
+
+	rec := fc.record
+	fc.record = nil
+	return rec
+}
+
+// Replay restores the state of the character when the specified recording started and replays
+// its inputs on the next updates, each with its recorded time step, ignoring user input.
+// With the same colliders the character follows the same path as when it was recorded.
+func (fc *FPSController) Replay(rec *FPSRecording) {
+
+	fc.replay = rec
+	fc.replayAt = 0
+	fc.feet = rec.Position
+	fc.velocity = rec.Velocity
+	fc.grounded = rec.Grounded
+	fc.jump = false
+	fc.SetLook(rec.Yaw, rec.Pitch)
+}
+
+// Replaying returns whether a recording is being replayed.
+func (fc *FPSController) Replaying() bool {
+
+	return fc.replay != nil
+}
+
+// StopReplay stops replaying the current recording, returning the control to user input.
+func (fc *FPSController) StopReplay() {
+
+	fc.replay = nil
+}
+
+// nextReplay returns the next replayed input, or nil if no recording is being replayed.
+// The replay stops after its last input.
+func (fc *FPSController) nextReplay() *FPSInput {
+
+	if fc.replay == nil {
+		return nil
+	}
+	if fc.replayAt >= len(fc.replay.Inputs) {
+		fc.replay = nil
+		return nil
+	}
+	in := &fc.replay.Inputs[fc.replayAt]
+	fc.replayAt++
+	return in
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// Axes and buttons of the standard gamepad layout used by the default gamepad bindings.
+const (
+	GamepadLeftX   = 0
+	GamepadLeftY   = 1
+	GamepadRightX  = 2
+	GamepadRightY  = 3
+	GamepadButtonA = 0
+)
+
+// GamepadAxis binds an axis of the connected gamepads to an analog action.
+// Values are between -1 and 1; stick axes are negative to the left and up.
+type GamepadAxis struct {
+	Index    int     `json:"index"`              // Index of the axis
+	Invert   bool    `json:"invert,omitempty"`   // Whether the value is negated
+	Deadzone float32 `json:"deadzone,omitempty"` // Magnitude below which the axis is at rest
+	Curve    float32 `json:"curve,omitempty"`    // Exponent of the response curve, for finer control near rest (0 means 1, linear)
+}
+
+// Value returns the value of the axis with the largest magnitude among the specified joysticks,
+// rescaled to start at 0 outside the dead zone and shaped by the response curve.
+func (ga GamepadAxis) Value(joysticks []*window.Joystick) float32 {
+
+	var v float32
+	for _, j := range joysticks {
+		if a := j.Axis(ga.Index); math32.Abs(a) > math32.Abs(v) {
+			v = a
+		}
+	}
+	mag := math32.Abs(v)
+	if mag <= ga.Deadzone {
+		return 0
+	}
+	if ga.Deadzone > 0 && ga.Deadzone < 1 {
+		mag = (mag - ga.Deadzone) / (1 - ga.Deadzone)
+	}
+	if ga.Curve > 0 {
+		mag = math32.Pow(math32.Min(mag, 1), ga.Curve)
+	}
+	if (v < 0) != ga.Invert {
+		return -mag
+	}
+	return mag
+}
+
+// gamepadButton returns whether the specified button is pressed on any of the specified joysticks.
+func gamepadButton(joysticks []*window.Joystick, index int) bool {
+
+	for _, j := range joysticks {
+		if j.Button(index) {
+			return true
+		}
+	}
+	return false
+}
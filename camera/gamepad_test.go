@@ -0,0 +1,38 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// TestGamepadAxisValue checks the dead zone, response curve and inversion of gamepad axes.
+func TestGamepadAxisValue(t *testing.T) {
+
+	pads := []*window.Joystick{{Axes: []float32{0.1, -0.6}}, {Axes: []float32{0.3, 1}}}
+	tests := []struct {
+		axis GamepadAxis
+		want float32
+	}{
+		{GamepadAxis{Index: 0}, 0.3},
+		{GamepadAxis{Index: 0, Deadzone: 0.5}, 0},
+		{GamepadAxis{Index: 1}, 1},
+		{GamepadAxis{Index: 1, Invert: true}, -1},
+		{GamepadAxis{Index: 0, Deadzone: 0.2, Curve: 2}, 0.015625},
+		{GamepadAxis{Index: 5}, 0},
+	}
+	for i, test := range tests {
+		if got := test.axis.Value(pads); math32.Abs(got-test.want) > 1e-6 {
+			t.Errorf("axis %d: value is %v instead of %v", i, got, test.want)
+		}
+	}
+	pads[1].Axes[1] = 0
+	if got := (GamepadAxis{Index: 1, Deadzone: 0.2}).Value(pads); math32.Abs(got+0.5) > 1e-6 {
+		t.Errorf("negative axis value is %v instead of -0.5", got)
+	}
+}
@@ -0,0 +1,360 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// Mouse actions of the OrbitControl.
+const (
+	OrbitMouseRotate = "rotate"
+	OrbitMouseZoom   = "zoom"
+	OrbitMousePan    = "pan"
+)
+
+// Key actions of the OrbitControl.
+const (
+	OrbitKeyRotateUp    = "rotateUp"
+	OrbitKeyRotateDown  = "rotateDown"
+	OrbitKeyRotateLeft  = "rotateLeft"
+	OrbitKeyRotateRight = "rotateRight"
+	OrbitKeyZoomIn      = "zoomIn"
+	OrbitKeyZoomOut     = "zoomOut"
+	OrbitKeyPanUp       = "panUp"
+	OrbitKeyPanDown     = "panDown"
+	OrbitKeyPanLeft     = "panLeft"
+	OrbitKeyPanRight    = "panRight"
+)
+
+// MouseBinding binds a mouse button, with optional modifier keys, to an action.
+// A binding without modifiers matches when no more specific binding does.
+type MouseBinding struct {
+	Button window.MouseButton `json:"button"`
+	Mods   window.ModifierKey `json:"mods,omitempty"`
+}
+
+// KeyBinding binds a key, with modifier keys, to an action.
+// If Scancode is not zero the binding refers to the physical key with that scancode,
+// independently of the keyboard layout, and Key is ignored.
+type KeyBinding struct {
+	Key      window.Key         `json:"key"`
+	Scancode window.Scancode    `json:"scancode,omitempty"`
+	Mods     window.ModifierKey `json:"mods,omitempty"`
+}
+
+// ScancodeBinding returns a layout independent KeyBinding for the physical key at the
+// position the specified key has on the US layout, such as W of WASD.
+// If the key has no known scancode the returned binding uses the key symbol.
+func ScancodeBinding(key window.Key, mods window.ModifierKey) KeyBinding {
+
+	sc, _ := window.KeyScancode(key)
+	return KeyBinding{Key: key, Scancode: sc, Mods: mods}
+}
+
+// Matches returns whether the binding matches the specified key event.
+func (kb KeyBinding) Matches(kev *window.KeyEvent) bool {
+
+	if kb.Mods != kev.Mods {
+		return false
+	}
+	if kb.Scancode != 0 {
+		return kb.Scancode == kev.Scancode
+	}
+	return kb.Key == kev.Key
+}
+
+// Name returns the name of the bound key suitable for display, following the current
+// keyboard layout for scancode bindings.
+func (kb KeyBinding) Name() string {
+
+	if kb.Scancode != 0 {
+		return window.ScancodeName(kb.Scancode)
+	}
+	return window.KeyName(kb.Key, 0)
+}
+
+// OrbitBindings contains the input bindings, speeds and constraints of an OrbitControl
+// in a form which can be serialized, for example to save user customizations.
+// Constraints which are unlimited (infinite) are omitted since JSON cannot represent them,
+// and absent constraints are restored as unlimited.
+// Keys, buttons and modifiers are stored with the stable names of the window package,
+// such as "KP8", "Middle" and "Shift+Control", so files work with any window backend.
+type OrbitBindings struct {
+	Mouse       map[string]MouseBinding `json:"mouse"`
+	Keys        map[string]KeyBinding   `json:"keys"`
+	Speeds      map[string]float32      `json:"speeds"`
+	Constraints map[string]float32      `json:"constraints"`
+}
+
+// Registered presets
+var orbitPresets = map[string]*OrbitBindings{
+	"default": DefaultOrbitBindings(),
+	"blender": {
+		Mouse: map[string]MouseBinding{
+			OrbitMouseRotate: {Button: window.MouseButtonMiddle},
+			OrbitMousePan:    {Button: window.MouseButtonMiddle, Mods: window.ModShift},
+			OrbitMouseZoom:   {Button: window.MouseButtonMiddle, Mods: window.ModControl},
+		},
+		Keys: map[string]KeyBinding{
+			OrbitKeyRotateUp:    {Key: window.KeyKP8},
+			OrbitKeyRotateDown:  {Key: window.KeyKP2},
+			OrbitKeyRotateLeft:  {Key: window.KeyKP4},
+			OrbitKeyRotateRight: {Key: window.KeyKP6},
+			OrbitKeyZoomIn:      {Key: window.KeyKPAdd},
+			OrbitKeyZoomOut:     {Key: window.KeyKPSubtract},
+			OrbitKeyPanUp:       {Key: window.KeyKP8, Mods: window.ModControl},
+			OrbitKeyPanDown:     {Key: window.KeyKP2, Mods: window.ModControl},
+			OrbitKeyPanLeft:     {Key: window.KeyKP4, Mods: window.ModControl},
+			OrbitKeyPanRight:    {Key: window.KeyKP6, Mods: window.ModControl},
+		},
+	},
+}
+
+// DefaultOrbitBindings returns the default OrbitControl bindings.
+// Speeds and constraints are empty, which leaves the current values unchanged when applied.
+func DefaultOrbitBindings() *OrbitBindings {
+
+	return &OrbitBindings{
+		Mouse: map[string]MouseBinding{
+			OrbitMouseRotate: {Button: window.MouseButtonLeft},
+			OrbitMouseZoom:   {Button: window.MouseButtonMiddle},
+			OrbitMousePan:    {Button: window.MouseButtonRight},
+		},
+		Keys: map[string]KeyBinding{
+			OrbitKeyRotateUp:    {Key: window.KeyUp},
+			OrbitKeyRotateDown:  {Key: window.KeyDown},
+			OrbitKeyRotateLeft:  {Key: window.KeyLeft},
+			OrbitKeyRotateRight: {Key: window.KeyRight},
+			OrbitKeyZoomIn:      {Key: window.KeyUp, Mods: window.ModControl},
+			OrbitKeyZoomOut:     {Key: window.KeyDown, Mods: window.ModControl},
+			OrbitKeyPanUp:       {Key: window.KeyUp, Mods: window.ModShift},
+			OrbitKeyPanDown:     {Key: window.KeyDown, Mods: window.ModShift},
+			OrbitKeyPanLeft:     {Key: window.KeyLeft, Mods: window.ModShift},
+			OrbitKeyPanRight:    {Key: window.KeyRight, Mods: window.ModShift},
+		},
+	}
+}
+
+// Clone returns a deep copy of the bindings. Nil maps stay nil.
+func (b *OrbitBindings) Clone() *OrbitBindings {
+
+	c := &OrbitBindings{}
+	if b.Mouse != nil {
+		c.Mouse = make(map[string]MouseBinding, len(b.Mouse))
+		for action, mb := range b.Mouse {
+			c.Mouse[action] = mb
+		}
+	}
+	if b.Keys != nil {
+		c.Keys = make(map[string]KeyBinding, len(b.Keys))
+		for action, kb := range b.Keys {
+			c.Keys[action] = kb
+		}
+	}
+	c.Speeds = cloneFloats(b.Speeds)
+	c.Constraints = cloneFloats(b.Constraints)
+	return c
+}
+
+// cloneFloats returns a copy of the specified map, or nil if it is nil.
+func cloneFloats(m map[string]float32) map[string]float32 {
+
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]float32, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// RegisterOrbitPreset registers (or replaces) a named preset of OrbitControl bindings.
+// The preset is copied, so later changes to the specified bindings do not affect it.
+func RegisterOrbitPreset(name string, b *OrbitBindings) {
+
+	orbitPresets[name] = b.Clone()
+}
+
+// OrbitPreset returns a copy of the registered preset with the specified name,
+// which can be modified without changing the preset.
+func OrbitPreset(name string) (*OrbitBindings, bool) {
+
+	b, ok := orbitPresets[name]
+	if !ok {
+		return nil, false
+	}
+	return b.Clone(), true
+}
+
+// OrbitPresets returns the sorted names of all registered presets.
+func OrbitPresets() []string {
+
+	names := make([]string, 0, len(orbitPresets))
+	for name := range orbitPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bindings returns a copy of the current bindings, speeds and constraints of the control.
+func (oc *OrbitControl) Bindings() *OrbitBindings {
+
+	b := &OrbitBindings{
+		Mouse:       make(map[string]MouseBinding),
+		Keys:        make(map[string]KeyBinding),
+		Speeds:      make(map[string]float32),
+		Constraints: make(map[string]float32),
+	}
+	for action, mb := range oc.Mouse {
+		b.Mouse[action] = mb
+	}
+	for action, kb := range oc.Keys {
+		b.Keys[action] = kb
+	}
+	for name, ptr := range oc.speedFields() {
+		b.Speeds[name] = *ptr
+	}
+	for name, c := range oc.constraintFields() {
+		if !math.IsInf(float64(*c.ptr), 0) {
+			b.Constraints[name] = *c.ptr
+		}
+	}
+	return b
+}
+
+// SetBindings replaces the input bindings of the control with the specified ones
+// and sets the speeds and constraints present in them.
+// If Constraints is not nil, absent constraints are set to their unlimited values.
+func (oc *OrbitControl) SetBindings(b *OrbitBindings) {
+
+	if b.Mouse != nil {
+		oc.Mouse = make(map[string]MouseBinding)
+		for action, mb := range b.Mouse {
+			oc.Mouse[action] = mb
+		}
+	}
+	if b.Keys != nil {
+		oc.Keys = make(map[string]KeyBinding)
+		for action, kb := range b.Keys {
+			oc.Keys[action] = kb
+		}
+	}
+	speeds := oc.speedFields()
+	for name, v := range b.Speeds {
+		if ptr, ok := speeds[name]; ok {
+			*ptr = v
+		}
+	}
+	if b.Constraints != nil {
+		for name, c := range oc.constraintFields() {
+			if v, ok := b.Constraints[name]; ok {
+				*c.ptr = v
+			} else {
+				*c.ptr = c.unlimited
+			}
+		}
+	}
+}
+
+// ApplyPreset sets the bindings of the control from the registered preset with the specified name.
+func (oc *OrbitControl) ApplyPreset(name string) error {
+
+	b, ok := orbitPresets[name]
+	if !ok {
+		return fmt.Errorf("orbit preset not found: %s", name)
+	}
+	oc.SetBindings(b)
+	return nil
+}
+
+// MarshalBindings returns the JSON encoding of the current bindings of the control.
+func (oc *OrbitControl) MarshalBindings() ([]byte, error) {
+
+	return json.MarshalIndent(oc.Bindings(), "", "  ")
+}
+
+// UnmarshalBindings decodes the specified JSON encoded bindings and sets them in the control.
+func (oc *OrbitControl) UnmarshalBindings(data []byte) error {
+
+	var b OrbitBindings
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	oc.SetBindings(&b)
+	return nil
+}
+
+// speedFields returns the serializable speed fields by name.
+func (oc *OrbitControl) speedFields() map[string]*float32 {
+
+	return map[string]*float32{
+		"rotate":     &oc.RotSpeed,
+		"zoom":       &oc.ZoomSpeed,
+		"keyRotate":  &oc.KeyRotSpeed,
+		"keyZoom":    &oc.KeyZoomSpeed,
+		"keyPan":     &oc.KeyPanSpeed,
+		"autoRotate": &oc.AutoRotateSpeed,
+		"damping":    &oc.Damping,
+	}
+}
+
+// constraint describes a serializable constraint field and its unlimited value.
+type constraint struct {
+	ptr       *float32
+	unlimited float32
+}
+
+// constraintFields returns the serializable constraint fields by name.
+func (oc *OrbitControl) constraintFields() map[string]constraint {
+
+	inf := float32(math.Inf(1))
+	return map[string]constraint{
+		"minDistance":     {&oc.MinDistance, 0},
+		"maxDistance":     {&oc.MaxDistance, inf},
+		"minPolarAngle":   {&oc.MinPolarAngle, 0},
+		"maxPolarAngle":   {&oc.MaxPolarAngle, math32.Pi},
+		"minAzimuthAngle": {&oc.MinAzimuthAngle, -inf},
+		"maxAzimuthAngle": {&oc.MaxAzimuthAngle, inf},
+	}
+}
+
+// mouseAction returns the mouse action bound to the specified button and modifiers.
+// Bindings with matching modifiers take precedence over bindings without modifiers.
+func (oc *OrbitControl) mouseAction(button window.MouseButton, mods window.ModifierKey) string {
+
+	fallback := ""
+	for action, mb := range oc.Mouse {
+		if mb.Button != button {
+			continue
+		}
+		if mb.Mods == mods && mods != 0 {
+			return action
+		}
+		if mb.Mods == 0 && (fallback == "" || action < fallback) {
+			fallback = action
+		}
+	}
+	return fallback
+}
+
+// keyAction returns the key action bound to the specified key event.
+func (oc *OrbitControl) keyAction(kev *window.KeyEvent) string {
+
+	for action, kb := range oc.Keys {
+		if kb.Matches(kev) {
+			return action
+		}
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/window"
+)
+
+// TestOrbitPresetCopy checks that presets cannot be modified through the returned bindings.
+func TestOrbitPresetCopy(t *testing.T) {
+
+	b, ok := OrbitPreset("blender")
+	if !ok {
+		t.Fatal("blender preset is not registered")
+	}
+	b.Mouse[OrbitMouseRotate] = MouseBinding{Button: window.MouseButtonLeft}
+	delete(b.Keys, OrbitKeyZoomIn)
+	if b.Speeds != nil || b.Constraints != nil {
+		t.Fatal("nil maps of the preset were not kept nil")
+	}
+
+	again, _ := OrbitPreset("blender")
+	if again.Mouse[OrbitMouseRotate].Button != window.MouseButtonMiddle {
+		t.Fatal("preset mouse binding was modified")
+	}
+	if _, ok := again.Keys[OrbitKeyZoomIn]; !ok {
+		t.Fatal("preset key binding was removed")
+	}
+}
@@ -6,6 +6,7 @@ package camera
 
 import (
 	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/graphic"
 	"github.com/g3n/engine/gui"
 	"github.com/g3n/engine/math32"
 	"github.com/g3n/engine/window"
@@ -25,6 +26,15 @@ const (
 	OrbitAll  OrbitEnabled = 0xFF
 )
 
+// OrbitPanMode specifies how mouse dragging pans the camera.
+type OrbitPanMode int
+
+// The possible pan modes.
+const (
+	OrbitPanSpeed  OrbitPanMode = iota // Pan proportionally to the cursor displacement, scaled by the distance to the target
+	OrbitPanCursor                     // Pan so the point grabbed on the focus plane stays exactly under the cursor
+)
+
 // orbitState bitmask
 type orbitState int
 
@@ -37,31 +47,85 @@ const (
 
 // OrbitControl is a camera controller that allows orbiting a target point while looking at it.
 // It allows the user to rotate, zoom, and pan a 3D scene using the mouse or keyboard.
+// It dispatches OnCameraMove, OnCameraRotate and OnZoomChange events when it changes the camera.
 type OrbitControl struct {
 	core.Dispatcher                // Embedded event dispatcher
 	cam             *Camera        // Controlled camera
 	target          math32.Vector3 // Camera target, around which the camera orbits
-	up              math32.Vector3 // The orbit axis (Y+)
+	up              math32.Vector3 // The orbit axis (default is the engine up direction)
 	enabled         OrbitEnabled   // Which controls are enabled
 	state           orbitState     // Current control state
+	active          bool           // Whether the control is subscribed to user input
+	offset          math32.Vector3 // Camera position relative to the target when the control was last deactivated
+	hasOffset       bool           // Whether offset was saved
 
 	// Public properties
-	MinDistance     float32 // Minimum distance from target (default is 1)
-	MaxDistance     float32 // Maximum distance from target (default is infinity)
-	MinPolarAngle   float32 // Minimum polar angle in radians (default is 0)
-	MaxPolarAngle   float32 // Maximum polar angle in radians (default is Pi)
-	MinAzimuthAngle float32 // Minimum azimuthal angle in radians (default is negative infinity)
-	MaxAzimuthAngle float32 // Maximum azimuthal angle in radians (default is infinity)
-	RotSpeed        float32 // Rotation speed factor (default is 1)
-	ZoomSpeed       float32 // Zoom speed factor (default is 0.1)
-	KeyRotSpeed     float32 // Rotation delta in radians used on each rotation key event (default is the equivalent of 15 degrees)
-	KeyZoomSpeed    float32 // Zoom delta used on each zoom key event (default is 2)
-	KeyPanSpeed     float32 // Pan delta used on each pan key event (default is 35)
+	MinDistance     float32      // Minimum distance from target (default is 1)
+	MaxDistance     float32      // Maximum distance from target (default is infinity)
+	MinPolarAngle   float32      // Minimum polar angle in radians (default is 0)
+	MaxPolarAngle   float32      // Maximum polar angle in radians (default is Pi)
+	MinAzimuthAngle float32      // Minimum azimuthal angle in radians (default is negative infinity)
+	MaxAzimuthAngle float32      // Maximum azimuthal angle in radians (default is infinity)
+	RotSpeed        float32      // Rotation speed factor (default is 1)
+	ZoomSpeed       float32      // Zoom speed factor (default is 0.1)
+	KeyRotSpeed     float32      // Rotation delta in radians used on each rotation key event (default is the equivalent of 15 degrees)
+	KeyZoomSpeed    float32      // Zoom delta used on each zoom key event (default is 2)
+	KeyPanSpeed     float32      // Pan delta used on each pan key event (default is 35)
+	ContinuousKeys  bool         // Held keys move the camera each update by their key speeds per second, instead of on each key event (default is false)
+	PanMode         OrbitPanMode // Mouse pan mode (default is OrbitPanSpeed)
+	ZoomToCursor    bool         // Mouse wheel zoom dollies toward the point under the cursor (default is false)
+	AutoRotate      bool         // Turntable rotation around the target while idle (default is false)
+	AutoRotateSpeed float32      // Auto rotation speed in radians per second (default is one turn every 30 seconds)
+	IdleTimeout     float32      // Seconds without user input before idle behaviors start (default is 3)
+	IdleDrift       float32      // Amplitude of the idle breathing drift as a fraction of the distance (default is 0, disabled)
+	IdleDriftPeriod float32      // Period of the idle breathing drift in seconds (default is 8)
+	Damping         float32      // Decay rate per second of the rotation and pan inertia after the mouse is released (default is 0, no inertia)
+	TargetBounds    *math32.Box3 // Box the target is kept inside of by panning and zooming to the cursor (default is nil, unbounded)
+
+	// Mouse and Keys map action names (OrbitMouse* and OrbitKey* constants) to input bindings.
+	// They can be changed directly, saved and restored with MarshalBindings and UnmarshalBindings,
+	// or set from a named preset with ApplyPreset.
+	Mouse map[string]MouseBinding
+	Keys  map[string]KeyBinding
+
+	// CursorPicker, if not nil, is used by ZoomToCursor to find the 3D point hit by the specified
+	// ray, usually by raycasting the scene. It returns false if nothing was hit, in which case
+	// the point where the ray crosses the focus plane through the target is used.
+	CursorPicker func(ray *math32.Ray, point *math32.Vector3) bool
 
 	// Internal
 	rotStart  math32.Vector2
 	panStart  math32.Vector2
 	zoomStart float32
+	trans     orbitTransition       // Current animated transition
+	panPlane  math32.Plane          // Focus plane used by cursor panning
+	panGrab   math32.Vector3        // Point on the focus plane grabbed by cursor panning
+	cursorPos math32.Vector2        // Last known cursor position
+	idleTime  float32               // Seconds since the last user input
+	driftTime float32               // Seconds since the idle drift started
+	driftPrev float32               // Last applied drift value
+	notify    changeNotifier        // Dispatches camera control events
+	poses     map[string]CameraPose // Saved pose bookmarks
+	rotDelta  math32.Vector2        // Rotation applied by the mouse since the last update
+	panDelta  math32.Vector3        // Pan applied by the mouse since the last update
+	rotVel    math32.Vector2        // Rotation velocity in radians per second
+	panVel    math32.Vector3        // Pan velocity in world units per second
+	held      map[string]bool       // Key actions held with ContinuousKeys
+}
+
+// orbitTransition describes an animated transition of the camera and target.
+type orbitTransition struct {
+	active     bool           // Whether the transition is running
+	elapsed    float32        // Elapsed time in seconds
+	duration   float32        // Total duration in seconds
+	fromPos    math32.Vector3 // Initial camera position
+	toPos      math32.Vector3 // Final camera position
+	fromTarget math32.Vector3 // Initial target
+	toTarget   math32.Vector3 // Final target
+	fromSize   float32        // Initial orthographic size
+	toSize     float32        // Final orthographic size
+	fromFov    float32        // Initial field of view
+	toFov      float32        // Final field of view
 }
 
 // NewOrbitControl creates and returns a pointer to a new orbit control for the specified camera.
@@ -71,7 +135,7 @@ func NewOrbitControl(cam *Camera) *OrbitControl {
 	oc.Dispatcher.Initialize()
 	oc.cam = cam
 	oc.target = *math32.NewVec3()
-	oc.up = *math32.NewVector3(0, 1, 0)
+	oc.up = core.EngineCoords().UpVector()
 	oc.enabled = OrbitAll
 
 	oc.MinDistance = 1.0
@@ -85,14 +149,17 @@ func NewOrbitControl(cam *Camera) *OrbitControl {
 	oc.KeyRotSpeed = 15 * math32.Pi / 180 // 15 degrees as radians
 	oc.KeyZoomSpeed = 2.0
 	oc.KeyPanSpeed = 35.0
+	oc.AutoRotateSpeed = 2 * math32.Pi / 30
+	oc.IdleTimeout = 3
+	oc.IdleDriftPeriod = 8
+	oc.Damping = 0
+	oc.held = make(map[string]bool)
+	oc.SetBindings(DefaultOrbitBindings())
 
 	// Subscribe to events
-	gui.Manager().SubscribeID(window.OnMouseUp, &oc, oc.onMouse)
-	gui.Manager().SubscribeID(window.OnMouseDown, &oc, oc.onMouse)
-	gui.Manager().SubscribeID(window.OnScroll, &oc, oc.onScroll)
-	gui.Manager().SubscribeID(window.OnKeyDown, &oc, oc.onKey)
-	gui.Manager().SubscribeID(window.OnKeyRepeat, &oc, oc.onKey)
-	oc.SubscribeID(window.OnCursor, &oc, oc.onCursor)
+	oc.SubscribeID(window.OnCursor, oc, oc.onCursor)
+	window.Get().SubscribeID(window.OnCursor, oc, oc.onCursorPos)
+	oc.SetActive(true)
 
 	return oc
 }
@@ -100,17 +167,73 @@ func NewOrbitControl(cam *Camera) *OrbitControl {
 // Dispose unsubscribes from all events.
 func (oc *OrbitControl) Dispose() {
 
-	gui.Manager().UnsubscribeID(window.OnMouseUp, &oc)
-	gui.Manager().UnsubscribeID(window.OnMouseDown, &oc)
-	gui.Manager().UnsubscribeID(window.OnScroll, &oc)
-	gui.Manager().UnsubscribeID(window.OnKeyDown, &oc)
-	gui.Manager().UnsubscribeID(window.OnKeyRepeat, &oc)
-	oc.UnsubscribeID(window.OnCursor, &oc)
+	oc.SetActive(false)
+	oc.UnsubscribeID(window.OnCursor, oc)
+	window.Get().UnsubscribeID(window.OnCursor, oc)
+}
+
+// Active returns whether the control is receiving user input.
+func (oc *OrbitControl) Active() bool {
+
+	return oc.active
+}
+
+// SetActive sets whether the control receives user input.
+// An inactive control can still be moved programmatically and updated.
+func (oc *OrbitControl) SetActive(active bool) {
+
+	if active == oc.active {
+		return
+	}
+	oc.active = active
+	if active {
+		gui.Manager().SubscribeID(window.OnMouseUp, oc, oc.onMouse)
+		gui.Manager().SubscribeID(window.OnMouseDown, oc, oc.onMouse)
+		gui.Manager().SubscribeID(window.OnScroll, oc, oc.onScroll)
+		gui.Manager().SubscribeID(window.OnKeyDown, oc, oc.onKey)
+		gui.Manager().SubscribeID(window.OnKeyRepeat, oc, oc.onKey)
+		gui.Manager().SubscribeID(window.OnKeyUp, oc, oc.onKey)
+		return
+	}
+	gui.Manager().UnsubscribeID(window.OnMouseUp, oc)
+	gui.Manager().UnsubscribeID(window.OnMouseDown, oc)
+	gui.Manager().UnsubscribeID(window.OnScroll, oc)
+	gui.Manager().UnsubscribeID(window.OnKeyDown, oc)
+	gui.Manager().UnsubscribeID(window.OnKeyRepeat, oc)
+	gui.Manager().UnsubscribeID(window.OnKeyUp, oc)
+	oc.held = make(map[string]bool)
+	if oc.state != stateNone {
+		gui.Manager().SetCursorFocus(nil)
+		oc.state = stateNone
+	}
+	oc.offset = oc.cam.Position()
+	oc.offset.Sub(&oc.target)
+	oc.hasOffset = true
+}
+
+// ControlPose returns the pose the control drives the camera to: its position relative to
+// the target when the control was last deactivated, or the current camera position, looking
+// at the target. Satisfies the IPoseControl interface.
+func (oc *OrbitControl) ControlPose() Pose {
+
+	pos := oc.cam.Position()
+	if oc.hasOffset {
+		pos = oc.target
+		pos.Add(&oc.offset)
+	}
+	var m math32.Matrix4
+	var p Pose
+	m.LookAt(&pos, &oc.target, &oc.up)
+	p.Position = pos
+	p.Rotation.SetFromRotationMatrix(&m)
+	return p
 }
 
 // Reset resets the orbit control.
 func (oc *OrbitControl) Reset() {
 
+	oc.beginChange()
+	defer oc.endChange()
 	oc.target = *math32.NewVec3()
 }
 
@@ -135,11 +258,17 @@ func (oc *OrbitControl) SetEnabled(bitmask OrbitEnabled) {
 // Rotate rotates the camera around the target by the specified angles.
 func (oc *OrbitControl) Rotate(thetaDelta, phiDelta float32) {
 
+	oc.beginChange()
+	defer oc.endChange()
 	const EPS = 0.0001
 
-	// Compute direction vector from target to camera
+	// Compute direction vector from target to camera, in a frame where the orbit axis is Y+
 	tcam := oc.cam.Position()
 	tcam.Sub(&oc.target)
+	var toFrame math32.Quaternion
+	up := oc.up
+	toFrame.SetFromUnitVectors(up.Normalize(), &math32.Vector3{Y: 1})
+	tcam.ApplyQuaternion(&toFrame)
 
 	// Calculate angles based on current camera position plus deltas
 	radius := tcam.Length()
@@ -155,9 +284,10 @@ func (oc *OrbitControl) Rotate(thetaDelta, phiDelta float32) {
 	tcam.X = radius * math32.Sin(phi) * math32.Sin(theta)
 	tcam.Y = radius * math32.Cos(phi)
 	tcam.Z = radius * math32.Sin(phi) * math32.Cos(theta)
+	tcam.ApplyQuaternion(toFrame.Conjugate())
 
 	// Update camera position and orientation
-	oc.cam.SetPositionVec(oc.target.Clone().Add(&tcam))
+	oc.cam.SetPositionVec(tcam.Add(&oc.target))
 	oc.cam.LookAt(&oc.target, &oc.up)
 }
 
@@ -165,6 +295,8 @@ func (oc *OrbitControl) Rotate(thetaDelta, phiDelta float32) {
 // and also updates the camera's orthographic size to match.
 func (oc *OrbitControl) Zoom(delta float32) {
 
+	oc.beginChange()
+	defer oc.endChange()
 	// Compute direction vector from target to camera
 	tcam := oc.cam.Position()
 	tcam.Sub(&oc.target)
@@ -176,30 +308,357 @@ func (oc *OrbitControl) Zoom(delta float32) {
 
 	// Update orthographic size and camera position with new distance
 	oc.cam.UpdateSize(tcam.Length())
-	oc.cam.SetPositionVec(oc.target.Clone().Add(&tcam))
+	oc.cam.SetPositionVec(tcam.Add(&oc.target))
+}
+
+// ZoomAt moves the camera and target closer or farther from the specified point the specified
+// amount, keeping the point fixed on the screen. The distance to the target changes as in Zoom.
+func (oc *OrbitControl) ZoomAt(delta float32, point *math32.Vector3) {
+
+	oc.beginChange()
+	defer oc.endChange()
+	// Compute new distance from target and apply limits
+	position := oc.cam.Position()
+	dist := position.DistanceTo(&oc.target)
+	if dist == 0 {
+		return
+	}
+	newDist := dist * (1 + delta/10)
+	newDist = math32.Max(oc.MinDistance, math32.Min(oc.MaxDistance, newDist))
+	f := newDist / dist
+
+	// Scale camera position and target about the point
+	position.Sub(point).MultiplyScalar(f).Add(point)
+	oc.target.Sub(point).MultiplyScalar(f).Add(point)
+
+	// Update orthographic size and camera position with new distance
+	oc.cam.UpdateSize(newDist)
+	oc.cam.SetPositionVec(&position)
+	if oc.TargetBounds != nil {
+		oc.translate(&math32.Vector3{})
+	}
 }
 
 // Pan pans the camera and target the specified amount on the plane perpendicular to the viewing direction.
 func (oc *OrbitControl) Pan(deltaX, deltaY float32) {
 
+	oc.beginChange()
+	defer oc.endChange()
 	// Compute direction vector from camera to target
 	position := oc.cam.Position()
-	vdir := oc.target.Clone().Sub(&position)
+	var vdir math32.Vector3
+	vdir.SubVectors(&oc.target, &position)
 
 	// Conversion constant between an on-screen cursor delta and its projection on the target plane
 	c := 2 * vdir.Length() * math32.Tan((oc.cam.Fov()/2.0)*math32.Pi/180.0) / oc.winSize()
 
 	// Calculate pan components, scale by the converted offsets and combine them
 	var pan, panX, panY math32.Vector3
-	panX.CrossVectors(&oc.up, vdir).Normalize()
-	panY.CrossVectors(vdir, &panX).Normalize()
+	panX.CrossVectors(&oc.up, &vdir).Normalize()
+	panY.CrossVectors(&vdir, &panX).Normalize()
 	panY.MultiplyScalar(c * deltaY)
 	panX.MultiplyScalar(c * deltaX)
 	pan.AddVectors(&panX, &panY)
 
 	// Add pan offset to camera and target
-	oc.cam.SetPositionVec(position.Add(&pan))
-	oc.target.Add(&pan)
+	oc.translate(&pan)
+}
+
+// translate moves the camera and the target by the specified offset,
+// keeping the target inside the target bounds. Returns the applied offset.
+func (oc *OrbitControl) translate(offset *math32.Vector3) math32.Vector3 {
+
+	applied := *offset
+	if b := oc.TargetBounds; b != nil {
+		target := oc.target
+		target.Add(offset).Clamp(&b.Min, &b.Max)
+		applied.SubVectors(&target, &oc.target)
+	}
+	position := oc.cam.Position()
+	oc.cam.SetPositionVec(position.Add(&applied))
+	oc.target.Add(&applied)
+	return applied
+}
+
+// SetPolarLimits sets the minimum and maximum polar angles in radians,
+// moving the camera inside the new limits if necessary.
+func (oc *OrbitControl) SetPolarLimits(min, max float32) {
+
+	oc.MinPolarAngle = min
+	oc.MaxPolarAngle = max
+	oc.Rotate(0, 0)
+}
+
+// SetAzimuthLimits sets the minimum and maximum azimuthal angles in radians,
+// moving the camera inside the new limits if necessary.
+func (oc *OrbitControl) SetAzimuthLimits(min, max float32) {
+
+	oc.MinAzimuthAngle = min
+	oc.MaxAzimuthAngle = max
+	oc.Rotate(0, 0)
+}
+
+// SetDistanceLimits sets the minimum and maximum distances from the target,
+// moving the camera inside the new limits if necessary.
+func (oc *OrbitControl) SetDistanceLimits(min, max float32) {
+
+	oc.MinDistance = min
+	oc.MaxDistance = max
+	oc.Zoom(0)
+}
+
+// SetTargetBounds sets the box the target is kept inside of by panning and zooming to the cursor,
+// or nil to not bound it, moving the camera and the target inside the new bounds if necessary.
+func (oc *OrbitControl) SetTargetBounds(bounds *math32.Box3) {
+
+	oc.beginChange()
+	defer oc.endChange()
+	oc.TargetBounds = bounds
+	oc.translate(&math32.Vector3{})
+}
+
+// StopInertia stops the rotation and pan which continue after the mouse is released.
+func (oc *OrbitControl) StopInertia() {
+
+	oc.rotDelta.Zero()
+	oc.panDelta.Zero()
+	oc.rotVel.Zero()
+	oc.panVel.Zero()
+}
+
+// FitToBox moves the camera and the target so that the bounding sphere of the specified box,
+// in world coordinates, fills the view with the specified margin added as a fraction of the box size.
+// The viewing direction is kept. If duration (in seconds) is positive the camera moves smoothly
+// and Update must be called every frame to advance the transition.
+func (oc *OrbitControl) FitToBox(box *math32.Box3, margin, duration float32) {
+
+	oc.moveTo(box, oc.cam.FitDistance(box, margin), oc.cam.fitSize(box, margin), duration)
+}
+
+// FrameBox moves the camera and the target so that the corners of the specified box, in world
+// coordinates, fill the view with FrameMargin, as Camera.FrameBox. The viewing direction is kept.
+// If duration (in seconds) is positive the camera moves smoothly and Update must be called
+// every frame to advance the transition.
+func (oc *OrbitControl) FrameBox(box *math32.Box3, duration float32) {
+
+	// The camera looks at the target, so its orientation is kept by the transition
+	dist, size := oc.cam.fitCorners(box, FrameMargin)
+	oc.moveTo(box, dist, size, duration)
+}
+
+// moveTo starts the transition which targets the center of the specified box from the
+// specified distance, clamped to the distance limits, with the specified orthographic size.
+func (oc *OrbitControl) moveTo(box *math32.Box3, dist, size, duration float32) {
+
+	var center math32.Vector3
+	box.Center(&center)
+	dist = math32.Max(oc.MinDistance, math32.Min(oc.MaxDistance, dist))
+
+	// Keep the current direction from the target to the camera
+	position := oc.cam.Position()
+	dir := position
+	dir.Sub(&oc.target)
+	if dir.Length() == 0 {
+		dir.Set(0, 0, 1)
+	}
+	dir.SetLength(dist)
+
+	t := &oc.trans
+	t.fromPos = position
+	t.fromTarget = oc.target
+	t.fromSize = oc.cam.Size()
+	t.toTarget = center
+	t.toPos = center
+	t.toPos.Add(&dir)
+	t.toSize = size
+	t.fromFov = oc.cam.Fov()
+	t.toFov = t.fromFov
+	t.elapsed = 0
+	t.duration = duration
+	t.active = true
+	if duration <= 0 {
+		oc.Update(0)
+	}
+}
+
+// FrameSelection moves the camera and the target so that the specified nodes
+// fill the view, as FitToBox. Graphics are framed using their world bounding boxes
+// and other nodes using their world positions.
+// Returns false, without moving the camera, if there is nothing to frame.
+func (oc *OrbitControl) FrameSelection(margin, duration float32, nodes ...core.INode) bool {
+
+	var box math32.Box3
+	empty := true
+	expand := func(b *math32.Box3) {
+		if empty {
+			box = *b
+			empty = false
+		} else {
+			box.Union(b)
+		}
+	}
+	for _, inode := range nodes {
+		inode.UpdateMatrixWorld()
+		graphics := graphic.FindGraphics(inode)
+		if len(graphics) == 0 {
+			var pos math32.Vector3
+			inode.GetNode().WorldPosition(&pos)
+			expand(&math32.Box3{Min: pos, Max: pos})
+			continue
+		}
+		for _, igr := range graphics {
+			bb := igr.GetGraphic().WorldBoundingBox()
+			expand(&bb)
+		}
+	}
+	if empty {
+		return false
+	}
+	oc.FitToBox(&box, margin, duration)
+	return true
+}
+
+// Update advances animated transitions, held keys, inertia and idle behaviors
+// by the specified elapsed time in seconds. It should be called every frame.
+func (oc *OrbitControl) Update(deltaTime float32) {
+
+	oc.beginChange()
+	defer oc.endChange()
+	t := &oc.trans
+	if !t.active {
+		oc.updateKeys(deltaTime)
+		oc.updateInertia(deltaTime)
+		oc.updateIdle(deltaTime)
+		return
+	}
+	t.elapsed += deltaTime
+	alpha := float32(1)
+	if t.duration > 0 && t.elapsed < t.duration {
+		// Smoothstep easing
+		x := t.elapsed / t.duration
+		alpha = x * x * (3 - 2*x)
+	} else {
+		t.active = false
+	}
+	pos := t.fromPos
+	pos.Lerp(&t.toPos, alpha)
+	oc.target = t.fromTarget
+	oc.target.Lerp(&t.toTarget, alpha)
+	oc.cam.SetPositionVec(&pos)
+	oc.cam.LookAt(&oc.target, &oc.up)
+	if oc.cam.Projection() == Orthographic {
+		oc.cam.SetSize(t.fromSize + (t.toSize-t.fromSize)*alpha)
+	} else if t.toFov != t.fromFov {
+		oc.cam.SetFov(t.fromFov + (t.toFov-t.fromFov)*alpha)
+	}
+}
+
+// updateInertia estimates the velocity of the rotation and pan while the mouse is dragged
+// and continues them with a decaying velocity after it is released.
+func (oc *OrbitControl) updateInertia(deltaTime float32) {
+
+	if oc.Damping <= 0 || deltaTime <= 0 {
+		oc.rotDelta.Zero()
+		oc.panDelta.Zero()
+		return
+	}
+
+	// While dragging, smooth the velocity over the last frames, as cursor
+	// events are not synchronized with frames
+	if oc.state == stateRotate || oc.state == statePan {
+		const smoothing = 0.5
+		oc.rotDelta.MultiplyScalar(1 / deltaTime)
+		oc.rotVel.Lerp(&oc.rotDelta, smoothing)
+		oc.panDelta.MultiplyScalar(1 / deltaTime)
+		oc.panVel.Lerp(&oc.panDelta, smoothing)
+		oc.rotDelta.Zero()
+		oc.panDelta.Zero()
+		return
+	}
+
+	// Stop when the motion is no longer noticeable
+	const minRotVel = 1e-3
+	position := oc.cam.Position()
+	minPanVel := 1e-3 * position.DistanceTo(&oc.target)
+	if oc.rotVel.Length() < minRotVel && oc.panVel.Length() < minPanVel {
+		oc.StopInertia()
+		return
+	}
+	oc.resetIdle()
+	oc.Rotate(oc.rotVel.X*deltaTime, oc.rotVel.Y*deltaTime)
+	offset := oc.panVel
+	oc.translate(offset.MultiplyScalar(deltaTime))
+	decay := math32.Exp(-oc.Damping * deltaTime)
+	oc.rotVel.MultiplyScalar(decay)
+	oc.panVel.MultiplyScalar(decay)
+}
+
+// updateIdle applies the auto rotation and the idle drift when there was no user input
+// for longer than the idle timeout.
+func (oc *OrbitControl) updateIdle(deltaTime float32) {
+
+	oc.idleTime += deltaTime
+	idle := oc.idleTime - oc.IdleTimeout
+	if idle <= 0 || oc.state != stateNone {
+		return
+	}
+	// Ease in during the first second
+	ease := math32.Min(idle, 1)
+	if oc.AutoRotate {
+		oc.Rotate(oc.AutoRotateSpeed*ease*deltaTime, 0)
+	}
+	if oc.IdleDrift > 0 && oc.IdleDriftPeriod > 0 {
+		oc.driftTime += deltaTime
+		drift := oc.IdleDrift * ease * math32.Sin(2*math32.Pi*oc.driftTime/oc.IdleDriftPeriod)
+		// Change the distance to the target by the difference since the last drift,
+		// keeping it within the distance limits as zooming does
+		tcam := oc.cam.Position()
+		tcam.Sub(&oc.target)
+		dist := tcam.Length() * (1 + drift) / (1 + oc.driftPrev)
+		dist = math32.Max(oc.MinDistance, math32.Min(oc.MaxDistance, dist))
+		tcam.SetLength(dist)
+		oc.cam.SetPositionVec(tcam.Add(&oc.target))
+		oc.driftPrev = drift
+	}
+}
+
+// beginChange starts a change of the camera state.
+func (oc *OrbitControl) beginChange() {
+
+	oc.notify.begin(oc.cam, &oc.target)
+}
+
+// endChange finishes a change of the camera state, dispatching
+// camera control events if it was the outermost one.
+func (oc *OrbitControl) endChange() {
+
+	oc.notify.end(oc, oc.cam, &oc.target)
+}
+
+// resetIdle restarts the idle timer. It is called on each user input.
+func (oc *OrbitControl) resetIdle() {
+
+	oc.idleTime = 0
+	oc.driftTime = 0
+	oc.driftPrev = 0
+}
+
+// Idle returns whether there was no user input for longer than the idle timeout.
+func (oc *OrbitControl) Idle() bool {
+
+	return oc.idleTime > oc.IdleTimeout
+}
+
+// Animating returns whether an animated transition is in progress.
+func (oc *OrbitControl) Animating() bool {
+
+	return oc.trans.active
+}
+
+// StopAnimation stops the current animated transition, leaving the camera where it is.
+func (oc *OrbitControl) StopAnimation() {
+
+	oc.trans.active = false
 }
 
 // onMouse is called when an OnMouseDown/OnMouseUp event is received.
@@ -210,25 +669,31 @@ func (oc *OrbitControl) onMouse(evname string, ev interface{}) {
 		return
 	}
 
+	oc.resetIdle()
 	switch evname {
 	case window.OnMouseDown:
+		oc.StopAnimation()
+		oc.StopInertia()
 		gui.Manager().SetCursorFocus(oc)
 		mev := ev.(*window.MouseEvent)
-		switch mev.Button {
-		case window.MouseButtonLeft: // Rotate
+		switch oc.mouseAction(mev.Button, mev.Mods) {
+		case OrbitMouseRotate:
 			if oc.enabled&OrbitRot != 0 {
 				oc.state = stateRotate
 				oc.rotStart.Set(mev.Xpos, mev.Ypos)
 			}
-		case window.MouseButtonMiddle: // Zoom
+		case OrbitMouseZoom:
 			if oc.enabled&OrbitZoom != 0 {
 				oc.state = stateZoom
 				oc.zoomStart = mev.Ypos
 			}
-		case window.MouseButtonRight: // Pan
+		case OrbitMousePan:
 			if oc.enabled&OrbitPan != 0 {
 				oc.state = statePan
 				oc.panStart.Set(mev.Xpos, mev.Ypos)
+				if oc.PanMode == OrbitPanCursor {
+					oc.startCursorPan(mev.Xpos, mev.Ypos)
+				}
 			}
 		}
 	case window.OnMouseUp:
@@ -245,19 +710,30 @@ func (oc *OrbitControl) onCursor(evname string, ev interface{}) {
 		return
 	}
 
+	oc.resetIdle()
 	mev := ev.(*window.CursorEvent)
 	switch oc.state {
 	case stateRotate:
 		c := -2 * math32.Pi * oc.RotSpeed / oc.winSize()
-		oc.Rotate(c*(mev.Xpos-oc.rotStart.X),
-			c*(mev.Ypos-oc.rotStart.Y))
+		thetaDelta := c * (mev.Xpos - oc.rotStart.X)
+		phiDelta := c * (mev.Ypos - oc.rotStart.Y)
+		oc.Rotate(thetaDelta, phiDelta)
+		oc.rotDelta.X += thetaDelta
+		oc.rotDelta.Y += phiDelta
 		oc.rotStart.Set(mev.Xpos, mev.Ypos)
 	case stateZoom:
 		oc.Zoom(oc.ZoomSpeed * (mev.Ypos - oc.zoomStart))
 		oc.zoomStart = mev.Ypos
 	case statePan:
-		oc.Pan(mev.Xpos-oc.panStart.X,
-			mev.Ypos-oc.panStart.Y)
+		if oc.PanMode == OrbitPanCursor {
+			oc.cursorPan(mev.Xpos, mev.Ypos)
+		} else {
+			target := oc.target
+			oc.Pan(mev.Xpos-oc.panStart.X,
+				mev.Ypos-oc.panStart.Y)
+			var offset math32.Vector3
+			oc.panDelta.Add(offset.SubVectors(&oc.target, &target))
+		}
 		oc.panStart.Set(mev.Xpos, mev.Ypos)
 	}
 }
@@ -266,52 +742,176 @@ func (oc *OrbitControl) onCursor(evname string, ev interface{}) {
 func (oc *OrbitControl) onScroll(evname string, ev interface{}) {
 
 	if oc.enabled&OrbitZoom != 0 {
+		oc.resetIdle()
+		oc.StopAnimation()
 		sev := ev.(*window.ScrollEvent)
-		oc.Zoom(-sev.Yoffset)
+		if oc.ZoomToCursor {
+			oc.ZoomAt(-sev.Yoffset, oc.cursorPoint())
+		} else {
+			oc.Zoom(-sev.Yoffset)
+		}
 	}
 }
 
-// onKey is called when an OnKeyDown/OnKeyRepeat event is received.
+// onKey is called when an OnKeyDown/OnKeyRepeat/OnKeyUp event is received.
 func (oc *OrbitControl) onKey(evname string, ev interface{}) {
 
+	kev := ev.(*window.KeyEvent)
+	if evname == window.OnKeyUp {
+		// Releases the held actions of the key, whatever the modifiers are now
+		for action, kb := range oc.Keys {
+			if (kb.Scancode != 0 && kb.Scancode == kev.Scancode) || (kb.Scancode == 0 && kb.Key == kev.Key) {
+				delete(oc.held, action)
+			}
+		}
+		return
+	}
+
 	// If keyboard control is disabled ignore event
 	if oc.enabled&OrbitKeys == 0 {
 		return
 	}
+	oc.resetIdle()
+	action := oc.keyAction(kev)
+	if !oc.ContinuousKeys {
+		oc.keyStep(action, 1)
+	} else if action != "" {
+		oc.held[action] = true
+	}
+}
 
-	kev := ev.(*window.KeyEvent)
-	if kev.Mods == 0 && oc.enabled&OrbitRot != 0 {
-		switch kev.Key {
-		case window.KeyUp:
-			oc.Rotate(0, -oc.KeyRotSpeed)
-		case window.KeyDown:
-			oc.Rotate(0, oc.KeyRotSpeed)
-		case window.KeyLeft:
-			oc.Rotate(-oc.KeyRotSpeed, 0)
-		case window.KeyRight:
-			oc.Rotate(oc.KeyRotSpeed, 0)
-		}
+// updateKeys moves the camera by the key actions held during the specified elapsed time in seconds.
+func (oc *OrbitControl) updateKeys(deltaTime float32) {
+
+	if len(oc.held) == 0 || oc.enabled&OrbitKeys == 0 {
+		return
 	}
-	if kev.Mods == window.ModControl && oc.enabled&OrbitZoom != 0 {
-		switch kev.Key {
-		case window.KeyUp:
-			oc.Zoom(-oc.KeyZoomSpeed)
-		case window.KeyDown:
-			oc.Zoom(oc.KeyZoomSpeed)
-		}
+	oc.resetIdle()
+	for action := range oc.held {
+		oc.keyStep(action, deltaTime)
 	}
-	if kev.Mods == window.ModShift && oc.enabled&OrbitPan != 0 {
-		switch kev.Key {
-		case window.KeyUp:
-			oc.Pan(0, oc.KeyPanSpeed)
-		case window.KeyDown:
-			oc.Pan(0, -oc.KeyPanSpeed)
-		case window.KeyLeft:
-			oc.Pan(oc.KeyPanSpeed, 0)
-		case window.KeyRight:
-			oc.Pan(-oc.KeyPanSpeed, 0)
+}
+
+// keyStep applies the specified key action with its key speed multiplied by the specified scale.
+func (oc *OrbitControl) keyStep(action string, scale float32) {
+
+	rot := oc.enabled&OrbitRot != 0
+	zoom := oc.enabled&OrbitZoom != 0
+	pan := oc.enabled&OrbitPan != 0
+	rotDelta := oc.KeyRotSpeed * scale
+	zoomDelta := oc.KeyZoomSpeed * scale
+	panDelta := oc.KeyPanSpeed * scale
+	switch action {
+	case OrbitKeyRotateUp:
+		if rot {
+			oc.Rotate(0, -rotDelta)
 		}
+	case OrbitKeyRotateDown:
+		if rot {
+			oc.Rotate(0, rotDelta)
+		}
+	case OrbitKeyRotateLeft:
+		if rot {
+			oc.Rotate(-rotDelta, 0)
+		}
+	case OrbitKeyRotateRight:
+		if rot {
+			oc.Rotate(rotDelta, 0)
+		}
+	case OrbitKeyZoomIn:
+		if zoom {
+			oc.Zoom(-zoomDelta)
+		}
+	case OrbitKeyZoomOut:
+		if zoom {
+			oc.Zoom(zoomDelta)
+		}
+	case OrbitKeyPanUp:
+		if pan {
+			oc.Pan(0, panDelta)
+		}
+	case OrbitKeyPanDown:
+		if pan {
+			oc.Pan(0, -panDelta)
+		}
+	case OrbitKeyPanLeft:
+		if pan {
+			oc.Pan(panDelta, 0)
+		}
+	case OrbitKeyPanRight:
+		if pan {
+			oc.Pan(-panDelta, 0)
+		}
+	}
+}
+
+// cursorRay sets the specified ray to the ray from the camera through the specified cursor position.
+func (oc *OrbitControl) cursorRay(xpos, ypos float32, ray *math32.Ray) {
+
+	width, height := window.Get().GetFramebufferSize()
+	x := 2*xpos/float32(width) - 1
+	y := 1 - 2*ypos/float32(height)
+	oc.cam.UnprojectRay(x, y, ray)
+}
+
+// onCursorPos is called when the window dispatches an OnCursor event and keeps track of the cursor position.
+func (oc *OrbitControl) onCursorPos(evname string, ev interface{}) {
+
+	cev := ev.(*window.CursorEvent)
+	oc.cursorPos.Set(cev.Xpos, cev.Ypos)
+}
+
+// cursorPoint returns the 3D point under the last known cursor position.
+// It uses the CursorPicker if set, otherwise the focus plane through the target.
+func (oc *OrbitControl) cursorPoint() *math32.Vector3 {
+
+	var ray math32.Ray
+	var point math32.Vector3
+	oc.cursorRay(oc.cursorPos.X, oc.cursorPos.Y, &ray)
+	if oc.CursorPicker != nil && oc.CursorPicker(&ray, &point) {
+		return &point
+	}
+	position := oc.cam.Position()
+	var normal math32.Vector3
+	normal.SubVectors(&oc.target, &position).Normalize()
+	var plane math32.Plane
+	plane.SetFromNormalAndCoplanarPoint(&normal, &oc.target)
+	if ray.IntersectPlane(&plane, &point) == nil {
+		point = oc.target
+	}
+	return &point
+}
+
+// startCursorPan sets up the focus plane, which passes through the target perpendicular to the
+// viewing direction, and grabs the point of the plane under the specified cursor position.
+func (oc *OrbitControl) startCursorPan(xpos, ypos float32) {
+
+	position := oc.cam.Position()
+	var normal math32.Vector3
+	normal.SubVectors(&oc.target, &position).Normalize()
+	oc.panPlane.SetFromNormalAndCoplanarPoint(&normal, &oc.target)
+	var ray math32.Ray
+	oc.cursorRay(xpos, ypos, &ray)
+	if ray.IntersectPlane(&oc.panPlane, &oc.panGrab) == nil {
+		oc.panGrab = oc.target
+	}
+}
+
+// cursorPan pans the camera and target so that the grabbed point is under the specified cursor position.
+func (oc *OrbitControl) cursorPan(xpos, ypos float32) {
+
+	oc.beginChange()
+	defer oc.endChange()
+	var ray math32.Ray
+	var point math32.Vector3
+	oc.cursorRay(xpos, ypos, &ray)
+	if ray.IntersectPlane(&oc.panPlane, &point) == nil {
+		return
 	}
+	var offset math32.Vector3
+	offset.SubVectors(&oc.panGrab, &point)
+	offset = oc.translate(&offset)
+	oc.panDelta.Add(&offset)
 }
 
 // winSize returns the window height or width based on the camera reference axis.
@@ -0,0 +1,135 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/g3n/engine/math32"
+)
+
+// CameraPose is a snapshot of the view of an OrbitControl, which can be saved as a named
+// bookmark and restored later, for example to jump between saved views with number keys.
+// The orientation of the camera is given by its position, target and up direction.
+type CameraPose struct {
+	Position math32.Vector3 `json:"position"` // Camera position
+	Target   math32.Vector3 `json:"target"`   // Orbit target
+	Up       math32.Vector3 `json:"up"`       // Orbit axis
+	Fov      float32        `json:"fov"`      // Perspective field of view in degrees
+	Size     float32        `json:"size"`     // Orthographic size
+}
+
+// Pose returns the current pose of the control and its camera.
+func (oc *OrbitControl) Pose() CameraPose {
+
+	return CameraPose{
+		Position: oc.cam.Position(),
+		Target:   oc.target,
+		Up:       oc.up,
+		Fov:      oc.cam.Fov(),
+		Size:     oc.cam.Size(),
+	}
+}
+
+// SetPose moves the control and its camera to the specified pose. If duration (in seconds)
+// is positive the camera moves smoothly, as in FitToBox, and Update must be called every
+// frame to advance the transition. The up direction changes immediately.
+func (oc *OrbitControl) SetPose(pose *CameraPose, duration float32) {
+
+	if pose.Up.LengthSq() > 0 {
+		oc.up = pose.Up
+	}
+	t := &oc.trans
+	t.fromPos = oc.cam.Position()
+	t.fromTarget = oc.target
+	t.fromSize = oc.cam.Size()
+	t.fromFov = oc.cam.Fov()
+	t.toPos = pose.Position
+	t.toTarget = pose.Target
+	t.toSize = pose.Size
+	t.toFov = pose.Fov
+	t.elapsed = 0
+	t.duration = duration
+	t.active = true
+	if duration <= 0 {
+		oc.Update(0)
+	}
+}
+
+// SavePose saves the current pose as a bookmark with the specified name,
+// replacing any previous bookmark with the same name, and returns it.
+func (oc *OrbitControl) SavePose(name string) CameraPose {
+
+	if oc.poses == nil {
+		oc.poses = make(map[string]CameraPose)
+	}
+	pose := oc.Pose()
+	oc.poses[name] = pose
+	return pose
+}
+
+// RestorePose moves the camera to the bookmark with the specified name as in SetPose.
+// Returns false, without moving the camera, if there is no such bookmark.
+func (oc *OrbitControl) RestorePose(name string, duration float32) bool {
+
+	pose, ok := oc.poses[name]
+	if !ok {
+		return false
+	}
+	oc.SetPose(&pose, duration)
+	return true
+}
+
+// DeletePose removes the bookmark with the specified name.
+func (oc *OrbitControl) DeletePose(name string) {
+
+	delete(oc.poses, name)
+}
+
+// SavedPose returns the bookmark with the specified name and whether it exists.
+func (oc *OrbitControl) SavedPose(name string) (CameraPose, bool) {
+
+	pose, ok := oc.poses[name]
+	return pose, ok
+}
+
+// PoseNames returns the sorted names of the saved bookmarks.
+func (oc *OrbitControl) PoseNames() []string {
+
+	names := make([]string, 0, len(oc.poses))
+	for name := range oc.poses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarshalPoses returns the JSON encoding of the saved bookmarks, to be stored with a project.
+func (oc *OrbitControl) MarshalPoses() ([]byte, error) {
+
+	poses := oc.poses
+	if poses == nil {
+		poses = map[string]CameraPose{}
+	}
+	return json.MarshalIndent(poses, "", "  ")
+}
+
+// UnmarshalPoses decodes the specified JSON encoded bookmarks and adds them to the saved bookmarks,
+// replacing the ones with the same names.
+func (oc *OrbitControl) UnmarshalPoses(data []byte) error {
+
+	var poses map[string]CameraPose
+	if err := json.Unmarshal(data, &poses); err != nil {
+		return err
+	}
+	if oc.poses == nil {
+		oc.poses = make(map[string]CameraPose)
+	}
+	for name, pose := range poses {
+		oc.poses[name] = pose
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"sort"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// Path control events.
+const (
+	OnPathWaypoint = "camera.OnPathWaypoint" // Camera reached a waypoint of the path. Data is *PathEvent
+	OnPathEnd      = "camera.OnPathEnd"      // Camera reached the end of the path and stopped. Data is *PathEvent
+)
+
+// PathEvent describes a waypoint reached by a PathControl.
+type PathEvent struct {
+	Waypoint int     // Index of the waypoint, which is a knot of the spline
+	Distance float32 // Distance of the waypoint along the path
+}
+
+// LookKey is a key of the point looked at by a PathControl.
+// Keys are positioned along the path in waypoints, so 1.5 is halfway, in curve parameter,
+// between the second and third waypoints.
+type LookKey struct {
+	At     float32           // Position of the key along the path in waypoints
+	Target math32.Vector3    // Point looked at, in world coordinates
+	Node   core.INode        // Node whose world position is looked at instead of Target, if not nil
+	Ease   math32.EasingFunc // Easing of the transition from the previous key (default is smoothstep)
+}
+
+// SpeedKey is a key of the speed of a PathControl, positioned along the path in waypoints.
+type SpeedKey struct {
+	At    float32           // Position of the key along the path in waypoints
+	Speed float32           // Speed in units per second
+	Ease  math32.EasingFunc // Easing of the transition from the previous key (default is smoothstep)
+}
+
+// PathControl moves a camera along a spline, for cutscenes and fly-throughs.
+// The camera looks at points given by look keys, or along the path if there are none,
+// and moves either at the speed given by speed keys or over a fixed duration with easing.
+// Waypoints are the knots of the spline and an OnPathWaypoint event is dispatched each
+// time the camera reaches one. It satisfies the IControl interface; the control does not
+// use user input, so it is updated whether it is active or not.
+// The camera is expected to be in world space, such as a child of the scene root.
+type PathControl struct {
+	core.Dispatcher                // Embedded event dispatcher
+	cam             *Camera        // Controlled camera
+	path            *math32.Spline // Path of the camera
+	active          bool           // Whether the control is active
+	Speed           float32        // Speed in units per second without speed keys (default is 5)
+	LookAhead       float32        // Distance ahead along the path looked at without look keys (default is 1)
+	Loop            bool           // Whether the camera goes back to the start of the path at its end
+	Up              math32.Vector3 // Up direction of the camera (default is the engine up direction)
+
+	lookKeys  []LookKey         // Keys of the point looked at, sorted
+	speedKeys []SpeedKey        // Keys of the speed, sorted
+	duration  float32           // Duration of a timed playback, or 0 to use the speed
+	ease      math32.EasingFunc // Easing of a timed playback
+	playing   bool              // Whether the camera is moving
+	time      float32           // Elapsed time of a timed playback
+	distance  float32           // Current distance along the path
+	look      math32.Vector3    // Current point looked at
+	notify    changeNotifier    // Dispatches camera control events
+}
+
+// NewPathControl creates and returns a pointer to a new path control for the specified
+// camera and path. The camera is placed at the start of the path and is not playing.
+func NewPathControl(cam *Camera, path *math32.Spline) *PathControl {
+
+	pc := new(PathControl)
+	pc.Dispatcher.Initialize()
+	pc.cam = cam
+	pc.path = path
+	pc.active = true
+	pc.Speed = 5
+	pc.LookAhead = 1
+	pc.Up = core.EngineCoords().UpVector()
+	pc.apply()
+	return pc
+}
+
+// SetPath sets the path of the camera and moves the camera to its start.
+func (pc *PathControl) SetPath(path *math32.Spline) {
+
+	pc.path = path
+	pc.Seek(0)
+}
+
+// Path returns the path of the camera.
+func (pc *PathControl) Path() *math32.Spline {
+
+	return pc.path
+}
+
+// SetLookKeys sets the keys of the point looked at, which are sorted by their position.
+// Without keys the camera looks along the path.
+func (pc *PathControl) SetLookKeys(keys []LookKey) {
+
+	pc.lookKeys = append(pc.lookKeys[:0:0], keys...)
+	sort.SliceStable(pc.lookKeys, func(i, j int) bool { return pc.lookKeys[i].At < pc.lookKeys[j].At })
+}
+
+// LookKeys returns the keys of the point looked at.
+func (pc *PathControl) LookKeys() []LookKey {
+
+	return pc.lookKeys
+}
+
+// SetSpeedKeys sets the keys of the speed, which are sorted by their position.
+// Without keys the camera moves at Speed. Keys are ignored during a timed playback.
+func (pc *PathControl) SetSpeedKeys(keys []SpeedKey) {
+
+	pc.speedKeys = append(pc.speedKeys[:0:0], keys...)
+	sort.SliceStable(pc.speedKeys, func(i, j int) bool { return pc.speedKeys[i].At < pc.speedKeys[j].At })
+}
+
+// SpeedKeys returns the keys of the speed.
+func (pc *PathControl) SpeedKeys() []SpeedKey {
+
+	return pc.speedKeys
+}
+
+// SetDuration sets a timed playback, which covers the whole path in the specified number
+// of seconds with the specified easing (nil is linear), instead of following the speed.
+// A zero duration goes back to following the speed.
+func (pc *PathControl) SetDuration(duration float32, ease math32.EasingFunc) {
+
+	pc.duration = duration
+	pc.ease = ease
+	pc.time = pc.timeAt(pc.distance)
+}
+
+// Duration returns the duration of a timed playback, or 0 if the camera follows the speed.
+func (pc *PathControl) Duration() float32 {
+
+	return pc.duration
+}
+
+// Play starts or resumes moving the camera. At the end of a path which is not looped,
+// it restarts from the beginning.
+func (pc *PathControl) Play() {
+
+	if !pc.Loop && pc.distance >= pc.path.Length() {
+		pc.Seek(0)
+	}
+	pc.playing = true
+}
+
+// Pause stops moving the camera, keeping its position along the path.
+func (pc *PathControl) Pause() {
+
+	pc.playing = false
+}
+
+// Stop stops moving the camera and moves it back to the start of the path.
+func (pc *PathControl) Stop() {
+
+	pc.playing = false
+	pc.Seek(0)
+}
+
+// Playing returns whether the camera is moving.
+func (pc *PathControl) Playing() bool {
+
+	return pc.playing
+}
+
+// Seek moves the camera to the specified distance along the path without dispatching
+// waypoint events.
+func (pc *PathControl) Seek(distance float32) {
+
+	pc.distance = math32.Clamp(distance, 0, pc.path.Length())
+	pc.time = pc.timeAt(pc.distance)
+	pc.apply()
+}
+
+// SeekWaypoint moves the camera to the waypoint with the specified index.
+func (pc *PathControl) SeekWaypoint(index int) {
+
+	pc.Seek(pc.waypointDistance(index))
+}
+
+// Distance returns the current distance of the camera along the path.
+func (pc *PathControl) Distance() float32 {
+
+	return pc.distance
+}
+
+// Progress returns the current distance of the camera along the path over its length.
+func (pc *PathControl) Progress() float32 {
+
+	length := pc.path.Length()
+	if length == 0 {
+		return 0
+	}
+	return pc.distance / length
+}
+
+// LookPoint returns the point the camera looks at, in world coordinates.
+func (pc *PathControl) LookPoint() math32.Vector3 {
+
+	return pc.look
+}
+
+// Active returns whether the control is active.
+func (pc *PathControl) Active() bool {
+
+	return pc.active
+}
+
+// SetActive sets whether the control is active. The control has no user input to
+// subscribe to, so it only records the state for the ControlManager.
+func (pc *PathControl) SetActive(active bool) {
+
+	pc.active = active
+}
+
+// Dispose satisfies the IControl interface. The control holds no resources.
+func (pc *PathControl) Dispose() {
+
+	pc.SetActive(false)
+}
+
+// Update moves the camera along the path for the specified elapsed time in seconds
+// if it is playing, and updates the point looked at. It should be called every frame.
+func (pc *PathControl) Update(deltaTime float32) {
+
+	length := pc.path.Length()
+	if !pc.playing || length == 0 {
+		pc.apply()
+		return
+	}
+
+	prev := pc.distance
+	wrapped := false
+	ended := false
+	if pc.duration > 0 {
+		pc.time += deltaTime
+		if pc.time >= pc.duration {
+			if pc.Loop {
+				pc.time = math32.Mod(pc.time, pc.duration)
+				wrapped = true
+			} else {
+				pc.time = pc.duration
+				ended = true
+			}
+		}
+		x := pc.time / pc.duration
+		if pc.ease != nil {
+			x = pc.ease(x)
+		}
+		pc.distance = math32.Clamp(x, 0, 1) * length
+	} else {
+		pc.distance += math32.Max(pc.speedAt(pc.distance), 0) * deltaTime
+		if pc.distance >= length {
+			if pc.Loop {
+				pc.distance = math32.Mod(pc.distance, length)
+				wrapped = true
+			} else {
+				pc.distance = length
+				ended = true
+			}
+		}
+	}
+
+	// Waypoints crossed since the last update, including the end of a wrapped path
+	if wrapped {
+		pc.dispatchWaypoints(prev, length, false)
+		pc.dispatchWaypoints(0, pc.distance, true)
+	} else {
+		pc.dispatchWaypoints(prev, pc.distance, prev == 0 && pc.distance > 0)
+	}
+	pc.apply()
+	if ended {
+		pc.playing = false
+		last := pc.path.Knots() - 1
+		pc.Dispatch(OnPathEnd, &PathEvent{Waypoint: last, Distance: length})
+	}
+}
+
+// apply places the camera at the current distance along the path, looking at the current look point.
+func (pc *PathControl) apply() {
+
+	if pc.path.Segments() == 0 {
+		return
+	}
+	pc.notify.begin(pc.cam, &pc.look)
+	defer pc.notify.end(pc, pc.cam, &pc.look)
+
+	var pos math32.Vector3
+	t := pc.path.ParamAtDistance(pc.distance)
+	pc.path.Point(t, &pos)
+	pc.lookAt(t, &pos, &pc.look)
+
+	var m math32.Matrix4
+	var rot math32.Quaternion
+	m.LookAt(&pos, &pc.look, &pc.Up)
+	rot.SetFromRotationMatrix(&m)
+	pc.cam.SetPositionVec(&pos)
+	pc.cam.SetQuaternionQuat(&rot)
+}
+
+// lookAt sets the point looked at from the specified position at the specified curve parameter.
+func (pc *PathControl) lookAt(t float32, pos, look *math32.Vector3) {
+
+	if len(pc.lookKeys) == 0 {
+		var dir math32.Vector3
+		pc.path.Tangent(t, &dir)
+		look.Copy(&dir).MultiplyScalar(math32.Max(pc.LookAhead, 1e-3)).Add(pos)
+		return
+	}
+	k0, k1, alpha := pc.lookKeySpan(t * float32(pc.path.Segments()))
+	var a, b math32.Vector3
+	keyTarget(k0, &a)
+	keyTarget(k1, &b)
+	look.Copy(&a).Lerp(&b, alpha)
+}
+
+// lookKeySpan returns the look keys around the specified position in waypoints and the eased
+// interpolation factor between them.
+func (pc *PathControl) lookKeySpan(at float32) (*LookKey, *LookKey, float32) {
+
+	keys := pc.lookKeys
+	i := sort.Search(len(keys), func(i int) bool { return keys[i].At > at })
+	if i == 0 {
+		return &keys[0], &keys[0], 0
+	}
+	if i == len(keys) {
+		return &keys[i-1], &keys[i-1], 0
+	}
+	k0, k1 := &keys[i-1], &keys[i]
+	return k0, k1, easeSpan(k0.At, k1.At, at, k1.Ease)
+}
+
+// speedAt returns the speed at the specified distance along the path.
+func (pc *PathControl) speedAt(distance float32) float32 {
+
+	keys := pc.speedKeys
+	if len(keys) == 0 {
+		return pc.Speed
+	}
+	at := pc.path.ParamAtDistance(distance) * float32(pc.path.Segments())
+	i := sort.Search(len(keys), func(i int) bool { return keys[i].At > at })
+	if i == 0 {
+		return keys[0].Speed
+	}
+	if i == len(keys) {
+		return keys[i-1].Speed
+	}
+	k0, k1 := &keys[i-1], &keys[i]
+	return k0.Speed + (k1.Speed-k0.Speed)*easeSpan(k0.At, k1.At, at, k1.Ease)
+}
+
+// timeAt returns the time of a timed playback at the specified distance along the path,
+// inverting its easing by bisection.
+func (pc *PathControl) timeAt(distance float32) float32 {
+
+	length := pc.path.Length()
+	if pc.duration <= 0 || length == 0 {
+		return 0
+	}
+	x := distance / length
+	if pc.ease == nil {
+		return x * pc.duration
+	}
+	lo, hi := float32(0), float32(1)
+	for i := 0; i < 24; i++ {
+		mid := (lo + hi) / 2
+		if pc.ease(mid) < x {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2 * pc.duration
+}
+
+// dispatchWaypoints dispatches an OnPathWaypoint event for each waypoint after the distance
+// from, or at it if inclusive is true, and up to the distance to.
+func (pc *PathControl) dispatchWaypoints(from, to float32, inclusive bool) {
+
+	for i := 0; i < pc.path.Knots(); i++ {
+		d := pc.waypointDistance(i)
+		if (d > from || (inclusive && d == from)) && d <= to {
+			pc.Dispatch(OnPathWaypoint, &PathEvent{Waypoint: i, Distance: d})
+		}
+	}
+}
+
+// waypointDistance returns the distance along the path of the waypoint with the specified index.
+func (pc *PathControl) waypointDistance(index int) float32 {
+
+	return pc.path.DistanceAtParam(pc.path.KnotParam(index))
+}
+
+// keyTarget sets the world position looked at by the specified key.
+func keyTarget(k *LookKey, target *math32.Vector3) {
+
+	if k.Node == nil {
+		*target = k.Target
+		return
+	}
+	k.Node.UpdateMatrixWorld()
+	k.Node.GetNode().WorldPosition(target)
+}
+
+// easeSpan returns the eased interpolation factor of a position between two key positions.
+func easeSpan(from, to, at float32, ease math32.EasingFunc) float32 {
+
+	if to <= from {
+		return 1
+	}
+	x := math32.Clamp((at-from)/(to-from), 0, 1)
+	if ease == nil {
+		return math32.EaseSmoothstep(x)
+	}
+	return ease(x)
+}
@@ -0,0 +1,179 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// ShakeType is the kind of motion of a camera shake.
+type ShakeType int
+
+// The kinds of motion of a camera shake.
+const (
+	ShakePerlin     = ShakeType(iota) // Smooth random motion, for rumbles and explosions
+	ShakeDampedSine                   // Oscillation which fades with the trauma, for recoils and impacts
+)
+
+// Shake is a trauma based camera shake.
+// Trauma, between 0 and 1, is added by gameplay events and decays over time.
+// The shake intensity is the square of the trauma, and the rotation and translation
+// offsets are driven either by Perlin noise, so the motion is smooth and never repeats,
+// or by sine waves, so the camera oscillates with an amplitude decaying with the trauma.
+//
+// Shake can be used with any camera or control: call Update every frame, then Apply
+// to offset the camera before rendering and Restore afterwards, so controls which keep
+// their state in the camera transform are not affected.
+type Shake struct {
+	Type      ShakeType      // Kind of motion (default is ShakePerlin)
+	Decay     float32        // Trauma lost per second (default is 1)
+	MaxAngle  math32.Vector3 // Maximum pitch, yaw and roll offsets in radians (default is 0.1, 0.1, 0.05)
+	MaxOffset math32.Vector3 // Maximum translation offsets in local coordinates (default is zero)
+	Frequency float32        // Frequency of the noise or of the oscillation in Hz (default is 15)
+	Seed      uint32         // Noise seed, which also sets the phases of the oscillation
+
+	trauma  float32           // Current trauma
+	time    float32           // Noise time in seconds
+	offPos  math32.Vector3    // Current translation offset
+	offRot  math32.Quaternion // Current rotation offset
+	node    core.INode        // Node the offsets are applied to, if any
+	savePos math32.Vector3    // Node position before Apply
+	saveRot math32.Quaternion // Node rotation before Apply
+}
+
+// ShakeImpulse is a source of shake, such as an explosion, whose effect decreases with distance.
+type ShakeImpulse struct {
+	Position math32.Vector3 // Position of the source in world coordinates
+	Trauma   float32        // Trauma added at the source position
+	Radius   float32        // Distance at which the source adds no trauma
+}
+
+// NewShake creates and returns a pointer to a new camera shake with default parameters.
+func NewShake() *Shake {
+
+	s := new(Shake)
+	s.Decay = 1
+	s.MaxAngle.Set(0.1, 0.1, 0.05)
+	s.Frequency = 15
+	s.offRot.Set(0, 0, 0, 1)
+	return s
+}
+
+// Trauma returns the current trauma.
+func (s *Shake) Trauma() float32 {
+
+	return s.trauma
+}
+
+// SetTrauma sets the current trauma, clamped between 0 and 1.
+func (s *Shake) SetTrauma(trauma float32) {
+
+	s.trauma = math32.Clamp(trauma, 0, 1)
+}
+
+// AddTrauma adds the specified amount to the current trauma, clamped between 0 and 1.
+// An oscillation starts over when trauma is added to a camera at rest.
+func (s *Shake) AddTrauma(amount float32) {
+
+	if s.Type == ShakeDampedSine && s.trauma == 0 {
+		s.time = 0
+	}
+	s.SetTrauma(s.trauma + amount)
+}
+
+// Impulse adds the trauma caused by the specified source
+// to a listener at the specified position in world coordinates.
+// The trauma falls off quadratically with the distance to the source.
+func (s *Shake) Impulse(src *ShakeImpulse, listener *math32.Vector3) {
+
+	s.AddTrauma(src.TraumaAt(listener))
+}
+
+// TraumaAt returns the trauma the source causes at the specified position in world coordinates.
+func (src *ShakeImpulse) TraumaAt(pos *math32.Vector3) float32 {
+
+	if src.Radius <= 0 {
+		return src.Trauma
+	}
+	f := 1 - src.Position.DistanceTo(pos)/src.Radius
+	if f <= 0 {
+		return 0
+	}
+	return src.Trauma * f * f
+}
+
+// Update decays the trauma and computes the offsets for the specified elapsed time in seconds.
+// It should be called every frame.
+func (s *Shake) Update(deltaTime float32) {
+
+	s.trauma = math32.Max(0, s.trauma-s.Decay*deltaTime)
+	s.time += deltaTime
+	shake := s.trauma * s.trauma
+	if shake == 0 {
+		s.offPos.Zero()
+		s.offRot.Set(0, 0, 0, 1)
+		return
+	}
+	var wave [6]float32
+	t := s.time * s.Frequency
+	for i := range wave {
+		if s.Type == ShakeDampedSine {
+			// Phases spread by the golden angle so the axes do not move in step
+			wave[i] = math32.Sin(2*math32.Pi*t + float32(s.Seed+uint32(i))*2.39996)
+		} else {
+			wave[i] = math32.Noise1(t, s.Seed+uint32(i))
+		}
+	}
+	euler := math32.Vector3{
+		X: s.MaxAngle.X * shake * wave[0],
+		Y: s.MaxAngle.Y * shake * wave[1],
+		Z: s.MaxAngle.Z * shake * wave[2],
+	}
+	s.offRot.SetFromEuler(&euler)
+	s.offPos.Set(
+		s.MaxOffset.X*shake*wave[3],
+		s.MaxOffset.Y*shake*wave[4],
+		s.MaxOffset.Z*shake*wave[5],
+	)
+}
+
+// Offsets returns the current translation offset, in local coordinates, and rotation offset.
+func (s *Shake) Offsets() (math32.Vector3, math32.Quaternion) {
+
+	return s.offPos, s.offRot
+}
+
+// Apply adds the current offsets to the transform of the specified node,
+// usually a camera. Restore must be called before the next Apply.
+func (s *Shake) Apply(inode core.INode) {
+
+	n := inode.GetNode()
+	s.node = inode
+	s.savePos = n.Position()
+	s.saveRot = n.Quaternion()
+
+	// Translation offset is relative to the node orientation
+	off := s.offPos
+	off.ApplyQuaternion(&s.saveRot)
+	pos := s.savePos
+	pos.Add(&off)
+	rot := s.saveRot
+	rot.Multiply(&s.offRot)
+	n.SetPositionVec(&pos)
+	n.SetQuaternionQuat(&rot)
+}
+
+// Restore removes the offsets added by the last Apply.
+func (s *Shake) Restore() {
+
+	if s.node == nil {
+		return
+	}
+	n := s.node.GetNode()
+	n.SetPositionVec(&s.savePos)
+	n.SetQuaternionQuat(&s.saveRot)
+	s.node = nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// Eye identifies an eye of a stereo rig.
+type Eye int
+
+// The eyes of a stereo rig.
+const (
+	LeftEye = Eye(iota)
+	RightEye
+)
+
+// DefaultIPD is the average interpupillary distance of adults in meters.
+const DefaultIPD = 0.063
+
+// StereoRig derives the cameras of the left and right eyes from a center camera,
+// which is moved and set up as usual, for stereoscopic displays and VR headsets.
+// Each eye is offset by half the interpupillary distance along the X axis of the
+// center camera and looks in the same direction. The eye frustums are asymmetric so that
+// both views match on the plane at the convergence distance, where objects appear at the
+// depth of the screen, which avoids the vertical parallax of cameras turned inward.
+type StereoRig struct {
+	IPD         float32 // Interpupillary distance in scene units
+	Convergence float32 // Distance from the camera to the plane of zero parallax in scene units
+	cam         *Camera
+	eyes        [2]StereoEye
+}
+
+// StereoEye is the camera of an eye of a stereo rig. Its matrices are derived from
+// the center camera of the rig each time they are requested.
+type StereoEye struct {
+	rig *StereoRig
+	eye Eye
+}
+
+// NewStereoRig creates and returns a pointer to a new stereo rig for the specified camera,
+// with the average interpupillary distance and a convergence distance of 2 meters
+// in the units of the engine coordinate system.
+func NewStereoRig(cam *Camera) *StereoRig {
+
+	rig := new(StereoRig)
+	rig.cam = cam
+	coords := core.EngineCoords()
+	rig.IPD = coords.Meters(DefaultIPD)
+	rig.Convergence = coords.Meters(2)
+	rig.eyes[LeftEye] = StereoEye{rig: rig, eye: LeftEye}
+	rig.eyes[RightEye] = StereoEye{rig: rig, eye: RightEye}
+	return rig
+}
+
+// Camera returns the center camera of the rig.
+func (rig *StereoRig) Camera() *Camera {
+
+	return rig.cam
+}
+
+// Eye returns the camera of the specified eye.
+func (rig *StereoRig) Eye(eye Eye) *StereoEye {
+
+	return &rig.eyes[eye]
+}
+
+// EyeOffset returns the offset of the specified eye along the X axis of the center camera.
+func (rig *StereoRig) EyeOffset(eye Eye) float32 {
+
+	if eye == LeftEye {
+		return -rig.IPD / 2
+	}
+	return rig.IPD / 2
+}
+
+// EyePosition sets the specified vector to the world position of the specified eye,
+// for example to place an audio listener or cast rays from it.
+func (rig *StereoRig) EyePosition(eye Eye, pos *math32.Vector3) {
+
+	rig.cam.UpdateMatrixWorld()
+	matrixWorld := rig.cam.MatrixWorld()
+	pos.Set(rig.EyeOffset(eye), 0, 0)
+	pos.ApplyMatrix4(&matrixWorld)
+}
+
+// Eye returns which eye this camera is.
+func (se *StereoEye) Eye() Eye {
+
+	return se.eye
+}
+
+// Rig returns the stereo rig of this eye.
+func (se *StereoEye) Rig() *StereoRig {
+
+	return se.rig
+}
+
+// ViewMatrix returns the view matrix of the eye.
+func (se *StereoEye) ViewMatrix(m *math32.Matrix4) {
+
+	se.rig.cam.ViewMatrix(m)
+	var offset math32.Matrix4
+	offset.MakeTranslation(-se.rig.EyeOffset(se.eye), 0, 0)
+	m.MultiplyMatrices(&offset, m)
+}
+
+// ProjMatrix returns the projection matrix of the eye.
+// Orthographic center cameras have no parallax, so both eyes use their projection.
+func (se *StereoEye) ProjMatrix(m *math32.Matrix4) {
+
+	c := se.rig.cam
+	if c.proj == Orthographic || se.rig.Convergence <= 0 {
+		c.ProjMatrix(m)
+		return
+	}
+	top := c.near * math32.Tan(c.fov*math32.Pi/360)
+	right := top * c.aspect
+	// Shifts the frustum of the eye so the views of both eyes match at the convergence distance
+	shift := se.rig.EyeOffset(se.eye) * c.near / se.rig.Convergence
+	m.MakeFrustum(-right-shift, right-shift, -top, top, c.near, c.far)
+}
@@ -0,0 +1,140 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"github.com/g3n/engine/math32"
+)
+
+// CameraViewport is a camera rendering into a rectangle of the window, such as one of the
+// views of a split-screen game or of an editor quad view. The rectangle is normalized so
+// it follows the window when resized: X, Y, Width and Height are fractions of the window
+// size, with the origin at the bottom left corner like OpenGL viewports.
+type CameraViewport struct {
+	Camera     *Camera        // Camera rendered into the viewport
+	X          float32        // Left edge, from 0 to 1
+	Y          float32        // Bottom edge, from 0 to 1
+	Width      float32        // Width, from 0 to 1
+	Height     float32        // Height, from 0 to 1
+	Background *math32.Color4 // Color the viewport is cleared to before rendering, or nil to keep the window background
+	Disabled   bool           // Whether the viewport is skipped when rendering and picking
+}
+
+// NewCameraViewport creates and returns a pointer to a new viewport of the specified camera
+// with the specified normalized rectangle.
+func NewCameraViewport(cam *Camera, x, y, width, height float32) *CameraViewport {
+
+	return &CameraViewport{Camera: cam, X: x, Y: y, Width: width, Height: height}
+}
+
+// Rect returns the rectangle of the viewport in pixels, with the origin at the bottom left
+// corner, for a window of the specified size in pixels. The edges are rounded so that
+// adjacent viewports cover the window without gaps or overlaps.
+func (vp *CameraViewport) Rect(width, height int) (x, y, w, h int) {
+
+	x = int(math32.Round(vp.X * float32(width)))
+	y = int(math32.Round(vp.Y * float32(height)))
+	w = int(math32.Round((vp.X+vp.Width)*float32(width))) - x
+	h = int(math32.Round((vp.Y+vp.Height)*float32(height))) - y
+	return
+}
+
+// Aspect returns the aspect ratio of the viewport for a window of the specified size in pixels,
+// or 1 if the viewport is empty.
+func (vp *CameraViewport) Aspect(width, height int) float32 {
+
+	_, _, w, h := vp.Rect(width, height)
+	if w <= 0 || h <= 0 {
+		return 1
+	}
+	return float32(w) / float32(h)
+}
+
+// UpdateAspect sets the aspect ratio of the camera to the aspect ratio of the viewport
+// for a window of the specified size in pixels.
+func (vp *CameraViewport) UpdateAspect(width, height int) {
+
+	if vp.Camera == nil {
+		return
+	}
+	aspect := vp.Aspect(width, height)
+	if vp.Camera.Aspect() != aspect {
+		vp.Camera.SetAspect(aspect)
+	}
+}
+
+// Contains returns whether the specified cursor position, in window pixels with the origin
+// at the top left corner as in cursor events, is inside the viewport for a window of the
+// specified size in pixels.
+func (vp *CameraViewport) Contains(cx, cy float32, width, height int) bool {
+
+	nx, ny := vp.ToNDC(cx, cy, width, height)
+	return nx >= -1 && nx <= 1 && ny >= -1 && ny <= 1
+}
+
+// ToNDC converts the specified cursor position, in window pixels with the origin at the top
+// left corner, to the normalized device coordinates of the viewport (-1 to 1 inside it),
+// which can be passed to the UnprojectRay method of its camera.
+func (vp *CameraViewport) ToNDC(cx, cy float32, width, height int) (x, y float32) {
+
+	rx, ry, w, h := vp.Rect(width, height)
+	if w <= 0 || h <= 0 {
+		return 2, 2
+	}
+	// Flips the cursor position to the bottom left origin of the viewport rectangle
+	fy := float32(height) - cy
+	x = 2*(cx-float32(rx))/float32(w) - 1
+	y = 2*(fy-float32(ry))/float32(h) - 1
+	return
+}
+
+// ViewportAt returns the last enabled viewport of the list containing the specified cursor
+// position, in window pixels with the origin at the top left corner, or nil if none does.
+// It is used to route cursor events and picking to the camera under the cursor.
+func ViewportAt(viewports []*CameraViewport, cx, cy float32, width, height int) *CameraViewport {
+
+	for i := len(viewports) - 1; i >= 0; i-- {
+		vp := viewports[i]
+		if vp != nil && !vp.Disabled && vp.Contains(cx, cy, width, height) {
+			return vp
+		}
+	}
+	return nil
+}
+
+// SplitScreen returns viewports dividing the window between the specified cameras for local
+// multiplayer games: one camera fills the window, two are side by side, three have the first
+// one on the top half and the others on the bottom half, and four or more are arranged in
+// a grid filled from the top left corner.
+func SplitScreen(cams ...*Camera) []*CameraViewport {
+
+	n := len(cams)
+	viewports := make([]*CameraViewport, 0, n)
+	switch n {
+	case 0:
+	case 1:
+		viewports = append(viewports, NewCameraViewport(cams[0], 0, 0, 1, 1))
+	case 2:
+		viewports = append(viewports,
+			NewCameraViewport(cams[0], 0, 0, 0.5, 1),
+			NewCameraViewport(cams[1], 0.5, 0, 0.5, 1))
+	case 3:
+		viewports = append(viewports,
+			NewCameraViewport(cams[0], 0, 0.5, 1, 0.5),
+			NewCameraViewport(cams[1], 0, 0, 0.5, 0.5),
+			NewCameraViewport(cams[2], 0.5, 0, 0.5, 0.5))
+	default:
+		cols := int(math32.Ceil(math32.Sqrt(float32(n))))
+		rows := (n + cols - 1) / cols
+		w := 1 / float32(cols)
+		h := 1 / float32(rows)
+		for i, cam := range cams {
+			col := i % cols
+			row := i / cols
+			viewports = append(viewports, NewCameraViewport(cam, float32(col)*w, 1-float32(row+1)*h, w, h))
+		}
+	}
+	return viewports
+}
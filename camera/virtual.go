@@ -0,0 +1,377 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"sort"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// OnLiveCameraChange is dispatched by a Brain when its live virtual camera changes.
+// Data is the new live *VirtualCamera, which may be nil.
+const OnLiveCameraChange = "camera.OnLiveCameraChange"
+
+// Pose describes the position, orientation and field of view of a camera.
+type Pose struct {
+	Position math32.Vector3
+	Rotation math32.Quaternion
+	Fov      float32 // Vertical field of view in degrees (0 keeps the camera's)
+}
+
+// Noise describes procedural handheld camera shake.
+type Noise struct {
+	PositionAmplitude float32 // Maximum position offset
+	RotationAmplitude float32 // Maximum rotation offset in radians
+	Frequency         float32 // Frequency of the shake in Hz
+}
+
+// VirtualCamera is a lightweight description of how a camera should be placed.
+// It does not render anything; a Brain drives the real camera from the
+// highest priority enabled virtual camera.
+type VirtualCamera struct {
+	Name         string         // Optional name
+	Priority     int            // Highest priority enabled virtual camera is the live one
+	Enabled      bool           // Whether the virtual camera can become live (default is true, see Brain.Activate)
+	Position     math32.Vector3 // Position used if there is no follow target
+	Rotation     math32.Quaternion
+	Fov          float32        // Vertical field of view in degrees (0 keeps the camera's)
+	Follow       core.INode     // Node followed by the camera, if any
+	FollowOffset math32.Vector3 // Offset from the follow target in world coordinates
+	Damping      float32        // Follow smoothing time constant in seconds (0 is rigid)
+	LookAt       core.INode     // Node the camera looks at, if any; otherwise Rotation is used
+	LookOffset   math32.Vector3 // Offset from the look at target in world coordinates
+	Up           math32.Vector3 // Up direction used when looking at a target (default is the engine up direction)
+	Noise        Noise          // Handheld shake
+
+	pos     math32.Vector3 // Current (damped) position
+	started bool           // Whether pos has been initialized
+	time    float32        // Time used by the noise
+	seed    uint32         // Seed of the noise of the first axis, the next ones use the following seeds
+	order   int            // Order added to the brain, used to break priority ties
+}
+
+// NewVirtualCamera creates and returns a pointer to a new enabled virtual camera with the specified priority.
+func NewVirtualCamera(name string, priority int) *VirtualCamera {
+
+	vc := new(VirtualCamera)
+	vc.Name = name
+	vc.Priority = priority
+	vc.Enabled = true
+	vc.Rotation.Set(0, 0, 0, 1)
+	vc.Up = core.EngineCoords().UpVector()
+	return vc
+}
+
+// Evaluate advances the virtual camera by the specified elapsed time in seconds
+// and returns the pose it currently wants for the camera.
+func (vc *VirtualCamera) Evaluate(deltaTime float32) Pose {
+
+	var p Pose
+	p.Fov = vc.Fov
+
+	// Position, following the target with optional damping
+	desired := vc.Position
+	if vc.Follow != nil {
+		vc.Follow.UpdateMatrixWorld()
+		vc.Follow.GetNode().WorldPosition(&desired)
+		desired.Add(&vc.FollowOffset)
+	}
+	if !vc.started || vc.Damping <= 0 {
+		vc.pos = desired
+		vc.started = true
+	} else {
+		vc.pos.Lerp(&desired, 1-math32.Exp(-deltaTime/vc.Damping))
+	}
+	p.Position = vc.pos
+
+	// Orientation
+	p.Rotation = vc.Rotation
+	if vc.LookAt != nil {
+		var target math32.Vector3
+		vc.LookAt.UpdateMatrixWorld()
+		vc.LookAt.GetNode().WorldPosition(&target)
+		target.Add(&vc.LookOffset)
+		var m math32.Matrix4
+		m.LookAt(&p.Position, &target, &vc.Up)
+		p.Rotation.SetFromRotationMatrix(&m)
+	}
+
+	// Handheld noise
+	vc.time += deltaTime
+	n := &vc.Noise
+	if n.Frequency > 0 && (n.PositionAmplitude > 0 || n.RotationAmplitude > 0) {
+		t := n.Frequency * vc.time
+		p.Position.X += n.PositionAmplitude * math32.Noise1(t, vc.seed)
+		p.Position.Y += n.PositionAmplitude * math32.Noise1(t, vc.seed+1)
+		p.Position.Z += n.PositionAmplitude * math32.Noise1(t, vc.seed+2)
+		euler := math32.Vector3{
+			X: n.RotationAmplitude * math32.Noise1(t, vc.seed+3),
+			Y: n.RotationAmplitude * math32.Noise1(t, vc.seed+4),
+			Z: n.RotationAmplitude * math32.Noise1(t, vc.seed+5),
+		}
+		var q math32.Quaternion
+		q.SetFromEuler(&euler)
+		p.Rotation.Multiply(&q)
+	}
+	return p
+}
+
+// Reset makes the next Evaluate snap to the desired position instead of damping towards it.
+func (vc *VirtualCamera) Reset() {
+
+	vc.started = false
+}
+
+// Brain drives a real camera from a set of virtual cameras.
+// The highest priority enabled virtual camera is live; when it changes,
+// the camera blends from the previous pose to the new live one.
+type Brain struct {
+	core.Dispatcher                  // Embedded event dispatcher
+	DefaultBlend    float32          // Duration in seconds of blends between virtual cameras (default is 1)
+	cam             *Camera          // Driven camera
+	vcams           []*VirtualCamera // Managed virtual cameras
+	live            *VirtualCamera   // Current live virtual camera
+	count           int              // Number of virtual cameras ever added
+	blending        bool             // Whether a blend is running
+	blendFrom       Pose             // Pose when the blend started
+	blendTime       float32          // Elapsed blend time in seconds
+	blendDuration   float32          // Total blend duration in seconds
+	blends          []customBlend    // Blend durations between specific virtual cameras
+	last            Pose             // Last pose applied to the camera
+}
+
+// customBlend is the blend duration between two virtual cameras, where nil matches any camera.
+type customBlend struct {
+	from     *VirtualCamera
+	to       *VirtualCamera
+	duration float32
+}
+
+// Director is an alias of Brain, the name used for it by other engines and tools.
+type Director = Brain
+
+// NewDirector creates and returns a pointer to a new director which drives the specified camera.
+// It is the same as NewBrain.
+func NewDirector(cam *Camera) *Director {
+
+	return NewBrain(cam)
+}
+
+// NewBrain creates and returns a pointer to a new brain which drives the specified camera.
+func NewBrain(cam *Camera) *Brain {
+
+	b := new(Brain)
+	b.Dispatcher.Initialize()
+	b.cam = cam
+	b.DefaultBlend = 1
+	return b
+}
+
+// Add adds virtual cameras to the brain.
+func (b *Brain) Add(vcams ...*VirtualCamera) {
+
+	for _, vc := range vcams {
+		vc.order = b.count
+		vc.seed = uint32(b.count) * 6
+		b.count++
+		b.vcams = append(b.vcams, vc)
+	}
+}
+
+// Remove removes the specified virtual camera and the blends set for it from the brain.
+func (b *Brain) Remove(vc *VirtualCamera) {
+
+	for i, v := range b.vcams {
+		if v == vc {
+			b.vcams = append(b.vcams[:i], b.vcams[i+1:]...)
+			break
+		}
+	}
+	blends := b.blends[:0]
+	for _, cb := range b.blends {
+		if cb.from != vc && cb.to != vc {
+			blends = append(blends, cb)
+		}
+	}
+	b.blends = blends
+}
+
+// Activate enables the specified virtual camera, adding it to the brain if necessary, and puts it
+// on top of the virtual cameras with the same priority, so it becomes live unless a virtual camera
+// with a higher priority is enabled. Activating virtual cameras of the same priority as game events
+// happen, such as cutscenes or entering areas, and deactivating them afterwards uses the brain
+// as a stack returning to the previous camera.
+func (b *Brain) Activate(vc *VirtualCamera) {
+
+	found := false
+	for _, v := range b.vcams {
+		if v == vc {
+			found = true
+			break
+		}
+	}
+	if !found {
+		b.Add(vc)
+	} else {
+		vc.order = b.count
+		b.count++
+	}
+	vc.Enabled = true
+}
+
+// Deactivate disables the specified virtual camera, so the brain blends to the highest priority
+// of the remaining enabled virtual cameras if it was live.
+func (b *Brain) Deactivate(vc *VirtualCamera) {
+
+	vc.Enabled = false
+}
+
+// SetBlend sets the duration in seconds of the blends from a virtual camera to another,
+// overriding DefaultBlend. A nil virtual camera matches any camera, so SetBlend(nil, vc, 0)
+// cuts to vc from any camera. Blends between specific cameras take precedence over those
+// from or to any camera.
+func (b *Brain) SetBlend(from, to *VirtualCamera, duration float32) {
+
+	for i := range b.blends {
+		if b.blends[i].from == from && b.blends[i].to == to {
+			b.blends[i].duration = duration
+			return
+		}
+	}
+	b.blends = append(b.blends, customBlend{from: from, to: to, duration: duration})
+}
+
+// ClearBlends removes the blend durations set with SetBlend.
+func (b *Brain) ClearBlends() {
+
+	b.blends = nil
+}
+
+// blendBetween returns the duration in seconds of the blend between the specified virtual cameras.
+func (b *Brain) blendBetween(from, to *VirtualCamera) float32 {
+
+	best := -1
+	duration := b.DefaultBlend
+	for _, cb := range b.blends {
+		if (cb.from != nil && cb.from != from) || (cb.to != nil && cb.to != to) {
+			continue
+		}
+		score := 0
+		if cb.from != nil {
+			score++
+		}
+		if cb.to != nil {
+			score++
+		}
+		if score > best {
+			best = score
+			duration = cb.duration
+		}
+	}
+	return duration
+}
+
+// VirtualCameras returns the virtual cameras of the brain sorted by decreasing priority.
+func (b *Brain) VirtualCameras() []*VirtualCamera {
+
+	list := append([]*VirtualCamera(nil), b.vcams...)
+	sort.SliceStable(list, func(i, j int) bool {
+		return higher(list[i], list[j])
+	})
+	return list
+}
+
+// Live returns the current live virtual camera or nil if none is enabled.
+func (b *Brain) Live() *VirtualCamera {
+
+	return b.live
+}
+
+// Blending returns whether the camera is blending between virtual cameras.
+func (b *Brain) Blending() bool {
+
+	return b.blending
+}
+
+// Update selects the live virtual camera, advances all virtual cameras
+// and the blend by the specified elapsed time in seconds and applies
+// the resulting pose to the camera. It should be called every frame.
+func (b *Brain) Update(deltaTime float32) {
+
+	// Select the live virtual camera
+	var live *VirtualCamera
+	for _, vc := range b.vcams {
+		if vc.Enabled && (live == nil || higher(vc, live)) {
+			live = vc
+		}
+	}
+	if live != b.live {
+		if duration := b.blendBetween(b.live, live); b.live != nil && live != nil && duration > 0 {
+			b.blending = true
+			b.blendFrom = b.last
+			b.blendTime = 0
+			b.blendDuration = duration
+		} else {
+			b.blending = false
+		}
+		b.live = live
+		b.Dispatch(OnLiveCameraChange, live)
+	}
+
+	// Advance all virtual cameras so followers stay in place while not live
+	var pose Pose
+	for _, vc := range b.vcams {
+		p := vc.Evaluate(deltaTime)
+		if vc == live {
+			pose = p
+		}
+	}
+	if live == nil {
+		return
+	}
+	if pose.Fov == 0 {
+		pose.Fov = b.cam.Fov()
+	}
+
+	// Blend from the previous pose
+	if b.blending {
+		b.blendTime += deltaTime
+		if b.blendTime >= b.blendDuration {
+			b.blending = false
+		} else {
+			x := b.blendTime / b.blendDuration
+			alpha := x * x * (3 - 2*x)
+			from := b.blendFrom
+			from.Position.Lerp(&pose.Position, alpha)
+			from.Rotation.Slerp(&pose.Rotation, alpha)
+			from.Fov += (pose.Fov - from.Fov) * alpha
+			pose = from
+		}
+	}
+	b.apply(&pose)
+}
+
+// apply sets the specified pose on the camera.
+func (b *Brain) apply(p *Pose) {
+
+	b.cam.SetPositionVec(&p.Position)
+	b.cam.SetQuaternionQuat(&p.Rotation)
+	if p.Fov != b.cam.Fov() {
+		b.cam.SetFov(p.Fov)
+	}
+	b.last = *p
+}
+
+// higher returns whether virtual camera a takes precedence over virtual camera b.
+// Ties are broken in favor of the most recently added.
+func higher(a, b *VirtualCamera) bool {
+
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.order > b.order
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"sync"
+)
+
+// CommandQueue is a goroutine-safe queue of deferred scene graph mutations.
+// Worker goroutines, such as background loaders, post commands to the queue and
+// the goroutine which owns the scene graph executes them at a sync point by calling Flush.
+type CommandQueue struct {
+	mutex    sync.Mutex // Protects the pending list
+	pending  []func()   // Commands waiting to be executed
+	flushing []func()   // Commands being executed (reused between flushes)
+}
+
+// NewCommandQueue creates and returns a pointer to a new CommandQueue.
+func NewCommandQueue() *CommandQueue {
+
+	q := new(CommandQueue)
+	return q
+}
+
+// Post queues the specified command to be executed by the next Flush.
+// It is safe to call from any goroutine.
+func (q *CommandQueue) Post(cmd func()) {
+
+	q.mutex.Lock()
+	q.pending = append(q.pending, cmd)
+	q.mutex.Unlock()
+}
+
+// Add queues the addition of the specified child to the specified parent.
+func (q *CommandQueue) Add(parent, child INode) {
+
+	q.Post(func() { parent.GetNode().Add(child) })
+}
+
+// Remove queues the removal of the specified child from the specified parent.
+func (q *CommandQueue) Remove(parent, child INode) {
+
+	q.Post(func() { parent.GetNode().Remove(child) })
+}
+
+// Dispatch queues the dispatch of the specified event by the specified dispatcher.
+func (q *CommandQueue) Dispatch(d IDispatcher, evname string, ev interface{}) {
+
+	q.Post(func() { d.Dispatch(evname, ev) })
+}
+
+// Len returns the number of commands waiting to be executed.
+func (q *CommandQueue) Len() int {
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.pending)
+}
+
+// Flush executes all queued commands in the order they were posted and returns
+// how many were executed. It must be called by the goroutine which owns the scene graph.
+// Commands posted while flushing are executed by the next Flush.
+func (q *CommandQueue) Flush() int {
+
+	q.mutex.Lock()
+	q.pending, q.flushing = q.flushing[:0], q.pending
+	q.mutex.Unlock()
+
+	for i, cmd := range q.flushing {
+		cmd()
+		q.flushing[i] = nil
+	}
+	return len(q.flushing)
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestCommandQueueConcurrentLoaders checks that subtrees built by several
+// goroutines and posted to a CommandQueue end up attached to the scene.
+// Run with -race to check that the scene graph is only touched by Flush.
+func TestCommandQueueConcurrentLoaders(t *testing.T) {
+
+	const loaders = 8
+	const perLoader = 50
+	scene := NewNode()
+	q := NewCommandQueue()
+
+	var wg sync.WaitGroup
+	for i := 0; i < loaders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perLoader; j++ {
+				// Detached subtrees are owned by the loader until posted
+				sub := NewNode()
+				sub.Add(NewNode())
+				q.Add(scene, sub)
+			}
+		}()
+	}
+
+	// Flush concurrently with the loaders, as the application loop would
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	flushed := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		flushed += q.Flush()
+	}
+	flushed += q.Flush()
+
+	if flushed != loaders*perLoader {
+		t.Fatalf("flushed %d commands, expected %d", flushed, loaders*perLoader)
+	}
+	if n := len(scene.Children()); n != loaders*perLoader {
+		t.Fatalf("scene has %d children, expected %d", n, loaders*perLoader)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("queue not empty after flush: %d", q.Len())
+	}
+}
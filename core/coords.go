@@ -0,0 +1,137 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"github.com/g3n/engine/math32"
+)
+
+// UpAxis is the axis pointing up in a coordinate system.
+type UpAxis int
+
+// The supported up axes. Both are right-handed.
+const (
+	YUp UpAxis = iota // Y axis up and Z axis toward the viewer, as in glTF and most game content
+	ZUp               // Z axis up and Y axis away from the viewer, as in CAD and many modeling tools
+)
+
+// Common lengths of one unit in meters.
+const (
+	Meter      = 1
+	Centimeter = 0.01
+	Millimeter = 0.001
+	Inch       = 0.0254
+	Foot       = 0.3048
+)
+
+// CoordSystem describes the conventions of a coordinate system: the axis pointing up and the
+// length of one unit. The zero value is Y-up with one unit per meter.
+type CoordSystem struct {
+	Up   UpAxis  // Axis pointing up
+	Unit float32 // Length of one unit in meters (0 means 1)
+}
+
+// Common coordinate systems.
+var (
+	YUpMeters      = CoordSystem{Up: YUp, Unit: Meter}      // Game engines and glTF
+	ZUpMeters      = CoordSystem{Up: ZUp, Unit: Meter}      // Blender and many DCC tools
+	ZUpMillimeters = CoordSystem{Up: ZUp, Unit: Millimeter} // Most CAD packages
+)
+
+// engineCoords is the coordinate system of the scene.
+var engineCoords = YUpMeters
+
+// EngineCoords returns the coordinate system of the scene, which content is converted to
+// by the loaders and which helpers and controls use by default.
+func EngineCoords() CoordSystem {
+
+	return engineCoords
+}
+
+// SetEngineCoords sets the coordinate system of the scene. It should be set once at startup,
+// before loading content and creating helpers and controls.
+func SetEngineCoords(cs CoordSystem) {
+
+	engineCoords = cs
+}
+
+// UnitScale returns the length of one unit in meters.
+func (cs CoordSystem) UnitScale() float32 {
+
+	if cs.Unit <= 0 {
+		return 1
+	}
+	return cs.Unit
+}
+
+// UpVector returns the unit vector pointing up.
+func (cs CoordSystem) UpVector() math32.Vector3 {
+
+	if cs.Up == ZUp {
+		return math32.Vector3{X: 0, Y: 0, Z: 1}
+	}
+	return math32.Vector3{X: 0, Y: 1, Z: 0}
+}
+
+// Meters returns the number of units of the specified length in meters,
+// for example to express physical constants such as the gravity.
+func (cs CoordSystem) Meters(length float32) float32 {
+
+	return length / cs.UnitScale()
+}
+
+// Conversion returns the rotation and uniform scale converting coordinates
+// from this coordinate system to the specified one.
+func (cs CoordSystem) Conversion(to CoordSystem) (rot math32.Quaternion, scale float32) {
+
+	rot.SetIdentity()
+	switch {
+	case cs.Up == ZUp && to.Up != ZUp:
+		rot.SetFromAxisAngle(&math32.Vector3{X: 1}, -math32.Pi/2)
+	case cs.Up != ZUp && to.Up == ZUp:
+		rot.SetFromAxisAngle(&math32.Vector3{X: 1}, math32.Pi/2)
+	}
+	return rot, cs.UnitScale() / to.UnitScale()
+}
+
+// ConversionMatrix sets the specified matrix to the transform converting coordinates
+// from this coordinate system to the specified one.
+func (cs CoordSystem) ConversionMatrix(to CoordSystem, m *math32.Matrix4) {
+
+	rot, scale := cs.Conversion(to)
+	m.Compose(&math32.Vector3{}, &rot, &math32.Vector3{X: scale, Y: scale, Z: scale})
+}
+
+// Convert converts the specified position from this coordinate system to the specified one.
+func (cs CoordSystem) Convert(to CoordSystem, v *math32.Vector3) *math32.Vector3 {
+
+	rot, scale := cs.Conversion(to)
+	return v.ApplyQuaternion(&rot).MultiplyScalar(scale)
+}
+
+// ConvertNode converts the local transform of the specified node, usually the root of loaded
+// content, from the specified coordinate system to the coordinate system of the scene, so the
+// content has the right orientation and size without manual rotations and scales.
+// Does nothing if the coordinate systems are the same.
+func ConvertNode(inode INode, from CoordSystem) {
+
+	to := EngineCoords()
+	if from.Up == to.Up && from.UnitScale() == to.UnitScale() {
+		return
+	}
+	rot, scale := from.Conversion(to)
+	n := inode.GetNode()
+
+	// The conversion is a rotation and a uniform scale, which commute with the node scale
+	pos := n.Position()
+	pos.ApplyQuaternion(&rot).MultiplyScalar(scale)
+	quat := n.Quaternion()
+	quat.MultiplyQuaternions(&rot, &quat)
+	sca := n.Scale()
+	sca.MultiplyScalar(scale)
+	n.SetPositionVec(&pos)
+	n.SetQuaternionQuat(&quat)
+	n.SetScaleVec(&sca)
+}
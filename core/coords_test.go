@@ -0,0 +1,59 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/math32"
+)
+
+// TestCoordSystemConvert checks that Z-up millimeter positions are converted
+// to Y-up meters and back.
+func TestCoordSystemConvert(t *testing.T) {
+
+	up := ZUpMillimeters.UpVector()
+	ZUpMillimeters.Convert(YUpMeters, &up)
+	if !up.AlmostEquals(&math32.Vector3{X: 0, Y: 0.001, Z: 0}, 1e-6) {
+		t.Fatalf("converted up vector is %v", up)
+	}
+
+	// The Y axis, away from the viewer in Z-up, points into the screen in Y-up
+	v := math32.Vector3{X: 1000, Y: 2000, Z: 3000}
+	ZUpMillimeters.Convert(YUpMeters, &v)
+	if !v.AlmostEquals(&math32.Vector3{X: 1, Y: 3, Z: -2}, 1e-5) {
+		t.Fatalf("converted position is %v", v)
+	}
+	YUpMeters.Convert(ZUpMillimeters, &v)
+	if !v.AlmostEquals(&math32.Vector3{X: 1000, Y: 2000, Z: 3000}, 1e-2) {
+		t.Fatalf("position converted back is %v", v)
+	}
+}
+
+// TestConvertNode checks that a converted node places its descendants
+// where the converted positions are.
+func TestConvertNode(t *testing.T) {
+
+	root := NewNode()
+	root.SetPosition(10, 0, 0)
+	child := NewNode()
+	child.SetPosition(0, 0, 500)
+	root.Add(child)
+
+	ConvertNode(root, ZUpMillimeters)
+	root.UpdateMatrixWorld()
+	var pos math32.Vector3
+	child.WorldPosition(&pos)
+	if !pos.AlmostEquals(&math32.Vector3{X: 0.01, Y: 0.5, Z: 0}, 1e-5) {
+		t.Fatalf("converted child position is %v", pos)
+	}
+
+	// Nothing changes between identical coordinate systems
+	other := NewNode()
+	ConvertNode(other, CoordSystem{})
+	if s := other.Scale(); s.X != 1 {
+		t.Fatal("node converted between identical coordinate systems")
+	}
+}
@@ -3,4 +3,13 @@
 // license that can be found in the LICENSE file.
 
 // Package core implements some basic types used by other packages.
+//
+// Concurrency
+//
+// Nodes, dispatchers and timers are not safe for concurrent use.
+// The scene graph is owned by a single goroutine, normally the one running the
+// application loop, and only that goroutine may read or mutate it.
+// Other goroutines, such as background loaders, may build detached subtrees
+// of their own and hand them over through a CommandQueue, whose commands
+// are executed by the owner goroutine at a sync point by calling Flush.
 package core
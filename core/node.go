@@ -7,7 +7,11 @@ package core
 import (
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
+	"path"
+	"reflect"
+	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 // INode is the interface for all node types.
@@ -36,20 +40,36 @@ type INode interface {
 // Node events.
 const (
 	OnDescendant = "core.OnDescendant" // Dispatched when a descendent is added or removed
+	OnAttach     = "core.OnAttach"     // Dispatched to a node when it is added to a parent
+	OnDetach     = "core.OnDetach"     // Dispatched to a node when it is removed from its parent
+	OnUpdate     = "core.OnUpdate"     // Dispatched to a node by UpdateTree
 )
 
+// IComponent is the interface for behaviors which can be attached to nodes.
+type IComponent interface {
+	OnAttach(node INode)                    // Called when the component is added to the node
+	OnDetach(node INode)                    // Called when the component is removed from the node
+	OnUpdate(node INode, deltaTime float32) // Called by UpdateTree with the elapsed time in seconds
+}
+
 // Node represents an object in 3D space existing within a hierarchy.
 type Node struct {
-	Dispatcher                 // Embedded event dispatcher
-	inode          INode       // The INode associated with this Node
-	parent         INode       // Parent node
-	children       []INode     // Children nodes
-	name           string      // Optional node name
-	loaderID       string      // ID used by loader
-	visible        bool        // Whether the node is visible
-	matNeedsUpdate bool        // Whether the the local matrix needs to be updated because position or scale has changed
-	rotNeedsUpdate bool        // Whether the euler rotation and local matrix need to be updated because the quaternion has changed
-	userData       interface{} // Generic user data
+	Dispatcher                            // Embedded event dispatcher
+	inode          INode                  // The INode associated with this Node
+	parent         INode                  // Parent node
+	children       []INode                // Children nodes
+	name           string                 // Optional node name
+	loaderID       string                 // ID used by loader
+	visible        bool                   // Whether the node is visible
+	matNeedsUpdate bool                   // Whether the the local matrix needs to be updated because position or scale has changed
+	rotNeedsUpdate bool                   // Whether the euler rotation and local matrix need to be updated because the quaternion has changed
+	wldNeedsUpdate bool                   // Whether the world matrix needs to be updated because the local matrix or an ancestor has changed
+	desNeedsUpdate bool                   // Whether some descendant needs its world matrix updated
+	wldVersion     uint64                 // Incremented each time the world matrix is recomputed
+	userData       interface{}            // Generic user data
+	userValues     map[string]interface{} // Named user data values
+	tags           map[string]bool        // Optional set of tags
+	components     []IComponent           // Attached components
 
 	// Spatial properties
 	position   math32.Vector3    // Node position in 3D space (relative to parent)
@@ -89,6 +109,7 @@ func (n *Node) Init(inode INode) {
 	n.quaternion.Set(0, 0, 0, 1)
 	n.matrix.Identity()
 	n.matrixWorld.Identity()
+	n.wldNeedsUpdate = true
 
 	// Subscribe to events
 	n.Subscribe(OnDescendant, func(evname string, ev interface{}) {
@@ -142,6 +163,12 @@ func (n *Node) Clone() INode {
 	clone.loaderID = n.loaderID
 	clone.visible = n.visible
 	clone.userData = n.userData
+	for key, value := range n.userValues {
+		clone.SetUserValue(key, value)
+	}
+	for tag := range n.tags {
+		clone.AddTag(tag)
+	}
 
 	// Update matrix world and rotation if necessary
 	n.UpdateMatrixWorld()
@@ -155,6 +182,7 @@ func (n *Node) Clone() INode {
 	clone.quaternion = n.quaternion
 	clone.matrix = n.matrix
 	clone.matrixWorld = n.matrixWorld
+	clone.wldNeedsUpdate = true
 	clone.children = make([]INode, 0)
 
 	// Clone children recursively
@@ -203,7 +231,7 @@ func (n *Node) LoaderID() string {
 func (n *Node) SetVisible(state bool) {
 
 	n.visible = state
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // Visible returns the visibility of the node.
@@ -215,7 +243,11 @@ func (n *Node) Visible() bool {
 // SetChanged sets the matNeedsUpdate flag of the node.
 func (n *Node) SetChanged(changed bool) {
 
-	n.matNeedsUpdate = changed
+	if changed {
+		n.invalidateMatrix()
+	} else {
+		n.matNeedsUpdate = false
+	}
 }
 
 // Changed returns the matNeedsUpdate flag of the node.
@@ -236,6 +268,26 @@ func (n *Node) UserData() interface{} {
 	return n.userData
 }
 
+// SetUserValue sets the user data value with the specified key, so several packages
+// can associate their own data to the node. A nil value removes the key.
+func (n *Node) SetUserValue(key string, value interface{}) {
+
+	if value == nil {
+		delete(n.userValues, key)
+		return
+	}
+	if n.userValues == nil {
+		n.userValues = make(map[string]interface{})
+	}
+	n.userValues[key] = value
+}
+
+// UserValue returns the user data value with the specified key or nil if not set.
+func (n *Node) UserValue(key string) interface{} {
+
+	return n.userValues[key]
+}
+
 // FindPath finds a node with the specified path starting with this node and
 // searching in all its children recursively.
 // A path is the sequence of the names from the first node to the desired node
@@ -295,6 +347,202 @@ func (n *Node) FindLoaderID(id string) INode {
 	return finder(n, id)
 }
 
+// AddTag adds the specified tags to the node.
+func (n *Node) AddTag(tags ...string) {
+
+	if n.tags == nil {
+		n.tags = make(map[string]bool)
+	}
+	for _, tag := range tags {
+		n.tags[tag] = true
+	}
+}
+
+// RemoveTag removes the specified tags from the node.
+func (n *Node) RemoveTag(tags ...string) {
+
+	for _, tag := range tags {
+		delete(n.tags, tag)
+	}
+}
+
+// HasTag returns whether the node has the specified tag.
+func (n *Node) HasTag(tag string) bool {
+
+	return n.tags[tag]
+}
+
+// Tags returns the sorted list of tags of the node.
+func (n *Node) Tags() []string {
+
+	tags := make([]string, 0, len(n.tags))
+	for tag := range n.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Walk calls the specified function for this node and all its descendants in depth-first order.
+// If the function returns false the descendants of the current node are not visited.
+func (n *Node) Walk(f func(INode) bool) {
+
+	var walker func(inode INode)
+	walker = func(inode INode) {
+		if !f(inode) {
+			return
+		}
+		for _, ichild := range inode.GetNode().children {
+			walker(ichild)
+		}
+	}
+	walker(n.GetINode())
+}
+
+// FindFunc returns all nodes, starting with this node and searching in all its children
+// recursively, for which the specified function returns true.
+func (n *Node) FindFunc(f func(INode) bool) []INode {
+
+	found := make([]INode, 0)
+	n.Walk(func(inode INode) bool {
+		if f(inode) {
+			found = append(found, inode)
+		}
+		return true
+	})
+	return found
+}
+
+// FindByName looks in this node and in all its children for a node
+// with the specified name and returns the first one found in depth-first order.
+// Returns nil if not found.
+func (n *Node) FindByName(name string) INode {
+
+	var found INode
+	n.Walk(func(inode INode) bool {
+		if found != nil {
+			return false
+		}
+		if inode.GetNode().name == name {
+			found = inode
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// FindByTag returns all nodes, starting with this node and searching
+// in all its children recursively, which have the specified tag.
+func (n *Node) FindByTag(tag string) []INode {
+
+	return n.FindFunc(func(inode INode) bool {
+		return inode.GetNode().tags[tag]
+	})
+}
+
+// FindAllByName returns all nodes, starting with this node and searching in all its
+// children recursively, whose names match the specified pattern, using the syntax of
+// path.Match, such as "enemy*" or "wheel_[0-3]".
+// A malformed pattern matches no nodes.
+func (n *Node) FindAllByName(pattern string) []INode {
+
+	return n.FindFunc(func(inode INode) bool {
+		ok, _ := path.Match(pattern, inode.GetNode().name)
+		return ok
+	})
+}
+
+// FindByTags returns all nodes, starting with this node and searching
+// in all its children recursively, which have all the specified tags.
+// No nodes are returned if no tags are specified.
+func (n *Node) FindByTags(tags ...string) []INode {
+
+	if len(tags) == 0 {
+		return []INode{}
+	}
+	found := n.FindByTag(tags[0])
+	matches := found[:0]
+	for _, inode := range found {
+		node := inode.GetNode()
+		all := true
+		for _, tag := range tags[1:] {
+			if !node.HasTag(tag) {
+				all = false
+				break
+			}
+		}
+		if all {
+			matches = append(matches, inode)
+		}
+	}
+	return matches
+}
+
+// FindByType returns all nodes, starting with this node and searching in all its
+// children recursively, of the type of the specified value. If the value is a nil
+// pointer to an interface, the nodes implementing the interface are returned:
+//
+//	meshes := scene.FindByType((*graphic.Mesh)(nil))
+//	graphics := scene.FindByType((*graphic.IGraphic)(nil))
+func (n *Node) FindByType(sample interface{}) []INode {
+
+	typ := reflect.TypeOf(sample)
+	if typ == nil {
+		return []INode{}
+	}
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+		iface := typ.Elem()
+		return n.FindFunc(func(inode INode) bool {
+			return reflect.TypeOf(inode).Implements(iface)
+		})
+	}
+	return n.FindFunc(func(inode INode) bool {
+		return reflect.TypeOf(inode) == typ
+	})
+}
+
+// Query returns all descendants of this node whose path relative to this node
+// matches the specified pattern.
+// The pattern is a sequence of name patterns separated by the forward slash, as in
+// "world/enemies/*". Each element is matched against a node name using the syntax
+// of path.Match and the special element "**" matches any number of levels, including zero.
+// A leading slash is optional. This node itself is never returned, so "**" returns all
+// its descendants.
+func (n *Node) Query(pattern string) []INode {
+
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	found := make([]INode, 0)
+	root := n.GetINode()
+	seen := make(map[INode]bool)
+	var matcher func(inode INode, parts []string)
+	matcher = func(inode INode, parts []string) {
+		if len(parts) == 0 {
+			if inode != root && !seen[inode] {
+				seen[inode] = true
+				found = append(found, inode)
+			}
+			return
+		}
+		first := parts[0]
+		if first == "**" {
+			// Match zero levels or descend one level keeping the wildcard
+			matcher(inode, parts[1:])
+			for _, ichild := range inode.GetNode().children {
+				matcher(ichild, parts)
+			}
+			return
+		}
+		for _, ichild := range inode.GetNode().children {
+			if ok, _ := path.Match(first, ichild.GetNode().name); ok {
+				matcher(ichild, parts[1:])
+			}
+		}
+	}
+	matcher(root, parts)
+	return found
+}
+
 // Children returns the list of children.
 func (n *Node) Children() []INode {
 
@@ -307,6 +555,7 @@ func (n *Node) Add(ichild INode) *Node {
 
 	setParent(n.GetINode(), ichild)
 	n.children = append(n.children, ichild)
+	ichild.Dispatch(OnAttach, n.GetINode())
 	n.Dispatch(OnDescendant, nil)
 	return n
 }
@@ -327,6 +576,7 @@ func (n *Node) AddAt(idx int, ichild INode) *Node {
 	copy(n.children[idx+1:], n.children[idx:])
 	n.children[idx] = ichild
 
+	ichild.Dispatch(OnAttach, n.GetINode())
 	n.Dispatch(OnDescendant, nil)
 
 	return n
@@ -347,6 +597,7 @@ func setParent(parent INode, child INode) {
 		child.Parent().GetNode().Remove(child)
 	}
 	child.GetNode().parent = parent
+	child.GetNode().invalidateMatrixWorld()
 }
 
 // ChildAt returns the child at the specified index.
@@ -417,6 +668,8 @@ func (n *Node) Remove(ichild INode) bool {
 			n.children[len(n.children)-1] = nil
 			n.children = n.children[:len(n.children)-1]
 			ichild.GetNode().parent = nil
+			ichild.GetNode().wldNeedsUpdate = true
+			ichild.Dispatch(OnDetach, n.GetINode())
 			n.Dispatch(OnDescendant, nil)
 			return true
 		}
@@ -433,12 +686,15 @@ func (n *Node) RemoveAt(idx int) INode {
 	}
 
 	child := n.children[idx]
+	child.GetNode().wldNeedsUpdate = true
 
 	// Remove child from children list
 	copy(n.children[idx:], n.children[idx+1:])
 	n.children[len(n.children)-1] = nil
 	n.children = n.children[:len(n.children)-1]
+	child.GetNode().parent = nil
 
+	child.Dispatch(OnDetach, n.GetINode())
 	n.Dispatch(OnDescendant, nil)
 
 	return child
@@ -450,6 +706,8 @@ func (n *Node) RemoveAll(recurs bool) {
 	for pos, ichild := range n.children {
 		n.children[pos] = nil
 		ichild.GetNode().parent = nil
+		ichild.GetNode().wldNeedsUpdate = true
+		ichild.Dispatch(OnDetach, n.GetINode())
 		if recurs {
 			ichild.GetNode().RemoveAll(recurs)
 		}
@@ -464,6 +722,7 @@ func (n *Node) DisposeChildren(recurs bool) {
 	for pos, ichild := range n.children {
 		n.children[pos] = nil
 		ichild.GetNode().parent = nil
+		ichild.Dispatch(OnDetach, n.GetINode())
 		if recurs {
 			ichild.GetNode().DisposeChildren(true)
 		}
@@ -472,39 +731,104 @@ func (n *Node) DisposeChildren(recurs bool) {
 	n.children = n.children[0:0]
 }
 
+// AddComponent attaches the specified component to the node and calls its OnAttach method.
+func (n *Node) AddComponent(c IComponent) {
+
+	n.components = append(n.components, c)
+	c.OnAttach(n.GetINode())
+}
+
+// RemoveComponent detaches the specified component from the node and calls its OnDetach method.
+// Returns true if found or false otherwise.
+func (n *Node) RemoveComponent(c IComponent) bool {
+
+	for pos, current := range n.components {
+		if current == c {
+			copy(n.components[pos:], n.components[pos+1:])
+			n.components[len(n.components)-1] = nil
+			n.components = n.components[:len(n.components)-1]
+			c.OnDetach(n.GetINode())
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveAllComponents detaches all components from the node.
+func (n *Node) RemoveAllComponents() {
+
+	for len(n.components) > 0 {
+		n.RemoveComponent(n.components[len(n.components)-1])
+	}
+}
+
+// Components returns the list of components attached to the node.
+func (n *Node) Components() []IComponent {
+
+	return n.components
+}
+
+// FindComponent returns the first component attached to the node
+// for which the specified function returns true, or nil if not found.
+// It is normally used with a type assertion to retrieve a component of a specific type.
+func (n *Node) FindComponent(f func(IComponent) bool) IComponent {
+
+	for _, c := range n.components {
+		if f(c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// UpdateTree calls OnUpdate for the components of this node and all its descendants
+// and dispatches OnUpdate to each of these nodes, passing the elapsed time in seconds.
+// It is normally called once per frame for the scene before rendering.
+func (n *Node) UpdateTree(deltaTime float32) {
+
+	inode := n.GetINode()
+	for i := 0; i < len(n.components); i++ {
+		n.components[i].OnUpdate(inode, deltaTime)
+	}
+	n.Dispatch(OnUpdate, deltaTime)
+	for i := 0; i < len(n.children); i++ {
+		n.children[i].GetNode().UpdateTree(deltaTime)
+	}
+}
+
 // SetPosition sets the position.
 func (n *Node) SetPosition(x, y, z float32) {
 
 	n.position.Set(x, y, z)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetPositionVec sets the position based on the specified vector pointer.
 func (n *Node) SetPositionVec(vpos *math32.Vector3) {
 
 	n.position = *vpos
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetPositionX sets the X coordinate of the position.
 func (n *Node) SetPositionX(x float32) {
 
 	n.position.X = x
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetPositionY sets the Y coordinate of the position.
 func (n *Node) SetPositionY(y float32) {
 
 	n.position.Y = y
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetPositionZ sets the Z coordinate of the position.
 func (n *Node) SetPositionZ(z float32) {
 
 	n.position.Z = z
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // Position returns the position as a vector.
@@ -520,7 +844,7 @@ func (n *Node) TranslateOnAxis(axis *math32.Vector3, dist float32) {
 	v.ApplyQuaternion(&n.quaternion)
 	v.MultiplyScalar(dist)
 	n.position.Add(v)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // TranslateX translates the specified distance on the local X axis.
@@ -546,7 +870,7 @@ func (n *Node) SetRotation(x, y, z float32) {
 
 	n.rotation.Set(x, y, z)
 	n.quaternion.SetFromEuler(&n.rotation)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetRotationVec sets the global rotation in Euler angles (radians) based on the specified vector pointer.
@@ -554,7 +878,7 @@ func (n *Node) SetRotationVec(vrot *math32.Vector3) {
 
 	n.rotation = *vrot
 	n.quaternion.SetFromEuler(&n.rotation)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetRotationQuat sets the global rotation based on the specified quaternion pointer.
@@ -562,6 +886,7 @@ func (n *Node) SetRotationQuat(quat *math32.Quaternion) {
 
 	n.quaternion = *quat
 	n.rotNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetRotationX sets the global X rotation to the specified angle in radians.
@@ -573,7 +898,7 @@ func (n *Node) SetRotationX(x float32) {
 	}
 	n.rotation.X = x
 	n.quaternion.SetFromEuler(&n.rotation)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetRotationY sets the global Y rotation to the specified angle in radians.
@@ -585,7 +910,7 @@ func (n *Node) SetRotationY(y float32) {
 	}
 	n.rotation.Y = y
 	n.quaternion.SetFromEuler(&n.rotation)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetRotationZ sets the global Z rotation to the specified angle in radians.
@@ -597,7 +922,7 @@ func (n *Node) SetRotationZ(z float32) {
 	}
 	n.rotation.Z = z
 	n.quaternion.SetFromEuler(&n.rotation)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // Rotation returns the current global rotation in Euler angles (radians).
@@ -641,6 +966,7 @@ func (n *Node) SetQuaternion(x, y, z, w float32) {
 
 	n.quaternion.Set(x, y, z, w)
 	n.rotNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetQuaternionVec sets the quaternion based on the specified quaternion unit multiples vector.
@@ -648,6 +974,7 @@ func (n *Node) SetQuaternionVec(q *math32.Vector4) {
 
 	n.quaternion.Set(q.X, q.Y, q.Z, q.W)
 	n.rotNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetQuaternionQuat sets the quaternion based on the specified quaternion pointer.
@@ -655,6 +982,7 @@ func (n *Node) SetQuaternionQuat(q *math32.Quaternion) {
 
 	n.quaternion = *q
 	n.rotNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // QuaternionMult multiplies the current quaternion by the specified quaternion.
@@ -662,6 +990,7 @@ func (n *Node) QuaternionMult(q *math32.Quaternion) {
 
 	n.quaternion.Multiply(q)
 	n.rotNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // Quaternion returns the current quaternion.
@@ -679,41 +1008,42 @@ func (n *Node) LookAt(target, up *math32.Vector3) {
 	rotMat.LookAt(&worldPos, target, up)
 	n.quaternion.SetFromRotationMatrix(&rotMat)
 	n.rotNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetScale sets the scale.
 func (n *Node) SetScale(x, y, z float32) {
 
 	n.scale.Set(x, y, z)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetScaleVec sets the scale based on the specified vector pointer.
 func (n *Node) SetScaleVec(scale *math32.Vector3) {
 
 	n.scale = *scale
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetScaleX sets the X scale.
 func (n *Node) SetScaleX(sx float32) {
 
 	n.scale.X = sx
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetScaleY sets the Y scale.
 func (n *Node) SetScaleY(sy float32) {
 
 	n.scale.Y = sy
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetScaleZ sets the Z scale.
 func (n *Node) SetScaleZ(sz float32) {
 
 	n.scale.Z = sz
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // Scale returns the current scale.
@@ -726,14 +1056,14 @@ func (n *Node) Scale() math32.Vector3 {
 func (n *Node) SetDirection(x, y, z float32) {
 
 	n.direction.Set(x, y, z)
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // SetDirectionVec sets the direction based on a vector pointer.
 func (n *Node) SetDirectionVec(vdir *math32.Vector3) {
 
 	n.direction = *vdir
-	n.matNeedsUpdate = true
+	n.invalidateMatrix()
 }
 
 // Direction returns the direction.
@@ -748,6 +1078,7 @@ func (n *Node) SetMatrix(m *math32.Matrix4) {
 	n.matrix = *m
 	n.matrix.Decompose(&n.position, &n.quaternion, &n.scale)
 	n.rotNeedsUpdate = true
+	n.invalidateMatrixWorld()
 }
 
 // Matrix returns a copy of the local transformation matrix.
@@ -809,29 +1140,121 @@ func (n *Node) MatrixWorld() math32.Matrix4 {
 	return n.matrixWorld
 }
 
+// MatrixWorldVersion returns a counter which is incremented each time the world matrix
+// of this node is recomputed. It can be used to cache values derived from the world matrix.
+func (n *Node) MatrixWorldVersion() uint64 {
+
+	return n.wldVersion
+}
+
 // UpdateMatrix updates (if necessary) the local transform matrix
 // of this node based on its position, quaternion, and scale.
 func (n *Node) UpdateMatrix() bool {
 
-	if !n.matNeedsUpdate && !n.rotNeedsUpdate {
+	if !n.matNeedsUpdate {
 		return false
 	}
 	n.matrix.Compose(&n.position, &n.quaternion, &n.scale)
 	n.matNeedsUpdate = false
+	n.wldNeedsUpdate = true
+	atomic.AddUint64(&transformStats.LocalUpdates, 1)
 	return true
 }
 
-// UpdateMatrixWorld updates this node world transform matrix and of all its children
+// UpdateMatrixWorld updates this node world transform matrix and of all its children.
+// Only the world matrices of nodes whose local matrix or an ancestor's changed
+// since the last update are recomputed, and subtrees without changes are skipped.
 func (n *Node) UpdateMatrixWorld() {
 
 	n.UpdateMatrix()
-	if n.parent == nil {
-		n.matrixWorld = n.matrix
-	} else {
-		n.matrixWorld.MultiplyMatrices(&n.parent.GetNode().matrixWorld, &n.matrix)
+	updated := n.wldNeedsUpdate
+	if updated {
+		if n.parent == nil {
+			n.matrixWorld = n.matrix
+		} else {
+			n.matrixWorld.MultiplyMatrices(&n.parent.GetNode().matrixWorld, &n.matrix)
+		}
+		n.wldNeedsUpdate = false
+		n.wldVersion++
+		atomic.AddUint64(&transformStats.WorldUpdates, 1)
+		// All children world matrices depend on this one
+		for _, ichild := range n.children {
+			ichild.GetNode().wldNeedsUpdate = true
+		}
+		n.desNeedsUpdate = len(n.children) > 0
+	}
+	if !n.desNeedsUpdate {
+		if !updated {
+			atomic.AddUint64(&transformStats.Skipped, 1)
+		}
+		return
 	}
+	n.desNeedsUpdate = false
 	// Update this Node children matrices
 	for _, ichild := range n.children {
 		ichild.UpdateMatrixWorld()
 	}
 }
+
+// ForceUpdateMatrixWorld recomputes the local and world transform
+// matrices of this node and of all its descendants, ignoring the dirty flags.
+func (n *Node) ForceUpdateMatrixWorld() {
+
+	n.Walk(func(inode INode) bool {
+		node := inode.GetNode()
+		node.matNeedsUpdate = true
+		node.wldNeedsUpdate = true
+		node.desNeedsUpdate = len(node.children) > 0
+		return true
+	})
+	n.UpdateMatrixWorld()
+}
+
+// invalidateMatrix marks the local matrix of this node as needing to be recomposed.
+func (n *Node) invalidateMatrix() {
+
+	n.matNeedsUpdate = true
+	n.invalidateMatrixWorld()
+}
+
+// invalidateMatrixWorld marks the world matrix of this node as needing to be recomputed
+// and flags all its ancestors so the next update pass visits this node.
+// Types which override UpdateMatrixWorld may not clear the flags, so the walk
+// always goes up to the root instead of stopping at the first flagged ancestor.
+func (n *Node) invalidateMatrixWorld() {
+
+	n.wldNeedsUpdate = true
+	for ipar := n.parent; ipar != nil; ipar = ipar.Parent() {
+		ipar.GetNode().desNeedsUpdate = true
+	}
+}
+
+// TransformStats contains cumulative counters of transform matrix updates
+// performed by all nodes, for performance evaluation.
+// The counters are updated atomically, so separate trees can be updated concurrently.
+type TransformStats struct {
+	LocalUpdates uint64 // Number of local matrices recomposed
+	WorldUpdates uint64 // Number of world matrices recomputed
+	Skipped      uint64 // Number of unchanged subtrees skipped
+}
+
+// transformStats holds the global transform counters
+var transformStats TransformStats
+
+// TransformStatistics returns the current transform update counters.
+func TransformStatistics() TransformStats {
+
+	return TransformStats{
+		LocalUpdates: atomic.LoadUint64(&transformStats.LocalUpdates),
+		WorldUpdates: atomic.LoadUint64(&transformStats.WorldUpdates),
+		Skipped:      atomic.LoadUint64(&transformStats.Skipped),
+	}
+}
+
+// ResetTransformStatistics clears the transform update counters.
+func ResetTransformStatistics() {
+
+	atomic.StoreUint64(&transformStats.LocalUpdates, 0)
+	atomic.StoreUint64(&transformStats.WorldUpdates, 0)
+	atomic.StoreUint64(&transformStats.Skipped, 0)
+}
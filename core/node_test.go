@@ -0,0 +1,145 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/math32"
+)
+
+// testNode is a node type used by the typed queries tests.
+type testNode struct {
+	Node
+}
+
+func newTestNode(name string) *testNode {
+
+	tn := new(testNode)
+	tn.Node.Init(tn)
+	tn.SetName(name)
+	return tn
+}
+
+// TestNodeQueries checks the name pattern, tags and type queries of nodes.
+func TestNodeQueries(t *testing.T) {
+
+	root := NewNode()
+	root.SetName("root")
+	enemies := NewNode()
+	enemies.SetName("enemies")
+	root.Add(enemies)
+	for _, name := range []string{"enemy_1", "enemy_2", "boss"} {
+		e := newTestNode(name)
+		e.AddTag("enemy")
+		enemies.Add(e)
+	}
+	enemies.Children()[2].GetNode().AddTag("boss")
+	root.Add(newTestNode("player"))
+
+	if found := root.Query("**"); len(found) != 5 {
+		t.Fatalf("query ** found %d descendants", len(found))
+	}
+	// The trailing wildcard also matches zero levels, so enemies itself
+	if found := root.Query("enemies/**"); len(found) != 4 {
+		t.Fatalf("query enemies/** found %d nodes", len(found))
+	}
+	if found := root.Query("**/boss"); len(found) != 1 {
+		t.Fatalf("query **/boss found %d nodes", len(found))
+	}
+	if found := root.FindAllByName("enemy_*"); len(found) != 2 {
+		t.Fatalf("found %d nodes named enemy_*", len(found))
+	}
+	if found := root.FindAllByName("["); len(found) != 0 {
+		t.Fatalf("malformed pattern found %d nodes", len(found))
+	}
+	if found := root.FindByTag("enemy"); len(found) != 3 {
+		t.Fatalf("found %d enemies", len(found))
+	}
+	found := root.FindByTags("enemy", "boss")
+	if len(found) != 1 || found[0].GetNode().Name() != "boss" {
+		t.Fatalf("found %d bosses", len(found))
+	}
+	if found := root.FindByTags(); len(found) != 0 {
+		t.Fatalf("empty tag set found %d nodes", len(found))
+	}
+	if found := root.FindByType((*testNode)(nil)); len(found) != 4 {
+		t.Fatalf("found %d nodes of type *testNode", len(found))
+	}
+	if found := root.FindByType((*Node)(nil)); len(found) != 2 {
+		t.Fatalf("found %d nodes of type *Node", len(found))
+	}
+	if found := enemies.FindByType((*INode)(nil)); len(found) != 4 {
+		t.Fatalf("found %d nodes implementing INode", len(found))
+	}
+}
+
+// TestForceUpdateMatrixWorld checks that forced updates recompute the matrices of all descendants
+// and that separate trees can be updated concurrently.
+func TestForceUpdateMatrixWorld(t *testing.T) {
+
+	done := make(chan bool)
+	for i := 0; i < 2; i++ {
+		go func() {
+			root := NewNode()
+			child := NewNode()
+			root.Add(child)
+			child.SetPosition(1, 2, 3)
+			root.UpdateMatrixWorld()
+			// Modify the child transform without invalidating its matrices
+			child.position.Set(4, 5, 6)
+			root.ForceUpdateMatrixWorld()
+			var pos math32.Vector3
+			mw := child.MatrixWorld()
+			pos.SetFromMatrixPosition(&mw)
+			done <- pos.X == 4 && pos.Y == 5 && pos.Z == 6
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if !<-done {
+			t.Fatal("forced update did not recompute the descendant matrix")
+		}
+	}
+	if stats := TransformStatistics(); stats.LocalUpdates == 0 || stats.WorldUpdates == 0 {
+		t.Fatalf("transform statistics not updated: %+v", stats)
+	}
+}
+
+// TestUpdateMatrixWorldSkips checks that only the subtrees without changes are skipped.
+func TestUpdateMatrixWorldSkips(t *testing.T) {
+
+	root := NewNode()
+	var branches, leaves [2]*Node
+	for i := range branches {
+		branches[i] = NewNode()
+		leaves[i] = NewNode()
+		branches[i].Add(leaves[i])
+		root.Add(branches[i])
+	}
+	root.UpdateMatrixWorld()
+
+	// Moving a branch updates it and its leaf and skips the other branch
+	ResetTransformStatistics()
+	branches[0].SetPosition(1, 0, 0)
+	root.UpdateMatrixWorld()
+	if stats := TransformStatistics(); stats.WorldUpdates != 2 || stats.Skipped != 1 {
+		t.Fatalf("moving a branch: %+v", stats)
+	}
+
+	// Moving a leaf updates it and skips the other branch
+	ResetTransformStatistics()
+	leaves[1].SetPosition(0, 1, 0)
+	root.UpdateMatrixWorld()
+	if stats := TransformStatistics(); stats.WorldUpdates != 1 || stats.Skipped != 1 {
+		t.Fatalf("moving a leaf: %+v", stats)
+	}
+
+	// Without changes the whole tree is skipped
+	ResetTransformStatistics()
+	root.UpdateMatrixWorld()
+	if stats := TransformStatistics(); stats.WorldUpdates != 0 || stats.Skipped != 1 {
+		t.Fatalf("without changes: %+v", stats)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+// NodePool recycles nodes which are frequently spawned and removed, such as projectiles,
+// particle effects and enemies, avoiding the garbage and the creation and deletion of
+// graphics resources of allocating new nodes. Acquired nodes are added to the parent of
+// the pool and released nodes are removed from their parent without being disposed.
+type NodePool struct {
+	Reset      func(INode) // Called on reused nodes before they are acquired again, to restore their initial state, or nil
+	Deactivate func(INode) // Called on nodes when they are released, to stop their activity, or nil
+	MaxFree    int         // Maximum number of free nodes, released nodes beyond it are disposed (default is 0 for no limit)
+
+	create func() INode       // Creates new nodes
+	parent INode              // Node the acquired nodes are added to, or nil
+	free   []INode            // Released nodes
+	active map[INode]struct{} // Acquired nodes
+	stats  PoolStats          // Usage metrics
+}
+
+// PoolStats are the usage metrics of a NodePool.
+type PoolStats struct {
+	Created    int // Nodes created
+	Acquired   int // Acquisitions, including the ones served by created nodes
+	Reused     int // Acquisitions served by released nodes
+	Released   int // Releases
+	Disposed   int // Released nodes disposed as the pool was full
+	Active     int // Nodes currently acquired
+	Free       int // Nodes currently waiting in the pool
+	PeakActive int // Maximum number of nodes acquired at the same time
+}
+
+// NewNodePool creates and returns a pointer to a new pool whose nodes are created by
+// the specified function and added to the specified parent, if not nil, when acquired.
+func NewNodePool(parent INode, create func() INode) *NodePool {
+
+	p := new(NodePool)
+	p.create = create
+	p.parent = parent
+	p.active = make(map[INode]struct{})
+	return p
+}
+
+// Parent returns the node the acquired nodes are added to.
+func (p *NodePool) Parent() INode {
+
+	return p.parent
+}
+
+// SetParent sets the node the nodes acquired from now on are added to.
+func (p *NodePool) SetParent(parent INode) {
+
+	p.parent = parent
+}
+
+// Prewarm creates nodes until the specified number of nodes are waiting in the pool,
+// usually while loading so no node is created during the game.
+func (p *NodePool) Prewarm(count int) {
+
+	for len(p.free) < count {
+		p.free = append(p.free, p.create())
+		p.stats.Created++
+	}
+}
+
+// Acquire returns a visible node, reused from the released nodes if possible,
+// and adds it to the parent of the pool.
+func (p *NodePool) Acquire() INode {
+
+	var inode INode
+	if n := len(p.free); n > 0 {
+		inode = p.free[n-1]
+		p.free[n-1] = nil
+		p.free = p.free[:n-1]
+		p.stats.Reused++
+		if p.Reset != nil {
+			p.Reset(inode)
+		}
+	} else {
+		inode = p.create()
+		p.stats.Created++
+	}
+	p.stats.Acquired++
+	p.active[inode] = struct{}{}
+	if len(p.active) > p.stats.PeakActive {
+		p.stats.PeakActive = len(p.active)
+	}
+	inode.GetNode().SetVisible(true)
+	if p.parent != nil {
+		p.parent.GetNode().Add(inode)
+	}
+	return inode
+}
+
+// Release returns the specified acquired node to the pool, removing it from its parent
+// without disposing it. Returns false if the node was not acquired from the pool.
+func (p *NodePool) Release(inode INode) bool {
+
+	if _, ok := p.active[inode]; !ok {
+		return false
+	}
+	delete(p.active, inode)
+	p.stats.Released++
+	if p.Deactivate != nil {
+		p.Deactivate(inode)
+	}
+	node := inode.GetNode()
+	if parent := node.Parent(); parent != nil {
+		parent.GetNode().Remove(inode)
+	}
+	if p.MaxFree > 0 && len(p.free) >= p.MaxFree {
+		inode.Dispose()
+		p.stats.Disposed++
+		return true
+	}
+	p.free = append(p.free, inode)
+	return true
+}
+
+// ReleaseAll returns all the acquired nodes to the pool.
+func (p *NodePool) ReleaseAll() {
+
+	for inode := range p.active {
+		p.Release(inode)
+	}
+}
+
+// Stats returns the usage metrics of the pool.
+func (p *NodePool) Stats() PoolStats {
+
+	stats := p.stats
+	stats.Active = len(p.active)
+	stats.Free = len(p.free)
+	return stats
+}
+
+// Dispose disposes the nodes waiting in the pool. Acquired nodes belong to the scene
+// and are disposed with it.
+func (p *NodePool) Dispose() {
+
+	for i, inode := range p.free {
+		inode.Dispose()
+		p.free[i] = nil
+	}
+	p.free = p.free[:0]
+}
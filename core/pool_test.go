@@ -0,0 +1,74 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package core
+
+import (
+	"testing"
+)
+
+// TestNodePoolReuse checks that released nodes leave the scene without being
+// disposed and are reused, reset and added back by the next acquisitions.
+func TestNodePoolReuse(t *testing.T) {
+
+	scene := NewNode()
+	pool := NewNodePool(scene, func() INode { return NewNode() })
+	resets := 0
+	pool.Reset = func(inode INode) {
+		inode.GetNode().SetPosition(0, 0, 0)
+		resets++
+	}
+	pool.Prewarm(2)
+
+	a := pool.Acquire()
+	pool.Acquire()
+	pool.Acquire()
+	if len(scene.Children()) != 3 {
+		t.Fatalf("scene has %d children, want 3", len(scene.Children()))
+	}
+	a.GetNode().SetPosition(1, 2, 3)
+	a.GetNode().SetVisible(false)
+	if !pool.Release(a) || pool.Release(a) {
+		t.Fatal("a node must be released exactly once")
+	}
+	if a.GetNode().Parent() != nil || len(scene.Children()) != 2 {
+		t.Fatal("released node still in the scene")
+	}
+
+	d := pool.Acquire()
+	if d != a {
+		t.Fatal("released node not reused")
+	}
+	if pos := d.GetNode().Position(); pos.X != 0 || !d.GetNode().Visible() {
+		t.Fatal("reused node not reset")
+	}
+	pool.ReleaseAll()
+	if len(scene.Children()) != 0 {
+		t.Fatalf("scene has %d children after releasing all, want 0", len(scene.Children()))
+	}
+
+	stats := pool.Stats()
+	want := PoolStats{Created: 3, Acquired: 4, Reused: 3, Released: 4, Free: 3, PeakActive: 3}
+	if stats != want {
+		t.Fatalf("stats are %+v, want %+v", stats, want)
+	}
+	if resets != 3 {
+		t.Fatalf("%d resets, want 3", resets)
+	}
+}
+
+// TestNodePoolMaxFree checks that nodes released into a full pool are disposed.
+func TestNodePoolMaxFree(t *testing.T) {
+
+	pool := NewNodePool(nil, func() INode { return NewNode() })
+	pool.MaxFree = 1
+	a := pool.Acquire()
+	b := pool.Acquire()
+	pool.Release(a)
+	pool.Release(b)
+	stats := pool.Stats()
+	if stats.Free != 1 || stats.Disposed != 1 {
+		t.Fatalf("pool has %d free and %d disposed nodes, want 1 and 1", stats.Free, stats.Disposed)
+	}
+}
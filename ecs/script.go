@@ -0,0 +1,29 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ecs
+
+// Script is a component with a function called each frame by the ScriptSystem,
+// for behaviors specific to an entity.
+type Script struct {
+	Update func(w *World, e Entity, deltaTime float32) // Called with the elapsed time in seconds
+}
+
+// ScriptSystem is the system calling the Update functions of the Script components.
+// It is added to a world with AddSystem.
+var ScriptSystem System = SystemFunc(updateScripts)
+
+// updateScripts calls the functions of the Script components, in a stable order, allowing
+// them to add and remove components and entities.
+func updateScripts(w *World, deltaTime float32) {
+
+	store := w.Store(Script{})
+	entities := append([]Entity(nil), store.Entities()...)
+	for _, e := range entities {
+		script, ok := store.Get(e).(*Script)
+		if ok && script.Update != nil {
+			script.Update(w, e, deltaTime)
+		}
+	}
+}
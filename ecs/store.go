@@ -0,0 +1,140 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Store stores the components of one type of the entities of a world.
+// The components are stored by value in a dense slice, in no particular order,
+// so systems iterate them contiguously:
+//
+//	vel := world.Store(Velocity{})
+//	values := vel.Slice().([]Velocity)
+//	for i, e := range vel.Entities() {
+//		node := world.Node(e)
+//		...values[i]...
+//	}
+//
+// The pointers returned by Get and the slices returned by Slice and Entities are only
+// valid until components of the type are added or removed.
+type Store struct {
+	typ      reflect.Type  // Type of the components
+	data     reflect.Value // Dense slice of the components
+	entities []Entity      // Entity of each component
+	sparse   []int32       // Index of the component of each entity index plus one, or zero
+}
+
+// newStore creates and returns a pointer to a new store of the specified component type.
+func newStore(typ reflect.Type) *Store {
+
+	s := new(Store)
+	s.typ = typ
+	s.data = reflect.MakeSlice(reflect.SliceOf(typ), 0, 16)
+	return s
+}
+
+// Type returns the type of the components of the store.
+func (s *Store) Type() reflect.Type {
+
+	return s.typ
+}
+
+// Len returns the number of components in the store.
+func (s *Store) Len() int {
+
+	return len(s.entities)
+}
+
+// Slice returns the dense slice of the components, such as []Velocity,
+// in the order of the entities returned by Entities.
+func (s *Store) Slice() interface{} {
+
+	return s.data.Interface()
+}
+
+// Entities returns the entities of the components, in the order of the components.
+func (s *Store) Entities() []Entity {
+
+	return s.entities
+}
+
+// Has returns whether the specified entity has a component in the store.
+func (s *Store) Has(e Entity) bool {
+
+	return s.index(e) >= 0
+}
+
+// Get returns a pointer to the component of the specified entity, such as *Velocity,
+// or nil if it has none.
+func (s *Store) Get(e Entity) interface{} {
+
+	i := s.index(e)
+	if i < 0 {
+		return nil
+	}
+	return s.data.Index(i).Addr().Interface()
+}
+
+// Set sets the component of the specified entity, adding it if needed, and returns a pointer to it.
+// The component must be a value of the type of the store. The world must own the entity.
+func (s *Store) Set(e Entity, component interface{}) interface{} {
+
+	v := reflect.ValueOf(component)
+	if v.Type() != s.typ {
+		panic(fmt.Sprintf("ecs: component of type %v set in store of %v", v.Type(), s.typ))
+	}
+	i := s.index(e)
+	if i < 0 {
+		i = len(s.entities)
+		s.entities = append(s.entities, e)
+		s.data = reflect.Append(s.data, v)
+		idx := e.Index()
+		for len(s.sparse) <= idx {
+			s.sparse = append(s.sparse, 0)
+		}
+		s.sparse[idx] = int32(i + 1)
+	} else {
+		s.data.Index(i).Set(v)
+	}
+	return s.data.Index(i).Addr().Interface()
+}
+
+// Remove removes the component of the specified entity and returns whether it had one.
+// The last component takes its place.
+func (s *Store) Remove(e Entity) bool {
+
+	i := s.index(e)
+	if i < 0 {
+		return false
+	}
+	last := len(s.entities) - 1
+	if i != last {
+		s.data.Index(i).Set(s.data.Index(last))
+		s.entities[i] = s.entities[last]
+		s.sparse[s.entities[i].Index()] = int32(i + 1)
+	}
+	s.data.Index(last).Set(reflect.Zero(s.typ))
+	s.data = s.data.Slice(0, last)
+	s.entities = s.entities[:last]
+	s.sparse[e.Index()] = 0
+	return true
+}
+
+// index returns the index of the component of the specified entity or -1.
+func (s *Store) index(e Entity) int {
+
+	idx := e.Index()
+	if idx >= len(s.sparse) {
+		return -1
+	}
+	i := int(s.sparse[idx]) - 1
+	if i < 0 || s.entities[i] != e {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ecs
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type position struct {
+	X, Y float32
+}
+
+type health struct {
+	Value int
+}
+
+// TestStoreRandom checks random additions, removals and entity destructions against maps.
+func TestStoreRandom(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(1))
+	w := NewWorld()
+	positions := w.Store(position{})
+	healths := w.Store(health{})
+	refPos := make(map[Entity]position)
+	refHealth := make(map[Entity]health)
+	var alive, dead []Entity
+
+	for step := 0; step < 20000; step++ {
+		switch op := rnd.Intn(10); {
+		case op < 2 || len(alive) == 0:
+			alive = append(alive, w.NewEntity(nil))
+		case op < 5:
+			e := alive[rnd.Intn(len(alive))]
+			p := position{rnd.Float32(), rnd.Float32()}
+			w.Set(e, p)
+			refPos[e] = p
+		case op < 7:
+			e := alive[rnd.Intn(len(alive))]
+			h := health{rnd.Int()}
+			w.Set(e, h)
+			refHealth[e] = h
+		case op < 8:
+			e := alive[rnd.Intn(len(alive))]
+			_, had := refPos[e]
+			if removed := w.Remove(e, position{}); removed != had {
+				t.Fatalf("step %d: removing position of %v returned %v", step, e, removed)
+			}
+			delete(refPos, e)
+		default:
+			i := rnd.Intn(len(alive))
+			e := alive[i]
+			alive = append(alive[:i], alive[i+1:]...)
+			dead = append(dead, e)
+			w.Destroy(e)
+			delete(refPos, e)
+			delete(refHealth, e)
+		}
+
+		// Checks the stores against the maps
+		if positions.Len() != len(refPos) || healths.Len() != len(refHealth) {
+			t.Fatalf("step %d: stores have %d and %d components instead of %d and %d",
+				step, positions.Len(), healths.Len(), len(refPos), len(refHealth))
+		}
+		values := positions.Slice().([]position)
+		for i, e := range positions.Entities() {
+			if p, ok := refPos[e]; !ok || p != values[i] {
+				t.Fatalf("step %d: position of %v is %v instead of %v", step, e, values[i], p)
+			}
+		}
+		for e, h := range refHealth {
+			if got := healths.Get(e); got == nil || *got.(*health) != h {
+				t.Fatalf("step %d: health of %v is %v instead of %v", step, e, got, h)
+			}
+		}
+	}
+
+	// Destroyed entities have no components, even if their indices were reused
+	for _, e := range dead {
+		if w.Alive(e) || positions.Has(e) || healths.Has(e) {
+			t.Fatalf("destroyed %v still has components", e)
+		}
+	}
+
+	// Each visits the entities with both components
+	visited := make(map[Entity]bool)
+	w.Each(func(e Entity) {
+		visited[e] = true
+	}, positions, healths)
+	count := 0
+	for e := range refPos {
+		if _, ok := refHealth[e]; ok {
+			count++
+			if !visited[e] {
+				t.Fatalf("Each did not visit %v", e)
+			}
+		}
+	}
+	if len(visited) != count {
+		t.Fatalf("Each visited %d entities instead of %d", len(visited), count)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ecs implements an optional entity component system over the scene graph.
+//
+// Entities are identifiers, optionally bound to nodes. Their components are plain Go values,
+// such as the data of their physics, scripts or sounds, stored by value in one dense store per
+// type. Systems are run in order each frame by the world, and iterate the components of the
+// types they use. This composes gameplay data and behaviors without embedding node types.
+//
+// A world is updated with the scene when it is attached to a node as a component:
+//
+//	world := ecs.NewWorld()
+//	scene.AddComponent(world)
+//
+// As the scene graph, worlds are not safe for concurrent use.
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/g3n/engine/core"
+)
+
+// Entity identifies an entity of a world. Identifiers of destroyed entities are not reused.
+type Entity uint64
+
+// Index returns the index of the entity in its world.
+func (e Entity) Index() int {
+
+	return int(uint32(e))
+}
+
+// Generation returns the number of times the index of the entity was reused.
+func (e Entity) Generation() uint32 {
+
+	return uint32(e >> 32)
+}
+
+// String satisfies the fmt.Stringer interface.
+func (e Entity) String() string {
+
+	return fmt.Sprintf("Entity(%d:%d)", e.Index(), e.Generation())
+}
+
+// System is the interface for the systems run by a world.
+type System interface {
+	Update(w *World, deltaTime float32) // Called by World.Update with the elapsed time in seconds
+}
+
+// SystemFunc is a function used as a System.
+type SystemFunc func(w *World, deltaTime float32)
+
+// Update satisfies the System interface.
+func (f SystemFunc) Update(w *World, deltaTime float32) {
+
+	f(w, deltaTime)
+}
+
+// World contains entities, their components and the systems updating them.
+type World struct {
+	generations []uint32                // Generation of each entity index
+	alive       []bool                  // Whether each entity index is used
+	free        []int                   // Unused entity indices
+	nodes       []core.INode            // Node bound to each entity index
+	entities    map[*core.Node]Entity   // Entity bound to each node
+	stores      map[reflect.Type]*Store // Stores by component type
+	storeList   []*Store                // Stores in creation order
+	systems     []system                // Systems in order of priority
+	count       int                     // Number of alive entities
+}
+
+// system is a system of a world with its priority.
+type system struct {
+	sys      System
+	priority int
+}
+
+// NewWorld creates and returns a pointer to a new empty world.
+func NewWorld() *World {
+
+	w := new(World)
+	w.entities = make(map[*core.Node]Entity)
+	w.stores = make(map[reflect.Type]*Store)
+	return w
+}
+
+// NewEntity creates and returns a new entity bound to the specified node, if not nil.
+// A node is bound to at most one entity.
+func (w *World) NewEntity(node core.INode) Entity {
+
+	var idx int
+	if n := len(w.free); n > 0 {
+		idx = w.free[n-1]
+		w.free = w.free[:n-1]
+	} else {
+		idx = len(w.generations)
+		w.generations = append(w.generations, 0)
+		w.alive = append(w.alive, false)
+		w.nodes = append(w.nodes, nil)
+	}
+	e := Entity(uint64(w.generations[idx])<<32 | uint64(idx))
+	w.alive[idx] = true
+	w.count++
+	if node != nil {
+		w.Bind(e, node)
+	}
+	return e
+}
+
+// Destroy removes the components of the specified entity and destroys it.
+// Its node, if any, is not removed from the scene.
+func (w *World) Destroy(e Entity) {
+
+	if !w.Alive(e) {
+		return
+	}
+	for _, s := range w.storeList {
+		s.Remove(e)
+	}
+	w.Bind(e, nil)
+	idx := e.Index()
+	w.alive[idx] = false
+	w.generations[idx]++
+	w.free = append(w.free, idx)
+	w.count--
+}
+
+// Alive returns whether the specified entity exists.
+func (w *World) Alive(e Entity) bool {
+
+	idx := e.Index()
+	return idx < len(w.alive) && w.alive[idx] && w.generations[idx] == e.Generation()
+}
+
+// Len returns the number of entities.
+func (w *World) Len() int {
+
+	return w.count
+}
+
+// Bind binds the specified entity to the specified node, or unbinds it if nil.
+// The node is unbound from its previous entity, if any.
+func (w *World) Bind(e Entity, node core.INode) {
+
+	if !w.Alive(e) {
+		return
+	}
+	idx := e.Index()
+	if old := w.nodes[idx]; old != nil {
+		delete(w.entities, old.GetNode())
+	}
+	w.nodes[idx] = nil
+	if node == nil {
+		return
+	}
+	if prev, ok := w.entities[node.GetNode()]; ok {
+		w.nodes[prev.Index()] = nil
+	}
+	w.nodes[idx] = node
+	w.entities[node.GetNode()] = e
+}
+
+// Node returns the node bound to the specified entity or nil.
+func (w *World) Node(e Entity) core.INode {
+
+	if !w.Alive(e) {
+		return nil
+	}
+	return w.nodes[e.Index()]
+}
+
+// Entity returns the entity bound to the specified node and whether there is one.
+func (w *World) Entity(node core.INode) (Entity, bool) {
+
+	e, ok := w.entities[node.GetNode()]
+	return e, ok
+}
+
+// DestroyTree destroys the entities bound to the specified node and its descendants.
+func (w *World) DestroyTree(node core.INode) {
+
+	node.GetNode().Walk(func(n core.INode) bool {
+		if e, ok := w.entities[n.GetNode()]; ok {
+			w.Destroy(e)
+		}
+		return true
+	})
+}
+
+// Store returns the store of the components with the type of the specified value,
+// such as Velocity{}, creating it if needed.
+func (w *World) Store(component interface{}) *Store {
+
+	typ := reflect.TypeOf(component)
+	s, ok := w.stores[typ]
+	if !ok {
+		s = newStore(typ)
+		w.stores[typ] = s
+		w.storeList = append(w.storeList, s)
+	}
+	return s
+}
+
+// Set sets the specified component of the specified entity, adding it if needed,
+// and returns a pointer to the stored component. It panics if the entity does not exist.
+func (w *World) Set(e Entity, component interface{}) interface{} {
+
+	if !w.Alive(e) {
+		panic(fmt.Sprintf("ecs: set component of destroyed %v", e))
+	}
+	return w.Store(component).Set(e, component)
+}
+
+// Get returns a pointer to the component of the specified entity with the type of the
+// specified value, such as Velocity{}, or nil if it has none.
+func (w *World) Get(e Entity, component interface{}) interface{} {
+
+	s, ok := w.stores[reflect.TypeOf(component)]
+	if !ok {
+		return nil
+	}
+	return s.Get(e)
+}
+
+// Remove removes the component of the specified entity with the type of the
+// specified value and returns whether it had one.
+func (w *World) Remove(e Entity, component interface{}) bool {
+
+	s, ok := w.stores[reflect.TypeOf(component)]
+	if !ok {
+		return false
+	}
+	return s.Remove(e)
+}
+
+// Each calls the specified function for the entities with components in all the specified
+// stores, iterating the smallest one. Components may be changed but not added or removed
+// by the function.
+func (w *World) Each(f func(e Entity), stores ...*Store) {
+
+	if len(stores) == 0 {
+		return
+	}
+	smallest := stores[0]
+	for _, s := range stores[1:] {
+		if s.Len() < smallest.Len() {
+			smallest = s
+		}
+	}
+	for _, e := range smallest.entities {
+		all := true
+		for _, s := range stores {
+			if s != smallest && !s.Has(e) {
+				all = false
+				break
+			}
+		}
+		if all {
+			f(e)
+		}
+	}
+}
+
+// AddSystem adds the specified system with the specified priority.
+// Systems are updated by increasing priorities, and in the order they were added for
+// equal priorities.
+func (w *World) AddSystem(sys System, priority int) {
+
+	w.systems = append(w.systems, system{sys: sys, priority: priority})
+	sort.SliceStable(w.systems, func(i, j int) bool { return w.systems[i].priority < w.systems[j].priority })
+}
+
+// RemoveSystem removes the specified system and returns whether it was found.
+// Systems which are functions can not be removed.
+func (w *World) RemoveSystem(sys System) bool {
+
+	if !reflect.TypeOf(sys).Comparable() {
+		return false
+	}
+	for i := range w.systems {
+		if w.systems[i].sys == sys {
+			w.systems = append(w.systems[:i], w.systems[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Update updates the systems in order with the elapsed time in seconds.
+func (w *World) Update(deltaTime float32) {
+
+	for i := 0; i < len(w.systems); i++ {
+		w.systems[i].sys.Update(w, deltaTime)
+	}
+}
+
+// OnAttach satisfies the core.IComponent interface.
+func (w *World) OnAttach(node core.INode) {}
+
+// OnDetach satisfies the core.IComponent interface.
+func (w *World) OnDetach(node core.INode) {}
+
+// OnUpdate satisfies the core.IComponent interface, updating the world.
+func (w *World) OnUpdate(node core.INode, deltaTime float32) {
+
+	w.Update(deltaTime)
+}
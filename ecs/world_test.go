@@ -0,0 +1,134 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ecs
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/core"
+)
+
+// TestWorldNodes checks the binding of entities to nodes.
+func TestWorldNodes(t *testing.T) {
+
+	w := NewWorld()
+	root := core.NewNode()
+	child := core.NewNode()
+	root.Add(child)
+	a := w.NewEntity(root)
+	b := w.NewEntity(child)
+	if w.Node(a) != root || w.Node(b) != child {
+		t.Fatal("entities are not bound to their nodes")
+	}
+
+	// Binding a node to another entity unbinds it from the previous one
+	c := w.NewEntity(nil)
+	w.Bind(c, child)
+	if w.Node(b) != nil || w.Node(c) != child {
+		t.Fatal("node was not rebound")
+	}
+	if e, ok := w.Entity(child); !ok || e != c {
+		t.Fatalf("node is bound to %v instead of %v", e, c)
+	}
+
+	// Destroying the tree destroys the entities of the node and its descendants
+	w.Set(c, health{1})
+	w.DestroyTree(root)
+	if w.Alive(a) || !w.Alive(b) || w.Alive(c) || w.Len() != 1 {
+		t.Fatal("tree entities were not destroyed")
+	}
+	if _, ok := w.Entity(child); ok || w.Get(c, health{}) != nil {
+		t.Fatal("destroyed entity is still bound or has components")
+	}
+
+	// Reused indices have a new generation
+	d := w.NewEntity(nil)
+	if d.Index() != c.Index() || d.Generation() == c.Generation() || w.Alive(c) {
+		t.Fatalf("entity %v reused the index of %v without a new generation", d, c)
+	}
+}
+
+// orderSystem is a comparable system recording its updates.
+type orderSystem struct {
+	name  string
+	order *[]string
+}
+
+// Update satisfies the System interface.
+func (s *orderSystem) Update(w *World, deltaTime float32) {
+
+	*s.order = append(*s.order, s.name)
+}
+
+// TestWorldSystems checks the order and removal of systems.
+func TestWorldSystems(t *testing.T) {
+
+	w := NewWorld()
+	var order []string
+	late := &orderSystem{"late", &order}
+	first := &orderSystem{"first", &order}
+	second := &orderSystem{"second", &order}
+	w.AddSystem(late, 10)
+	w.AddSystem(first, 0)
+	w.AddSystem(second, 0)
+	w.AddSystem(SystemFunc(func(w *World, deltaTime float32) { order = append(order, "func") }), 5)
+	w.Update(0.1)
+	if got := len(order); got != 4 || order[0] != "first" || order[1] != "second" || order[2] != "func" || order[3] != "late" {
+		t.Fatalf("systems updated in order %v", order)
+	}
+
+	if !w.RemoveSystem(second) || w.RemoveSystem(second) {
+		t.Fatal("system was not removed once")
+	}
+	if w.RemoveSystem(SystemFunc(updateScripts)) {
+		t.Fatal("function system was removed")
+	}
+	order = order[:0]
+	w.Update(0.1)
+	if len(order) != 3 || order[1] != "func" {
+		t.Fatalf("systems updated in order %v after removal", order)
+	}
+}
+
+// TestScripts checks that scripts can destroy entities while being updated.
+func TestScripts(t *testing.T) {
+
+	w := NewWorld()
+	w.AddSystem(ScriptSystem, 0)
+	calls := 0
+	for i := 0; i < 3; i++ {
+		e := w.NewEntity(nil)
+		w.Set(e, Script{Update: func(w *World, e Entity, deltaTime float32) {
+			calls++
+			w.Destroy(e)
+		}})
+	}
+	w.Update(0.1)
+	if calls != 3 || w.Len() != 0 || w.Store(Script{}).Len() != 0 {
+		t.Fatalf("scripts were called %d times and left %d entities", calls, w.Len())
+	}
+}
+
+// TestWorldPanics checks that invalid components are rejected.
+func TestWorldPanics(t *testing.T) {
+
+	w := NewWorld()
+	e := w.NewEntity(nil)
+	expectPanic(t, "wrong component type", func() { w.Store(position{}).Set(e, health{}) })
+	w.Destroy(e)
+	expectPanic(t, "destroyed entity", func() { w.Set(e, position{}) })
+}
+
+// expectPanic checks that the specified function panics.
+func expectPanic(t *testing.T, name string, f func()) {
+
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("%s did not panic", name)
+		}
+	}()
+	f()
+}
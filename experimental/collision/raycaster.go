@@ -7,10 +7,12 @@ package collision
 import (
 	"github.com/g3n/engine/camera"
 	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/graphic"
 	"github.com/g3n/engine/material"
 	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/spatial"
 	"sort"
 )
 
@@ -100,6 +102,21 @@ func (rc *Raycaster) IntersectObjects(inodes []core.INode, recursive bool) []Int
 	return intersects
 }
 
+// IntersectIndex checks intersections between this raycaster and the graphics
+// of the specified spatial index whose bounding boxes are intersected by the ray.
+// Intersections are returned sorted by distance, closest first.
+func (rc *Raycaster) IntersectIndex(index *spatial.Index) []Intersect {
+
+	intersects := []Intersect{}
+	for _, igr := range index.QueryRay(&rc.Ray, rc.Far, nil) {
+		rc.intersectObject(igr, &intersects, false)
+	}
+	sort.Slice(intersects, func(i, j int) bool {
+		return intersects[i].Distance < intersects[j].Distance
+	})
+	return intersects
+}
+
 func (rc *Raycaster) intersectObject(inode core.INode, intersects *[]Intersect, recursive bool) {
 
 	node := inode.GetNode()
@@ -118,6 +135,8 @@ func (rc *Raycaster) intersectObject(inode core.INode, intersects *[]Intersect,
 		rc.RaycastLines(in, intersects)
 	case *graphic.LineStrip:
 		rc.RaycastLineStrip(in, intersects)
+	case *graphic.LineLoop:
+		rc.RaycastLineLoop(in, intersects)
 	}
 
 	if recursive {
@@ -173,6 +192,9 @@ func (rc *Raycaster) RaycastSprite(s *graphic.Sprite, intersects *[]Intersect) {
 
 	// Get buffer with vertices and uvs
 	geom := s.GetGeometry()
+	if err := geom.Restore(); err != nil {
+		return
+	}
 	vboPos := geom.VBO(gls.VertexPosition)
 	if vboPos == nil {
 		panic("sprite.Raycast(): VertexPosition VBO not found")
@@ -359,17 +381,24 @@ func (rc *Raycaster) RaycastMesh(m *graphic.Mesh, intersects *[]Intersect) {
 // RaycastLines
 func (rc *Raycaster) RaycastLines(l *graphic.Lines, intersects *[]Intersect) {
 
-	lineRaycast(l, rc, intersects, 2)
+	lineRaycast(l, rc, intersects, gls.LINES)
 }
 
 // RaycastLineStrip
 func (rc *Raycaster) RaycastLineStrip(l *graphic.LineStrip, intersects *[]Intersect) {
 
-	lineRaycast(l, rc, intersects, 1)
+	lineRaycast(l, rc, intersects, gls.LINE_STRIP)
 }
 
-// Internal function used by raycasting for Lines and LineStrip.
-func lineRaycast(igr graphic.IGraphic, rc *Raycaster, intersects *[]Intersect, step int) {
+// RaycastLineLoop
+func (rc *Raycaster) RaycastLineLoop(l *graphic.LineLoop, intersects *[]Intersect) {
+
+	lineRaycast(l, rc, intersects, gls.LINE_LOOP)
+}
+
+// Internal function used by raycasting for Lines, LineStrip and LineLoop.
+// The primitive of the geometry, if set, overrides the specified mode.
+func lineRaycast(igr graphic.IGraphic, rc *Raycaster, intersects *[]Intersect, mode uint32) {
 
 	// Get the bounding sphere
 	gr := igr.GetGraphic()
@@ -399,6 +428,9 @@ func lineRaycast(igr graphic.IGraphic, rc *Raycaster, intersects *[]Intersect, s
 	var interRay math32.Vector3
 
 	// Get geometry positions and indices buffers
+	if err := geom.Restore(); err != nil {
+		return
+	}
 	vboPos := geom.VBO(gls.VertexPosition)
 	if vboPos == nil {
 		return
@@ -407,6 +439,27 @@ func lineRaycast(igr graphic.IGraphic, rc *Raycaster, intersects *[]Intersect, s
 	indices := geom.Indices()
 	precisionSq := rc.LinePrecision * rc.LinePrecision
 
+	// Converts loops and strips with restart indices to individual lines
+	if p := geom.Primitive(); p != 0 {
+		mode = p
+	}
+	step := 2
+	if mode == gls.LINE_LOOP || (mode == gls.LINE_STRIP && geom.PrimitiveRestart()) {
+		if indices.Size() == 0 {
+			indices = math32.NewArrayU32(positions.Size()/3, positions.Size()/3)
+			for i := range indices {
+				indices[i] = uint32(i)
+			}
+		}
+		if mode == gls.LINE_LOOP {
+			indices = geometry.LinesFromLoop(indices)
+		} else {
+			indices = geometry.LinesFromStrip(indices)
+		}
+	} else if mode == gls.LINE_STRIP {
+		step = 1
+	}
+
 	// Checks intersection with individual lines for indexed geometry
 	if indices.Size() > 0 {
 		for i := 0; i < indices.Size()-1; i += step {
@@ -0,0 +1,128 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package physics
+
+import (
+	"github.com/g3n/engine/experimental/collision/shape"
+	"github.com/g3n/engine/experimental/physics/object"
+	"github.com/g3n/engine/math32"
+)
+
+// CollideCapsule tests a capsule, the segment from a to b swept by a sphere of the specified
+// radius, against the bodies of the simulation which have collision response.
+// If the capsule penetrates a body it sets push to the smallest translation which separates
+// the capsule from the most penetrated body and returns true.
+// It is meant to move characters, such as with the camera.FPSController, without adding them
+// to the simulation. Spheres and planes are tested exactly, convex hulls are tested with their
+// face normals only and other shapes with their world bounding boxes.
+func (s *Simulation) CollideCapsule(a, b *math32.Vector3, radius float32, push *math32.Vector3) bool {
+
+	found := false
+	var best float32
+	var normal math32.Vector3
+	for _, body := range s.bodies {
+		if body == nil || !body.CollisionResponse() || body.Shape() == nil {
+			continue
+		}
+		n, depth, hit := capsuleBody(a, b, radius, body)
+		if hit && (!found || depth > best) {
+			found = true
+			best = depth
+			normal = n
+		}
+	}
+	if !found {
+		return false
+	}
+	push.Copy(&normal).MultiplyScalar(best)
+	return true
+}
+
+// capsuleBody returns the direction and depth of the penetration of the specified capsule
+// into the specified body, and whether they overlap.
+func capsuleBody(a, b *math32.Vector3, radius float32, body *object.Body) (math32.Vector3, float32, bool) {
+
+	pos := body.Position()
+	quat := body.Quaternion()
+	switch sh := body.Shape().(type) {
+	case *shape.Sphere:
+		var closest, n math32.Vector3
+		closestOnSegment(a, b, &pos, &closest)
+		n.SubVectors(&closest, &pos)
+		dist := n.Length()
+		depth := radius + sh.Radius() - dist
+		if depth <= 0 {
+			return n, 0, false
+		}
+		if dist > 0 {
+			n.MultiplyScalar(1 / dist)
+		} else {
+			n.Set(0, 1, 0)
+		}
+		return n, depth, true
+	case *shape.Plane:
+		n := sh.Normal()
+		n.ApplyQuaternion(quat).Normalize()
+		var da, db math32.Vector3
+		da.SubVectors(a, &pos)
+		db.SubVectors(b, &pos)
+		depth := radius - math32.Min(da.Dot(&n), db.Dot(&n))
+		return n, depth, depth > 0
+	case *shape.ConvexHull:
+		faces := sh.Faces()
+		normals := sh.FaceNormals()
+		worldNormals := make([]math32.Vector3, len(faces))
+		points := make([]math32.Vector3, len(faces))
+		for i := range faces {
+			worldNormals[i] = normals[i]
+			worldNormals[i].ApplyQuaternion(quat)
+			points[i] = faces[i][0]
+			points[i].ApplyQuaternion(quat).Add(&pos)
+		}
+		return capsulePlanes(a, b, radius, worldNormals, points)
+	}
+
+	// Uses the world bounding box of other shapes
+	box := body.BoundingBox()
+	normals := []math32.Vector3{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}, {Z: 1}, {Z: -1}}
+	points := []math32.Vector3{box.Max, box.Min, box.Max, box.Min, box.Max, box.Min}
+	return capsulePlanes(a, b, radius, normals, points)
+}
+
+// capsulePlanes returns the direction and depth of the penetration of the specified capsule
+// into the convex volume bounded by the planes with the specified outward normals through
+// the specified points, and whether they overlap, testing only the normals as separating axes.
+func capsulePlanes(a, b *math32.Vector3, radius float32, normals, points []math32.Vector3) (math32.Vector3, float32, bool) {
+
+	var normal math32.Vector3
+	best := float32(-1)
+	for i := range normals {
+		var da, db math32.Vector3
+		da.SubVectors(a, &points[i])
+		db.SubVectors(b, &points[i])
+		depth := radius - math32.Min(da.Dot(&normals[i]), db.Dot(&normals[i]))
+		if depth <= 0 {
+			return normal, 0, false
+		}
+		if best < 0 || depth < best {
+			best = depth
+			normal = normals[i]
+		}
+	}
+	return normal, best, best > 0
+}
+
+// closestOnSegment sets result to the point of the segment from a to b closest to the specified point.
+func closestOnSegment(a, b, point, result *math32.Vector3) {
+
+	var ab, ap math32.Vector3
+	ab.SubVectors(b, a)
+	ap.SubVectors(point, a)
+	t := float32(0)
+	if l2 := ab.LengthSq(); l2 > 0 {
+		t = math32.Clamp(ap.Dot(&ab)/l2, 0, 1)
+	}
+	result.Copy(&ab).MultiplyScalar(t).Add(a)
+}
@@ -49,6 +49,18 @@ func (ptpc *PointToPoint) initialize(bodyA, bodyB IBody, pivotA, pivotB *math32.
 	ptpc.AddEquation(&ptpc.eqZ.Equation)
 }
 
+// PivotA returns the pivot point defined locally in bodyA.
+func (ptpc *PointToPoint) PivotA() math32.Vector3 {
+
+	return *ptpc.pivotA
+}
+
+// PivotB returns the pivot point defined locally in bodyB.
+func (ptpc *PointToPoint) PivotB() math32.Vector3 {
+
+	return *ptpc.pivotB
+}
+
 // Update updates the equations with data.
 func (ptpc *PointToPoint) Update() {
 
@@ -7,6 +7,8 @@ package physics
 import (
 	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/experimental/collision"
+	"github.com/g3n/engine/experimental/collision/shape"
+	"github.com/g3n/engine/experimental/physics/object"
 	"github.com/g3n/engine/geometry"
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/graphic"
@@ -15,7 +17,229 @@ import (
 )
 
 // This file contains helpful infrastructure for debugging physics
+
+// DebugFlags selects the parts of a simulation shown by a DebugHelper.
+type DebugFlags int
+
+// Parts of a simulation shown by a DebugHelper
+const (
+	DebugColliders = DebugFlags(1 << iota)                        // Collision shapes of the bodies
+	DebugContacts                                                 // Contact points and normals of the last step
+	DebugJoints                                                   // Anchors of the constraints
+	DebugAll       = DebugColliders | DebugContacts | DebugJoints // All parts
+)
+
+// Colors of the parts shown by the DebugHelper
+var (
+	debugDynamicColor  = math32.Color{R: 0.2, G: 1, B: 0.2}
+	debugSleepingColor = math32.Color{R: 0.5, G: 0.5, B: 0.5}
+	debugStaticColor   = math32.Color{R: 0.2, G: 0.6, B: 1}
+	debugContactColor  = math32.Color{R: 1, G: 0.2, B: 0.2}
+	debugJointColor    = math32.Color{R: 1, G: 1, B: 0.2}
+)
+
+// Number of segments of the circles of the sphere colliders
+const debugCircleSegments = 24
+
+// DebugHelper draws the collision shapes of the bodies of a simulation, the contacts
+// found in its last step and the anchors of its constraints, each of which can be toggled.
+// Dynamic bodies are green, or gray when sleeping, static and kinematic bodies are blue,
+// contacts are red and constraints are yellow. It is drawn over the scene and
+// is in world coordinates, so it should be added to the scene root.
 type DebugHelper struct {
+	graphic.Lines
+	sim   *Simulation // Simulation shown
+	flags DebugFlags  // Parts shown
+	size  float32     // Size of the markers of contacts and anchors
+}
+
+// NewDebugHelper creates and returns a pointer to a new DebugHelper
+// showing the specified parts of the simulation.
+func NewDebugHelper(sim *Simulation, flags DebugFlags) *DebugHelper {
+
+	dh := new(DebugHelper)
+	dh.sim = sim
+	dh.flags = flags
+	dh.size = 0.1
+
+	geom := geometry.NewGeometry()
+	vbo := gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexPosition)
+	// The positions are usually updated every frame
+	vbo.SetUsage(gls.STREAM_DRAW)
+	geom.AddVBO(vbo)
+	geom.AddVBO(gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexColor))
+
+	mat := material.NewBasic()
+	mat.SetDepthTest(false)
+	mat.SetTransparent(true)
+
+	dh.Lines.Init(geom, mat)
+	dh.SetCullable(false)
+	dh.Update()
+	return dh
+}
+
+// SetShown sets whether the specified parts of the simulation are shown.
+func (dh *DebugHelper) SetShown(flags DebugFlags, state bool) {
+
+	if state {
+		dh.flags |= flags
+	} else {
+		dh.flags &^= flags
+	}
+}
+
+// Shown returns whether all the specified parts of the simulation are shown.
+func (dh *DebugHelper) Shown(flags DebugFlags) bool {
+
+	return dh.flags&flags == flags
+}
+
+// SetMarkerSize sets the size of the markers of contacts and constraint anchors (default = 0.1).
+func (dh *DebugHelper) SetMarkerSize(size float32) {
+
+	dh.size = size
+}
+
+// MarkerSize returns the size of the markers of contacts and constraint anchors.
+func (dh *DebugHelper) MarkerSize() float32 {
+
+	return dh.size
+}
+
+// Update should be called in the render loop, after stepping
+// the simulation, to update the lines from its current state.
+func (dh *DebugHelper) Update() {
+
+	positions := math32.NewArrayF32(0, 0)
+	colors := math32.NewArrayF32(0, 0)
+	line := func(a, b *math32.Vector3, color *math32.Color) {
+		positions.AppendVector3(a, b)
+		colors.AppendColor(color, color)
+	}
+	cross := func(p *math32.Vector3, color *math32.Color) {
+		for _, axis := range []math32.Vector3{{X: dh.size}, {Y: dh.size}, {Z: dh.size}} {
+			a := *p
+			b := *p
+			a.Sub(&axis)
+			b.Add(&axis)
+			line(&a, &b, color)
+		}
+	}
+
+	if dh.Shown(DebugColliders) {
+		for _, body := range dh.sim.Bodies() {
+			if body == nil || body.Shape() == nil {
+				continue
+			}
+			color := &debugDynamicColor
+			if body.BodyType() != object.Dynamic {
+				color = &debugStaticColor
+			} else if body.Sleeping() {
+				color = &debugSleepingColor
+			}
+			pos := body.Position()
+			quat := body.Quaternion()
+			switch s := body.Shape().(type) {
+			case *shape.Sphere:
+				axes := []math32.Vector3{{X: 1}, {Y: 1}, {Z: 1}}
+				for i := range axes {
+					axes[i].ApplyQuaternion(quat)
+				}
+				for i := range axes {
+					u := axes[i]
+					v := axes[(i+1)%3]
+					var prev math32.Vector3
+					for j := 0; j <= debugCircleSegments; j++ {
+						a := 2 * math32.Pi * float32(j) / debugCircleSegments
+						p := pos
+						p.Add(u.Clone().MultiplyScalar(s.Radius() * math32.Cos(a)))
+						p.Add(v.Clone().MultiplyScalar(s.Radius() * math32.Sin(a)))
+						if j > 0 {
+							line(&prev, &p, color)
+						}
+						prev = p
+					}
+				}
+			case *shape.ConvexHull:
+				for _, face := range s.Faces() {
+					w := s.WorldFace(face, &pos, quat)
+					line(&w[0], &w[1], color)
+					line(&w[1], &w[2], color)
+					line(&w[2], &w[0], color)
+				}
+			case *shape.Plane:
+				// A square of the infinite plane with its normal
+				normal := s.Normal()
+				normal.ApplyQuaternion(quat)
+				u := math32.Vector3{X: 1}
+				if math32.Abs(normal.X) > 0.9 {
+					u = math32.Vector3{Y: 1}
+				}
+				u.Cross(&normal).Normalize()
+				v := normal
+				v.Cross(&u)
+				half := dh.size * 50
+				var corners [4]math32.Vector3
+				for i, sign := range [][2]float32{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+					corners[i] = pos
+					corners[i].Add(u.Clone().MultiplyScalar(sign[0] * half))
+					corners[i].Add(v.Clone().MultiplyScalar(sign[1] * half))
+				}
+				for i := range corners {
+					line(&corners[i], &corners[(i+1)%4], color)
+				}
+				tip := normal
+				tip.MultiplyScalar(dh.size * 10).Add(&pos)
+				line(&pos, &tip, color)
+			}
+		}
+	}
+
+	if dh.Shown(DebugContacts) {
+		for _, c := range dh.sim.Contacts() {
+			p := c.BodyA().Position()
+			ra := c.RA()
+			p.Add(&ra)
+			cross(&p, &debugContactColor)
+			tip := c.Normal()
+			tip.MultiplyScalar(dh.size * 4).Add(&p)
+			line(&p, &tip, &debugContactColor)
+		}
+	}
+
+	if dh.Shown(DebugJoints) {
+		type pivoted interface {
+			PivotA() math32.Vector3
+			PivotB() math32.Vector3
+		}
+		for _, c := range dh.sim.Constraints() {
+			ba := c.BodyA()
+			bb := c.BodyB()
+			pa := ba.Position()
+			pb := bb.Position()
+			if pc, ok := c.(pivoted); ok {
+				// Lines from the bodies to their anchors and between the anchors
+				pivotA := pc.PivotA()
+				pivotB := pc.PivotB()
+				wa := ba.VectorToWorld(&pivotA)
+				wb := bb.VectorToWorld(&pivotB)
+				wa.Add(&pa)
+				wb.Add(&pb)
+				line(&pa, &wa, &debugJointColor)
+				line(&pb, &wb, &debugJointColor)
+				line(&wa, &wb, &debugJointColor)
+				cross(&wa, &debugJointColor)
+				cross(&wb, &debugJointColor)
+				continue
+			}
+			line(&pa, &pb, &debugJointColor)
+		}
+	}
+
+	geom := dh.GetGeometry()
+	geom.VBO(gls.VertexPosition).SetBuffer(positions)
+	geom.VBO(gls.VertexColor).SetBuffer(colors)
 }
 
 func ShowWorldFace(scene *core.Node, face []math32.Vector3, color *math32.Color) {
@@ -0,0 +1,175 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fracture
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/experimental/physics"
+	"github.com/g3n/engine/experimental/physics/object"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// OnShatter is dispatched by a Destructible after it is replaced by its chunks.
+// Data is *ShatterEvent.
+const OnShatter = "fracture.OnShatter"
+
+// ShatterEvent describes the shattering of a Destructible.
+type ShatterEvent struct {
+	Point  math32.Vector3 // Impact point in world coordinates
+	Pieces []*object.Body // Bodies of the chunks
+}
+
+// Destructible replaces an intact physics body by the bodies of its precomputed chunks when
+// it is hit hard enough. Collisions are detected while the simulation steps, when bodies
+// cannot be added or removed, so Update must be called after each step to shatter the body.
+// The intact body is expected to be a child of the scene root without scale, as physics bodies are.
+type Destructible struct {
+	core.Dispatcher                     // Embedded event dispatcher
+	sim             *physics.Simulation // Simulation of the bodies
+	body            *object.Body        // Intact body
+	chunks          []*Chunk            // Chunks replacing the body
+	capMat          material.IMaterial  // Material of the interior caps
+	Threshold       float32             // Relative speed of an impact which shatters the body (default is 5)
+	Impulse         float32             // Speed of the chunks away from the impact point (default is 2)
+	pending         bool                // Whether an impact is waiting for Update
+	point           math32.Vector3      // Impact point
+	pieces          []*object.Body      // Bodies of the chunks once shattered
+}
+
+// NewDestructible creates and returns a pointer to a new destructible for the specified body,
+// which must be in the simulation, and its chunks computed by Fracture from the geometry of
+// its graphic. The chunks keep the materials of the graphic and their interior caps use the
+// specified material. The chunks can be shared by several destructibles.
+func NewDestructible(sim *physics.Simulation, body *object.Body, chunks []*Chunk, capMat material.IMaterial) *Destructible {
+
+	d := new(Destructible)
+	d.Dispatcher.Initialize()
+	d.sim = sim
+	d.body = body
+	d.chunks = chunks
+	d.capMat = capMat
+	d.Threshold = 5
+	d.Impulse = 2
+	body.SubscribeID(physics.CollisionEv, d, d.onCollide)
+	return d
+}
+
+// Body returns the intact body.
+func (d *Destructible) Body() *object.Body {
+
+	return d.body
+}
+
+// Shattered returns whether the body was replaced by its chunks.
+func (d *Destructible) Shattered() bool {
+
+	return d.pieces != nil
+}
+
+// Pieces returns the bodies of the chunks once shattered.
+func (d *Destructible) Pieces() []*object.Body {
+
+	return d.pieces
+}
+
+// Update shatters the body if it was hit hard enough during the last simulation step.
+func (d *Destructible) Update() {
+
+	if d.pending {
+		d.pending = false
+		d.Shatter(&d.point)
+	}
+}
+
+// Shatter replaces the intact body and its graphic by the bodies and meshes of the chunks,
+// which keep the velocity of the body and move away from the specified impact point,
+// in world coordinates. It must not be called while the simulation is stepping.
+func (d *Destructible) Shatter(point *math32.Vector3) {
+
+	if d.pieces != nil {
+		return
+	}
+	d.body.UnsubscribeID(physics.CollisionEv, d)
+	node := d.body.GetNode()
+	parent := node.Parent()
+	pos := node.Position()
+	rot := node.Quaternion()
+	vel := d.body.Velocity()
+	angVel := d.body.AngularVelocity()
+	mass := d.body.Mass()
+	materials := d.body.Materials()
+	d.sim.RemoveBody(d.body)
+	if parent != nil {
+		parent.GetNode().Remove(node.GetINode())
+	}
+
+	total := float32(0)
+	for _, c := range d.chunks {
+		total += c.Volume
+	}
+	d.pieces = make([]*object.Body, 0, len(d.chunks))
+	for i, c := range d.chunks {
+		mesh := graphic.NewMesh(c.Geometry.Incref(), nil)
+		if len(materials) > 0 {
+			mesh.AddGroupMaterial(materials[0].IMaterial(), 0)
+		}
+		if d.capMat != nil {
+			mesh.AddGroupMaterial(d.capMat, 1)
+		}
+		offset := c.Center
+		offset.ApplyQuaternion(&rot)
+		var cpos math32.Vector3
+		cpos.AddVectors(&pos, &offset)
+		mesh.SetPositionVec(&cpos)
+		mesh.SetQuaternionQuat(&rot)
+		if parent != nil {
+			parent.GetNode().Add(mesh)
+		}
+
+		// Velocity of the point of the body at the chunk center plus the push from the impact
+		piece := object.NewBody(mesh)
+		if total > 0 && mass > 0 {
+			piece.SetMass(mass * c.Volume / total)
+		}
+		var v, push math32.Vector3
+		v.CrossVectors(&angVel, &offset).Add(&vel)
+		push.SubVectors(&cpos, point)
+		if push.LengthSq() > 0 {
+			v.Add(push.Normalize().MultiplyScalar(d.Impulse))
+		}
+		piece.SetVelocity(&v)
+		piece.SetAngularVelocity(&angVel)
+		d.sim.AddBody(piece, fmt.Sprintf("%s.%d", d.body.Name(), i))
+		d.pieces = append(d.pieces, piece)
+	}
+	d.Dispatch(OnShatter, &ShatterEvent{Point: *point, Pieces: d.pieces})
+}
+
+// Dispose stops listening to the collisions of the intact body.
+func (d *Destructible) Dispose() {
+
+	d.body.UnsubscribeID(physics.CollisionEv, d)
+}
+
+// onCollide records the impact point of a collision fast enough to shatter the body.
+func (d *Destructible) onCollide(evname string, ev interface{}) {
+
+	cev := ev.(*physics.CollideEvent)
+	rel := d.body.Velocity()
+	other := cev.Body().Velocity()
+	rel.Sub(&other)
+	if d.pending || rel.Length() < d.Threshold {
+		return
+	}
+	contact := cev.Contact()
+	d.point = contact.BodyA().Position()
+	ra := contact.RA()
+	d.point.Add(&ra)
+	d.pending = true
+}
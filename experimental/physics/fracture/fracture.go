@@ -0,0 +1,515 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fracture splits meshes into Voronoi chunks ahead of time and replaces
+// intact physics bodies by their chunks when they are hit hard enough.
+package fracture
+
+import (
+	"math/rand"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// Chunk is a piece of a fractured geometry.
+type Chunk struct {
+	Geometry *geometry.Geometry // Geometry centered on the center of the chunk, with the original surface in group 0 and the interior caps in group 1
+	Center   math32.Vector3     // Center of the chunk in the space of the original geometry
+	Volume   float32            // Volume of the chunk
+}
+
+// vertex is a vertex of a triangle being clipped.
+type vertex struct {
+	pos    math32.Vector3
+	normal math32.Vector3
+	uv     math32.Vector2
+}
+
+// triangle is a triangle being clipped, from the original surface or from an interior cap.
+type triangle struct {
+	v   [3]vertex
+	cap bool
+}
+
+// RandomSites returns the specified number of sites uniformly distributed in a box,
+// usually the bounding box of the geometry to fracture.
+func RandomSites(box *math32.Box3, count int, seed int64) []math32.Vector3 {
+
+	rng := rand.New(rand.NewSource(seed))
+	sites := make([]math32.Vector3, count)
+	for i := range sites {
+		sites[i].Set(
+			box.Min.X+rng.Float32()*(box.Max.X-box.Min.X),
+			box.Min.Y+rng.Float32()*(box.Max.Y-box.Min.Y),
+			box.Min.Z+rng.Float32()*(box.Max.Z-box.Min.Z),
+		)
+	}
+	return sites
+}
+
+// ImpactSites returns the specified number of sites normally distributed around an impact
+// point with the specified spread, so chunks are small near the point. Sites falling out of
+// the box are drawn again, as many sites on its faces would give slivers.
+func ImpactSites(box *math32.Box3, point *math32.Vector3, spread float32, count int, seed int64) []math32.Vector3 {
+
+	rng := rand.New(rand.NewSource(seed))
+	sites := make([]math32.Vector3, count)
+	for i := range sites {
+		for try := 0; try < 16; try++ {
+			sites[i].Set(
+				point.X+float32(rng.NormFloat64())*spread,
+				point.Y+float32(rng.NormFloat64())*spread,
+				point.Z+float32(rng.NormFloat64())*spread,
+			)
+			if box.ContainsPoint(&sites[i]) {
+				break
+			}
+		}
+		sites[i].Clamp(&box.Min, &box.Max)
+	}
+	return sites
+}
+
+// Fracture splits the specified closed triangle geometry into the Voronoi cells of the
+// specified sites, in the space of the geometry, and returns the non empty chunks.
+// The holes cut into each chunk are closed by caps whose texture coordinates are their
+// positions in the plane of the cut, so a tiling texture covers them evenly.
+// Chunks of convex geometries are convex, as physics bodies require.
+func Fracture(geom *geometry.Geometry, sites []math32.Vector3) []*Chunk {
+
+	tris := readTriangles(geom)
+	bbox := geom.BoundingBox()
+	var size math32.Vector3
+	bbox.Size(&size)
+	eps := math32.Max(size.Length(), 1) * 1e-5
+
+	var chunks []*Chunk
+	for i := range sites {
+		cell := tris
+		for j := range sites {
+			if i == j {
+				continue
+			}
+			if sites[i].DistanceToSquared(&sites[j]) == 0 {
+				// Repeated sites have a single cell
+				if j < i {
+					cell = nil
+					break
+				}
+				continue
+			}
+			// Bisector plane, keeping the half space of site i
+			var normal, mid math32.Vector3
+			normal.SubVectors(&sites[j], &sites[i]).Normalize()
+			mid.AddVectors(&sites[i], &sites[j]).MultiplyScalar(0.5)
+			cell = clip(cell, &normal, normal.Dot(&mid), eps)
+			if len(cell) == 0 {
+				break
+			}
+		}
+		if len(cell) == 0 {
+			continue
+		}
+		if chunk := newChunk(cell); chunk.Volume > 0 {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// readTriangles returns the triangles of the geometry with their normals and texture coordinates.
+func readTriangles(geom *geometry.Geometry) []triangle {
+
+	indices := geom.TriangleIndices()
+	posVBO := geom.VBO(gls.VertexPosition)
+	if indices == nil || posVBO == nil {
+		return nil
+	}
+	normVBO := geom.VBO(gls.VertexNormal)
+	uvVBO := geom.VBO(gls.VertexTexcoord)
+	var values [4]float32
+	read := func(idx int, v *vertex) {
+		posVBO.ReadAttrib(posVBO.Attrib(gls.VertexPosition), idx, values[:])
+		v.pos.Set(values[0], values[1], values[2])
+		if normVBO != nil {
+			normVBO.ReadAttrib(normVBO.Attrib(gls.VertexNormal), idx, values[:])
+			v.normal.Set(values[0], values[1], values[2])
+		}
+		if uvVBO != nil {
+			uvVBO.ReadAttrib(uvVBO.Attrib(gls.VertexTexcoord), idx, values[:])
+			v.uv.Set(values[0], values[1])
+		}
+	}
+	tris := make([]triangle, len(indices)/3)
+	for t := range tris {
+		for k := 0; k < 3; k++ {
+			read(int(indices[3*t+k]), &tris[t].v[k])
+		}
+		if normVBO == nil {
+			// Flat normals
+			n := math32.Normal(&tris[t].v[0].pos, &tris[t].v[1].pos, &tris[t].v[2].pos, nil)
+			for k := 0; k < 3; k++ {
+				tris[t].v[k].normal = *n
+			}
+		}
+	}
+	return tris
+}
+
+// clip returns the triangles clipped to the half space where the dot product with the
+// normal is at most the constant, with the cut closed by cap triangles facing the normal.
+func clip(tris []triangle, normal *math32.Vector3, constant, eps float32) []triangle {
+
+	// Nothing to do if the triangles are all inside
+	inside := true
+	for t := range tris {
+		for k := 0; k < 3 && inside; k++ {
+			inside = tris[t].v[k].pos.Dot(normal)-constant <= eps
+		}
+	}
+	if inside {
+		return tris
+	}
+
+	out := make([]triangle, 0, len(tris))
+	var segments [][2]math32.Vector3
+	var poly [4]vertex
+	for t := range tris {
+		tri := &tris[t]
+		var dist [3]float32
+		in, strict, on := 0, 0, 0
+		for k := 0; k < 3; k++ {
+			// Vertices within eps of the plane are on it
+			dist[k] = tri.v[k].pos.Dot(normal) - constant
+			if math32.Abs(dist[k]) <= eps {
+				dist[k] = 0
+				on++
+			}
+			if dist[k] <= 0 {
+				in++
+			}
+			if dist[k] < 0 {
+				strict++
+			}
+		}
+		if in == 3 {
+			// Edges in the plane bound the cap, unless the triangle across them is also kept
+			out = append(out, *tri)
+			for k := 0; k < 3 && on == 2; k++ {
+				next := (k + 1) % 3
+				if dist[k] == 0 && dist[next] == 0 {
+					segments = append(segments, [2]math32.Vector3{tri.v[next].pos, tri.v[k].pos})
+				}
+			}
+			continue
+		}
+		if strict == 0 {
+			continue
+		}
+
+		// Sutherland-Hodgman clipping of the triangle, which gives a triangle or a quad.
+		// The cut edge of the kept polygon goes from where it exits the half space to where
+		// it enters it again, so the cap has the opposite direction.
+		n := 0
+		var exit, entry math32.Vector3
+		for k := 0; k < 3; k++ {
+			next := (k + 1) % 3
+			inK, inNext := dist[k] <= 0, dist[next] <= 0
+			if inK {
+				poly[n] = tri.v[k]
+				n++
+			}
+			if inK != inNext {
+				v := intersect(&tri.v[k], &tri.v[next], dist[k], dist[next])
+				if inK {
+					exit = v.pos
+				} else {
+					entry = v.pos
+				}
+				// The intersection is the vertex itself when it is on the plane
+				if (inK && dist[k] == 0) || (inNext && dist[next] == 0) {
+					continue
+				}
+				poly[n] = v
+				n++
+			}
+		}
+		for k := 1; k+1 < n; k++ {
+			out = append(out, triangle{v: [3]vertex{poly[0], poly[k], poly[k+1]}, cap: tri.cap})
+		}
+		segments = append(segments, [2]math32.Vector3{entry, exit})
+	}
+	return append(out, capTriangles(segments, normal, eps)...)
+}
+
+// intersect returns the vertex where the edge between two vertices crosses the plane,
+// computed from the endpoints in a fixed order so shared edges give the same vertex.
+func intersect(a, b *vertex, da, db float32) vertex {
+
+	if db < da || (db == da && b.pos.X < a.pos.X) {
+		a, b = b, a
+		da, db = db, da
+	}
+	t := float32(0)
+	if db != da {
+		t = math32.Clamp(da/(da-db), 0, 1)
+	}
+	var v vertex
+	v.pos = a.pos
+	v.pos.Lerp(&b.pos, t)
+	v.normal = a.normal
+	v.normal.Lerp(&b.normal, t).Normalize()
+	v.uv = a.uv
+	v.uv.Lerp(&b.uv, t)
+	return v
+}
+
+// capTriangles chains the cut segments into loops and returns the triangles closing
+// them, facing the normal of the cutting plane.
+func capTriangles(segments [][2]math32.Vector3, normal *math32.Vector3, eps float32) []triangle {
+
+	if len(segments) < 3 {
+		return nil
+	}
+
+	// Endpoints closer than eps are welded, along with the points welded to them,
+	// so the tiny segments of slivers vanish
+	type cell [3]int64
+	grid := make(map[cell][]int, 2*len(segments))
+	points := make([]math32.Vector3, 0, 2*len(segments))
+	parent := make([]int, 0, 2*len(segments))
+	var find func(id int) int
+	find = func(id int) int {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	for i := range segments {
+		for _, p := range segments[i] {
+			id := len(points)
+			points = append(points, p)
+			parent = append(parent, id)
+			c := cell{int64(math32.Floor(p.X / eps)), int64(math32.Floor(p.Y / eps)), int64(math32.Floor(p.Z / eps))}
+			for dx := int64(-1); dx <= 1; dx++ {
+				for dy := int64(-1); dy <= 1; dy++ {
+					for dz := int64(-1); dz <= 1; dz++ {
+						for _, other := range grid[cell{c[0] + dx, c[1] + dy, c[2] + dz}] {
+							if points[other].DistanceToSquared(&p) <= eps*eps {
+								parent[find(other)] = find(id)
+							}
+						}
+					}
+				}
+			}
+			grid[c] = append(grid[c], id)
+		}
+	}
+
+	// Opposite segments, from edges in the plane shared by kept triangles, cancel out
+	edges := make([][2]int, 0, len(segments))
+	index := make(map[[2]int]int, len(segments))
+	for i := range segments {
+		e := [2]int{find(2 * i), find(2*i + 1)}
+		if e[0] == e[1] {
+			continue
+		}
+		if j, ok := index[[2]int{e[1], e[0]}]; ok {
+			edges[j][0] = -1
+			delete(index, [2]int{e[1], e[0]})
+			continue
+		}
+		index[e] = len(edges)
+		edges = append(edges, e)
+	}
+	starts := make(map[int][]int, len(edges))
+	for i, e := range edges {
+		if e[0] >= 0 {
+			starts[e[0]] = append(starts[e[0]], i)
+		}
+	}
+
+	// Chains of edges from their start points
+	used := make([]bool, len(edges))
+	var closed, open [][]int
+	for i := range edges {
+		if used[i] || edges[i][0] < 0 {
+			continue
+		}
+		var chain []int
+		last := i
+		for cur := i; cur >= 0 && !used[cur]; {
+			used[cur] = true
+			chain = append(chain, edges[cur][0])
+			last = cur
+			next := -1
+			for _, e := range starts[edges[cur][1]] {
+				if !used[e] {
+					next = e
+					break
+				}
+			}
+			cur = next
+		}
+		if edges[last][1] == chain[0] {
+			closed = append(closed, chain)
+		} else {
+			open = append(open, append(chain, edges[last][1]))
+		}
+	}
+
+	// Chains left open by nearly degenerate cuts are joined to the closest chain start
+	for len(open) > 0 {
+		chain := open[0]
+		end := &points[chain[len(chain)-1]]
+		best, bestDist := 0, end.DistanceToSquared(&points[chain[0]])
+		for k := 1; k < len(open); k++ {
+			if d := end.DistanceToSquared(&points[open[k][0]]); d < bestDist {
+				best, bestDist = k, d
+			}
+		}
+		if best == 0 {
+			closed = append(closed, chain)
+			open = open[1:]
+			continue
+		}
+		open[0] = append(chain, open[best]...)
+		open = append(open[:best], open[best+1:]...)
+	}
+	var loops [][]math32.Vector3
+	for _, chain := range closed {
+		if len(chain) < 3 {
+			continue
+		}
+		loop := make([]math32.Vector3, len(chain))
+		for k, id := range chain {
+			loop[k] = points[id]
+		}
+		loops = append(loops, loop)
+	}
+
+	// Loops in 2D coordinates of the plane, whose orientation is counter clockwise around the normal
+	var u, v math32.Vector3
+	if math32.Abs(normal.X) < 0.9 {
+		u.Set(1, 0, 0)
+	} else {
+		u.Set(0, 1, 0)
+	}
+	u.Sub(normal.Clone().MultiplyScalar(u.Dot(normal))).Normalize()
+	v.CrossVectors(normal, &u)
+	flat := make([][]math32.Vector2, len(loops))
+	for i, loop := range loops {
+		flat[i] = make([]math32.Vector2, len(loop))
+		for k := range loop {
+			flat[i][k] = math32.Vector2{X: loop[k].Dot(&u), Y: loop[k].Dot(&v)}
+		}
+	}
+
+	// Loops inside an even number of other loops are outer contours and the others are holes
+	// of the smallest loop containing them
+	level := make([]int, len(loops))
+	for i := range flat {
+		for j := range flat {
+			if i != j && polygonContains(flat[j], flat[i][0]) {
+				level[i]++
+			}
+		}
+	}
+	var tris []triangle
+	for i := range flat {
+		if level[i]%2 != 0 {
+			continue
+		}
+		points := append([]math32.Vector3{}, loops[i]...)
+		var holes [][]math32.Vector2
+		for j := range flat {
+			if level[j] == level[i]+1 && polygonContains(flat[i], flat[j][0]) {
+				holes = append(holes, flat[j])
+				points = append(points, loops[j]...)
+			}
+		}
+		flatPoints := append([]math32.Vector2{}, flat[i]...)
+		for _, h := range holes {
+			flatPoints = append(flatPoints, h...)
+		}
+		idx := geometry.Triangulate(flat[i], holes)
+		for k := 0; k+2 < len(idx); k += 3 {
+			var tri triangle
+			tri.cap = true
+			for c := 0; c < 3; c++ {
+				tri.v[c].pos = points[idx[k+c]]
+				tri.v[c].normal = *normal
+				tri.v[c].uv = flatPoints[idx[k+c]]
+			}
+			tris = append(tris, tri)
+		}
+	}
+	return tris
+}
+
+// polygonContains returns whether the point is inside the polygon.
+func polygonContains(poly []math32.Vector2, p math32.Vector2) bool {
+
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// newChunk returns the chunk with the specified triangles, centered on its center of mass,
+// with the surface triangles before the cap triangles.
+func newChunk(tris []triangle) *Chunk {
+
+	// Center of mass from the signed tetrahedra between the origin and each triangle
+	c := new(Chunk)
+	var weighted math32.Vector3
+	var cross math32.Vector3
+	for t := range tris {
+		a, b, d := &tris[t].v[0].pos, &tris[t].v[1].pos, &tris[t].v[2].pos
+		vol := a.Dot(cross.CrossVectors(b, d)) / 6
+		c.Volume += vol
+		weighted.X += vol * (a.X + b.X + d.X) / 4
+		weighted.Y += vol * (a.Y + b.Y + d.Y) / 4
+		weighted.Z += vol * (a.Z + b.Z + d.Z) / 4
+	}
+	if c.Volume <= 0 {
+		return c
+	}
+	c.Center = weighted
+	c.Center.MultiplyScalar(1 / c.Volume)
+
+	positions := math32.NewArrayF32(0, 9*len(tris))
+	normals := math32.NewArrayF32(0, 9*len(tris))
+	uvs := math32.NewArrayF32(0, 6*len(tris))
+	indices := math32.NewArrayU32(0, 3*len(tris))
+	g := geometry.NewGeometry()
+	for group, caps := range []bool{false, true} {
+		start := indices.Size()
+		for t := range tris {
+			if tris[t].cap != caps {
+				continue
+			}
+			for k := 0; k < 3; k++ {
+				v := &tris[t].v[k]
+				indices.Append(uint32(positions.Size() / 3))
+				positions.Append(v.pos.X-c.Center.X, v.pos.Y-c.Center.Y, v.pos.Z-c.Center.Z)
+				normals.AppendVector3(&v.normal)
+				uvs.AppendVector2(&v.uv)
+			}
+		}
+		g.AddGroup(start, indices.Size()-start, group)
+	}
+	g.SetIndices(indices)
+	g.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	g.AddVBO(gls.NewVBO(normals).AddAttrib(gls.VertexNormal))
+	g.AddVBO(gls.NewVBO(uvs).AddAttrib(gls.VertexTexcoord))
+	c.Geometry = g
+	return c
+}
@@ -257,6 +257,12 @@ func (b *Body) SetMass(mass float32) {
 	b.UpdateMassProperties()
 }
 
+// Mass returns the mass of the body.
+func (b *Body) Mass() float32 {
+
+	return b.mass
+}
+
 func (b *Body) SetIndex(i int) {
 
 	b.index = i
@@ -48,6 +48,7 @@ type Simulation struct {
 	solver      solver.ISolver // The solver algorithm to use, default is Gauss-Seidel
 
 	constraints       []constraint.IConstraint  // All constraints
+	contactEqs        []*equation.Contact       // Contact equations generated in the last step
 
 	materials         []*Material               // All added materials
 	cMaterials        []*ContactMaterial
@@ -142,13 +143,19 @@ func (s *Simulation) AddBody(body *object.Body, name string) {
 	//s.Dispatch(AddBodyEvent, BodyEvent{body})
 }
 
-// RemoveBody removes the specified body from the simulation.
-// Returns true if found, false otherwise.
+// RemoveBody removes the specified body from the simulation and updates the indices of the
+// following bodies. It must not be called while the simulation is stepping, such as from
+// a collision event handler. Returns true if found, false otherwise.
 func (s *Simulation) RemoveBody(body *object.Body) bool {
 
 	for idx, current := range s.bodies {
 		if current == body {
-			s.bodies[idx] = nil
+			copy(s.bodies[idx:], s.bodies[idx+1:])
+			s.bodies[len(s.bodies)-1] = nil
+			s.bodies = s.bodies[:len(s.bodies)-1]
+			for i := idx; i < len(s.bodies); i++ {
+				s.bodies[i].SetIndex(i)
+			}
 			// TODO dispatch remove-body event
 			//s.Dispatch(AddBodyEvent, BodyEvent{body})
 			return true
@@ -175,7 +182,6 @@ func (s *Simulation) RemoveBody(body *object.Body) bool {
 //}
 
 // Bodies returns the slice of bodies under simulation.
-// The slice may contain nil values!
 func (s *Simulation) Bodies() []*object.Body{
 
 	return s.bodies
@@ -258,6 +264,19 @@ func (s *Simulation) RemoveConstraint(c constraint.IConstraint) {
 	// TODO
 }
 
+// Constraints returns the constraints added to the simulation.
+func (s *Simulation) Constraints() []constraint.IConstraint {
+
+	return s.constraints
+}
+
+// Contacts returns the contact equations generated in the last internal step,
+// whose RA and RB are the contact points relative to the positions of the bodies.
+func (s *Simulation) Contacts() []*equation.Contact {
+
+	return s.contactEqs
+}
+
 func (s *Simulation) AddMaterial(mat *Material) {
 
 	s.materials = append(s.materials, mat)
@@ -301,6 +320,18 @@ type CollideEvent struct {
 	contactEq *equation.Contact
 }
 
+// Body returns the other body involved in the collision.
+func (e *CollideEvent) Body() *object.Body {
+
+	return e.body
+}
+
+// Contact returns the contact equation of the collision.
+func (e *CollideEvent) Contact() *equation.Contact {
+
+	return e.contactEq
+}
+
 // TODO AddBodyEvent, RemoveBodyEvent
 type ContactEvent struct {
 	bodyA *object.Body
@@ -412,6 +443,7 @@ func (s *Simulation) internalStep(dt float32) {
 
     // Resolve collisions and generate contact and friction equations
 	contactEqs, frictionEqs := s.narrowphase.GenerateEquations(pairs)
+	s.contactEqs = contactEqs
 
 	// Add all friction equations to solver
 	for i := 0; i < len(frictionEqs); i++ {
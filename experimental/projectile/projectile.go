@@ -0,0 +1,298 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package projectile simulates pooled ballistic projectiles, such as bullets,
+// arrows and shells, with gravity, drag, swept ray collisions and tracers.
+package projectile
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/experimental/collision"
+	"github.com/g3n/engine/math32"
+)
+
+// Projectile events.
+const (
+	OnHit    = "projectile.OnHit"    // Projectile hit a target. Data is *HitEvent
+	OnExpire = "projectile.OnExpire" // Projectile reached the end of its lifetime. Data is *Projectile
+)
+
+// HitEvent describes the hit of a target by a projectile.
+type HitEvent struct {
+	Projectile *Projectile         // Projectile which hit the target
+	Intersect  collision.Intersect // Point, distance and node which was hit
+	Keep       bool                // Set by handlers to keep the projectile flying, after changing its velocity for a ricochet or a penetration
+}
+
+// Projectile is a simulated projectile in world coordinates.
+// Projectiles are recycled by their system after they hit a target or expire,
+// so they must not be kept after the OnHit and OnExpire events.
+type Projectile struct {
+	Position math32.Vector3 // Current position
+	Velocity math32.Vector3 // Current velocity
+	Gravity  float32        // Scale of the gravity of the system
+	Drag     float32        // Deceleration per squared unit of speed
+	Life     float32        // Remaining lifetime in seconds
+	Owner    core.INode     // Node which fired the projectile and cannot be hit by it, with its descendants, or nil
+	Node     core.INode     // Node following the projectile, such as a mesh, which is left in the scene when the projectile ends, or nil
+	Data     interface{}    // Application data, such as the damage of the projectile
+
+	origin   math32.Vector3 // Position where the projectile was fired
+	traveled float32        // Distance traveled
+	active   bool           // Whether the projectile is flying
+}
+
+// Active returns whether the projectile is flying, as opposed to waiting in the pool.
+func (p *Projectile) Active() bool {
+
+	return p.active
+}
+
+// Traveled returns the distance traveled by the projectile since it was fired.
+func (p *Projectile) Traveled() float32 {
+
+	return p.traveled
+}
+
+// Origin returns the position where the projectile was fired.
+func (p *Projectile) Origin() math32.Vector3 {
+
+	return p.origin
+}
+
+// System fires, moves and collides projectiles against target nodes, reusing
+// the projectiles which hit or expire. Targets are raycast from the previous to
+// the new position of each projectile at each step, so fast projectiles cannot
+// tunnel through thin targets.
+type System struct {
+	core.Dispatcher                // Embedded event dispatcher
+	Gravity         math32.Vector3 // Acceleration of gravity (default is 9.8 m/s² down in the engine coordinate system)
+	Drag            float32        // Drag of new projectiles (default is 0)
+	Lifetime        float32        // Lifetime of new projectiles in seconds (default is 5)
+	MaxStep         float32        // Maximum time step in seconds, larger steps are split (default is 1/30)
+	Targets         []core.INode   // Nodes which can be hit, with their descendants
+	active          []*Projectile  // Flying projectiles
+	free            []*Projectile  // Recycled projectiles
+	rc              *collision.Raycaster
+	tracers         *Tracers // Tracers of the projectiles, if created
+	fired           int      // Number of projectiles fired
+	hits            int      // Number of hits
+	allocated       int      // Number of projectiles allocated
+}
+
+// NewSystem creates and returns a pointer to a new projectile system
+// with the specified number of preallocated projectiles.
+func NewSystem(capacity int) *System {
+
+	s := new(System)
+	s.Dispatcher.Initialize()
+	cs := core.EngineCoords()
+	s.Gravity = cs.UpVector()
+	s.Gravity.MultiplyScalar(-cs.Meters(9.8))
+	s.Lifetime = 5
+	s.MaxStep = 1.0 / 30
+	s.active = make([]*Projectile, 0, capacity)
+	s.free = make([]*Projectile, 0, capacity)
+	for i := 0; i < capacity; i++ {
+		s.free = append(s.free, new(Projectile))
+	}
+	s.allocated = capacity
+	s.rc = collision.NewRaycaster(&math32.Vector3{}, &math32.Vector3{Z: -1})
+	return s
+}
+
+// Fire launches and returns a projectile from the specified position, in world coordinates,
+// with the specified velocity and the drag and lifetime of the system.
+// Fields of the projectile, such as its owner, can be changed before the next Update.
+func (s *System) Fire(position, velocity *math32.Vector3) *Projectile {
+
+	var p *Projectile
+	if n := len(s.free); n > 0 {
+		p = s.free[n-1]
+		s.free = s.free[:n-1]
+	} else {
+		p = new(Projectile)
+		s.allocated++
+	}
+	*p = Projectile{
+		Position: *position,
+		Velocity: *velocity,
+		Gravity:  1,
+		Drag:     s.Drag,
+		Life:     s.Lifetime,
+		origin:   *position,
+		active:   true,
+	}
+	s.active = append(s.active, p)
+	s.fired++
+	return p
+}
+
+// Projectiles returns the flying projectiles.
+func (s *System) Projectiles() []*Projectile {
+
+	return s.active
+}
+
+// Stats returns the number of projectiles fired and of hits since the system was created,
+// and the number of projectiles allocated, which stops growing once the pool is large enough.
+func (s *System) Stats() (fired, hits, allocated int) {
+
+	return s.fired, s.hits, s.allocated
+}
+
+// Release stops the specified projectile and returns it to the pool without any event.
+func (s *System) Release(p *Projectile) {
+
+	for i, q := range s.active {
+		if q == p {
+			s.remove(i)
+			return
+		}
+	}
+}
+
+// Clear releases all the flying projectiles without any event.
+func (s *System) Clear() {
+
+	for len(s.active) > 0 {
+		s.remove(len(s.active) - 1)
+	}
+}
+
+// Hitscan returns the closest hit of the targets by a ray from the specified origin along
+// the specified direction, up to the specified distance, ignoring the owner and its
+// descendants if not nil, for weapons whose projectiles are too fast to simulate.
+func (s *System) Hitscan(origin, direction *math32.Vector3, distance float32, owner core.INode) (collision.Intersect, bool) {
+
+	dir := *direction
+	dir.Normalize()
+	return s.raycast(origin, &dir, distance, owner)
+}
+
+// Update moves the projectiles for the specified elapsed time in seconds, dispatching
+// OnHit for the targets they hit and OnExpire for the projectiles reaching their lifetime,
+// and then updates the tracers.
+func (s *System) Update(deltaTime float32) {
+
+	steps := 1
+	if s.MaxStep > 0 && deltaTime > s.MaxStep {
+		steps = int(math32.Ceil(deltaTime / s.MaxStep))
+	}
+	dt := deltaTime / float32(steps)
+	for step := 0; step < steps; step++ {
+		for i := 0; i < len(s.active); {
+			p := s.active[i]
+			if s.step(p, dt) {
+				i++
+				continue
+			}
+			// Event handlers may have released projectiles, so the index is looked up again
+			if p.active {
+				s.Release(p)
+			}
+		}
+	}
+	for _, p := range s.active {
+		if p.Node != nil {
+			p.Node.GetNode().SetPositionVec(&p.Position)
+		}
+	}
+	if s.tracers != nil {
+		s.tracers.update(s.active)
+	}
+}
+
+// step integrates the motion of the projectile for the specified time step and returns
+// whether it is still flying.
+func (s *System) step(p *Projectile, dt float32) bool {
+
+	p.Life -= dt
+	if p.Life <= 0 {
+		s.Dispatch(OnExpire, p)
+		return false
+	}
+
+	// Semi-implicit Euler with quadratic drag opposed to the velocity
+	var acc math32.Vector3
+	acc.Copy(&s.Gravity).MultiplyScalar(p.Gravity)
+	if p.Drag != 0 {
+		drag := p.Velocity
+		acc.Add(drag.MultiplyScalar(-p.Drag * p.Velocity.Length()))
+	}
+	p.Velocity.Add(acc.MultiplyScalar(dt))
+	move := p.Velocity
+	move.MultiplyScalar(dt)
+	length := move.Length()
+	if length == 0 {
+		return true
+	}
+
+	// Swept collision along the motion of the step
+	dir := move
+	dir.MultiplyScalar(1 / length)
+	hit, ok := s.raycast(&p.Position, &dir, length, p.Owner)
+	if !ok {
+		p.Position.Add(&move)
+		p.traveled += length
+		return true
+	}
+	p.Position = hit.Point
+	p.traveled += hit.Distance
+	s.hits++
+	ev := &HitEvent{Projectile: p, Intersect: hit}
+	s.Dispatch(OnHit, ev)
+	if !ev.Keep {
+		return false
+	}
+	// Moves away from the surface so the next step does not hit it again
+	if speed := p.Velocity.Length(); speed > 0 {
+		away := p.Velocity
+		p.Position.Add(away.MultiplyScalar(1e-3 / speed))
+	}
+	return p.active
+}
+
+// raycast returns the closest hit of the targets by the specified ray up to the specified
+// distance, ignoring the owner and its descendants.
+func (s *System) raycast(origin, dir *math32.Vector3, distance float32, owner core.INode) (collision.Intersect, bool) {
+
+	s.rc.Ray.Set(origin, dir)
+	s.rc.Far = distance
+	for _, hit := range s.rc.IntersectObjects(s.Targets, true) {
+		if owner == nil || !descends(hit.Object, owner) {
+			return hit, true
+		}
+	}
+	return collision.Intersect{}, false
+}
+
+// remove returns the projectile at the specified index of the active list to the pool.
+func (s *System) remove(i int) {
+
+	p := s.active[i]
+	last := len(s.active) - 1
+	s.active[i] = s.active[last]
+	s.active[last] = nil
+	s.active = s.active[:last]
+	p.active = false
+	p.Owner = nil
+	p.Node = nil
+	p.Data = nil
+	s.free = append(s.free, p)
+}
+
+// descends returns whether the node is the ancestor or one of its descendants.
+func descends(inode, ancestor core.INode) bool {
+
+	anc := ancestor.GetNode()
+	for inode != nil {
+		n := inode.GetNode()
+		if n == anc {
+			return true
+		}
+		inode = n.Parent()
+	}
+	return false
+}
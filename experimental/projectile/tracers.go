@@ -0,0 +1,78 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package projectile
+
+import (
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// Tracers shows the flying projectiles of a system as streaks fading from their
+// heads towards where they came from. It is in world coordinates, so it should be
+// added to the scene root.
+type Tracers struct {
+	graphic.Lines
+	Length    float32         // Length of the streaks in world units (default is 2)
+	Color     math32.Color    // Color of the heads of the streaks
+	positions math32.ArrayF32 // Reused positions buffer
+	colors    math32.ArrayF32 // Reused colors buffer
+}
+
+// Tracers returns the tracers of the projectiles of the system, creating them
+// with the specified color the first time.
+func (s *System) Tracers(color *math32.Color) *Tracers {
+
+	if s.tracers != nil {
+		return s.tracers
+	}
+	t := new(Tracers)
+	t.Length = 2
+	t.Color = *color
+
+	geom := geometry.NewGeometry()
+	posVBO := gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexPosition)
+	colVBO := gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexColor)
+	// The streaks are updated every frame
+	posVBO.SetUsage(gls.STREAM_DRAW)
+	colVBO.SetUsage(gls.STREAM_DRAW)
+	geom.AddVBO(posVBO)
+	geom.AddVBO(colVBO)
+
+	// The tails fade to black, which adds nothing to the background
+	mat := material.NewBasic()
+	mat.SetBlending(material.BlendingAdditive)
+	mat.SetDepthMask(false)
+	mat.SetTransparent(true)
+
+	t.Lines.Init(geom, mat)
+	t.SetCullable(false)
+	s.tracers = t
+	t.update(s.active)
+	return t
+}
+
+// update rebuilds the streaks of the specified projectiles.
+func (t *Tracers) update(projectiles []*Projectile) {
+
+	t.positions = t.positions[:0]
+	t.colors = t.colors[:0]
+	for _, p := range projectiles {
+		speed := p.Velocity.Length()
+		length := math32.Min(t.Length, p.traveled)
+		if speed == 0 || length <= 0 {
+			continue
+		}
+		tail := p.Velocity
+		tail.MultiplyScalar(-length / speed).Add(&p.Position)
+		t.positions.AppendVector3(&p.Position, &tail)
+		t.colors.AppendColor(&t.Color, &math32.Color{})
+	}
+	geom := t.GetGeometry()
+	geom.VBO(gls.VertexPosition).SetBuffer(t.positions)
+	geom.VBO(gls.VertexColor).SetBuffer(t.colors)
+}
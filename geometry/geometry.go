@@ -6,6 +6,7 @@
 package geometry
 
 import (
+	"fmt"
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
 	"github.com/g3n/engine/util/logger"
@@ -34,12 +35,24 @@ type Geometry struct {
 	updateIndices bool              // Flag to indicate that indices must be transferred
 	ShaderDefines gls.ShaderDefines // Geometry-specific shader defines
 
+	// CPU-side data release
+	releaseData bool                    // Release the data after it is transferred to OpenGL
+	released    bool                    // Data was released
+	numIndices  int                     // Number of indices when the data was released
+	numItems    int                     // Number of items when the data was released
+	fetcher     func(g *Geometry) error // Restores the released data
+
+	// Primitive topology
+	primitive uint32 // OpenGL primitive described by the geometry (0 for the graphic mode)
+	restart   bool   // Restart indices start new primitives
+
 	// Geometric properties
 	boundingBox    math32.Box3    // Last calculated bounding box
 	boundingSphere math32.Sphere  // Last calculated bounding sphere
 	area           float32        // Last calculated area
 	volume         float32        // Last calculated volume
 	rotInertia     math32.Matrix3 // Last calculated rotational inertia matrix
+	version        uint64         // Incremented each time the geometric properties are invalidated
 
 	// Flags indicating whether geometric properties are valid
 	boundingBoxValid    bool // Indicates if last calculated bounding box is valid
@@ -99,6 +112,12 @@ func (g *Geometry) AddGroupList(groups []Group) {
 	}
 }
 
+// ClearGroups removes all the geometry groups.
+func (g *Geometry) ClearGroups() {
+
+	g.groups = g.groups[0:0]
+}
+
 // GroupCount returns the number of geometry groups (for multimaterial).
 func (g *Geometry) GroupCount() int {
 
@@ -116,16 +135,75 @@ func (g *Geometry) SetIndices(indices math32.ArrayU32) {
 
 	g.indices = indices
 	g.updateIndices = true
-	g.boundingBoxValid = false
-	g.boundingSphereValid = false
+	g.Invalidate()
 }
 
 // Indices returns the indices array for this geometry.
+// It is empty if the data was released (see SetReleaseData and Restore).
 func (g *Geometry) Indices() math32.ArrayU32 {
 
 	return g.indices
 }
 
+// IndexCount returns the number of indices of this geometry,
+// which is kept when the data is released.
+func (g *Geometry) IndexCount() int {
+
+	if g.released {
+		return g.numIndices
+	}
+	return g.indices.Size()
+}
+
+// SetReleaseData sets whether the CPU-side data of the geometry (the VBO buffers and the
+// indices) is released after it is transferred to OpenGL, halving the memory used by large
+// static geometries. The bounding box and sphere are computed before the data is released.
+// Methods reading the vertices restore the data with the fetcher set by SetFetcher.
+// VBOs with STREAM_DRAW usage keep their data.
+func (g *Geometry) SetReleaseData(release bool) {
+
+	g.releaseData = release
+}
+
+// ReleaseData returns whether the CPU-side data of the geometry is released after it is
+// transferred to OpenGL.
+func (g *Geometry) ReleaseData() bool {
+
+	return g.releaseData
+}
+
+// Released returns whether the CPU-side data of the geometry is currently released.
+func (g *Geometry) Released() bool {
+
+	return g.released
+}
+
+// SetFetcher sets the function which restores the released data of the geometry, usually
+// reloading it from its asset source. It must set the same VBO buffers and indices.
+func (g *Geometry) SetFetcher(fetcher func(g *Geometry) error) {
+
+	g.fetcher = fetcher
+}
+
+// Restore restores the released CPU-side data of the geometry using its fetcher.
+// The data is transferred again and released again, if still requested,
+// the next time the geometry is rendered. It does nothing if the data was not released.
+func (g *Geometry) Restore() error {
+
+	if !g.released {
+		return nil
+	}
+	if g.fetcher == nil {
+		return fmt.Errorf("geometry data released without fetcher")
+	}
+	err := g.fetcher(g)
+	if err != nil {
+		return err
+	}
+	g.released = false
+	return nil
+}
+
 // AddVBO adds a Vertex Buffer Object for this geometry.
 func (g *Geometry) AddVBO(vbo *gls.VBO) {
 
@@ -142,6 +220,7 @@ func (g *Geometry) AddVBO(vbo *gls.VBO) {
 	}
 
 	g.vbos = append(g.vbos, vbo)
+	g.Invalidate()
 }
 
 // VBO returns a pointer to this geometry's VBO which contain the specified attribute.
@@ -174,19 +253,47 @@ func (g *Geometry) VBOs() []*gls.VBO {
 	return g.vbos
 }
 
+// Interleave replaces the VBOs of this geometry, except per instance VBOs and those with
+// STREAM_DRAW usage, by a single VBO storing their attributes interleaved, converted to the
+// formats of the specified layout (see gls.CompactLayout). Interleaved vertices improve the
+// vertex cache behavior and compact formats reduce the memory used by large meshes.
+func (g *Geometry) Interleave(layout gls.VertexLayout) {
+
+	if err := g.Restore(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+	var static, others []*gls.VBO
+	for _, vbo := range g.vbos {
+		if vbo.Usage() == gls.STREAM_DRAW || vbo.Divisor() != 0 {
+			others = append(others, vbo)
+		} else {
+			static = append(static, vbo)
+		}
+	}
+	if len(static) == 0 {
+		return
+	}
+	interleaved := gls.Interleave(static, layout)
+	for _, vbo := range static {
+		vbo.Dispose()
+	}
+	g.vbos = append([]*gls.VBO{interleaved}, others...)
+	g.Invalidate()
+}
+
 // Items returns the number of items in the first VBO.
 // (The number of items should be same for all VBOs)
 // An item is a complete group of attributes in the VBO buffer.
 func (g *Geometry) Items() int {
 
-	if len(g.vbos) == 0 {
-		return 0
+	if g.released {
+		return g.numItems
 	}
-	vbo := g.vbos[0]
-	if vbo.AttribCount() == 0 {
+	if len(g.vbos) == 0 {
 		return 0
 	}
-	return vbo.Buffer().Bytes() / vbo.StrideSize()
+	return g.vbos[0].Items()
 }
 
 // SetAttributeName sets the name of the VBO attribute associated with the provided attribute type.
@@ -211,6 +318,11 @@ func (g *Geometry) AttributeName(atype gls.AttribType) string {
 // The callback function returns false to continue or true to break.
 func (g *Geometry) OperateOnVertices(cb func(vertex *math32.Vector3) bool) {
 
+	if err := g.Restore(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
 	// Get buffer with position vertices
 	vbo := g.VBO(gls.VertexPosition)
 	if vbo == nil {
@@ -219,11 +331,27 @@ func (g *Geometry) OperateOnVertices(cb func(vertex *math32.Vector3) bool) {
 	vbo.OperateOnVectors3(gls.VertexPosition, cb)
 
 	// Geometric properties may have changed
+	g.Invalidate()
+}
+
+// Invalidate marks the cached geometric properties (bounding volumes, area, volume
+// and rotational inertia) as invalid so they are recalculated when next requested.
+// It must be called after vertex positions are modified directly in a VBO buffer.
+func (g *Geometry) Invalidate() {
+
 	g.boundingBoxValid = false
 	g.boundingSphereValid = false
 	g.areaValid = false
 	g.volumeValid = false
 	g.rotInertiaValid = false
+	g.version++
+}
+
+// Version returns a counter which is incremented each time the geometric properties are invalidated.
+// It can be used to cache values derived from the geometry.
+func (g *Geometry) Version() uint64 {
+
+	return g.version
 }
 
 // ReadVertices iterates over all the vertices and calls
@@ -231,6 +359,11 @@ func (g *Geometry) OperateOnVertices(cb func(vertex *math32.Vector3) bool) {
 // The callback function returns false to continue or true to break.
 func (g *Geometry) ReadVertices(cb func(vertex math32.Vector3) bool) {
 
+	if err := g.Restore(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
 	// Get buffer with position vertices
 	vbo := g.VBO(gls.VertexPosition)
 	if vbo == nil {
@@ -246,6 +379,11 @@ func (g *Geometry) ReadVertices(cb func(vertex math32.Vector3) bool) {
 // The callback function returns false to continue or true to break.
 func (g *Geometry) OperateOnVertexNormals(cb func(normal *math32.Vector3) bool) {
 
+	if err := g.Restore(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
 	// Get buffer with position vertices
 	vbo := g.VBO(gls.VertexNormal)
 	if vbo == nil {
@@ -259,6 +397,11 @@ func (g *Geometry) OperateOnVertexNormals(cb func(normal *math32.Vector3) bool)
 // The callback function returns false to continue or true to break.
 func (g *Geometry) ReadVertexNormals(cb func(vertex math32.Vector3) bool) {
 
+	if err := g.Restore(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
 	// Get buffer with position vertices
 	vbo := g.VBO(gls.VertexNormal)
 	if vbo == nil {
@@ -269,34 +412,36 @@ func (g *Geometry) ReadVertexNormals(cb func(vertex math32.Vector3) bool) {
 
 // ReadFaces iterates over all the vertices and calls
 // the specified callback function with face-forming vertex triples.
+// Triangle strips and fans are decoded (see SetPrimitive).
 // The callback function returns false to continue or true to break.
 func (g *Geometry) ReadFaces(cb func(vA, vB, vC math32.Vector3) bool) {
 
+	if err := g.Restore(); err != nil {
+		log.Error("%v", err)
+		return
+	}
+
 	// Get buffer with position vertices
 	vbo := g.VBO(gls.VertexPosition)
 	if vbo == nil {
 		return
 	}
 
-	// If geometry has indexed vertices need to loop over indexes
-	if g.Indexed() {
-		var vA, vB, vC math32.Vector3
-		positions := vbo.Buffer()
-		for i := 0; i < g.indices.Size(); i += 3 {
-			// Get face vertices
-			positions.GetVector3(int(3*g.indices[i]), &vA)
-			positions.GetVector3(int(3*g.indices[i+1]), &vB)
-			positions.GetVector3(int(3*g.indices[i+2]), &vC)
-			// Call callback with face vertices
-			brk := cb(vA, vB, vC)
-			if brk {
-				break
-			}
-		}
-	} else {
-		// Geometry does NOT have indexed vertices - can read vertices in sequence
-		vbo.ReadTripleVectors3(gls.VertexPosition, cb)
+	// Loops over the triangles of the indexed or non indexed geometry
+	attrib := vbo.Attrib(gls.VertexPosition)
+	var vA, vB, vC math32.Vector3
+	vertex := func(idx uint32, v *math32.Vector3) {
+		var values [4]float32
+		vbo.ReadAttrib(attrib, int(idx), values[:])
+		v.Set(values[0], values[1], values[2])
 	}
+	g.readTriangles(func(a, b, c uint32) bool {
+		vertex(a, &vA)
+		vertex(b, &vB)
+		vertex(c, &vC)
+		// Call callback with face vertices
+		return cb(vA, vB, vC)
+	})
 }
 
 // TODO Read and Operate on Texcoords, Faces, Edges, FaceNormals, etc...
@@ -304,7 +449,7 @@ func (g *Geometry) ReadFaces(cb func(vA, vB, vC math32.Vector3) bool) {
 // Indexed returns whether the geometry is indexed or not.
 func (g *Geometry) Indexed() bool {
 
-	return g.indices.Size() > 0
+	return g.IndexCount() > 0
 }
 
 // BoundingBox computes the bounding box of the geometry if necessary
@@ -478,6 +623,47 @@ func (g *Geometry) ApplyMatrix(m *math32.Matrix4) {
 	})
 }
 
+// ComputeVertexNormals recomputes the vertex normals of the geometry, if it has them,
+// as the area weighted average of the normals of the triangles sharing each vertex.
+// It is usually called after the vertex positions are modified, as when flattening a terrain.
+func (g *Geometry) ComputeVertexNormals() {
+
+	if g.VBO(gls.VertexNormal) == nil {
+		return
+	}
+	positions := make([]math32.Vector3, 0, g.Items())
+	g.ReadVertices(func(vertex math32.Vector3) bool {
+		positions = append(positions, vertex)
+		return false
+	})
+	if len(positions) == 0 {
+		return
+	}
+	normals := make([]math32.Vector3, len(positions))
+	var e1, e2, n math32.Vector3
+	g.readTriangles(func(a, b, c uint32) bool {
+		if int(a) >= len(positions) || int(b) >= len(positions) || int(c) >= len(positions) {
+			return false
+		}
+		e1.SubVectors(&positions[b], &positions[a])
+		e2.SubVectors(&positions[c], &positions[a])
+		n.CrossVectors(&e1, &e2)
+		normals[a].Add(&n)
+		normals[b].Add(&n)
+		normals[c].Add(&n)
+		return false
+	})
+	i := 0
+	g.OperateOnVertexNormals(func(normal *math32.Vector3) bool {
+		if normals[i].LengthSq() > 0 {
+			*normal = normals[i]
+			normal.Normalize()
+		}
+		i++
+		return i >= len(normals)
+	})
+}
+
 // Incref increments the reference count for this geometry
 // and returns a pointer to the geometry.
 // It should be used when this geometry is shared by another
@@ -535,4 +721,24 @@ func (g *Geometry) RenderSetup(gs *gls.GLS) {
 		gs.BufferData(gls.ELEMENT_ARRAY_BUFFER, g.indices.Bytes(), g.indices.ToUint32(), gls.STATIC_DRAW)
 		g.updateIndices = false
 	}
+
+	// Release the transferred data if requested
+	if g.releaseData && !g.released {
+		g.release()
+	}
+}
+
+// release releases the CPU-side data of the geometry, keeping the properties
+// needed to render and cull it.
+func (g *Geometry) release() {
+
+	g.BoundingBox()
+	g.BoundingSphere()
+	g.numIndices = g.indices.Size()
+	g.numItems = g.Items()
+	for _, vbo := range g.vbos {
+		vbo.Release()
+	}
+	g.indices = nil
+	g.released = true
 }
@@ -0,0 +1,334 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// RestartIndex is the index value which restarts strip, fan and loop
+// primitives when primitive restart is enabled (see SetPrimitiveRestart).
+const RestartIndex = 0xFFFFFFFF
+
+// SetPrimitive sets the OpenGL primitive (TRIANGLES, TRIANGLE_STRIP, TRIANGLE_FAN,
+// LINES, LINE_STRIP, LINE_LOOP or POINTS) described by the geometry vertices or indices.
+// Graphics draw geometries with a primitive using it instead of their own mode.
+// Zero, the default, means the geometry is drawn with the mode of the graphic and is
+// read as a triangle list by ReadFaces.
+func (g *Geometry) SetPrimitive(mode uint32) {
+
+	g.primitive = mode
+	g.Invalidate()
+}
+
+// Primitive returns the OpenGL primitive described by the geometry or zero if not set.
+func (g *Geometry) Primitive() uint32 {
+
+	return g.primitive
+}
+
+// SetPrimitiveRestart sets if RestartIndex values in the indices start a new strip,
+// fan or loop, so many of them can be stored in a single geometry and drawn at once.
+func (g *Geometry) SetPrimitiveRestart(restart bool) {
+
+	g.restart = restart
+	g.Invalidate()
+}
+
+// PrimitiveRestart returns if RestartIndex values in the indices start a new primitive.
+func (g *Geometry) PrimitiveRestart() bool {
+
+	return g.restart
+}
+
+// TriangleIndices returns the indices of the triangles of the geometry as a triangle list,
+// converting strips and fans and generating the indices of non indexed geometries.
+// It returns nil if the geometry primitive is not a triangle primitive.
+func (g *Geometry) TriangleIndices() math32.ArrayU32 {
+
+	if err := g.Restore(); err != nil {
+		log.Error("%v", err)
+		return nil
+	}
+	indices := g.indices
+	if !g.Indexed() {
+		indices = math32.NewArrayU32(g.Items(), g.Items())
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	switch g.primitive {
+	case 0, gls.TRIANGLES:
+		return indices
+	case gls.TRIANGLE_STRIP:
+		return TrianglesFromStrip(indices)
+	case gls.TRIANGLE_FAN:
+		return TrianglesFromFan(indices)
+	}
+	return nil
+}
+
+// ToTriangles converts the indices of a triangle strip or fan geometry to a triangle list.
+func (g *Geometry) ToTriangles() {
+
+	if g.primitive == 0 || g.primitive == gls.TRIANGLES {
+		return
+	}
+	indices := g.TriangleIndices()
+	if indices == nil {
+		return
+	}
+	g.primitive = gls.TRIANGLES
+	g.restart = false
+	g.SetIndices(indices)
+}
+
+// ToTriangleStrips converts the indices of a triangle list geometry to triangle strips
+// separated by restart indices, keeping the winding of the triangles. The indices are
+// only replaced if the strips use fewer indices than the triangle list.
+func (g *Geometry) ToTriangleStrips() {
+
+	if g.primitive != 0 && g.primitive != gls.TRIANGLES {
+		return
+	}
+	tris := g.TriangleIndices()
+	strips := StripFromTriangles(tris)
+	if len(strips) >= len(tris) {
+		return
+	}
+	g.primitive = gls.TRIANGLE_STRIP
+	g.restart = true
+	g.SetIndices(strips)
+}
+
+// readTriangles calls the specified callback with the vertex indices of each triangle of
+// the geometry, decoding strips and fans. The callback returns true to stop iterating.
+func (g *Geometry) readTriangles(cb func(a, b, c uint32) bool) {
+
+	n := g.IndexCount()
+	index := func(i int) uint32 { return uint32(i) }
+	if g.Indexed() {
+		index = func(i int) uint32 { return g.indices[i] }
+	} else {
+		n = g.Items()
+	}
+	switch g.primitive {
+	case 0, gls.TRIANGLES:
+		for i := 0; i+2 < n; i += 3 {
+			if cb(index(i), index(i+1), index(i+2)) {
+				return
+			}
+		}
+	case gls.TRIANGLE_STRIP, gls.TRIANGLE_FAN:
+		start := 0
+		for i := 0; i < n; i++ {
+			idx := index(i)
+			if g.restart && idx == RestartIndex {
+				start = i + 1
+				continue
+			}
+			k := i - start
+			if k < 2 {
+				continue
+			}
+			var a, b, c uint32
+			if g.primitive == gls.TRIANGLE_FAN {
+				a, b, c = index(start), index(i-1), idx
+			} else if k%2 == 0 {
+				a, b, c = index(i-2), index(i-1), idx
+			} else {
+				a, b, c = index(i-1), index(i-2), idx
+			}
+			if a == b || b == c || a == c {
+				continue
+			}
+			if cb(a, b, c) {
+				return
+			}
+		}
+	}
+}
+
+// TrianglesFromStrip converts the specified triangle strip indices, which may contain
+// restart indices, to a triangle list with the same winding. Degenerate triangles are removed.
+func TrianglesFromStrip(indices math32.ArrayU32) math32.ArrayU32 {
+
+	var g Geometry
+	g.indices = indices
+	g.primitive = gls.TRIANGLE_STRIP
+	g.restart = true
+	return g.collectTriangles(len(indices))
+}
+
+// TrianglesFromFan converts the specified triangle fan indices, which may contain
+// restart indices, to a triangle list with the same winding.
+func TrianglesFromFan(indices math32.ArrayU32) math32.ArrayU32 {
+
+	var g Geometry
+	g.indices = indices
+	g.primitive = gls.TRIANGLE_FAN
+	g.restart = true
+	return g.collectTriangles(3 * len(indices))
+}
+
+// collectTriangles returns the triangles of the geometry as a triangle list.
+func (g *Geometry) collectTriangles(capacity int) math32.ArrayU32 {
+
+	tris := math32.NewArrayU32(0, capacity)
+	g.readTriangles(func(a, b, c uint32) bool {
+		tris.Append(a, b, c)
+		return false
+	})
+	return tris
+}
+
+// StripFromTriangles converts the specified triangle list indices to triangle strips
+// separated by restart indices, keeping the winding of the triangles.
+// Strips are built greedily following shared edges, so meshes with a regular
+// topology, such as grids and terrain, produce long strips.
+func StripFromTriangles(indices math32.ArrayU32) math32.ArrayU32 {
+
+	type edge struct{ a, b uint32 }
+	ntris := len(indices) / 3
+
+	// Maps each directed edge to the triangles containing it
+	edges := make(map[edge][]int, len(indices))
+	for t := 0; t < ntris; t++ {
+		a, b, c := indices[3*t], indices[3*t+1], indices[3*t+2]
+		edges[edge{a, b}] = append(edges[edge{a, b}], t)
+		edges[edge{b, c}] = append(edges[edge{b, c}], t)
+		edges[edge{c, a}] = append(edges[edge{c, a}], t)
+	}
+
+	// third returns the vertex of the triangle opposite to the specified edge
+	third := func(t int, e edge) uint32 {
+		tri := indices[3*t : 3*t+3]
+		for i := 0; i < 3; i++ {
+			if tri[i] == e.a && tri[(i+1)%3] == e.b {
+				return tri[(i+2)%3]
+			}
+		}
+		return RestartIndex
+	}
+
+	// extend extends the specified strip with unused triangles sharing its last edge.
+	// The triangles used are recorded with the specified stamp.
+	used := make([]int, ntris)
+	extend := func(strip []uint32, trial []int, stamp int) []uint32 {
+		for {
+			n := len(strip)
+			// Triangles at even positions contain the last edge in strip order,
+			// at odd positions in reverse order.
+			e := edge{strip[n-2], strip[n-1]}
+			if (n-2)%2 == 1 {
+				e = edge{strip[n-1], strip[n-2]}
+			}
+			next := -1
+			for _, t := range edges[e] {
+				if used[t] == 0 && trial[t] != stamp {
+					next = t
+					break
+				}
+			}
+			if next < 0 {
+				return strip
+			}
+			trial[next] = stamp
+			strip = append(strip, third(next, e))
+		}
+	}
+
+	strips := math32.NewArrayU32(0, len(indices))
+	trial := make([]int, ntris)
+	stamp := 0
+	for t := 0; t < ntris; t++ {
+		if used[t] != 0 {
+			continue
+		}
+		// Tries the three rotations of the first triangle and keeps the longest strip
+		tri := indices[3*t : 3*t+3]
+		var best []uint32
+		for r := 0; r < 3; r++ {
+			stamp++
+			trial[t] = stamp
+			strip := extend([]uint32{tri[r], tri[(r+1)%3], tri[(r+2)%3]}, trial, stamp)
+			if len(strip) > len(best) {
+				best = strip
+			}
+		}
+		// Marks the triangles of the chosen strip as used
+		used[t] = 1
+		for i := 3; i < len(best); i++ {
+			e := edge{best[i-2], best[i-1]}
+			if (i-2)%2 == 1 {
+				e = edge{best[i-1], best[i-2]}
+			}
+			for _, et := range edges[e] {
+				if used[et] == 0 && third(et, e) == best[i] {
+					used[et] = 1
+					break
+				}
+			}
+		}
+		if len(strips) > 0 {
+			strips.Append(RestartIndex)
+		}
+		strips.Append(best...)
+	}
+	return strips
+}
+
+// LinesFromStrip converts the specified line strip indices, which may contain
+// restart indices, to a line list.
+func LinesFromStrip(indices math32.ArrayU32) math32.ArrayU32 {
+
+	return linesFromStrip(indices, false)
+}
+
+// LinesFromLoop converts the specified line loop indices, which may contain
+// restart indices, to a line list including the closing lines.
+func LinesFromLoop(indices math32.ArrayU32) math32.ArrayU32 {
+
+	return linesFromStrip(indices, true)
+}
+
+// linesFromStrip converts line strip or loop indices to a line list.
+func linesFromStrip(indices math32.ArrayU32, loop bool) math32.ArrayU32 {
+
+	lines := math32.NewArrayU32(0, 2*len(indices))
+	start := 0
+	for i := 0; i <= len(indices); i++ {
+		if i < len(indices) && indices[i] != RestartIndex {
+			if i > start {
+				lines.Append(indices[i-1], indices[i])
+			}
+			continue
+		}
+		if loop && i-start > 2 {
+			lines.Append(indices[i-1], indices[start])
+		}
+		start = i + 1
+	}
+	return lines
+}
+
+// GridStripIndices returns triangle strip indices, with a strip for each row separated
+// by restart indices, for a grid of (cols+1)*(rows+1) vertices stored row by row, such as
+// terrain height maps. The triangles have the same winding as the ones of NewPlane
+// and use about a third of the indices of a triangle list.
+func GridStripIndices(cols, rows int) math32.ArrayU32 {
+
+	indices := math32.NewArrayU32(0, rows*(2*(cols+1)+1))
+	for iy := 0; iy < rows; iy++ {
+		if iy > 0 {
+			indices.Append(RestartIndex)
+		}
+		for ix := 0; ix <= cols; ix++ {
+			indices.Append(uint32(ix+(cols+1)*iy), uint32(ix+(cols+1)*(iy+1)))
+		}
+	}
+	return indices
+}
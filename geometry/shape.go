@@ -0,0 +1,205 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// NewExtrudedShape creates and returns a pointer to a geometry with the flat shape defined
+// by the specified closed contours on the XY plane extruded along the Z axis by depth,
+// centered on the XY plane. Contours inside an odd number of other contours are holes,
+// whatever their orientation, so glyph and SVG like outlines can be used directly.
+// The first group of the geometry has the front and back faces and the second group the sides.
+// If depth is zero only the front face, facing +Z, is created.
+func NewExtrudedShape(contours [][]math32.Vector2, depth float32) *Geometry {
+
+	g := NewGeometry()
+	positions := math32.NewArrayF32(0, 0)
+	normals := math32.NewArrayF32(0, 0)
+	uvs := math32.NewArrayF32(0, 0)
+	indices := math32.NewArrayU32(0, 0)
+
+	// Removes degenerate contours and closing points
+	shapes := make([][]math32.Vector2, 0, len(contours))
+	bmin := math32.Vector2{X: math32.Infinity, Y: math32.Infinity}
+	bmax := math32.Vector2{X: -math32.Infinity, Y: -math32.Infinity}
+	for _, c := range contours {
+		if len(c) > 1 && c[0] == c[len(c)-1] {
+			c = c[:len(c)-1]
+		}
+		if len(c) < 3 || contourArea(c) == 0 {
+			continue
+		}
+		shapes = append(shapes, c)
+		for _, p := range c {
+			bmin.Set(math32.Min(bmin.X, p.X), math32.Min(bmin.Y, p.Y))
+			bmax.Set(math32.Max(bmax.X, p.X), math32.Max(bmax.Y, p.Y))
+		}
+	}
+	if len(shapes) == 0 {
+		return g
+	}
+
+	// Classifies the contours by their nesting level and orients the
+	// outer contours counter clockwise and the holes clockwise
+	level := make([]int, len(shapes))
+	parent := make([]int, len(shapes))
+	for i, c := range shapes {
+		parent[i] = -1
+		for j, other := range shapes {
+			if i != j && contourContains(other, c[0]) {
+				level[i]++
+			}
+		}
+	}
+	for i, c := range shapes {
+		if level[i]%2 == 0 {
+			continue
+		}
+		// The parent of a hole is the smallest contour containing it one level up
+		for j, other := range shapes {
+			if level[j] == level[i]-1 && contourContains(other, c[0]) &&
+				(parent[i] < 0 || math32.Abs(contourArea(other)) < math32.Abs(contourArea(shapes[parent[i]]))) {
+				parent[i] = j
+			}
+		}
+	}
+	for i, c := range shapes {
+		if (contourArea(c) > 0) != (level[i]%2 == 0) {
+			rev := make([]math32.Vector2, len(c))
+			for k := range c {
+				rev[k] = c[len(c)-1-k]
+			}
+			shapes[i] = rev
+		}
+	}
+
+	size := bmax
+	size.Sub(&bmin)
+	faceUV := func(p math32.Vector2) (float32, float32) {
+		u, v := float32(0), float32(0)
+		if size.X > 0 {
+			u = (p.X - bmin.X) / size.X
+		}
+		if size.Y > 0 {
+			v = (p.Y - bmin.Y) / size.Y
+		}
+		return u, v
+	}
+
+	// Front and back faces
+	front := depth / 2
+	for i, c := range shapes {
+		if level[i]%2 != 0 {
+			continue
+		}
+		points := append([]math32.Vector2{}, c...)
+		var holes [][]math32.Vector2
+		for j, h := range shapes {
+			if parent[j] == i {
+				holes = append(holes, h)
+				points = append(points, h...)
+			}
+		}
+		tris := Triangulate(c, holes)
+		sides := []float32{front}
+		if depth != 0 {
+			sides = append(sides, -front)
+		}
+		for _, z := range sides {
+			base := uint32(positions.Size() / 3)
+			nz := float32(1)
+			if z < front {
+				nz = -1
+			}
+			for _, p := range points {
+				u, v := faceUV(p)
+				positions.Append(p.X, p.Y, z)
+				normals.Append(0, 0, nz)
+				uvs.Append(u, v)
+			}
+			for k := 0; k+2 < len(tris); k += 3 {
+				if nz > 0 {
+					indices.Append(base+uint32(tris[k]), base+uint32(tris[k+1]), base+uint32(tris[k+2]))
+				} else {
+					indices.Append(base+uint32(tris[k]), base+uint32(tris[k+2]), base+uint32(tris[k+1]))
+				}
+			}
+		}
+	}
+	g.AddGroup(0, indices.Size(), 0)
+
+	// Sides, with the normals of adjacent edges averaged when the angle
+	// between them is small so curved outlines are smoothly shaded
+	if depth != 0 {
+		start := indices.Size()
+		const smoothCos = 0.85
+		edgeNormal := func(a, b math32.Vector2) math32.Vector2 {
+			n := math32.Vector2{X: b.Y - a.Y, Y: a.X - b.X}
+			return *n.Normalize()
+		}
+		vertexNormal := func(edge, other math32.Vector2) math32.Vector2 {
+			if edge.Dot(&other) < smoothCos {
+				return edge
+			}
+			n := edge
+			return *n.Add(&other).Normalize()
+		}
+		for _, c := range shapes {
+			n := len(c)
+			length := float32(0)
+			for k := 0; k < n; k++ {
+				a, b := c[k], c[(k+1)%n]
+				prev := edgeNormal(c[(k+n-1)%n], a)
+				edge := edgeNormal(a, b)
+				next := edgeNormal(b, c[(k+2)%n])
+				na := vertexNormal(edge, prev)
+				nb := vertexNormal(edge, next)
+				ab := b
+				l := ab.Sub(&a).Length()
+
+				base := uint32(positions.Size() / 3)
+				positions.Append(a.X, a.Y, front, a.X, a.Y, -front, b.X, b.Y, front, b.X, b.Y, -front)
+				normals.Append(na.X, na.Y, 0, na.X, na.Y, 0, nb.X, nb.Y, 0, nb.X, nb.Y, 0)
+				uvs.Append(length, 0, length, 1, length+l, 0, length+l, 1)
+				indices.Append(base, base+1, base+2, base+2, base+1, base+3)
+				length += l
+			}
+		}
+		g.AddGroup(start, indices.Size()-start, 1)
+	}
+
+	g.SetIndices(indices)
+	g.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	g.AddVBO(gls.NewVBO(normals).AddAttrib(gls.VertexNormal))
+	g.AddVBO(gls.NewVBO(uvs).AddAttrib(gls.VertexTexcoord))
+	return g
+}
+
+// contourArea returns the signed area of the specified closed contour,
+// which is positive if the contour is counter clockwise.
+func contourArea(c []math32.Vector2) float32 {
+
+	area := float32(0)
+	for i, j := 0, len(c)-1; i < len(c); j, i = i, i+1 {
+		area += c[j].X*c[i].Y - c[i].X*c[j].Y
+	}
+	return area / 2
+}
+
+// contourContains returns if the specified point is inside the specified closed contour.
+func contourContains(c []math32.Vector2, p math32.Vector2) bool {
+
+	inside := false
+	for i, j := 0, len(c)-1; i < len(c); j, i = i, i+1 {
+		a, b := c[i], c[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,432 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// HeightFunc returns the height of the ground at the specified horizontal position,
+// and whether there is ground there.
+type HeightFunc func(x, z float32) (float32, bool)
+
+// SweepOptions are the options of the cross sections and texture coordinates of a sweep.
+// The zero value sweeps an upright profile every unit of length without conforming to a ground.
+type SweepOptions struct {
+	Spacing       float32    // Distance along the curve between cross sections (default is 1)
+	ClosedProfile bool       // Whether the profile is a loop, such as the circle of a pipe
+	Banking       []float32  // Banking angles in radians at the knots of the curve, linearly interpolated; positive angles raise the right side
+	FreeFrame     bool       // Whether cross sections twist as little as possible instead of staying upright, for pipes which go vertical
+	TextureLength float32    // Length along the curve covered once by the texture (default is the length of the profile)
+	Height        HeightFunc // Ground the curve is conformed to, or nil to keep the heights of the curve
+	HeightOffset  float32    // Height of the curve above the ground when conforming
+	Drape         bool       // Whether every vertex, instead of the center of each cross section, is conformed to the ground
+}
+
+// NewSweep creates and returns a pointer to a new geometry sweeping the specified cross section
+// profile along a curve, such as a road, a river, a pipe or a racetrack.
+// The profile is in the plane of each cross section, with X to the right and Y up when looking
+// along the curve. Faces are on the left of the direction of the profile, so a flat profile from
+// left to right faces up and a closed profile should be clockwise. A sharp corner is made by
+// repeating a point of the profile. The texture coordinates go from 0 to 1 across the profile
+// and repeat every TextureLength along the curve.
+func NewSweep(path *math32.Spline, profile []math32.Vector2, opts *SweepOptions) *Geometry {
+
+	g := NewGeometry()
+	if opts == nil {
+		opts = &SweepOptions{}
+	}
+	length := path.Length()
+	if len(profile) < 2 || length == 0 {
+		return g
+	}
+	spacing := opts.Spacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+
+	// Closed profiles repeat their first point for the texture seam
+	prof := profile
+	if opts.ClosedProfile {
+		prof = append(append([]math32.Vector2{}, profile...), profile[0])
+	}
+	m := len(prof)
+
+	// Profile normals, texture coordinates and length
+	pnorms := make([]math32.Vector2, m)
+	us := make([]float32, m)
+	for i := 1; i < m; i++ {
+		us[i] = us[i-1] + prof[i].DistanceTo(&prof[i-1])
+	}
+	plength := us[m-1]
+	for i := range prof {
+		var sum math32.Vector2
+		prev, next := i-1, i+1
+		if opts.ClosedProfile {
+			if prev < 0 {
+				prev = m - 2
+			}
+			if next == m {
+				next = 1
+			}
+		}
+		if prev >= 0 {
+			sum.Add(edgeNormal(&prof[prev], &prof[i]))
+		}
+		if next < m {
+			sum.Add(edgeNormal(&prof[i], &prof[next]))
+		}
+		pnorms[i] = *sum.Normalize()
+		if plength > 0 {
+			us[i] /= plength
+		}
+	}
+	texLength := opts.TextureLength
+	if texLength <= 0 {
+		texLength = plength
+	}
+	if texLength <= 0 {
+		texLength = 1
+	}
+
+	// Centers of the cross sections evenly spaced along the curve, conformed to the ground
+	count := int(math32.Ceil(length/spacing)) + 1
+	centers := make([]math32.Vector3, count)
+	params := make([]float32, count)
+	for k := range centers {
+		d := length * float32(k) / float32(count-1)
+		params[k] = path.ParamAtDistance(d)
+		if k == count-1 {
+			params[k] = 1
+		}
+		path.Point(params[k], &centers[k])
+		if opts.Height != nil && !opts.Drape {
+			if h, ok := opts.Height(centers[k].X, centers[k].Z); ok {
+				centers[k].Y = h + opts.HeightOffset
+			}
+		}
+	}
+
+	// Frames of the cross sections, from the directions between the conformed centers
+	frames := sweepFrames(path, centers, opts.FreeFrame)
+
+	positions := math32.NewArrayF32(0, 3*m*count)
+	normals := math32.NewArrayF32(0, 3*m*count)
+	uvs := math32.NewArrayF32(0, 2*m*count)
+	var pos, norm, tmp math32.Vector3
+	for k := range centers {
+		f := &frames[k]
+		right, up := f.right, f.up
+		if angle := sweepBank(path, opts.Banking, params[k]); angle != 0 {
+			c, s := math32.Cos(angle), math32.Sin(angle)
+			right.MultiplyScalar(c).Add(tmp.Copy(&f.up).MultiplyScalar(s))
+			up.MultiplyScalar(c).Sub(tmp.Copy(&f.right).MultiplyScalar(s))
+		}
+		v := length * float32(k) / float32(count-1) / texLength
+		for i := range prof {
+			pos.Copy(&centers[k])
+			pos.Add(tmp.Copy(&right).MultiplyScalar(prof[i].X))
+			pos.Add(tmp.Copy(&up).MultiplyScalar(prof[i].Y))
+			if opts.Height != nil && opts.Drape {
+				if h, ok := opts.Height(pos.X, pos.Z); ok {
+					pos.Y = h + opts.HeightOffset + prof[i].Y
+				}
+			}
+			norm.Copy(&right).MultiplyScalar(pnorms[i].X)
+			norm.Add(tmp.Copy(&up).MultiplyScalar(pnorms[i].Y))
+			positions.AppendVector3(&pos)
+			normals.AppendVector3(norm.Normalize())
+			uvs.Append(us[i], v)
+		}
+	}
+
+	// Quads between consecutive cross sections
+	indices := math32.NewArrayU32(0, 6*(m-1)*(count-1))
+	for k := 0; k < count-1; k++ {
+		for i := 0; i < m-1; i++ {
+			a := uint32(k*m + i)
+			b := a + 1
+			c := a + uint32(m)
+			d := c + 1
+			indices.Append(a, b, c, b, d, c)
+		}
+	}
+
+	g.SetIndices(indices)
+	g.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	g.AddVBO(gls.NewVBO(normals).AddAttrib(gls.VertexNormal))
+	g.AddVBO(gls.NewVBO(uvs).AddAttrib(gls.VertexTexcoord))
+	if opts.Height != nil && opts.Drape {
+		g.ComputeVertexNormals()
+	}
+	return g
+}
+
+// RoadProfile returns the flat profile of a road or river surface with the specified width,
+// centered on the curve and facing up, with optional square curbs of the specified height
+// on both sides, outside of the width.
+func RoadProfile(width, curb float32) []math32.Vector2 {
+
+	w := width / 2
+	if curb <= 0 {
+		return []math32.Vector2{{X: -w}, {X: w}}
+	}
+	c := w + curb
+	return []math32.Vector2{
+		{X: -c, Y: curb}, {X: -w, Y: curb}, {X: -w, Y: curb}, {X: -w}, {X: -w},
+		{X: w}, {X: w}, {X: w, Y: curb}, {X: w, Y: curb}, {X: c, Y: curb},
+	}
+}
+
+// TubeProfile returns the closed circular profile of a pipe with the specified radius
+// and number of segments, to be swept with the ClosedProfile option.
+func TubeProfile(radius float32, segments int) []math32.Vector2 {
+
+	if segments < 3 {
+		segments = 3
+	}
+	profile := make([]math32.Vector2, segments)
+	for i := range profile {
+		// Clockwise, so the faces are outside
+		angle := -2 * math32.Pi * float32(i) / float32(segments)
+		profile[i] = math32.Vector2{X: radius * math32.Cos(angle), Y: radius * math32.Sin(angle)}
+	}
+	return profile
+}
+
+// FlattenAlong flattens the terrain geometry under a curve, such as the path of a road
+// swept by NewSweep, so the terrain is at the height of the curve minus the specified
+// depth within half the width of the curve and blends back to its heights over the falloff
+// distance. Heights are the Y coordinates of the local space of the terrain, which is also
+// the space of the curve, and the vertex normals are recomputed.
+func FlattenAlong(terrain *Geometry, path *math32.Spline, width, falloff, depth float32) {
+
+	length := path.Length()
+	if length == 0 {
+		return
+	}
+
+	// Samples of the curve, about four per width
+	step := math32.Max(width/4, length/4096)
+	count := int(math32.Ceil(length/step)) + 1
+	samples := make([]math32.Vector3, count)
+	var bounds math32.Box3
+	bounds.MakeEmpty()
+	for k := range samples {
+		path.PointAtDistance(length*float32(k)/float32(count-1), &samples[k])
+		bounds.ExpandByPoint(&samples[k])
+	}
+
+	half := width / 2
+	reach := half + math32.Max(falloff, 0)
+	bounds.ExpandByScalar(reach)
+	terrain.OperateOnVertices(func(vertex *math32.Vector3) bool {
+		if vertex.X < bounds.Min.X || vertex.X > bounds.Max.X || vertex.Z < bounds.Min.Z || vertex.Z > bounds.Max.Z {
+			return false
+		}
+		// Closest point of the polyline through the samples in the horizontal plane
+		best := reach * reach
+		target := float32(0)
+		found := false
+		for k := 0; k+1 < count; k++ {
+			a, b := &samples[k], &samples[k+1]
+			dx, dz := b.X-a.X, b.Z-a.Z
+			ll := dx*dx + dz*dz
+			t := float32(0)
+			if ll > 0 {
+				t = math32.Clamp(((vertex.X-a.X)*dx+(vertex.Z-a.Z)*dz)/ll, 0, 1)
+			}
+			px, pz := a.X+t*dx-vertex.X, a.Z+t*dz-vertex.Z
+			if d := px*px + pz*pz; d <= best {
+				best = d
+				target = a.Y + t*(b.Y-a.Y) - depth
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+		w := float32(1)
+		if d := math32.Sqrt(best); d > half {
+			// Smoothstep blend over the falloff
+			x := 1 - (d-half)/(reach-half)
+			w = x * x * (3 - 2*x)
+		}
+		vertex.Y += (target - vertex.Y) * w
+		return false
+	})
+	terrain.ComputeVertexNormals()
+}
+
+// SurfaceHeight returns a function with the height of the highest triangle of the geometry,
+// such as a terrain, at horizontal positions of its local space, which can conform sweeps
+// to it. The function is valid while the vertices of the geometry do not change.
+func SurfaceHeight(surface *Geometry) HeightFunc {
+
+	var positions []math32.Vector3
+	surface.ReadVertices(func(vertex math32.Vector3) bool {
+		positions = append(positions, vertex)
+		return false
+	})
+	tris := surface.collectTriangles(surface.Items())
+	bbox := surface.BoundingBox()
+	ntris := len(tris) / 3
+
+	// Grid of the triangles overlapping each horizontal cell
+	cells := int(math32.Sqrt(float32(ntris))) + 1
+	sizeX := math32.Max(bbox.Max.X-bbox.Min.X, 1e-6) / float32(cells)
+	sizeZ := math32.Max(bbox.Max.Z-bbox.Min.Z, 1e-6) / float32(cells)
+	cell := func(x, z float32) (int, int) {
+		return math32.ClampInt(int((x-bbox.Min.X)/sizeX), 0, cells-1),
+			math32.ClampInt(int((z-bbox.Min.Z)/sizeZ), 0, cells-1)
+	}
+	grid := make([][]int, cells*cells)
+	for t := 0; t < ntris; t++ {
+		a, b, c := &positions[tris[3*t]], &positions[tris[3*t+1]], &positions[tris[3*t+2]]
+		x0, z0 := cell(math32.Min(a.X, math32.Min(b.X, c.X)), math32.Min(a.Z, math32.Min(b.Z, c.Z)))
+		x1, z1 := cell(math32.Max(a.X, math32.Max(b.X, c.X)), math32.Max(a.Z, math32.Max(b.Z, c.Z)))
+		for z := z0; z <= z1; z++ {
+			for x := x0; x <= x1; x++ {
+				grid[z*cells+x] = append(grid[z*cells+x], t)
+			}
+		}
+	}
+
+	return func(x, z float32) (float32, bool) {
+		if x < bbox.Min.X || x > bbox.Max.X || z < bbox.Min.Z || z > bbox.Max.Z {
+			return 0, false
+		}
+		cx, cz := cell(x, z)
+		height := float32(0)
+		found := false
+		for _, t := range grid[cz*cells+cx] {
+			a, b, c := &positions[tris[3*t]], &positions[tris[3*t+1]], &positions[tris[3*t+2]]
+			// Barycentric coordinates of the position in the horizontal projection of the triangle
+			det := (b.Z-c.Z)*(a.X-c.X) + (c.X-b.X)*(a.Z-c.Z)
+			if det == 0 {
+				continue
+			}
+			l1 := ((b.Z-c.Z)*(x-c.X) + (c.X-b.X)*(z-c.Z)) / det
+			l2 := ((c.Z-a.Z)*(x-c.X) + (a.X-c.X)*(z-c.Z)) / det
+			l3 := 1 - l1 - l2
+			const eps = -1e-5
+			if l1 < eps || l2 < eps || l3 < eps {
+				continue
+			}
+			h := l1*a.Y + l2*b.Y + l3*c.Y
+			if !found || h > height {
+				height = h
+				found = true
+			}
+		}
+		return height, found
+	}
+}
+
+// sweepFrame is the orientation of a cross section of a sweep.
+type sweepFrame struct {
+	right math32.Vector3
+	up    math32.Vector3
+}
+
+// sweepFrames returns the frames of the cross sections at the specified centers, upright or
+// rotation minimizing, with the directions of the curve between the centers.
+func sweepFrames(path *math32.Spline, centers []math32.Vector3, free bool) []sweepFrame {
+
+	count := len(centers)
+	closed := path.Closed() && centers[0].DistanceToSquared(&centers[count-1]) < 1e-8
+	tangents := make([]math32.Vector3, count)
+	for k := range centers {
+		prev, next := k-1, k+1
+		if prev < 0 {
+			prev = 0
+			if closed {
+				prev = count - 2
+			}
+		}
+		if next >= count {
+			next = count - 1
+			if closed {
+				next = 1
+			}
+		}
+		tangents[k].SubVectors(&centers[next], &centers[prev])
+		if tangents[k].LengthSq() == 0 && k > 0 {
+			tangents[k] = tangents[k-1]
+		}
+		tangents[k].Normalize()
+	}
+
+	frames := make([]sweepFrame, count)
+	worldUp := math32.Vector3{Y: 1}
+	lastRight := math32.Vector3{X: 1}
+	for k := range frames {
+		f := &frames[k]
+		t := &tangents[k]
+		if k == 0 || !free {
+			f.right.CrossVectors(t, &worldUp)
+			if f.right.LengthSq() < 1e-8 {
+				// Vertical direction keeps the last right direction
+				f.right = lastRight
+				f.right.Sub(t.Clone().MultiplyScalar(f.right.Dot(t)))
+			}
+			f.right.Normalize()
+		} else {
+			// Double reflection rotation minimizing frame
+			var v1, rL, tL math32.Vector3
+			v1.SubVectors(&centers[k], &centers[k-1])
+			c1 := v1.Dot(&v1)
+			if c1 == 0 {
+				f.right = frames[k-1].right
+			} else {
+				rL = frames[k-1].right
+				rL.Sub(v1.Clone().MultiplyScalar(2 / c1 * v1.Dot(&rL)))
+				tL = tangents[k-1]
+				tL.Sub(v1.Clone().MultiplyScalar(2 / c1 * v1.Dot(&tL)))
+				var v2 math32.Vector3
+				v2.SubVectors(t, &tL)
+				c2 := v2.Dot(&v2)
+				f.right = rL
+				if c2 > 0 {
+					f.right.Sub(v2.Clone().MultiplyScalar(2 / c2 * v2.Dot(&rL)))
+				}
+			}
+			f.right.Normalize()
+		}
+		f.up.CrossVectors(&f.right, t).Normalize()
+		lastRight = f.right
+	}
+	return frames
+}
+
+// sweepBank returns the banking angle at the specified curve parameter,
+// interpolated between the angles at the knots.
+func sweepBank(path *math32.Spline, banking []float32, t float32) float32 {
+
+	if len(banking) == 0 {
+		return 0
+	}
+	x := t * float32(path.Segments())
+	i := int(x)
+	frac := x - float32(i)
+	a := banking[math32.ClampInt(i, 0, len(banking)-1)]
+	j := i + 1
+	if path.Closed() {
+		j %= len(banking)
+	}
+	b := banking[math32.ClampInt(j, 0, len(banking)-1)]
+	return a + (b-a)*frac
+}
+
+// edgeNormal returns the unit normal on the left of a profile edge, or zero if it is degenerate.
+func edgeNormal(a, b *math32.Vector2) *math32.Vector2 {
+
+	n := math32.Vector2{X: a.Y - b.Y, Y: b.X - a.X}
+	if n.LengthSq() == 0 {
+		return &n
+	}
+	return n.Normalize()
+}
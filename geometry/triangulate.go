@@ -0,0 +1,474 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"math"
+	"sort"
+
+	"github.com/g3n/engine/math32"
+)
+
+// Triangulate triangulates the polygon with the specified outer contour and holes using
+// ear clipping, returning the indices of the triangles into the points of the contour
+// followed by the points of each hole. The contour and holes may have any orientation and
+// the triangles are returned counter clockwise. Self intersecting polygons are triangulated
+// on a best effort basis.
+func Triangulate(contour []math32.Vector2, holes [][]math32.Vector2) []int {
+
+	points := make([]math32.Vector2, 0, len(contour))
+	points = append(points, contour...)
+	starts := make([]int, 0, len(holes))
+	for _, hole := range holes {
+		starts = append(starts, len(points))
+		points = append(points, hole...)
+	}
+
+	tris := make([]int, 0, 3*len(points))
+	outer := earList(points, 0, len(contour), true)
+	if outer == nil || outer.next == outer.prev {
+		return tris
+	}
+	for _, i := range sortHoles(holes) {
+		if len(holes[i]) == 0 {
+			continue
+		}
+		start := starts[i]
+		outer = earEliminateHole(earList(points, start, start+len(holes[i]), false), outer)
+	}
+	tris = earcut(outer, tris, 0)
+
+	// Ear clipping keeps the orientation of the outer contour, given by the total area
+	// of the triangles as slivers may have either orientation
+	area := float32(0)
+	for i := 0; i+2 < len(tris); i += 3 {
+		a, b, c := points[tris[i]], points[tris[i+1]], points[tris[i+2]]
+		area += (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+	}
+	if area < 0 {
+		for i := 0; i+2 < len(tris); i += 3 {
+			tris[i+1], tris[i+2] = tris[i+2], tris[i+1]
+		}
+	}
+	return tris
+}
+
+// earNode is a vertex of the circular doubly linked list used by the ear clipping triangulation.
+type earNode struct {
+	i          int     // Index of the vertex
+	x, y       float64 // Vertex coordinates
+	prev, next *earNode
+	steiner    bool // Whether the vertex is a single point hole
+}
+
+// earList creates a circular linked list with the specified range of points in the
+// specified orientation and returns its last node.
+func earList(points []math32.Vector2, start, end int, clockwise bool) *earNode {
+
+	sum := float64(0)
+	for i, j := start, end-1; i < end; j, i = i, i+1 {
+		sum += float64(points[j].X-points[i].X) * float64(points[i].Y+points[j].Y)
+	}
+	var last *earNode
+	if clockwise == (sum > 0) {
+		for i := start; i < end; i++ {
+			last = earInsert(i, points[i], last)
+		}
+	} else {
+		for i := end - 1; i >= start; i-- {
+			last = earInsert(i, points[i], last)
+		}
+	}
+	if last != nil && earEquals(last, last.next) {
+		earRemove(last)
+		last = last.next
+	}
+	return last
+}
+
+// earInsert creates a node for the specified point and inserts it after the specified node.
+func earInsert(i int, p math32.Vector2, last *earNode) *earNode {
+
+	n := &earNode{i: i, x: float64(p.X), y: float64(p.Y)}
+	if last == nil {
+		n.prev = n
+		n.next = n
+		return n
+	}
+	n.next = last.next
+	n.prev = last
+	last.next.prev = n
+	last.next = n
+	return n
+}
+
+// earRemove removes the specified node from its list.
+func earRemove(n *earNode) {
+
+	n.next.prev = n.prev
+	n.prev.next = n.next
+}
+
+// earFilter removes duplicate and collinear points between start and end.
+func earFilter(start, end *earNode) *earNode {
+
+	if start == nil {
+		return start
+	}
+	if end == nil {
+		end = start
+	}
+	p := start
+	for {
+		again := false
+		if !p.steiner && (earEquals(p, p.next) || earArea(p.prev, p, p.next) == 0) {
+			earRemove(p)
+			p = p.prev
+			end = p
+			if p == p.next {
+				break
+			}
+			again = true
+		} else {
+			p = p.next
+		}
+		if !again && p == end {
+			break
+		}
+	}
+	return end
+}
+
+// earcut clips the ears of the polygon starting at the specified node, appending the
+// triangles to tris. When no ear is found, the next pass filters collinear points,
+// then cures local self intersections and finally splits the polygon in two.
+func earcut(ear *earNode, tris []int, pass int) []int {
+
+	if ear == nil {
+		return tris
+	}
+	stop := ear
+	for ear.prev != ear.next {
+		prev := ear.prev
+		next := ear.next
+		if earIsEar(ear) {
+			tris = append(tris, prev.i, ear.i, next.i)
+			earRemove(ear)
+			ear = next.next
+			stop = next.next
+			continue
+		}
+		ear = next
+		if ear == stop {
+			switch pass {
+			case 0:
+				tris = earcut(earFilter(ear, nil), tris, 1)
+			case 1:
+				ear, tris = earCureIntersections(earFilter(ear, nil), tris)
+				tris = earcut(ear, tris, 2)
+			case 2:
+				tris = earSplit(ear, tris)
+			}
+			return tris
+		}
+	}
+	return tris
+}
+
+// earIsEar returns if the triangle formed by the node and its neighbours is a valid ear.
+func earIsEar(ear *earNode) bool {
+
+	a, b, c := ear.prev, ear, ear.next
+	if earArea(a, b, c) >= 0 {
+		return false // reflex vertex
+	}
+	for p := c.next; p != a; p = p.next {
+		if earInTriangle(a.x, a.y, b.x, b.y, c.x, c.y, p.x, p.y) && earArea(p.prev, p, p.next) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// earCureIntersections removes small self intersections by clipping the triangles they form.
+func earCureIntersections(start *earNode, tris []int) (*earNode, []int) {
+
+	p := start
+	for {
+		a := p.prev
+		b := p.next.next
+		if !earEquals(a, b) && earIntersects(a, p, p.next, b) && earLocallyInside(a, b) && earLocallyInside(b, a) {
+			tris = append(tris, a.i, p.i, b.i)
+			earRemove(p)
+			earRemove(p.next)
+			p = b
+			start = b
+		}
+		p = p.next
+		if p == start {
+			break
+		}
+	}
+	return earFilter(p, nil), tris
+}
+
+// earSplit splits the polygon along a valid diagonal and triangulates both halves.
+func earSplit(start *earNode, tris []int) []int {
+
+	a := start
+	for {
+		for b := a.next.next; b != a.prev; b = b.next {
+			if a.i != b.i && earValidDiagonal(a, b) {
+				c := earSplitPolygon(a, b)
+				a = earFilter(a, a.next)
+				c = earFilter(c, c.next)
+				tris = earcut(a, tris, 0)
+				return earcut(c, tris, 0)
+			}
+		}
+		a = a.next
+		if a == start {
+			return tris
+		}
+	}
+}
+
+// earEliminateHole connects the specified hole to the outer polygon with a bridge,
+// returning the new outer polygon.
+func earEliminateHole(hole, outer *earNode) *earNode {
+
+	if hole == nil {
+		return outer
+	}
+	if hole == hole.next {
+		hole.steiner = true
+	}
+	// Uses the leftmost vertex of the hole
+	left := hole
+	for p := hole.next; p != hole; p = p.next {
+		if p.x < left.x || (p.x == left.x && p.y < left.y) {
+			left = p
+		}
+	}
+	bridge := earHoleBridge(left, outer)
+	if bridge == nil {
+		return outer
+	}
+	reverse := earSplitPolygon(bridge, left)
+	earFilter(reverse, reverse.next)
+	return earFilter(bridge, bridge.next)
+}
+
+// earHoleBridge finds a vertex of the outer polygon visible from the specified hole vertex.
+func earHoleBridge(hole, outer *earNode) *earNode {
+
+	hx, hy := hole.x, hole.y
+	qx := math.Inf(-1)
+	var m *earNode
+
+	// Finds the segment of the outer polygon to the left of the hole vertex and closest to it
+	p := outer
+	for {
+		if hy <= p.y && hy >= p.next.y && p.next.y != p.y {
+			x := p.x + (hy-p.y)*(p.next.x-p.x)/(p.next.y-p.y)
+			if x <= hx && x > qx {
+				qx = x
+				m = p.next
+				if p.x < p.next.x {
+					m = p
+				}
+				if x == hx {
+					return m
+				}
+			}
+		}
+		p = p.next
+		if p == outer {
+			break
+		}
+	}
+	if m == nil {
+		return nil
+	}
+
+	// Looks for points inside the triangle formed by the hole vertex, the intersection
+	// and the segment endpoint, choosing the one with the smallest angle to the hole vertex
+	stop := m
+	mx, my := m.x, m.y
+	tanMin := math.Inf(1)
+	p = m
+	for {
+		ax, cx := qx, hx
+		if hy < my {
+			ax, cx = hx, qx
+		}
+		if hx >= p.x && p.x >= mx && hx != p.x && earInTriangle(ax, hy, mx, my, cx, hy, p.x, p.y) {
+			tan := math.Abs(hy-p.y) / (hx - p.x)
+			if earLocallyInside(p, hole) &&
+				(tan < tanMin || (tan == tanMin && (p.x > m.x || (p.x == m.x && earSectorContainsSector(m, p))))) {
+				m = p
+				tanMin = tan
+			}
+		}
+		p = p.next
+		if p == stop {
+			break
+		}
+	}
+	return m
+}
+
+// earSectorContainsSector returns if the sector of the vertex m contains the sector of the vertex p.
+func earSectorContainsSector(m, p *earNode) bool {
+
+	return earArea(m.prev, m, p.prev) < 0 && earArea(p.next, m, m.next) < 0
+}
+
+// earValidDiagonal returns if the diagonal between the specified nodes is inside the polygon.
+func earValidDiagonal(a, b *earNode) bool {
+
+	if a.next.i == b.i || a.prev.i == b.i || earIntersectsPolygon(a, b) {
+		return false
+	}
+	if earLocallyInside(a, b) && earLocallyInside(b, a) && earMiddleInside(a, b) &&
+		(earArea(a.prev, a, b.prev) != 0 || earArea(a, b.prev, b) != 0) {
+		return true
+	}
+	return earEquals(a, b) && earArea(a.prev, a, a.next) > 0 && earArea(b.prev, b, b.next) > 0
+}
+
+// earIntersectsPolygon returns if the segment between the specified nodes intersects any polygon edge.
+func earIntersectsPolygon(a, b *earNode) bool {
+
+	p := a
+	for {
+		if p.i != a.i && p.next.i != a.i && p.i != b.i && p.next.i != b.i && earIntersects(p, p.next, a, b) {
+			return true
+		}
+		p = p.next
+		if p == a {
+			return false
+		}
+	}
+}
+
+// earLocallyInside returns if the diagonal from a to b starts inside the polygon.
+func earLocallyInside(a, b *earNode) bool {
+
+	if earArea(a.prev, a, a.next) < 0 {
+		return earArea(a, b, a.next) >= 0 && earArea(a, a.prev, b) >= 0
+	}
+	return earArea(a, b, a.prev) < 0 || earArea(a, a.next, b) < 0
+}
+
+// earMiddleInside returns if the middle point of the diagonal from a to b is inside the polygon.
+func earMiddleInside(a, b *earNode) bool {
+
+	inside := false
+	px := (a.x + b.x) / 2
+	py := (a.y + b.y) / 2
+	p := a
+	for {
+		if (p.y > py) != (p.next.y > py) && p.next.y != p.y && px < (p.next.x-p.x)*(py-p.y)/(p.next.y-p.y)+p.x {
+			inside = !inside
+		}
+		p = p.next
+		if p == a {
+			return inside
+		}
+	}
+}
+
+// earSplitPolygon links a and b with a diagonal, splitting the polygon in two,
+// and returns a node of the second polygon.
+func earSplitPolygon(a, b *earNode) *earNode {
+
+	a2 := &earNode{i: a.i, x: a.x, y: a.y}
+	b2 := &earNode{i: b.i, x: b.x, y: b.y}
+	an := a.next
+	bp := b.prev
+
+	a.next = b
+	b.prev = a
+	a2.next = an
+	an.prev = a2
+	b2.next = a2
+	a2.prev = b2
+	bp.next = b2
+	b2.prev = bp
+	return b2
+}
+
+// earIntersects returns if the segments p1-q1 and p2-q2 intersect.
+func earIntersects(p1, q1, p2, q2 *earNode) bool {
+
+	o1 := earSign(earArea(p1, q1, p2))
+	o2 := earSign(earArea(p1, q1, q2))
+	o3 := earSign(earArea(p2, q2, p1))
+	o4 := earSign(earArea(p2, q2, q1))
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+	return (o1 == 0 && earOnSegment(p1, p2, q1)) || (o2 == 0 && earOnSegment(p1, q2, q1)) ||
+		(o3 == 0 && earOnSegment(p2, p1, q2)) || (o4 == 0 && earOnSegment(p2, q1, q2))
+}
+
+// earOnSegment returns if q is inside the bounding box of the collinear segment p-r.
+func earOnSegment(p, q, r *earNode) bool {
+
+	return q.x <= math.Max(p.x, r.x) && q.x >= math.Min(p.x, r.x) &&
+		q.y <= math.Max(p.y, r.y) && q.y >= math.Min(p.y, r.y)
+}
+
+// earInTriangle returns if the point p is inside the triangle abc.
+func earInTriangle(ax, ay, bx, by, cx, cy, px, py float64) bool {
+
+	return (cx-px)*(ay-py) >= (ax-px)*(cy-py) &&
+		(ax-px)*(by-py) >= (bx-px)*(ay-py) &&
+		(bx-px)*(cy-py) >= (cx-px)*(by-py)
+}
+
+// earArea returns the signed area of the triangle pqr.
+func earArea(p, q, r *earNode) float64 {
+
+	return (q.y-p.y)*(r.x-q.x) - (q.x-p.x)*(r.y-q.y)
+}
+
+// earEquals returns if the nodes have the same coordinates.
+func earEquals(a, b *earNode) bool {
+
+	return a.x == b.x && a.y == b.y
+}
+
+// earSign returns the sign of the specified value.
+func earSign(v float64) int {
+
+	if v > 0 {
+		return 1
+	}
+	if v < 0 {
+		return -1
+	}
+	return 0
+}
+
+// sortHoles returns the indices of the specified holes sorted by their leftmost X coordinate,
+// which is the order in which they must be bridged to the outer contour.
+func sortHoles(holes [][]math32.Vector2) []int {
+
+	left := make([]float32, len(holes))
+	order := make([]int, len(holes))
+	for i, hole := range holes {
+		order[i] = i
+		left[i] = math32.Infinity
+		for _, p := range hole {
+			if p.X < left[i] {
+				left[i] = p.X
+			}
+		}
+	}
+	sort.Slice(order, func(a, b int) bool { return left[order[a]] < left[order[b]] })
+	return order
+}
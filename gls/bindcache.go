@@ -0,0 +1,145 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+// bindCache keeps the objects currently bound to the OpenGL context,
+// so redundant binding calls can be filtered.
+type bindCache struct {
+	vao          uint32                    // bound vertex array object
+	vaoValid     bool                      // vao is known
+	buffers      map[int]uint32            // bound buffers by target
+	textures     map[textureBinding]uint32 // bound textures by texture unit and target
+	framebuffers map[uint32]uint32         // bound framebuffers by target (READ_FRAMEBUFFER and DRAW_FRAMEBUFFER)
+}
+
+// textureBinding identifies a texture binding point.
+type textureBinding struct {
+	unit   uint32 // texture unit (TEXTURE0, TEXTURE1, ...)
+	target int    // texture target (TEXTURE_2D, ...)
+}
+
+// reset forgets all the cached bindings.
+func (bc *bindCache) reset() {
+
+	bc.vao = 0
+	bc.vaoValid = false
+	bc.buffers = make(map[int]uint32)
+	bc.textures = make(map[textureBinding]uint32)
+	bc.framebuffers = make(map[uint32]uint32)
+}
+
+// vertexArray records the binding of the specified vertex array object
+// and returns true if it was already bound.
+func (bc *bindCache) vertexArray(vao uint32) bool {
+
+	if bc.vaoValid && bc.vao == vao {
+		return true
+	}
+	bc.vao = vao
+	bc.vaoValid = true
+	// The element array buffer binding is part of the vertex array state
+	delete(bc.buffers, ELEMENT_ARRAY_BUFFER)
+	return false
+}
+
+// buffer records the binding of the specified buffer to the specified target
+// and returns true if it was already bound.
+func (bc *bindCache) buffer(target int, buf uint32) bool {
+
+	if curr, ok := bc.buffers[target]; ok && curr == buf {
+		return true
+	}
+	bc.buffers[target] = buf
+	return false
+}
+
+// texture records the binding of the specified texture to the specified
+// unit and target and returns true if it was already bound.
+func (bc *bindCache) texture(unit uint32, target int, tex uint32) bool {
+
+	key := textureBinding{unit, target}
+	if curr, ok := bc.textures[key]; ok && curr == tex {
+		return true
+	}
+	bc.textures[key] = tex
+	return false
+}
+
+// framebuffer records the binding of the specified framebuffer to the specified
+// target and returns true if it was already bound.
+func (bc *bindCache) framebuffer(target uint32, fb uint32) bool {
+
+	if target == FRAMEBUFFER {
+		draw, okDraw := bc.framebuffers[DRAW_FRAMEBUFFER]
+		read, okRead := bc.framebuffers[READ_FRAMEBUFFER]
+		if okDraw && okRead && draw == fb && read == fb {
+			return true
+		}
+		bc.framebuffers[DRAW_FRAMEBUFFER] = fb
+		bc.framebuffers[READ_FRAMEBUFFER] = fb
+		return false
+	}
+	if curr, ok := bc.framebuffers[target]; ok && curr == fb {
+		return true
+	}
+	bc.framebuffers[target] = fb
+	return false
+}
+
+// deleteVertexArrays updates the cache for deleted vertex array objects,
+// which revert the binding to zero if bound.
+func (bc *bindCache) deleteVertexArrays(vaos []uint32) {
+
+	for _, vao := range vaos {
+		if bc.vaoValid && bc.vao == vao {
+			bc.vertexArray(0)
+		}
+	}
+}
+
+// deleteBuffers updates the cache for deleted buffers, which revert the bindings to zero.
+func (bc *bindCache) deleteBuffers(bufs []uint32) {
+
+	for _, buf := range bufs {
+		for target, curr := range bc.buffers {
+			if curr == buf {
+				bc.buffers[target] = 0
+			}
+		}
+	}
+}
+
+// deleteTextures updates the cache for deleted textures, which revert the bindings to zero.
+func (bc *bindCache) deleteTextures(texs []uint32) {
+
+	for _, tex := range texs {
+		for key, curr := range bc.textures {
+			if curr == tex {
+				bc.textures[key] = 0
+			}
+		}
+	}
+}
+
+// deleteFramebuffers updates the cache for deleted framebuffers, which revert the bindings to zero.
+func (bc *bindCache) deleteFramebuffers(fbs []uint32) {
+
+	for _, fb := range fbs {
+		for target, curr := range bc.framebuffers {
+			if curr == fb {
+				bc.framebuffers[target] = 0
+			}
+		}
+	}
+}
+
+// InvalidateBindings forgets the cached object bindings (buffers, textures, vertex arrays
+// and framebuffers), so the next binding calls are always issued. It must be called when
+// OpenGL objects are bound by code which does not use this GLS, such as other libraries
+// sharing the context.
+func (gs *GLS) InvalidateBindings() {
+
+	gs.bind.reset()
+}
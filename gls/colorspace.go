@@ -0,0 +1,22 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+// SetLinearColors sets whether the linear color pipeline is enabled. When enabled, sRGB
+// textures are decoded to linear values when sampled, material, light, vertex and panel
+// colors, which are specified in sRGB, are converted to linear values, lighting and
+// blending are computed with linear values, and the renderer encodes the output to sRGB
+// with an sRGB framebuffer. When disabled, the default, all colors are used as specified.
+// It should be set once, before the first frame is rendered.
+func (gs *GLS) SetLinearColors(enable bool) {
+
+	gs.linear = enable
+}
+
+// LinearColors returns whether the linear color pipeline is enabled.
+func (gs *GLS) LinearColors() bool {
+
+	return gs.linear
+}
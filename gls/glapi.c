@@ -536,6 +536,10 @@ static PFNGLVERTEXATTRIBP3UIPROC                      pglVertexAttribP3ui;
 static PFNGLVERTEXATTRIBP3UIVPROC                     pglVertexAttribP3uiv;
 static PFNGLVERTEXATTRIBP4UIPROC                      pglVertexAttribP4ui;
 static PFNGLVERTEXATTRIBP4UIVPROC                     pglVertexAttribP4uiv;
+static PFNGLDRAWELEMENTSINDIRECTPROC                  pglDrawElementsIndirect;
+static PFNGLMULTIDRAWELEMENTSINDIRECTPROC             pglMultiDrawElementsIndirect;
+static PFNGLBUFFERSTORAGEPROC                         pglBufferStorage;
+static PFNGLMAXSHADERCOMPILERTHREADSARBPROC           pglMaxShaderCompilerThreadsARB;
 
 //
 // load_procs loads all gl functions addresses into the pointers
@@ -887,6 +891,10 @@ static void load_procs() {
 	pglVertexAttribP3uiv = (PFNGLVERTEXATTRIBP3UIVPROC)get_proc("glVertexAttribP3uiv"); 
 	pglVertexAttribP4ui = (PFNGLVERTEXATTRIBP4UIPROC)get_proc("glVertexAttribP4ui"); 
 	pglVertexAttribP4uiv = (PFNGLVERTEXATTRIBP4UIVPROC)get_proc("glVertexAttribP4uiv"); 
+	pglDrawElementsIndirect = (PFNGLDRAWELEMENTSINDIRECTPROC)get_proc("glDrawElementsIndirect"); 
+	pglMultiDrawElementsIndirect = (PFNGLMULTIDRAWELEMENTSINDIRECTPROC)get_proc("glMultiDrawElementsIndirect"); 
+	pglBufferStorage = (PFNGLBUFFERSTORAGEPROC)get_proc("glBufferStorage"); 
+	pglMaxShaderCompilerThreadsARB = (PFNGLMAXSHADERCOMPILERTHREADSARBPROC)get_proc("glMaxShaderCompilerThreadsARB"); 
 	
 }
 
@@ -5036,4 +5044,76 @@ void glVertexAttribP4uiv (GLuint index, GLenum type, GLboolean normalized, const
 	
 }
 
+void glDrawElementsIndirect (GLenum mode, GLenum type, const void *indirect) {
+
+	pglDrawElementsIndirect(mode, type, indirect);
+	if (checkError) {
+		GLenum err = pglGetError();
+		if (err != GL_NO_ERROR) {
+			panic(err, "glDrawElementsIndirect");
+		}
+	}
+	
+}
+
+void glMultiDrawElementsIndirect (GLenum mode, GLenum type, const void *indirect, GLsizei drawcount, GLsizei stride) {
+
+	pglMultiDrawElementsIndirect(mode, type, indirect, drawcount, stride);
+	if (checkError) {
+		GLenum err = pglGetError();
+		if (err != GL_NO_ERROR) {
+			panic(err, "glMultiDrawElementsIndirect");
+		}
+	}
+	
+}
+
+void glBufferStorage (GLenum target, GLsizeiptr size, const void *data, GLbitfield flags) {
+
+	pglBufferStorage(target, size, data, flags);
+	if (checkError) {
+		GLenum err = pglGetError();
+		if (err != GL_NO_ERROR) {
+			panic(err, "glBufferStorage");
+		}
+	}
+	
+}
+
+void glMaxShaderCompilerThreadsARB (GLuint count) {
+
+	pglMaxShaderCompilerThreadsARB(count);
+	if (checkError) {
+		GLenum err = pglGetError();
+		if (err != GL_NO_ERROR) {
+			panic(err, "glMaxShaderCompilerThreadsARB");
+		}
+	}
+	
+}
+
+
+// glapiHasDrawElementsIndirect returns if the optional function glDrawElementsIndirect was loaded
+int glapiHasDrawElementsIndirect(void) {
+
+	return pglDrawElementsIndirect != NULL;
+}
+
+// glapiHasMultiDrawElementsIndirect returns if the optional function glMultiDrawElementsIndirect was loaded
+int glapiHasMultiDrawElementsIndirect(void) {
+
+	return pglMultiDrawElementsIndirect != NULL;
+}
+
+// glapiHasBufferStorage returns if the optional function glBufferStorage was loaded
+int glapiHasBufferStorage(void) {
+
+	return pglBufferStorage != NULL;
+}
+
+// glapiHasMaxShaderCompilerThreadsARB returns if the optional function glMaxShaderCompilerThreadsARB was loaded
+int glapiHasMaxShaderCompilerThreadsARB(void) {
+
+	return pglMaxShaderCompilerThreadsARB != NULL;
+}
 
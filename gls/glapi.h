@@ -13,4 +13,16 @@ int glapiLoad(void);
 // Set the internal flag to enable/disable OpenGL error checking
 void glapiCheckError(int check);
 
+// Returns if the optional function glDrawElementsIndirect was loaded
+int glapiHasDrawElementsIndirect(void);
+
+// Returns if the optional function glMultiDrawElementsIndirect was loaded
+int glapiHasMultiDrawElementsIndirect(void);
+
+// Returns if the optional function glBufferStorage was loaded
+int glapiHasBufferStorage(void);
+
+// Returns if the optional function glMaxShaderCompilerThreadsARB was loaded
+int glapiHasMaxShaderCompilerThreadsARB(void);
+
 #endif
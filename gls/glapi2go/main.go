@@ -20,6 +20,7 @@ const (
 // Command line options
 var (
 	oGLVersion = flag.String("glversion", "GL_VERSION_3_3", "OpenGL version to use")
+	oGLExt     = flag.String("ext", "", "Comma separated list of optional functions from later OpenGL versions")
 )
 
 const (
@@ -111,10 +112,19 @@ func parser(fheader io.Reader, h *GLHeader) error {
 	// capturing return value (1), function name (2) and parameters (3)
 	rexApi := regexp.MustCompile(`GLAPI\s+(.*)APIENTRY\s+(\w+)\s+\((.*)\)`)
 
+	// Optional functions from later versions, loaded if available
+	optional := make(map[string]bool)
+	for _, name := range strings.Split(*oGLExt, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			optional[name] = true
+		}
+	}
+
 	h.Defines = make([]GLDefine, 0)
 	h.Funcs = make([]GLFunc, 0)
 	bufin := bufio.NewReader(fheader)
 	maxLength := 0
+	later := false
 	for {
 		// Reads next line and abort on error (not EOF)
 		line, err := bufin.ReadString('\n')
@@ -127,13 +137,17 @@ func parser(fheader io.Reader, h *GLHeader) error {
 		res := rexEndif.FindStringSubmatch(line)
 		if len(res) > 0 {
 			if res[1] == *oGLVersion {
-				break
+				if len(optional) == 0 {
+					break
+				}
+				// Continues parsing only to find the optional functions
+				later = true
 			}
 		}
 
 		// Checks for "#define" of GL constants
 		res = rexDefine.FindStringSubmatch(line)
-		if len(res) >= 3 {
+		if len(res) >= 3 && !later {
 			dname := res[1]
 			if strings.HasPrefix(dname, "GL_") {
 				h.Defines = append(h.Defines, GLDefine{
@@ -145,8 +159,9 @@ func parser(fheader io.Reader, h *GLHeader) error {
 
 		// Checks for function declaration
 		res = rexApi.FindStringSubmatch(line)
-		if len(res) >= 2 {
+		if len(res) >= 2 && (!later || optional[res[2]]) {
 			var f GLFunc
+			f.Optional = later
 			f.Rtype = strings.Trim(res[1], " ")
 			f.Ptype = "PFN" + strings.ToUpper(res[2]) + "PROC"
 			f.Fname = res[2]
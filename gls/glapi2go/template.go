@@ -31,6 +31,7 @@ type GLFunc struct {
 	Args     string    // list of comma separated argument names ex:"x, y, z"
 	GoParams string    // list of comma separated Go parameters ex:"x float32, y float32"
 	Params   []GLParam // array of function parameters
+	Optional bool      // function from a later OpenGL version which may not be available
 }
 
 // GLParam is the definition of an argument to an OpenGL function (GLFunc).
@@ -316,7 +317,15 @@ static void load_procs() {
 	{{- end}}
 }
 {{end}}
+{{range .Funcs}}
+{{- if .Optional}}
+// glapiHas{{.FnameGo}} returns if the optional function {{.Fname}} was loaded
+int glapiHas{{.FnameGo}}(void) {
 
+	return {{.Pname}} != NULL;
+}
+{{end}}
+{{- end}}
 `
 
 //
@@ -336,7 +345,12 @@ int glapiLoad(void);
 
 // Set the internal flag to enable/disable OpenGL error checking
 void glapiCheckError(int check);
-
+{{range .Funcs}}
+{{- if .Optional}}
+// Returns if the optional function {{.Fname}} was loaded
+int glapiHas{{.FnameGo}}(void);
+{{end}}
+{{- end}}
 #endif
 `
 
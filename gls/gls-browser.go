@@ -19,6 +19,10 @@ type GLS struct {
 	prog        *Program          // current active shader program
 	programs    map[*Program]bool // shader programs cache
 	checkErrors bool              // check openGL API errors flag
+	bind        bindCache         // cached object bindings
+	linear      bool              // linear color pipeline enabled
+
+	parallelCompile bool // shader compile completion can be queried
 
 	// Cache WebGL state to avoid making unnecessary API calls
 	activeTexture       uint32      // cached last set active texture unit
@@ -29,12 +33,10 @@ type GLS struct {
 	lineWidth           float32     // cached last set line width
 	sideView            int         // cached last set triangle side view mode
 	frontFace           uint32      // cached last set glFrontFace value
+	cullFace            uint32      // cached last set glCullFace value
 	depthFunc           uint32      // cached last set depth function
 	depthMask           int         // cached last set depth mask
 	capabilities        map[int]int // cached capabilities (Enable/Disable)
-	blendEquation       uint32      // cached last set blend equation value
-	blendSrc            uint32      // cached last set blend src value
-	blendDst            uint32      // cached last set blend equation destination value
 	blendEquationRGB    uint32      // cached last set blend equation rgb value
 	blendEquationAlpha  uint32      // cached last set blend equation alpha value
 	blendSrcRGB         uint32      // cached last set blend src rgb
@@ -45,6 +47,9 @@ type GLS struct {
 	polygonModeMode     uint32      // cached last set polygon mode mode
 	polygonOffsetFactor float32     // cached last set polygon offset factor
 	polygonOffsetUnits  float32     // cached last set polygon offset units
+	colorMask           int         // cached last set color mask bits
+	blendColor          [4]float32  // cached last set blend color
+	blendColorSet       bool        // blend color was set
 
 	// js.Value storage maps
 	programMap      map[uint32]js.Value
@@ -102,6 +107,8 @@ func New(webglCtx js.Value) (*GLS, error) {
 	gs.uniformMapIndex = 1
 	gs.vertexArrayMapIndex = 1
 
+	gs.parallelCompile = gs.gl.Call("getExtension", "KHR_parallel_shader_compile").Truthy()
+
 	gs.setDefaultState()
 	return gs, nil
 }
@@ -126,16 +133,17 @@ func (gs *GLS) reset() {
 	gs.lineWidth = 0.0
 	gs.sideView = uintUndef
 	gs.frontFace = 0
+	gs.cullFace = 0
 	gs.depthFunc = 0
 	gs.depthMask = uintUndef
+	gs.colorMask = uintUndef
+	gs.blendColorSet = false
 	gs.capabilities = make(map[int]int)
 	gs.programs = make(map[*Program]bool)
 	gs.prog = nil
+	gs.bind.reset()
 
 	gs.activeTexture = uintUndef
-	gs.blendEquation = uintUndef
-	gs.blendSrc = uintUndef
-	gs.blendDst = uintUndef
 	gs.blendEquationRGB = 0
 	gs.blendEquationAlpha = 0
 	gs.blendSrcRGB = uintUndef
@@ -204,6 +212,10 @@ func (gs *GLS) AttachShader(program, shader uint32) {
 // BindBuffer binds a buffer object to the specified buffer binding point.
 func (gs *GLS) BindBuffer(target int, vbo uint32) {
 
+	if gs.bind.buffer(target, vbo) {
+		gs.stats.Bindhits++
+		return
+	}
 	gs.gl.Call("bindBuffer", target, gs.bufferMap[vbo])
 	gs.checkError("BindBuffer")
 }
@@ -211,6 +223,10 @@ func (gs *GLS) BindBuffer(target int, vbo uint32) {
 // BindTexture lets you create or use a named texture.
 func (gs *GLS) BindTexture(target int, tex uint32) {
 
+	if gs.bind.texture(gs.activeTexture, target, tex) {
+		gs.stats.Bindhits++
+		return
+	}
 	gs.gl.Call("bindTexture", target, gs.textureMap[tex])
 	gs.checkError("BindTexture")
 }
@@ -218,6 +234,10 @@ func (gs *GLS) BindTexture(target int, tex uint32) {
 // BindVertexArray binds the vertex array object.
 func (gs *GLS) BindVertexArray(vao uint32) {
 
+	if gs.bind.vertexArray(vao) {
+		gs.stats.Bindhits++
+		return
+	}
 	gs.gl.Call("bindVertexArray", gs.vertexArrayMap[vao])
 	gs.checkError("BindVertexArray")
 }
@@ -225,12 +245,13 @@ func (gs *GLS) BindVertexArray(vao uint32) {
 // BlendEquation sets the blend equations for all draw buffers.
 func (gs *GLS) BlendEquation(mode uint32) {
 
-	if gs.blendEquation == mode {
+	if gs.blendEquationRGB == mode && gs.blendEquationAlpha == mode {
 		return
 	}
 	gs.gl.Call("blendEquation", int(mode))
 	gs.checkError("BlendEquation")
-	gs.blendEquation = mode
+	gs.blendEquationRGB = mode
+	gs.blendEquationAlpha = mode
 }
 
 // BlendEquationSeparate sets the blend equations for all draw buffers
@@ -250,13 +271,16 @@ func (gs *GLS) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
 // all draw buffers when blending is enabled.
 func (gs *GLS) BlendFunc(sfactor, dfactor uint32) {
 
-	if gs.blendSrc == sfactor && gs.blendDst == dfactor {
+	if gs.blendSrcRGB == sfactor && gs.blendDstRGB == dfactor &&
+		gs.blendSrcAlpha == sfactor && gs.blendDstAlpha == dfactor {
 		return
 	}
 	gs.gl.Call("blendFunc", int(sfactor), int(dfactor))
 	gs.checkError("BlendFunc")
-	gs.blendSrc = sfactor
-	gs.blendDst = dfactor
+	gs.blendSrcRGB = sfactor
+	gs.blendDstRGB = dfactor
+	gs.blendSrcAlpha = sfactor
+	gs.blendDstAlpha = dfactor
 }
 
 // BlendFuncSeparate defines the operation of blending for all draw buffers when blending
@@ -275,16 +299,115 @@ func (gs *GLS) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32,
 	gs.blendDstAlpha = dstAlpha
 }
 
+// BlendColor sets the constant color used by the CONSTANT_COLOR and CONSTANT_ALPHA blend factors.
+func (gs *GLS) BlendColor(r, g, b, a float32) {
+
+	c := [4]float32{r, g, b, a}
+	if gs.blendColorSet && gs.blendColor == c {
+		return
+	}
+	gs.gl.Call("blendColor", r, g, b, a)
+	gs.checkError("BlendColor")
+	gs.blendColor = c
+	gs.blendColorSet = true
+}
+
 // BufferData creates a new data store for the buffer object currently
 // bound to target, deleting any pre-existing data store.
+// If data is nil, the data store is allocated with the specified size in bytes.
 func (gs *GLS) BufferData(target uint32, size int, data interface{}, usage uint32) {
 
+	if data == nil {
+		gs.gl.Call("bufferData", int(target), size, int(usage))
+		gs.checkError("BufferData")
+		return
+	}
 	dataTA := js.TypedArrayOf(data)
 	gs.gl.Call("bufferData", int(target), dataTA, int(usage))
 	gs.checkError("BufferData")
 	dataTA.Release()
 }
 
+// BufferSubData updates a subset of the data store of the buffer object
+// currently bound to target, starting at the specified byte offset.
+// All the specified data slice is used.
+func (gs *GLS) BufferSubData(target uint32, offset, size int, data interface{}) {
+
+	dataTA := js.TypedArrayOf(data)
+	gs.gl.Call("bufferSubData", int(target), offset, dataTA)
+	gs.checkError("BufferSubData")
+	dataTA.Release()
+}
+
+// BufferStorage is not supported by WebGL: it creates a mutable data store.
+// See BufferStorageSupported.
+func (gs *GLS) BufferStorage(target uint32, size int, data interface{}, flags uint32) {
+
+	gs.BufferData(target, size, data, DYNAMIC_DRAW)
+}
+
+// BufferStorageSupported returns if immutable buffer storage, required
+// for persistently mapped buffers, is supported. It is not supported by WebGL.
+func (gs *GLS) BufferStorageSupported() bool {
+
+	return false
+}
+
+// ParallelCompileSupported returns if the completion of shader compilation can be queried
+// with the COMPLETION_STATUS parameter without blocking.
+// It requires the KHR_parallel_shader_compile extension.
+func (gs *GLS) ParallelCompileSupported() bool {
+
+	return gs.parallelCompile
+}
+
+// MaxShaderCompilerThreads has no effect in WebGL, where the browser
+// chooses the number of threads used to compile shaders.
+func (gs *GLS) MaxShaderCompilerThreads(count uint32) {
+}
+
+// MapBufferRange is not supported by WebGL and always returns nil.
+func (gs *GLS) MapBufferRange(target uint32, offset, length int, access uint32) []byte {
+
+	return nil
+}
+
+// UnmapBuffer is not supported by WebGL and always returns false.
+func (gs *GLS) UnmapBuffer(target uint32) bool {
+
+	return false
+}
+
+// Sync is a WebGL sync object.
+type Sync *js.Value
+
+// FenceSync creates and inserts a fence sync object in the command stream
+// which is signaled when all the previous commands are completed.
+func (gs *GLS) FenceSync() Sync {
+
+	sync := gs.gl.Call("fenceSync", SYNC_GPU_COMMANDS_COMPLETE, 0)
+	gs.checkError("FenceSync")
+	return Sync(&sync)
+}
+
+// ClientWaitSync waits for the specified sync object to be signaled or for the
+// timeout in nanoseconds to expire and returns the wait status:
+// ALREADY_SIGNALED, CONDITION_SATISFIED, TIMEOUT_EXPIRED or WAIT_FAILED.
+// WebGL does not allow waiting, so the timeout is ignored.
+func (gs *GLS) ClientWaitSync(sync Sync, flags uint32, timeout uint64) uint32 {
+
+	res := gs.gl.Call("clientWaitSync", *sync, int(flags), 0).Int()
+	gs.checkError("ClientWaitSync")
+	return uint32(res)
+}
+
+// DeleteSync deletes the specified sync object.
+func (gs *GLS) DeleteSync(sync Sync) {
+
+	gs.gl.Call("deleteSync", *sync)
+	gs.checkError("DeleteSync")
+}
+
 // ClearColor specifies the red, green, blue, and alpha values
 // used by glClear to clear the color buffers.
 func (gs *GLS) ClearColor(r, g, b, a float32) {
@@ -349,6 +472,7 @@ func (gs *GLS) CreateShader(stype uint32) uint32 {
 // by the elements of the provided array.
 func (gs *GLS) DeleteBuffers(bufs ...uint32) {
 
+	gs.bind.deleteBuffers(bufs)
 	for _, buf := range bufs {
 		gs.gl.Call("deleteBuffer", gs.bufferMap[buf])
 		gs.checkError("DeleteBuffers")
@@ -379,6 +503,7 @@ func (gs *GLS) DeleteProgram(program uint32) {
 // by the elements of the provided array.
 func (gs *GLS) DeleteTextures(tex ...uint32) {
 
+	gs.bind.deleteTextures(tex)
 	for _, t := range tex {
 		gs.gl.Call("deleteTexture", gs.textureMap[t])
 		gs.checkError("DeleteTextures")
@@ -391,6 +516,7 @@ func (gs *GLS) DeleteTextures(tex ...uint32) {
 // by the elements of the provided array.
 func (gs *GLS) DeleteVertexArrays(vaos ...uint32) {
 
+	gs.bind.deleteVertexArrays(vaos)
 	for _, v := range vaos {
 		gs.gl.Call("deleteVertexArray", gs.vertexArrayMap[v])
 		gs.checkError("DeleteVertexArrays")
@@ -399,6 +525,104 @@ func (gs *GLS) DeleteVertexArrays(vaos ...uint32) {
 	}
 }
 
+// GenFramebuffer generates a framebuffer object name.
+func (gs *GLS) GenFramebuffer() uint32 {
+
+	gs.framebufferMap[gs.framebufferMapIndex] = gs.gl.Call("createFramebuffer")
+	gs.checkError("CreateFramebuffer")
+	idx := gs.framebufferMapIndex
+	gs.framebufferMapIndex++
+	gs.stats.Framebuffers++
+	return idx
+}
+
+// BindFramebuffer binds the specified framebuffer to the specified target.
+// Binding the framebuffer 0 restores the default (canvas) framebuffer.
+func (gs *GLS) BindFramebuffer(target uint32, fb uint32) {
+
+	if gs.bind.framebuffer(target, fb) {
+		gs.stats.Bindhits++
+		return
+	}
+	if fb == 0 {
+		gs.gl.Call("bindFramebuffer", int(target), js.Null())
+	} else {
+		gs.gl.Call("bindFramebuffer", int(target), gs.framebufferMap[fb])
+	}
+	gs.checkError("BindFramebuffer")
+}
+
+// DeleteFramebuffers deletes the specified framebuffer objects.
+func (gs *GLS) DeleteFramebuffers(fbs ...uint32) {
+
+	gs.bind.deleteFramebuffers(fbs)
+	for _, fb := range fbs {
+		gs.gl.Call("deleteFramebuffer", gs.framebufferMap[fb])
+		gs.checkError("DeleteFramebuffers")
+		delete(gs.framebufferMap, fb)
+		gs.stats.Framebuffers--
+	}
+}
+
+// FramebufferTexture2D attaches a level of a texture object to the currently bound framebuffer.
+func (gs *GLS) FramebufferTexture2D(target, attachment, textarget uint32, tex uint32, level int32) {
+
+	gs.gl.Call("framebufferTexture2D", int(target), int(attachment), int(textarget), gs.textureMap[tex], level)
+	gs.checkError("FramebufferTexture2D")
+}
+
+// FramebufferRenderbuffer attaches a renderbuffer object to the currently bound framebuffer.
+func (gs *GLS) FramebufferRenderbuffer(target, attachment, rbtarget uint32, rb uint32) {
+
+	gs.gl.Call("framebufferRenderbuffer", int(target), int(attachment), int(rbtarget), gs.renderbufferMap[rb])
+	gs.checkError("FramebufferRenderbuffer")
+}
+
+// CheckFramebufferStatus returns the completeness status of the framebuffer bound to the specified target.
+func (gs *GLS) CheckFramebufferStatus(target uint32) uint32 {
+
+	status := gs.gl.Call("checkFramebufferStatus", int(target))
+	gs.checkError("CheckFramebufferStatus")
+	return uint32(status.Int())
+}
+
+// GenRenderbuffer generates a renderbuffer object name.
+func (gs *GLS) GenRenderbuffer() uint32 {
+
+	gs.renderbufferMap[gs.renderbufferMapIndex] = gs.gl.Call("createRenderbuffer")
+	gs.checkError("CreateRenderbuffer")
+	idx := gs.renderbufferMapIndex
+	gs.renderbufferMapIndex++
+	gs.stats.Renderbuffers++
+	return idx
+}
+
+// BindRenderbuffer binds the specified renderbuffer to the specified target.
+func (gs *GLS) BindRenderbuffer(target uint32, rb uint32) {
+
+	gs.gl.Call("bindRenderbuffer", int(target), gs.renderbufferMap[rb])
+	gs.checkError("BindRenderbuffer")
+}
+
+// RenderbufferStorage establishes the data storage format and dimensions
+// of the renderbuffer bound to the specified target.
+func (gs *GLS) RenderbufferStorage(target, iformat uint32, width, height int32) {
+
+	gs.gl.Call("renderbufferStorage", int(target), int(iformat), width, height)
+	gs.checkError("RenderbufferStorage")
+}
+
+// DeleteRenderbuffers deletes the specified renderbuffer objects.
+func (gs *GLS) DeleteRenderbuffers(rbs ...uint32) {
+
+	for _, rb := range rbs {
+		gs.gl.Call("deleteRenderbuffer", gs.renderbufferMap[rb])
+		gs.checkError("DeleteRenderbuffers")
+		delete(gs.renderbufferMap, rb)
+		gs.stats.Renderbuffers--
+	}
+}
+
 // TODO ReadPixels
 
 // DepthFunc specifies the function used to compare each incoming pixel
@@ -431,6 +655,23 @@ func (gs *GLS) DepthMask(flag bool) {
 	}
 }
 
+// ColorMask enables or disables writing each of the color components into the color buffers.
+func (gs *GLS) ColorMask(r, g, b, a bool) {
+
+	mask := 0
+	for i, flag := range [4]bool{r, g, b, a} {
+		if flag {
+			mask |= 1 << uint(i)
+		}
+	}
+	if gs.colorMask == mask {
+		return
+	}
+	gs.gl.Call("colorMask", r, g, b, a)
+	gs.checkError("ColorMask")
+	gs.colorMask = mask
+}
+
 // DrawArrays renders primitives from array data.
 func (gs *GLS) DrawArrays(mode uint32, first int32, count int32) {
 
@@ -447,6 +688,50 @@ func (gs *GLS) DrawElements(mode uint32, count int32, itype uint32, start uint32
 	gs.stats.Drawcalls++
 }
 
+// DrawArraysInstanced renders instancecount instances of primitives from array data.
+func (gs *GLS) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+
+	gs.gl.Call("drawArraysInstanced", int(mode), first, count, instancecount)
+	gs.checkError("DrawArraysInstanced")
+	gs.stats.Drawcalls++
+}
+
+// DrawElementsInstanced renders instancecount instances of primitives from array data.
+func (gs *GLS) DrawElementsInstanced(mode uint32, count int32, itype uint32, start uint32, instancecount int32) {
+
+	gs.gl.Call("drawElementsInstanced", int(mode), count, int(itype), start, instancecount)
+	gs.checkError("DrawElementsInstanced")
+	gs.stats.Drawcalls++
+}
+
+// PrimitiveRestart has no effect in WebGL 2, which always restarts strip, fan
+// and loop primitives at the maximum index value (0xFFFFFFFF for UNSIGNED_INT indices).
+func (gs *GLS) PrimitiveRestart(enable bool) {
+}
+
+// MultiDrawElements renders multiple ranges of indexed primitives.
+// WebGL has no multi-draw, so each range is rendered with its own draw call.
+func (gs *GLS) MultiDrawElements(mode uint32, counts []int32, itype uint32, offsets []uintptr) {
+
+	for i, count := range counts {
+		gs.DrawElements(mode, count, itype, uint32(offsets[i]))
+	}
+}
+
+// DrawElementsIndirect is not supported by WebGL and has no effect (see MultiDrawIndirectSupported).
+func (gs *GLS) DrawElementsIndirect(mode uint32, itype uint32, offset int) {
+}
+
+// MultiDrawElementsIndirect is not supported by WebGL and has no effect (see MultiDrawIndirectSupported).
+func (gs *GLS) MultiDrawElementsIndirect(mode uint32, itype uint32, offset int, drawcount, stride int32) {
+}
+
+// MultiDrawIndirectSupported returns false, since WebGL has no indirect draws.
+func (gs *GLS) MultiDrawIndirectSupported() bool {
+
+	return false
+}
+
 // Enable enables the specified capability.
 func (gs *GLS) Enable(cap int) {
 
@@ -481,8 +766,12 @@ func (gs *GLS) EnableVertexAttribArray(index uint32) {
 // CullFace specifies whether front- or back-facing facets can be culled.
 func (gs *GLS) CullFace(mode uint32) {
 
+	if gs.cullFace == mode {
+		return
+	}
 	gs.gl.Call("cullFace", int(mode))
 	gs.checkError("CullFace")
+	gs.cullFace = mode
 }
 
 // FrontFace defines front- and back-facing polygons.
@@ -544,13 +833,20 @@ func (gs *GLS) GetAttribLocation(program uint32, name string) int32 {
 	return int32(loc)
 }
 
+// GetIntegerv returns the value of the specified integer parameter.
+func (gs *GLS) GetIntegerv(pname uint32, params *int32) {
+
+	*params = int32(gs.gl.Call("getParameter", int(pname)).Int())
+	gs.checkError("GetIntegerv")
+}
+
 // GetProgramiv returns the specified parameter from the specified program object.
 func (gs *GLS) GetProgramiv(program, pname uint32, params *int32) {
 
 	sparam := gs.gl.Call("getProgramParameter", gs.programMap[program], int(pname))
 	gs.checkError("GetProgramiv")
 	switch pname {
-	case DELETE_STATUS, LINK_STATUS, VALIDATE_STATUS:
+	case DELETE_STATUS, LINK_STATUS, VALIDATE_STATUS, COMPLETION_STATUS:
 		if sparam.Bool() {
 			*params = TRUE
 		} else {
@@ -663,6 +959,30 @@ func (gs *GLS) TexImage2D(target uint32, level int32, iformat int32, width int32
 	dataTA.Release()
 }
 
+// TexImage3D specifies a three-dimensional or array texture image.
+func (gs *GLS) TexImage3D(target uint32, level int32, iformat int32, width, height, depth int32, format uint32, itype uint32, data interface{}) {
+
+	if data == nil {
+		gs.gl.Call("texImage3D", int(target), level, iformat, width, height, depth, 0, int(format), int(itype), js.Null())
+		gs.checkError("TexImage3D")
+		return
+	}
+	dataTA := js.TypedArrayOf(data)
+	gs.gl.Call("texImage3D", int(target), level, iformat, width, height, depth, 0, int(format), int(itype), dataTA)
+	gs.checkError("TexImage3D")
+	dataTA.Release()
+}
+
+// TexSubImage3D specifies a subregion of a three-dimensional or array texture image,
+// such as one layer of a texture array.
+func (gs *GLS) TexSubImage3D(target uint32, level int32, xoffset, yoffset, zoffset, width, height, depth int32, format uint32, itype uint32, data interface{}) {
+
+	dataTA := js.TypedArrayOf(data)
+	gs.gl.Call("texSubImage3D", int(target), level, xoffset, yoffset, zoffset, width, height, depth, int(format), int(itype), dataTA)
+	gs.checkError("TexSubImage3D")
+	dataTA.Release()
+}
+
 // TexParameteri sets the specified texture parameter on the specified texture.
 func (gs *GLS) TexParameteri(target uint32, pname uint32, param int32) {
 
@@ -794,6 +1114,14 @@ func (gs *GLS) Uniform4fv(location int32, count int32, v *float32) {
 	gs.stats.Unisets++
 }
 
+// VertexAttribDivisor sets the number of instances drawn with each element of the specified
+// generic vertex attribute. Zero advances the attribute per vertex instead of per instance.
+func (gs *GLS) VertexAttribDivisor(index uint32, divisor uint32) {
+
+	gs.gl.Call("vertexAttribDivisor", index, divisor)
+	gs.checkError("VertexAttribDivisor")
+}
+
 // VertexAttribPointer defines an array of generic vertex attribute data.
 func (gs *GLS) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uint32) {
 
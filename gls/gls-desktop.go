@@ -24,6 +24,12 @@ type GLS struct {
 	prog        *Program          // current active shader program
 	programs    map[*Program]bool // shader programs cache
 	checkErrors bool              // check openGL API errors flag
+	bind        bindCache         // cached object bindings
+	linear      bool              // linear color pipeline enabled
+
+	bufferStorage   bool // immutable buffer storage is supported
+	parallelCompile bool // shader compile completion can be queried
+	multiDrawIndir  bool // indirect multi-draw is supported
 
 	// Cache OpenGL state to avoid making unnecessary API calls
 	activeTexture  uint32  // cached last set active texture unit
@@ -34,14 +40,12 @@ type GLS struct {
 	lineWidth      float32 // cached last set line width
 	sideView       int     // cached last set triangle side view mode
 	frontFace      uint32  // cached last set glFrontFace value
+	cullFace       uint32  // cached last set glCullFace value
 	depthFunc      uint32  // cached last set depth function
 	depthMask      int     // cached last set depth mask
 	//stencilFunc
 	stencilMask         uint32      // cached last set stencil mask
 	capabilities        map[int]int // cached capabilities (Enable/Disable)
-	blendEquation       uint32      // cached last set blend equation value
-	blendSrc            uint32      // cached last set blend src value
-	blendDst            uint32      // cached last set blend equation destination value
 	blendEquationRGB    uint32      // cached last set blend equation rgb value
 	blendEquationAlpha  uint32      // cached last set blend equation alpha value
 	blendSrcRGB         uint32      // cached last set blend src rgb
@@ -52,6 +56,9 @@ type GLS struct {
 	polygonModeMode     uint32      // cached last set polygon mode mode
 	polygonOffsetFactor float32     // cached last set polygon offset factor
 	polygonOffsetUnits  float32     // cached last set polygon offset units
+	colorMask           int         // cached last set color mask bits
+	blendColor          [4]float32  // cached last set blend color
+	blendColorSet       bool        // blend color was set
 	gobuf               []byte      // conversion buffer with GO memory
 	cbuf                []byte      // conversion buffer with C memory
 }
@@ -72,6 +79,10 @@ func New() (*GLS, error) {
 	}
 	gs.setDefaultState()
 	gs.checkErrors = true
+	gs.bufferStorage = gs.hasBufferStorage()
+	gs.parallelCompile = C.glapiHasMaxShaderCompilerThreadsARB() != 0 &&
+		(gs.hasExtension("GL_ARB_parallel_shader_compile") || gs.hasExtension("GL_KHR_parallel_shader_compile"))
+	gs.multiDrawIndir = gs.hasMultiDrawIndirect()
 
 	// Preallocate conversion buffers
 	size := 1 * 1024
@@ -107,16 +118,17 @@ func (gs *GLS) reset() {
 	gs.lineWidth = 0.0
 	gs.sideView = uintUndef
 	gs.frontFace = 0
+	gs.cullFace = 0
 	gs.depthFunc = 0
 	gs.depthMask = uintUndef
+	gs.colorMask = uintUndef
+	gs.blendColorSet = false
 	gs.capabilities = make(map[int]int)
 	gs.programs = make(map[*Program]bool)
 	gs.prog = nil
+	gs.bind.reset()
 
 	gs.activeTexture = uintUndef
-	gs.blendEquation = uintUndef
-	gs.blendSrc = uintUndef
-	gs.blendDst = uintUndef
 	gs.blendEquationRGB = 0
 	gs.blendEquationAlpha = 0
 	gs.blendSrcRGB = uintUndef
@@ -183,29 +195,42 @@ func (gs *GLS) AttachShader(program, shader uint32) {
 // BindBuffer binds a buffer object to the specified buffer binding point.
 func (gs *GLS) BindBuffer(target int, vbo uint32) {
 
+	if gs.bind.buffer(target, vbo) {
+		gs.stats.Bindhits++
+		return
+	}
 	C.glBindBuffer(C.GLenum(target), C.GLuint(vbo))
 }
 
 // BindTexture lets you create or use a named texture.
 func (gs *GLS) BindTexture(target int, tex uint32) {
 
+	if gs.bind.texture(gs.activeTexture, target, tex) {
+		gs.stats.Bindhits++
+		return
+	}
 	C.glBindTexture(C.GLenum(target), C.GLuint(tex))
 }
 
 // BindVertexArray binds the vertex array object.
 func (gs *GLS) BindVertexArray(vao uint32) {
 
+	if gs.bind.vertexArray(vao) {
+		gs.stats.Bindhits++
+		return
+	}
 	C.glBindVertexArray(C.GLuint(vao))
 }
 
 // BlendEquation sets the blend equations for all draw buffers.
 func (gs *GLS) BlendEquation(mode uint32) {
 
-	if gs.blendEquation == mode {
+	if gs.blendEquationRGB == mode && gs.blendEquationAlpha == mode {
 		return
 	}
 	C.glBlendEquation(C.GLenum(mode))
-	gs.blendEquation = mode
+	gs.blendEquationRGB = mode
+	gs.blendEquationAlpha = mode
 }
 
 // BlendEquationSeparate sets the blend equations for all draw buffers
@@ -224,12 +249,15 @@ func (gs *GLS) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
 // all draw buffers when blending is enabled.
 func (gs *GLS) BlendFunc(sfactor, dfactor uint32) {
 
-	if gs.blendSrc == sfactor && gs.blendDst == dfactor {
+	if gs.blendSrcRGB == sfactor && gs.blendDstRGB == dfactor &&
+		gs.blendSrcAlpha == sfactor && gs.blendDstAlpha == dfactor {
 		return
 	}
 	C.glBlendFunc(C.GLenum(sfactor), C.GLenum(dfactor))
-	gs.blendSrc = sfactor
-	gs.blendDst = dfactor
+	gs.blendSrcRGB = sfactor
+	gs.blendDstRGB = dfactor
+	gs.blendSrcAlpha = sfactor
+	gs.blendDstAlpha = dfactor
 }
 
 // BlendFuncSeparate defines the operation of blending for all draw buffers when blending
@@ -247,6 +275,18 @@ func (gs *GLS) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32,
 	gs.blendDstAlpha = dstAlpha
 }
 
+// BlendColor sets the constant color used by the CONSTANT_COLOR and CONSTANT_ALPHA blend factors.
+func (gs *GLS) BlendColor(r, g, b, a float32) {
+
+	c := [4]float32{r, g, b, a}
+	if gs.blendColorSet && gs.blendColor == c {
+		return
+	}
+	C.glBlendColor(C.GLfloat(r), C.GLfloat(g), C.GLfloat(b), C.GLfloat(a))
+	gs.blendColor = c
+	gs.blendColorSet = true
+}
+
 // BufferData creates a new data store for the buffer object currently
 // bound to target, deleting any pre-existing data store.
 func (gs *GLS) BufferData(target uint32, size int, data interface{}, usage uint32) {
@@ -254,6 +294,90 @@ func (gs *GLS) BufferData(target uint32, size int, data interface{}, usage uint3
 	C.glBufferData(C.GLenum(target), C.GLsizeiptr(size), ptr(data), C.GLenum(usage))
 }
 
+// BufferSubData updates a subset of the data store of the buffer object
+// currently bound to target, starting at the specified byte offset.
+func (gs *GLS) BufferSubData(target uint32, offset, size int, data interface{}) {
+
+	C.glBufferSubData(C.GLenum(target), C.GLintptr(offset), C.GLsizeiptr(size), ptr(data))
+}
+
+// BufferStorage creates an immutable data store for the buffer object currently
+// bound to target. It requires OpenGL 4.4 or the ARB_buffer_storage extension
+// (see BufferStorageSupported).
+func (gs *GLS) BufferStorage(target uint32, size int, data interface{}, flags uint32) {
+
+	C.glBufferStorage(C.GLenum(target), C.GLsizeiptr(size), ptr(data), C.GLbitfield(flags))
+}
+
+// BufferStorageSupported returns if immutable buffer storage, required
+// for persistently mapped buffers, is supported by the current context.
+func (gs *GLS) BufferStorageSupported() bool {
+
+	return gs.bufferStorage
+}
+
+// ParallelCompileSupported returns if shaders can be compiled in parallel by the driver
+// and the completion of their compilation can be queried with the COMPLETION_STATUS
+// parameter without blocking. It requires the ARB or KHR parallel_shader_compile extension.
+func (gs *GLS) ParallelCompileSupported() bool {
+
+	return gs.parallelCompile
+}
+
+// MaxShaderCompilerThreads sets the number of threads used by the driver to compile shaders.
+// Zero disables parallel compilation and 0xFFFFFFFF, the default, lets the driver choose.
+// It has no effect if parallel compilation is not supported (see ParallelCompileSupported).
+func (gs *GLS) MaxShaderCompilerThreads(count uint32) {
+
+	if gs.parallelCompile {
+		C.glMaxShaderCompilerThreadsARB(C.GLuint(count))
+	}
+}
+
+// MapBufferRange maps the specified range of the data store of the buffer object
+// currently bound to target and returns the mapped memory.
+// The returned slice must not be used after the buffer is unmapped, unless it was mapped
+// persistently, and it must not be used after the buffer is deleted.
+func (gs *GLS) MapBufferRange(target uint32, offset, length int, access uint32) []byte {
+
+	p := C.glMapBufferRange(C.GLenum(target), C.GLintptr(offset), C.GLsizeiptr(length), C.GLbitfield(access))
+	if p == nil {
+		return nil
+	}
+	return (*[1 << 30]byte)(p)[:length:length]
+}
+
+// UnmapBuffer releases the mapping of the data store of the buffer object currently bound to target.
+// It returns false if the data store contents became corrupt while mapped.
+func (gs *GLS) UnmapBuffer(target uint32) bool {
+
+	return C.glUnmapBuffer(C.GLenum(target)) == C.GL_TRUE
+}
+
+// Sync is an OpenGL sync object.
+type Sync C.GLsync
+
+// FenceSync creates and inserts a fence sync object in the command stream
+// which is signaled when all the previous commands are completed.
+func (gs *GLS) FenceSync() Sync {
+
+	return Sync(C.glFenceSync(C.GL_SYNC_GPU_COMMANDS_COMPLETE, 0))
+}
+
+// ClientWaitSync waits for the specified sync object to be signaled or for the
+// timeout in nanoseconds to expire and returns the wait status:
+// ALREADY_SIGNALED, CONDITION_SATISFIED, TIMEOUT_EXPIRED or WAIT_FAILED.
+func (gs *GLS) ClientWaitSync(sync Sync, flags uint32, timeout uint64) uint32 {
+
+	return uint32(C.glClientWaitSync(C.GLsync(sync), C.GLbitfield(flags), C.GLuint64(timeout)))
+}
+
+// DeleteSync deletes the specified sync object.
+func (gs *GLS) DeleteSync(sync Sync) {
+
+	C.glDeleteSync(C.GLsync(sync))
+}
+
 // ClearColor specifies the red, green, blue, and alpha values
 // used by glClear to clear the color buffers.
 func (gs *GLS) ClearColor(r, g, b, a float32) {
@@ -307,6 +431,7 @@ func (gs *GLS) CreateShader(stype uint32) uint32 {
 // by the elements of the provided array.
 func (gs *GLS) DeleteBuffers(bufs ...uint32) {
 
+	gs.bind.deleteBuffers(bufs)
 	C.glDeleteBuffers(C.GLsizei(len(bufs)), (*C.GLuint)(&bufs[0]))
 	gs.stats.Buffers -= len(bufs)
 }
@@ -329,6 +454,7 @@ func (gs *GLS) DeleteProgram(program uint32) {
 // by the elements of the provided array.
 func (gs *GLS) DeleteTextures(tex ...uint32) {
 
+	gs.bind.deleteTextures(tex)
 	C.glDeleteTextures(C.GLsizei(len(tex)), (*C.GLuint)(&tex[0]))
 	gs.stats.Textures -= len(tex)
 }
@@ -337,10 +463,86 @@ func (gs *GLS) DeleteTextures(tex ...uint32) {
 // by the elements of the provided array.
 func (gs *GLS) DeleteVertexArrays(vaos ...uint32) {
 
+	gs.bind.deleteVertexArrays(vaos)
 	C.glDeleteVertexArrays(C.GLsizei(len(vaos)), (*C.GLuint)(&vaos[0]))
 	gs.stats.Vaos -= len(vaos)
 }
 
+// GenFramebuffer generates a framebuffer object name.
+func (gs *GLS) GenFramebuffer() uint32 {
+
+	var fb uint32
+	C.glGenFramebuffers(1, (*C.GLuint)(&fb))
+	gs.stats.Framebuffers++
+	return fb
+}
+
+// BindFramebuffer binds the specified framebuffer to the specified target.
+// Binding the framebuffer 0 restores the default (window) framebuffer.
+func (gs *GLS) BindFramebuffer(target uint32, fb uint32) {
+
+	if gs.bind.framebuffer(target, fb) {
+		gs.stats.Bindhits++
+		return
+	}
+	C.glBindFramebuffer(C.GLenum(target), C.GLuint(fb))
+}
+
+// DeleteFramebuffers deletes the specified framebuffer objects.
+func (gs *GLS) DeleteFramebuffers(fbs ...uint32) {
+
+	gs.bind.deleteFramebuffers(fbs)
+	C.glDeleteFramebuffers(C.GLsizei(len(fbs)), (*C.GLuint)(&fbs[0]))
+	gs.stats.Framebuffers -= len(fbs)
+}
+
+// FramebufferTexture2D attaches a level of a texture object to the currently bound framebuffer.
+func (gs *GLS) FramebufferTexture2D(target, attachment, textarget uint32, tex uint32, level int32) {
+
+	C.glFramebufferTexture2D(C.GLenum(target), C.GLenum(attachment), C.GLenum(textarget), C.GLuint(tex), C.GLint(level))
+}
+
+// FramebufferRenderbuffer attaches a renderbuffer object to the currently bound framebuffer.
+func (gs *GLS) FramebufferRenderbuffer(target, attachment, rbtarget uint32, rb uint32) {
+
+	C.glFramebufferRenderbuffer(C.GLenum(target), C.GLenum(attachment), C.GLenum(rbtarget), C.GLuint(rb))
+}
+
+// CheckFramebufferStatus returns the completeness status of the framebuffer bound to the specified target.
+func (gs *GLS) CheckFramebufferStatus(target uint32) uint32 {
+
+	return uint32(C.glCheckFramebufferStatus(C.GLenum(target)))
+}
+
+// GenRenderbuffer generates a renderbuffer object name.
+func (gs *GLS) GenRenderbuffer() uint32 {
+
+	var rb uint32
+	C.glGenRenderbuffers(1, (*C.GLuint)(&rb))
+	gs.stats.Renderbuffers++
+	return rb
+}
+
+// BindRenderbuffer binds the specified renderbuffer to the specified target.
+func (gs *GLS) BindRenderbuffer(target uint32, rb uint32) {
+
+	C.glBindRenderbuffer(C.GLenum(target), C.GLuint(rb))
+}
+
+// RenderbufferStorage establishes the data storage format and dimensions
+// of the renderbuffer bound to the specified target.
+func (gs *GLS) RenderbufferStorage(target, iformat uint32, width, height int32) {
+
+	C.glRenderbufferStorage(C.GLenum(target), C.GLenum(iformat), C.GLsizei(width), C.GLsizei(height))
+}
+
+// DeleteRenderbuffers deletes the specified renderbuffer objects.
+func (gs *GLS) DeleteRenderbuffers(rbs ...uint32) {
+
+	C.glDeleteRenderbuffers(C.GLsizei(len(rbs)), (*C.GLuint)(&rbs[0]))
+	gs.stats.Renderbuffers -= len(rbs)
+}
+
 // ReadPixels returns the current rendered image.
 // x, y: specifies the window coordinates of the first pixel that is read from the frame buffer.
 // width, height: specifies the dimensions of the pixel rectangle.
@@ -381,6 +583,22 @@ func (gs *GLS) DepthMask(flag bool) {
 	}
 }
 
+// ColorMask enables or disables writing each of the color components into the color buffers.
+func (gs *GLS) ColorMask(r, g, b, a bool) {
+
+	mask := 0
+	for i, flag := range [4]bool{r, g, b, a} {
+		if flag {
+			mask |= 1 << uint(i)
+		}
+	}
+	if gs.colorMask == mask {
+		return
+	}
+	C.glColorMask(bool2c(r), bool2c(g), bool2c(b), bool2c(a))
+	gs.colorMask = mask
+}
+
 func (gs *GLS) StencilOp(fail, zfail, zpass uint32) {
 
 	// TODO save state
@@ -418,6 +636,74 @@ func (gs *GLS) DrawElements(mode uint32, count int32, itype uint32, start uint32
 	gs.stats.Drawcalls++
 }
 
+// DrawArraysInstanced renders instancecount instances of primitives from array data.
+func (gs *GLS) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+
+	C.glDrawArraysInstanced(C.GLenum(mode), C.GLint(first), C.GLsizei(count), C.GLsizei(instancecount))
+	gs.stats.Drawcalls++
+}
+
+// DrawElementsInstanced renders instancecount instances of primitives from array data.
+func (gs *GLS) DrawElementsInstanced(mode uint32, count int32, itype uint32, start uint32, instancecount int32) {
+
+	C.glDrawElementsInstanced(C.GLenum(mode), C.GLsizei(count), C.GLenum(itype), unsafe.Pointer(uintptr(start)), C.GLsizei(instancecount))
+	gs.stats.Drawcalls++
+}
+
+// PrimitiveRestart enables or disables restarting strip, fan and loop primitives at
+// the maximum index value (0xFFFFFFFF for UNSIGNED_INT indices), as WebGL 2 always does.
+func (gs *GLS) PrimitiveRestart(enable bool) {
+
+	if !enable {
+		gs.Disable(PRIMITIVE_RESTART)
+		return
+	}
+	if gs.capabilities[PRIMITIVE_RESTART] != capEnabled {
+		C.glPrimitiveRestartIndex(C.GLuint(0xFFFFFFFF))
+	}
+	gs.Enable(PRIMITIVE_RESTART)
+}
+
+// MultiDrawElements renders multiple ranges of indexed primitives with a single call.
+// The count and byte offset in the element array buffer of each range are specified
+// by counts and offsets, which must have the same length.
+func (gs *GLS) MultiDrawElements(mode uint32, counts []int32, itype uint32, offsets []uintptr) {
+
+	if len(counts) == 0 {
+		return
+	}
+	C.glMultiDrawElements(C.GLenum(mode), (*C.GLsizei)(unsafe.Pointer(&counts[0])), C.GLenum(itype),
+		(*unsafe.Pointer)(unsafe.Pointer(&offsets[0])), C.GLsizei(len(counts)))
+	gs.stats.Drawcalls++
+}
+
+// DrawElementsIndirect renders indexed primitives using the DrawElementsIndirectCommand
+// stored at the specified byte offset of the buffer bound to DRAW_INDIRECT_BUFFER.
+// It requires OpenGL 4.0 (see MultiDrawIndirectSupported).
+func (gs *GLS) DrawElementsIndirect(mode uint32, itype uint32, offset int) {
+
+	C.glDrawElementsIndirect(C.GLenum(mode), C.GLenum(itype), unsafe.Pointer(uintptr(offset)))
+	gs.stats.Drawcalls++
+}
+
+// MultiDrawElementsIndirect renders indexed primitives using drawcount DrawElementsIndirectCommand
+// structures stored from the specified byte offset of the buffer bound to DRAW_INDIRECT_BUFFER,
+// separated by stride bytes (zero for tightly packed commands).
+// It requires OpenGL 4.3 or the ARB_multi_draw_indirect extension (see MultiDrawIndirectSupported).
+func (gs *GLS) MultiDrawElementsIndirect(mode uint32, itype uint32, offset int, drawcount, stride int32) {
+
+	C.glMultiDrawElementsIndirect(C.GLenum(mode), C.GLenum(itype), unsafe.Pointer(uintptr(offset)),
+		C.GLsizei(drawcount), C.GLsizei(stride))
+	gs.stats.Drawcalls++
+}
+
+// MultiDrawIndirectSupported returns if indirect draws and indirect multi-draws
+// from buffers bound to DRAW_INDIRECT_BUFFER are supported by the current context.
+func (gs *GLS) MultiDrawIndirectSupported() bool {
+
+	return gs.multiDrawIndir
+}
+
 // Enable enables the specified capability.
 func (gs *GLS) Enable(cap int) {
 
@@ -449,7 +735,11 @@ func (gs *GLS) EnableVertexAttribArray(index uint32) {
 // CullFace specifies whether front- or back-facing facets can be culled.
 func (gs *GLS) CullFace(mode uint32) {
 
+	if gs.cullFace == mode {
+		return
+	}
 	C.glCullFace(C.GLenum(mode))
+	gs.cullFace = mode
 }
 
 // FrontFace defines front- and back-facing polygons.
@@ -502,6 +792,12 @@ func (gs *GLS) GetAttribLocation(program uint32, name string) int32 {
 	return int32(loc)
 }
 
+// GetIntegerv returns the value of the specified integer parameter.
+func (gs *GLS) GetIntegerv(pname uint32, params *int32) {
+
+	C.glGetIntegerv(C.GLenum(pname), (*C.GLint)(params))
+}
+
 // GetProgramiv returns the specified parameter from the specified program object.
 func (gs *GLS) GetProgramiv(program, pname uint32, params *int32) {
 
@@ -601,6 +897,38 @@ func (gs *GLS) TexImage2D(target uint32, level int32, iformat int32, width int32
 		ptr(data))
 }
 
+// TexImage3D specifies a three-dimensional or array texture image.
+func (gs *GLS) TexImage3D(target uint32, level int32, iformat int32, width, height, depth int32, format uint32, itype uint32, data interface{}) {
+
+	C.glTexImage3D(C.GLenum(target),
+		C.GLint(level),
+		C.GLint(iformat),
+		C.GLsizei(width),
+		C.GLsizei(height),
+		C.GLsizei(depth),
+		C.GLint(0),
+		C.GLenum(format),
+		C.GLenum(itype),
+		ptr(data))
+}
+
+// TexSubImage3D specifies a subregion of a three-dimensional or array texture image,
+// such as one layer of a texture array.
+func (gs *GLS) TexSubImage3D(target uint32, level int32, xoffset, yoffset, zoffset, width, height, depth int32, format uint32, itype uint32, data interface{}) {
+
+	C.glTexSubImage3D(C.GLenum(target),
+		C.GLint(level),
+		C.GLint(xoffset),
+		C.GLint(yoffset),
+		C.GLint(zoffset),
+		C.GLsizei(width),
+		C.GLsizei(height),
+		C.GLsizei(depth),
+		C.GLenum(format),
+		C.GLenum(itype),
+		ptr(data))
+}
+
 // TexParameteri sets the specified texture parameter on the specified texture.
 func (gs *GLS) TexParameteri(target uint32, pname uint32, param int32) {
 
@@ -706,6 +1034,13 @@ func (gs *GLS) Uniform4fv(location int32, count int32, v *float32) {
 	gs.stats.Unisets++
 }
 
+// VertexAttribDivisor sets the number of instances drawn with each element of the specified
+// generic vertex attribute. Zero advances the attribute per vertex instead of per instance.
+func (gs *GLS) VertexAttribDivisor(index uint32, divisor uint32) {
+
+	C.glVertexAttribDivisor(C.GLuint(index), C.GLuint(divisor))
+}
+
 // VertexAttribPointer defines an array of generic vertex attribute data.
 func (gs *GLS) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uint32) {
 
@@ -738,6 +1073,52 @@ func (gs *GLS) UseProgram(prog *Program) {
 	}
 }
 
+// hasBufferStorage checks if the glBufferStorage function was loaded and the
+// context version is 4.4 or later or it supports the ARB_buffer_storage extension.
+func (gs *GLS) hasBufferStorage() bool {
+
+	if C.glapiHasBufferStorage() == 0 {
+		return false
+	}
+	var major, minor int32
+	gs.GetIntegerv(MAJOR_VERSION, &major)
+	gs.GetIntegerv(MINOR_VERSION, &minor)
+	if major > 4 || (major == 4 && minor >= 4) {
+		return true
+	}
+	return gs.hasExtension("GL_ARB_buffer_storage")
+}
+
+// hasMultiDrawIndirect checks if the indirect draw functions were loaded and the
+// context version is 4.3 or later or it supports the ARB_multi_draw_indirect extension.
+func (gs *GLS) hasMultiDrawIndirect() bool {
+
+	if C.glapiHasDrawElementsIndirect() == 0 || C.glapiHasMultiDrawElementsIndirect() == 0 {
+		return false
+	}
+	var major, minor int32
+	gs.GetIntegerv(MAJOR_VERSION, &major)
+	gs.GetIntegerv(MINOR_VERSION, &minor)
+	if major > 4 || (major == 4 && minor >= 3) {
+		return true
+	}
+	return gs.hasExtension("GL_ARB_multi_draw_indirect")
+}
+
+// hasExtension returns if the current context supports the specified extension.
+func (gs *GLS) hasExtension(name string) bool {
+
+	var count int32
+	gs.GetIntegerv(NUM_EXTENSIONS, &count)
+	for i := int32(0); i < count; i++ {
+		cs := C.glGetStringi(C.GL_EXTENSIONS, C.GLuint(i))
+		if C.GoString((*C.char)(unsafe.Pointer(cs))) == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Ptr takes a slice or pointer (to a singular scalar value or the first
 // element of an array or slice) and returns its GL-compatible address.
 //
@@ -24,15 +24,20 @@ var log = logger.New("GLS", logger.Default)
 // Stats contains counters of WebGL resources being used as well
 // the cumulative numbers of some WebGL calls for performance evaluation.
 type Stats struct {
-	Shaders    int    // Current number of shader programs
-	Vaos       int    // Number of Vertex Array Objects
-	Buffers    int    // Number of Buffer Objects
-	Textures   int    // Number of Textures
-	Caphits    uint64 // Cumulative number of hits for Enable/Disable
-	UnilocHits uint64 // Cumulative number of uniform location cache hits
-	UnilocMiss uint64 // Cumulative number of uniform location cache misses
-	Unisets    uint64 // Cumulative number of uniform sets
-	Drawcalls  uint64 // Cumulative number of draw calls
+	Shaders       int    // Current number of shader programs
+	Vaos          int    // Number of Vertex Array Objects
+	Buffers       int    // Number of Buffer Objects
+	Textures      int    // Number of Textures
+	Framebuffers  int    // Number of Framebuffer Objects
+	Renderbuffers int    // Number of Renderbuffer Objects
+	Caphits       uint64 // Cumulative number of hits for Enable/Disable
+	Bindhits      uint64 // Cumulative number of redundant object bindings filtered
+	UnilocHits    uint64 // Cumulative number of uniform location cache hits
+	UnilocMiss    uint64 // Cumulative number of uniform location cache misses
+	Unisets       uint64 // Cumulative number of uniform sets
+	Drawcalls     uint64 // Cumulative number of draw calls
+	StreamBytes   uint64 // Cumulative number of bytes written to stream buffers
+	StreamWaits   uint64 // Cumulative number of waits for the GPU to release a stream buffer region
 }
 
 const (
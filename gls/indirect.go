@@ -0,0 +1,47 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"encoding/binary"
+)
+
+// Indirect draw constants from OpenGL 4.0 (ARB_draw_indirect),
+// which are not part of the OpenGL 3.3 constants.
+const (
+	DRAW_INDIRECT_BUFFER         = 0x8F3F
+	DRAW_INDIRECT_BUFFER_BINDING = 0x8F43
+)
+
+// DrawElementsIndirectCommandSize is the size in bytes of a DrawElementsIndirectCommand.
+const DrawElementsIndirectCommandSize = 20
+
+// DrawElementsIndirectCommand is the command read from the buffer bound to DRAW_INDIRECT_BUFFER
+// by DrawElementsIndirect and MultiDrawElementsIndirect for each draw.
+// The commands can be written by the application or by shaders doing GPU visibility culling.
+type DrawElementsIndirectCommand struct {
+	Count         uint32 // Number of indices
+	InstanceCount uint32 // Number of instances (zero skips the draw)
+	FirstIndex    uint32 // Index of the first index in the element array buffer
+	BaseVertex    int32  // Value added to the indices
+	BaseInstance  uint32 // Base instance for instanced vertex attributes
+}
+
+// AppendDrawCommands appends the specified commands, encoded as tightly packed
+// structures, to the specified bytes and returns the result.
+func AppendDrawCommands(b []byte, cmds []DrawElementsIndirectCommand) []byte {
+
+	var buf [DrawElementsIndirectCommandSize]byte
+	for i := range cmds {
+		cmd := &cmds[i]
+		binary.LittleEndian.PutUint32(buf[0:], cmd.Count)
+		binary.LittleEndian.PutUint32(buf[4:], cmd.InstanceCount)
+		binary.LittleEndian.PutUint32(buf[8:], cmd.FirstIndex)
+		binary.LittleEndian.PutUint32(buf[12:], uint32(cmd.BaseVertex))
+		binary.LittleEndian.PutUint32(buf[16:], cmd.BaseInstance)
+		b = append(b, buf[:]...)
+	}
+	return b
+}
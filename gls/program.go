@@ -13,6 +13,12 @@ import (
 	"strings"
 )
 
+// Parameters of the parallel_shader_compile extensions
+const (
+	MAX_SHADER_COMPILER_THREADS = 0x91B0
+	COMPLETION_STATUS           = 0x91B1
+)
+
 // Program represents an OpenGL program.
 // It must have Vertex and Fragment shaders.
 // It can also have a Geometry shader.
@@ -22,6 +28,7 @@ type Program struct {
 	handle     uint32           // OpenGL program handle
 	shaders    []shaderInfo     // List of shaders for this program
 	uniforms   map[string]int32 // List of uniforms
+	building   bool             // Build started and not finished
 }
 
 // shaderInfo contains OpenGL-related shader information.
@@ -71,10 +78,10 @@ func (prog *Program) AddShader(stype uint32, source string) {
 // DeleteShaders deletes all of this program's shaders from OpenGL.
 func (prog *Program) DeleteShaders() {
 
-	for _, shaderInfo := range prog.shaders {
-		if shaderInfo.handle != 0 {
-			prog.gs.DeleteShader(shaderInfo.handle)
-			shaderInfo.handle = 0
+	for i := range prog.shaders {
+		if prog.shaders[i].handle != 0 {
+			prog.gs.DeleteShader(prog.shaders[i].handle)
+			prog.shaders[i].handle = 0
 		}
 	}
 }
@@ -82,6 +89,18 @@ func (prog *Program) DeleteShaders() {
 // Build builds the program, compiling and linking the previously supplied shaders.
 func (prog *Program) Build() error {
 
+	err := prog.Start()
+	if err != nil {
+		return err
+	}
+	return prog.Finish()
+}
+
+// Start starts building the program, compiling and linking the previously supplied shaders
+// without waiting for the results, so the driver can build several programs in parallel.
+// Ready returns if the build completed, and Finish must be called before using the program.
+func (prog *Program) Start() error {
+
 	// Check if program already built
 	if prog.handle != 0 {
 		return fmt.Errorf("program already built")
@@ -98,27 +117,73 @@ func (prog *Program) Build() error {
 		return fmt.Errorf("error creating program")
 	}
 
+	// Compile and attach shaders
+	for i := range prog.shaders {
+		sinfo := &prog.shaders[i]
+		sinfo.handle = prog.gs.CreateShader(sinfo.stype)
+		if sinfo.handle == 0 {
+			prog.DeleteShaders()
+			prog.gs.DeleteProgram(prog.handle)
+			prog.handle = 0
+			return fmt.Errorf("error creating shader")
+		}
+		prog.gs.ShaderSource(sinfo.handle, sinfo.source)
+		prog.gs.CompileShader(sinfo.handle)
+		prog.gs.AttachShader(prog.handle, sinfo.handle)
+	}
+
+	// Link program
+	prog.gs.LinkProgram(prog.handle)
+	prog.building = true
+	return nil
+}
+
+// Ready returns if the build started by Start completed, so Finish does not block.
+// If the driver does not support parallel shader compilation (see GLS.ParallelCompileSupported)
+// it always returns true and Finish may block.
+func (prog *Program) Ready() bool {
+
+	if !prog.building || !prog.gs.ParallelCompileSupported() {
+		return true
+	}
+	var status int32
+	prog.gs.GetProgramiv(prog.handle, COMPLETION_STATUS, &status)
+	return status != FALSE
+}
+
+// Finish waits for the completion of the build started by Start and checks its results.
+func (prog *Program) Finish() error {
+
+	if !prog.building {
+		return nil
+	}
+	prog.building = false
+
 	// Clean unused GL allocated resources
 	defer prog.DeleteShaders()
 
-	// Compile and attach shaders
+	// Check the compilation of the shaders
 	for _, sinfo := range prog.shaders {
-		shader, err := prog.CompileShader(sinfo.stype, sinfo.source)
-		if err != nil {
+		slog := prog.gs.GetShaderInfoLog(sinfo.handle)
+		var status int32
+		prog.gs.GetShaderiv(sinfo.handle, COMPILE_STATUS, &status)
+		if status == FALSE {
 			prog.gs.DeleteProgram(prog.handle)
 			prog.handle = 0
-			msg := fmt.Sprintf("error compiling %s: %s", shaderNames[sinfo.stype], err)
+			msg := fmt.Sprintf("error compiling %s: %s", shaderNames[sinfo.stype], slog)
 			if prog.ShowSource {
 				msg += FormatSource(sinfo.source)
 			}
 			return errors.New(msg)
 		}
-		sinfo.handle = shader
-		prog.gs.AttachShader(prog.handle, shader)
+		// If the shader compiled OK but the log has data,
+		// log this data instead of returning error
+		if len(slog) > 2 {
+			log.Warn("%s", slog)
+		}
 	}
 
-	// Link program and check for errors
-	prog.gs.LinkProgram(prog.handle)
+	// Check for link errors
 	var status int32
 	prog.gs.GetProgramiv(prog.handle, LINK_STATUS, &status)
 	if status == FALSE {
@@ -0,0 +1,39 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+// ValidBlendEquation returns if the specified value is a valid blend equation.
+func ValidBlendEquation(mode uint32) bool {
+
+	switch mode {
+	case FUNC_ADD, FUNC_SUBTRACT, FUNC_REVERSE_SUBTRACT, MIN, MAX:
+		return true
+	}
+	return false
+}
+
+// ValidBlendFactor returns if the specified value is a valid blend source or destination factor.
+// SRC_ALPHA_SATURATE is only valid as a source factor.
+func ValidBlendFactor(factor uint32) bool {
+
+	switch factor {
+	case ZERO, ONE, SRC_COLOR, ONE_MINUS_SRC_COLOR, DST_COLOR, ONE_MINUS_DST_COLOR,
+		SRC_ALPHA, ONE_MINUS_SRC_ALPHA, DST_ALPHA, ONE_MINUS_DST_ALPHA,
+		CONSTANT_COLOR, ONE_MINUS_CONSTANT_COLOR, CONSTANT_ALPHA, ONE_MINUS_CONSTANT_ALPHA,
+		SRC_ALPHA_SATURATE:
+		return true
+	}
+	return false
+}
+
+// ValidCompareFunc returns if the specified value is a valid depth or stencil compare function.
+func ValidCompareFunc(fn uint32) bool {
+
+	switch fn {
+	case NEVER, LESS, EQUAL, LEQUAL, GREATER, NOTEQUAL, GEQUAL, ALWAYS:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"unsafe"
+)
+
+// Buffer storage flags from OpenGL 4.4 (ARB_buffer_storage),
+// which are not part of the OpenGL 3.3 constants.
+const (
+	MAP_PERSISTENT_BIT  = 0x0040
+	MAP_COHERENT_BIT    = 0x0080
+	DYNAMIC_STORAGE_BIT = 0x0100
+	CLIENT_STORAGE_BIT  = 0x0200
+)
+
+// Default number of frames a stream buffer can have in flight.
+const StreamBufferFrames = 3
+
+// Default alignment in bytes of the data written to a stream buffer.
+const streamBufferAlign = 16
+
+// StreamBuffer is an OpenGL buffer object for data which is rewritten every frame,
+// such as dynamic geometry, particles or debug lines.
+// The buffer is divided into regions, one for each frame in flight, used as a ring,
+// so new data never overwrites data which may still be read by the GPU.
+// When immutable buffer storage is supported (OpenGL 4.4) the whole buffer is mapped
+// persistently and written directly, and fences are used to wait for the GPU before
+// a region is reused. Otherwise the data is uploaded with BufferSubData and the
+// buffer data store is orphaned each time the ring wraps around, so the driver
+// never has to stall while the previous data store is in use.
+//
+// The data of a frame is written with the Write methods, which return the byte offset
+// of the data in the buffer, followed by the draw calls using it. EndFrame must be
+// called after the draw calls using the data of a frame were issued.
+type StreamBuffer struct {
+	gs         *GLS   // Reference to OpenGL state
+	target     uint32 // Binding target used by the draw calls (ARRAY_BUFFER, ELEMENT_ARRAY_BUFFER, ...)
+	handle     uint32 // OpenGL handle of the buffer
+	size       int    // Size in bytes of each region
+	regions    int    // Number of regions (frames in flight)
+	region     int    // Index of the region being written
+	offset     int    // Write offset in the current region
+	align      int    // Alignment of written data
+	persistent bool   // Buffer is persistently mapped
+	mapped     []byte // Persistently mapped memory
+	fences     []Sync // Fences of the regions in use by the GPU
+}
+
+// NewStreamBuffer creates and returns a pointer to a new stream buffer for the specified
+// binding target, with regions of the specified size in bytes for the specified number
+// of frames in flight. The regions grow as needed.
+func NewStreamBuffer(gs *GLS, target uint32, size, frames int) *StreamBuffer {
+
+	if frames < 1 {
+		frames = StreamBufferFrames
+	}
+	sb := new(StreamBuffer)
+	sb.gs = gs
+	sb.target = target
+	sb.regions = frames
+	sb.align = streamBufferAlign
+	sb.fences = make([]Sync, frames)
+	sb.alloc(size)
+	return sb
+}
+
+// Handle returns the OpenGL handle of the buffer.
+// It changes when the buffer grows, so it must be bound after the data is written.
+func (sb *StreamBuffer) Handle() uint32 {
+
+	return sb.handle
+}
+
+// Target returns the binding target of the buffer.
+func (sb *StreamBuffer) Target() uint32 {
+
+	return sb.target
+}
+
+// Persistent returns if the buffer is persistently mapped.
+func (sb *StreamBuffer) Persistent() bool {
+
+	return sb.persistent
+}
+
+// Size returns the size in bytes of the data which can be written in a frame without growing the buffer.
+func (sb *StreamBuffer) Size() int {
+
+	return sb.size
+}
+
+// SetAlignment sets the alignment in bytes of the offsets returned by the Write methods.
+// The default is 16 bytes, enough for vertex data. Uniform buffers usually require 256 bytes.
+func (sb *StreamBuffer) SetAlignment(align int) {
+
+	if align > 0 {
+		sb.align = align
+	}
+}
+
+// Write writes the specified bytes in the region of the current frame and
+// returns their byte offset in the buffer.
+func (sb *StreamBuffer) Write(data []byte) int {
+
+	if len(data) == 0 {
+		return sb.base() + sb.offset
+	}
+	return sb.write(unsafe.Pointer(&data[0]), len(data), data)
+}
+
+// WriteFloat32 writes the specified float32 values in the region of the current frame
+// and returns their byte offset in the buffer.
+func (sb *StreamBuffer) WriteFloat32(data []float32) int {
+
+	if len(data) == 0 {
+		return sb.base() + sb.offset
+	}
+	return sb.write(unsafe.Pointer(&data[0]), len(data)*4, data)
+}
+
+// WriteUint32 writes the specified uint32 values in the region of the current frame
+// and returns their byte offset in the buffer.
+func (sb *StreamBuffer) WriteUint32(data []uint32) int {
+
+	if len(data) == 0 {
+		return sb.base() + sb.offset
+	}
+	return sb.write(unsafe.Pointer(&data[0]), len(data)*4, data)
+}
+
+// EndFrame marks the end of the data of the current frame and advances to the next region.
+// It must be called after the draw calls using the data were issued. If the GPU may still
+// be reading the next region, it waits for it (persistent mode) or orphans the data store
+// when the ring wraps around.
+func (sb *StreamBuffer) EndFrame() {
+
+	if sb.persistent {
+		if sb.fences[sb.region] != nil {
+			sb.gs.DeleteSync(sb.fences[sb.region])
+		}
+		sb.fences[sb.region] = sb.gs.FenceSync()
+	}
+	sb.region = (sb.region + 1) % sb.regions
+	sb.offset = 0
+	if sb.persistent {
+		sb.wait(sb.region)
+		return
+	}
+	if sb.region == 0 {
+		sb.gs.BindBuffer(COPY_WRITE_BUFFER, sb.handle)
+		sb.gs.BufferData(COPY_WRITE_BUFFER, sb.size*sb.regions, nil, STREAM_DRAW)
+		sb.gs.BindBuffer(COPY_WRITE_BUFFER, 0)
+	}
+}
+
+// Dispose releases the OpenGL resources of the buffer.
+func (sb *StreamBuffer) Dispose() {
+
+	sb.release()
+	sb.gs = nil
+}
+
+// base returns the byte offset of the current region.
+func (sb *StreamBuffer) base() int {
+
+	return sb.region * sb.size
+}
+
+// write copies size bytes from the specified pointer (or uploads the specified Go data)
+// to the current region, growing the buffer if necessary, and returns their byte offset.
+func (sb *StreamBuffer) write(p unsafe.Pointer, size int, data interface{}) int {
+
+	offset := (sb.offset + sb.align - 1) / sb.align * sb.align
+	if offset+size > sb.size {
+		// The data written earlier in this frame was already used by issued draw calls,
+		// so the buffer can be replaced by a larger one.
+		grow := 2 * sb.size
+		if grow < size {
+			grow = size
+		}
+		sb.release()
+		sb.alloc(grow)
+		offset = 0
+	}
+	pos := sb.base() + offset
+	if sb.persistent {
+		copy(sb.mapped[pos:pos+size], (*[1 << 30]byte)(p)[:size:size])
+	} else {
+		sb.gs.BindBuffer(COPY_WRITE_BUFFER, sb.handle)
+		sb.gs.BufferSubData(COPY_WRITE_BUFFER, pos, size, data)
+		sb.gs.BindBuffer(COPY_WRITE_BUFFER, 0)
+	}
+	sb.offset = offset + size
+	sb.gs.stats.StreamBytes += uint64(size)
+	return pos
+}
+
+// wait waits until the GPU finished reading the specified region.
+func (sb *StreamBuffer) wait(region int) {
+
+	fence := sb.fences[region]
+	if fence == nil {
+		return
+	}
+	status := sb.gs.ClientWaitSync(fence, 0, 0)
+	if status != ALREADY_SIGNALED && status != CONDITION_SATISFIED {
+		sb.gs.stats.StreamWaits++
+		for status == TIMEOUT_EXPIRED {
+			status = sb.gs.ClientWaitSync(fence, SYNC_FLUSH_COMMANDS_BIT, 1000000)
+		}
+	}
+	sb.gs.DeleteSync(fence)
+	sb.fences[region] = nil
+}
+
+// alloc creates the buffer with regions of the specified size.
+func (sb *StreamBuffer) alloc(size int) {
+
+	if size < sb.align {
+		size = sb.align
+	}
+	size = (size + sb.align - 1) / sb.align * sb.align
+	sb.size = size
+	sb.region = 0
+	sb.offset = 0
+	sb.handle = sb.gs.GenBuffer()
+	sb.gs.BindBuffer(COPY_WRITE_BUFFER, sb.handle)
+	total := size * sb.regions
+	sb.persistent = false
+	if sb.gs.BufferStorageSupported() {
+		flags := uint32(MAP_WRITE_BIT | MAP_PERSISTENT_BIT | MAP_COHERENT_BIT)
+		sb.gs.BufferStorage(COPY_WRITE_BUFFER, total, nil, flags)
+		sb.mapped = sb.gs.MapBufferRange(COPY_WRITE_BUFFER, 0, total, flags)
+		sb.persistent = sb.mapped != nil
+	}
+	if !sb.persistent {
+		sb.gs.BufferData(COPY_WRITE_BUFFER, total, nil, STREAM_DRAW)
+	}
+	sb.gs.BindBuffer(COPY_WRITE_BUFFER, 0)
+}
+
+// release deletes the buffer and its pending fences.
+func (sb *StreamBuffer) release() {
+
+	if sb.gs == nil || sb.handle == 0 {
+		return
+	}
+	for i, fence := range sb.fences {
+		if fence != nil {
+			sb.gs.DeleteSync(fence)
+			sb.fences[i] = nil
+		}
+	}
+	if sb.persistent {
+		sb.gs.BindBuffer(COPY_WRITE_BUFFER, sb.handle)
+		sb.gs.UnmapBuffer(COPY_WRITE_BUFFER)
+		sb.gs.BindBuffer(COPY_WRITE_BUFFER, 0)
+		sb.mapped = nil
+	}
+	sb.gs.DeleteBuffers(sb.handle)
+	sb.handle = 0
+}
@@ -0,0 +1,159 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/math32"
+)
+
+// Uniforms is a set of named custom uniforms with their values, transferred to the
+// current shader program by Transfer. The zero value is an empty set ready to use.
+//
+// The values can be of the types bool, int, int32, float32, []float32, math32.Vector2,
+// math32.Vector3, math32.Vector4, math32.Color, math32.Color4, math32.Matrix3 and
+// math32.Matrix4, or pointers to the math32 types, whose current values are transferred.
+type Uniforms struct {
+	entries []uniformEntry // Uniforms in the order they were set
+}
+
+// uniformEntry is a uniform of a set with its value.
+type uniformEntry struct {
+	uni   Uniform
+	value interface{}
+}
+
+// Set sets the value of the specified uniform, adding it if needed.
+// It panics if the type of the value is not supported.
+func (us *Uniforms) Set(name string, value interface{}) {
+
+	switch value.(type) {
+	case bool, int, int32, float32, []float32,
+		math32.Vector2, *math32.Vector2, math32.Vector3, *math32.Vector3, math32.Vector4, *math32.Vector4,
+		math32.Color, *math32.Color, math32.Color4, *math32.Color4,
+		math32.Matrix3, *math32.Matrix3, math32.Matrix4, *math32.Matrix4:
+	default:
+		panic(fmt.Sprintf("gls: uniform %q of unsupported type %T", name, value))
+	}
+	if i := us.index(name); i >= 0 {
+		us.entries[i].value = value
+		return
+	}
+	us.entries = append(us.entries, uniformEntry{value: value})
+	us.entries[len(us.entries)-1].uni.Init(name)
+}
+
+// Get returns the value of the specified uniform or nil if not set.
+func (us *Uniforms) Get(name string) interface{} {
+
+	if i := us.index(name); i >= 0 {
+		return us.entries[i].value
+	}
+	return nil
+}
+
+// Unset removes the specified uniform.
+func (us *Uniforms) Unset(name string) {
+
+	if i := us.index(name); i >= 0 {
+		us.entries = append(us.entries[:i], us.entries[i+1:]...)
+	}
+}
+
+// Len returns the number of uniforms of the set.
+func (us *Uniforms) Len() int {
+
+	return len(us.entries)
+}
+
+// Names returns the names of the uniforms in the order they were set.
+func (us *Uniforms) Names() []string {
+
+	names := make([]string, len(us.entries))
+	for i := range us.entries {
+		names[i] = us.entries[i].uni.name
+	}
+	return names
+}
+
+// Clone returns a copy of the set. Pointer values are shared.
+func (us *Uniforms) Clone() Uniforms {
+
+	var clone Uniforms
+	for _, e := range us.entries {
+		clone.Set(e.uni.name, e.value)
+	}
+	return clone
+}
+
+// Transfer transfers the values of the uniforms used by the current shader program.
+func (us *Uniforms) Transfer(gs *GLS) {
+
+	for i := range us.entries {
+		e := &us.entries[i]
+		location := e.uni.Location(gs)
+		if location < 0 {
+			continue
+		}
+		switch v := e.value.(type) {
+		case bool:
+			var b int32
+			if v {
+				b = 1
+			}
+			gs.Uniform1i(location, b)
+		case int:
+			gs.Uniform1i(location, int32(v))
+		case int32:
+			gs.Uniform1i(location, v)
+		case float32:
+			gs.Uniform1f(location, v)
+		case []float32:
+			if len(v) > 0 {
+				gs.Uniform1fv(location, int32(len(v)), &v[0])
+			}
+		case math32.Vector2:
+			gs.Uniform2f(location, v.X, v.Y)
+		case *math32.Vector2:
+			gs.Uniform2f(location, v.X, v.Y)
+		case math32.Vector3:
+			gs.Uniform3f(location, v.X, v.Y, v.Z)
+		case *math32.Vector3:
+			gs.Uniform3f(location, v.X, v.Y, v.Z)
+		case math32.Vector4:
+			gs.Uniform4f(location, v.X, v.Y, v.Z, v.W)
+		case *math32.Vector4:
+			gs.Uniform4f(location, v.X, v.Y, v.Z, v.W)
+		case math32.Color:
+			gs.Uniform3f(location, v.R, v.G, v.B)
+		case *math32.Color:
+			gs.Uniform3f(location, v.R, v.G, v.B)
+		case math32.Color4:
+			gs.Uniform4f(location, v.R, v.G, v.B, v.A)
+		case *math32.Color4:
+			gs.Uniform4f(location, v.R, v.G, v.B, v.A)
+		case math32.Matrix3:
+			gs.UniformMatrix3fv(location, 1, false, &v[0])
+		case *math32.Matrix3:
+			gs.UniformMatrix3fv(location, 1, false, &v[0])
+		case math32.Matrix4:
+			gs.UniformMatrix4fv(location, 1, false, &v[0])
+		case *math32.Matrix4:
+			gs.UniformMatrix4fv(location, 1, false, &v[0])
+		}
+	}
+}
+
+// index returns the index of the specified uniform or -1.
+func (us *Uniforms) index(name string) int {
+
+	for i := range us.entries {
+		if us.entries[i].uni.name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -15,7 +15,10 @@ type VBO struct {
 	usage   uint32          // Expected usage pattern of the buffer
 	update  bool            // Update flag
 	buffer  math32.ArrayF32 // Data buffer
+	data    []byte          // Raw data buffer used instead of buffer for packed formats
 	attribs []VBOattrib     // List of attributes
+	stream  *StreamBuffer   // Stream buffer used for STREAM_DRAW usage
+	divisor uint32          // Number of instances per item, zero for per vertex attributes
 }
 
 // VBOattrib describes one attribute of an OpenGL Vertex Buffer Object.
@@ -25,6 +28,8 @@ type VBOattrib struct {
 	ByteOffset  uint32     // Byte offset from the start of the VBO
 	NumElements int32      // Number of elements
 	ElementType uint32     // Type of the element (e.g. FLOAT, INT, UNSIGNED_SHORT, etc...)
+	Normalized  bool       // Fixed-point elements are normalized when accessed by shaders
+	aligned     bool       // Whether the size is aligned to 4 bytes, for attributes added with a format
 }
 
 // AttribType is the functional type of a vbo attribute.
@@ -75,6 +80,7 @@ var elementTypeSizeMap = map[uint32]int{
 	INT:            4,
 	UNSIGNED_INT:   4,
 	FLOAT:          4,
+	HALF_FLOAT:     2,
 }
 
 // NewVBO creates and returns a pointer to a new OpenGL Vertex Buffer Object.
@@ -86,6 +92,17 @@ func NewVBO(buffer math32.ArrayF32) *VBO {
 	return vbo
 }
 
+// NewVBOBytes creates and returns a pointer to a new OpenGL Vertex Buffer Object
+// storing the specified raw data, which can contain attributes in any format
+// (see AddAttribFormat and Interleave).
+func NewVBOBytes(data []byte) *VBO {
+
+	vbo := new(VBO)
+	vbo.init()
+	vbo.SetBytes(data)
+	return vbo
+}
+
 // init initializes the VBO.
 func (vbo *VBO) init() {
 
@@ -149,6 +166,49 @@ func (vbo *VBO) AddCustomAttribOffset(name string, itemSize int32, byteOffset ui
 	return vbo
 }
 
+// AddAttribFormat adds a new attribute to the VBO with the specified type, stored in the
+// specified format. The attribute's ByteOffset is computed automatically based on the existing
+// attributes. Attributes in formats other than FormatFloat require a VBO with raw data.
+func (vbo *VBO) AddAttribFormat(atype AttribType, format AttribFormat) *VBO {
+
+	return vbo.addAttrib(atype, attribTypeNameMap[atype], attribTypeSizeMap[atype], format)
+}
+
+// AddCustomAttribFormat adds a new attribute to the VBO with the specified name and itemSize,
+// stored in the specified format. The attribute's ByteOffset is computed automatically based on
+// the existing attributes. Attributes in formats other than FormatFloat require a VBO with raw data.
+func (vbo *VBO) AddCustomAttribFormat(name string, itemSize int32, format AttribFormat) *VBO {
+
+	return vbo.addAttrib(Undefined, name, itemSize, format)
+}
+
+// AddTypedAttribFormat adds a new attribute to the VBO with the specified type, name and itemSize,
+// stored in the specified format, such as an attribute described by a file of preprocessed vertices.
+// The attribute's ByteOffset is computed automatically based on the existing attributes.
+func (vbo *VBO) AddTypedAttribFormat(atype AttribType, name string, itemSize int32, format AttribFormat) *VBO {
+
+	return vbo.addAttrib(atype, name, itemSize, format)
+}
+
+// addAttrib adds a new attribute stored in the specified format after the existing attributes.
+// Packed attributes always have 4 elements. The size of the attribute is aligned to 4 bytes.
+func (vbo *VBO) addAttrib(atype AttribType, name string, itemSize int32, format AttribFormat) *VBO {
+
+	if format == FormatSnorm2101010 {
+		itemSize = 4
+	}
+	vbo.attribs = append(vbo.attribs, VBOattrib{
+		Type:        atype,
+		Name:        name,
+		ByteOffset:  uint32(vbo.StrideSize()),
+		NumElements: itemSize,
+		ElementType: format.ElementType(),
+		Normalized:  format.Normalized(),
+		aligned:     true,
+	})
+	return vbo
+}
+
 // Attrib finds and returns a pointer to the VBO attribute with the specified type.
 // Returns nil if not found.
 func (vbo *VBO) Attrib(atype AttribType) *VBOattrib {
@@ -196,7 +256,10 @@ func (vbo *VBO) Attributes() []VBOattrib {
 // it is not referenced counted.
 func (vbo *VBO) Dispose() {
 
-	if vbo.gs != nil {
+	if vbo.stream != nil {
+		vbo.stream.Dispose()
+		vbo.stream = nil
+	} else if vbo.gs != nil {
 		vbo.gs.DeleteBuffers(vbo.handle)
 	}
 	vbo.gs = nil
@@ -206,17 +269,78 @@ func (vbo *VBO) Dispose() {
 func (vbo *VBO) SetBuffer(buffer math32.ArrayF32) *VBO {
 
 	vbo.buffer = buffer
+	vbo.data = nil
+	vbo.update = true
+	return vbo
+}
+
+// SetBytes sets the raw data of the VBO, used instead of a float buffer
+// when the attributes are stored in other formats.
+func (vbo *VBO) SetBytes(data []byte) *VBO {
+
+	vbo.data = data
+	vbo.buffer = nil
 	vbo.update = true
 	return vbo
 }
 
+// Bytes returns the raw data of the VBO or nil if it uses a float buffer.
+func (vbo *VBO) Bytes() []byte {
+
+	return vbo.data
+}
+
+// ByteSize returns the size in bytes of the VBO data.
+func (vbo *VBO) ByteSize() int {
+
+	if vbo.data != nil {
+		return len(vbo.data)
+	}
+	return vbo.buffer.Bytes()
+}
+
+// Release releases the VBO buffer if its data was already transferred to OpenGL,
+// keeping the OpenGL buffer. It has no effect on VBOs with STREAM_DRAW usage.
+func (vbo *VBO) Release() {
+
+	if vbo.gs == nil || vbo.update || vbo.usage == STREAM_DRAW {
+		return
+	}
+	vbo.buffer = nil
+	vbo.data = nil
+}
+
 // SetUsage sets the expected usage pattern of the buffer.
 // The default value is GL_STATIC_DRAW.
+// The data of VBOs with GL_STREAM_DRAW usage, which are updated every frame,
+// is written to a StreamBuffer instead of reallocating the buffer at each update.
+// The usage must be set before the VBO is transferred for the first time.
 func (vbo *VBO) SetUsage(usage uint32) {
 
 	vbo.usage = usage
 }
 
+// SetDivisor sets the number of instances drawn with each item of the VBO, making its
+// attributes per instance attributes of instanced draws (see graphic.InstancedMesh).
+// The default value 0 makes them per vertex attributes.
+// The divisor must be set before the VBO is transferred for the first time.
+func (vbo *VBO) SetDivisor(divisor uint32) {
+
+	vbo.divisor = divisor
+}
+
+// Divisor returns the number of instances drawn with each item of the VBO.
+func (vbo *VBO) Divisor() uint32 {
+
+	return vbo.divisor
+}
+
+// Usage returns the expected usage pattern of the buffer.
+func (vbo *VBO) Usage() uint32 {
+
+	return vbo.usage
+}
+
 // Buffer returns a pointer to the VBO buffer.
 func (vbo *VBO) Buffer() *math32.ArrayF32 {
 
@@ -278,17 +402,38 @@ func (vbo *VBO) Stride() int {
 func (vbo *VBO) StrideSize() int {
 
 	strideSize := 0
-	for _, attrib := range vbo.attribs {
-		strideSize += int(attrib.NumElements) * elementTypeSizeMap[attrib.ElementType]
+	for i := range vbo.attribs {
+		strideSize += vbo.attribs[i].ByteSize()
 	}
 	return strideSize
 }
 
+// ByteSize returns the number of bytes used by the attribute in each item.
+// The sizes of the attributes added with a format, such as by AddAttribFormat and Interleave,
+// are aligned to 4 bytes, while the other attributes keep their exact sizes.
+func (a *VBOattrib) ByteSize() int {
+
+	if a.ElementType == INT_2_10_10_10_REV || a.ElementType == UNSIGNED_INT_2_10_10_10_REV {
+		return 4
+	}
+	size := int(a.NumElements) * elementTypeSizeMap[a.ElementType]
+	if a.aligned {
+		size = (size + 3) &^ 3
+	}
+	return size
+}
+
 // Transfer (called internally) transfers the data from the VBO buffer to OpenGL if necessary.
 func (vbo *VBO) Transfer(gs *GLS) {
 
 	// If the VBO buffer is empty, ignore
-	if vbo.buffer.Bytes() == 0 {
+	if vbo.ByteSize() == 0 {
+		return
+	}
+
+	// Streamed data is written to a new region of the stream buffer at each update
+	if vbo.usage == STREAM_DRAW {
+		vbo.transferStream(gs)
 		return
 	}
 
@@ -308,7 +453,10 @@ func (vbo *VBO) Transfer(gs *GLS) {
 			}
 			// Enables attribute and sets its stride and offset in the buffer
 			gs.EnableVertexAttribArray(uint32(loc))
-			gs.VertexAttribPointer(uint32(loc), attrib.NumElements, attrib.ElementType, false, int32(strideSize), attrib.ByteOffset)
+			gs.VertexAttribPointer(uint32(loc), attrib.NumElements, attrib.ElementType, attrib.Normalized, int32(strideSize), attrib.ByteOffset)
+			if vbo.divisor != 0 {
+				gs.VertexAttribDivisor(uint32(loc), vbo.divisor)
+			}
 		}
 		vbo.gs = gs // this indicates that the vbo was initialized
 	}
@@ -320,7 +468,52 @@ func (vbo *VBO) Transfer(gs *GLS) {
 
 	// Transfer the VBO data to OpenGL
 	gs.BindBuffer(ARRAY_BUFFER, vbo.handle)
-	gs.BufferData(ARRAY_BUFFER, vbo.buffer.Bytes(), vbo.buffer.ToFloat32(), vbo.usage)
+	if vbo.data != nil {
+		gs.BufferData(ARRAY_BUFFER, len(vbo.data), vbo.data, vbo.usage)
+	} else {
+		gs.BufferData(ARRAY_BUFFER, vbo.buffer.Bytes(), vbo.buffer.ToFloat32(), vbo.usage)
+	}
+	vbo.update = false
+}
+
+// transferStream writes the VBO data to its stream buffer if necessary
+// and points the attributes to the written data.
+func (vbo *VBO) transferStream(gs *GLS) {
+
+	if vbo.gs != nil && !vbo.update {
+		return
+	}
+	if vbo.stream == nil {
+		vbo.stream = NewStreamBuffer(gs, ARRAY_BUFFER, vbo.ByteSize(), StreamBufferFrames)
+	} else {
+		// The draw calls using the previous data were already issued
+		vbo.stream.EndFrame()
+	}
+	var offset int
+	if vbo.data != nil {
+		offset = vbo.stream.Write(vbo.data)
+	} else {
+		offset = vbo.stream.WriteFloat32(vbo.buffer.ToFloat32())
+	}
+
+	// The attributes must point to the written region of the buffer
+	gs.BindBuffer(ARRAY_BUFFER, vbo.stream.Handle())
+	strideSize := vbo.StrideSize()
+	for _, attrib := range vbo.attribs {
+		loc := gs.prog.GetAttribLocation(attrib.Name)
+		if loc < 0 {
+			if vbo.gs == nil {
+				log.Warn("Attribute not found: %v", attrib.Name)
+			}
+			continue
+		}
+		gs.EnableVertexAttribArray(uint32(loc))
+		gs.VertexAttribPointer(uint32(loc), attrib.NumElements, attrib.ElementType, attrib.Normalized, int32(strideSize), uint32(offset)+attrib.ByteOffset)
+		if vbo.divisor != 0 {
+			gs.VertexAttribDivisor(uint32(loc), vbo.divisor)
+		}
+	}
+	vbo.gs = gs
 	vbo.update = false
 }
 
@@ -330,6 +523,26 @@ func (vbo *VBO) Transfer(gs *GLS) {
 // The callback function returns false to continue or true to break.
 func (vbo *VBO) OperateOnVectors3(attribType AttribType, cb func(vec *math32.Vector3) bool) {
 
+	// Raw data is decoded and encoded according to the attribute format
+	if vbo.data != nil {
+		attrib := vbo.Attrib(attribType)
+		if attrib == nil {
+			return
+		}
+		var values [4]float32
+		for item := 0; item < vbo.Items(); item++ {
+			vbo.ReadAttrib(attrib, item, values[:])
+			vec := math32.Vector3{X: values[0], Y: values[1], Z: values[2]}
+			brk := cb(&vec)
+			values[0], values[1], values[2] = vec.X, vec.Y, vec.Z
+			vbo.WriteAttrib(attrib, item, values[:])
+			if brk {
+				break
+			}
+		}
+		return
+	}
+
 	stride := vbo.Stride()
 	offset := vbo.AttribOffset(attribType)
 	buffer := vbo.Buffer()
@@ -352,6 +565,22 @@ func (vbo *VBO) OperateOnVectors3(attribType AttribType, cb func(vec *math32.Vec
 // The callback function returns false to continue or true to break.
 func (vbo *VBO) ReadVectors3(attribType AttribType, cb func(vec math32.Vector3) bool) {
 
+	// Raw data is decoded according to the attribute format
+	if vbo.data != nil {
+		attrib := vbo.Attrib(attribType)
+		if attrib == nil {
+			return
+		}
+		var values [4]float32
+		for item := 0; item < vbo.Items(); item++ {
+			vbo.ReadAttrib(attrib, item, values[:])
+			if cb(math32.Vector3{X: values[0], Y: values[1], Z: values[2]}) {
+				break
+			}
+		}
+		return
+	}
+
 	stride := vbo.Stride()
 	offset := vbo.AttribOffset(attribType)
 	positions := vbo.Buffer()
@@ -372,6 +601,26 @@ func (vbo *VBO) ReadVectors3(attribType AttribType, cb func(vec math32.Vector3)
 // The callback function returns false to continue or true to break.
 func (vbo *VBO) ReadTripleVectors3(attribType AttribType, cb func(vec1, vec2, vec3 math32.Vector3) bool) {
 
+	// Raw data is decoded according to the attribute format
+	if vbo.data != nil {
+		attrib := vbo.Attrib(attribType)
+		if attrib == nil {
+			return
+		}
+		var values [4]float32
+		var vecs [3]math32.Vector3
+		for item := 0; item+2 < vbo.Items(); item += 3 {
+			for i := range vecs {
+				vbo.ReadAttrib(attrib, item+i, values[:])
+				vecs[i].Set(values[0], values[1], values[2])
+			}
+			if cb(vecs[0], vecs[1], vecs[2]) {
+				break
+			}
+		}
+		return
+	}
+
 	stride := vbo.Stride()
 	offset := vbo.AttribOffset(attribType)
 	positions := vbo.Buffer()
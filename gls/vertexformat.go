@@ -0,0 +1,326 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// AttribFormat is the storage format of the elements of a vertex attribute.
+type AttribFormat int
+
+const (
+	FormatFloat        = AttribFormat(iota) // 32 bit floats
+	FormatHalf                              // 16 bit floats
+	FormatSnorm16                           // 16 bit signed integers normalized to [-1, 1]
+	FormatUnorm16                           // 16 bit unsigned integers normalized to [0, 1]
+	FormatUnorm8                            // 8 bit unsigned integers normalized to [0, 1]
+	FormatSnorm2101010                      // 10 bit x, y, z and 2 bit w signed normalized values packed in 32 bits
+)
+
+// VertexLayout maps attribute types to the formats used to store them in an interleaved VBO.
+// Attributes not in the layout are stored as 32 bit floats.
+type VertexLayout map[AttribType]AttribFormat
+
+// CompactLayout is a vertex layout which stores normals and tangents as packed 10 bit values,
+// texture coordinates as half floats and colors and skin weights as normalized bytes, using
+// 24 bytes per vertex with positions, normals, texture coordinates and tangents instead of 44.
+// Texture coordinates outside the half float precision, such as large repeats, should use floats.
+var CompactLayout = VertexLayout{
+	VertexNormal:    FormatSnorm2101010,
+	VertexTangent:   FormatSnorm2101010,
+	VertexColor:     FormatUnorm8,
+	VertexTexcoord:  FormatHalf,
+	VertexTexcoord2: FormatHalf,
+	SkinWeight:      FormatUnorm8,
+}
+
+// ElementType returns the OpenGL type of the elements stored in this format.
+func (f AttribFormat) ElementType() uint32 {
+
+	switch f {
+	case FormatHalf:
+		return HALF_FLOAT
+	case FormatSnorm16:
+		return SHORT
+	case FormatUnorm16:
+		return UNSIGNED_SHORT
+	case FormatUnorm8:
+		return UNSIGNED_BYTE
+	case FormatSnorm2101010:
+		return INT_2_10_10_10_REV
+	default:
+		return FLOAT
+	}
+}
+
+// Normalized returns if the fixed-point elements stored in this format are normalized.
+func (f AttribFormat) Normalized() bool {
+
+	return f != FormatFloat && f != FormatHalf
+}
+
+// Interleave returns a new VBO storing the attributes of the specified VBOs interleaved
+// in a single raw buffer, converted to the formats of the specified layout.
+// All the VBOs must have the same number of items. Each attribute is aligned to 4 bytes
+// and attributes in the packed 10 bit format have 4 elements, the last one being zero.
+func Interleave(vbos []*VBO, layout VertexLayout) *VBO {
+
+	dst := NewVBOBytes(nil)
+	items := -1
+	maxElements := int32(4)
+	for _, src := range vbos {
+		if count := src.Items(); items < 0 || count < items {
+			items = count
+		}
+		for _, attrib := range src.attribs {
+			format := FormatFloat
+			if attrib.Type != Undefined {
+				format = layout[attrib.Type]
+			}
+			dst.addAttrib(attrib.Type, attrib.Name, attrib.NumElements, format)
+			if attrib.NumElements > maxElements {
+				maxElements = attrib.NumElements
+			}
+		}
+	}
+	if items <= 0 {
+		return dst
+	}
+
+	// Converts the attributes of each item
+	dst.data = make([]byte, items*dst.StrideSize())
+	values := make([]float32, maxElements)
+	for item := 0; item < items; item++ {
+		idx := 0
+		for _, src := range vbos {
+			for i := range src.attribs {
+				values[3] = 0
+				src.ReadAttrib(&src.attribs[i], item, values)
+				dst.WriteAttrib(&dst.attribs[idx], item, values)
+				idx++
+			}
+		}
+	}
+	dst.update = true
+	return dst
+}
+
+// Items returns the number of items (complete groups of attributes) in the VBO buffer.
+func (vbo *VBO) Items() int {
+
+	stride := vbo.StrideSize()
+	if stride == 0 {
+		return 0
+	}
+	return vbo.ByteSize() / stride
+}
+
+// ReadAttrib decodes the elements of the specified attribute of the specified item,
+// whatever their format, into values, which must have at least NumElements entries.
+func (vbo *VBO) ReadAttrib(attrib *VBOattrib, item int, values []float32) {
+
+	offset := item*vbo.StrideSize() + int(attrib.ByteOffset)
+	if vbo.data == nil {
+		copy(values[:attrib.NumElements], vbo.buffer[offset/4:])
+		return
+	}
+	b := vbo.data[offset:]
+	n := int(attrib.NumElements)
+	switch attrib.ElementType {
+	case FLOAT:
+		for i := 0; i < n; i++ {
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+		}
+	case HALF_FLOAT:
+		for i := 0; i < n; i++ {
+			values[i] = Float32FromHalf(binary.LittleEndian.Uint16(b[2*i:]))
+		}
+	case SHORT:
+		for i := 0; i < n; i++ {
+			values[i] = float32(int16(binary.LittleEndian.Uint16(b[2*i:])))
+			if attrib.Normalized {
+				values[i] = float32(math.Max(float64(values[i])/math.MaxInt16, -1))
+			}
+		}
+	case UNSIGNED_SHORT:
+		for i := 0; i < n; i++ {
+			values[i] = float32(binary.LittleEndian.Uint16(b[2*i:]))
+			if attrib.Normalized {
+				values[i] /= math.MaxUint16
+			}
+		}
+	case BYTE:
+		for i := 0; i < n; i++ {
+			values[i] = float32(int8(b[i]))
+			if attrib.Normalized {
+				values[i] = float32(math.Max(float64(values[i])/math.MaxInt8, -1))
+			}
+		}
+	case UNSIGNED_BYTE:
+		for i := 0; i < n; i++ {
+			values[i] = float32(b[i])
+			if attrib.Normalized {
+				values[i] /= math.MaxUint8
+			}
+		}
+	case INT_2_10_10_10_REV:
+		UnpackSnorm2101010(binary.LittleEndian.Uint32(b), values)
+	}
+}
+
+// WriteAttrib encodes the specified values, which must have at least NumElements entries,
+// into the elements of the specified attribute of the specified item, in the attribute format.
+func (vbo *VBO) WriteAttrib(attrib *VBOattrib, item int, values []float32) {
+
+	offset := item*vbo.StrideSize() + int(attrib.ByteOffset)
+	vbo.update = true
+	if vbo.data == nil {
+		copy(vbo.buffer[offset/4:], values[:attrib.NumElements])
+		return
+	}
+	b := vbo.data[offset:]
+	n := int(attrib.NumElements)
+	switch attrib.ElementType {
+	case FLOAT:
+		for i := 0; i < n; i++ {
+			binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(values[i]))
+		}
+	case HALF_FLOAT:
+		for i := 0; i < n; i++ {
+			binary.LittleEndian.PutUint16(b[2*i:], HalfFromFloat32(values[i]))
+		}
+	case SHORT:
+		for i := 0; i < n; i++ {
+			v := float64(values[i])
+			if attrib.Normalized {
+				v = math.Round(clamp(v, -1, 1) * math.MaxInt16)
+			}
+			binary.LittleEndian.PutUint16(b[2*i:], uint16(int16(v)))
+		}
+	case UNSIGNED_SHORT:
+		for i := 0; i < n; i++ {
+			v := float64(values[i])
+			if attrib.Normalized {
+				v = math.Round(clamp(v, 0, 1) * math.MaxUint16)
+			}
+			binary.LittleEndian.PutUint16(b[2*i:], uint16(v))
+		}
+	case BYTE:
+		for i := 0; i < n; i++ {
+			v := float64(values[i])
+			if attrib.Normalized {
+				v = math.Round(clamp(v, -1, 1) * math.MaxInt8)
+			}
+			b[i] = byte(int8(v))
+		}
+	case UNSIGNED_BYTE:
+		for i := 0; i < n; i++ {
+			v := float64(values[i])
+			if attrib.Normalized {
+				v = math.Round(clamp(v, 0, 1) * math.MaxUint8)
+			}
+			b[i] = byte(v)
+		}
+	case INT_2_10_10_10_REV:
+		binary.LittleEndian.PutUint32(b, PackSnorm2101010(values))
+	}
+}
+
+// HalfFromFloat32 converts a 32 bit float to a 16 bit float, rounding to the nearest value.
+func HalfFromFloat32(f float32) uint16 {
+
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23&0xff) - 127 + 15
+	mant := bits & 0x7fffff
+
+	switch {
+	// Infinity and NaN
+	case exp == 0xff-127+15:
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	// Overflow to infinity
+	case exp >= 0x1f:
+		return sign | 0x7c00
+	// Subnormal half floats or zero
+	case exp <= 0:
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - exp)
+		half := uint16(mant >> shift)
+		if mant>>(shift-1)&1 != 0 {
+			half++
+		}
+		return sign | half
+	}
+	half := sign | uint16(exp)<<10 | uint16(mant>>13)
+	// Rounding may carry into the exponent, which is still correct
+	if mant&0x1000 != 0 {
+		half++
+	}
+	return half
+}
+
+// Float32FromHalf converts a 16 bit float to a 32 bit float.
+func Float32FromHalf(h uint16) float32 {
+
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Subnormal half float
+		v := float32(mant) / (1 << 24)
+		if sign != 0 {
+			return -v
+		}
+		return v
+	}
+	return math.Float32frombits(sign | (exp-15+127)<<23 | mant<<13)
+}
+
+// PackSnorm2101010 packs the first four values, clamped to [-1, 1],
+// into 10 bit x, y, z and 2 bit w signed normalized values.
+func PackSnorm2101010(values []float32) uint32 {
+
+	pack := func(v float32, max float64, bits uint32) uint32 {
+		i := int32(math.Round(clamp(float64(v), -1, 1) * max))
+		return uint32(i) & (1<<bits - 1)
+	}
+	return pack(values[0], 511, 10) | pack(values[1], 511, 10)<<10 |
+		pack(values[2], 511, 10)<<20 | pack(values[3], 1, 2)<<30
+}
+
+// UnpackSnorm2101010 unpacks 10 bit x, y, z and 2 bit w signed normalized values
+// into the first four values.
+func UnpackSnorm2101010(packed uint32, values []float32) {
+
+	unpack := func(shift, bits uint32, max float64) float32 {
+		// Sign extends the field
+		i := int32(packed<<(32-shift-bits)) >> (32 - bits)
+		return float32(math.Max(float64(i)/max, -1))
+	}
+	values[0] = unpack(0, 10, 511)
+	values[1] = unpack(10, 10, 511)
+	values[2] = unpack(20, 10, 511)
+	values[3] = unpack(30, 2, 1)
+}
+
+// clamp returns the value clamped to the specified range.
+func clamp(v, min, max float64) float64 {
+
+	return math.Max(min, math.Min(max, v))
+}
@@ -0,0 +1,351 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"errors"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// IDrawer is the interface for graphics which issue their own draw calls.
+// If the graphic of a graphic material implements it, Draw is called after the
+// material, geometry and graphic were set up instead of drawing the material range.
+type IDrawer interface {
+	Draw(gs *gls.GLS, grmat *GraphicMaterial)
+}
+
+// Batch is a Mesh which merges the geometries of many static meshes sharing a material
+// into a single geometry, so they can be drawn with a single multi-draw call.
+// Each added mesh becomes a range of the batch indices which can be hidden individually,
+// either by the application, by the per-range frustum culling of the batch or by draw
+// commands written by the GPU (see SetCommandBuffer). Visible ranges are drawn with
+// MultiDrawElementsIndirect when supported and with MultiDrawElements otherwise.
+type Batch struct {
+	Mesh                                       // Embedded mesh
+	geom     *geometry.Geometry                // Merged geometry
+	ranges   []batchRange                      // Index ranges of the added meshes
+	pos      math32.ArrayF32                   // Merged vertex positions
+	norm     math32.ArrayF32                   // Merged vertex normals
+	uv       math32.ArrayF32                   // Merged texture coordinates
+	indices  math32.ArrayU32                   // Merged indices
+	culling  bool                              // Ranges are frustum culled
+	cmdbuf   uint32                            // Buffer with draw commands written by the application or the GPU
+	frustum  math32.Frustum                    // Frustum in the batch space of the current frame
+	cmds     []gls.DrawElementsIndirectCommand // Draw commands of the current frame
+	cmdBytes []byte                            // Encoded draw commands of the current frame
+	counts   []int32                           // Index counts for MultiDrawElements
+	offsets  []uintptr                         // Index byte offsets for MultiDrawElements
+	indirect *gls.StreamBuffer                 // Stream buffer with the draw commands
+}
+
+// batchRange is the index range of a mesh added to a batch.
+type batchRange struct {
+	start   int         // Index of the first index
+	count   int         // Number of indices
+	box     math32.Box3 // Bounding box in the batch space
+	visible bool        // Range is visible
+}
+
+// NewBatch creates and returns a pointer to a new empty batch using the specified material.
+func NewBatch(imat material.IMaterial) *Batch {
+
+	b := new(Batch)
+	b.geom = geometry.NewGeometry()
+	b.Graphic.Init(b, b.geom, gls.TRIANGLES)
+	b.uniMm.Init("ModelMatrix")
+	b.uniMVm.Init("ModelViewMatrix")
+	b.uniMVPm.Init("MVP")
+	b.uniNm.Init("NormalMatrix")
+	b.culling = true
+	if imat != nil {
+		b.Graphic.AddMaterial(b, imat, 0, 0)
+	}
+	return b
+}
+
+// Add merges the geometry of the specified mesh, transformed by its local matrix, into
+// the batch and returns the index of its range. The mesh itself is not changed and is
+// usually not added to the scene. The first mesh added determines if the batch has
+// normals and texture coordinates: other meshes missing them get zero values.
+func (b *Batch) Add(igr IGraphic) (int, error) {
+
+	gr := igr.GetGraphic()
+	geom := igr.GetGeometry()
+	if gr.mode != gls.TRIANGLES && geom.Primitive() == 0 {
+		return -1, errors.New("only triangle meshes can be batched")
+	}
+	if err := geom.Restore(); err != nil {
+		return -1, err
+	}
+	tris := geom.TriangleIndices()
+	if tris == nil {
+		return -1, errors.New("only triangle meshes can be batched")
+	}
+	vbo := geom.VBO(gls.VertexPosition)
+	if vbo == nil {
+		return -1, errors.New("geometry has no vertex positions")
+	}
+	if len(b.ranges) == 0 {
+		b.pos = math32.NewArrayF32(0, 0)
+		if geom.VBO(gls.VertexNormal) != nil {
+			b.norm = math32.NewArrayF32(0, 0)
+		}
+		if geom.VBO(gls.VertexTexcoord) != nil {
+			b.uv = math32.NewArrayF32(0, 0)
+		}
+	}
+
+	// Transforms and appends the vertex attributes
+	gr.UpdateMatrix()
+	m := gr.Matrix()
+	var nm math32.Matrix3
+	nm.GetNormalMatrix(&m)
+	base := uint32(len(b.pos) / 3)
+	var values [4]float32
+	items := vbo.Items()
+	b.appendAttrib(geom, gls.VertexPosition, items, 3, func(v []float32) {
+		p := math32.Vector3{X: v[0], Y: v[1], Z: v[2]}
+		p.ApplyMatrix4(&m)
+		b.pos.Append(p.X, p.Y, p.Z)
+	}, values[:])
+	if b.norm != nil {
+		b.appendAttrib(geom, gls.VertexNormal, items, 3, func(v []float32) {
+			n := math32.Vector3{X: v[0], Y: v[1], Z: v[2]}
+			n.ApplyMatrix3(&nm).Normalize()
+			b.norm.Append(n.X, n.Y, n.Z)
+		}, values[:])
+	}
+	if b.uv != nil {
+		b.appendAttrib(geom, gls.VertexTexcoord, items, 2, func(v []float32) {
+			b.uv.Append(v[0], v[1])
+		}, values[:])
+	}
+
+	// Appends the indices offset to the first vertex of the mesh
+	var r batchRange
+	r.start = len(b.indices)
+	r.visible = true
+	for _, idx := range tris {
+		b.indices.Append(base + idx)
+	}
+	r.count = len(b.indices) - r.start
+	r.box.MakeEmpty()
+	for i := int(base); i < len(b.pos)/3; i++ {
+		r.box.ExpandByPoint(&math32.Vector3{X: b.pos[3*i], Y: b.pos[3*i+1], Z: b.pos[3*i+2]})
+	}
+	b.ranges = append(b.ranges, r)
+	b.update()
+	return len(b.ranges) - 1, nil
+}
+
+// Count returns the number of ranges (added meshes) of the batch.
+func (b *Batch) Count() int {
+
+	return len(b.ranges)
+}
+
+// SetRangeVisible sets the visibility of the range with the specified index.
+// Ranges are visible by default.
+func (b *Batch) SetRangeVisible(idx int, visible bool) {
+
+	b.ranges[idx].visible = visible
+}
+
+// RangeVisible returns the visibility of the range with the specified index.
+func (b *Batch) RangeVisible(idx int) bool {
+
+	return b.ranges[idx].visible
+}
+
+// SetCulling sets if the visible ranges are culled against the camera frustum
+// each frame, using their bounding boxes. It is enabled by default.
+func (b *Batch) SetCulling(culling bool) {
+
+	b.culling = culling
+}
+
+// Culling returns if the visible ranges are culled against the camera frustum.
+func (b *Batch) Culling() bool {
+
+	return b.culling
+}
+
+// Commands returns a draw command for each range of the batch, in the order the meshes
+// were added, as expected in the buffer specified by SetCommandBuffer.
+func (b *Batch) Commands() []gls.DrawElementsIndirectCommand {
+
+	cmds := make([]gls.DrawElementsIndirectCommand, len(b.ranges))
+	for i, r := range b.ranges {
+		cmds[i] = gls.DrawElementsIndirectCommand{Count: uint32(r.count), InstanceCount: 1, FirstIndex: uint32(r.start)}
+	}
+	return cmds
+}
+
+// SetCommandBuffer sets the handle of a buffer with the draw commands of all the ranges
+// (see Commands), usually written by GPU visibility culling setting the instance count of
+// hidden ranges to zero. The commands are drawn as they are and the visibility and culling
+// of the ranges are ignored. It requires indirect multi-draw support (see
+// gls.MultiDrawIndirectSupported). Zero restores drawing the ranges culled by the CPU.
+func (b *Batch) SetCommandBuffer(handle uint32) {
+
+	b.cmdbuf = handle
+}
+
+// CommandBuffer returns the handle of the buffer set by SetCommandBuffer.
+func (b *Batch) CommandBuffer() uint32 {
+
+	return b.cmdbuf
+}
+
+// Clone clones the batch and satisfies the INode interface.
+// The clone shares the merged geometry of the batch.
+func (b *Batch) Clone() core.INode {
+
+	clone := new(Batch)
+	clone.Graphic = *b.Graphic.Clone().(*Graphic)
+	clone.SetIGraphic(clone)
+	clone.uniMm.Init("ModelMatrix")
+	clone.uniMVm.Init("ModelViewMatrix")
+	clone.uniMVPm.Init("MVP")
+	clone.uniNm.Init("NormalMatrix")
+	clone.geom = b.geom
+	clone.ranges = append([]batchRange(nil), b.ranges...)
+	clone.pos = b.pos
+	clone.norm = b.norm
+	clone.uv = b.uv
+	clone.indices = b.indices
+	clone.culling = b.culling
+	return clone
+}
+
+// RenderSetup transfers the mesh matrices and updates the
+// camera frustum used to cull the ranges of the batch.
+func (b *Batch) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	b.Mesh.RenderSetup(gs, rinfo)
+	if b.culling {
+		b.frustum.SetFromMatrix(b.ModelViewProjectionMatrix())
+	}
+}
+
+// Draw draws the visible ranges of the batch and satisfies the IDrawer interface.
+// Adjacent visible ranges are merged into a single range.
+func (b *Batch) Draw(gs *gls.GLS, grmat *GraphicMaterial) {
+
+	if b.cmdbuf != 0 {
+		if gs.MultiDrawIndirectSupported() {
+			gs.BindBuffer(gls.DRAW_INDIRECT_BUFFER, b.cmdbuf)
+			gs.MultiDrawElementsIndirect(b.mode, gls.UNSIGNED_INT, 0, int32(len(b.ranges)), 0)
+			gs.BindBuffer(gls.DRAW_INDIRECT_BUFFER, 0)
+		}
+		return
+	}
+
+	// Builds the draw commands of the visible ranges
+	b.cmds = b.cmds[:0]
+	for i := range b.ranges {
+		r := &b.ranges[i]
+		if !r.visible || (b.culling && !b.frustum.IntersectsBox(&r.box)) {
+			continue
+		}
+		if n := len(b.cmds); n > 0 && b.cmds[n-1].FirstIndex+b.cmds[n-1].Count == uint32(r.start) {
+			b.cmds[n-1].Count += uint32(r.count)
+			continue
+		}
+		b.cmds = append(b.cmds, gls.DrawElementsIndirectCommand{Count: uint32(r.count), InstanceCount: 1, FirstIndex: uint32(r.start)})
+	}
+	if len(b.cmds) == 0 {
+		return
+	}
+
+	// Single range
+	if len(b.cmds) == 1 {
+		gs.DrawElements(b.mode, int32(b.cmds[0].Count), gls.UNSIGNED_INT, 4*b.cmds[0].FirstIndex)
+		return
+	}
+
+	// Indirect multi-draw from the stream buffer
+	if gs.MultiDrawIndirectSupported() {
+		b.cmdBytes = gls.AppendDrawCommands(b.cmdBytes[:0], b.cmds)
+		if b.indirect == nil {
+			b.indirect = gls.NewStreamBuffer(gs, gls.DRAW_INDIRECT_BUFFER, len(b.cmdBytes), gls.StreamBufferFrames)
+			b.indirect.SetAlignment(4)
+		}
+		offset := b.indirect.Write(b.cmdBytes)
+		gs.BindBuffer(gls.DRAW_INDIRECT_BUFFER, b.indirect.Handle())
+		gs.MultiDrawElementsIndirect(b.mode, gls.UNSIGNED_INT, offset, int32(len(b.cmds)), 0)
+		gs.BindBuffer(gls.DRAW_INDIRECT_BUFFER, 0)
+		b.indirect.EndFrame()
+		return
+	}
+
+	// Multi-draw from client arrays
+	b.counts = b.counts[:0]
+	b.offsets = b.offsets[:0]
+	for _, cmd := range b.cmds {
+		b.counts = append(b.counts, int32(cmd.Count))
+		b.offsets = append(b.offsets, uintptr(4*cmd.FirstIndex))
+	}
+	gs.MultiDrawElements(b.mode, b.counts, gls.UNSIGNED_INT, b.offsets)
+}
+
+// Dispose releases the merged geometry, the material and the draw command buffer of the batch.
+func (b *Batch) Dispose() {
+
+	b.Graphic.Dispose()
+	if b.indirect != nil {
+		b.indirect.Dispose()
+		b.indirect = nil
+	}
+}
+
+// appendAttrib reads the specified attribute of the first items of the specified geometry and
+// calls the callback with its first size elements, or with zeros if the geometry does not have it.
+func (b *Batch) appendAttrib(geom *geometry.Geometry, atype gls.AttribType, items, size int, cb func(v []float32), values []float32) {
+
+	vbo := geom.VBO(atype)
+	if vbo == nil {
+		for i := range values {
+			values[i] = 0
+		}
+		for i := 0; i < items; i++ {
+			cb(values[:size])
+		}
+		return
+	}
+	attrib := vbo.Attrib(atype)
+	for i := 0; i < items; i++ {
+		vbo.ReadAttrib(attrib, i, values)
+		cb(values[:size])
+	}
+}
+
+// update updates the VBOs and indices of the merged geometry.
+func (b *Batch) update() {
+
+	if b.geom.VBO(gls.VertexPosition) == nil {
+		b.geom.AddVBO(gls.NewVBO(b.pos).AddAttrib(gls.VertexPosition))
+		if b.norm != nil {
+			b.geom.AddVBO(gls.NewVBO(b.norm).AddAttrib(gls.VertexNormal))
+		}
+		if b.uv != nil {
+			b.geom.AddVBO(gls.NewVBO(b.uv).AddAttrib(gls.VertexTexcoord))
+		}
+	} else {
+		b.geom.VBO(gls.VertexPosition).SetBuffer(b.pos)
+		if b.norm != nil {
+			b.geom.VBO(gls.VertexNormal).SetBuffer(b.norm)
+		}
+		if b.uv != nil {
+			b.geom.VBO(gls.VertexTexcoord).SetBuffer(b.uv)
+		}
+	}
+	b.geom.SetIndices(b.indices)
+}
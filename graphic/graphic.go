@@ -42,8 +42,10 @@ type Graphic struct {
 	renderable  bool               // Renderable flag
 	cullable    bool               // Cullable flag
 	renderOrder int                // Render order
+	bounds      bounds             // Cached bounding volumes
 
 	ShaderDefines gls.ShaderDefines // Graphic-specific shader defines
+	Uniforms      gls.Uniforms      // Graphic-specific custom uniforms transferred when rendering
 
 	mm   math32.Matrix4 // Cached Model matrix
 	mvm  math32.Matrix4 // Cached ModelView matrix
@@ -73,6 +75,19 @@ func (gr *Graphic) Init(igr IGraphic, igeom geometry.IGeometry, mode uint32) *Gr
 	return gr
 }
 
+// FindGraphics returns all graphics found in the specified node and all its descendants.
+func FindGraphics(root core.INode) []IGraphic {
+
+	graphics := make([]IGraphic, 0)
+	root.GetNode().Walk(func(inode core.INode) bool {
+		if igr, ok := inode.(IGraphic); ok {
+			graphics = append(graphics, igr)
+		}
+		return true
+	})
+	return graphics
+}
+
 // GetGraphic satisfies the IGraphic interface and
 // returns pointer to the base Graphic.
 func (gr *Graphic) GetGraphic() *Graphic {
@@ -94,6 +109,13 @@ func (gr *Graphic) IGeometry() geometry.IGeometry {
 	return gr.igeom
 }
 
+// Mode returns the OpenGL primitive mode used to draw the graphic,
+// unless its geometry describes its own primitive.
+func (gr *Graphic) Mode() uint32 {
+
+	return gr.mode
+}
+
 // Dispose overrides the embedded Node Dispose method.
 func (gr *Graphic) Dispose() {
 
@@ -117,6 +139,7 @@ func (gr *Graphic) Clone() core.INode {
 	clone.cullable = gr.cullable
 	clone.renderOrder = gr.renderOrder
 	clone.ShaderDefines = gr.ShaderDefines
+	clone.Uniforms = gr.Uniforms.Clone()
 	clone.materials = make([]GraphicMaterial, len(gr.materials))
 
 	for i, grmat := range gr.materials {
@@ -244,10 +267,87 @@ func (gr *Graphic) BoundingBox() math32.Box3 {
 	return bbox
 }
 
+// bounds caches the local and world bounding volumes of a graphic.
+// Cached values are keyed by the geometry version and the world matrix version.
+type bounds struct {
+	geom        *geometry.Geometry // Geometry the cached volumes were calculated from
+	geomVersion uint64             // Geometry version of the cached volumes
+	wldVersion  uint64             // World matrix version of the cached world volumes
+	localValid  bool               // Whether the local volumes are valid
+	worldValid  bool               // Whether the world volumes are valid
+	localBox    math32.Box3        // Local bounding box
+	localSphere math32.Sphere      // Local bounding sphere
+	worldBox    math32.Box3        // World bounding box
+	worldSphere math32.Sphere      // World bounding sphere
+}
+
+// updateBounds recalculates the cached bounding volumes if
+// the geometry or the world transform changed.
+func (gr *Graphic) updateBounds() {
+
+	b := &gr.bounds
+	geom := gr.igeom.GetGeometry()
+	if !b.localValid || b.geom != geom || b.geomVersion != geom.Version() {
+		b.localBox = geom.BoundingBox()
+		b.localSphere = geom.BoundingSphere()
+		b.geom = geom
+		b.geomVersion = geom.Version()
+		b.localValid = true
+		b.worldValid = false
+	}
+	if !b.worldValid || b.wldVersion != gr.MatrixWorldVersion() {
+		mw := gr.MatrixWorld()
+		b.worldBox = b.localBox
+		b.worldBox.ApplyMatrix4(&mw)
+		b.worldSphere = b.localSphere
+		b.worldSphere.ApplyMatrix4(&mw)
+		b.wldVersion = gr.MatrixWorldVersion()
+		b.worldValid = true
+	}
+}
+
+// LocalBoundingBox returns the cached bounding box of this graphic's geometry in local coordinates.
+func (gr *Graphic) LocalBoundingBox() math32.Box3 {
+
+	gr.updateBounds()
+	return gr.bounds.localBox
+}
+
+// LocalBoundingSphere returns the cached bounding sphere of this graphic's geometry in local coordinates.
+func (gr *Graphic) LocalBoundingSphere() math32.Sphere {
+
+	gr.updateBounds()
+	return gr.bounds.localSphere
+}
+
+// WorldBoundingBox returns the cached axis-aligned bounding box of this graphic's geometry in world coordinates.
+// It is valid as of the last update of the world matrix.
+func (gr *Graphic) WorldBoundingBox() math32.Box3 {
+
+	gr.updateBounds()
+	return gr.bounds.worldBox
+}
+
+// WorldBoundingSphere returns the cached bounding sphere of this graphic's geometry in world coordinates.
+// It is valid as of the last update of the world matrix.
+func (gr *Graphic) WorldBoundingSphere() math32.Sphere {
+
+	gr.updateBounds()
+	return gr.bounds.worldSphere
+}
+
 // CalculateMatrices calculates the model view and model view projection matrices.
 func (gr *Graphic) CalculateMatrices(gs *gls.GLS, rinfo *core.RenderInfo) {
 
-	gr.mm = gr.MatrixWorld()
+	mm := gr.MatrixWorld()
+	gr.SetMatrices(&mm, rinfo)
+}
+
+// SetMatrices sets the model matrix, usually a snapshot of the world matrix,
+// and calculates the model view and model view projection matrices.
+func (gr *Graphic) SetMatrices(mm *math32.Matrix4, rinfo *core.RenderInfo) {
+
+	gr.mm = *mm
 	gr.mvm.MultiplyMatrices(&rinfo.ViewMatrix, &gr.mm)
 	gr.mvpm.MultiplyMatrices(&rinfo.ProjMatrix, &gr.mvm)
 }
@@ -286,6 +386,13 @@ func (grmat *GraphicMaterial) IMaterial() material.IMaterial {
 	return grmat.imat
 }
 
+// Range returns the index of the first element of the geometry rendered with the
+// GraphicMaterial and the number of elements, which is zero for all the elements.
+func (grmat *GraphicMaterial) Range() (start, count int) {
+
+	return grmat.start, grmat.count
+}
+
 // IGraphic returns the graphic associated with the GraphicMaterial.
 func (grmat *GraphicMaterial) IGraphic() IGraphic {
 
@@ -295,8 +402,15 @@ func (grmat *GraphicMaterial) IGraphic() IGraphic {
 // Render is called by the renderer to render this graphic material.
 func (grmat *GraphicMaterial) Render(gs *gls.GLS, rinfo *core.RenderInfo) {
 
+	grmat.RenderMaterial(gs, rinfo, grmat.imat)
+}
+
+// RenderMaterial renders the graphic using the specified material instead of its own,
+// as the renderer does with placeholder materials while shader programs are built.
+func (grmat *GraphicMaterial) RenderMaterial(gs *gls.GLS, rinfo *core.RenderInfo, imat material.IMaterial) {
+
 	// Setup the associated material (set states and transfer material uniforms and textures)
-	grmat.imat.RenderSetup(gs)
+	imat.RenderSetup(gs)
 
 	// Setup the associated geometry (set VAO and transfer VBOS)
 	gr := grmat.igraphic.GetGraphic()
@@ -304,23 +418,35 @@ func (grmat *GraphicMaterial) Render(gs *gls.GLS, rinfo *core.RenderInfo) {
 
 	// Setup current graphic (transfer matrices)
 	grmat.igraphic.RenderSetup(gs, rinfo)
+	gr.Uniforms.Transfer(gs)
+
+	// Graphics issuing their own draw calls
+	if drawer, ok := grmat.igraphic.(IDrawer); ok {
+		drawer.Draw(gs, grmat)
+		return
+	}
 
 	// Get the number of vertices for the current material
 	count := grmat.count
 
+	// Geometries describing their primitive override the graphic mode
 	geom := gr.igeom.GetGeometry()
-	indices := geom.Indices()
+	mode := gr.mode
+	if p := geom.Primitive(); p != 0 {
+		mode = p
+	}
 	// Indexed geometry
-	if indices.Size() > 0 {
+	if geom.Indexed() {
 		if count == 0 {
-			count = indices.Size()
+			count = geom.IndexCount()
 		}
-		gs.DrawElements(gr.mode, int32(count), gls.UNSIGNED_INT, 4*uint32(grmat.start))
+		gs.PrimitiveRestart(geom.PrimitiveRestart())
+		gs.DrawElements(mode, int32(count), gls.UNSIGNED_INT, 4*uint32(grmat.start))
 		// Non indexed geometry
 	} else {
 		if count == 0 {
 			count = geom.Items()
 		}
-		gs.DrawArrays(gr.mode, int32(grmat.start), int32(count))
+		gs.DrawArrays(mode, int32(grmat.start), int32(count))
 	}
 }
@@ -0,0 +1,102 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/material"
+)
+
+// InstancedMesh is a Mesh whose geometry is drawn many times with a single instanced draw call.
+// The per instance data, such as the position of each instance, is stored in VBOs of the
+// geometry with a non zero divisor (see gls.VBO.SetDivisor), and is applied by the shader
+// of the material, since the built-in shaders ignore it.
+// The geometry of an instanced mesh should not be shared, since its VBOs are bound to a
+// single vertex array; the per instance VBOs usually cover a large area, so the mesh
+// is not culled by default.
+type InstancedMesh struct {
+	Mesh      // Embedded mesh
+	count int // Number of instances
+}
+
+// NewInstancedMesh creates and returns a pointer to a new instanced mesh with the
+// specified geometry, material and number of instances.
+func NewInstancedMesh(igeom geometry.IGeometry, imat material.IMaterial, count int) *InstancedMesh {
+
+	im := new(InstancedMesh)
+	im.Init(igeom, imat, count)
+	return im
+}
+
+// Init initializes the instanced mesh and its uniforms.
+func (im *InstancedMesh) Init(igeom geometry.IGeometry, imat material.IMaterial, count int) {
+
+	im.Graphic.Init(im, igeom, gls.TRIANGLES)
+	im.uniMm.Init("ModelMatrix")
+	im.uniMVm.Init("ModelViewMatrix")
+	im.uniMVPm.Init("MVP")
+	im.uniNm.Init("NormalMatrix")
+	im.count = count
+	im.SetCullable(false)
+	if imat != nil {
+		im.Graphic.AddMaterial(im, imat, 0, 0)
+	}
+}
+
+// SetInstanceCount sets the number of instances drawn, which must not be
+// larger than the number of items of the per instance VBOs.
+func (im *InstancedMesh) SetInstanceCount(count int) {
+
+	im.count = count
+}
+
+// InstanceCount returns the number of instances drawn.
+func (im *InstancedMesh) InstanceCount() int {
+
+	return im.count
+}
+
+// Clone clones the instanced mesh and satisfies the INode interface.
+// The clone shares the geometry of the instanced mesh.
+func (im *InstancedMesh) Clone() core.INode {
+
+	clone := new(InstancedMesh)
+	clone.Graphic = *im.Graphic.Clone().(*Graphic)
+	clone.SetIGraphic(clone)
+	clone.uniMm.Init("ModelMatrix")
+	clone.uniMVm.Init("ModelViewMatrix")
+	clone.uniMVPm.Init("MVP")
+	clone.uniNm.Init("NormalMatrix")
+	clone.count = im.count
+	return clone
+}
+
+// Draw draws all the instances of the graphic material and satisfies the IDrawer interface.
+func (im *InstancedMesh) Draw(gs *gls.GLS, grmat *GraphicMaterial) {
+
+	if im.count <= 0 {
+		return
+	}
+	geom := im.igeom.GetGeometry()
+	mode := im.mode
+	if p := geom.Primitive(); p != 0 {
+		mode = p
+	}
+	count := grmat.count
+	if geom.Indexed() {
+		if count == 0 {
+			count = geom.IndexCount()
+		}
+		gs.PrimitiveRestart(geom.PrimitiveRestart())
+		gs.DrawElementsInstanced(mode, int32(count), gls.UNSIGNED_INT, 4*uint32(grmat.start), int32(im.count))
+		return
+	}
+	if count == 0 {
+		count = geom.Items()
+	}
+	gs.DrawArraysInstanced(mode, int32(grmat.start), int32(count), int32(im.count))
+}
@@ -0,0 +1,178 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/text"
+)
+
+// SDFText is a flat text positioned in space, made of one quad per glyph textured with
+// the signed distance fields of a text.SDFAtlas, which stays sharp at any distance.
+// It is normally rendered with a material.SDFText using a texture with the atlas image.
+// It can optionally face the camera like a Sprite, for labels.
+type SDFText struct {
+	Graphic                  // Embedded graphic
+	atlas     *text.SDFAtlas // Atlas with the glyphs
+	text      string         // Text
+	size      float32        // Size in world units per em
+	anchor    math32.Vector2 // Point of the text bounds at the origin
+	billboard bool           // Whether the text faces the camera
+	uniMVPM   gls.Uniform    // Model view projection matrix uniform location cache
+}
+
+// NewSDFText creates and returns a pointer to a text with the glyphs of the specified atlas,
+// with size world units per em and the specified material. The text is centered on its origin.
+func NewSDFText(atlas *text.SDFAtlas, str string, size float32, imat material.IMaterial) *SDFText {
+
+	t := new(SDFText)
+	t.atlas = atlas
+	t.size = size
+	t.anchor = math32.Vector2{X: 0.5, Y: 0.5}
+
+	geom := geometry.NewGeometry()
+	geom.AddVBO(
+		gls.NewVBO(math32.NewArrayF32(0, 0)).
+			AddAttrib(gls.VertexPosition).
+			AddAttrib(gls.VertexTexcoord),
+	)
+	t.Graphic.Init(t, geom, gls.TRIANGLES)
+	t.AddMaterial(t, imat, 0, 0)
+	t.uniMVPM.Init("MVP")
+	t.SetText(str)
+	return t
+}
+
+// SetText sets the text, which may contain line breaks (\n).
+// Runes without glyphs in the atlas are skipped.
+func (t *SDFText) SetText(str string) {
+
+	t.text = str
+	t.update()
+}
+
+// Text returns the text.
+func (t *SDFText) Text() string {
+
+	return t.text
+}
+
+// SetSize sets the size of the text in world units per em.
+func (t *SDFText) SetSize(size float32) {
+
+	t.size = size
+	t.update()
+}
+
+// Size returns the size of the text in world units per em.
+func (t *SDFText) Size() float32 {
+
+	return t.size
+}
+
+// SetAnchor sets the point of the text bounds placed at the origin, as fractions of
+// the text width and height from its bottom left corner. The default is (0.5, 0.5),
+// the center of the text, and (0, 0) is the bottom left corner.
+func (t *SDFText) SetAnchor(x, y float32) {
+
+	t.anchor = math32.Vector2{X: x, Y: y}
+	t.update()
+}
+
+// Anchor returns the point of the text bounds placed at the origin.
+func (t *SDFText) Anchor() math32.Vector2 {
+
+	return t.anchor
+}
+
+// SetBillboard sets if the text always faces the camera, keeping only its rotation around the Z axis.
+func (t *SDFText) SetBillboard(state bool) {
+
+	t.billboard = state
+}
+
+// Billboard returns if the text always faces the camera.
+func (t *SDFText) Billboard() bool {
+
+	return t.billboard
+}
+
+// Atlas returns the atlas with the glyphs of the text.
+func (t *SDFText) Atlas() *text.SDFAtlas {
+
+	return t.atlas
+}
+
+// update rebuilds the glyph quads.
+func (t *SDFText) update() {
+
+	a := t.atlas
+	positions := math32.NewArrayF32(0, 20*len(t.text))
+	indices := math32.NewArrayU32(0, 6*len(t.text))
+	advance := func(r rune) float32 {
+		if g := a.Glyphs[r]; g != nil {
+			return g.Advance
+		}
+		return 0
+	}
+	width, lines := layoutText(t.text, a.LineHeight, advance, a.Kern, func(r rune, x, y float32) {
+		g := a.Glyphs[r]
+		if g == nil || g.Max.X <= g.Min.X {
+			return
+		}
+		base := uint32(positions.Size() / 5)
+		positions.Append(
+			x+g.Min.X, y+g.Min.Y, 0, g.UVMin.X, g.UVMin.Y,
+			x+g.Max.X, y+g.Min.Y, 0, g.UVMax.X, g.UVMin.Y,
+			x+g.Max.X, y+g.Max.Y, 0, g.UVMax.X, g.UVMax.Y,
+			x+g.Min.X, y+g.Max.Y, 0, g.UVMin.X, g.UVMax.Y,
+		)
+		indices.Append(base, base+1, base+2, base, base+2, base+3)
+	})
+
+	// Moves the anchor point of the text bounds to the origin and scales to the text size
+	top := a.Ascent
+	bottom := -a.Descent - float32(lines-1)*a.LineHeight
+	ox := -t.anchor.X * width
+	oy := -(bottom + t.anchor.Y*(top-bottom))
+	for i := 0; i < positions.Size(); i += 5 {
+		positions[i] = (positions[i] + ox) * t.size
+		positions[i+1] = (positions[i+1] + oy) * t.size
+	}
+
+	geom := t.GetGeometry()
+	geom.VBO(gls.VertexPosition).SetBuffer(positions)
+	geom.SetIndices(indices)
+}
+
+// RenderSetup sets up the rendering of the text.
+func (t *SDFText) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	mw := t.MatrixWorld()
+	var mvm math32.Matrix4
+	mvm.MultiplyMatrices(&rinfo.ViewMatrix, &mw)
+
+	// Removes any rotation in X and Y axes, as sprites do
+	if t.billboard {
+		var position math32.Vector3
+		var quaternion math32.Quaternion
+		var scale math32.Vector3
+		mvm.Decompose(&position, &quaternion, &scale)
+		rotation := t.Rotation()
+		rotation.X = 0
+		rotation.Y = 0
+		quaternion.SetFromEuler(&rotation)
+		mvm.Compose(&position, &quaternion, &scale)
+	}
+
+	var mvpm math32.Matrix4
+	mvpm.MultiplyMatrices(&rinfo.ProjMatrix, &mvm)
+	location := t.uniMVPM.Location(gs)
+	gs.UniformMatrix4fv(location, 1, false, &mvpm[0])
+}
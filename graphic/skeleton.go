@@ -14,6 +14,7 @@ type Skeleton struct {
 	inverseBindMatrices []math32.Matrix4
 	boneMatrices        []math32.Matrix4
 	bones               []*core.Node
+	sockets             map[string]*Socket // Attachment sockets by name
 }
 
 // NewSkeleton creates and returns a pointer to a new Skeleton.
@@ -46,6 +47,17 @@ func (sk *Skeleton) Bones() []*core.Node {
 	return sk.bones
 }
 
+// BoneByName returns the first bone of the skeleton with the specified name or nil.
+func (sk *Skeleton) BoneByName(name string) *core.Node {
+
+	for _, bone := range sk.bones {
+		if bone.Name() == name {
+			return bone
+		}
+	}
+	return nil
+}
+
 // BoneMatrices calculates and returns the bone world matrices to be sent to the shader.
 func (sk *Skeleton) BoneMatrices(invMat *math32.Matrix4) []math32.Matrix4 {
 
@@ -0,0 +1,127 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/g3n/engine/core"
+)
+
+// Socket is a named attachment point on a bone of a skeleton.
+// The socket is a child node of its bone, and its transform is the offset of the
+// attachments relative to the bone. The nodes attached to the socket, such as weapons,
+// hats or particle emitters, are its children, so they follow the animation of the bone.
+type Socket struct {
+	core.Node            // Embedded node, child of the bone
+	bone      *core.Node // Bone of the socket
+}
+
+// NewSocket creates and returns a pointer to a new socket with the specified name
+// on the specified bone, with an identity offset.
+func NewSocket(name string, bone *core.Node) *Socket {
+
+	s := new(Socket)
+	s.Node.Init(s)
+	s.SetName(name)
+	s.SetBone(bone)
+	return s
+}
+
+// Bone returns the bone of the socket.
+func (s *Socket) Bone() *core.Node {
+
+	return s.bone
+}
+
+// SetBone moves the socket, with its attachments, to the specified bone keeping its offset.
+func (s *Socket) SetBone(bone *core.Node) {
+
+	if s.bone != nil {
+		s.bone.Remove(s)
+	}
+	s.bone = bone
+	bone.Add(s)
+}
+
+// Attach attaches the specified node to the socket, removing it from its previous parent
+// or socket. The transform of the node is kept as its offset relative to the socket.
+func (s *Socket) Attach(inode core.INode) {
+
+	s.Add(inode)
+}
+
+// Detach detaches the specified node from the socket and returns whether it was attached.
+func (s *Socket) Detach(inode core.INode) bool {
+
+	return s.Remove(inode)
+}
+
+// Attachments returns the nodes attached to the socket.
+func (s *Socket) Attachments() []core.INode {
+
+	return s.Children()
+}
+
+// AddSocket creates, adds and returns a socket with the specified name on the bone of the
+// skeleton with the specified name. A previous socket with the same name is removed
+// together with its attachments.
+func (sk *Skeleton) AddSocket(name, bone string) (*Socket, error) {
+
+	b := sk.BoneByName(bone)
+	if b == nil {
+		return nil, fmt.Errorf("skeleton has no bone named:%s", bone)
+	}
+	sk.RemoveSocket(name)
+	s := NewSocket(name, b)
+	if sk.sockets == nil {
+		sk.sockets = make(map[string]*Socket)
+	}
+	sk.sockets[name] = s
+	return s, nil
+}
+
+// RemoveSocket removes the socket with the specified name, together with its attachments,
+// from its bone and returns whether it was found.
+func (sk *Skeleton) RemoveSocket(name string) bool {
+
+	s, ok := sk.sockets[name]
+	if !ok {
+		return false
+	}
+	s.bone.Remove(s)
+	delete(sk.sockets, name)
+	return true
+}
+
+// Socket returns the socket with the specified name or nil.
+func (sk *Skeleton) Socket(name string) *Socket {
+
+	return sk.sockets[name]
+}
+
+// Sockets returns the sorted names of the sockets of the skeleton.
+func (sk *Skeleton) Sockets() []string {
+
+	names := make([]string, 0, len(sk.sockets))
+	for name := range sk.sockets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Attach attaches the specified node to the socket with the specified name,
+// moving it from its previous socket if any.
+func (sk *Skeleton) Attach(socket string, inode core.INode) error {
+
+	s := sk.sockets[socket]
+	if s == nil {
+		return fmt.Errorf("skeleton has no socket named:%s", socket)
+	}
+	s.Attach(inode)
+	return nil
+}
@@ -58,10 +58,8 @@ func NewSprite(width, height float32, imat material.IMaterial) *Sprite {
 // RenderSetup sets up the rendering of the sprite.
 func (s *Sprite) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 
-	// Calculates model view matrix
-	mw := s.MatrixWorld()
-	var mvm math32.Matrix4
-	mvm.MultiplyMatrices(&rinfo.ViewMatrix, &mw)
+	// Model view matrix calculated from the model matrix of the frame
+	mvm := *s.ModelViewMatrix()
 
 	// Decomposes model view matrix
 	var position math32.Vector3
@@ -0,0 +1,87 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/text"
+)
+
+// Number of segments each curve of the glyph outlines is split into
+const text3DCurveSegments = 4
+
+// NewText3D creates and returns a pointer to a mesh with the glyph outlines of the specified
+// text extruded by depth, as created by NewText3DGeometry, and the specified material.
+func NewText3D(font *text.Font, str string, size, depth float32, imat material.IMaterial) *Mesh {
+
+	return NewMesh(NewText3DGeometry(font, str, size, depth), imat)
+}
+
+// NewText3DGeometry creates and returns a pointer to a geometry with the glyph outlines of the
+// specified text, using the specified font with size world units per em, extruded along the
+// Z axis by depth. Lines are separated by line breaks (\n) and the origin is at the left of
+// the baseline of the first line. The first group of the geometry has the front and back faces
+// and the second group the sides. If depth is zero only the front face is created.
+func NewText3DGeometry(font *text.Font, str string, size, depth float32) *geometry.Geometry {
+
+	_, _, lineHeight := font.EmMetrics()
+	type glyph struct {
+		contours [][]math32.Vector2
+		advance  float32
+	}
+	glyphs := make(map[rune]*glyph)
+	outline := func(r rune) *glyph {
+		g := glyphs[r]
+		if g == nil {
+			g = new(glyph)
+			g.contours, g.advance = font.GlyphOutline(r, text3DCurveSegments)
+			glyphs[r] = g
+		}
+		return g
+	}
+
+	var contours [][]math32.Vector2
+	advance := func(r rune) float32 { return outline(r).advance }
+	layoutText(str, lineHeight, advance, font.Kern, func(r rune, x, y float32) {
+		for _, c := range outline(r).contours {
+			placed := make([]math32.Vector2, len(c))
+			for i, p := range c {
+				placed[i] = math32.Vector2{X: (p.X + x) * size, Y: (p.Y + y) * size}
+			}
+			contours = append(contours, placed)
+		}
+	})
+	return geometry.NewExtrudedShape(contours, depth)
+}
+
+// layoutText calls place with each rune of the specified text, except line breaks, and its
+// pen position in em units, starting at the origin and moving down by lineHeight at each
+// line break. It returns the width of the widest line and the number of lines.
+func layoutText(str string, lineHeight float32, advance func(r rune) float32,
+	kern func(r0, r1 rune) float32, place func(r rune, x, y float32)) (float32, int) {
+
+	var x, y, width float32
+	lines := 1
+	prev := rune(-1)
+	for _, r := range str {
+		if r == '\n' {
+			x = 0
+			y -= lineHeight
+			lines++
+			prev = -1
+			continue
+		}
+		if prev >= 0 {
+			x += kern(prev, r)
+		}
+		place(r, x, y)
+		x += advance(r)
+		width = math32.Max(width, x)
+		prev = r
+	}
+	return width, lines
+}
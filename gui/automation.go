@@ -0,0 +1,344 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/window"
+)
+
+// WidgetInfo describes the state of a widget for UI automation, testing and accessibility tools.
+type WidgetInfo struct {
+	Panel       IPanel      // The widget
+	Kind        string      // Type name of the widget such as "Button" or "Edit"
+	Name        string      // Node name of the widget
+	Path        string      // Names of the widget and its ancestors separated by "/" (unnamed nodes appear as "#index")
+	Text        string      // Text shown by the widget, if any
+	Description string      // Tooltip text of the widget, if any
+	Value       interface{} // Value of the widget such as a checkbox state or slider position, if any
+	Rect        Rect        // Screen rectangle of the widget in pixels
+	Visible     bool        // Whether the widget and all its ancestors are visible
+	Enabled     bool        // Whether the widget is enabled
+	Focused     bool        // Whether the widget has the key focus
+}
+
+// Automation drives the GUI programmatically as if a user was using the mouse and keyboard.
+// It enumerates the widgets of the scene set in the GUI manager, queries their state,
+// and synthesizes input events which are dispatched by the window so they go through
+// the same path as real user input. It can be used to write GUI tests running in a
+// hidden window or to implement accessibility tools.
+type Automation struct {
+	x    float32            // Synthesized cursor position
+	y    float32            // Synthesized cursor position
+	mods window.ModifierKey // Modifier keys held while synthesizing events
+}
+
+// NewAutomation creates and returns a pointer to a new GUI automation driver.
+func NewAutomation() *Automation {
+
+	return new(Automation)
+}
+
+// Widgets returns the descriptions of all the panels in the scene set in the
+// GUI manager, in depth first order, including hidden and disabled ones.
+func (a *Automation) Widgets() []*WidgetInfo {
+
+	var list []*WidgetInfo
+	if gm == nil || gm.scene == nil {
+		return list
+	}
+	var walk func(inode core.INode, path string, visible bool)
+	walk = func(inode core.INode, path string, visible bool) {
+		if ipan, ok := inode.(IPanel); ok {
+			visible = visible && ipan.Visible()
+			info := Describe(ipan)
+			info.Path = path
+			info.Visible = visible
+			list = append(list, info)
+		}
+		for i, child := range inode.Children() {
+			name := child.GetNode().Name()
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+			if path != "" {
+				name = path + "/" + name
+			}
+			walk(child, name, visible)
+		}
+	}
+	walk(gm.scene, "", true)
+	return list
+}
+
+// Find returns the first widget for which the specified function returns true or nil.
+func (a *Automation) Find(f func(*WidgetInfo) bool) *WidgetInfo {
+
+	for _, info := range a.Widgets() {
+		if f(info) {
+			return info
+		}
+	}
+	return nil
+}
+
+// FindAll returns all the widgets for which the specified function returns true.
+func (a *Automation) FindAll(f func(*WidgetInfo) bool) []*WidgetInfo {
+
+	var list []*WidgetInfo
+	for _, info := range a.Widgets() {
+		if f(info) {
+			list = append(list, info)
+		}
+	}
+	return list
+}
+
+// FindByName returns the first widget with the specified node name or nil.
+func (a *Automation) FindByName(name string) *WidgetInfo {
+
+	return a.Find(func(info *WidgetInfo) bool { return info.Name == name })
+}
+
+// FindByPath returns the widget with the specified path or nil.
+func (a *Automation) FindByPath(path string) *WidgetInfo {
+
+	return a.Find(func(info *WidgetInfo) bool { return info.Path == path })
+}
+
+// FindByText returns the first visible widget of the specified kind showing the specified text, or nil.
+// If kind is empty, widgets of any kind are considered.
+func (a *Automation) FindByText(kind, text string) *WidgetInfo {
+
+	return a.Find(func(info *WidgetInfo) bool {
+		return info.Visible && info.Text == text && (kind == "" || info.Kind == kind)
+	})
+}
+
+// Describe returns the description of the specified widget.
+// The Path and Visible fields only consider the widget itself; use Automation.Widgets
+// to obtain them relative to the scene.
+func Describe(ipan IPanel) *WidgetInfo {
+
+	p := ipan.GetPanel()
+	info := new(WidgetInfo)
+	info.Panel = ipan
+	info.Kind = reflect.Indirect(reflect.ValueOf(ipan)).Type().Name()
+	info.Name = p.Name()
+	info.Path = info.Name
+	info.Text = widgetText(ipan)
+	if p.tooltip != nil {
+		info.Description = p.tooltip.Text()
+	}
+	info.Value = widgetValue(ipan)
+	pos := p.Pospix()
+	info.Rect = Rect{X: pos.X, Y: pos.Y, Width: p.Width(), Height: p.Height()}
+	info.Visible = p.Visible()
+	info.Enabled = p.Enabled()
+	info.Focused = gm != nil && gm.keyFocus == ipan
+	return info
+}
+
+// widgetText returns the text shown by the specified widget or an empty string.
+func widgetText(ipan IPanel) string {
+
+	switch w := ipan.(type) {
+	case interface{ Text() string }:
+		return w.Text()
+	case *Button:
+		return w.Label.Text()
+	case *CheckRadio:
+		return w.Label.Text()
+	case *ImageButton:
+		if w.label != nil {
+			return w.label.Text()
+		}
+	case *MenuItem:
+		if w.label != nil {
+			return w.label.Text()
+		}
+	case *DropDown:
+		if sel := w.Selected(); sel != nil {
+			return sel.Text()
+		}
+	}
+	return ""
+}
+
+// widgetValue returns the value of the specified widget or nil.
+func widgetValue(ipan IPanel) interface{} {
+
+	switch w := ipan.(type) {
+	case *MenuItem:
+		if w.checkable {
+			return w.Checked()
+		}
+	case *TabBar:
+		return w.Selected()
+	case interface{ Value() bool }:
+		return w.Value()
+	case interface{ Value() float32 }:
+		return w.Value()
+	case interface{ Value() float64 }:
+		return w.Value()
+	}
+	return nil
+}
+
+// Position returns the synthesized cursor position.
+func (a *Automation) Position() (float32, float32) {
+
+	return a.x, a.y
+}
+
+// SetModifiers sets the modifier keys held while synthesizing the following events.
+func (a *Automation) SetModifiers(mods window.ModifierKey) {
+
+	a.mods = mods
+}
+
+// MoveTo moves the synthesized cursor to the specified screen position.
+func (a *Automation) MoveTo(x, y float32) {
+
+	a.x = x
+	a.y = y
+	window.Get().Dispatch(window.OnCursor, &window.CursorEvent{Xpos: x, Ypos: y, Mods: a.mods})
+}
+
+// Hover moves the synthesized cursor to the center of the specified widget.
+// It returns an error if the widget is not the panel under the cursor or one of its ancestors,
+// for example because it is hidden, disabled or covered by another panel.
+func (a *Automation) Hover(ipan IPanel) error {
+
+	p := ipan.GetPanel()
+	pos := p.Pospix()
+	a.MoveTo(pos.X+p.Width()/2, pos.Y+p.Height()/2)
+	if gm == nil || gm.target == nil || !ipan.IsAncestorOf(gm.target) {
+		return fmt.Errorf("widget %q is not reachable at (%v, %v)", Describe(ipan).Kind, a.x, a.y)
+	}
+	return nil
+}
+
+// Press presses the specified mouse button at the synthesized cursor position.
+func (a *Automation) Press(button window.MouseButton) {
+
+	window.Get().Dispatch(window.OnMouseDown, &window.MouseEvent{Xpos: a.x, Ypos: a.y, Button: button, Mods: a.mods})
+}
+
+// Release releases the specified mouse button at the synthesized cursor position.
+func (a *Automation) Release(button window.MouseButton) {
+
+	window.Get().Dispatch(window.OnMouseUp, &window.MouseEvent{Xpos: a.x, Ypos: a.y, Button: button, Mods: a.mods})
+}
+
+// ClickAt clicks the specified mouse button at the specified screen position.
+func (a *Automation) ClickAt(x, y float32, button window.MouseButton) {
+
+	a.MoveTo(x, y)
+	a.Press(button)
+	a.Release(button)
+}
+
+// Click moves the synthesized cursor to the center of the specified widget
+// and clicks the left mouse button. It returns an error without clicking if
+// the widget cannot be reached by the cursor.
+func (a *Automation) Click(ipan IPanel) error {
+
+	if err := a.Hover(ipan); err != nil {
+		return err
+	}
+	a.Press(window.MouseButtonLeft)
+	a.Release(window.MouseButtonLeft)
+	return nil
+}
+
+// Drag presses the left mouse button at the specified start position,
+// moves the cursor to the end position in the specified number of steps and releases it.
+func (a *Automation) Drag(x0, y0, x1, y1 float32, steps int) {
+
+	if steps < 1 {
+		steps = 1
+	}
+	a.MoveTo(x0, y0)
+	a.Press(window.MouseButtonLeft)
+	for i := 1; i <= steps; i++ {
+		t := float32(i) / float32(steps)
+		a.MoveTo(x0+(x1-x0)*t, y0+(y1-y0)*t)
+	}
+	a.Release(window.MouseButtonLeft)
+}
+
+// Scroll synthesizes a mouse wheel event at the synthesized cursor position.
+func (a *Automation) Scroll(xoffset, yoffset float32) {
+
+	window.Get().Dispatch(window.OnScroll, &window.ScrollEvent{Xoffset: xoffset, Yoffset: yoffset, Mods: a.mods})
+}
+
+// Focus sets the key focus to the specified widget.
+func (a *Automation) Focus(ipan IPanel) {
+
+	Manager().SetKeyFocus(ipan)
+}
+
+// PressKey presses and releases the specified key with the specified modifiers
+// in addition to the ones set with SetModifiers.
+func (a *Automation) PressKey(key window.Key, mods window.ModifierKey) {
+
+	ev := &window.KeyEvent{Key: key, Mods: a.mods | mods}
+	window.Get().Dispatch(window.OnKeyDown, ev)
+	window.Get().Dispatch(window.OnKeyUp, ev)
+}
+
+// Type synthesizes the char events to type the specified text.
+// Newlines and tabs are typed by pressing the Enter and Tab keys.
+func (a *Automation) Type(text string) {
+
+	for _, r := range text {
+		switch r {
+		case '\n':
+			a.PressKey(window.KeyEnter, 0)
+		case '\t':
+			a.PressKey(window.KeyTab, 0)
+		default:
+			window.Get().Dispatch(window.OnChar, &window.CharEvent{Char: r, Mods: a.mods})
+		}
+	}
+}
+
+// Dump returns a textual tree of the visible widgets, one per line, indented by depth.
+// It is useful to inspect the structure of a GUI when writing tests.
+func (a *Automation) Dump() string {
+
+	var sb strings.Builder
+	for _, info := range a.Widgets() {
+		if !info.Visible {
+			continue
+		}
+		depth := strings.Count(info.Path, "/")
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(info.Kind)
+		if info.Name != "" {
+			fmt.Fprintf(&sb, " %q", info.Name)
+		}
+		if info.Text != "" {
+			fmt.Fprintf(&sb, " text=%q", info.Text)
+		}
+		if info.Value != nil {
+			fmt.Fprintf(&sb, " value=%v", info.Value)
+		}
+		if !info.Enabled {
+			sb.WriteString(" disabled")
+		}
+		if info.Focused {
+			sb.WriteString(" focused")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
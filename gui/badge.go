@@ -0,0 +1,228 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"image"
+	"strconv"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// Badge is a small pill shaped panel with a text, normally a notification count,
+// which can be attached over the top right corner of another panel such as a
+// button or a tab header.
+type Badge struct {
+	Panel                       // Embedded panel
+	style    *BadgeStyle        // Pointer to current style
+	label    *Label             // Text label
+	counting bool               // Whether the badge shows a count
+	count    int                // Current count
+	max      int                // Maximum count shown
+	showZero bool               // Whether a zero count is shown
+	target   IPanel             // Panel the badge is attached to
+	img      *image.RGBA        // Image with the drawn background
+	tex      *texture.Texture2D // Texture with the drawn background
+}
+
+// BadgeStyle contains the styling of a Badge.
+type BadgeStyle struct {
+	BgColor   math32.Color4 // Background color
+	FgColor   math32.Color4 // Text color
+	PointSize float64       // Text font point size
+	Padding   float32       // Horizontal space between the text and the ends of the badge
+	Offset    float32       // Distance of the badge center inside the corner of the attached panel
+}
+
+// NewBadge creates and returns a pointer to a new badge with the specified text.
+func NewBadge(text string) *Badge {
+
+	b := new(Badge)
+	b.Panel.Initialize(b, 0, 0)
+	b.style = &StyleDefault().Badge
+	b.max = 99
+	b.label = NewLabel(text)
+	b.Panel.Add(b.label)
+	b.update()
+	return b
+}
+
+// NewCountBadge creates and returns a pointer to a new badge showing the specified count.
+func NewCountBadge(count int) *Badge {
+
+	b := NewBadge("")
+	b.SetCount(count)
+	return b
+}
+
+// SetText sets the text of the badge.
+func (b *Badge) SetText(text string) *Badge {
+
+	b.counting = false
+	b.label.SetText(text)
+	b.SetVisible(true)
+	b.recalc()
+	return b
+}
+
+// Text returns the text of the badge.
+func (b *Badge) Text() string {
+
+	return b.label.Text()
+}
+
+// SetCount sets the count shown by the badge.
+// A count greater than the maximum is shown as the maximum followed by "+".
+// A zero or negative count hides the badge unless SetShowZero(true) was called.
+func (b *Badge) SetCount(count int) *Badge {
+
+	b.counting = true
+	b.count = count
+	text := strconv.Itoa(count)
+	if count > b.max {
+		text = strconv.Itoa(b.max) + "+"
+	}
+	b.label.SetText(text)
+	b.SetVisible(count > 0 || b.showZero)
+	b.recalc()
+	return b
+}
+
+// Count returns the current count.
+func (b *Badge) Count() int {
+
+	return b.count
+}
+
+// SetMax sets the maximum count shown (default 99).
+func (b *Badge) SetMax(max int) *Badge {
+
+	b.max = max
+	if b.counting {
+		b.SetCount(b.count)
+	}
+	return b
+}
+
+// SetShowZero sets whether the badge is shown when its count is zero.
+func (b *Badge) SetShowZero(state bool) *Badge {
+
+	b.showZero = state
+	if b.counting {
+		b.SetCount(b.count)
+	}
+	return b
+}
+
+// SetStyle sets the style of the badge.
+func (b *Badge) SetStyle(bs *BadgeStyle) {
+
+	b.style = bs
+	b.update()
+}
+
+// Attach adds the badge over the top right corner of the specified panel,
+// detaching it from the previous one if any.
+// The badge is not clipped by the panel and follows its size changes.
+// Panels with a layout also lay out their badge, so it should be attached
+// to panels without layout such as buttons and tab headers.
+func (b *Badge) Attach(ipan IPanel) {
+
+	b.Detach()
+	b.target = ipan
+	b.SetBounded(false)
+	b.SetZLayerDelta(1)
+	ipan.GetPanel().Add(b)
+	ipan.SubscribeID(OnResize, b, func(evname string, ev interface{}) { b.place() })
+	b.place()
+}
+
+// Detach removes the badge from the panel it is attached to.
+func (b *Badge) Detach() {
+
+	if b.target == nil {
+		return
+	}
+	b.target.UnsubscribeID(OnResize, b)
+	b.target.GetPanel().Remove(b)
+	b.target = nil
+}
+
+// Target returns the panel the badge is attached to or nil.
+func (b *Badge) Target() IPanel {
+
+	return b.target
+}
+
+// update updates the visual state of the badge.
+func (b *Badge) update() {
+
+	b.label.SetFontSize(b.style.PointSize)
+	b.label.SetColor4(&b.style.FgColor)
+	b.recalc()
+}
+
+// recalc recalculates the size of the badge, redraws its background and repositions it.
+func (b *Badge) recalc() {
+
+	height := b.label.Height()
+	width := math32.Max(b.label.Width()+2*b.style.Padding, height)
+	b.label.SetPosition((width-b.label.Width())/2, 0)
+	b.SetContentSize(width, height)
+	b.redraw()
+	b.place()
+}
+
+// redraw draws the pill shaped background of the badge.
+func (b *Badge) redraw() {
+
+	width := int(b.ContentWidth())
+	height := int(b.ContentHeight())
+	if width <= 0 || height <= 0 {
+		return
+	}
+	if b.img == nil || b.img.Rect.Dx() != width || b.img.Rect.Dy() != height {
+		b.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	} else {
+		for i := range b.img.Pix {
+			b.img.Pix[i] = 0
+		}
+	}
+	// Coverage is the distance to the segment joining the centers of the rounded ends
+	r := float32(height) / 2
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			px := math32.Clamp(float32(x)+0.5, r, float32(width)-r)
+			dx := float32(x) + 0.5 - px
+			dy := float32(y) + 0.5 - r
+			blendPixel(b.img, x, y, &b.style.BgColor, r+0.5-math32.Sqrt(dx*dx+dy*dy))
+		}
+	}
+
+	if b.tex == nil {
+		b.tex = texture.NewTexture2DFromRGBA(b.img)
+		b.tex.SetMagFilter(gls.NEAREST)
+		b.tex.SetMinFilter(gls.NEAREST)
+		b.Panel.Material().AddTexture(b.tex)
+	} else {
+		b.tex.SetFromRGBA(b.img)
+	}
+}
+
+// place positions the badge centered near the top right corner of the attached panel.
+func (b *Badge) place() {
+
+	if b.target == nil {
+		return
+	}
+	tp := b.target.GetPanel()
+	left := tp.Margins().Left + tp.Borders().Left + tp.Paddings().Left
+	top := tp.Margins().Top + tp.Borders().Top + tp.Paddings().Top
+	x := tp.Width() - left - b.style.Offset - b.Width()/2
+	y := -top + b.style.Offset - b.Height()/2
+	b.SetPosition(x, y)
+}
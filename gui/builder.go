@@ -143,6 +143,7 @@ const (
 	AttribStepx          = "stepx"         // float32
 	AttribText           = "text"          // string
 	AttribTitle          = "title"         // string
+	AttribTooltip        = "tooltip"       // string
 	AttribType           = "type"          // string
 	AttribUserData       = "userdata"      // interface{}
 	AttribWidth          = "width"         // float32
@@ -288,6 +289,7 @@ func NewBuilder() *Builder {
 		AttribStepx:         AttribCheckFloat,
 		AttribText:          AttribCheckString,
 		AttribTitle:         AttribCheckString,
+		AttribTooltip:       AttribCheckString,
 		AttribType:          AttribCheckStringLower,
 		AttribUserData:      AttribCheckInterface,
 		AttribValue:         AttribCheckFloat,
@@ -579,6 +581,10 @@ func (b *Builder) SetAttribs(am map[string]interface{}, ipan IPanel) error {
 		panel.SetUserData(am[AttribUserData])
 	}
 
+	if am[AttribTooltip] != nil {
+		panel.SetTooltipText(am[AttribTooltip].(string))
+	}
+
 	// Sets optional layout (must pass IPanel not *Panel)
 	err := b.setLayout(am, ipan)
 	if err != nil {
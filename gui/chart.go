@@ -291,9 +291,14 @@ func (ch *Chart) RangeY() (minY, maxY float32) {
 	return ch.minY, ch.maxY
 }
 
-// AddLineGraph adds a line graph to the chart
+// AddLineGraph adds a line graph to the chart.
+// If color is nil, the next color of the series palette of the default style is used.
 func (ch *Chart) AddLineGraph(color *math32.Color, data []float32) *Graph {
 
+	if color == nil {
+		c := StyleDefault().Color.Series.At(len(ch.graphs))
+		color = &c
+	}
 	graph := newGraph(ch, color, data)
 	ch.graphs = append(ch.graphs, graph)
 	ch.Add(graph)
@@ -631,6 +636,8 @@ func newGraph(chart *Chart, color *math32.Color, data []float32) *Graph {
 	geom := geometry.NewGeometry()
 	lg.positions = math32.NewArrayF32(0, 0)
 	lg.vbo = gls.NewVBO(lg.positions).AddAttrib(gls.VertexPosition)
+	// The data of real time graphs may be updated every frame
+	lg.vbo.SetUsage(gls.STREAM_DRAW)
 	geom.AddVBO(lg.vbo)
 
 	// Initializes the panel with this graphic
@@ -0,0 +1,381 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TextPos is a position in a multi-line text.
+type TextPos struct {
+	Line int // Line index starting at 0
+	Col  int // Column in runes starting at 0
+}
+
+// Less returns whether the position is before the other position.
+func (p TextPos) Less(other TextPos) bool {
+
+	return p.Line < other.Line || (p.Line == other.Line && p.Col < other.Col)
+}
+
+// textEdit records a replacement of text for undo and redo.
+type textEdit struct {
+	pos      TextPos // Start of the replacement
+	removed  string  // Removed text
+	inserted string  // Inserted text
+	merge    bool    // Whether the edit may absorb following typed characters
+}
+
+// codeBuffer is the editable text of a CodeEditor with undo and redo history.
+type codeBuffer struct {
+	lines   [][]rune   // Text lines without line breaks
+	undo    []textEdit // Undo history
+	redo    []textEdit // Redo history
+	maxUndo int        // Maximum number of undo steps
+	changed int        // First line changed since the last call to firstChanged
+}
+
+// newCodeBuffer creates and returns a pointer to a new empty codeBuffer.
+func newCodeBuffer() *codeBuffer {
+
+	b := new(codeBuffer)
+	b.lines = [][]rune{{}}
+	b.maxUndo = 1000
+	return b
+}
+
+// text returns the whole text of the buffer.
+func (b *codeBuffer) text() string {
+
+	var sb strings.Builder
+	for i, line := range b.lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(string(line))
+	}
+	return sb.String()
+}
+
+// setText replaces the whole text and clears the history.
+func (b *codeBuffer) setText(text string) {
+
+	b.lines = splitLines(text)
+	b.undo = b.undo[:0]
+	b.redo = b.redo[:0]
+	b.changed = 0
+}
+
+// splitLines splits the specified text into lines of runes, accepting any line ending.
+func splitLines(text string) [][]rune {
+
+	parts := strings.Split(normalizeLineEnds(text), "\n")
+	lines := make([][]rune, len(parts))
+	for i, p := range parts {
+		lines[i] = []rune(p)
+	}
+	return lines
+}
+
+// normalizeLineEnds converts all line endings of the specified text to "\n".
+func normalizeLineEnds(text string) string {
+
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	return strings.Replace(text, "\r", "\n", -1)
+}
+
+// lineCount returns the number of lines.
+func (b *codeBuffer) lineCount() int {
+
+	return len(b.lines)
+}
+
+// line returns the runes of the specified line.
+func (b *codeBuffer) line(i int) []rune {
+
+	return b.lines[i]
+}
+
+// clamp returns the nearest valid position to the specified position.
+func (b *codeBuffer) clamp(p TextPos) TextPos {
+
+	if p.Line < 0 {
+		return TextPos{}
+	}
+	if p.Line >= len(b.lines) {
+		last := len(b.lines) - 1
+		return TextPos{last, len(b.lines[last])}
+	}
+	if p.Col < 0 {
+		p.Col = 0
+	} else if p.Col > len(b.lines[p.Line]) {
+		p.Col = len(b.lines[p.Line])
+	}
+	return p
+}
+
+// end returns the position after the last character.
+func (b *codeBuffer) end() TextPos {
+
+	last := len(b.lines) - 1
+	return TextPos{last, len(b.lines[last])}
+}
+
+// textRange returns the text between the specified positions.
+func (b *codeBuffer) textRange(from, to TextPos) string {
+
+	if to.Less(from) {
+		from, to = to, from
+	}
+	if from.Line == to.Line {
+		return string(b.lines[from.Line][from.Col:to.Col])
+	}
+	var sb strings.Builder
+	sb.WriteString(string(b.lines[from.Line][from.Col:]))
+	for i := from.Line + 1; i < to.Line; i++ {
+		sb.WriteByte('\n')
+		sb.WriteString(string(b.lines[i]))
+	}
+	sb.WriteByte('\n')
+	sb.WriteString(string(b.lines[to.Line][:to.Col]))
+	return sb.String()
+}
+
+// replace replaces the text between the specified positions, records the edit
+// in the undo history and returns the position after the inserted text.
+func (b *codeBuffer) replace(from, to TextPos, text string) TextPos {
+
+	if to.Less(from) {
+		from, to = to, from
+	}
+	text = normalizeLineEnds(text)
+	removed := b.textRange(from, to)
+	if removed == "" && text == "" {
+		return from
+	}
+	end := b.rawReplace(from, to, text)
+	b.redo = b.redo[:0]
+
+	// Consecutive typed characters of the same word are undone together
+	typed := removed == "" && len([]rune(text)) == 1 && text != "\n"
+	if typed && len(b.undo) > 0 {
+		last := &b.undo[len(b.undo)-1]
+		lastEnd := posAfter(last.pos, last.inserted)
+		if last.merge && lastEnd == from && isWordRune([]rune(text)[0]) == isWordRune(lastRune(last.inserted)) {
+			last.inserted += text
+			return end
+		}
+	}
+	b.undo = append(b.undo, textEdit{pos: from, removed: removed, inserted: text, merge: typed})
+	if len(b.undo) > b.maxUndo {
+		b.undo = b.undo[len(b.undo)-b.maxUndo:]
+	}
+	return end
+}
+
+// rawReplace replaces the text between the specified positions without recording
+// the edit and returns the position after the inserted text.
+func (b *codeBuffer) rawReplace(from, to TextPos, text string) TextPos {
+
+	ins := splitLines(text)
+	head := b.lines[from.Line][:from.Col]
+	tail := b.lines[to.Line][to.Col:]
+
+	// Build the replaced lines
+	repl := make([][]rune, len(ins))
+	for i, l := range ins {
+		repl[i] = l
+	}
+	repl[0] = append(append([]rune{}, head...), repl[0]...)
+	last := len(repl) - 1
+	endCol := len(repl[last])
+	repl[last] = append(repl[last], tail...)
+
+	lines := make([][]rune, 0, len(b.lines)-(to.Line-from.Line)+last)
+	lines = append(lines, b.lines[:from.Line]...)
+	lines = append(lines, repl...)
+	lines = append(lines, b.lines[to.Line+1:]...)
+	b.lines = lines
+	if from.Line < b.changed {
+		b.changed = from.Line
+	}
+	return TextPos{from.Line + last, endCol}
+}
+
+// firstChanged returns the first line changed since the last call and resets the mark.
+func (b *codeBuffer) firstChanged() int {
+
+	first := b.changed
+	b.changed = len(b.lines)
+	return first
+}
+
+// canUndo returns whether there are edits to undo.
+func (b *codeBuffer) canUndo() bool {
+
+	return len(b.undo) > 0
+}
+
+// canRedo returns whether there are undone edits to redo.
+func (b *codeBuffer) canRedo() bool {
+
+	return len(b.redo) > 0
+}
+
+// undoEdit undoes the last edit and returns the range of the restored text.
+func (b *codeBuffer) undoEdit() (from, to TextPos, ok bool) {
+
+	if len(b.undo) == 0 {
+		return
+	}
+	e := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	to = b.rawReplace(e.pos, posAfter(e.pos, e.inserted), e.removed)
+	e.merge = false
+	b.redo = append(b.redo, e)
+	return e.pos, to, true
+}
+
+// redoEdit redoes the last undone edit and returns the range of the inserted text.
+func (b *codeBuffer) redoEdit() (from, to TextPos, ok bool) {
+
+	if len(b.redo) == 0 {
+		return
+	}
+	e := b.redo[len(b.redo)-1]
+	b.redo = b.redo[:len(b.redo)-1]
+	to = b.rawReplace(e.pos, posAfter(e.pos, e.removed), e.inserted)
+	b.undo = append(b.undo, e)
+	return e.pos, to, true
+}
+
+// find searches for the specified text starting at the specified position and returns the
+// range of the first match, wrapping around the end (or start if backward) of the text.
+func (b *codeBuffer) find(query string, from TextPos, caseSensitive, backward bool) (TextPos, TextPos, bool) {
+
+	text := foldRunes([]rune(b.text()), caseSensitive)
+	q := foldRunes([]rune(query), caseSensitive)
+	if len(q) == 0 {
+		return from, from, false
+	}
+	start := b.offset(from)
+	n := len(text) + 1
+	for i := 0; i < n; i++ {
+		off := (start + i) % n
+		if backward {
+			off = ((start-1-i)%n + n) % n
+		}
+		if off+len(q) <= len(text) && runesEqual(text[off:off+len(q)], q) {
+			return b.posAt(off), b.posAt(off + len(q)), true
+		}
+	}
+	return from, from, false
+}
+
+// replaceAll replaces all occurrences of the specified text as a single undoable edit
+// and returns the number of replacements.
+func (b *codeBuffer) replaceAll(query, repl string, caseSensitive bool) int {
+
+	text := []rune(b.text())
+	src := foldRunes(append([]rune{}, text...), caseSensitive)
+	q := foldRunes([]rune(query), caseSensitive)
+	if len(q) == 0 {
+		return 0
+	}
+	var sb strings.Builder
+	count := 0
+	last := 0
+	for i := 0; i+len(q) <= len(src); {
+		if runesEqual(src[i:i+len(q)], q) {
+			sb.WriteString(string(text[last:i]))
+			sb.WriteString(repl)
+			i += len(q)
+			last = i
+			count++
+			continue
+		}
+		i++
+	}
+	if count == 0 {
+		return 0
+	}
+	sb.WriteString(string(text[last:]))
+	b.replace(TextPos{}, b.end(), sb.String())
+	return count
+}
+
+// foldRunes converts the specified runes to lower case in place unless the comparison is case sensitive.
+func foldRunes(r []rune, caseSensitive bool) []rune {
+
+	if !caseSensitive {
+		for i := range r {
+			r[i] = unicode.ToLower(r[i])
+		}
+	}
+	return r
+}
+
+// offset returns the rune offset of the specified position in the whole text.
+func (b *codeBuffer) offset(p TextPos) int {
+
+	off := 0
+	for i := 0; i < p.Line; i++ {
+		off += len(b.lines[i]) + 1
+	}
+	return off + p.Col
+}
+
+// posAt returns the position of the specified rune offset in the whole text.
+func (b *codeBuffer) posAt(off int) TextPos {
+
+	for i, l := range b.lines {
+		if off <= len(l) {
+			return TextPos{i, off}
+		}
+		off -= len(l) + 1
+	}
+	return b.end()
+}
+
+// posAfter returns the position after the specified text inserted at the specified position.
+func posAfter(p TextPos, text string) TextPos {
+
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return TextPos{p.Line, p.Col + len([]rune(text))}
+	}
+	return TextPos{p.Line + len(lines) - 1, len([]rune(lines[len(lines)-1]))}
+}
+
+// lastRune returns the last rune of the specified string or 0.
+func lastRune(s string) rune {
+
+	r := []rune(s)
+	if len(r) == 0 {
+		return 0
+	}
+	return r[len(r)-1]
+}
+
+// isWordRune returns whether the specified rune can be part of an identifier.
+func isWordRune(r rune) bool {
+
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// runesEqual returns whether the specified rune slices are equal.
+func runesEqual(a, b []rune) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
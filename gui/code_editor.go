@@ -0,0 +1,981 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/text"
+	"github.com/g3n/engine/texture"
+	"github.com/g3n/engine/window"
+)
+
+// CodeEditor is a multi-line text editor for source code with line numbers, selection,
+// undo and redo, clipboard, search and pluggable syntax highlighting.
+// It requires a monospaced font, by default the style's FontMono.
+// It dispatches OnChange when the text is changed by the user.
+type CodeEditor struct {
+	Panel                            // Embedded panel
+	TabSize      int                 // Number of columns of a tab stop
+	InsertSpaces bool                // Whether the tab key inserts spaces instead of a tab
+	AutoIndent   bool                // Whether new lines copy the indentation of the previous one
+	ReadOnly     bool                // Whether the text can only be selected and copied
+	LineNumbers  bool                // Whether line numbers are shown
+	buf          *codeBuffer         // Edited text
+	hl           Highlighter         // Syntax highlighter (may be nil)
+	states       []int               // Highlighter state at the start of each line
+	tokens       []Token             // Scratch tokens
+	font         *text.Font          // Monospaced font
+	attrib       text.FontAttributes // Font attributes
+	styles       *CodeEditorStyles   // Styles for each state
+	style        *CodeEditorStyle    // Current style
+	caret        TextPos             // Caret position
+	anchor       TextPos             // Selection anchor (equal to caret without selection)
+	goalX        int                 // Preferred visual column for vertical moves
+	first        int                 // First visible line
+	scrollX      float32             // Horizontal scroll in pixels
+	advance      float32             // Width of a character in pixels
+	lineHeight   int                 // Height of a line in pixels
+	focus        bool                // Whether the editor has the key focus
+	cursorOver   bool                // Whether the cursor is over the editor
+	dragging     bool                // Whether a selection is being dragged
+	caretOn      bool                // Blink state of the caret
+	blinkID      int                 // Blink timer
+	query        string              // Last searched text
+	matchCase    bool                // Whether the last search was case sensitive
+	img          *image.RGBA         // Rendered content
+	tex          *texture.Texture2D  // Texture with the rendered content
+}
+
+// CodeEditorStyle contains the styling of a CodeEditor.
+type CodeEditorStyle struct {
+	Border           RectBounds
+	Paddings         RectBounds
+	BorderColor      math32.Color4
+	BgColor          math32.Color4
+	FgColor          math32.Color4
+	GutterColor      math32.Color4
+	LineNumberColor  math32.Color4
+	CurrentLineColor math32.Color4
+	SelectionColor   math32.Color4
+	CaretColor       math32.Color4
+	TokenColors      map[TokenKind]math32.Color4 // Colors of highlighted tokens; missing kinds use FgColor
+}
+
+// CodeEditorStyles contains a CodeEditorStyle for each valid GUI state.
+type CodeEditorStyles struct {
+	Normal   CodeEditorStyle
+	Over     CodeEditorStyle
+	Focus    CodeEditorStyle
+	Disabled CodeEditorStyle
+}
+
+// Margin between the gutter and the text, in pixels
+const codeEditorMargin = 4
+
+// NewCodeEditor creates and returns a pointer to a new code editor with the specified dimensions.
+func NewCodeEditor(width, height float32) *CodeEditor {
+
+	ce := new(CodeEditor)
+	ce.Panel.Initialize(ce, width, height)
+	ce.TabSize = 4
+	ce.AutoIndent = true
+	ce.LineNumbers = true
+	ce.buf = newCodeBuffer()
+	ce.styles = &StyleDefault().CodeEditor
+	ce.font = StyleDefault().FontMono
+	ce.attrib = StyleDefault().Label.FontAttributes
+	ce.measure()
+
+	ce.Subscribe(OnKeyDown, ce.onKey)
+	ce.Subscribe(OnKeyRepeat, ce.onKey)
+	ce.Subscribe(OnChar, ce.onChar)
+	ce.Subscribe(OnMouseDown, ce.onMouse)
+	ce.Subscribe(OnMouseUp, ce.onMouse)
+	ce.Subscribe(OnCursor, ce.onCursor)
+	ce.Subscribe(OnCursorEnter, ce.onCursor)
+	ce.Subscribe(OnCursorLeave, ce.onCursor)
+	ce.Subscribe(OnScroll, ce.onScroll)
+	ce.Subscribe(OnFocusLost, ce.onFocusLost)
+	ce.Subscribe(OnResize, func(evname string, ev interface{}) { ce.redraw() })
+	ce.Subscribe(OnEnable, func(evname string, ev interface{}) { ce.update() })
+
+	ce.update()
+	return ce
+}
+
+// SetStyles sets the editor styles overriding the default style.
+func (ce *CodeEditor) SetStyles(styles *CodeEditorStyles) {
+
+	ce.styles = styles
+	ce.update()
+}
+
+// SetFont sets the monospaced font and its point size.
+func (ce *CodeEditor) SetFont(font *text.Font, size float64) {
+
+	ce.font = font
+	ce.attrib.PointSize = size
+	ce.measure()
+	ce.redraw()
+}
+
+// SetHighlighter sets the syntax highlighter. Nil disables highlighting.
+func (ce *CodeEditor) SetHighlighter(h Highlighter) {
+
+	ce.hl = h
+	ce.states = ce.states[:0]
+	ce.redraw()
+}
+
+// Highlighter returns the current syntax highlighter.
+func (ce *CodeEditor) Highlighter() Highlighter {
+
+	return ce.hl
+}
+
+// SetText sets the text of the editor, moving the caret to the start and clearing the undo history.
+func (ce *CodeEditor) SetText(text string) {
+
+	ce.buf.setText(text)
+	ce.states = ce.states[:0]
+	ce.caret = TextPos{}
+	ce.anchor = ce.caret
+	ce.first = 0
+	ce.scrollX = 0
+	ce.redraw()
+}
+
+// Text returns the text of the editor.
+func (ce *CodeEditor) Text() string {
+
+	return ce.buf.text()
+}
+
+// LineCount returns the number of lines of the text.
+func (ce *CodeEditor) LineCount() int {
+
+	return ce.buf.lineCount()
+}
+
+// Caret returns the position of the caret.
+func (ce *CodeEditor) Caret() TextPos {
+
+	return ce.caret
+}
+
+// SetCaret moves the caret to the specified position, clearing the selection.
+func (ce *CodeEditor) SetCaret(pos TextPos) {
+
+	ce.moveTo(ce.buf.clamp(pos), false)
+}
+
+// Selection returns the start and end of the selection, which are equal if nothing is selected.
+func (ce *CodeEditor) Selection() (from, to TextPos) {
+
+	if ce.caret.Less(ce.anchor) {
+		return ce.caret, ce.anchor
+	}
+	return ce.anchor, ce.caret
+}
+
+// SetSelection selects the text between the specified positions, leaving the caret at the end.
+func (ce *CodeEditor) SetSelection(from, to TextPos) {
+
+	ce.anchor = ce.buf.clamp(from)
+	ce.caret = ce.buf.clamp(to)
+	ce.goalX = ce.visualCol(ce.caret)
+	ce.scrollToCaret()
+	ce.redraw()
+}
+
+// SelectAll selects the whole text.
+func (ce *CodeEditor) SelectAll() {
+
+	ce.SetSelection(TextPos{}, ce.buf.end())
+}
+
+// SelectedText returns the selected text.
+func (ce *CodeEditor) SelectedText() string {
+
+	from, to := ce.Selection()
+	return ce.buf.textRange(from, to)
+}
+
+// Insert replaces the selection with the specified text as an undoable edit.
+func (ce *CodeEditor) Insert(text string) {
+
+	from, to := ce.Selection()
+	ce.edit(from, to, text)
+}
+
+// Undo undoes the last edit.
+func (ce *CodeEditor) Undo() {
+
+	if _, to, ok := ce.buf.undoEdit(); ok {
+		ce.changed(to)
+	}
+}
+
+// Redo redoes the last undone edit.
+func (ce *CodeEditor) Redo() {
+
+	if _, to, ok := ce.buf.redoEdit(); ok {
+		ce.changed(to)
+	}
+}
+
+// CanUndo returns whether there are edits to undo.
+func (ce *CodeEditor) CanUndo() bool {
+
+	return ce.buf.canUndo()
+}
+
+// CanRedo returns whether there are undone edits to redo.
+func (ce *CodeEditor) CanRedo() bool {
+
+	return ce.buf.canRedo()
+}
+
+// Copy copies the selected text to the clipboard.
+func (ce *CodeEditor) Copy() {
+
+	if sel := ce.SelectedText(); sel != "" {
+		window.Get().SetClipboard(sel)
+	}
+}
+
+// Cut copies the selected text to the clipboard and deletes it.
+func (ce *CodeEditor) Cut() {
+
+	if ce.ReadOnly {
+		return
+	}
+	ce.Copy()
+	ce.Insert("")
+}
+
+// Paste replaces the selection with the text of the clipboard.
+func (ce *CodeEditor) Paste() {
+
+	if ce.ReadOnly {
+		return
+	}
+	if clip := window.Get().Clipboard(); clip != "" {
+		ce.Insert(clip)
+	}
+}
+
+// Find selects the next occurrence of the specified text after the caret, or the previous
+// one before the selection if backward, wrapping around the text. It returns whether it was found.
+func (ce *CodeEditor) Find(query string, matchCase, backward bool) bool {
+
+	ce.query = query
+	ce.matchCase = matchCase
+	from, to := ce.Selection()
+	start := to
+	if backward {
+		start = from
+	}
+	a, b, ok := ce.buf.find(query, start, matchCase, backward)
+	if ok {
+		ce.SetSelection(a, b)
+	}
+	return ok
+}
+
+// FindNext repeats the last search forward or backward.
+func (ce *CodeEditor) FindNext(backward bool) bool {
+
+	return ce.Find(ce.query, ce.matchCase, backward)
+}
+
+// ReplaceAll replaces all occurrences of the specified text as a single undoable edit
+// and returns the number of replacements.
+func (ce *CodeEditor) ReplaceAll(query, repl string, matchCase bool) int {
+
+	if ce.ReadOnly {
+		return 0
+	}
+	count := ce.buf.replaceAll(query, repl, matchCase)
+	if count > 0 {
+		ce.changed(ce.buf.clamp(ce.caret))
+	}
+	return count
+}
+
+// ScrollToLine scrolls the editor so the specified line is visible.
+func (ce *CodeEditor) ScrollToLine(line int) {
+
+	visible := ce.visibleLines()
+	if line < ce.first {
+		ce.first = line
+	} else if line >= ce.first+visible {
+		ce.first = line - visible + 1
+	}
+	max := ce.buf.lineCount() - visible
+	if ce.first > max {
+		ce.first = max
+	}
+	if ce.first < 0 {
+		ce.first = 0
+	}
+	ce.redraw()
+}
+
+// edit replaces the specified range with the specified text and moves the caret after it.
+func (ce *CodeEditor) edit(from, to TextPos, text string) {
+
+	if ce.ReadOnly {
+		return
+	}
+	ce.changed(ce.buf.replace(from, to, text))
+}
+
+// changed updates the editor after the text was changed, moving the caret to the specified position.
+func (ce *CodeEditor) changed(caret TextPos) {
+
+	first := ce.buf.firstChanged()
+	if first < len(ce.states) {
+		ce.states = ce.states[:first]
+	}
+	ce.caret = ce.buf.clamp(caret)
+	ce.anchor = ce.caret
+	ce.goalX = ce.visualCol(ce.caret)
+	ce.scrollToCaret()
+	ce.redraw()
+	ce.Dispatch(OnChange, nil)
+}
+
+// moveTo moves the caret to the specified position, extending the selection if requested.
+func (ce *CodeEditor) moveTo(pos TextPos, extend bool) {
+
+	ce.caret = pos
+	if !extend {
+		ce.anchor = pos
+	}
+	ce.goalX = ce.visualCol(pos)
+	ce.caretOn = true
+	ce.scrollToCaret()
+	ce.redraw()
+}
+
+// moveVert moves the caret the specified number of lines keeping its visual column.
+func (ce *CodeEditor) moveVert(lines int, extend bool) {
+
+	goal := ce.goalX
+	line := ce.caret.Line + lines
+	if line < 0 {
+		line = 0
+	} else if line >= ce.buf.lineCount() {
+		line = ce.buf.lineCount() - 1
+	}
+	ce.moveTo(TextPos{line, ce.colAt(line, goal)}, extend)
+	ce.goalX = goal
+}
+
+// wordLeft returns the position of the start of the word before the specified position.
+func (ce *CodeEditor) wordLeft(p TextPos) TextPos {
+
+	if p.Col == 0 {
+		if p.Line == 0 {
+			return p
+		}
+		return TextPos{p.Line - 1, len(ce.buf.line(p.Line - 1))}
+	}
+	line := ce.buf.line(p.Line)
+	col := p.Col
+	for col > 0 && unicode.IsSpace(line[col-1]) {
+		col--
+	}
+	if col > 0 && isWordRune(line[col-1]) {
+		for col > 0 && isWordRune(line[col-1]) {
+			col--
+		}
+	} else if col > 0 {
+		col--
+	}
+	return TextPos{p.Line, col}
+}
+
+// wordRight returns the position of the end of the word after the specified position.
+func (ce *CodeEditor) wordRight(p TextPos) TextPos {
+
+	line := ce.buf.line(p.Line)
+	if p.Col >= len(line) {
+		if p.Line+1 >= ce.buf.lineCount() {
+			return p
+		}
+		return TextPos{p.Line + 1, 0}
+	}
+	col := p.Col
+	for col < len(line) && unicode.IsSpace(line[col]) {
+		col++
+	}
+	if col < len(line) && isWordRune(line[col]) {
+		for col < len(line) && isWordRune(line[col]) {
+			col++
+		}
+	} else if col < len(line) {
+		col++
+	}
+	return TextPos{p.Line, col}
+}
+
+// indentation returns the leading white space of the specified line.
+func (ce *CodeEditor) indentation(line int) string {
+
+	l := ce.buf.line(line)
+	n := 0
+	for n < len(l) && (l[n] == ' ' || l[n] == '\t') {
+		n++
+	}
+	return string(l[:n])
+}
+
+// indentUnit returns the text inserted by the tab key.
+func (ce *CodeEditor) indentUnit() string {
+
+	if ce.InsertSpaces {
+		return strings.Repeat(" ", ce.TabSize)
+	}
+	return "\t"
+}
+
+// indentLines indents or unindents the lines of the selection as a single edit.
+func (ce *CodeEditor) indentLines(unindent bool) {
+
+	from, to := ce.Selection()
+	last := to.Line
+	if to.Col == 0 && to.Line > from.Line {
+		last--
+	}
+	lines := make([]string, 0, last-from.Line+1)
+	for i := from.Line; i <= last; i++ {
+		l := string(ce.buf.line(i))
+		if unindent {
+			switch {
+			case strings.HasPrefix(l, "\t"):
+				l = l[1:]
+			default:
+				n := 0
+				for n < ce.TabSize && n < len(l) && l[n] == ' ' {
+					n++
+				}
+				l = l[n:]
+			}
+		} else if l != "" {
+			l = ce.indentUnit() + l
+		}
+		lines = append(lines, l)
+	}
+	start := TextPos{from.Line, 0}
+	end := TextPos{last, len(ce.buf.line(last))}
+	ce.edit(start, end, strings.Join(lines, "\n"))
+	ce.anchor = start
+	ce.caret = ce.buf.clamp(TextPos{last, len(ce.buf.line(last))})
+	ce.redraw()
+}
+
+// onKey receives subscribed key events.
+func (ce *CodeEditor) onKey(evname string, ev interface{}) {
+
+	kev := ev.(*window.KeyEvent)
+	shift := kev.Mods&window.ModShift != 0
+	ctrl := kev.Mods&(window.ModControl|window.ModSuper) != 0
+	from, to := ce.Selection()
+	hasSel := from != to
+
+	switch kev.Key {
+	case window.KeyLeft:
+		switch {
+		case ctrl:
+			ce.moveTo(ce.wordLeft(ce.caret), shift)
+		case hasSel && !shift:
+			ce.moveTo(from, false)
+		case ce.caret.Col > 0:
+			ce.moveTo(TextPos{ce.caret.Line, ce.caret.Col - 1}, shift)
+		case ce.caret.Line > 0:
+			ce.moveTo(TextPos{ce.caret.Line - 1, len(ce.buf.line(ce.caret.Line - 1))}, shift)
+		}
+	case window.KeyRight:
+		switch {
+		case ctrl:
+			ce.moveTo(ce.wordRight(ce.caret), shift)
+		case hasSel && !shift:
+			ce.moveTo(to, false)
+		case ce.caret.Col < len(ce.buf.line(ce.caret.Line)):
+			ce.moveTo(TextPos{ce.caret.Line, ce.caret.Col + 1}, shift)
+		case ce.caret.Line+1 < ce.buf.lineCount():
+			ce.moveTo(TextPos{ce.caret.Line + 1, 0}, shift)
+		}
+	case window.KeyUp:
+		ce.moveVert(-1, shift)
+	case window.KeyDown:
+		ce.moveVert(1, shift)
+	case window.KeyPageUp:
+		ce.moveVert(-ce.visibleLines(), shift)
+	case window.KeyPageDown:
+		ce.moveVert(ce.visibleLines(), shift)
+	case window.KeyHome:
+		if ctrl {
+			ce.moveTo(TextPos{}, shift)
+			break
+		}
+		// Go to the first non blank column, or to column 0 if already there
+		indent := len([]rune(ce.indentation(ce.caret.Line)))
+		if ce.caret.Col == indent {
+			indent = 0
+		}
+		ce.moveTo(TextPos{ce.caret.Line, indent}, shift)
+	case window.KeyEnd:
+		if ctrl {
+			ce.moveTo(ce.buf.end(), shift)
+			break
+		}
+		ce.moveTo(TextPos{ce.caret.Line, len(ce.buf.line(ce.caret.Line))}, shift)
+	case window.KeyBackspace:
+		if !hasSel {
+			if ctrl {
+				from = ce.wordLeft(ce.caret)
+			} else if ce.caret.Col > 0 {
+				from = TextPos{ce.caret.Line, ce.caret.Col - 1}
+			} else if ce.caret.Line > 0 {
+				from = TextPos{ce.caret.Line - 1, len(ce.buf.line(ce.caret.Line - 1))}
+			}
+		}
+		ce.edit(from, to, "")
+	case window.KeyDelete:
+		if !hasSel {
+			if ctrl {
+				to = ce.wordRight(ce.caret)
+			} else if ce.caret.Col < len(ce.buf.line(ce.caret.Line)) {
+				to = TextPos{ce.caret.Line, ce.caret.Col + 1}
+			} else if ce.caret.Line+1 < ce.buf.lineCount() {
+				to = TextPos{ce.caret.Line + 1, 0}
+			}
+		}
+		ce.edit(from, to, "")
+	case window.KeyEnter, window.KeyKPEnter:
+		indent := ""
+		if ce.AutoIndent {
+			indent = ce.indentation(from.Line)
+			if from.Col < len([]rune(indent)) {
+				indent = string([]rune(indent)[:from.Col])
+			}
+		}
+		ce.edit(from, to, "\n"+indent)
+	case window.KeyTab:
+		if shift || (hasSel && from.Line != to.Line) {
+			ce.indentLines(shift)
+			break
+		}
+		ce.edit(from, to, ce.indentUnit())
+	case window.KeyA:
+		if ctrl {
+			ce.SelectAll()
+		}
+	case window.KeyC, window.KeyInsert:
+		if ctrl {
+			ce.Copy()
+		}
+	case window.KeyX:
+		if ctrl {
+			ce.Cut()
+		}
+	case window.KeyV:
+		if ctrl {
+			ce.Paste()
+		}
+	case window.KeyZ:
+		if ctrl && shift {
+			ce.Redo()
+		} else if ctrl {
+			ce.Undo()
+		}
+	case window.KeyY:
+		if ctrl {
+			ce.Redo()
+		}
+	case window.KeyF3:
+		ce.FindNext(shift)
+	default:
+		return
+	}
+	ce.caretOn = true
+	ce.redraw()
+}
+
+// onChar receives subscribed char events.
+func (ce *CodeEditor) onChar(evname string, ev interface{}) {
+
+	cev := ev.(*window.CharEvent)
+	if cev.Mods&(window.ModControl|window.ModSuper) != 0 {
+		return
+	}
+	ce.Insert(string(cev.Char))
+}
+
+// onMouse receives subscribed mouse button events.
+func (ce *CodeEditor) onMouse(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	if mev.Button != window.MouseButtonLeft {
+		return
+	}
+	switch evname {
+	case OnMouseDown:
+		Manager().SetKeyFocus(ce)
+		if !ce.focus {
+			ce.focus = true
+			ce.caretOn = true
+			ce.blinkID = Manager().SetInterval(750*time.Millisecond, nil, ce.blink)
+			ce.update()
+		}
+		ce.moveTo(ce.posAt(mev.Xpos, mev.Ypos), mev.Mods&window.ModShift != 0)
+		ce.dragging = true
+		Manager().SetCursorFocus(ce)
+	case OnMouseUp:
+		ce.dragging = false
+		Manager().SetCursorFocus(nil)
+	}
+}
+
+// onCursor receives subscribed cursor events.
+func (ce *CodeEditor) onCursor(evname string, ev interface{}) {
+
+	switch evname {
+	case OnCursorEnter:
+		window.Get().SetCursor(window.IBeamCursor)
+		ce.cursorOver = true
+		ce.update()
+	case OnCursorLeave:
+		window.Get().SetCursor(window.ArrowCursor)
+		ce.cursorOver = false
+		ce.update()
+	case OnCursor:
+		if ce.dragging {
+			cev := ev.(*window.CursorEvent)
+			ce.moveTo(ce.posAt(cev.Xpos, cev.Ypos), true)
+		}
+	}
+}
+
+// onScroll receives subscribed scroll events.
+func (ce *CodeEditor) onScroll(evname string, ev interface{}) {
+
+	sev := ev.(*window.ScrollEvent)
+	ce.first -= int(sev.Yoffset * 3)
+	max := ce.buf.lineCount() - ce.visibleLines()
+	if ce.first > max {
+		ce.first = max
+	}
+	if ce.first < 0 {
+		ce.first = 0
+	}
+	ce.scrollX -= sev.Xoffset * ce.advance * 3
+	if ce.scrollX < 0 {
+		ce.scrollX = 0
+	}
+	ce.redraw()
+}
+
+// onFocusLost is called when the editor loses the key focus.
+func (ce *CodeEditor) onFocusLost(evname string, ev interface{}) {
+
+	ce.focus = false
+	ce.dragging = false
+	Manager().ClearTimeout(ce.blinkID)
+	ce.update()
+}
+
+// blink blinks the caret.
+func (ce *CodeEditor) blink(arg interface{}) {
+
+	if !ce.focus {
+		return
+	}
+	ce.caretOn = !ce.caretOn
+	ce.redraw()
+}
+
+// measure measures the character advance and line height of the font.
+func (ce *CodeEditor) measure() {
+
+	ce.font.SetAttributes(&ce.attrib)
+	const n = 100
+	width, _ := ce.font.MeasureText(strings.Repeat("M", n))
+	ce.advance = float32(width) / n
+	metrics := ce.font.Metrics()
+	ce.lineHeight = (metrics.Ascent + metrics.Descent).Ceil()
+}
+
+// visibleLines returns the number of lines which fit in the editor.
+func (ce *CodeEditor) visibleLines() int {
+
+	n := int(ce.ContentHeight()) / ce.lineHeight
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// gutterWidth returns the width of the line number gutter in pixels.
+func (ce *CodeEditor) gutterWidth() float32 {
+
+	if !ce.LineNumbers {
+		return 0
+	}
+	digits := len(strconv.Itoa(ce.buf.lineCount()))
+	if digits < 3 {
+		digits = 3
+	}
+	return float32(digits+1) * ce.advance
+}
+
+// visualCol returns the visual column of the specified position, expanding tabs.
+func (ce *CodeEditor) visualCol(p TextPos) int {
+
+	return ce.lineVisualCol(ce.buf.line(p.Line), p.Col)
+}
+
+// lineVisualCol returns the visual column of the specified column of a line, expanding tabs.
+func (ce *CodeEditor) lineVisualCol(line []rune, col int) int {
+
+	vcol := 0
+	for i := 0; i < col && i < len(line); i++ {
+		if line[i] == '\t' {
+			vcol += ce.TabSize - vcol%ce.TabSize
+		} else {
+			vcol++
+		}
+	}
+	return vcol
+}
+
+// colAt returns the column of the specified line nearest to the specified visual column.
+func (ce *CodeEditor) colAt(line, vcol int) int {
+
+	l := ce.buf.line(line)
+	v := 0
+	for i, r := range l {
+		w := 1
+		if r == '\t' {
+			w = ce.TabSize - v%ce.TabSize
+		}
+		if vcol < v+(w+1)/2 {
+			return i
+		}
+		v += w
+	}
+	return len(l)
+}
+
+// posAt returns the text position at the specified window coordinates.
+func (ce *CodeEditor) posAt(wx, wy float32) TextPos {
+
+	cx, cy := ce.ContentCoords(wx, wy)
+	line := ce.first + int(math.Floor(float64(cy)/float64(ce.lineHeight)))
+	line = int(math32.Clamp(float32(line), 0, float32(ce.buf.lineCount()-1)))
+	x := cx - ce.gutterWidth() - codeEditorMargin + ce.scrollX
+	vcol := int(math.Floor(float64(x/ce.advance + 0.5)))
+	return TextPos{line, ce.colAt(line, vcol)}
+}
+
+// scrollToCaret scrolls the editor so the caret is visible.
+func (ce *CodeEditor) scrollToCaret() {
+
+	visible := ce.visibleLines()
+	if ce.caret.Line < ce.first {
+		ce.first = ce.caret.Line
+	} else if ce.caret.Line >= ce.first+visible {
+		ce.first = ce.caret.Line - visible + 1
+	}
+	textWidth := ce.ContentWidth() - ce.gutterWidth() - codeEditorMargin*2
+	x := float32(ce.visualCol(ce.caret)) * ce.advance
+	if x < ce.scrollX {
+		ce.scrollX = x
+	} else if x > ce.scrollX+textWidth {
+		ce.scrollX = x - textWidth
+	}
+}
+
+// lineState returns the highlighter state at the start of the specified line,
+// highlighting the preceding lines whose state is not cached.
+func (ce *CodeEditor) lineState(line int) int {
+
+	if len(ce.states) == 0 {
+		ce.states = append(ce.states, 0)
+	}
+	for len(ce.states) <= line {
+		i := len(ce.states) - 1
+		var state int
+		ce.tokens, state = ce.hl.HighlightLine(ce.buf.line(i), ce.states[i], ce.tokens)
+		ce.states = append(ce.states, state)
+	}
+	return ce.states[line]
+}
+
+// expandTabs returns the specified runes with tabs expanded to spaces starting at the specified visual column.
+func (ce *CodeEditor) expandTabs(runes []rune, vcol int) string {
+
+	var sb strings.Builder
+	for _, r := range runes {
+		if r == '\t' {
+			n := ce.TabSize - vcol%ce.TabSize
+			sb.WriteString(strings.Repeat(" ", n))
+			vcol += n
+			continue
+		}
+		sb.WriteRune(r)
+		vcol++
+	}
+	return sb.String()
+}
+
+// redraw renders the visible text to the panel texture.
+func (ce *CodeEditor) redraw() {
+
+	width := int(ce.ContentWidth())
+	height := int(ce.ContentHeight())
+	if width <= 0 || height <= 0 || ce.style == nil {
+		return
+	}
+	if ce.img == nil || ce.img.Rect.Dx() != width || ce.img.Rect.Dy() != height {
+		ce.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	s := ce.style
+	fillRect(ce.img, ce.img.Rect, &s.BgColor)
+	ce.font.SetAttributes(&ce.attrib)
+
+	gutter := ce.gutterWidth()
+	textX := gutter + codeEditorMargin - ce.scrollX
+	from, to := ce.Selection()
+	visible := ce.visibleLines() + 1
+	for i := ce.first; i < ce.buf.lineCount() && i < ce.first+visible; i++ {
+		y := (i - ce.first) * ce.lineHeight
+		line := ce.buf.line(i)
+		lineRect := image.Rect(int(gutter), y, width, y+ce.lineHeight)
+
+		// Current line and selection backgrounds
+		if ce.focus && from == to && i == ce.caret.Line {
+			fillRect(ce.img, lineRect, &s.CurrentLineColor)
+		}
+		if from != to && i >= from.Line && i <= to.Line {
+			x0, x1 := float32(0), float32(ce.visualCol(TextPos{i, len(line)})+1)*ce.advance
+			if i == from.Line {
+				x0 = float32(ce.visualCol(from)) * ce.advance
+			}
+			if i == to.Line {
+				x1 = float32(ce.visualCol(to)) * ce.advance
+			}
+			r := image.Rect(int(textX+x0), y, int(textX+x1), y+ce.lineHeight).Intersect(lineRect)
+			fillRect(ce.img, r, &s.SelectionColor)
+		}
+
+		// Text runs with the colors of their tokens
+		tokens := ce.tokens[:0]
+		if ce.hl != nil {
+			state := ce.lineState(i)
+			tokens, _ = ce.hl.HighlightLine(line, state, ce.tokens)
+			ce.tokens = tokens
+		}
+		col := 0
+		for _, tok := range tokens {
+			ce.drawRun(line, col, tok.Start, y, textX, &s.FgColor)
+			color, ok := s.TokenColors[tok.Kind]
+			if !ok {
+				color = s.FgColor
+			}
+			ce.drawRun(line, tok.Start, tok.End, y, textX, &color)
+			col = tok.End
+		}
+		ce.drawRun(line, col, len(line), y, textX, &s.FgColor)
+
+		// Line number
+		if ce.LineNumbers {
+			fillRect(ce.img, image.Rect(0, y, int(gutter), y+ce.lineHeight), &s.GutterColor)
+			num := strconv.Itoa(i + 1)
+			nx := int(gutter - float32(len(num))*ce.advance - ce.advance/2)
+			ce.font.SetColor(&s.LineNumberColor)
+			ce.font.DrawTextOnImage(num, nx, y, ce.img)
+		}
+	}
+	if ce.LineNumbers {
+		fillRect(ce.img, image.Rect(0, (ce.buf.lineCount()-ce.first)*ce.lineHeight, int(gutter), height), &s.GutterColor)
+	}
+
+	// Caret
+	if ce.focus && ce.caretOn && ce.caret.Line >= ce.first && ce.caret.Line < ce.first+visible {
+		x := int(textX + float32(ce.visualCol(ce.caret))*ce.advance)
+		y := (ce.caret.Line - ce.first) * ce.lineHeight
+		if float32(x) >= gutter {
+			fillRect(ce.img, image.Rect(x, y, x+2, y+ce.lineHeight), &s.CaretColor)
+		}
+	}
+
+	if ce.tex == nil {
+		ce.tex = texture.NewTexture2DFromRGBA(ce.img)
+		ce.tex.SetMagFilter(gls.NEAREST)
+		ce.tex.SetMinFilter(gls.NEAREST)
+		ce.Material().AddTexture(ce.tex)
+	} else {
+		ce.tex.SetFromRGBA(ce.img)
+	}
+}
+
+// drawRun draws the specified columns of a line with the specified color.
+func (ce *CodeEditor) drawRun(line []rune, start, end, y int, textX float32, color *math32.Color4) {
+
+	if start >= end {
+		return
+	}
+	vcol := ce.lineVisualCol(line, start)
+	x := textX + float32(vcol)*ce.advance
+	if x > float32(ce.img.Rect.Dx()) {
+		return
+	}
+	ce.font.SetColor(color)
+	ce.font.DrawTextOnImage(ce.expandTabs(line[start:end], vcol), int(x), y, ce.img)
+}
+
+// fillRect fills the specified rectangle of the image with the specified color.
+func fillRect(img *image.RGBA, r image.Rectangle, color *math32.Color4) {
+
+	draw.Draw(img, r, image.NewUniform(text.Color4RGBA(color)), image.ZP, draw.Over)
+}
+
+// update updates the visual state.
+func (ce *CodeEditor) update() {
+
+	switch {
+	case !ce.Enabled():
+		ce.applyStyle(&ce.styles.Disabled)
+	case ce.focus:
+		ce.applyStyle(&ce.styles.Focus)
+	case ce.cursorOver:
+		ce.applyStyle(&ce.styles.Over)
+	default:
+		ce.applyStyle(&ce.styles.Normal)
+	}
+}
+
+// applyStyle applies the specified style.
+func (ce *CodeEditor) applyStyle(s *CodeEditorStyle) {
+
+	ce.style = s
+	ce.SetBordersFrom(&s.Border)
+	ce.SetBordersColor4(&s.BorderColor)
+	ce.SetPaddingsFrom(&s.Paddings)
+	ce.redraw()
+}
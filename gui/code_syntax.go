@@ -0,0 +1,250 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// TokenKind identifies the kind of a highlighted token.
+type TokenKind int
+
+// The token kinds
+const (
+	TokenText = TokenKind(iota)
+	TokenKeyword
+	TokenType
+	TokenBuiltin
+	TokenNumber
+	TokenString
+	TokenComment
+	TokenPreprocessor
+)
+
+// Token is a highlighted range of a line.
+type Token struct {
+	Start int       // First column of the token
+	End   int       // Column after the last one of the token
+	Kind  TokenKind // Kind of the token
+}
+
+// Highlighter splits lines of code into highlighted tokens.
+// The state is returned for each line and passed to the highlighting of the next one,
+// so constructs spanning several lines, such as block comments, can be recognized.
+// The first line is highlighted with state 0.
+type Highlighter interface {
+	HighlightLine(line []rune, state int, tokens []Token) ([]Token, int)
+}
+
+// Syntax is a configurable Highlighter for languages with C-like lexical structure.
+type Syntax struct {
+	Keywords     map[string]bool // Language keywords
+	Types        map[string]bool // Built-in type names
+	Builtins     map[string]bool // Built-in functions and variables
+	LineComment  string          // Start of comments which end at the end of the line
+	BlockStart   string          // Start of block comments
+	BlockEnd     string          // End of block comments
+	Quotes       string          // Characters which delimit strings
+	Preprocessor rune            // Character which starts preprocessor lines when first on the line
+}
+
+// Highlighter states
+const (
+	syntaxNormal = iota
+	syntaxBlockComment
+)
+
+// HighlightLine satisfies the Highlighter interface.
+func (s *Syntax) HighlightLine(line []rune, state int, tokens []Token) ([]Token, int) {
+
+	tokens = tokens[:0]
+	i := 0
+	n := len(line)
+
+	// Continue block comment from the previous line
+	if state == syntaxBlockComment {
+		end := indexRunes(line, 0, s.BlockEnd)
+		if end < 0 {
+			return append(tokens, Token{0, n, TokenComment}), syntaxBlockComment
+		}
+		i = end + len([]rune(s.BlockEnd))
+		tokens = append(tokens, Token{0, i, TokenComment})
+	}
+
+	// Preprocessor lines
+	if s.Preprocessor != 0 {
+		j := i
+		for j < n && unicode.IsSpace(line[j]) {
+			j++
+		}
+		if j < n && line[j] == s.Preprocessor {
+			end := n
+			if s.LineComment != "" {
+				if c := indexRunes(line, j, s.LineComment); c >= 0 {
+					end = c
+				}
+			}
+			tokens = append(tokens, Token{j, end, TokenPreprocessor})
+			if end < n {
+				tokens = append(tokens, Token{end, n, TokenComment})
+			}
+			return tokens, syntaxNormal
+		}
+	}
+
+	for i < n {
+		r := line[i]
+		switch {
+		case s.LineComment != "" && hasRunesAt(line, i, s.LineComment):
+			return append(tokens, Token{i, n, TokenComment}), syntaxNormal
+		case s.BlockStart != "" && hasRunesAt(line, i, s.BlockStart):
+			start := i
+			end := indexRunes(line, i+len([]rune(s.BlockStart)), s.BlockEnd)
+			if end < 0 {
+				return append(tokens, Token{start, n, TokenComment}), syntaxBlockComment
+			}
+			i = end + len([]rune(s.BlockEnd))
+			tokens = append(tokens, Token{start, i, TokenComment})
+		case strings.ContainsRune(s.Quotes, r):
+			start := i
+			i++
+			for i < n && line[i] != r {
+				if line[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i < n {
+				i++
+			}
+			tokens = append(tokens, Token{start, i, TokenString})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < n && unicode.IsDigit(line[i+1])):
+			start := i
+			for i < n && (isWordRune(line[i]) || line[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, Token{start, i, TokenNumber})
+		case isWordRune(r):
+			start := i
+			for i < n && isWordRune(line[i]) {
+				i++
+			}
+			word := string(line[start:i])
+			switch {
+			case s.Keywords[word]:
+				tokens = append(tokens, Token{start, i, TokenKeyword})
+			case s.Types[word]:
+				tokens = append(tokens, Token{start, i, TokenType})
+			case s.Builtins[word]:
+				tokens = append(tokens, Token{start, i, TokenBuiltin})
+			}
+		default:
+			i++
+		}
+	}
+	return tokens, syntaxNormal
+}
+
+// hasRunesAt returns whether the specified line contains the specified string at the specified column.
+func hasRunesAt(line []rune, col int, s string) bool {
+
+	for _, r := range s {
+		if col >= len(line) || line[col] != r {
+			return false
+		}
+		col++
+	}
+	return true
+}
+
+// indexRunes returns the first column from the specified one where the
+// specified string occurs in the line, or -1 if not found.
+func indexRunes(line []rune, from int, s string) int {
+
+	for i := from; i < len(line); i++ {
+		if hasRunesAt(line, i, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// wordSet returns a set with the space separated words of the specified string.
+func wordSet(words string) map[string]bool {
+
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// GLSLSyntax returns a Syntax for the OpenGL shading language.
+func GLSLSyntax() *Syntax {
+
+	return &Syntax{
+		Keywords: wordSet(`attribute const uniform varying buffer shared coherent volatile restrict readonly
+			writeonly layout centroid flat smooth noperspective patch sample break continue do for while switch
+			case default if else subroutine in out inout invariant precise discard return lowp mediump highp
+			precision struct true false`),
+		Types: wordSet(`void bool int uint float double vec2 vec3 vec4 dvec2 dvec3 dvec4 bvec2 bvec3 bvec4
+			ivec2 ivec3 ivec4 uvec2 uvec3 uvec4 mat2 mat3 mat4 mat2x2 mat2x3 mat2x4 mat3x2 mat3x3 mat3x4
+			mat4x2 mat4x3 mat4x4 sampler1D sampler2D sampler3D samplerCube sampler2DShadow samplerCubeShadow
+			sampler2DArray sampler2DArrayShadow isampler2D usampler2D sampler2DMS samplerBuffer image2D`),
+		Builtins: wordSet(`radians degrees sin cos tan asin acos atan sinh cosh tanh pow exp log exp2 log2 sqrt
+			inversesqrt abs sign floor trunc round ceil fract mod modf min max clamp mix step smoothstep isnan
+			isinf length distance dot cross normalize faceforward reflect refract matrixCompMult outerProduct
+			transpose determinant inverse lessThan lessThanEqual greaterThan greaterThanEqual equal notEqual
+			any all not texture textureLod textureOffset texelFetch textureSize textureGrad dFdx dFdy fwidth
+			gl_Position gl_FragCoord gl_FragDepth gl_PointSize gl_VertexID gl_InstanceID gl_FrontFacing
+			gl_PointCoord gl_FragColor`),
+		LineComment:  "//",
+		BlockStart:   "/*",
+		BlockEnd:     "*/",
+		Quotes:       `"`,
+		Preprocessor: '#',
+	}
+}
+
+// GoSyntax returns a Syntax for the Go language.
+func GoSyntax() *Syntax {
+
+	return &Syntax{
+		Keywords: wordSet(`break case chan const continue default defer else fallthrough for func go goto if
+			import interface map package range return select struct switch type var true false nil iota`),
+		Types: wordSet(`bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune
+			string uint uint8 uint16 uint32 uint64 uintptr`),
+		Builtins:    wordSet(`append cap close complex copy delete imag len make new panic print println real recover`),
+		LineComment: "//",
+		BlockStart:  "/*",
+		BlockEnd:    "*/",
+		Quotes:      "\"'`",
+	}
+}
+
+// Highlighters by file extension
+var syntaxes = map[string]Highlighter{
+	".glsl": GLSLSyntax(),
+	".vert": GLSLSyntax(),
+	".frag": GLSLSyntax(),
+	".geom": GLSLSyntax(),
+	".go":   GoSyntax(),
+}
+
+// RegisterSyntax registers the Highlighter to be used for files with the specified extension,
+// such as ".lua", replacing any previous one.
+func RegisterSyntax(ext string, h Highlighter) {
+
+	syntaxes[strings.ToLower(ext)] = h
+}
+
+// SyntaxForFile returns the registered Highlighter for the extension of the
+// specified file name or nil if there is none.
+func SyntaxForFile(name string) Highlighter {
+
+	return syntaxes[strings.ToLower(filepath.Ext(name))]
+}
@@ -0,0 +1,581 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/g3n/engine/gui/assets/icon"
+	"github.com/g3n/engine/window"
+)
+
+// NativeDialogs specifies whether the dialog functions use the native dialogs of the platform when
+// they are available. If false, or if the platform has none, the in-engine dialogs are used.
+var NativeDialogs = true
+
+// errNoNativeDialog is returned by the native dialog implementations when
+// the requested dialog is not available and the in-engine one must be used.
+var errNoNativeDialog = errors.New("native dialog not available")
+
+// FileDialogMode specifies the kind of file dialog.
+type FileDialogMode int
+
+// The file dialog modes
+const (
+	FileDialogOpen   = FileDialogMode(iota) // Select one or more existing files
+	FileDialogSave                          // Select the name of a file to write
+	FileDialogFolder                        // Select an existing folder
+)
+
+// FileFilter restricts the files shown by a file dialog.
+type FileFilter struct {
+	Name     string   // Description shown to the user, such as "Images"
+	Patterns []string // Glob patterns matched against file names, such as "*.png"
+}
+
+// Match returns whether the specified file name matches any of the patterns of the filter.
+// A filter without patterns matches all files.
+func (f *FileFilter) Match(name string) bool {
+
+	if len(f.Patterns) == 0 {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, pat := range f.Patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pat), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// label returns the filter name followed by its patterns.
+func (f *FileFilter) label() string {
+
+	if len(f.Patterns) == 0 {
+		return f.Name
+	}
+	return f.Name + " (" + strings.Join(f.Patterns, ", ") + ")"
+}
+
+// FileDialogOptions contains the options of a file dialog.
+type FileDialogOptions struct {
+	Title     string       // Dialog title
+	Dir       string       // Initial folder (the working directory if empty)
+	File      string       // Initial file name for save dialogs
+	Filters   []FileFilter // File filters; the first one is initially selected
+	Multiple  bool         // Whether open dialogs allow selecting several files
+	Overwrite bool         // Whether save dialogs accept existing files without asking
+}
+
+// MessageKind specifies the icon shown by a message box.
+type MessageKind int
+
+// The message box kinds
+const (
+	MessageInfo = MessageKind(iota)
+	MessageWarning
+	MessageError
+	MessageQuestion
+)
+
+// OpenFileDialog shows a dialog to select existing files and calls the specified function with the
+// selected paths, or with nil if the dialog was cancelled.
+// Native dialogs block until closed, so the function may be called before OpenFileDialog returns.
+func OpenFileDialog(opts *FileDialogOptions, cb func(paths []string)) {
+
+	showFileDialog(FileDialogOpen, opts, cb)
+}
+
+// SaveFileDialog shows a dialog to select the name of a file to write and calls the specified
+// function with the selected path, or with an empty string if the dialog was cancelled.
+func SaveFileDialog(opts *FileDialogOptions, cb func(path string)) {
+
+	showFileDialog(FileDialogSave, opts, func(paths []string) { cb(firstPath(paths)) })
+}
+
+// FolderDialog shows a dialog to select an existing folder and calls the specified
+// function with the selected path, or with an empty string if the dialog was cancelled.
+func FolderDialog(opts *FileDialogOptions, cb func(path string)) {
+
+	showFileDialog(FileDialogFolder, opts, func(paths []string) { cb(firstPath(paths)) })
+}
+
+// MessageBox shows a message box with the specified buttons and calls the specified function with
+// the index of the clicked button, or -1 if it was closed without clicking a button.
+// If no buttons are specified a single "OK" button is shown.
+func MessageBox(kind MessageKind, title, msg string, buttons []string, cb func(button int)) {
+
+	if len(buttons) == 0 {
+		buttons = []string{"OK"}
+	}
+	if NativeDialogs {
+		button, err := nativeMessageBox(kind, title, msg, buttons)
+		if err == nil {
+			if cb != nil {
+				cb(button)
+			}
+			return
+		}
+		if err != errNoNativeDialog {
+			log.Warn("native message box: %v", err)
+		}
+	}
+	md := NewMessageDialog(kind, title, msg, buttons)
+	md.cb = cb
+	md.Show()
+}
+
+// showFileDialog shows a native or in-engine file dialog.
+func showFileDialog(mode FileDialogMode, opts *FileDialogOptions, cb func(paths []string)) {
+
+	if opts == nil {
+		opts = &FileDialogOptions{}
+	}
+	if NativeDialogs {
+		paths, err := nativeFileDialog(mode, opts)
+		if err == nil {
+			if cb != nil {
+				cb(paths)
+			}
+			return
+		}
+		if err != errNoNativeDialog {
+			log.Warn("native file dialog: %v", err)
+		}
+	}
+	fd := NewFileDialog(mode, opts)
+	fd.cb = cb
+	fd.Show()
+}
+
+// firstPath returns the first of the specified paths or an empty string.
+func firstPath(paths []string) string {
+
+	if len(paths) == 0 {
+		return ""
+	}
+	return paths[0]
+}
+
+// showModal adds the specified dialog centered over the GUI root and makes it modal.
+func showModal(ipan IPanel) {
+
+	root := Manager().scene
+	if root == nil {
+		log.Error("dialog: the GUI manager has no scene")
+		return
+	}
+	p := ipan.GetPanel()
+	width, height := window.Get().GetSize()
+	p.SetPosition((float32(width)-p.Width())/2, (float32(height)-p.Height())/2)
+	p.SetZLayerDelta(100)
+	root.GetNode().Add(ipan)
+	Manager().SetModal(ipan)
+}
+
+// closeModal removes the specified modal dialog from the GUI root and disposes it.
+func closeModal(ipan IPanel) {
+
+	if Manager().modal == ipan {
+		Manager().SetModal(nil)
+	}
+	if parent := ipan.Parent(); parent != nil {
+		parent.GetNode().Remove(ipan)
+	}
+	ipan.Dispose()
+}
+
+// MessageDialog is the in-engine message box.
+type MessageDialog struct {
+	Window            // Embedded window
+	icon    *Label    // Kind icon
+	msg     *Label    // Message text
+	buttons []*Button // Buttons in order
+	cb      func(int) // Function called with the clicked button
+	done    bool      // Whether the dialog was closed
+}
+
+// NewMessageDialog creates and returns a pointer to a new in-engine message dialog.
+// Most code should call MessageBox instead, which prefers the native message box of the platform.
+func NewMessageDialog(kind MessageKind, title, msg string, buttons []string) *MessageDialog {
+
+	md := new(MessageDialog)
+	md.Window.Initialize(md, 0, 0)
+	md.SetTitle(title)
+	md.Subscribe(OnWindowClose, func(evname string, ev interface{}) { md.finish(-1, false) })
+
+	md.icon = NewIcon(messageIcons[kind])
+	md.icon.SetFontSize(StyleDefault().Label.PointSize * 2.5)
+	md.Add(md.icon)
+	md.msg = NewLabel(msg)
+	md.Add(md.msg)
+	for i, text := range buttons {
+		index := i
+		b := NewButton(text)
+		b.Subscribe(OnClick, func(evname string, ev interface{}) { md.finish(index, true) })
+		md.buttons = append(md.buttons, b)
+		md.Add(b)
+	}
+	md.layout()
+	return md
+}
+
+// Icons of the message kinds
+var messageIcons = map[MessageKind]string{
+	MessageInfo:     icon.Info,
+	MessageWarning:  icon.Warning,
+	MessageError:    icon.Error,
+	MessageQuestion: icon.HelpOutline,
+}
+
+// Show shows the dialog centered in the window as a modal panel.
+func (md *MessageDialog) Show() {
+
+	showModal(md)
+}
+
+// layout sets the positions of the internal panels and the size of the dialog.
+func (md *MessageDialog) layout() {
+
+	const pad = 12
+	md.icon.SetPosition(pad, pad)
+	md.msg.SetPosition(pad*2+md.icon.Width(), pad)
+	width := md.msg.Position().X + md.msg.Width() + pad
+	height := pad + md.msg.Height()
+	if md.icon.Height() > md.msg.Height() {
+		height = pad + md.icon.Height()
+	}
+
+	bwidth := float32(0)
+	for _, b := range md.buttons {
+		bwidth += b.Width() + pad/2
+	}
+	if bwidth+pad*2 > width {
+		width = bwidth + pad*2
+	}
+	x := width - pad - bwidth + pad/2
+	for _, b := range md.buttons {
+		b.SetPosition(x, height+pad)
+		x += b.Width() + pad/2
+	}
+	if len(md.buttons) > 0 {
+		height += pad + md.buttons[0].Height()
+	}
+	height += pad
+	if md.title != nil {
+		height += md.title.Height()
+	}
+	md.SetContentSize(width, height)
+}
+
+// finish closes the dialog and calls the callback with the specified button.
+func (md *MessageDialog) finish(button int, close bool) {
+
+	if md.done {
+		return
+	}
+	md.done = true
+	if close {
+		closeModal(md)
+	} else if Manager().modal == IPanel(md) {
+		Manager().SetModal(nil)
+	}
+	if md.cb != nil {
+		md.cb(button)
+	}
+}
+
+// FileDialog is the in-engine file dialog.
+type FileDialog struct {
+	Window                         // Embedded window
+	mode    FileDialogMode         // Dialog mode
+	opts    FileDialogOptions      // Dialog options
+	dir     string                 // Current folder
+	up      *Button                // Button to go to the parent folder
+	path    *Edit                  // Current folder path
+	list    *List                  // Folder entries
+	name    *Edit                  // Selected file name
+	filter  *DropDown              // Filter selection (nil without filters)
+	ok      *Button                // Accept button
+	cancel  *Button                // Cancel button
+	entries map[IPanel]os.FileInfo // Folder entry of each list item
+	cb      func([]string)         // Function called with the selected paths
+	done    bool                   // Whether the dialog was closed
+}
+
+// Size of the in-engine file dialog
+const fileDialogWidth, fileDialogHeight = 520, 380
+
+// NewFileDialog creates and returns a pointer to a new in-engine file dialog.
+// Most code should call OpenFileDialog, SaveFileDialog or FolderDialog instead,
+// which prefer the native dialogs of the platform.
+func NewFileDialog(mode FileDialogMode, opts *FileDialogOptions) *FileDialog {
+
+	fd := new(FileDialog)
+	fd.mode = mode
+	if opts != nil {
+		fd.opts = *opts
+	}
+	fd.Window.Initialize(fd, fileDialogWidth, fileDialogHeight)
+	title := fd.opts.Title
+	if title == "" {
+		title = [...]string{"Open", "Save", "Select Folder"}[mode]
+	}
+	fd.SetTitle(title)
+	fd.Subscribe(OnWindowClose, func(evname string, ev interface{}) { fd.finish(nil, false) })
+
+	fd.up = NewButton("")
+	fd.up.SetIcon(icon.ArrowUpward)
+	fd.up.Subscribe(OnClick, func(evname string, ev interface{}) { fd.SetDir(filepath.Dir(fd.dir)) })
+	fd.Add(fd.up)
+	fd.path = NewEdit(0, "")
+	fd.path.Subscribe(OnKeyDown, func(evname string, ev interface{}) {
+		if ev.(*window.KeyEvent).Key == window.KeyEnter {
+			fd.SetDir(fd.path.Text())
+		}
+	})
+	fd.Add(fd.path)
+
+	fd.list = NewVList(0, 0)
+	fd.list.SetSingle(!(mode == FileDialogOpen && fd.opts.Multiple))
+	fd.list.Subscribe(OnChange, fd.onSelect)
+	fd.Add(fd.list)
+
+	fd.name = NewEdit(0, "")
+	fd.name.SetText(fd.opts.File)
+	fd.name.Subscribe(OnKeyDown, func(evname string, ev interface{}) {
+		if ev.(*window.KeyEvent).Key == window.KeyEnter {
+			fd.accept()
+		}
+	})
+	fd.Add(fd.name)
+
+	if len(fd.opts.Filters) > 0 {
+		fd.filter = NewDropDown(0, NewImageLabel(fd.opts.Filters[0].label()))
+		for i := range fd.opts.Filters {
+			fd.filter.Add(NewImageLabel(fd.opts.Filters[i].label()))
+		}
+		fd.filter.SelectPos(0)
+		fd.filter.Subscribe(OnChange, func(evname string, ev interface{}) { fd.SetDir(fd.dir) })
+		fd.Add(fd.filter)
+	}
+
+	fd.ok = NewButton([...]string{"Open", "Save", "Select"}[mode])
+	fd.ok.Subscribe(OnClick, func(evname string, ev interface{}) { fd.accept() })
+	fd.Add(fd.ok)
+	fd.cancel = NewButton("Cancel")
+	fd.cancel.Subscribe(OnClick, func(evname string, ev interface{}) { fd.finish(nil, true) })
+	fd.Add(fd.cancel)
+
+	fd.layout()
+	dir := fd.opts.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	fd.SetDir(dir)
+	return fd
+}
+
+// Show shows the dialog centered in the window as a modal panel.
+func (fd *FileDialog) Show() {
+
+	showModal(fd)
+}
+
+// Dir returns the folder currently shown.
+func (fd *FileDialog) Dir() string {
+
+	return fd.dir
+}
+
+// SetDir shows the contents of the specified folder, keeping the current one if it cannot be read.
+func (fd *FileDialog) SetDir(dir string) {
+
+	dir, err := filepath.Abs(dir)
+	if err == nil {
+		var infos []os.FileInfo
+		infos, err = ioutil.ReadDir(dir)
+		if err == nil {
+			fd.dir = dir
+			fd.fill(infos)
+		}
+	}
+	if err != nil {
+		log.Warn("file dialog: %v", err)
+	}
+	fd.path.SetText(fd.dir)
+}
+
+// fill fills the list with the specified folder entries,
+// folders first, which pass the current filter.
+func (fd *FileDialog) fill(infos []os.FileInfo) {
+
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].IsDir() && !infos[j].IsDir() })
+	fd.list.Clear()
+	fd.entries = make(map[IPanel]os.FileInfo)
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		if !info.IsDir() {
+			if fd.mode == FileDialogFolder {
+				continue
+			}
+			if f := fd.currentFilter(); f != nil && !f.Match(info.Name()) {
+				continue
+			}
+		}
+		item := NewImageLabel(info.Name())
+		if info.IsDir() {
+			item.SetIcon(icon.Folder)
+		} else {
+			item.SetIcon(icon.InsertDriveFile)
+		}
+		fd.entries[item] = info
+		fd.list.Add(item)
+	}
+}
+
+// currentFilter returns the selected filter or nil.
+func (fd *FileDialog) currentFilter() *FileFilter {
+
+	if fd.filter == nil || fd.filter.SelectedPos() < 0 {
+		return nil
+	}
+	return &fd.opts.Filters[fd.filter.SelectedPos()]
+}
+
+// onSelect enters selected folders and copies the names of selected files to the name edit.
+func (fd *FileDialog) onSelect(evname string, ev interface{}) {
+
+	var names []string
+	for _, item := range fd.list.Selected() {
+		info := fd.entries[item]
+		if info == nil {
+			continue
+		}
+		if info.IsDir() && fd.mode != FileDialogFolder {
+			fd.SetDir(filepath.Join(fd.dir, info.Name()))
+			return
+		}
+		names = append(names, info.Name())
+	}
+	fd.name.SetText(strings.Join(names, "; "))
+}
+
+// accept validates the selection and closes the dialog if it is acceptable.
+func (fd *FileDialog) accept() {
+
+	text := strings.TrimSpace(fd.name.Text())
+	switch fd.mode {
+	case FileDialogFolder:
+		if text == "" {
+			fd.finish([]string{fd.dir}, true)
+			return
+		}
+		fd.finish([]string{fd.resolve(text)}, true)
+	case FileDialogOpen:
+		var paths []string
+		for _, name := range strings.Split(text, ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				paths = append(paths, fd.resolve(name))
+			}
+		}
+		if len(paths) == 0 {
+			return
+		}
+		if info, err := os.Stat(paths[0]); err == nil && info.IsDir() {
+			fd.SetDir(paths[0])
+			fd.name.SetText("")
+			return
+		}
+		fd.finish(paths, true)
+	case FileDialogSave:
+		if text == "" {
+			return
+		}
+		path := fd.resolve(text)
+		info, err := os.Stat(path)
+		if err == nil && info.IsDir() {
+			fd.SetDir(path)
+			fd.name.SetText("")
+			return
+		}
+		if err != nil || fd.opts.Overwrite {
+			fd.finish([]string{path}, true)
+			return
+		}
+		md := NewMessageDialog(MessageQuestion, "Confirm", filepath.Base(path)+" already exists.\nDo you want to replace it?",
+			[]string{"Replace", "Cancel"})
+		md.cb = func(button int) {
+			Manager().SetModal(fd)
+			if button == 0 {
+				fd.finish([]string{path}, true)
+			}
+		}
+		md.Show()
+	}
+}
+
+// resolve returns the path of the specified name relative to the current folder.
+func (fd *FileDialog) resolve(name string) string {
+
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(fd.dir, name)
+}
+
+// layout sets the positions and sizes of the internal panels.
+func (fd *FileDialog) layout() {
+
+	const pad = 8
+	width := fd.client.ContentWidth()
+	height := fd.client.ContentHeight()
+
+	fd.up.SetPosition(pad, pad)
+	fd.path.SetPosition(pad*2+fd.up.Width(), pad)
+	fd.path.SetWidth(width - fd.path.Position().X - pad)
+	fd.path.SetHeight(fd.up.Height())
+
+	by := height - pad - fd.ok.Height()
+	fd.cancel.SetPosition(width-pad-fd.cancel.Width(), by)
+	fd.ok.SetPosition(fd.cancel.Position().X-pad-fd.ok.Width(), by)
+	if fd.filter != nil {
+		fd.filter.SetPosition(pad, by)
+		fd.filter.SetWidth(fd.ok.Position().X - pad*2)
+	}
+	ny := by - pad - fd.ok.Height()
+	fd.name.SetPosition(pad, ny)
+	fd.name.SetWidth(width - pad*2)
+	fd.name.SetHeight(fd.ok.Height())
+
+	ly := fd.up.Position().Y + fd.up.Height() + pad
+	fd.list.SetPosition(pad, ly)
+	fd.list.SetSize(width-pad*2, ny-pad-ly)
+}
+
+// finish closes the dialog and calls the callback with the specified paths.
+func (fd *FileDialog) finish(paths []string, close bool) {
+
+	if fd.done {
+		return
+	}
+	fd.done = true
+	if close {
+		closeModal(fd)
+	} else if Manager().modal == IPanel(fd) {
+		Manager().SetModal(nil)
+	}
+	if fd.cb != nil {
+		fd.cb(paths)
+	}
+}
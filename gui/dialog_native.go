@@ -0,0 +1,423 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !wasm
+
+package gui
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// The native dialogs are shown by running the dialog tool of the platform:
+// zenity or kdialog on Linux and BSD, osascript on macOS and PowerShell on Windows.
+// They block until the user closes them.
+
+// nativeFileDialog shows the native file dialog of the platform and returns the selected paths.
+func nativeFileDialog(mode FileDialogMode, opts *FileDialogOptions) ([]string, error) {
+
+	switch runtime.GOOS {
+	case "darwin":
+		return osascriptFileDialog(mode, opts)
+	case "windows":
+		return powershellFileDialog(mode, opts)
+	default:
+		if _, err := exec.LookPath("zenity"); err == nil {
+			return zenityFileDialog(mode, opts)
+		}
+		return kdialogFileDialog(mode, opts)
+	}
+}
+
+// nativeMessageBox shows the native message box of the platform and returns the clicked button.
+func nativeMessageBox(kind MessageKind, title, msg string, buttons []string) (int, error) {
+
+	switch runtime.GOOS {
+	case "darwin":
+		return osascriptMessageBox(kind, title, msg, buttons)
+	case "windows":
+		return powershellMessageBox(kind, title, msg, buttons)
+	default:
+		if _, err := exec.LookPath("zenity"); err == nil {
+			return zenityMessageBox(kind, title, msg, buttons)
+		}
+		return kdialogMessageBox(kind, title, msg, buttons)
+	}
+}
+
+// runDialog runs the specified dialog tool and returns its trimmed output and exit code.
+// It returns errNoNativeDialog if the tool is not installed.
+func runDialog(name string, args ...string) (string, int, error) {
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", 0, errNoNativeDialog
+	}
+	out, err := exec.Command(path, args...).Output()
+	code := 0
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return "", 0, err
+		}
+		code = exitErr.ExitCode()
+	}
+	return strings.TrimRight(string(out), "\r\n"), code, nil
+}
+
+// splitPaths splits the output of a dialog tool into paths, one per line.
+func splitPaths(out string) []string {
+
+	var paths []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
+
+// initialDir returns the absolute initial folder of a dialog.
+func initialDir(opts *FileDialogOptions) string {
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return dir
+}
+
+// initialPath returns the initial folder of a dialog joined with its initial file name.
+func initialPath(opts *FileDialogOptions) string {
+
+	return filepath.Join(initialDir(opts), opts.File)
+}
+
+// zenityFileDialog shows a file dialog using zenity.
+func zenityFileDialog(mode FileDialogMode, opts *FileDialogOptions) ([]string, error) {
+
+	args := []string{"--file-selection", "--separator=\n"}
+	if opts.Title != "" {
+		args = append(args, "--title="+opts.Title)
+	}
+	start := initialPath(opts)
+	if opts.File == "" {
+		start += string(filepath.Separator)
+	}
+	args = append(args, "--filename="+start)
+	switch mode {
+	case FileDialogOpen:
+		if opts.Multiple {
+			args = append(args, "--multiple")
+		}
+	case FileDialogSave:
+		args = append(args, "--save")
+		if !opts.Overwrite {
+			args = append(args, "--confirm-overwrite")
+		}
+	case FileDialogFolder:
+		args = append(args, "--directory")
+	}
+	if mode != FileDialogFolder {
+		for _, f := range opts.Filters {
+			patterns := strings.Join(f.Patterns, " ")
+			if patterns == "" {
+				patterns = "*"
+			}
+			args = append(args, "--file-filter="+f.Name+" | "+patterns)
+		}
+	}
+	out, code, err := runDialog("zenity", args...)
+	if err != nil || code != 0 {
+		return nil, err
+	}
+	return splitPaths(out), nil
+}
+
+// zenityMessageBox shows a message box with up to two buttons using zenity.
+func zenityMessageBox(kind MessageKind, title, msg string, buttons []string) (int, error) {
+
+	if len(buttons) > 2 {
+		return 0, errNoNativeDialog
+	}
+	args := []string{"--title=" + title, "--text=" + msg, "--ok-label=" + buttons[0]}
+	if len(buttons) == 2 {
+		args = append(args, "--question", "--cancel-label="+buttons[1])
+	} else {
+		args = append(args, [...]string{"--info", "--warning", "--error", "--info"}[kind])
+	}
+	_, code, err := runDialog("zenity", args...)
+	if err != nil {
+		return 0, err
+	}
+	if code == 0 {
+		return 0, nil
+	}
+	if code == 1 && len(buttons) == 2 {
+		return 1, nil
+	}
+	return -1, nil
+}
+
+// kdialogFileDialog shows a file dialog using kdialog.
+func kdialogFileDialog(mode FileDialogMode, opts *FileDialogOptions) ([]string, error) {
+
+	var filters []string
+	for _, f := range opts.Filters {
+		patterns := strings.Join(f.Patterns, " ")
+		if patterns == "" {
+			patterns = "*"
+		}
+		filters = append(filters, f.Name+" ("+patterns+")")
+	}
+	var args []string
+	switch mode {
+	case FileDialogOpen:
+		args = append(args, "--getopenfilename", initialPath(opts), strings.Join(filters, "|"))
+		if opts.Multiple {
+			args = append(args, "--multiple", "--separate-output")
+		}
+	case FileDialogSave:
+		args = append(args, "--getsavefilename", initialPath(opts), strings.Join(filters, "|"))
+	case FileDialogFolder:
+		args = append(args, "--getexistingdirectory", initialDir(opts))
+	}
+	if opts.Title != "" {
+		args = append(args, "--title", opts.Title)
+	}
+	out, code, err := runDialog("kdialog", args...)
+	if err != nil || code != 0 {
+		return nil, err
+	}
+	return splitPaths(out), nil
+}
+
+// kdialogMessageBox shows a message box with up to three buttons using kdialog.
+func kdialogMessageBox(kind MessageKind, title, msg string, buttons []string) (int, error) {
+
+	var args []string
+	switch len(buttons) {
+	case 1:
+		args = []string{[...]string{"--msgbox", "--sorry", "--error", "--msgbox"}[kind], msg}
+	case 2:
+		args = []string{"--yesno", msg, "--yes-label", buttons[0], "--no-label", buttons[1]}
+	case 3:
+		args = []string{"--yesnocancel", msg, "--yes-label", buttons[0], "--no-label", buttons[1], "--cancel-label", buttons[2]}
+	default:
+		return 0, errNoNativeDialog
+	}
+	args = append(args, "--title", title)
+	_, code, err := runDialog("kdialog", args...)
+	if err != nil {
+		return 0, err
+	}
+	if code < len(buttons) {
+		return code, nil
+	}
+	return -1, nil
+}
+
+// appleString returns the specified string as an AppleScript string literal.
+func appleString(s string) string {
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// appleTypes returns the AppleScript literals of the file extensions of the specified filters.
+// AppleScript only supports a single list of extensions, so it returns nil if any filter
+// has a pattern which is not a simple extension or has no patterns.
+func appleTypes(filters []FileFilter) []string {
+
+	var types []string
+	for _, f := range filters {
+		if len(f.Patterns) == 0 {
+			return nil
+		}
+		for _, pat := range f.Patterns {
+			if !strings.HasPrefix(pat, "*.") || strings.ContainsAny(pat[2:], "*?[") {
+				return nil
+			}
+			types = append(types, appleString(pat[2:]))
+		}
+	}
+	return types
+}
+
+// osascriptFileDialog shows a file dialog using AppleScript.
+func osascriptFileDialog(mode FileDialogMode, opts *FileDialogOptions) ([]string, error) {
+
+	var script strings.Builder
+	prompt := ""
+	if opts.Title != "" {
+		prompt = " with prompt " + appleString(opts.Title)
+	}
+	location := " default location POSIX file " + appleString(initialDir(opts))
+	switch mode {
+	case FileDialogOpen:
+		script.WriteString("set fs to choose file" + prompt + location)
+		types := appleTypes(opts.Filters)
+		if len(types) > 0 {
+			script.WriteString(" of type {" + strings.Join(types, ", ") + "}")
+		}
+		if opts.Multiple {
+			script.WriteString(" with multiple selections allowed")
+		} else {
+			script.WriteString("\nset fs to {fs}")
+		}
+		script.WriteString("\nset out to \"\"\nrepeat with f in fs\nset out to out & POSIX path of f & linefeed\nend repeat\nout")
+	case FileDialogSave:
+		script.WriteString("POSIX path of (choose file name" + prompt + location)
+		if opts.File != "" {
+			script.WriteString(" default name " + appleString(opts.File))
+		}
+		script.WriteString(")")
+	case FileDialogFolder:
+		script.WriteString("POSIX path of (choose folder" + prompt + location + ")")
+	}
+	out, code, err := runDialog("osascript", "-e", script.String())
+	if err != nil || code != 0 {
+		return nil, err
+	}
+	return splitPaths(out), nil
+}
+
+// osascriptMessageBox shows a message box with up to three buttons using AppleScript.
+func osascriptMessageBox(kind MessageKind, title, msg string, buttons []string) (int, error) {
+
+	if len(buttons) > 3 {
+		return 0, errNoNativeDialog
+	}
+	labels := make([]string, len(buttons))
+	for i, b := range buttons {
+		labels[i] = appleString(b)
+	}
+	script := "display dialog " + appleString(msg) + " with title " + appleString(title) +
+		" buttons {" + strings.Join(labels, ", ") + "} default button 1" +
+		[...]string{" with icon note", " with icon caution", " with icon stop", ""}[kind] +
+		"\nbutton returned of result"
+	out, code, err := runDialog("osascript", "-e", script)
+	if err != nil {
+		return 0, err
+	}
+	if code == 0 {
+		for i, b := range buttons {
+			if b == out {
+				return i, nil
+			}
+		}
+	}
+	return -1, nil
+}
+
+// psQuotes doubles the characters PowerShell treats as single quotes, which are escaped
+// inside single quoted strings by doubling them.
+var psQuotes = strings.NewReplacer(
+	"'", "''",
+	"\u2018", "\u2018\u2018", // Left single quotation mark
+	"\u2019", "\u2019\u2019", // Right single quotation mark
+	"\u201a", "\u201a\u201a", // Single low-9 quotation mark
+	"\u201b", "\u201b\u201b", // Single high-reversed-9 quotation mark
+)
+
+// psString returns the specified string as a PowerShell string literal.
+func psString(s string) string {
+
+	return "'" + psQuotes.Replace(s) + "'"
+}
+
+// runPowershell runs the specified PowerShell script with Windows Forms loaded.
+func runPowershell(script string) (string, int, error) {
+
+	script = "Add-Type -AssemblyName System.Windows.Forms\n" + script
+	return runDialog("powershell", "-NoProfile", "-NonInteractive", "-STA", "-Command", script)
+}
+
+// powershellFileDialog shows a file dialog using Windows Forms.
+func powershellFileDialog(mode FileDialogMode, opts *FileDialogOptions) ([]string, error) {
+
+	var script strings.Builder
+	dir := initialDir(opts)
+	switch mode {
+	case FileDialogFolder:
+		script.WriteString("$d = New-Object System.Windows.Forms.FolderBrowserDialog\n")
+		script.WriteString("$d.SelectedPath = " + psString(dir) + "\n")
+		if opts.Title != "" {
+			script.WriteString("$d.Description = " + psString(opts.Title) + "\n")
+		}
+		script.WriteString("if ($d.ShowDialog() -eq 'OK') { $d.SelectedPath }")
+	default:
+		if mode == FileDialogOpen {
+			script.WriteString("$d = New-Object System.Windows.Forms.OpenFileDialog\n")
+			if opts.Multiple {
+				script.WriteString("$d.Multiselect = $true\n")
+			}
+		} else {
+			script.WriteString("$d = New-Object System.Windows.Forms.SaveFileDialog\n")
+			script.WriteString("$d.FileName = " + psString(opts.File) + "\n")
+			if opts.Overwrite {
+				script.WriteString("$d.OverwritePrompt = $false\n")
+			}
+		}
+		script.WriteString("$d.InitialDirectory = " + psString(dir) + "\n")
+		if opts.Title != "" {
+			script.WriteString("$d.Title = " + psString(opts.Title) + "\n")
+		}
+		var filters []string
+		for _, f := range opts.Filters {
+			patterns := strings.Join(f.Patterns, ";")
+			if patterns == "" {
+				patterns = "*.*"
+			}
+			filters = append(filters, f.label()+"|"+patterns)
+		}
+		if len(filters) > 0 {
+			script.WriteString("$d.Filter = " + psString(strings.Join(filters, "|")) + "\n")
+		}
+		script.WriteString("if ($d.ShowDialog() -eq 'OK') { $d.FileNames -join \"`n\" }")
+	}
+	out, code, err := runPowershell(script.String())
+	if err != nil || code != 0 {
+		return nil, err
+	}
+	return splitPaths(out), nil
+}
+
+// Button sets supported by the Windows message box
+var windowsButtonSets = map[string]string{
+	"OK":                 "OK",
+	"OK|Cancel":          "OKCancel",
+	"Yes|No":             "YesNo",
+	"Yes|No|Cancel":      "YesNoCancel",
+	"Retry|Cancel":       "RetryCancel",
+	"Abort|Retry|Ignore": "AbortRetryIgnore",
+}
+
+// powershellMessageBox shows a Windows Forms message box, which only supports the standard button sets.
+func powershellMessageBox(kind MessageKind, title, msg string, buttons []string) (int, error) {
+
+	set, ok := windowsButtonSets[strings.Join(buttons, "|")]
+	if !ok {
+		return 0, errNoNativeDialog
+	}
+	script := "[System.Windows.Forms.MessageBox]::Show(" + psString(msg) + ", " + psString(title) + ", '" + set + "', '" +
+		[...]string{"Information", "Warning", "Error", "Question"}[kind] + "')"
+	out, code, err := runPowershell(script)
+	if err != nil {
+		return 0, err
+	}
+	if code == 0 {
+		for i, b := range buttons {
+			if strings.EqualFold(b, out) {
+				return i, nil
+			}
+		}
+	}
+	return -1, nil
+}
@@ -0,0 +1,22 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build wasm
+
+package gui
+
+// Browsers have no dialogs to select local paths, so the in-engine ones are always used.
+
+// nativeFileDialog returns errNoNativeDialog since there are no native file dialogs in the browser.
+func nativeFileDialog(mode FileDialogMode, opts *FileDialogOptions) ([]string, error) {
+
+	return nil, errNoNativeDialog
+}
+
+// nativeMessageBox returns errNoNativeDialog since the browser alert and confirm dialogs
+// cannot show custom buttons.
+func nativeMessageBox(kind MessageKind, title, msg string, buttons []string) (int, error) {
+
+	return 0, errNoNativeDialog
+}
@@ -0,0 +1,357 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// Drag and drop events
+const (
+	OnDragStart = "gui.OnDragStart" // Sent to the source panel when a drag starts
+	OnDragEnd   = "gui.OnDragEnd"   // Sent to the source panel when the drag ends, dropped or cancelled
+	OnDragEnter = "gui.OnDragEnter" // Sent to a target panel when an accepted drag enters it
+	OnDragOver  = "gui.OnDragOver"  // Sent to a target panel when an accepted drag moves over it
+	OnDragLeave = "gui.OnDragLeave" // Sent to a target panel when an accepted drag leaves it or ends over it
+	OnDrop      = "gui.OnDrop"      // Sent to a target panel when an accepted drag is dropped on it
+)
+
+// DragData is the typed payload of a drag and drop operation.
+type DragData struct {
+	Type  string      // Type of the payload, matched against the types accepted by drop targets
+	Value interface{} // Payload value
+	Ghost IPanel      // Optional panel which follows the cursor during the drag (default is a translucent box)
+}
+
+// DragEvent describes a drag and drop event.
+// The same event is sent to the source and target panels during a drag.
+type DragEvent struct {
+	Source  IPanel    // Source panel of the drag
+	Target  IPanel    // Current target panel or nil
+	Data    *DragData // Payload being dragged
+	Xpos    float32   // Cursor position in pixels
+	Ypos    float32   // Cursor position in pixels
+	Dropped bool      // Whether the payload was dropped on a target (OnDragEnd only)
+}
+
+// DragSource makes a panel the source of drag operations
+// started by pressing the left mouse button over it and moving the cursor.
+type DragSource struct {
+	Threshold float32                      // Cursor distance in pixels needed to start the drag
+	ipan      IPanel                       // Source panel
+	data      func(x, y float32) *DragData // Returns the payload for a drag started at the specified position
+	enabled   bool                         // Whether drags can be started
+}
+
+// DropTarget makes a panel accept dragged payloads.
+type DropTarget struct {
+	Accept func(ev *DragEvent) bool // Optional function to further filter the accepted payloads
+	ipan   IPanel                   // Target panel
+	types  []string                 // Accepted payload types (all if empty)
+}
+
+// dragState is the state of a pending or active drag operation.
+type dragState struct {
+	source *DragSource // Source of the drag
+	startX float32     // Cursor position when the mouse button was pressed
+	startY float32
+	offX   float32 // Cursor position relative to the source panel
+	offY   float32
+	active bool        // Whether the drag has started
+	ghost  IPanel      // Panel following the cursor
+	target *DropTarget // Current accepting drop target
+	ev     DragEvent   // Event sent to source and target
+}
+
+// NewDragSource makes the specified panel a drag source and returns a pointer to it.
+// The specified function is called when a drag starts at the specified screen position
+// and returns the payload to drag or nil to not start the drag.
+func NewDragSource(ipan IPanel, data func(x, y float32) *DragData) *DragSource {
+
+	ds := new(DragSource)
+	ds.Threshold = 4
+	ds.ipan = ipan
+	ds.data = data
+	ds.enabled = true
+	Manager().dragSources[ipan] = ds
+	return ds
+}
+
+// Panel returns the source panel.
+func (ds *DragSource) Panel() IPanel {
+
+	return ds.ipan
+}
+
+// SetEnabled sets whether drags can be started from the source panel.
+func (ds *DragSource) SetEnabled(state bool) {
+
+	ds.enabled = state
+}
+
+// Enabled returns whether drags can be started from the source panel.
+func (ds *DragSource) Enabled() bool {
+
+	return ds.enabled
+}
+
+// Dispose removes the drag source from its panel, cancelling its drag if any.
+func (ds *DragSource) Dispose() {
+
+	if gm.drag != nil && gm.drag.source == ds {
+		gm.endDrag(false)
+	}
+	delete(gm.dragSources, ds.ipan)
+}
+
+// NewDropTarget makes the specified panel accept payloads of the specified types
+// (or of any type if none is specified) and returns a pointer to it.
+func NewDropTarget(ipan IPanel, types ...string) *DropTarget {
+
+	dt := new(DropTarget)
+	dt.ipan = ipan
+	dt.types = types
+	Manager().dropTargets[ipan] = dt
+	return dt
+}
+
+// Panel returns the target panel.
+func (dt *DropTarget) Panel() IPanel {
+
+	return dt.ipan
+}
+
+// Accepts returns whether the target accepts the payload of the specified event.
+func (dt *DropTarget) Accepts(ev *DragEvent) bool {
+
+	if len(dt.types) > 0 {
+		found := false
+		for _, t := range dt.types {
+			if t == ev.Data.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if dt.Accept != nil {
+		return dt.Accept(ev)
+	}
+	return true
+}
+
+// Dispose removes the drop target from its panel.
+func (dt *DropTarget) Dispose() {
+
+	if gm.drag != nil && gm.drag.target == dt {
+		gm.drag.target = nil
+		gm.drag.ev.Target = nil
+	}
+	delete(gm.dropTargets, dt.ipan)
+}
+
+// Dragging returns whether a drag is in progress.
+func (gm *manager) Dragging() bool {
+
+	return gm.drag != nil && gm.drag.active
+}
+
+// CancelDrag cancels the drag in progress if any.
+func (gm *manager) CancelDrag() {
+
+	if gm.drag != nil {
+		gm.endDrag(false)
+	}
+}
+
+// dragMouse is called by onMouse and returns whether the event was consumed by a drag.
+func (gm *manager) dragMouse(evname string, mev *window.MouseEvent) bool {
+
+	switch evname {
+	case OnMouseDown:
+		if gm.drag != nil || mev.Button != window.MouseButtonLeft || gm.target == nil {
+			return false
+		}
+		if gm.modal != nil && !gm.modal.IsAncestorOf(gm.target) {
+			return false
+		}
+		// Find the innermost drag source containing the target panel
+		var ok bool
+		for ipan := gm.target; ipan != nil; {
+			if ds := gm.dragSources[ipan]; ds != nil && ds.enabled {
+				pos := ipan.GetPanel().Pospix()
+				gm.drag = &dragState{source: ds, startX: mev.Xpos, startY: mev.Ypos, offX: mev.Xpos - pos.X, offY: mev.Ypos - pos.Y}
+				break
+			}
+			ipan, ok = ipan.Parent().(IPanel)
+			if !ok {
+				break
+			}
+		}
+		return false
+	case OnMouseUp:
+		if gm.drag == nil {
+			return false
+		}
+		if !gm.drag.active {
+			gm.drag = nil
+			return false
+		}
+		gm.drag.ev.Xpos = mev.Xpos
+		gm.drag.ev.Ypos = mev.Ypos
+		gm.endDrag(true)
+		return true
+	}
+	return false
+}
+
+// dragCursor is called by onCursor and returns whether the event was consumed by a drag.
+func (gm *manager) dragCursor(cev *window.CursorEvent) bool {
+
+	d := gm.drag
+	if !d.active {
+		dx := cev.Xpos - d.startX
+		dy := cev.Ypos - d.startY
+		if dx*dx+dy*dy < d.source.Threshold*d.source.Threshold {
+			return false
+		}
+		if !gm.startDrag(cev) {
+			gm.drag = nil
+			return false
+		}
+	}
+	d.ev.Xpos = cev.Xpos
+	d.ev.Ypos = cev.Ypos
+	d.ghost.GetPanel().SetPosition(cev.Xpos-d.offX, cev.Ypos-d.offY)
+
+	// Find the accepting drop target under the cursor
+	var target *DropTarget
+	if hit := gm.dragHit(cev.Xpos, cev.Ypos); hit != nil {
+		var ok bool
+		for ipan := hit; ipan != nil; {
+			if dt := gm.dropTargets[ipan]; dt != nil && ipan != d.source.ipan && dt.Accepts(&d.ev) {
+				target = dt
+				break
+			}
+			ipan, ok = ipan.Parent().(IPanel)
+			if !ok {
+				break
+			}
+		}
+	}
+	if target != d.target {
+		if d.target != nil {
+			d.target.ipan.Dispatch(OnDragLeave, &d.ev)
+		}
+		d.target = target
+		d.ev.Target = nil
+		if target != nil {
+			d.ev.Target = target.ipan
+			target.ipan.Dispatch(OnDragEnter, &d.ev)
+		}
+	}
+	if d.target != nil {
+		d.target.ipan.Dispatch(OnDragOver, &d.ev)
+	}
+	return true
+}
+
+// dragKey is called by onKeyboard and returns whether the event was consumed by a drag.
+// Pressing the Escape key cancels the drag.
+func (gm *manager) dragKey(evname string, ev interface{}) bool {
+
+	if gm.drag == nil || !gm.drag.active {
+		return false
+	}
+	if kev, ok := ev.(*window.KeyEvent); ok && evname == OnKeyDown && kev.Key == window.KeyEscape {
+		gm.endDrag(false)
+	}
+	return true
+}
+
+// startDrag starts the pending drag and returns false if the source supplied no payload.
+func (gm *manager) startDrag(cev *window.CursorEvent) bool {
+
+	d := gm.drag
+	data := d.source.data(d.startX, d.startY)
+	if data == nil {
+		return false
+	}
+	d.active = true
+	d.ev = DragEvent{Source: d.source.ipan, Data: data, Xpos: cev.Xpos, Ypos: cev.Ypos}
+
+	// The panel under the cursor stops receiving cursor events during the drag
+	if gm.target != nil && (gm.modal == nil || gm.modal.IsAncestorOf(gm.target)) {
+		sendAncestry(gm.target, true, nil, gm.modal, OnCursorLeave, cev)
+	}
+	gm.target = nil
+	gm.hideTooltip()
+	gm.tipOwner = nil
+
+	// Show the ghost on top of everything else
+	d.ghost = data.Ghost
+	if d.ghost == nil {
+		d.ghost = newDragGhost(d.source.ipan)
+	}
+	d.ghost.GetPanel().SetZLayerDelta(1000)
+	gm.scene.GetNode().Add(d.ghost)
+	d.source.ipan.Dispatch(OnDragStart, &d.ev)
+	return true
+}
+
+// endDrag ends the current drag dropping the payload on the current target if requested.
+func (gm *manager) endDrag(drop bool) {
+
+	d := gm.drag
+	gm.drag = nil
+	if !d.active {
+		return
+	}
+	if d.target != nil {
+		d.target.ipan.Dispatch(OnDragLeave, &d.ev)
+		if drop {
+			d.ev.Dropped = true
+			d.target.ipan.Dispatch(OnDrop, &d.ev)
+		}
+	}
+	if parent := d.ghost.Parent(); parent != nil {
+		parent.GetNode().Remove(d.ghost)
+	}
+	if d.ev.Data.Ghost == nil {
+		d.ghost.Dispose()
+	}
+	d.source.ipan.Dispatch(OnDragEnd, &d.ev)
+}
+
+// dragHit returns the panel under the specified position ignoring the drag ghost.
+func (gm *manager) dragHit(x, y float32) IPanel {
+
+	var hit IPanel
+	gm.forEachIPanel(func(ipan IPanel) {
+		if gm.drag.ghost.IsAncestorOf(ipan) {
+			return
+		}
+		if ipan.InsideBorders(x, y) && (hit == nil || ipan.Position().Z < hit.GetPanel().Position().Z) {
+			hit = ipan
+		}
+	})
+	if hit != nil && gm.modal != nil && !gm.modal.IsAncestorOf(hit) {
+		return nil
+	}
+	return hit
+}
+
+// newDragGhost creates and returns the default ghost panel for the specified source panel.
+func newDragGhost(src IPanel) IPanel {
+
+	p := NewPanel(src.GetPanel().Width(), src.GetPanel().Height())
+	p.SetBorders(1, 1, 1, 1)
+	p.SetBordersColor4(&math32.Color4{R: 0.2, G: 0.4, B: 0.8, A: 0.8})
+	p.SetColor4(&math32.Color4{R: 0.5, G: 0.6, B: 0.9, A: 0.35})
+	return p
+}
@@ -319,11 +319,16 @@ func (s *ItemScroller) onCursor(evname string, ev interface{}) {
 func (s *ItemScroller) onScroll(evname string, ev interface{}) {
 
 	sev := ev.(*window.ScrollEvent)
+	first := s.first
 	if sev.Yoffset > 0 {
 		s.ScrollUp()
 	} else if sev.Yoffset < 0 {
 		s.ScrollDown()
 	}
+	// If the list couldn't scroll, the event is passed to the parent
+	if s.first == first {
+		propagateScroll(s, ev)
+	}
 }
 
 // onResize receives resize events
@@ -5,6 +5,8 @@
 package gui
 
 import (
+	"time"
+
 	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/window"
 )
@@ -14,15 +16,26 @@ var gm *manager
 
 // manager routes GUI events to the appropriate panels.
 type manager struct {
-	core.Dispatcher                       // Embedded Dispatcher
-	core.TimerManager                     // Embedded TimerManager
-	win               window.IWindow      // The current IWindow
-	scene             core.INode          // INode containing IPanels to dispatch events to (can contain non-IPanels as well)
-	modal             IPanel              // Panel which along its descendants will exclusively receive all events
-	target            IPanel              // Panel immediately under the cursor
-	keyFocus          core.IDispatcher    // IDispatcher which will exclusively receive all key and char events
-	cursorFocus       core.IDispatcher    // IDispatcher which will exclusively receive all OnCursor events
-	cev               *window.CursorEvent // IDispatcher which will exclusively receive all OnCursor events
+	core.Dispatcher                          // Embedded Dispatcher
+	core.TimerManager                        // Embedded TimerManager
+	win               window.IWindow         // The current IWindow
+	scene             core.INode             // INode containing IPanels to dispatch events to (can contain non-IPanels as well)
+	modal             IPanel                 // Panel which along its descendants will exclusively receive all events
+	target            IPanel                 // Panel immediately under the cursor
+	keyFocus          core.IDispatcher       // IDispatcher which will exclusively receive all key and char events
+	cursorFocus       core.IDispatcher       // IDispatcher which will exclusively receive all OnCursor events
+	cev               *window.CursorEvent    // IDispatcher which will exclusively receive all OnCursor events
+	dragSources       map[IPanel]*DragSource // Registered drag sources by panel
+	dropTargets       map[IPanel]*DropTarget // Registered drop targets by panel
+	drag              *dragState             // Pending or active drag operation
+	shortcuts         []*MenuItem            // Menu items with application wide shortcuts
+	tip               *Tooltip               // Visible tooltip
+	tipOwner          IPanel                 // Panel whose tooltip is visible or pending
+	tipTimer          int                    // Timer to show or hide the tooltip
+	tipHidden         time.Time              // Time the last tooltip was hidden
+	tipSuppressed     bool                   // Tooltip hidden until the cursor leaves its owner
+	render            *RenderParams          // Parameters to render panels offscreen or nil
+	cursorXform       CursorTransform        // Maps window cursor positions to GUI positions or nil
 }
 
 // Manager returns the GUI manager singleton (creating it the first time)
@@ -36,6 +49,8 @@ func Manager() *manager {
 	gm = new(manager)
 	gm.Dispatcher.Initialize()
 	gm.TimerManager.Initialize()
+	gm.dragSources = make(map[IPanel]*DragSource)
+	gm.dropTargets = make(map[IPanel]*DropTarget)
 
 	// Subscribe to window events
 	gm.win = window.Get()
@@ -90,7 +105,7 @@ func (gm *manager) SetCursorFocus(disp core.IDispatcher) {
 	}
 	gm.cursorFocus = disp
 	if gm.cursorFocus == nil {
-		gm.onCursor(OnCursor, gm.cev)
+		gm.cursor(OnCursor, gm.cev)
 	}
 }
 
@@ -98,6 +113,12 @@ func (gm *manager) SetCursorFocus(disp core.IDispatcher) {
 // The events are dispatched to the focused IDispatcher or to non-GUI.
 func (gm *manager) onKeyboard(evname string, ev interface{}) {
 
+	if evname == OnKeyDown {
+		gm.suppressTooltip()
+	}
+	if gm.dragKey(evname, ev) || gm.menuShortcut(evname, ev) {
+		return
+	}
 	if gm.keyFocus != nil {
 		if gm.modal == nil {
 			gm.keyFocus.Dispatch(evname, ev)
@@ -114,12 +135,23 @@ func (gm *manager) onKeyboard(evname string, ev interface{}) {
 // OnMouseDownOut/OnMouseUpOut are dispatched to all non-target panels.
 func (gm *manager) onMouse(evname string, ev interface{}) {
 
+	ev = gm.transformCursor(ev)
+
 	// Check if gm.scene is nil and if so then there are no IPanels to send events to
 	if gm.scene == nil {
 		gm.Dispatch(evname, ev) // Dispatch event to non-GUI since event was not filtered by any GUI component
 		return
 	}
 
+	if evname == OnMouseDown {
+		gm.suppressTooltip()
+	}
+
+	// Start or finish drag operations
+	if gm.dragMouse(evname, ev.(*window.MouseEvent)) {
+		return
+	}
+
 	// Dispatch OnMouseDownOut/OnMouseUpOut to all panels except ancestors of target
 	gm.forEachIPanel(func(ipan IPanel) {
 		if gm.target == nil || !ipan.IsAncestorOf(gm.target) {
@@ -136,6 +168,9 @@ func (gm *manager) onMouse(evname string, ev interface{}) {
 	if gm.target != nil {
 		if gm.modal == nil || gm.modal.IsAncestorOf(gm.target) {
 			sendAncestry(gm.target, false, nil, gm.modal, evname, ev)
+			if evname == OnMouseDown {
+				gm.showContextMenu(ev.(*window.MouseEvent))
+			}
 		}
 	} else if gm.modal == nil {
 		gm.Dispatch(evname, ev)
@@ -146,6 +181,8 @@ func (gm *manager) onMouse(evname string, ev interface{}) {
 // The events are dispatched to the target panel or to non-GUI.
 func (gm *manager) onScroll(evname string, ev interface{}) {
 
+	gm.suppressTooltip()
+
 	// Check if gm.scene is nil and if so then there are no IPanels to send events to
 	if gm.scene == nil {
 		gm.Dispatch(evname, ev) // Dispatch event to non-GUI since event was not filtered by any GUI component
@@ -163,9 +200,15 @@ func (gm *manager) onScroll(evname string, ev interface{}) {
 }
 
 // onCursor is called when (mouse) cursor events are received.
-// Updates the target/click panels and dispatches OnCursor, OnCursorEnter, OnCursorLeave events.
 func (gm *manager) onCursor(evname string, ev interface{}) {
 
+	gm.cursor(evname, gm.transformCursor(ev))
+}
+
+// cursor updates the target/click panels and dispatches OnCursor, OnCursorEnter, OnCursorLeave events.
+// The cursor position is in GUI coordinates.
+func (gm *manager) cursor(evname string, ev interface{}) {
+
 	// If an IDispatcher is capturing cursor events dispatch to it and return
 	if gm.cursorFocus != nil {
 		gm.cursorFocus.Dispatch(evname, ev)
@@ -181,12 +224,20 @@ func (gm *manager) onCursor(evname string, ev interface{}) {
 	// Get and store CursorEvent
 	gm.cev = ev.(*window.CursorEvent)
 
+	// While dragging the cursor events are only used to track the drag
+	if gm.drag != nil && gm.dragCursor(gm.cev) {
+		return
+	}
+
 	// Temporarily store last target and clear current one
 	oldTarget := gm.target
 	gm.target = nil
 
 	// Find IPanel immediately under the cursor and store it in gm.target
 	gm.forEachIPanel(func(ipan IPanel) {
+		if gm.tip != nil && gm.tip.IsAncestorOf(ipan) {
+			return
+		}
 		if ipan.InsideBorders(gm.cev.Xpos, gm.cev.Ypos) && (gm.target == nil || ipan.Position().Z < gm.target.GetPanel().Position().Z) {
 			gm.target = ipan
 		}
@@ -208,6 +259,7 @@ func (gm *manager) onCursor(evname string, ev interface{}) {
 			sendAncestry(gm.target, true, commonAnc, gm.modal, OnCursorEnter, ev)
 		}
 	}
+	gm.updateTooltip()
 
 	// Appropriately dispatch the event to target panel's lowest subscribed ancestor or to non-GUI or not at all
 	if gm.target != nil {
@@ -5,6 +5,7 @@
 package gui
 
 import (
+	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/gui/assets/icon"
 	"github.com/g3n/engine/math32"
 	"github.com/g3n/engine/window"
@@ -12,14 +13,22 @@ import (
 	"time"
 )
 
+// OnContextMenu is the event dispatched to a context menu with the
+// right clicked panel before the menu is shown.
+const OnContextMenu = "gui.OnContextMenu"
+
 // Menu is the menu GUI element
 type Menu struct {
-	Panel                // embedded panel
-	styles   *MenuStyles // pointer to current styles
-	bar      bool        // true for menu bar
-	items    []*MenuItem // menu items
-	autoOpen bool        // open sub menus when mouse over if true
-	mitem    *MenuItem   // parent menu item for sub menu
+	Panel                      // embedded panel
+	styles    *MenuStyles      // pointer to current styles
+	bar       bool             // true for menu bar
+	items     []*MenuItem      // menu items
+	autoOpen  bool             // open sub menus when mouse over if true
+	mitem     *MenuItem        // parent menu item for sub menu
+	popup     bool             // true while shown as a popup menu
+	popupSubs bool             // true if subscribed to popup events
+	prevFocus core.IDispatcher // key focus before the popup menu was shown
+	invoker   IPanel           // panel which opened this context menu
 }
 
 // MenuBodyStyle describes the style of the menu body
@@ -41,20 +50,23 @@ type MenuStyles struct {
 
 // MenuItem is an option of a Menu
 type MenuItem struct {
-	Panel                       // embedded panel
-	styles   *MenuItemStyles    // pointer to current styles
-	menu     *Menu              // pointer to parent menu
-	licon    *Label             // optional left icon label
-	label    *Label             // optional text label (nil for separators)
-	shortcut *Label             // optional shorcut text label
-	ricon    *Label             // optional right internal icon label for submenu
-	id       string             // optional text id
-	icode    int                // icon code (if icon is set)
-	submenu  *Menu              // pointer to optional associated sub menu
-	keyMods  window.ModifierKey // shortcut key modifier
-	keyCode  window.Key         // shortcut key code
-	disabled bool               // item disabled state
-	selected bool               // selection state
+	Panel                        // embedded panel
+	styles    *MenuItemStyles    // pointer to current styles
+	menu      *Menu              // pointer to parent menu
+	licon     *Label             // optional left icon label
+	label     *Label             // optional text label (nil for separators)
+	shortcut  *Label             // optional shorcut text label
+	ricon     *Label             // optional right internal icon label for submenu
+	id        string             // optional text id
+	icode     int                // icon code (if icon is set)
+	submenu   *Menu              // pointer to optional associated sub menu
+	keyMods   window.ModifierKey // shortcut key modifier
+	keyCode   window.Key         // shortcut key code
+	disabled  bool               // item disabled state
+	selected  bool               // selection state
+	checkable bool               // item toggles its checked state when activated
+	group     string             // radio group of the item (empty if not a radio item)
+	checked   bool               // checked state
 }
 
 // MenuItemStyle describes the style of a menu item
@@ -78,6 +90,7 @@ var mapKeyModifier = map[window.ModifierKey]string{
 	window.ModShift:   "Shift",
 	window.ModControl: "Ctrl",
 	window.ModAlt:     "Alt",
+	window.ModSuper:   "Super",
 }
 var mapKeyText = map[window.Key]string{
 	window.KeyApostrophe: "'",
@@ -207,9 +220,106 @@ func (m *Menu) AddMenu(text string, subm *Menu) *MenuItem {
 	return mi
 }
 
-// RemoveItem removes the specified menu item from this menu
+// RemoveItem removes the specified menu item from this menu and disposes it
 func (m *Menu) RemoveItem(mi *MenuItem) {
 
+	for i := 0; i < len(m.items); i++ {
+		if m.items[i] != mi {
+			continue
+		}
+		copy(m.items[i:], m.items[i+1:])
+		m.items[len(m.items)-1] = nil
+		m.items = m.items[:len(m.items)-1]
+		Manager().removeShortcuts(mi)
+		m.Panel.Remove(mi)
+		mi.Dispose()
+		m.recalc()
+		return
+	}
+}
+
+// Popup shows this menu at the specified screen position on top of
+// all other panels. The menu is closed when one of its options is
+// activated, the Escape key is pressed or a mouse button is pressed
+// outside of it.
+func (m *Menu) Popup(x, y float32) {
+
+	gm := Manager()
+	if gm.scene == nil {
+		log.Error("menu: the GUI manager has no scene")
+		return
+	}
+	if m.popup {
+		m.ClosePopup()
+	}
+	if !m.popupSubs {
+		m.Panel.Subscribe(OnMouseDownOut, m.onPopupOut)
+		m.popupSubs = true
+	}
+
+	// Keeps the menu inside the window
+	width, height := window.Get().GetSize()
+	if x+m.Width() > float32(width) {
+		x = float32(width) - m.Width()
+	}
+	if y+m.Height() > float32(height) {
+		y = float32(height) - m.Height()
+	}
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	// If there is a modal panel, the menu must be inside it to receive events
+	if gm.modal != nil {
+		pos := gm.modal.GetPanel().Pospix()
+		m.SetBounded(false)
+		m.SetPosition(x-pos.X, y-pos.Y)
+		m.SetZLayerDelta(1)
+		gm.modal.GetPanel().Add(m)
+	} else {
+		m.SetPosition(x, y)
+		m.SetZLayerDelta(200)
+		gm.scene.GetNode().Add(m)
+	}
+	m.popup = true
+	m.autoOpen = true
+	m.setSelectedPos(-1)
+	m.prevFocus = gm.keyFocus
+	gm.SetKeyFocus(m)
+}
+
+// ClosePopup closes this menu if it was shown by Popup
+func (m *Menu) ClosePopup() {
+
+	if !m.popup {
+		return
+	}
+	m.popup = false
+	m.setSelectedPos(-1)
+	if parent := m.Parent(); parent != nil {
+		parent.GetNode().Remove(m)
+	}
+	// Restores the previous key focus if the focus is inside the menu
+	gm := Manager()
+	if ipan, ok := gm.keyFocus.(IPanel); ok && m.IsAncestorOf(ipan) {
+		gm.SetKeyFocus(m.prevFocus)
+	}
+	m.prevFocus = nil
+}
+
+// Invoker returns the panel which was right clicked to show this context menu
+func (m *Menu) Invoker() IPanel {
+
+	return m.invoker
+}
+
+// onPopupOut closes the popup menu when a mouse button is pressed outside of it
+func (m *Menu) onPopupOut(evname string, ev interface{}) {
+
+	m.ClosePopup()
 }
 
 // onKey process subscribed key events
@@ -299,6 +409,13 @@ func (m *Menu) onKey(evname string, ev interface{}) {
 			m.mitem.menu.setSelectedPos(next)
 			Manager().SetKeyFocus(m.mitem.menu)
 		}
+	// Escape -> Close popup menu
+	case window.KeyEscape:
+		root := m
+		for root.mitem != nil {
+			root = root.mitem.menu
+		}
+		root.ClosePopup()
 	// Enter -> Select menu option
 	case window.KeyEnter:
 		if sel < 0 {
@@ -580,7 +697,7 @@ func (mi *MenuItem) SetIcon(icon string) *MenuItem {
 	// Remove and dispose previous icon
 	if mi.licon != nil {
 		mi.Panel.Remove(mi.licon)
-		mi.Dispose()
+		mi.licon.Dispose()
 		mi.licon = nil
 	}
 	// Sets the new icon
@@ -616,40 +733,55 @@ func (mi *MenuItem) SetShortcut(mods window.ModifierKey, key window.Key) *MenuIt
 	}
 	mi.keyMods = mods
 	mi.keyCode = key
+	Manager().addShortcut(mi)
 
 	// If parent menu is a menu bar, nothing more to do
 	if mi.menu.bar {
 		return mi
 	}
 
-	// Builds shortcut text
+	// Creates and adds shortcut label or updates the existing one
+	text := shortcutText(mods, key)
+	if mi.shortcut != nil {
+		mi.shortcut.SetText(text)
+	} else {
+		mi.shortcut = NewLabel(text)
+		mi.Panel.Add(mi.shortcut)
+	}
+	mi.update()
+	mi.menu.recalc()
+	return mi
+}
+
+// shortcutText returns the text describing the specified keyboard shortcut, such as "Ctrl+S"
+func shortcutText(mods window.ModifierKey, key window.Key) string {
+
 	text := ""
-	if mi.keyMods&window.ModShift != 0 {
+	if mods&window.ModShift != 0 {
 		text = mapKeyModifier[window.ModShift]
 	}
-	if mi.keyMods&window.ModControl != 0 {
+	if mods&window.ModControl != 0 {
 		if text != "" {
 			text += "+"
 		}
 		text += mapKeyModifier[window.ModControl]
 	}
-	if mi.keyMods&window.ModAlt != 0 {
+	if mods&window.ModAlt != 0 {
 		if text != "" {
 			text += "+"
 		}
 		text += mapKeyModifier[window.ModAlt]
 	}
+	if mods&window.ModSuper != 0 {
+		if text != "" {
+			text += "+"
+		}
+		text += mapKeyModifier[window.ModSuper]
+	}
 	if text != "" {
 		text += "+"
 	}
-	text += mapKeyText[key]
-
-	// Creates and adds shortcut label
-	mi.shortcut = NewLabel(text)
-	mi.Panel.Add(mi.shortcut)
-	mi.update()
-	mi.menu.recalc()
-	return mi
+	return text + mapKeyText[key]
 }
 
 // SetSubmenu sets an associated sub menu item for this menu item
@@ -665,6 +797,93 @@ func (mi *MenuItem) SetEnabled(enabled bool) {
 	mi.update()
 }
 
+// SetCheckable sets whether this menu item shows a check box
+// and toggles its checked state when activated
+func (mi *MenuItem) SetCheckable(checkable bool) *MenuItem {
+
+	mi.checkable = checkable
+	mi.group = ""
+	mi.updateCheck()
+	return mi
+}
+
+// SetRadioGroup sets this menu item as a radio item of the specified group.
+// Activating a radio item checks it and unchecks the other items
+// of the same group in the menu.
+func (mi *MenuItem) SetRadioGroup(group string) *MenuItem {
+
+	mi.group = group
+	mi.checkable = group != ""
+	mi.updateCheck()
+	return mi
+}
+
+// SetChecked sets the checked state of this checkable or radio menu item
+func (mi *MenuItem) SetChecked(checked bool) *MenuItem {
+
+	if !mi.checkable {
+		return mi
+	}
+	mi.checked = checked
+	if checked && mi.group != "" {
+		for _, other := range mi.menu.items {
+			if other != mi && other.group == mi.group && other.checked {
+				other.checked = false
+				other.updateCheck()
+			}
+		}
+	}
+	mi.updateCheck()
+	return mi
+}
+
+// Checked returns the checked state of this menu item
+func (mi *MenuItem) Checked() bool {
+
+	return mi.checked
+}
+
+// updateCheck updates the check box or radio icon of this menu item
+func (mi *MenuItem) updateCheck() {
+
+	if !mi.checkable || mi.label == nil {
+		return
+	}
+	var code string
+	if mi.group == "" {
+		code = checkOFF
+		if mi.checked {
+			code = checkON
+		}
+	} else {
+		code = radioOFF
+		if mi.checked {
+			code = radioON
+		}
+	}
+	if mi.licon == nil {
+		mi.SetIcon(code)
+	} else {
+		mi.licon.SetText(code)
+	}
+	if mi.menu != nil {
+		mi.menu.recalc()
+	}
+}
+
+// toggle updates the checked state of this menu item when activated
+func (mi *MenuItem) toggle() {
+
+	if !mi.checkable {
+		return
+	}
+	if mi.group != "" {
+		mi.SetChecked(true)
+		return
+	}
+	mi.SetChecked(!mi.checked)
+}
+
 // SetId sets this menu item string id which can be used to identify
 // the selected menu option.
 func (mi *MenuItem) SetId(id string) *MenuItem {
@@ -731,15 +950,108 @@ func (mi *MenuItem) onMouse(evname string, ev interface{}) {
 // activate activates this menu item dispatching OnClick events
 func (mi *MenuItem) activate() {
 
+	mi.toggle()
 	rm := mi.rootMenu()
-	if rm.bar {
-		rm.autoOpen = false
+	if rm.popup {
+		rm.ClosePopup()
+	} else {
+		if rm.bar {
+			rm.autoOpen = false
+		}
+		rm.setSelectedPos(-1)
+		Manager().SetKeyFocus(rm)
 	}
-	rm.setSelectedPos(-1)
-	Manager().SetKeyFocus(rm)
 	mi.dispatchAll(OnClick, mi)
 }
 
+// shortcutEnabled returns whether this menu item and all its parent items are enabled
+func (mi *MenuItem) shortcutEnabled() bool {
+
+	for item := mi; item != nil; item = item.menu.mitem {
+		if item.disabled || item.menu == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// addShortcut registers the shortcut of the specified menu item as an application wide accelerator
+func (gm *manager) addShortcut(mi *MenuItem) {
+
+	for _, item := range gm.shortcuts {
+		if item == mi {
+			return
+		}
+	}
+	gm.shortcuts = append(gm.shortcuts, mi)
+}
+
+// removeShortcuts unregisters the shortcuts of the specified menu item and of its sub menus
+func (gm *manager) removeShortcuts(mi *MenuItem) {
+
+	for i := 0; i < len(gm.shortcuts); i++ {
+		if gm.shortcuts[i] == mi {
+			gm.shortcuts = append(gm.shortcuts[:i], gm.shortcuts[i+1:]...)
+			break
+		}
+	}
+	if mi.submenu != nil {
+		for _, item := range mi.submenu.items {
+			gm.removeShortcuts(item)
+		}
+	}
+}
+
+// menuShortcut is called by the manager for key events and activates the menu item
+// registered with the pressed shortcut if any, regardless of the key focus.
+// Only shortcuts using the Control, Alt or Super modifiers or function keys are
+// application wide, so they don't interfere with text editing.
+// Returns whether the event was consumed.
+func (gm *manager) menuShortcut(evname string, ev interface{}) bool {
+
+	if evname != OnKeyDown {
+		return false
+	}
+	kev := ev.(*window.KeyEvent)
+	if kev.Mods&(window.ModControl|window.ModAlt|window.ModSuper) == 0 && (kev.Key < window.KeyF1 || kev.Key > window.KeyF12) {
+		return false
+	}
+	for _, mi := range gm.shortcuts {
+		if mi.keyCode != kev.Key || mi.keyMods != kev.Mods || mi.submenu != nil || !mi.shortcutEnabled() {
+			continue
+		}
+		if gm.modal != nil && !gm.modal.IsAncestorOf(mi) {
+			continue
+		}
+		mi.toggle()
+		mi.dispatchAll(OnClick, mi)
+		return true
+	}
+	return false
+}
+
+// showContextMenu is called by the manager when a mouse button is pressed over the target
+// panel and shows the context menu of the panel or of its nearest ancestor which has one.
+func (gm *manager) showContextMenu(mev *window.MouseEvent) {
+
+	if mev.Button != window.MouseButtonRight {
+		return
+	}
+	var ok bool
+	for ipan := gm.target; ipan != nil; {
+		if m := ipan.GetPanel().contextMenu; m != nil {
+			m.invoker = gm.target
+			m.Dispatch(OnContextMenu, gm.target)
+			m.Popup(mev.Xpos, mev.Ypos)
+			return
+		}
+		ipan, ok = ipan.Parent().(IPanel)
+		if !ok {
+			return
+		}
+	}
+}
+
 // rootMenu returns the root menu for this menu item
 func (mi *MenuItem) rootMenu() *Menu {
 
@@ -0,0 +1,143 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/texture"
+)
+
+// NineSlice is a panel which draws an image scaled with the 9-slice technique.
+// The image is divided by its insets into nine regions: the corners are drawn
+// unscaled, the edges are stretched in one direction and the center is stretched
+// in both, so the image can be used as a skin of panels of any size.
+// If the panel is smaller than the sum of the insets the corners are shrunk proportionally.
+type NineSlice struct {
+	Panel                     // Embedded panel
+	src    *image.RGBA        // Source image
+	insets RectBounds         // Insets of the source image in pixels
+	img    *image.RGBA        // Image with the scaled source image
+	tex    *texture.Texture2D // Texture with the scaled source image
+}
+
+// NewNineSlice creates and returns a pointer to a new 9-slice panel
+// with the specified image and insets. The panel content size is initially the image size.
+func NewNineSlice(img image.Image, insets RectBounds) *NineSlice {
+
+	ns := new(NineSlice)
+	ns.Panel.Initialize(ns, 0, 0)
+	ns.Panel.Subscribe(OnResize, func(evname string, ev interface{}) { ns.redraw() })
+	ns.insets = insets
+	ns.SetImage(img)
+	ns.SetContentSize(float32(ns.src.Rect.Dx()), float32(ns.src.Rect.Dy()))
+	return ns
+}
+
+// NewNineSliceFromFile creates and returns a pointer to a new 9-slice panel
+// with the image from the specified file and the specified insets.
+func NewNineSliceFromFile(imgfile string, insets RectBounds) (*NineSlice, error) {
+
+	img, err := texture.DecodeImage(imgfile)
+	if err != nil {
+		return nil, err
+	}
+	return NewNineSlice(img, insets), nil
+}
+
+// SetImage sets the source image of the panel.
+func (ns *NineSlice) SetImage(img image.Image) {
+
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		ns.src = rgba
+	} else {
+		bounds := img.Bounds()
+		ns.src = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(ns.src, ns.src.Rect, img, bounds.Min, draw.Src)
+	}
+	ns.redraw()
+}
+
+// SetInsets sets the insets of the source image in pixels.
+func (ns *NineSlice) SetInsets(insets RectBounds) {
+
+	ns.insets = insets
+	ns.redraw()
+}
+
+// Insets returns the insets of the source image in pixels.
+func (ns *NineSlice) Insets() RectBounds {
+
+	return ns.insets
+}
+
+// redraw scales the source image to the panel content size and updates the texture.
+func (ns *NineSlice) redraw() {
+
+	width := int(ns.ContentWidth())
+	height := int(ns.ContentHeight())
+	if ns.src == nil || ns.src.Rect.Empty() || width <= 0 || height <= 0 {
+		return
+	}
+	if ns.img == nil || ns.img.Rect.Dx() != width || ns.img.Rect.Dy() != height {
+		ns.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+
+	// Maps the destination columns and rows to the source image
+	sw := ns.src.Rect.Dx()
+	sh := ns.src.Rect.Dy()
+	cols := make([]int, width)
+	for x := range cols {
+		cols[x] = sliceCoord(x, width, int(ns.insets.Left), int(ns.insets.Right), sw)
+	}
+	for y := 0; y < height; y++ {
+		sy := sliceCoord(y, height, int(ns.insets.Top), int(ns.insets.Bottom), sh)
+		srow := ns.src.Pix[sy*ns.src.Stride:]
+		drow := ns.img.Pix[y*ns.img.Stride:]
+		for x, sx := range cols {
+			copy(drow[x*4:x*4+4], srow[sx*4:sx*4+4])
+		}
+	}
+
+	if ns.tex == nil {
+		ns.tex = texture.NewTexture2DFromRGBA(ns.img)
+		ns.tex.SetMagFilter(gls.NEAREST)
+		ns.tex.SetMinFilter(gls.NEAREST)
+		ns.Panel.Material().AddTexture(ns.tex)
+	} else {
+		ns.tex.SetFromRGBA(ns.img)
+	}
+}
+
+// sliceCoord returns the source coordinate of the specified destination coordinate
+// along one axis with the specified destination size, source insets and source size.
+func sliceCoord(d, dsize, start, end, ssize int) int {
+
+	if start+end > ssize {
+		start = ssize / 2
+		end = ssize - start
+	}
+	// Shrinks the borders if the destination is too small
+	dstart, dend := start, end
+	if start+end > dsize {
+		dstart = dsize * start / (start + end)
+		dend = dsize - dstart
+	}
+	var s int
+	switch {
+	case d < dstart:
+		s = d * start / dstart
+	case d >= dsize-dend:
+		s = ssize - end + (d-(dsize-dend))*end/dend
+	default:
+		s = start + (d-dstart)*(ssize-start-end)/(dsize-dstart-dend)
+	}
+	if s >= ssize {
+		s = ssize - 1
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/g3n/engine/window"
+)
+
+// RenderParams specifies how panels are mapped to the current viewport when rendered.
+// By default panels are rendered at their window pixel positions using the window
+// scale. Offscreen GUI targets override the parameters while rendering, so a GUI
+// (or part of it) can be rendered into a texture at an independent resolution.
+type RenderParams struct {
+	ScaleX  float64 // Viewport pixels per GUI pixel
+	ScaleY  float64 // Viewport pixels per GUI pixel
+	OriginX float32 // GUI position rendered at the top left corner of the viewport
+	OriginY float32 // GUI position rendered at the top left corner of the viewport
+	FlipY   bool    // Renders upside down, so the first row of the target is the top of the GUI
+}
+
+// CursorTransform maps window cursor coordinates to GUI coordinates.
+// It returns false if the cursor is not over the GUI.
+type CursorTransform func(x, y float32) (float32, float32, bool)
+
+// SetRenderParams sets the parameters used to render panels.
+// Passing nil restores rendering to the window.
+func (gm *manager) SetRenderParams(rp *RenderParams) {
+
+	gm.render = rp
+}
+
+// RenderParams returns the parameters currently used to render panels or nil
+// if they are rendered to the window.
+func (gm *manager) RenderParams() *RenderParams {
+
+	return gm.render
+}
+
+// renderParams returns the effective parameters used to render panels.
+func (gm *manager) renderParams() RenderParams {
+
+	if gm.render != nil {
+		return *gm.render
+	}
+	sx, sy := gm.win.GetScale()
+	return RenderParams{ScaleX: sx, ScaleY: sy}
+}
+
+// SetCursorTransform sets a function which maps the window cursor position to GUI coordinates
+// before mouse and cursor events are processed. It is used when the GUI is not rendered
+// directly to the window, for example when it is composited scaled or rotated with a
+// TextureView or shown on an in-world screen. Passing nil removes the transform.
+func (gm *manager) SetCursorTransform(f CursorTransform) {
+
+	gm.cursorXform = f
+}
+
+// transformCursor returns the specified mouse or cursor event mapped by the cursor transform.
+// Positions outside the GUI are mapped far outside any panel.
+func (gm *manager) transformCursor(ev interface{}) interface{} {
+
+	if gm.cursorXform == nil {
+		return ev
+	}
+	const outside = -1e9
+	switch e := ev.(type) {
+	case *window.CursorEvent:
+		tev := *e
+		x, y, ok := gm.cursorXform(e.Xpos, e.Ypos)
+		if !ok {
+			x, y = outside, outside
+		}
+		tev.Xpos, tev.Ypos = x, y
+		return &tev
+	case *window.MouseEvent:
+		tev := *e
+		x, y, ok := gm.cursorXform(e.Xpos, e.Ypos)
+		if !ok {
+			x, y = outside, outside
+		}
+		tev.Xpos, tev.Ypos = x, y
+		return &tev
+	}
+	return ev
+}
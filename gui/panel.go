@@ -70,6 +70,8 @@ type Panel struct {
 
 	layout       ILayout     // current layout for children
 	layoutParams interface{} // current layout parameters used by container panel
+	contextMenu  *Menu       // menu shown when the panel is right clicked
+	tooltip      *Tooltip    // tooltip shown when the cursor rests over the panel
 
 	marginSizes  RectBounds // external margin sizes in pixel coordinates
 	borderSizes  RectBounds // border sizes in pixel coordinates
@@ -166,6 +168,8 @@ func (p *Panel) Initialize(ipan IPanel, width, height float32) { // TODO rename
 	p.mat.SetUseLights(material.UseLightNone)
 	p.mat.SetShader("panel")
 	p.mat.SetShaderUnique(true)
+	// Panels are double sided so they can also be rendered upside down into offscreen targets
+	p.mat.SetSide(material.SideDouble)
 
 	// For now set all panels as transparent by default
 	// This means they are all rendered back to front, after and on top of everything else
@@ -581,6 +585,33 @@ func (p *Panel) InsideBorders(x, y float32) bool {
 	return true
 }
 
+// VisibleRect returns the rectangle of this panel in screen pixels which
+// is not clipped by its ancestors, as calculated for the last rendered frame.
+func (p *Panel) VisibleRect() Rect {
+
+	return Rect{X: p.xmin, Y: p.ymin, Width: math32.Max(p.xmax-p.xmin, 0), Height: math32.Max(p.ymax-p.ymin, 0)}
+}
+
+// SetScissor enables the scissor test restricting rendering to the visible rectangle of this panel.
+// Widgets which render other content inside a panel, such as 3D sub-viewports, can use it
+// to be clipped like all other panels inside scrolling containers.
+// The scissor test should be disabled after rendering the content.
+func (p *Panel) SetScissor(gs *gls.GLS) {
+
+	_, _, _, height := gs.GetViewport()
+	rp := Manager().renderParams()
+	r := p.VisibleRect()
+	x := int32((r.X - rp.OriginX) * float32(rp.ScaleX))
+	y := int32((r.Y - rp.OriginY) * float32(rp.ScaleY))
+	w := int32(r.Width * float32(rp.ScaleX))
+	h := int32(r.Height * float32(rp.ScaleY))
+	if !rp.FlipY {
+		y = height - y - h
+	}
+	gs.Enable(gls.SCISSOR_TEST)
+	gs.Scissor(x, y, uint32(w), uint32(h))
+}
+
 // Intersects returns if this panel intersects with the other panel
 func (p *Panel) Intersects(other *Panel) bool {
 
@@ -605,6 +636,20 @@ func (p *Panel) Enabled() bool {
 	return p.enabled
 }
 
+// SetContextMenu sets the menu shown at the cursor position when this panel,
+// or a descendant without its own context menu, is clicked with the right mouse button.
+// To remove the context menu, call this function passing nil as parameter.
+func (p *Panel) SetContextMenu(m *Menu) {
+
+	p.contextMenu = m
+}
+
+// ContextMenu returns the context menu of this panel or nil
+func (p *Panel) ContextMenu() *Menu {
+
+	return p.contextMenu
+}
+
 // SetLayout sets the layout to use to position the children of this panel
 // To remove the layout, call this function passing nil as parameter.
 func (p *Panel) SetLayout(ilayout ILayout) {
@@ -862,28 +907,45 @@ func (p *Panel) RenderSetup(gl *gls.GLS, rinfo *core.RenderInfo) {
 	// Transfer panel parameters combined uniform
 	location = p.uniPanel.Location(gl)
 	const vec4count = 8
+	if !gl.LinearColors() {
+		gl.Uniform4fv(location, vec4count, &p.udata.bounds.X)
+		return
+	}
+	// Transfers the colors, which are specified in sRGB, as linear values,
+	// so panels are blended in linear space and encoded by the sRGB framebuffer
+	borders, paddings, content := p.udata.bordersColor, p.udata.paddingsColor, p.udata.contentColor
+	p.udata.bordersColor.ToLinear()
+	p.udata.paddingsColor.ToLinear()
+	p.udata.contentColor.ToLinear()
 	gl.Uniform4fv(location, vec4count, &p.udata.bounds.X)
+	p.udata.bordersColor, p.udata.paddingsColor, p.udata.contentColor = borders, paddings, content
 }
 
 // SetModelMatrix calculates and sets the specified matrix with the model matrix for this panel
 func (p *Panel) SetModelMatrix(gl *gls.GLS, mm *math32.Matrix4) {
 
-	// Get scale of window (for HiDPI support)
-	sX, sY := Manager().win.GetScale()
+	// Get scale of window (for HiDPI support) or of the offscreen target
+	rp := Manager().renderParams()
 
 	// Get the current viewport width and height
 	_, _, width, height := gl.GetViewport()
 
 	// Compute common factors
-	fX := 2 * float32(sX) / float32(width)
-	fY := 2 * float32(sY) / float32(height)
+	fX := 2 * float32(rp.ScaleX) / float32(width)
+	fY := 2 * float32(rp.ScaleY) / float32(height)
 
 	// Calculate the model matrix
 	// Convert pixel coordinates to standard OpenGL clip coordinates and scale the quad for the viewport
 	mm.Set(
-		fX*float32(p.width), 0, 0, fX*p.pospix.X-1,
-		0, fY*float32(p.height), 0, 1-fY*p.pospix.Y,
+		fX*float32(p.width), 0, 0, fX*(p.pospix.X-rp.OriginX)-1,
+		0, fY*float32(p.height), 0, 1-fY*(p.pospix.Y-rp.OriginY),
 		0, 0, 1, p.Position().Z,
 		0, 0, 0, 1,
 	)
+	// Negates the second row to render upside down
+	if rp.FlipY {
+		for i := 1; i < 16; i += 4 {
+			mm[i] = -mm[i]
+		}
+	}
 }
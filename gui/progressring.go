@@ -0,0 +1,221 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// ProgressRing is a circular progress indicator.
+// It shows either the fraction of a task completed as an arc of the ring,
+// or, when indeterminate, an arc spinning around the ring.
+// The spinning relies on the GUI manager timers, so the application must call
+// gui.Manager().ProcessTimers() every frame.
+type ProgressRing struct {
+	Panel                            // Embedded panel
+	style         *ProgressRingStyle // Pointer to current style
+	value         float32            // Completed fraction in the range [0,1]
+	indeterminate bool               // Spinning state
+	start         time.Time          // Start time of the spinning
+	angle         float32            // Current start angle of the spinning arc
+	timer         int                // Spinning timer id
+	label         *Label             // Optional percentage label
+	img           *image.RGBA        // Image with the drawn ring
+	tex           *texture.Texture2D // Texture with the drawn ring
+}
+
+// ProgressRingStyle contains the styling of a ProgressRing.
+type ProgressRingStyle struct {
+	TrackColor math32.Color4 // Color of the ring background
+	FgColor    math32.Color4 // Color of the progress arc
+	TextColor  math32.Color4 // Color of the percentage text
+	Thickness  float32       // Thickness of the ring in pixels
+}
+
+// Spinning parameters of indeterminate progress rings
+const (
+	progressRingSpeed = 1.2  // Turns per second
+	progressRingArc   = 0.25 // Length of the arc as a fraction of the ring
+)
+
+// NewProgressRing creates and returns a pointer to a new progress ring with the specified diameter.
+func NewProgressRing(size float32) *ProgressRing {
+
+	pr := new(ProgressRing)
+	pr.Panel.Initialize(pr, size, size)
+	pr.style = &StyleDefault().ProgressRing
+	pr.Panel.Subscribe(OnResize, func(evname string, ev interface{}) { pr.recalc() })
+	pr.recalc()
+	return pr
+}
+
+// SetValue sets the completed fraction in the range [0,1] and stops the spinning.
+func (pr *ProgressRing) SetValue(value float32) {
+
+	pr.value = math32.Clamp(value, 0, 1)
+	pr.SetIndeterminate(false)
+	if pr.label != nil {
+		pr.label.SetText(fmt.Sprintf("%d%%", int(pr.value*100+0.5)))
+	}
+	pr.recalc()
+}
+
+// Value returns the completed fraction.
+func (pr *ProgressRing) Value() float32 {
+
+	return pr.value
+}
+
+// SetIndeterminate sets whether the ring spins to indicate progress of unknown length.
+func (pr *ProgressRing) SetIndeterminate(state bool) {
+
+	if state == pr.indeterminate {
+		return
+	}
+	pr.indeterminate = state
+	if state {
+		pr.start = time.Now()
+		pr.timer = Manager().SetInterval(16*time.Millisecond, nil, pr.spin)
+	} else {
+		Manager().ClearTimeout(pr.timer)
+		pr.timer = 0
+	}
+	if pr.label != nil {
+		pr.label.SetVisible(!state)
+	}
+	pr.redraw()
+}
+
+// Indeterminate returns whether the ring is spinning.
+func (pr *ProgressRing) Indeterminate() bool {
+
+	return pr.indeterminate
+}
+
+// SetTextVisible sets whether the completed percentage is shown inside the ring.
+func (pr *ProgressRing) SetTextVisible(state bool) {
+
+	if state && pr.label == nil {
+		pr.label = NewLabel(fmt.Sprintf("%d%%", int(pr.value*100+0.5)))
+		pr.label.SetColor4(&pr.style.TextColor)
+		pr.label.SetVisible(!pr.indeterminate)
+		pr.Panel.Add(pr.label)
+	} else if !state && pr.label != nil {
+		pr.Panel.Remove(pr.label)
+		pr.label.Dispose()
+		pr.label = nil
+	}
+	pr.recalc()
+}
+
+// SetStyle sets the style of the progress ring.
+func (pr *ProgressRing) SetStyle(style *ProgressRingStyle) {
+
+	pr.style = style
+	if pr.label != nil {
+		pr.label.SetColor4(&pr.style.TextColor)
+	}
+	pr.redraw()
+}
+
+// Dispose stops the spinning and releases the resources of the progress ring.
+func (pr *ProgressRing) Dispose() {
+
+	pr.SetIndeterminate(false)
+	pr.Panel.Dispose()
+}
+
+// spin is called periodically to rotate the arc of indeterminate rings.
+func (pr *ProgressRing) spin(arg interface{}) {
+
+	turns := float32(time.Since(pr.start).Seconds()) * progressRingSpeed
+	pr.angle = (turns - math32.Floor(turns)) * 2 * math32.Pi
+	pr.redraw()
+}
+
+// recalc positions the percentage label and redraws the ring.
+func (pr *ProgressRing) recalc() {
+
+	if pr.label != nil {
+		pr.label.SetPosition((pr.ContentWidth()-pr.label.Width())/2, (pr.ContentHeight()-pr.label.Height())/2)
+	}
+	pr.redraw()
+}
+
+// redraw draws the ring into the panel texture.
+func (pr *ProgressRing) redraw() {
+
+	width := int(pr.ContentWidth())
+	height := int(pr.ContentHeight())
+	if width <= 0 || height <= 0 {
+		return
+	}
+	if pr.img == nil || pr.img.Rect.Dx() != width || pr.img.Rect.Dy() != height {
+		pr.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	} else {
+		for i := range pr.img.Pix {
+			pr.img.Pix[i] = 0
+		}
+	}
+
+	// Arc of the ring to draw with the foreground color, clockwise from the top
+	start := float32(0)
+	sweep := pr.value * 2 * math32.Pi
+	if pr.indeterminate {
+		start = pr.angle
+		sweep = progressRingArc * 2 * math32.Pi
+	}
+
+	cx := float32(width) / 2
+	cy := float32(height) / 2
+	outer := math32.Min(cx, cy)
+	thick := math32.Min(pr.style.Thickness, outer)
+	mid := outer - thick/2
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx := float32(x) + 0.5 - cx
+			dy := float32(y) + 0.5 - cy
+			d := math32.Sqrt(dx*dx + dy*dy)
+			coverage := thick/2 + 0.5 - math32.Abs(d-mid)
+			if coverage <= 0 {
+				continue
+			}
+			blendPixel(pr.img, x, y, &pr.style.TrackColor, coverage)
+			if sweep <= 0 {
+				continue
+			}
+			if sweep >= 2*math32.Pi {
+				blendPixel(pr.img, x, y, &pr.style.FgColor, coverage)
+				continue
+			}
+			// Angle of the pixel relative to the start of the arc
+			a := math32.Atan2(dx, -dy) - start
+			for a < 0 {
+				a += 2 * math32.Pi
+			}
+			if a > sweep+1/d {
+				continue
+			}
+			// Smooths the ends of the arc
+			edge := math32.Min(a*d, (sweep-a)*d) + 0.5
+			blendPixel(pr.img, x, y, &pr.style.FgColor, math32.Min(coverage, edge))
+		}
+	}
+
+	if pr.tex == nil {
+		pr.tex = texture.NewTexture2DFromRGBA(pr.img)
+		pr.tex.SetMagFilter(gls.NEAREST)
+		pr.tex.SetMinFilter(gls.NEAREST)
+		pr.Panel.Material().AddTexture(pr.tex)
+	} else {
+		pr.tex.SetFromRGBA(pr.img)
+	}
+}
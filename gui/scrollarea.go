@@ -0,0 +1,188 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"time"
+
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// ScrollArea is a generic scrolling container.
+// Its children are added to an internal content panel which is resized to
+// contain all of them, and which can be scrolled with the scrollbars, the mouse
+// wheel or by dragging it with the left mouse button.
+// When Kinetic is true the content keeps scrolling with decreasing speed after
+// it is dragged and released. Kinetic scrolling relies on the GUI manager timers,
+// so the application must call gui.Manager().ProcessTimers() every frame.
+type ScrollArea struct {
+	Scroller             // Embedded scroller
+	Kinetic    bool      // Content keeps scrolling after being dragged and released
+	Damping    float32   // Fraction of the kinetic scrolling speed lost per second
+	area       *Panel    // Content panel containing the children
+	dragging   bool      // Content is being dragged
+	dragX      float32   // Last cursor position while dragging
+	dragY      float32   // Last cursor position while dragging
+	dragTime   time.Time // Time of the last cursor event while dragging
+	velX       float32   // Scrolling velocity in pixels per second
+	velY       float32   // Scrolling velocity in pixels per second
+	kineticID  int       // Timer for kinetic scrolling
+	kineticRun time.Time // Time of the last kinetic scrolling step
+}
+
+// NewScrollArea creates and returns a pointer to a new scroll area
+// with the specified size and scroll mode.
+func NewScrollArea(width, height float32, mode ScrollMode) *ScrollArea {
+
+	sa := new(ScrollArea)
+	sa.area = NewPanel(0, 0)
+	sa.Scroller.initialize(width, height, mode, sa.area)
+	sa.Kinetic = true
+	sa.Damping = 0.95
+	sa.Scroller.Panel.Subscribe(OnMouseDown, sa.onMouse)
+	sa.Scroller.Panel.Subscribe(OnCursor, sa.onCursor)
+	sa.Scroller.Panel.Subscribe(OnScroll, sa.onWheel)
+	// The button may be released over a child panel which would receive the event
+	window.Get().SubscribeID(window.OnMouseUp, sa, sa.onMouse)
+	return sa
+}
+
+// Dispose releases the resources of the scroll area and its children.
+func (sa *ScrollArea) Dispose() {
+
+	sa.StopScrolling()
+	window.Get().UnsubscribeID(window.OnMouseUp, sa)
+	sa.Scroller.Dispose()
+}
+
+// Content returns the content panel which contains the children of the scroll area.
+// It can be used to set a layout for the children.
+func (sa *ScrollArea) Content() *Panel {
+
+	return sa.area
+}
+
+// Add adds a child panel to the content of the scroll area and updates the content size.
+func (sa *ScrollArea) Add(ichild IPanel) *ScrollArea {
+
+	sa.area.Add(ichild)
+	ichild.SubscribeID(OnResize, sa, func(evname string, ev interface{}) { sa.UpdateContent() })
+	sa.UpdateContent()
+	return sa
+}
+
+// Remove removes a child panel from the content of the scroll area and updates the content size.
+func (sa *ScrollArea) Remove(ichild IPanel) bool {
+
+	found := sa.area.Remove(ichild)
+	if found {
+		ichild.UnsubscribeID(OnResize, sa)
+		sa.UpdateContent()
+	}
+	return found
+}
+
+// UpdateContent resizes the content panel to contain all its children and updates the scrollbars.
+// It must be called after the children are moved.
+func (sa *ScrollArea) UpdateContent() {
+
+	var width, height float32
+	for _, ichild := range sa.area.Children() {
+		child := ichild.(IPanel).GetPanel()
+		if !child.Visible() {
+			continue
+		}
+		width = math32.Max(width, child.Position().X+child.Width())
+		height = math32.Max(height, child.Position().Y+child.Height())
+	}
+	sa.area.SetContentSize(width, height)
+	sa.Scroller.Update()
+}
+
+// StopScrolling stops the kinetic scrolling if active.
+func (sa *ScrollArea) StopScrolling() {
+
+	if sa.kineticID != 0 {
+		Manager().ClearTimeout(sa.kineticID)
+		sa.kineticID = 0
+	}
+	sa.velX = 0
+	sa.velY = 0
+}
+
+// onWheel stops kinetic scrolling when the mouse wheel is used.
+func (sa *ScrollArea) onWheel(evname string, ev interface{}) {
+
+	sa.StopScrolling()
+}
+
+// onMouse starts and ends dragging the content.
+func (sa *ScrollArea) onMouse(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	if mev.Button != window.MouseButtonLeft {
+		return
+	}
+	switch evname {
+	case OnMouseDown:
+		sa.StopScrolling()
+		sa.dragging = true
+		sa.dragX = mev.Xpos
+		sa.dragY = mev.Ypos
+		sa.dragTime = time.Now()
+		Manager().SetCursorFocus(sa)
+	case OnMouseUp:
+		if !sa.dragging {
+			return
+		}
+		sa.dragging = false
+		Manager().SetCursorFocus(nil)
+		// Starts kinetic scrolling if the content was released while moving
+		if time.Since(sa.dragTime) > 100*time.Millisecond {
+			sa.velX = 0
+			sa.velY = 0
+		}
+		if sa.Kinetic && (math32.Abs(sa.velX) > 20 || math32.Abs(sa.velY) > 20) {
+			sa.kineticRun = time.Now()
+			sa.kineticID = Manager().SetInterval(16*time.Millisecond, nil, sa.onKinetic)
+		}
+	}
+}
+
+// onCursor scrolls the content while it is dragged.
+func (sa *ScrollArea) onCursor(evname string, ev interface{}) {
+
+	if !sa.dragging {
+		return
+	}
+	cev := ev.(*window.CursorEvent)
+	dx, dy := sa.ScrollBy(sa.dragX-cev.Xpos, sa.dragY-cev.Ypos)
+	now := time.Now()
+	if dt := float32(now.Sub(sa.dragTime).Seconds()); dt > 0 {
+		// Smooths the velocity over the last cursor events
+		sa.velX = 0.5*sa.velX + 0.5*dx/dt
+		sa.velY = 0.5*sa.velY + 0.5*dy/dt
+	}
+	sa.dragX = cev.Xpos
+	sa.dragY = cev.Ypos
+	sa.dragTime = now
+}
+
+// onKinetic is called periodically while the content is scrolling kinetically.
+func (sa *ScrollArea) onKinetic(arg interface{}) {
+
+	now := time.Now()
+	dt := float32(now.Sub(sa.kineticRun).Seconds())
+	sa.kineticRun = now
+	dx, dy := sa.ScrollBy(sa.velX*dt, sa.velY*dt)
+	decay := math32.Pow(1-math32.Clamp(sa.Damping, 0, 0.999), dt)
+	sa.velX *= decay
+	sa.velY *= decay
+	// Stops when slow or when the content reached its limits
+	if (dx == 0 && dy == 0) || (math32.Abs(sa.velX) < 10 && math32.Abs(sa.velY) < 10) {
+		sa.StopScrolling()
+	}
+}
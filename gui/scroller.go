@@ -24,6 +24,7 @@ type Scroller struct {
 	style      *ScrollerStyle // The current style
 	corner     *Panel         // The optional corner panel (can be visible when scrollMode==Both, interlocking==None, corner=true)
 	cursorOver bool           // Cursor is over the scroller
+	chain      bool           // Scroll events are passed to the parent when the scroller can't scroll further
 }
 
 // ScrollMode specifies which scroll directions are allowed.
@@ -85,6 +86,9 @@ type ScrollerScrollbarStyle struct {
 // ScrollModifierKey is the ModifierKey that changes the scrolling direction from vertical to horizontal when pressed
 const ScrollModifierKey = window.ModShift
 
+// ScrollWheelStep is the distance in pixels scrolled by each step of the mouse wheel
+var ScrollWheelStep float32 = 40
+
 // NewScroller creates and returns a pointer to a new Scroller with the specified
 // target IPanel and ScrollMode.
 func NewScroller(width, height float32, mode ScrollMode, target IPanel) *Scroller {
@@ -102,6 +106,7 @@ func (s *Scroller) initialize(width, height float32, mode ScrollMode, target IPa
 	s.target = target
 	s.Panel.Add(s.target)
 	s.mode = mode
+	s.chain = true
 
 	s.Subscribe(OnResize, s.onResize)
 	s.Subscribe(OnScroll, s.onScroll)
@@ -268,48 +273,190 @@ func (s *Scroller) HorizontalScrollbarBroadness() float32 {
 	return s.style.HorizontalScrollbar.Broadness
 }
 
+// SetScrollChaining sets whether scroll events are passed to the nearest scrollable
+// ancestor when this scroller can't scroll further in the requested direction.
+// Scroll chaining is enabled by default so nested scrollers behave naturally.
+func (s *Scroller) SetScrollChaining(state bool) {
+
+	s.chain = state
+}
+
+// ScrollChaining returns whether scroll chaining is enabled
+func (s *Scroller) ScrollChaining() bool {
+
+	return s.chain
+}
+
+// ScrollPosition returns the position in pixels of the view area inside the target panel
+func (s *Scroller) ScrollPosition() (float32, float32) {
+
+	var x, y float32
+	rangeX, rangeY := s.scrollRange()
+	if s.hscroll != nil && s.hscroll.Visible() {
+		x = float32(s.hscroll.Value()) * rangeX
+	}
+	if s.vscroll != nil && s.vscroll.Visible() {
+		y = float32(s.vscroll.Value()) * rangeY
+	}
+	return x, y
+}
+
+// SetScrollPosition scrolls the target panel so the view area starts at the specified
+// position in pixels inside the target panel, limited to the scrollable range
+func (s *Scroller) SetScrollPosition(x, y float32) {
+
+	rangeX, rangeY := s.scrollRange()
+	if s.hscroll != nil && s.hscroll.Visible() && rangeX > 0 {
+		s.hscroll.SetValue(x / rangeX)
+	}
+	if s.vscroll != nil && s.vscroll.Visible() && rangeY > 0 {
+		s.vscroll.SetValue(y / rangeY)
+	}
+	s.recalc()
+}
+
+// ScrollBy scrolls the target panel by the specified distances in pixels
+// and returns the distances actually scrolled.
+func (s *Scroller) ScrollBy(dx, dy float32) (float32, float32) {
+
+	x0, y0 := s.ScrollPosition()
+	s.SetScrollPosition(x0+dx, y0+dy)
+	x1, y1 := s.ScrollPosition()
+	return x1 - x0, y1 - y0
+}
+
 // ScrollTo scrolls the target panel such that the specified target point is centered on the scroller's view area
 func (s *Scroller) ScrollTo(x, y float32) {
-	// TODO
+
+	viewWidth, viewHeight := s.viewSize()
+	s.SetScrollPosition(x-viewWidth/2, y-viewHeight/2)
+}
+
+// EnsureVisible scrolls the target panel the minimum needed to show the specified
+// descendant of the target panel inside the view area
+func (s *Scroller) EnsureVisible(ipan IPanel) {
+
+	x, y, ok := panelOffset(ipan, s.target)
+	if !ok {
+		return
+	}
+	viewWidth, viewHeight := s.viewSize()
+	scrollX, scrollY := s.ScrollPosition()
+	if x+ipan.GetPanel().Width() > scrollX+viewWidth {
+		scrollX = x + ipan.GetPanel().Width() - viewWidth
+	}
+	if x < scrollX {
+		scrollX = x
+	}
+	if y+ipan.GetPanel().Height() > scrollY+viewHeight {
+		scrollY = y + ipan.GetPanel().Height() - viewHeight
+	}
+	if y < scrollY {
+		scrollY = y
+	}
+	s.SetScrollPosition(scrollX, scrollY)
+}
+
+// SetStyle sets the style of the scroller, its scrollbars and corner panel
+func (s *Scroller) SetStyle(ss *ScrollerStyle) {
+
+	s.applyStyle(ss)
+}
+
+// scrollRange returns the distances in pixels the target panel can be scrolled
+func (s *Scroller) scrollRange() (float32, float32) {
+
+	rangeX := s.target.Width() - s.content.Width
+	rangeY := s.target.Height() - s.content.Height
+	if s.vscroll != nil && s.vscroll.Visible() && !s.style.VerticalScrollbar.OverlapContent {
+		rangeX += s.vscroll.width
+	}
+	if s.hscroll != nil && s.hscroll.Visible() && !s.style.HorizontalScrollbar.OverlapContent {
+		rangeY += s.hscroll.height
+	}
+	return math32.Max(rangeX, 0), math32.Max(rangeY, 0)
+}
+
+// viewSize returns the size of the view area not covered by the scrollbars
+func (s *Scroller) viewSize() (float32, float32) {
+
+	width := s.content.Width
+	height := s.content.Height
+	if s.vscroll != nil && s.vscroll.Visible() && !s.style.VerticalScrollbar.OverlapContent {
+		width -= s.vscroll.width
+	}
+	if s.hscroll != nil && s.hscroll.Visible() && !s.style.HorizontalScrollbar.OverlapContent {
+		height -= s.hscroll.height
+	}
+	return width, height
+}
+
+// panelOffset returns the position of the specified panel relative to the content area
+// of the specified ancestor or false if it is not a descendant of the ancestor
+func panelOffset(ipan, ancestor IPanel) (float32, float32, bool) {
+
+	var x, y float32
+	for ipan != ancestor {
+		p := ipan.GetPanel()
+		x += p.Position().X
+		y += p.Position().Y
+		par, ok := p.Parent().(IPanel)
+		if !ok {
+			return 0, 0, false
+		}
+		if par != ancestor && p.bounded {
+			pp := par.GetPanel()
+			x += pp.marginSizes.Left + pp.borderSizes.Left + pp.paddingSizes.Left
+			y += pp.marginSizes.Top + pp.borderSizes.Top + pp.paddingSizes.Top
+		}
+		ipan = par
+	}
+	return x, y, true
+}
+
+// propagateScroll sends a scroll event which could not be used by the specified panel
+// to its nearest subscribed ancestor, so nested scrollable panels scroll their
+// parents when they reach their limits
+func propagateScroll(ipan IPanel, ev interface{}) {
+
+	par, ok := ipan.Parent().(IPanel)
+	if !ok {
+		return
+	}
+	modal := Manager().modal
+	if modal != nil && !modal.IsAncestorOf(par) {
+		return
+	}
+	sendAncestry(par, false, nil, modal, OnScroll, ev)
 }
 
 // onScroll receives mouse scroll events when this scroller has the scroll focus (set by OnMouseEnter)
 func (s *Scroller) onScroll(evname string, ev interface{}) {
 
 	sev := ev.(*window.ScrollEvent)
-
-	vScrollVisible := (s.vscroll != nil) && s.vscroll.Visible()
-	hScrollVisible := (s.hscroll != nil) && s.hscroll.Visible()
-
-	mult := float32(1) / float32(10)
-	offsetX := sev.Xoffset * mult
-	offsetY := sev.Yoffset * mult
+	dx := -sev.Xoffset * ScrollWheelStep
+	dy := -sev.Yoffset * ScrollWheelStep
 
 	// If modifier key is pressed (left shift by default) - then scroll in the horizontal direction
 	if sev.Mods&ScrollModifierKey > 0 {
-		if math32.Abs(offsetY) > math32.Abs(offsetX) {
-			offsetX = offsetY
+		if math32.Abs(dy) > math32.Abs(dx) {
+			dx = dy
 		}
-		offsetY = 0
+		dy = 0
 	}
 
-	log.Error("X: %v, Y: %v", offsetX, offsetY)
-
-	if vScrollVisible {
-		if hScrollVisible {
-			// Both scrollbars are present - scroll both
-			s.vscroll.SetValue(float32(s.vscroll.Value()) - offsetY)
-			s.hscroll.SetValue(float32(s.hscroll.Value()) - offsetX)
-		} else {
-			// Only vertical scrollbar present - scroll it
-			s.vscroll.SetValue(float32(s.vscroll.Value()) - offsetY)
-		}
-	} else if hScrollVisible {
-		// Only horizontal scrollbar present - scroll it
-		s.hscroll.SetValue(float32(s.hscroll.Value()) - offsetX)
+	// If only horizontal scrolling is possible the wheel scrolls horizontally
+	vScrollVisible := (s.vscroll != nil) && s.vscroll.Visible()
+	hScrollVisible := (s.hscroll != nil) && s.hscroll.Visible()
+	if hScrollVisible && !vScrollVisible && dx == 0 {
+		dx = dy
 	}
 
-	s.recalc()
+	// If the scroller couldn't scroll, the event is passed to the parent
+	sx, sy := s.ScrollBy(dx, dy)
+	if sx == 0 && sy == 0 && s.chain {
+		propagateScroll(s, ev)
+	}
 }
 
 // onResize receives resize events
@@ -555,14 +702,21 @@ func (s *Scroller) Update() {
 	s.recalc()
 }
 
-// TODO - if the style is changed this needs to be called to update the scrollbars and corner panel
+// applyStyle applies the specified style to the scroller, its scrollbars and corner panel
 func (s *Scroller) applyStyle(ss *ScrollerStyle) {
 
 	s.style = ss
-
-	s.vscroll.applyStyle(&s.style.VerticalScrollbar.ScrollBarStyle)
-	s.hscroll.applyStyle(&s.style.HorizontalScrollbar.ScrollBarStyle)
-	s.corner.ApplyStyle(&s.style.CornerPanel)
-
+	s.Panel.ApplyStyle(&ss.PanelStyle)
+	if s.vscroll != nil {
+		s.vscroll.applyStyle(&s.style.VerticalScrollbar.ScrollBarStyle)
+		s.vscroll.SetWidth(s.style.VerticalScrollbar.Broadness)
+	}
+	if s.hscroll != nil {
+		s.hscroll.applyStyle(&s.style.HorizontalScrollbar.ScrollBarStyle)
+		s.hscroll.SetHeight(s.style.HorizontalScrollbar.Broadness)
+	}
+	if s.corner != nil {
+		s.corner.ApplyStyle(&s.style.CornerPanel)
+	}
 	s.Update()
 }
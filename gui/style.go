@@ -14,6 +14,7 @@ type Style struct {
 	Color         ColorStyle
 	Font          *text.Font
 	FontIcon      *text.Font
+	FontMono      *text.Font
 	Label         LabelStyle
 	Button        ButtonStyles
 	CheckRadio    CheckRadioStyles
@@ -33,6 +34,10 @@ type Style struct {
 	Table         TableStyles
 	ImageButton   ImageButtonStyles
 	TabBar        TabBarStyles
+	CodeEditor    CodeEditorStyles
+	Tooltip       TooltipStyle
+	ProgressRing  ProgressRingStyle
+	Badge         BadgeStyle
 }
 
 // ColorStyle defines the main colors used.
@@ -45,6 +50,7 @@ type ColorStyle struct {
 	Select    math32.Color4
 	Text      math32.Color4
 	TextDis   math32.Color4
+	Series    math32.Palette // Colors of data series, such as the graphs of a chart
 }
 
 // States that a GUI element can be in
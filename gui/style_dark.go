@@ -17,6 +17,7 @@ func NewDarkStyle() *Style {
 	// Fonts to use
 	const textFont = "fonts/FreeSans.ttf"
 	const iconFont = "fonts/MaterialIcons-Regular.ttf"
+	const monoFont = "fonts/FreeMono.ttf"
 	s := new(Style)
 
 	// Creates text font
@@ -35,6 +36,14 @@ func NewDarkStyle() *Style {
 	}
 	s.FontIcon = fontIcon
 
+	// Creates monospaced font
+	fontMonoData := assets.MustAsset(monoFont)
+	fontMono, err := text.NewFontFromData(fontMonoData)
+	if err != nil {
+		panic(err)
+	}
+	s.FontMono = fontMono
+
 	zeroBounds := RectBounds{0, 0, 0, 0}
 	oneBounds := RectBounds{1, 1, 1, 1}
 	twoBounds := RectBounds{2, 2, 2, 2}
@@ -47,6 +56,7 @@ func NewDarkStyle() *Style {
 	s.Color.Select = math32.Color4{13.0 / 256.0, 41.0 / 256.0, 62.0 / 256.0, 1}
 	s.Color.Text = math32.Color4{1, 1, 1, 1}
 	s.Color.TextDis = math32.Color4{0.4, 0.4, 0.4, 1}
+	s.Color.Series, _ = math32.PaletteName("category10")
 
 	borderColor := s.Color.BgDark
 	transparent := math32.Color4{0, 0, 0, 0}
@@ -398,5 +408,60 @@ func NewDarkStyle() *Style {
 	s.TabBar.Tab.Selected = s.TabBar.Tab.Normal
 	s.TabBar.Tab.Selected.BgColor = s.Color.BgOver
 
+	// CodeEditor styles
+	s.CodeEditor = CodeEditorStyles{}
+	s.CodeEditor.Normal = CodeEditorStyle{
+		Border:           oneBounds,
+		Paddings:         zeroBounds,
+		BorderColor:      borderColor,
+		BgColor:          s.Color.BgMed,
+		FgColor:          math32.Color4{R: 0.85, G: 0.85, B: 0.85, A: 1},
+		GutterColor:      s.Color.BgNormal,
+		LineNumberColor:  math32.Color4{R: 0.55, G: 0.55, B: 0.55, A: 1},
+		CurrentLineColor: math32.Color4{R: 1, G: 1, B: 1, A: 0.05},
+		SelectionColor:   math32.Color4{R: 33.0 / 256.0, G: 66.0 / 256.0, B: 131.0 / 256.0, A: 1},
+		CaretColor:       s.Color.Text,
+		TokenColors: map[TokenKind]math32.Color4{
+			TokenKeyword:      {R: 0.8, G: 0.47, B: 0.2, A: 1},
+			TokenType:         {R: 0.8, G: 0.47, B: 0.2, A: 1},
+			TokenBuiltin:      {R: 1, G: 0.78, B: 0.43, A: 1},
+			TokenNumber:       {R: 0.41, G: 0.59, B: 0.73, A: 1},
+			TokenString:       {R: 0.42, G: 0.53, B: 0.35, A: 1},
+			TokenComment:      {R: 0.5, G: 0.5, B: 0.5, A: 1},
+			TokenPreprocessor: {R: 0.73, G: 0.71, B: 0.16, A: 1},
+		},
+	}
+	s.CodeEditor.Over = s.CodeEditor.Normal
+	s.CodeEditor.Focus = s.CodeEditor.Normal
+	s.CodeEditor.Focus.BorderColor = s.Color.Highlight
+	s.CodeEditor.Disabled = s.CodeEditor.Normal
+	s.CodeEditor.Disabled.FgColor = s.Color.TextDis
+
+	// Tooltip style
+	s.Tooltip = TooltipStyle{}
+	s.Tooltip.Border = oneBounds
+	s.Tooltip.Padding = RectBounds{Top: 3, Right: 6, Bottom: 3, Left: 6}
+	s.Tooltip.BorderColor = borderColor
+	s.Tooltip.BgColor = s.Color.BgOver
+	s.Tooltip.FgColor = s.Color.Text
+	s.Tooltip.HintColor = math32.Color4{R: 0.6, G: 0.6, B: 0.6, A: 1}
+	s.Tooltip.Spacing = 4
+	s.Tooltip.Offset = 8
+
+	// ProgressRing style
+	s.ProgressRing = ProgressRingStyle{}
+	s.ProgressRing.TrackColor = s.Color.BgNormal
+	s.ProgressRing.FgColor = s.Color.Highlight
+	s.ProgressRing.TextColor = s.Color.Text
+	s.ProgressRing.Thickness = 4
+
+	// Badge style
+	s.Badge = BadgeStyle{}
+	s.Badge.BgColor = math32.Color4{R: 0.85, G: 0.2, B: 0.2, A: 1}
+	s.Badge.FgColor = math32.Color4{R: 1, G: 1, B: 1, A: 1}
+	s.Badge.PointSize = 11
+	s.Badge.Padding = 4
+	s.Badge.Offset = 2
+
 	return s
 }
@@ -17,6 +17,7 @@ func NewLightStyle() *Style {
 	// Fonts to use
 	const fontName = "fonts/FreeSans.ttf"
 	const iconName = "fonts/MaterialIcons-Regular.ttf"
+	const monoName = "fonts/FreeMono.ttf"
 	s := new(Style)
 
 	// Creates text font
@@ -35,10 +36,20 @@ func NewLightStyle() *Style {
 	}
 	s.FontIcon = fontIcon
 
+	// Creates monospaced font
+	fontMonoData := assets.MustAsset(monoName)
+	fontMono, err := text.NewFontFromData(fontMonoData)
+	if err != nil {
+		panic(err)
+	}
+	s.FontMono = fontMono
+
 	zeroBounds := RectBounds{0, 0, 0, 0}
 	oneBounds := RectBounds{1, 1, 1, 1}
 	twoBounds := RectBounds{2, 2, 2, 2}
 
+	s.Color.Series, _ = math32.PaletteName("category10")
+
 	borderColor := math32.Color4Name("DimGray")
 	borderColorDis := math32.Color4Name("LightGray")
 
@@ -394,5 +405,59 @@ func NewLightStyle() *Style {
 	s.TabBar.Tab.Selected = s.TabBar.Tab.Normal
 	s.TabBar.Tab.Selected.BgColor = math32.Color4{0.85, 0.85, 0.85, 1}
 
+	// CodeEditor styles
+	s.CodeEditor = CodeEditorStyles{}
+	s.CodeEditor.Normal = CodeEditorStyle{
+		Border:           oneBounds,
+		Paddings:         zeroBounds,
+		BorderColor:      borderColor,
+		BgColor:          math32.Color4{R: 1, G: 1, B: 1, A: 1},
+		FgColor:          fgColor,
+		GutterColor:      math32.Color4{R: 0.93, G: 0.93, B: 0.93, A: 1},
+		LineNumberColor:  math32.Color4{R: 0.6, G: 0.6, B: 0.6, A: 1},
+		CurrentLineColor: math32.Color4{R: 0, G: 0, B: 0, A: 0.04},
+		SelectionColor:   math32.Color4{R: 0.65, G: 0.8, B: 1, A: 1},
+		CaretColor:       fgColor,
+		TokenColors: map[TokenKind]math32.Color4{
+			TokenKeyword:      {R: 0, G: 0, B: 0.5, A: 1},
+			TokenType:         {R: 0, G: 0, B: 0.5, A: 1},
+			TokenBuiltin:      {R: 0.4, G: 0, B: 0.6, A: 1},
+			TokenNumber:       {R: 0, G: 0, B: 1, A: 1},
+			TokenString:       {R: 0, G: 0.5, B: 0, A: 1},
+			TokenComment:      {R: 0.5, G: 0.5, B: 0.5, A: 1},
+			TokenPreprocessor: {R: 0.5, G: 0.5, B: 0, A: 1},
+		},
+	}
+	s.CodeEditor.Over = s.CodeEditor.Normal
+	s.CodeEditor.Focus = s.CodeEditor.Normal
+	s.CodeEditor.Disabled = s.CodeEditor.Normal
+	s.CodeEditor.Disabled.FgColor = fgColorDis
+
+	// Tooltip style
+	s.Tooltip = TooltipStyle{}
+	s.Tooltip.Border = oneBounds
+	s.Tooltip.Padding = RectBounds{Top: 3, Right: 6, Bottom: 3, Left: 6}
+	s.Tooltip.BorderColor = borderColor
+	s.Tooltip.BgColor = math32.Color4{R: 1, G: 1, B: 0.88, A: 1}
+	s.Tooltip.FgColor = fgColor
+	s.Tooltip.HintColor = math32.Color4{R: 0.4, G: 0.4, B: 0.4, A: 1}
+	s.Tooltip.Spacing = 4
+	s.Tooltip.Offset = 8
+
+	// ProgressRing style
+	s.ProgressRing = ProgressRingStyle{}
+	s.ProgressRing.TrackColor = math32.Color4{R: 0.85, G: 0.85, B: 0.85, A: 1}
+	s.ProgressRing.FgColor = math32.Color4{R: 0.2, G: 0.5, B: 0.9, A: 1}
+	s.ProgressRing.TextColor = fgColor
+	s.ProgressRing.Thickness = 4
+
+	// Badge style
+	s.Badge = BadgeStyle{}
+	s.Badge.BgColor = math32.Color4{R: 0.9, G: 0.2, B: 0.2, A: 1}
+	s.Badge.FgColor = math32.Color4{R: 1, G: 1, B: 1, A: 1}
+	s.Badge.PointSize = 11
+	s.Badge.Padding = 4
+	s.Badge.Offset = 2
+
 	return s
 }
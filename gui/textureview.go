@@ -0,0 +1,146 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// TextureView is a panel which shows a texture scaled to its content area
+// and optionally rotated around its center.
+// It is normally used to composite a GUI rendered offscreen into the window,
+// for example to super-sample it or to apply effects with a custom shader set
+// in the panel material. The view itself should not be part of the GUI it shows.
+type TextureView struct {
+	Panel                        // Embedded panel
+	tex       *texture.Texture2D // Texture being shown
+	angle     float32            // Rotation around the center in radians (clockwise)
+	srcWidth  float32            // Size in GUI pixels of the content of the texture
+	srcHeight float32            // Size in GUI pixels of the content of the texture
+}
+
+// NewTextureView creates and returns a pointer to a new texture view
+// with the specified size showing the specified texture.
+func NewTextureView(width, height float32, tex *texture.Texture2D) *TextureView {
+
+	tv := new(TextureView)
+	tv.Panel.Initialize(tv, width, height)
+	// The panel material must be rendered with this view's RenderSetup
+	tv.Graphic.SetIGraphic(tv)
+	tv.SetTexture(tex)
+	return tv
+}
+
+// SetTexture sets the texture shown by the view.
+// The view does not take ownership of the texture.
+func (tv *TextureView) SetTexture(tex *texture.Texture2D) {
+
+	if tv.tex != nil {
+		tv.Material().RemoveTexture(tv.tex)
+		tv.tex.Dispose()
+	}
+	tv.tex = tex
+	if tex != nil {
+		tv.Material().AddTexture(tex.Incref())
+		if tv.srcWidth == 0 || tv.srcHeight == 0 {
+			tv.srcWidth = float32(tex.Width())
+			tv.srcHeight = float32(tex.Height())
+		}
+	}
+}
+
+// Texture returns the texture shown by the view.
+func (tv *TextureView) Texture() *texture.Texture2D {
+
+	return tv.tex
+}
+
+// SetAngle sets the clockwise rotation of the view around its center in radians.
+func (tv *TextureView) SetAngle(angle float32) {
+
+	tv.angle = angle
+}
+
+// Angle returns the clockwise rotation of the view around its center in radians.
+func (tv *TextureView) Angle() float32 {
+
+	return tv.angle
+}
+
+// SetSourceSize sets the size in GUI pixels of the content of the texture,
+// used to map positions over the view to positions in the shown GUI.
+// It defaults to the texture size.
+func (tv *TextureView) SetSourceSize(width, height float32) {
+
+	tv.srcWidth = width
+	tv.srcHeight = height
+}
+
+// ToSource maps the specified window position to a position in the GUI shown by the view,
+// taking into account its size and rotation. It returns false if the position is outside the view.
+// It can be used as the GUI manager cursor transform.
+func (tv *TextureView) ToSource(x, y float32) (float32, float32, bool) {
+
+	pos := tv.Pospix()
+	cx := pos.X + tv.width/2
+	cy := pos.Y + tv.height/2
+	// Undo the rotation around the center
+	s, c := math32.Sin(-tv.angle), math32.Cos(-tv.angle)
+	dx, dy := x-cx, y-cy
+	rx := cx + c*dx - s*dy
+	ry := cy + s*dx + c*dy
+	// Map the content area to the source size
+	cx, cy = tv.ContentCoords(rx, ry)
+	cw, ch := tv.ContentWidth(), tv.ContentHeight()
+	if cw <= 0 || ch <= 0 || cx < 0 || cy < 0 || cx >= cw || cy >= ch {
+		return 0, 0, false
+	}
+	return cx * tv.srcWidth / cw, cy * tv.srcHeight / ch, true
+}
+
+// Dispose releases the resources of the view.
+func (tv *TextureView) Dispose() {
+
+	tv.SetTexture(nil)
+	tv.Panel.Dispose()
+}
+
+// RenderSetup is called by the renderer before drawing this graphic.
+// It overrides the panel RenderSetup to rotate the panel around its center.
+func (tv *TextureView) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	tv.Panel.RenderSetup(gs, rinfo)
+	if tv.angle == 0 {
+		return
+	}
+
+	rp := Manager().renderParams()
+	_, _, width, height := gs.GetViewport()
+	fX := 2 * float32(rp.ScaleX) / float32(width)
+	fY := 2 * float32(rp.ScaleY) / float32(height)
+	s, c := math32.Sin(tv.angle), math32.Cos(tv.angle)
+	w, h := tv.width, tv.height
+	cx := tv.pospix.X - rp.OriginX + w/2
+	cy := tv.pospix.Y - rp.OriginY + h/2
+
+	// Rotates the quad in pixel coordinates (Y down) before converting to clip coordinates
+	var mm math32.Matrix4
+	mm.Set(
+		fX*c*w, fX*s*h, 0, fX*(cx-c*w/2+s*h/2)-1,
+		-fY*s*w, fY*c*h, 0, 1-fY*(cy-s*w/2-c*h/2),
+		0, 0, 1, tv.Position().Z,
+		0, 0, 0, 1,
+	)
+	if rp.FlipY {
+		for i := 1; i < 16; i += 4 {
+			mm[i] = -mm[i]
+		}
+	}
+	location := tv.uniMatrix.Location(gs)
+	gs.UniformMatrix4fv(location, 1, false, &mm[0])
+}
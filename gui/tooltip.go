@@ -0,0 +1,330 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"time"
+
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// Tooltip delay policy.
+// Tooltips are shown after the cursor rests over a widget for TooltipDelay.
+// If another tooltip was visible less than TooltipReshowWindow ago,
+// the next tooltip is shown after TooltipReshowDelay instead, so moving
+// the cursor along a toolbar shows the tooltips of its buttons immediately.
+// Tooltips rely on the GUI manager timers, so the application must call
+// gui.Manager().ProcessTimers() every frame.
+var (
+	TooltipDelay        = 600 * time.Millisecond
+	TooltipReshowDelay  = 50 * time.Millisecond
+	TooltipReshowWindow = 500 * time.Millisecond
+)
+
+// TooltipPlacement specifies where a tooltip is shown.
+type TooltipPlacement int
+
+// The tooltip placements
+const (
+	TooltipFollow = TooltipPlacement(iota) // Below and to the right of the cursor, following it
+	TooltipBelow                           // Anchored below the widget
+	TooltipAbove                           // Anchored above the widget
+	TooltipRight                           // Anchored to the right of the widget
+	TooltipLeft                            // Anchored to the left of the widget
+)
+
+// Tooltip is a small panel shown over a widget when the cursor rests on it.
+// It can contain an image, a text and a key hint such as "Ctrl+S".
+type Tooltip struct {
+	Panel                      // Embedded panel
+	Placement TooltipPlacement // Where the tooltip is shown
+	Delay     time.Duration    // Delay before showing the tooltip (0 uses TooltipDelay)
+	Duration  time.Duration    // Time the tooltip stays visible (0 for as long as the cursor is over the widget)
+	styles    *TooltipStyle    // Pointer to current style
+	image     *Image           // Optional image
+	label     *Label           // Text label
+	hint      *Label           // Optional key hint label
+}
+
+// TooltipStyle contains the styling of a Tooltip.
+type TooltipStyle struct {
+	PanelStyle
+	FgColor   math32.Color4 // Text color
+	HintColor math32.Color4 // Key hint color
+	Spacing   float32       // Horizontal space between the image, text and key hint
+	Offset    float32       // Distance from the cursor or anchor widget
+}
+
+// NewTooltip creates and returns a pointer to a new tooltip with the specified text.
+func NewTooltip(text string) *Tooltip {
+
+	t := new(Tooltip)
+	t.Panel.Initialize(t, 0, 0)
+	t.styles = &StyleDefault().Tooltip
+	t.label = NewLabel(text)
+	t.Panel.Add(t.label)
+	t.update()
+	return t
+}
+
+// SetText sets the text of the tooltip.
+func (t *Tooltip) SetText(text string) *Tooltip {
+
+	t.label.SetText(text)
+	t.recalc()
+	return t
+}
+
+// Text returns the text of the tooltip.
+func (t *Tooltip) Text() string {
+
+	return t.label.Text()
+}
+
+// SetImage sets the image shown to the left of the text or removes it if nil.
+func (t *Tooltip) SetImage(img *Image) *Tooltip {
+
+	if t.image != nil {
+		t.Panel.Remove(t.image)
+	}
+	t.image = img
+	if img != nil {
+		t.Panel.Add(img)
+	}
+	t.recalc()
+	return t
+}
+
+// SetKeyHint sets the key hint text shown to the right of the text or removes it if empty.
+func (t *Tooltip) SetKeyHint(text string) *Tooltip {
+
+	if text == "" {
+		if t.hint != nil {
+			t.Panel.Remove(t.hint)
+			t.hint.Dispose()
+			t.hint = nil
+		}
+		t.recalc()
+		return t
+	}
+	if t.hint == nil {
+		t.hint = NewLabel(text)
+		t.Panel.Add(t.hint)
+	} else {
+		t.hint.SetText(text)
+	}
+	t.update()
+	return t
+}
+
+// SetShortcutHint sets the key hint from the specified keyboard shortcut.
+func (t *Tooltip) SetShortcutHint(mods window.ModifierKey, key window.Key) *Tooltip {
+
+	return t.SetKeyHint(shortcutText(mods, key))
+}
+
+// SetStyle sets the style of the tooltip.
+func (t *Tooltip) SetStyle(ts *TooltipStyle) {
+
+	t.styles = ts
+	t.update()
+}
+
+// update updates the visual state of the tooltip.
+func (t *Tooltip) update() {
+
+	t.Panel.ApplyStyle(&t.styles.PanelStyle)
+	t.label.SetColor4(&t.styles.FgColor)
+	if t.hint != nil {
+		t.hint.SetColor4(&t.styles.HintColor)
+	}
+	t.recalc()
+}
+
+// recalc recalculates the positions of the tooltip contents and its size.
+func (t *Tooltip) recalc() {
+
+	// Height of the tallest content
+	height := t.label.Height()
+	if t.image != nil && t.image.Height() > height {
+		height = t.image.Height()
+	}
+	px := float32(0)
+	if t.image != nil {
+		t.image.SetPosition(px, (height-t.image.Height())/2)
+		px += t.image.Width() + t.styles.Spacing
+	}
+	t.label.SetPosition(px, (height-t.label.Height())/2)
+	px += t.label.Width()
+	if t.hint != nil {
+		px += t.styles.Spacing * 2
+		t.hint.SetPosition(px, (height-t.hint.Height())/2)
+		px += t.hint.Width()
+	}
+	t.SetContentSize(px, height)
+}
+
+// SetTooltip sets the tooltip shown when the cursor rests over this panel
+// or a descendant without its own tooltip.
+// To remove the tooltip, call this function passing nil as parameter.
+func (p *Panel) SetTooltip(t *Tooltip) {
+
+	p.tooltip = t
+}
+
+// SetTooltipText sets a tooltip with the specified text for this panel and returns it.
+func (p *Panel) SetTooltipText(text string) *Tooltip {
+
+	p.tooltip = NewTooltip(text)
+	return p.tooltip
+}
+
+// Tooltip returns the tooltip of this panel or nil.
+func (p *Panel) Tooltip() *Tooltip {
+
+	return p.tooltip
+}
+
+// tooltipOwner returns the target panel or its nearest ancestor having a tooltip.
+func (gm *manager) tooltipOwner() IPanel {
+
+	var ok bool
+	for ipan := gm.target; ipan != nil; {
+		if ipan.GetPanel().tooltip != nil {
+			return ipan
+		}
+		ipan, ok = ipan.Parent().(IPanel)
+		if !ok {
+			break
+		}
+	}
+	return nil
+}
+
+// updateTooltip is called by onCursor after the target panel is updated
+// and schedules, moves or hides the tooltip.
+func (gm *manager) updateTooltip() {
+
+	owner := gm.tooltipOwner()
+	if owner != gm.tipOwner {
+		gm.hideTooltip()
+		gm.tipOwner = owner
+		gm.tipSuppressed = false
+		if owner == nil {
+			return
+		}
+		t := owner.GetPanel().tooltip
+		delay := t.Delay
+		if delay == 0 {
+			delay = TooltipDelay
+		}
+		if time.Since(gm.tipHidden) < TooltipReshowWindow {
+			delay = TooltipReshowDelay
+		}
+		gm.tipTimer = gm.SetTimeout(delay, nil, func(interface{}) {
+			gm.tipTimer = 0
+			gm.showTooltip()
+		})
+		return
+	}
+	if gm.tip != nil && gm.tip.Placement == TooltipFollow {
+		gm.placeTooltip()
+	}
+}
+
+// suppressTooltip hides the tooltip when a mouse button, key or wheel is used,
+// and prevents it to be shown again until the cursor leaves the widget.
+func (gm *manager) suppressTooltip() {
+
+	if gm.tipOwner == nil {
+		return
+	}
+	gm.hideTooltip()
+	gm.tipSuppressed = true
+}
+
+// showTooltip shows the tooltip of the current tooltip owner.
+func (gm *manager) showTooltip() {
+
+	if gm.tipOwner == nil || gm.tipSuppressed || gm.scene == nil || gm.Dragging() {
+		return
+	}
+	t := gm.tipOwner.GetPanel().tooltip
+	if t == nil {
+		return
+	}
+	gm.tip = t
+	t.SetZLayerDelta(300)
+	gm.scene.GetNode().Add(t)
+	gm.placeTooltip()
+	if t.Duration > 0 {
+		gm.tipTimer = gm.SetTimeout(t.Duration, nil, func(interface{}) {
+			gm.tipTimer = 0
+			gm.suppressTooltip()
+		})
+	}
+}
+
+// hideTooltip hides the visible tooltip and cancels the pending one.
+func (gm *manager) hideTooltip() {
+
+	if gm.tipTimer != 0 {
+		gm.ClearTimeout(gm.tipTimer)
+		gm.tipTimer = 0
+	}
+	if gm.tip == nil {
+		return
+	}
+	if parent := gm.tip.Parent(); parent != nil {
+		parent.GetNode().Remove(gm.tip)
+	}
+	gm.tip = nil
+	gm.tipHidden = time.Now()
+}
+
+// placeTooltip positions the visible tooltip according to its placement,
+// keeping it inside the window.
+func (gm *manager) placeTooltip() {
+
+	t := gm.tip
+	off := t.styles.Offset
+	width, height := window.Get().GetSize()
+	owner := gm.tipOwner.GetPanel()
+	pos := owner.Pospix()
+	var x, y float32
+	switch t.Placement {
+	case TooltipFollow:
+		if gm.cev == nil {
+			return
+		}
+		x = gm.cev.Xpos + off
+		y = gm.cev.Ypos + 2*off
+		if y+t.Height() > float32(height) {
+			y = gm.cev.Ypos - off - t.Height()
+		}
+	case TooltipBelow, TooltipAbove:
+		x = pos.X + (owner.Width()-t.Width())/2
+		y = pos.Y + owner.Height() + off
+		if t.Placement == TooltipAbove || y+t.Height() > float32(height) {
+			y = pos.Y - off - t.Height()
+		}
+		if y < 0 {
+			y = pos.Y + owner.Height() + off
+		}
+	case TooltipRight, TooltipLeft:
+		y = pos.Y + (owner.Height()-t.Height())/2
+		x = pos.X + owner.Width() + off
+		if t.Placement == TooltipLeft || x+t.Width() > float32(width) {
+			x = pos.X - off - t.Width()
+		}
+		if x < 0 {
+			x = pos.X + owner.Width() + off
+		}
+	}
+	x = math32.Clamp(x, 0, math32.Max(0, float32(width)-t.Width()))
+	y = math32.Clamp(y, 0, math32.Max(0, float32(height)-t.Height()))
+	t.SetPosition(x, y)
+}
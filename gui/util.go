@@ -4,6 +4,12 @@
 
 package gui
 
+import (
+	"image"
+
+	"github.com/g3n/engine/math32"
+)
+
 // RectBounds specifies the size of the boundaries of a rectangle.
 // It can represent the thickness of the borders, the margins, or the padding of a rectangle.
 type RectBounds struct {
@@ -49,3 +55,19 @@ func (r *Rect) Contains(x, y float32) bool {
 	}
 	return true
 }
+
+// blendPixel blends the specified color over the pixel of the image at the specified
+// position, scaling the color alpha by the specified coverage in the range [0,1].
+func blendPixel(img *image.RGBA, x, y int, c *math32.Color4, coverage float32) {
+
+	a := c.A * math32.Clamp(coverage, 0, 1)
+	if a <= 0 || !(image.Point{X: x, Y: y}).In(img.Rect) {
+		return
+	}
+	inv := 1 - a
+	p := img.Pix[img.PixOffset(x, y):]
+	p[0] = uint8(c.R*a*255 + float32(p[0])*inv + 0.5)
+	p[1] = uint8(c.G*a*255 + float32(p[1])*inv + 0.5)
+	p[2] = uint8(c.B*a*255 + float32(p[2])*inv + 0.5)
+	p[3] = uint8(a*255 + float32(p[3])*inv + 0.5)
+}
@@ -30,6 +30,9 @@ import (
 
 *********************************************/
 
+// OnWindowClose is the event dispatched by a Window after it is closed by its close button
+const OnWindowClose = "gui.OnWindowClose"
+
 // Window represents a window GUI element
 type Window struct {
 	Panel       // Embedded Panel
@@ -70,9 +73,17 @@ type WindowStyles struct {
 func NewWindow(width, height float32) *Window {
 
 	w := new(Window)
+	w.Initialize(w, width, height)
+	return w
+}
+
+// Initialize initializes the window with the specified dimensions.
+// It is used by types which embed a Window, passing themselves as the IPanel.
+func (w *Window) Initialize(ipan IPanel, width, height float32) {
+
 	w.styles = &StyleDefault().Window
 
-	w.Panel.Initialize(w, width, height)
+	w.Panel.Initialize(ipan, width, height)
 	w.Panel.Subscribe(OnMouseDown, w.onMouse)
 	w.Panel.Subscribe(OnMouseUp, w.onMouse)
 	w.Panel.Subscribe(OnCursor, w.onCursor)
@@ -87,7 +98,6 @@ func NewWindow(width, height float32) *Window {
 
 	w.recalc()
 	w.update()
-	return w
 }
 
 // SetResizable sets whether the window is resizable.
@@ -326,9 +336,9 @@ func newWindowTitle(win *Window, text string) *WindowTitle {
 		window.Get().SetCursor(window.ArrowCursor)
 	})
 	wt.closeButton.Subscribe(OnClick, func(s string, i interface{}) {
-		wt.win.Parent().GetNode().Remove(wt.win)
+		wt.win.Parent().GetNode().Remove(wt.win.GetINode())
 		wt.win.Dispose()
-		wt.win.Dispatch("gui.OnWindowClose", nil)
+		wt.win.Dispatch(OnWindowClose, nil)
 	})
 	wt.Panel.Add(wt.closeButton)
 	wt.closeButtonVisible = true
@@ -0,0 +1,358 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package input maps keys, mouse buttons, mouse gestures, the scroll wheel and
+// gamepads to named actions, so controls and game code can share one rebinding system.
+package input
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+)
+
+// Action events dispatched by an ActionMap with an *ActionEvent.
+const (
+	OnActionPress   = "input.OnActionPress"   // An action became pressed
+	OnActionRelease = "input.OnActionRelease" // An action stopped being pressed
+)
+
+// ActionEvent describes a change of the pressed state of an action.
+type ActionEvent struct {
+	Action string  // Name of the action
+	Value  float32 // Value of the action
+}
+
+// Context is a named set of action bindings, such as the bindings of gameplay or of a menu,
+// which can be pushed on an ActionMap. Contexts are serializable to save user customizations;
+// keys, buttons and modifiers are stored with the stable names of the window package.
+type Context struct {
+	Name     string               `json:"name"`
+	Blocking bool                 `json:"blocking,omitempty"` // Whether the contexts below it are inactive
+	Actions  map[string][]Binding `json:"actions"`            // Bindings by action name
+}
+
+// NewContext creates and returns a pointer to a new empty context with the specified name.
+func NewContext(name string) *Context {
+
+	return &Context{Name: name, Actions: make(map[string][]Binding)}
+}
+
+// Bind adds the specified bindings to an action.
+func (c *Context) Bind(action string, bindings ...Binding) {
+
+	c.Actions[action] = append(c.Actions[action], bindings...)
+}
+
+// Rebind replaces all the bindings of an action with the specified ones.
+func (c *Context) Rebind(action string, bindings ...Binding) {
+
+	c.Actions[action] = append([]Binding(nil), bindings...)
+}
+
+// Unbind removes all the bindings of an action.
+func (c *Context) Unbind(action string) {
+
+	delete(c.Actions, action)
+}
+
+// Bindings returns the bindings of an action.
+func (c *Context) Bindings(action string) []Binding {
+
+	return c.Actions[action]
+}
+
+// actionState is the state of an action in the current frame.
+type actionState struct {
+	value       float32
+	pressed     bool
+	prevPressed bool
+}
+
+// ActionMap evaluates the actions of a stack of contexts from the input received from a window.
+// Actions are evaluated in the contexts from the top of the stack down to the first blocking
+// context, and an action bound in several of them takes the value with the largest magnitude.
+// Update should be called once per frame before querying the actions; an action is pressed
+// while the magnitude of its value is at least the press threshold.
+type ActionMap struct {
+	core.Dispatcher                             // Embedded event dispatcher
+	win             core.IDispatcher            // Window dispatcher
+	keys            *window.KeyState            // Pressed keys
+	buttons         map[window.MouseButton]bool // Pressed mouse buttons
+	cursor          [2]float32                  // Last cursor position
+	cursorValid     bool                        // Whether the cursor position is known
+	motion          [2]float32                  // Cursor movement since the last update
+	scroll          [2]float32                  // Scroll offsets since the last update
+	frameMotion     [2]float32                  // Cursor movement of the current frame
+	frameScroll     [2]float32                  // Scroll offsets of the current frame
+	contexts        []*Context                  // Context stack, top last
+	states          map[string]*actionState     // Action states by name
+	threshold       float32                     // Press threshold
+}
+
+// NewActionMap creates and returns a pointer to a new action map receiving input
+// from the specified window, with an empty context stack.
+func NewActionMap(win core.IDispatcher) *ActionMap {
+
+	am := new(ActionMap)
+	am.Dispatcher.Initialize()
+	am.win = win
+	am.keys = window.NewKeyState(win)
+	am.buttons = make(map[window.MouseButton]bool)
+	am.states = make(map[string]*actionState)
+	am.threshold = 0.5
+
+	am.win.SubscribeID(window.OnMouseDown, am, am.onMouse)
+	am.win.SubscribeID(window.OnMouseUp, am, am.onMouse)
+	am.win.SubscribeID(window.OnCursor, am, am.onCursor)
+	am.win.SubscribeID(window.OnScroll, am, am.onScroll)
+	return am
+}
+
+// Dispose unsubscribes from the window events.
+func (am *ActionMap) Dispose() {
+
+	am.keys.Dispose()
+	am.win.UnsubscribeAllID(am)
+}
+
+// PushContext pushes a context on the top of the stack.
+func (am *ActionMap) PushContext(c *Context) {
+
+	am.contexts = append(am.contexts, c)
+}
+
+// PopContext removes and returns the context on the top of the stack, or nil if it is empty.
+func (am *ActionMap) PopContext() *Context {
+
+	n := len(am.contexts)
+	if n == 0 {
+		return nil
+	}
+	c := am.contexts[n-1]
+	am.contexts = am.contexts[:n-1]
+	return c
+}
+
+// RemoveContext removes the specified context from anywhere in the stack and returns whether it was found.
+func (am *ActionMap) RemoveContext(c *Context) bool {
+
+	for i := range am.contexts {
+		if am.contexts[i] == c {
+			am.contexts = append(am.contexts[:i], am.contexts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Contexts returns the context stack, from the bottom to the top.
+func (am *ActionMap) Contexts() []*Context {
+
+	return am.contexts
+}
+
+// Context returns the topmost context with the specified name, or nil if there is none.
+func (am *ActionMap) Context(name string) *Context {
+
+	for i := len(am.contexts) - 1; i >= 0; i-- {
+		if am.contexts[i].Name == name {
+			return am.contexts[i]
+		}
+	}
+	return nil
+}
+
+// SetThreshold sets the magnitude of the value from which actions are pressed (default = 0.5).
+func (am *ActionMap) SetThreshold(threshold float32) {
+
+	am.threshold = threshold
+}
+
+// Threshold returns the magnitude of the value from which actions are pressed.
+func (am *ActionMap) Threshold() float32 {
+
+	return am.threshold
+}
+
+// Update evaluates all the actions of the active contexts from the current input and
+// dispatches OnActionPress and OnActionRelease for the actions whose pressed state changed.
+// It should be called once per frame.
+func (am *ActionMap) Update() {
+
+	am.frameMotion, am.motion = am.motion, [2]float32{}
+	am.frameScroll, am.scroll = am.scroll, [2]float32{}
+	joysticks := window.Joysticks()
+
+	for _, s := range am.states {
+		s.value = 0
+	}
+	for i := len(am.contexts) - 1; i >= 0; i-- {
+		c := am.contexts[i]
+		for action, bindings := range c.Actions {
+			s := am.states[action]
+			if s == nil {
+				s = new(actionState)
+				am.states[action] = s
+			}
+			for j := range bindings {
+				v := am.bindingValue(&bindings[j], joysticks)
+				if math32.Abs(v) > math32.Abs(s.value) {
+					s.value = v
+				}
+			}
+		}
+		if c.Blocking {
+			break
+		}
+	}
+
+	for action, s := range am.states {
+		s.prevPressed = s.pressed
+		s.pressed = math32.Abs(s.value) >= am.threshold
+		if s.pressed && !s.prevPressed {
+			am.Dispatch(OnActionPress, &ActionEvent{Action: action, Value: s.value})
+		} else if !s.pressed && s.prevPressed {
+			am.Dispatch(OnActionRelease, &ActionEvent{Action: action, Value: s.value})
+		}
+	}
+}
+
+// Value returns the value of an action in the current frame, or 0 if it is not bound in an active context.
+func (am *ActionMap) Value(action string) float32 {
+
+	if s := am.states[action]; s != nil {
+		return s.value
+	}
+	return 0
+}
+
+// Pressed returns whether an action is pressed in the current frame.
+func (am *ActionMap) Pressed(action string) bool {
+
+	s := am.states[action]
+	return s != nil && s.pressed
+}
+
+// JustPressed returns whether an action became pressed in the current frame.
+func (am *ActionMap) JustPressed(action string) bool {
+
+	s := am.states[action]
+	return s != nil && s.pressed && !s.prevPressed
+}
+
+// JustReleased returns whether an action stopped being pressed in the current frame.
+func (am *ActionMap) JustReleased(action string) bool {
+
+	s := am.states[action]
+	return s != nil && !s.pressed && s.prevPressed
+}
+
+// Axis returns the value of the positive action minus the value of the negative action,
+// such as "moveRight" and "moveLeft", clamped between -1 and 1.
+func (am *ActionMap) Axis(negative, positive string) float32 {
+
+	return math32.Clamp(am.Value(positive)-am.Value(negative), -1, 1)
+}
+
+// bindingValue returns the current value of a binding.
+func (am *ActionMap) bindingValue(b *Binding, joysticks []*window.Joystick) float32 {
+
+	var v float32
+	switch b.Device {
+	case DeviceKey:
+		if am.keys.Pressed(b.Key) && am.modsMatch(b.Mods, b.Key) {
+			v = 1
+		}
+	case DeviceScancode:
+		if am.keys.ScancodePressed(b.Scancode) && am.modsMatch(b.Mods, b.Key) {
+			v = 1
+		}
+	case DeviceMouseButton:
+		if am.buttons[b.Button] && am.modsMatch(b.Mods, window.KeyUnknown) {
+			v = 1
+		}
+	case DeviceMouseMotion:
+		if b.Drag && !(am.buttons[b.Button] && am.modsMatch(b.Mods, window.KeyUnknown)) {
+			return 0
+		}
+		v = am.frameMotion[b.Index&1]
+	case DeviceScroll:
+		v = am.frameScroll[b.Index&1]
+	case DeviceGamepadButton:
+		for _, j := range joysticks {
+			if j.Button(b.Index) {
+				v = 1
+				break
+			}
+		}
+	case DeviceGamepadAxis:
+		for _, j := range joysticks {
+			a := j.Axis(b.Index)
+			if math32.Abs(a) > math32.Abs(v) {
+				v = a
+			}
+		}
+		// Rescales the range outside the dead zone to start at 0
+		mag := math32.Abs(v)
+		if mag <= b.Deadzone {
+			return 0
+		}
+		if b.Deadzone > 0 && b.Deadzone < 1 {
+			v = v / mag * (mag - b.Deadzone) / (1 - b.Deadzone)
+		}
+	}
+	return v * b.scale()
+}
+
+// modsMatch returns whether exactly the specified modifier keys are held,
+// ignoring the modifier of the specified key, which is being pressed.
+func (am *ActionMap) modsMatch(mods window.ModifierKey, key window.Key) bool {
+
+	var held window.ModifierKey
+	for _, m := range modifierKeys {
+		if key != m.left && key != m.right && (am.keys.Pressed(m.left) || am.keys.Pressed(m.right)) {
+			held |= m.mod
+		}
+	}
+	return held == mods
+}
+
+// Modifier keys and their left and right keys
+var modifierKeys = []struct {
+	mod         window.ModifierKey
+	left, right window.Key
+}{
+	{window.ModShift, window.KeyLeftShift, window.KeyRightShift},
+	{window.ModControl, window.KeyLeftControl, window.KeyRightControl},
+	{window.ModAlt, window.KeyLeftAlt, window.KeyRightAlt},
+	{window.ModSuper, window.KeyLeftSuper, window.KeyRightSuper},
+}
+
+// onMouse receives mouse button events.
+func (am *ActionMap) onMouse(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	am.buttons[mev.Button] = evname == window.OnMouseDown
+}
+
+// onCursor receives cursor events and accumulates the cursor movement.
+func (am *ActionMap) onCursor(evname string, ev interface{}) {
+
+	cev := ev.(*window.CursorEvent)
+	if am.cursorValid {
+		am.motion[0] += cev.Xpos - am.cursor[0]
+		am.motion[1] += cev.Ypos - am.cursor[1]
+	}
+	am.cursor = [2]float32{cev.Xpos, cev.Ypos}
+	am.cursorValid = true
+}
+
+// onScroll receives scroll events and accumulates the scroll offsets.
+func (am *ActionMap) onScroll(evname string, ev interface{}) {
+
+	sev := ev.(*window.ScrollEvent)
+	am.scroll[0] += sev.Xoffset
+	am.scroll[1] += sev.Yoffset
+}
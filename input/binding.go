@@ -0,0 +1,203 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/g3n/engine/window"
+)
+
+// Device is the kind of input of a Binding.
+type Device int
+
+// The input devices which can be bound to actions.
+const (
+	DeviceKey           = Device(iota) // Keyboard key by symbol
+	DeviceScancode                     // Keyboard key by physical position
+	DeviceMouseButton                  // Mouse button
+	DeviceMouseMotion                  // Cursor movement, optionally only while dragging with a mouse button
+	DeviceScroll                       // Scroll wheel
+	DeviceGamepadButton                // Button of any connected joystick
+	DeviceGamepadAxis                  // Axis of any connected joystick
+)
+
+// Axes of the cursor movement and of the scroll wheel.
+const (
+	AxisX = 0
+	AxisY = 1
+)
+
+// Stable names of the devices
+var deviceIDs = []string{"key", "scancode", "mouse", "motion", "scroll", "gamepadButton", "gamepadAxis"}
+
+// MarshalText satisfies the encoding.TextMarshaler interface and returns the name of the device.
+func (d Device) MarshalText() ([]byte, error) {
+
+	if d < 0 || int(d) >= len(deviceIDs) {
+		return nil, fmt.Errorf("invalid input device: %d", int(d))
+	}
+	return []byte(deviceIDs[d]), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface and sets
+// the device from its name, ignoring case.
+func (d *Device) UnmarshalText(text []byte) error {
+
+	for i, id := range deviceIDs {
+		if strings.EqualFold(id, string(text)) {
+			*d = Device(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid input device name: %q", text)
+}
+
+// Binding binds an input to an action. Only the fields used by its device are relevant.
+// Digital inputs (keys and buttons) have the value Scale while they are held, and analog
+// inputs (motion, scroll and gamepad axes) have their value multiplied by Scale, so for example
+// a negative scale inverts an axis. A zero Scale is taken as 1.
+// Keys and mouse buttons are active only while exactly the modifier keys in Mods are held,
+// ignoring the modifier of the bound key itself.
+type Binding struct {
+	Device   Device             `json:"device"`
+	Key      window.Key         `json:"key,omitempty"`      // Key, also kept by scancode bindings to display them
+	Scancode window.Scancode    `json:"scancode,omitempty"` // Physical key of scancode bindings
+	Button   window.MouseButton `json:"button,omitempty"`   // Mouse button, also the dragging button of motion bindings
+	Drag     bool               `json:"drag,omitempty"`     // Whether motion bindings require Button to be held
+	Mods     window.ModifierKey `json:"mods,omitempty"`     // Modifier keys of key, mouse button and drag bindings
+	Index    int                `json:"index,omitempty"`    // Gamepad button or axis, or AxisX/AxisY of motion and scroll
+	Scale    float32            `json:"scale,omitempty"`    // Multiplier of the value
+	Deadzone float32            `json:"deadzone,omitempty"` // Absolute gamepad axis values below which the value is 0
+}
+
+// KeyBinding returns a binding of the specified key symbol with modifier keys.
+func KeyBinding(key window.Key, mods window.ModifierKey) Binding {
+
+	return Binding{Device: DeviceKey, Key: key, Mods: mods}
+}
+
+// ScancodeBinding returns a layout independent binding of the physical key at the
+// position the specified key has on the US layout, such as W of WASD.
+// If the key has no known scancode the returned binding uses the key symbol.
+func ScancodeBinding(key window.Key, mods window.ModifierKey) Binding {
+
+	sc, ok := window.KeyScancode(key)
+	if !ok {
+		return KeyBinding(key, mods)
+	}
+	return Binding{Device: DeviceScancode, Key: key, Scancode: sc, Mods: mods}
+}
+
+// MouseButtonBinding returns a binding of the specified mouse button with modifier keys.
+func MouseButtonBinding(button window.MouseButton, mods window.ModifierKey) Binding {
+
+	return Binding{Device: DeviceMouseButton, Button: button, Mods: mods}
+}
+
+// MotionBinding returns a binding of the cursor movement in pixels along the specified axis.
+func MotionBinding(axis int, scale float32) Binding {
+
+	return Binding{Device: DeviceMouseMotion, Index: axis, Scale: scale}
+}
+
+// DragBinding returns a binding of the cursor movement in pixels along the specified axis
+// while the specified mouse button and modifier keys are held.
+func DragBinding(button window.MouseButton, mods window.ModifierKey, axis int, scale float32) Binding {
+
+	return Binding{Device: DeviceMouseMotion, Button: button, Drag: true, Mods: mods, Index: axis, Scale: scale}
+}
+
+// ScrollBinding returns a binding of the scroll offset along the specified axis.
+func ScrollBinding(axis int, scale float32) Binding {
+
+	return Binding{Device: DeviceScroll, Index: axis, Scale: scale}
+}
+
+// GamepadButtonBinding returns a binding of the joystick button with the specified index.
+func GamepadButtonBinding(index int) Binding {
+
+	return Binding{Device: DeviceGamepadButton, Index: index}
+}
+
+// GamepadAxisBinding returns a binding of the joystick axis with the specified index.
+// Values whose magnitude is below the dead zone are 0 and the rest of the range is
+// rescaled to start at 0.
+func GamepadAxisBinding(index int, scale, deadzone float32) Binding {
+
+	return Binding{Device: DeviceGamepadAxis, Index: index, Scale: scale, Deadzone: deadzone}
+}
+
+// Name returns a description of the bound input suitable for display, following
+// the current keyboard layout for scancode bindings.
+func (b Binding) Name() string {
+
+	var name string
+	switch b.Device {
+	case DeviceKey:
+		name = window.KeyName(b.Key, 0)
+	case DeviceScancode:
+		name = window.ScancodeName(b.Scancode)
+	case DeviceMouseButton:
+		name = buttonName(b.Button) + " Button"
+	case DeviceMouseMotion:
+		name = "Mouse " + axisName(b.Index)
+		if b.Drag {
+			name = buttonName(b.Button) + " Drag " + axisName(b.Index)
+		}
+	case DeviceScroll:
+		name = "Scroll " + axisName(b.Index)
+	case DeviceGamepadButton:
+		name = fmt.Sprintf("Gamepad Button %d", b.Index)
+	case DeviceGamepadAxis:
+		name = fmt.Sprintf("Gamepad Axis %d", b.Index)
+	}
+	if b.Mods != 0 && b.usesMods() {
+		mods, _ := b.Mods.MarshalText()
+		name = string(mods) + "+" + name
+	}
+	return name
+}
+
+// usesMods returns whether the modifier keys are relevant for the device of the binding.
+func (b Binding) usesMods() bool {
+
+	switch b.Device {
+	case DeviceKey, DeviceScancode, DeviceMouseButton:
+		return true
+	case DeviceMouseMotion:
+		return b.Drag
+	}
+	return false
+}
+
+// scale returns the multiplier of the value of the binding.
+func (b Binding) scale() float32 {
+
+	if b.Scale == 0 {
+		return 1
+	}
+	return b.Scale
+}
+
+// buttonName returns the stable name of a mouse button, or its number if it has none.
+func buttonName(button window.MouseButton) string {
+
+	name, err := button.MarshalText()
+	if err != nil {
+		return fmt.Sprintf("Mouse%d", int(button))
+	}
+	return string(name)
+}
+
+// axisName returns the name of a motion or scroll axis.
+func axisName(axis int) string {
+
+	if axis == AxisY {
+		return "Y"
+	}
+	return "X"
+}
@@ -57,7 +57,11 @@ func (la *Ambient) Intensity() float32 {
 // RenderSetup is called by the engine before rendering the scene
 func (la *Ambient) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int) {
 
+	// Light colors are specified in sRGB
 	color := la.color
+	if gs.LinearColors() {
+		color.ToLinear()
+	}
 	color.MultiplyScalar(la.intensity)
 	location := la.uni.LocationIdx(gs, int32(idx))
 	gs.Uniform3f(location, color.R, color.G, color.B)
@@ -67,6 +67,13 @@ func (ld *Directional) Intensity() float32 {
 // RenderSetup is called by the engine before rendering the scene
 func (ld *Directional) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int) {
 
+	// Light colors are specified in sRGB
+	ld.udata.color = ld.color
+	if gs.LinearColors() {
+		ld.udata.color.ToLinear()
+	}
+	ld.udata.color.MultiplyScalar(ld.intensity)
+
 	// Calculates light position in camera coordinates and updates uniform
 	var pos math32.Vector3
 	ld.WorldPosition(&pos)
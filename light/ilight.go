@@ -13,3 +13,16 @@ import (
 type ILight interface {
 	RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int)
 }
+
+// FindLights returns all lights found in the specified node and all its descendants.
+func FindLights(root core.INode) []ILight {
+
+	lights := make([]ILight, 0)
+	root.GetNode().Walk(func(inode core.INode) bool {
+		if l, ok := inode.(ILight); ok {
+			lights = append(lights, l)
+		}
+		return true
+	})
+	return lights
+}
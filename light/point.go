@@ -97,6 +97,13 @@ func (lp *Point) QuadraticDecay() float32 {
 // RenderSetup is called by the engine before rendering the scene
 func (lp *Point) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int) {
 
+	// Light colors are specified in sRGB
+	lp.udata.color = lp.color
+	if gs.LinearColors() {
+		lp.udata.color.ToLinear()
+	}
+	lp.udata.color.MultiplyScalar(lp.intensity)
+
 	// Calculates light position in camera coordinates and updates uniform
 	var pos math32.Vector3
 	lp.WorldPosition(&pos)
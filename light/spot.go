@@ -124,6 +124,13 @@ func (l *Spot) QuadraticDecay() float32 {
 // RenderSetup is called by the engine before rendering the scene
 func (l *Spot) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int) {
 
+	// Light colors are specified in sRGB
+	l.udata.color = l.color
+	if gs.LinearColors() {
+		l.udata.color.ToLinear()
+	}
+	l.udata.color.MultiplyScalar(l.intensity)
+
 	// Calculates and updates light position uniform in camera coordinates
 	var pos math32.Vector3
 	l.WorldPosition(&pos)
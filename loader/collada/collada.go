@@ -13,6 +13,7 @@ import (
 	"github.com/g3n/engine/texture"
 	"io"
 	"os"
+	"strconv"
 )
 
 // Decoder contains all decoded data from collada file
@@ -24,6 +25,7 @@ type Decoder struct {
 	geometries map[string]geomInstance       // Instanced geometries by id
 	materials  map[string]material.IMaterial // Instanced materials by id
 	tex2D      map[string]*texture.Texture2D // Instanced textures 2D by id
+	cache      *material.Cache               // Shares textures with other models if not nil
 }
 
 type geomInstance struct {
@@ -65,6 +67,13 @@ func (d *Decoder) SetDirImages(path string) {
 	d.dirImages = path
 }
 
+// SetCache sets the cache used to share the textures of this model with
+// the other models loaded with the same cache, or nil to not share them.
+func (d *Decoder) SetCache(cache *material.Cache) {
+
+	d.cache = cache
+}
+
 //
 // Collada DOM root
 //
@@ -148,6 +157,7 @@ type Asset struct {
 	Created     string
 	Modified    string
 	UpAxis      string
+	UnitMeter   float32 // Length of one unit in meters, 0 if not specified
 }
 
 // Dump prints out information about the Asset
@@ -159,6 +169,7 @@ func (a *Asset) Dump(out io.Writer, indent int) {
 	fmt.Fprintf(out, "%sCreated:%s\n", sIndent(ind), a.Created)
 	fmt.Fprintf(out, "%sModified:%s\n", sIndent(ind), a.Modified)
 	fmt.Fprintf(out, "%sUpAxis:%s\n", sIndent(ind), a.UpAxis)
+	fmt.Fprintf(out, "%sUnitMeter:%v\n", sIndent(ind), a.UnitMeter)
 }
 
 //
@@ -354,6 +365,13 @@ func (d *Decoder) decAsset(assetStart xml.StartElement, a *Asset) error {
 			a.UpAxis = string(data)
 			continue
 		}
+		if child.Name.Local == "unit" {
+			meter, err := strconv.ParseFloat(findAttrib(child, "meter").Value, 32)
+			if err == nil && meter > 0 {
+				a.UnitMeter = float32(meter)
+			}
+			continue
+		}
 	}
 }
 
@@ -138,6 +138,9 @@ func (d *Decoder) NewTexture2D(id string) (*texture.Texture2D, error) {
 
 	// Builds image file path and try to create texture
 	filepath := filepath.Join(d.dirImages, filepath.Base(imgInitFrom.Uri))
+	if d.cache != nil {
+		return d.cache.LoadTexture(filepath, "")
+	}
 	tex, err := texture.NewTexture2DFromImage(filepath)
 	if err != nil {
 		return nil, err
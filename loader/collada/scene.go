@@ -34,10 +34,6 @@ func (d *Decoder) NewScene() (core.INode, error) {
 
 	// Creates parent scene
 	scene := core.NewNode()
-	// Rotate scene if necessary
-	if d.dom.Asset.UpAxis == "Z_UP" {
-		scene.SetRotationX(-math32.Pi / 2)
-	}
 
 	// Creates each node and adds it to the scene
 	for _, n := range vs.Node {
@@ -47,9 +43,22 @@ func (d *Decoder) NewScene() (core.INode, error) {
 		}
 		scene.Add(node)
 	}
+	// Converts the scene to the engine coordinate system
+	core.ConvertNode(scene, d.Coords())
 	return scene, nil
 }
 
+// Coords returns the coordinate system of the decoded file, given by its up axis
+// and unit, which NewScene converts to the engine coordinate system.
+func (d *Decoder) Coords() core.CoordSystem {
+
+	cs := core.CoordSystem{Up: core.YUp, Unit: d.dom.Asset.UnitMeter}
+	if d.dom.Asset.UpAxis == "Z_UP" {
+		cs.Up = core.ZUp
+	}
+	return cs
+}
+
 func (d *Decoder) newNode(cnode *Node) (core.INode, error) {
 
 	var node core.INode
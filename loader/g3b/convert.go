@@ -0,0 +1,521 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package g3b
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// Options specifies how the meshes are preprocessed when converting a scene.
+type Options struct {
+	Layout   gls.VertexLayout // Formats of the vertex attributes (see gls.CompactLayout), nil for 32 bit floats
+	Tangents bool             // Generates the tangents of the meshes with normals and texture coordinates
+	Optimize bool             // Reorders the triangles and vertices of indexed meshes for the vertex cache
+}
+
+// DefaultOptions are the options used when converting a scene without options.
+var DefaultOptions = Options{Tangents: true, Optimize: true}
+
+// Convert converts the scene with the specified root node, such as a scene loaded from
+// an OBJ or glTF file, preprocessing its meshes with the specified options or DefaultOptions
+// if nil. Meshes are converted with their Standard, Phong, Physical and Basic materials and
+// other graphics, lights and cameras are converted as plain nodes.
+func Convert(root core.INode, opts *Options) (*File, error) {
+
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+	c := &converter{
+		opts:      opts,
+		file:      new(File),
+		textures:  make(map[*texture.Texture2D]int),
+		materials: make(map[material.IMaterial]int),
+		meshes:    make(map[*geometry.Geometry]int),
+	}
+	err := c.addNode(root, -1)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Optimize {
+		for i := range c.file.Meshes {
+			c.optimize(i)
+		}
+	}
+	return c.file, nil
+}
+
+// converter converts the nodes of a scene, once for each shared texture, material and geometry.
+type converter struct {
+	opts      *Options
+	file      *File
+	textures  map[*texture.Texture2D]int
+	materials map[material.IMaterial]int
+	meshes    map[*geometry.Geometry]int
+	strides   []int
+}
+
+// addNode converts the specified node and its children.
+func (c *converter) addNode(inode core.INode, parent int) error {
+
+	node := inode.GetNode()
+	desc := Node{
+		Name:       node.Name(),
+		Parent:     parent,
+		Position:   node.Position(),
+		Quaternion: node.Quaternion(),
+		Scale:      node.Scale(),
+		Visible:    node.Visible(),
+		Mesh:       -1,
+	}
+	if mesh, ok := inode.(*graphic.Mesh); ok {
+		idx, err := c.addMesh(mesh.GetGeometry())
+		if err != nil {
+			return fmt.Errorf("mesh %q: %v", node.Name(), err)
+		}
+		desc.Mesh = idx
+		mats := mesh.Materials()
+		for i := range mats {
+			midx, err := c.addMaterial(mats[i].IMaterial())
+			if err != nil {
+				return fmt.Errorf("mesh %q: %v", node.Name(), err)
+			}
+			start, count := mats[i].Range()
+			desc.Materials = append(desc.Materials, NodeMaterial{Material: midx, Start: start, Count: count})
+		}
+	}
+	c.file.Nodes = append(c.file.Nodes, desc)
+	idx := len(c.file.Nodes) - 1
+	for _, child := range node.Children() {
+		err := c.addNode(child, idx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMesh converts the specified geometry and returns its index.
+func (c *converter) addMesh(geom *geometry.Geometry) (int, error) {
+
+	if idx, ok := c.meshes[geom]; ok {
+		return idx, nil
+	}
+	err := geom.Restore()
+	if err != nil {
+		return 0, err
+	}
+
+	// Interleaves the per vertex attributes with the generated tangents
+	var vbos []*gls.VBO
+	for _, vbo := range geom.VBOs() {
+		if vbo.Usage() != gls.STREAM_DRAW && vbo.Divisor() == 0 {
+			vbos = append(vbos, vbo)
+		}
+	}
+	if c.opts.Tangents && geom.VBO(gls.VertexTangent) == nil {
+		if tangents := generateTangents(geom); tangents != nil {
+			vbos = append(vbos, gls.NewVBO(tangents).AddAttrib(gls.VertexTangent))
+		}
+	}
+	var mesh Mesh
+	stride := 0
+	if len(vbos) > 0 {
+		vbo := gls.Interleave(vbos, c.opts.Layout)
+		for _, a := range vbo.Attributes() {
+			mesh.Attribs = append(mesh.Attribs, Attrib{
+				Type:     a.Type,
+				Name:     a.Name,
+				Elements: int(a.NumElements),
+				Format:   attribFormat(a.ElementType),
+			})
+		}
+		mesh.Vertices = vbo.Bytes()
+		stride = vbo.StrideSize()
+	}
+	if geom.Indexed() {
+		mesh.Indices = append(math32.ArrayU32(nil), geom.Indices()...)
+	}
+	for i := 0; i < geom.GroupCount(); i++ {
+		mesh.Groups = append(mesh.Groups, *geom.GroupAt(i))
+	}
+	c.file.Meshes = append(c.file.Meshes, mesh)
+	c.strides = append(c.strides, stride)
+	idx := len(c.file.Meshes) - 1
+	c.meshes[geom] = idx
+	return idx, nil
+}
+
+// optimize reorders the triangles and vertices of the mesh with the specified index.
+// Triangles are reordered within the ranges of the groups and materials of the mesh.
+func (c *converter) optimize(idx int) {
+
+	mesh := &c.file.Meshes[idx]
+	if mesh.Indices == nil || c.strides[idx] == 0 {
+		return
+	}
+	bounds := c.file.meshBounds(idx)
+	vertexCount := len(mesh.Vertices) / c.strides[idx]
+	for i := 1; i < len(bounds); i++ {
+		start, end := bounds[i-1], bounds[i]
+		if start%3 != 0 || end%3 != 0 {
+			continue
+		}
+		optimizeTriangles(mesh.Indices[start:end], vertexCount)
+	}
+	reorderVertices(mesh, c.strides[idx])
+}
+
+// meshBounds returns the sorted distinct boundaries of the index ranges of the groups and
+// materials of the indexed mesh with the specified index, including 0 and the index count.
+func (f *File) meshBounds(idx int) []int {
+
+	mesh := &f.Meshes[idx]
+	count := len(mesh.Indices)
+	bounds := []int{0, count}
+	for _, g := range mesh.Groups {
+		bounds = append(bounds, g.Start, g.Start+g.Count)
+	}
+	for _, n := range f.Nodes {
+		if n.Mesh != idx {
+			continue
+		}
+		for _, nm := range n.Materials {
+			if nm.Count > 0 {
+				bounds = append(bounds, nm.Start, nm.Start+nm.Count)
+			}
+		}
+	}
+	sort.Ints(bounds)
+	out := bounds[:0]
+	for _, b := range bounds {
+		if b >= 0 && b <= count && (len(out) == 0 || b != out[len(out)-1]) {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// addMaterial converts the specified material and returns its index.
+func (c *converter) addMaterial(imat material.IMaterial) (int, error) {
+
+	if idx, ok := c.materials[imat]; ok {
+		return idx, nil
+	}
+	desc := Material{
+		BaseColorMap:         -1,
+		MetallicRoughnessMap: -1,
+		NormalMap:            -1,
+		OcclusionMap:         -1,
+		EmissiveMap:          -1,
+	}
+	var sm *material.Standard
+	switch m := imat.(type) {
+	case *material.Standard:
+		desc.Kind = KindStandard
+		sm = m
+	case *material.Phong:
+		desc.Kind = KindPhong
+		sm = &m.Standard
+	case *material.Physical:
+		desc.Kind = KindPhysical
+		desc.BaseColor = m.BaseColorFactor()
+		desc.Emissive = m.EmissiveFactor()
+		desc.Metallic = m.MetallicFactor()
+		desc.Roughness = m.RoughnessFactor()
+		var err error
+		maps := []*int{&desc.BaseColorMap, &desc.MetallicRoughnessMap, &desc.NormalMap, &desc.OcclusionMap, &desc.EmissiveMap}
+		for i, tex := range []*texture.Texture2D{m.BaseColorMap(), m.MetallicRoughnessMap(), m.NormalMap(), m.OcclusionMap(), m.EmissiveMap()} {
+			*maps[i], err = c.addTexture(tex)
+			if err != nil {
+				return 0, err
+			}
+		}
+	case *material.Basic:
+		desc.Kind = KindBasic
+	default:
+		return 0, fmt.Errorf("unsupported material type %T", imat)
+	}
+	if sm != nil {
+		desc.Ambient = sm.AmbientColor()
+		desc.Diffuse = sm.Color()
+		desc.Specular = sm.SpecularColor()
+		desc.Emissive = sm.EmissiveColor()
+		desc.Shininess = sm.Shininess()
+		desc.Opacity = sm.Opacity()
+		for _, tex := range sm.Textures() {
+			tidx, err := c.addTexture(tex)
+			if err != nil {
+				return 0, err
+			}
+			desc.Textures = append(desc.Textures, tidx)
+		}
+	}
+	mat := imat.GetMaterial()
+	desc.Side = mat.Side()
+	desc.Transparent = mat.Transparent()
+	c.file.Materials = append(c.file.Materials, desc)
+	idx := len(c.file.Materials) - 1
+	c.materials[imat] = idx
+	return idx, nil
+}
+
+// addTexture converts the specified texture and returns its index, or -1 if it is nil.
+func (c *converter) addTexture(tex *texture.Texture2D) (int, error) {
+
+	if tex == nil {
+		return -1, nil
+	}
+	if idx, ok := c.textures[tex]; ok {
+		return idx, nil
+	}
+	err := tex.Restore()
+	if err != nil {
+		return 0, err
+	}
+	rgba := tex.RGBA()
+	if rgba == nil {
+		return 0, fmt.Errorf("texture data is not 8 bit RGBA")
+	}
+	desc := Texture{
+		Width:  tex.Width(),
+		Height: tex.Height(),
+		SRGB:   tex.SRGB(),
+		FlipY:  tex.FlipY(),
+		Pixels: rgba.Pix[:4*tex.Width()*tex.Height()],
+	}
+	desc.Repeat[0], desc.Repeat[1] = tex.Repeat()
+	desc.Offset[0], desc.Offset[1] = tex.Offset()
+	c.file.Textures = append(c.file.Textures, desc)
+	idx := len(c.file.Textures) - 1
+	c.textures[tex] = idx
+	return idx, nil
+}
+
+// attribFormat returns the format of attributes with the specified OpenGL element type.
+func attribFormat(elementType uint32) gls.AttribFormat {
+
+	switch elementType {
+	case gls.HALF_FLOAT:
+		return gls.FormatHalf
+	case gls.SHORT:
+		return gls.FormatSnorm16
+	case gls.UNSIGNED_SHORT:
+		return gls.FormatUnorm16
+	case gls.UNSIGNED_BYTE:
+		return gls.FormatUnorm8
+	case gls.INT_2_10_10_10_REV:
+		return gls.FormatSnorm2101010
+	default:
+		return gls.FormatFloat
+	}
+}
+
+// Save writes the file to the specified path, compressing its body if requested.
+func (f *File) Save(path string, compress bool) error {
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = f.Write(out, compress)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Write writes the file to the specified writer, compressing its body if requested.
+// Compressed files are smaller but can not be used directly from the mapped memory.
+func (f *File) Write(out io.Writer, compress bool) error {
+
+	w := new(writer)
+	w.u32(uint32(len(f.Textures)))
+	for i := range f.Textures {
+		t := &f.Textures[i]
+		w.u32(uint32(t.Width))
+		w.u32(uint32(t.Height))
+		var flags uint32
+		if t.SRGB {
+			flags |= 1
+		}
+		if t.FlipY {
+			flags |= 2
+		}
+		w.u32(flags)
+		w.f32(t.Repeat[0], t.Repeat[1], t.Offset[0], t.Offset[1])
+		w.blob(t.Pixels)
+	}
+	w.u32(uint32(len(f.Materials)))
+	for i := range f.Materials {
+		m := &f.Materials[i]
+		w.u32(uint32(m.Kind))
+		w.u32(uint32(m.Side))
+		w.bool(m.Transparent)
+		switch m.Kind {
+		case KindStandard, KindPhong:
+			w.color(&m.Ambient)
+			w.color(&m.Diffuse)
+			w.color(&m.Specular)
+			w.color(&m.Emissive)
+			w.f32(m.Shininess, m.Opacity)
+			w.u32(uint32(len(m.Textures)))
+			for _, t := range m.Textures {
+				w.i32(t)
+			}
+		case KindPhysical:
+			w.f32(m.BaseColor.R, m.BaseColor.G, m.BaseColor.B, m.BaseColor.A)
+			w.color(&m.Emissive)
+			w.f32(m.Metallic, m.Roughness)
+			w.i32(m.BaseColorMap)
+			w.i32(m.MetallicRoughnessMap)
+			w.i32(m.NormalMap)
+			w.i32(m.OcclusionMap)
+			w.i32(m.EmissiveMap)
+		}
+	}
+	w.u32(uint32(len(f.Meshes)))
+	for i := range f.Meshes {
+		m := &f.Meshes[i]
+		w.u32(uint32(len(m.Attribs)))
+		for _, a := range m.Attribs {
+			w.u32(uint32(a.Type))
+			w.blob([]byte(a.Name))
+			w.u32(uint32(a.Elements))
+			w.u32(uint32(a.Format))
+		}
+		w.meshData(m)
+	}
+	w.u32(uint32(len(f.Nodes)))
+	for i := range f.Nodes {
+		n := &f.Nodes[i]
+		w.blob([]byte(n.Name))
+		w.i32(n.Parent)
+		w.f32(n.Position.X, n.Position.Y, n.Position.Z)
+		w.f32(n.Quaternion.X, n.Quaternion.Y, n.Quaternion.Z, n.Quaternion.W)
+		w.f32(n.Scale.X, n.Scale.Y, n.Scale.Z)
+		w.bool(n.Visible)
+		w.i32(n.Mesh)
+		w.u32(uint32(len(n.Materials)))
+		for _, nm := range n.Materials {
+			w.u32(uint32(nm.Material))
+			w.u32(uint32(nm.Start))
+			w.u32(uint32(nm.Count))
+		}
+	}
+
+	// Writes the header and the body
+	body := w.buf.Bytes()
+	var header [HeaderSize]byte
+	copy(header[:], Magic)
+	binary.LittleEndian.PutUint32(header[4:], Version)
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(body)))
+	if compress {
+		binary.LittleEndian.PutUint32(header[8:], FlagCompressed)
+	}
+	_, err := out.Write(header[:])
+	if err != nil {
+		return err
+	}
+	if !compress {
+		_, err = out.Write(body)
+		return err
+	}
+	zw := zlib.NewWriter(out)
+	_, err = zw.Write(body)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writer encodes the values of a G3B body.
+type writer struct {
+	buf bytes.Buffer
+	tmp [4]byte
+}
+
+// meshData encodes the vertices, indices and groups of a mesh.
+func (w *writer) meshData(m *Mesh) {
+
+	w.blob(m.Vertices)
+	if m.Indices == nil {
+		w.u32(0)
+	} else {
+		w.u32(1)
+		data := make([]byte, 4*len(m.Indices))
+		for j, v := range m.Indices {
+			binary.LittleEndian.PutUint32(data[4*j:], v)
+		}
+		w.blob(data)
+	}
+	w.u32(uint32(len(m.Groups)))
+	for _, g := range m.Groups {
+		w.u32(uint32(g.Start))
+		w.u32(uint32(g.Count))
+		w.u32(uint32(g.Matindex))
+	}
+}
+
+// u32 encodes an unsigned 32 bit integer.
+func (w *writer) u32(v uint32) {
+
+	binary.LittleEndian.PutUint32(w.tmp[:], v)
+	w.buf.Write(w.tmp[:])
+}
+
+// i32 encodes a signed 32 bit integer.
+func (w *writer) i32(v int) {
+
+	w.u32(uint32(int32(v)))
+}
+
+// bool encodes a boolean as an integer.
+func (w *writer) bool(v bool) {
+
+	if v {
+		w.u32(1)
+	} else {
+		w.u32(0)
+	}
+}
+
+// f32 encodes 32 bit floats.
+func (w *writer) f32(values ...float32) {
+
+	for _, v := range values {
+		w.u32(math.Float32bits(v))
+	}
+}
+
+// color encodes an RGB color.
+func (w *writer) color(c *math32.Color) {
+
+	w.f32(c.R, c.G, c.B)
+}
+
+// blob encodes a byte array padded to 4 bytes.
+func (w *writer) blob(data []byte) {
+
+	w.u32(uint32(len(data)))
+	w.buf.Write(data)
+	for i := len(data); i < align4(len(data)); i++ {
+		w.buf.WriteByte(0)
+	}
+}
@@ -0,0 +1,547 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package g3b implements the G3B format, a compact binary format storing scenes whose
+// meshes were preprocessed when converted from other formats such as OBJ and glTF:
+// their vertices are interleaved in the formats used by the VBOs, tangents are generated
+// and triangles and vertices are reordered for the vertex cache. Loading a G3B file does
+// not parse text or convert vertices, and the vertex and index data of uncompressed files
+// opened with Open are used directly from the memory-mapped file.
+//
+// A file starts with a 16 byte header with the magic "G3B1", the format version, the flags
+// and the size of the body, which is optionally compressed with zlib. The body stores the
+// textures, materials, meshes and nodes of the scene as little endian values, with the byte
+// arrays aligned to 4 bytes.
+package g3b
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"unsafe"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// Format constants.
+const (
+	Magic      = "G3B1" // Magic identifying G3B files
+	Version    = 1      // Current format version
+	HeaderSize = 16     // Size of the file header in bytes
+)
+
+// maxCompression is the maximum compression ratio of zlib, which bounds the size of the
+// decompressed body of a file to check the size in its header before allocating it.
+const maxCompression = 1032
+
+// Header flags.
+const (
+	FlagCompressed = 1 << iota // The body is compressed with zlib
+)
+
+// MaterialKind identifies the type of a material.
+type MaterialKind uint32
+
+// Material kinds.
+const (
+	KindStandard = MaterialKind(iota) // material.Standard
+	KindPhong                         // material.Phong
+	KindPhysical                      // material.Physical
+	KindBasic                         // material.Basic
+)
+
+// File is the decoded content of a G3B file.
+type File struct {
+	Textures  []Texture  // Textures used by the materials
+	Materials []Material // Materials used by the nodes
+	Meshes    []Mesh     // Geometries of the meshes
+	Nodes     []Node     // Nodes of the scene, parents before their children
+	unmap     func() error
+}
+
+// Texture describes a texture with 8 bit RGBA pixels.
+type Texture struct {
+	Width  int        // Width in pixels
+	Height int        // Height in pixels
+	SRGB   bool       // The pixels are sRGB encoded colors
+	FlipY  bool       // The Y coordinate is flipped
+	Repeat [2]float32 // Repeat factors
+	Offset [2]float32 // Offset factors
+	Pixels []byte     // RGBA pixels
+}
+
+// Material describes a material. Texture indices are -1 for no texture.
+type Material struct {
+	Kind        MaterialKind
+	Side        material.Side
+	Transparent bool
+
+	// Standard and Phong materials
+	Ambient   math32.Color
+	Diffuse   math32.Color
+	Specular  math32.Color
+	Emissive  math32.Color // Also the emissive factor of physical materials
+	Shininess float32
+	Opacity   float32
+	Textures  []int
+
+	// Physical materials
+	BaseColor            math32.Color4
+	Metallic             float32
+	Roughness            float32
+	BaseColorMap         int
+	MetallicRoughnessMap int
+	NormalMap            int
+	OcclusionMap         int
+	EmissiveMap          int
+}
+
+// Attrib describes a vertex attribute of a mesh.
+type Attrib struct {
+	Type     gls.AttribType
+	Name     string
+	Elements int
+	Format   gls.AttribFormat
+}
+
+// Mesh describes a geometry with interleaved vertices.
+type Mesh struct {
+	Attribs  []Attrib         // Attributes of each vertex, in order
+	Vertices []byte           // Interleaved vertex data
+	Indices  math32.ArrayU32  // Triangle indices or nil if the mesh is not indexed
+	Groups   []geometry.Group // Groups of the geometry
+}
+
+// Node describes a node of the scene.
+type Node struct {
+	Name       string
+	Parent     int // Index of the parent node or -1 for the root nodes
+	Position   math32.Vector3
+	Quaternion math32.Quaternion
+	Scale      math32.Vector3
+	Visible    bool
+	Mesh       int            // Index of the mesh or -1 if the node is not a mesh
+	Materials  []NodeMaterial // Materials of the mesh
+}
+
+// NodeMaterial describes the material of a range of elements of a mesh.
+type NodeMaterial struct {
+	Material int // Index of the material
+	Start    int // Index of the first element
+	Count    int // Number of elements or zero for all the elements
+}
+
+// Load reads and decodes the specified G3B file.
+func Load(path string) (*File, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, _, err := decode(data)
+	return f, err
+}
+
+// Open memory-maps and decodes the specified G3B file. The vertices, indices and pixels of
+// uncompressed files reference the mapped memory, whose pages are copied only when modified,
+// so the file must not be closed while the decoded data or the meshes created from it are used.
+// On systems without memory mapping the file is read as with Load.
+func Open(path string) (*File, error) {
+
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, compressed, err := decode(data)
+	if err != nil || compressed {
+		if unmap != nil {
+			unmap()
+		}
+		return f, err
+	}
+	f.unmap = unmap
+	return f, nil
+}
+
+// Decode decodes the G3B file with the specified data.
+// The decoded vertices, indices and pixels of uncompressed files reference the data.
+func Decode(data []byte) (*File, error) {
+
+	f, _, err := decode(data)
+	return f, err
+}
+
+// Close releases the memory mapping of a file opened with Open.
+func (f *File) Close() error {
+
+	if f.unmap == nil {
+		return nil
+	}
+	err := f.unmap()
+	f.unmap = nil
+	return err
+}
+
+// decode decodes the G3B file with the specified data and returns whether its body was compressed.
+func decode(data []byte) (*File, bool, error) {
+
+	if len(data) < HeaderSize || string(data[:4]) != Magic {
+		return nil, false, fmt.Errorf("not a G3B file")
+	}
+	version := binary.LittleEndian.Uint32(data[4:])
+	if version != Version {
+		return nil, false, fmt.Errorf("unsupported G3B version:%d", version)
+	}
+	flags := binary.LittleEndian.Uint32(data[8:])
+	size := int(binary.LittleEndian.Uint32(data[12:]))
+	body := data[HeaderSize:]
+	compressed := flags&FlagCompressed != 0
+	if size < 0 {
+		return nil, compressed, fmt.Errorf("invalid G3B body size:%d", size)
+	}
+	if compressed {
+		if size/maxCompression > len(body) {
+			return nil, true, fmt.Errorf("invalid G3B body size:%d", size)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, true, err
+		}
+		body = make([]byte, size)
+		_, err = io.ReadFull(zr, body)
+		if err != nil {
+			return nil, true, err
+		}
+	} else if size > len(body) {
+		return nil, false, fmt.Errorf("truncated G3B file")
+	}
+
+	r := &reader{data: body[:size]}
+	f := new(File)
+	f.Textures = make([]Texture, r.count(28))
+	for i := range f.Textures {
+		t := &f.Textures[i]
+		t.Width = int(r.u32())
+		t.Height = int(r.u32())
+		flags := r.u32()
+		t.SRGB = flags&1 != 0
+		t.FlipY = flags&2 != 0
+		t.Repeat[0], t.Repeat[1] = r.f32(), r.f32()
+		t.Offset[0], t.Offset[1] = r.f32(), r.f32()
+		t.Pixels = r.blob()
+	}
+	f.Materials = make([]Material, r.count(12))
+	for i := range f.Materials {
+		m := &f.Materials[i]
+		m.Kind = MaterialKind(r.u32())
+		m.Side = material.Side(r.u32())
+		m.Transparent = r.u32() != 0
+		switch m.Kind {
+		case KindStandard, KindPhong:
+			m.Ambient = r.color()
+			m.Diffuse = r.color()
+			m.Specular = r.color()
+			m.Emissive = r.color()
+			m.Shininess = r.f32()
+			m.Opacity = r.f32()
+			m.Textures = make([]int, r.count(4))
+			for j := range m.Textures {
+				m.Textures[j] = r.texture(len(f.Textures))
+			}
+		case KindPhysical:
+			m.BaseColor = math32.Color4{R: r.f32(), G: r.f32(), B: r.f32(), A: r.f32()}
+			m.Emissive = r.color()
+			m.Metallic = r.f32()
+			m.Roughness = r.f32()
+			m.BaseColorMap = r.texture(len(f.Textures))
+			m.MetallicRoughnessMap = r.texture(len(f.Textures))
+			m.NormalMap = r.texture(len(f.Textures))
+			m.OcclusionMap = r.texture(len(f.Textures))
+			m.EmissiveMap = r.texture(len(f.Textures))
+		case KindBasic:
+		default:
+			return nil, compressed, fmt.Errorf("invalid kind of material %d", i)
+		}
+	}
+	f.Meshes = make([]Mesh, r.count(16))
+	for i := range f.Meshes {
+		m := &f.Meshes[i]
+		m.Attribs = make([]Attrib, r.count(16))
+		for j := range m.Attribs {
+			a := &m.Attribs[j]
+			a.Type = gls.AttribType(r.u32())
+			a.Name = r.str()
+			a.Elements = int(r.u32())
+			a.Format = gls.AttribFormat(r.u32())
+		}
+		r.meshData(m)
+	}
+	f.Nodes = make([]Node, r.count(56))
+	for i := range f.Nodes {
+		n := &f.Nodes[i]
+		n.Name = r.str()
+		n.Parent = int(r.i32())
+		n.Position = math32.Vector3{X: r.f32(), Y: r.f32(), Z: r.f32()}
+		n.Quaternion = math32.Quaternion{X: r.f32(), Y: r.f32(), Z: r.f32(), W: r.f32()}
+		n.Scale = math32.Vector3{X: r.f32(), Y: r.f32(), Z: r.f32()}
+		n.Visible = r.u32() != 0
+		n.Mesh = int(r.i32())
+		n.Materials = make([]NodeMaterial, r.count(12))
+		for j := range n.Materials {
+			nm := &n.Materials[j]
+			nm.Material = int(r.u32())
+			nm.Start = int(r.u32())
+			nm.Count = int(r.u32())
+		}
+	}
+	if r.err != nil {
+		return nil, compressed, r.err
+	}
+	if err := f.Validate(); err != nil {
+		return nil, compressed, err
+	}
+	return f, compressed, nil
+}
+
+// Validate returns an error if the file is inconsistent, such as if the pixels of a texture
+// or the vertices of a mesh do not match its size, or an index is out of range.
+// Decoded files are validated; files decoded otherwise, such as scene documents, must be
+// validated before being built.
+func (f *File) Validate() error {
+
+	for i := range f.Textures {
+		t := &f.Textures[i]
+		if t.Width < 0 || t.Height < 0 || (t.Height > 0 && t.Width > len(t.Pixels)/4/t.Height) ||
+			len(t.Pixels) != 4*t.Width*t.Height {
+			return fmt.Errorf("invalid size of texture %d", i)
+		}
+	}
+	for i := range f.Materials {
+		if err := f.Materials[i].validate(len(f.Textures)); err != nil {
+			return fmt.Errorf("invalid material %d: %v", i, err)
+		}
+	}
+	for i := range f.Meshes {
+		if err := f.Meshes[i].validate(); err != nil {
+			return fmt.Errorf("invalid mesh %d: %v", i, err)
+		}
+	}
+	for i := range f.Nodes {
+		n := &f.Nodes[i]
+		if n.Parent >= i || n.Parent < -1 || n.Mesh >= len(f.Meshes) || n.Mesh < -1 {
+			return fmt.Errorf("invalid node %d", i)
+		}
+		for _, nm := range n.Materials {
+			if nm.Material < 0 || nm.Material >= len(f.Materials) {
+				return fmt.Errorf("invalid material of node %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns an error if the kind of the material is unknown or it references
+// textures out of the range of the specified number of textures.
+func (m *Material) validate(textures int) error {
+
+	var maps []int
+	switch m.Kind {
+	case KindStandard, KindPhong:
+		maps = m.Textures
+	case KindPhysical:
+		maps = []int{m.BaseColorMap, m.MetallicRoughnessMap, m.NormalMap, m.OcclusionMap, m.EmissiveMap}
+	case KindBasic:
+	default:
+		return fmt.Errorf("unknown kind %d", m.Kind)
+	}
+	for _, idx := range maps {
+		if idx < -1 || idx >= textures {
+			return fmt.Errorf("invalid texture index %d", idx)
+		}
+	}
+	return nil
+}
+
+// validate returns an error if an attribute of the mesh has an unsupported number of elements
+// or format, its vertices are not a whole number of vertices or its indices or groups reference
+// vertices or indices it does not have.
+func (m *Mesh) validate() error {
+
+	for _, a := range m.Attribs {
+		if a.Elements < 1 || a.Elements > 4 {
+			return fmt.Errorf("invalid number of elements %d of attribute %q", a.Elements, a.Name)
+		}
+		if a.Format < gls.FormatFloat || a.Format > gls.FormatSnorm2101010 {
+			return fmt.Errorf("invalid format %d of attribute %q", a.Format, a.Name)
+		}
+	}
+	vbo := m.vbo()
+	if stride := vbo.StrideSize(); (stride == 0 && len(m.Vertices) > 0) || (stride > 0 && len(m.Vertices)%stride != 0) {
+		return fmt.Errorf("invalid size of vertices")
+	}
+	count := uint32(vbo.Items())
+	for _, idx := range m.Indices {
+		if idx >= count {
+			return fmt.Errorf("index %d out of range of %d vertices", idx, count)
+		}
+	}
+	elements := int(count)
+	if m.Indices != nil {
+		elements = len(m.Indices)
+	}
+	for _, g := range m.Groups {
+		if g.Start < 0 || g.Count < 0 || g.Start > elements-g.Count {
+			return fmt.Errorf("group out of range of %d elements", elements)
+		}
+	}
+	return nil
+}
+
+// reader decodes the values of a G3B body, keeping the first error.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// fail records the error of a truncated body.
+func (r *reader) fail() {
+
+	if r.err == nil {
+		r.err = fmt.Errorf("truncated G3B body at offset %d", r.pos)
+	}
+	r.pos = len(r.data)
+}
+
+// meshData decodes the vertices, indices and groups of a mesh.
+func (r *reader) meshData(m *Mesh) {
+
+	m.Vertices = r.blob()
+	m.Indices = nil
+	if r.u32() != 0 {
+		m.Indices = bytesToArrayU32(r.blob())
+	}
+	m.Groups = make([]geometry.Group, r.count(12))
+	for j := range m.Groups {
+		g := &m.Groups[j]
+		g.Start = int(r.u32())
+		g.Count = int(r.u32())
+		g.Matindex = int(r.u32())
+	}
+}
+
+// u32 decodes an unsigned 32 bit integer.
+func (r *reader) u32() uint32 {
+
+	if r.pos+4 > len(r.data) {
+		r.fail()
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v
+}
+
+// i32 decodes a signed 32 bit integer.
+func (r *reader) i32() int32 {
+
+	return int32(r.u32())
+}
+
+// f32 decodes a 32 bit float.
+func (r *reader) f32() float32 {
+
+	return math.Float32frombits(r.u32())
+}
+
+// color decodes an RGB color.
+func (r *reader) color() math32.Color {
+
+	return math32.Color{R: r.f32(), G: r.f32(), B: r.f32()}
+}
+
+// texture decodes a texture index, which is -1 or less than the specified number of textures.
+func (r *reader) texture(count int) int {
+
+	idx := int(r.i32())
+	if idx < -1 || idx >= count {
+		if r.err == nil {
+			r.err = fmt.Errorf("invalid texture index %d", idx)
+		}
+		return -1
+	}
+	return idx
+}
+
+// count decodes the number of items of a list, checking that the remaining data can
+// store that many items of at least the specified size.
+func (r *reader) count(size int) int {
+
+	n := int(r.u32())
+	if n < 0 || n > (len(r.data)-r.pos)/size {
+		r.fail()
+		return 0
+	}
+	return n
+}
+
+// blob decodes a byte array, which references the data.
+func (r *reader) blob() []byte {
+
+	n := int(r.u32())
+	if n < 0 || n > len(r.data)-r.pos {
+		r.fail()
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n : r.pos+n]
+	r.pos += align4(n)
+	if r.pos > len(r.data) {
+		r.pos = len(r.data)
+	}
+	return b
+}
+
+// str decodes a string.
+func (r *reader) str() string {
+
+	return string(r.blob())
+}
+
+// align4 returns the specified size rounded up to a multiple of 4.
+func align4(n int) int {
+
+	return (n + 3) &^ 3
+}
+
+// littleEndian is whether the host stores integers in little endian order,
+// so that the arrays of the file can be used directly.
+var littleEndian = func() bool {
+	v := uint16(1)
+	return *(*byte)(unsafe.Pointer(&v)) == 1
+}()
+
+// bytesToArrayU32 returns the array of 32 bit integers stored in the specified data,
+// referencing the data if its alignment and the host byte order allow it.
+func bytesToArrayU32(data []byte) math32.ArrayU32 {
+
+	count := len(data) / 4
+	if count == 0 {
+		return math32.ArrayU32{}
+	}
+	if littleEndian && uintptr(unsafe.Pointer(&data[0]))%4 == 0 {
+		return math32.ArrayU32((*[1 << 30]uint32)(unsafe.Pointer(&data[0]))[:count:count])
+	}
+	arr := math32.NewArrayU32(count, count)
+	for i := range arr {
+		arr[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	return arr
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package g3b
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// triangle returns a file with a triangle whose position attribute has the specified
+// number of elements and format, and vertices of the matching size if it is small.
+func triangle(elements int, format gls.AttribFormat) *File {
+
+	size := 12
+	if format == gls.FormatSnorm2101010 {
+		size = 4
+	} else if elements <= 8 {
+		size = 4 * elements
+	}
+	return &File{
+		Meshes: []Mesh{{
+			Attribs:  []Attrib{{Type: gls.VertexPosition, Name: "VertexPosition", Elements: elements, Format: format}},
+			Vertices: make([]byte, 3*size),
+			Indices:  math32.ArrayU32{0, 1, 2},
+		}},
+		Nodes: []Node{{Parent: -1, Mesh: 0, Scale: math32.Vector3{X: 1, Y: 1, Z: 1}}},
+	}
+}
+
+// TestDecodeAttribs checks that attributes with unsupported numbers of elements or formats are rejected.
+func TestDecodeAttribs(t *testing.T) {
+
+	tests := []struct {
+		elements int
+		format   gls.AttribFormat
+		valid    bool
+	}{
+		{3, gls.FormatFloat, true},
+		{1, gls.FormatHalf, true},
+		{4, gls.FormatSnorm2101010, true},
+		{0, gls.FormatFloat, false},
+		{5, gls.FormatFloat, false},
+		{1 << 31, gls.FormatUnorm8, false},
+		{3, gls.FormatSnorm2101010 + 1, false},
+		{3, -1, false},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := triangle(test.elements, test.format).Write(&buf, false); err != nil {
+			t.Fatal(err)
+		}
+		f, err := Decode(buf.Bytes())
+		if test.valid && err != nil {
+			t.Errorf("attribute with %d elements of format %d rejected: %v", test.elements, test.format, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("attribute with %d elements of format %d accepted", test.elements, test.format)
+		}
+		if err == nil && f.Meshes[0].Attribs[0].Elements != test.elements {
+			t.Errorf("attribute decoded with %d elements instead of %d", f.Meshes[0].Attribs[0].Elements, test.elements)
+		}
+	}
+}
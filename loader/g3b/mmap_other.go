@@ -0,0 +1,18 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package g3b
+
+import (
+	"io/ioutil"
+)
+
+// mapFile reads the specified file on systems without memory mapping.
+func mapFile(path string) ([]byte, func() error, error) {
+
+	data, err := ioutil.ReadFile(path)
+	return data, nil, err
+}
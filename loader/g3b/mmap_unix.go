@@ -0,0 +1,38 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux darwin freebsd netbsd openbsd
+
+package g3b
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the specified file into memory and returns its data and the function
+// releasing the mapping. The mapping is private, so modifying the data copies the
+// modified pages instead of changing the file.
+func mapFile(path string) ([]byte, func() error, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := fi.Size()
+	if size < HeaderSize || int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("invalid G3B file size:%d", size)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package g3b
+
+import (
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// generateTangents returns the per vertex tangents of the triangles of the specified geometry,
+// pointing in the direction of increasing U texture coordinates and orthogonal to the normals,
+// or nil if the geometry has no positions, normals or texture coordinates.
+func generateTangents(geom *geometry.Geometry) math32.ArrayF32 {
+
+	posVBO := geom.VBO(gls.VertexPosition)
+	if posVBO == nil || geom.VBO(gls.VertexNormal) == nil || geom.VBO(gls.VertexTexcoord) == nil {
+		return nil
+	}
+	count := posVBO.Items()
+	positions := readAttrib(geom, gls.VertexPosition, count, 3)
+	normals := readAttrib(geom, gls.VertexNormal, count, 3)
+	uvs := readAttrib(geom, gls.VertexTexcoord, count, 2)
+
+	// Accumulates the tangents of the triangles of each vertex
+	accum := make([]math32.Vector3, count)
+	triangle := func(a, b, c int) {
+		if a >= count || b >= count || c >= count {
+			return
+		}
+		var p0, p1, p2 math32.Vector3
+		p0.FromArray(positions, 3*a)
+		p1.FromArray(positions, 3*b)
+		p2.FromArray(positions, 3*c)
+		e1 := *p1.Sub(&p0)
+		e2 := *p2.Sub(&p0)
+		du1, dv1 := uvs[2*b]-uvs[2*a], uvs[2*b+1]-uvs[2*a+1]
+		du2, dv2 := uvs[2*c]-uvs[2*a], uvs[2*c+1]-uvs[2*a+1]
+		det := du1*dv2 - du2*dv1
+		if det == 0 {
+			return
+		}
+		t := *e1.MultiplyScalar(dv2 / det)
+		t.Sub(e2.MultiplyScalar(dv1 / det))
+		accum[a].Add(&t)
+		accum[b].Add(&t)
+		accum[c].Add(&t)
+	}
+	if geom.Indexed() {
+		indices := geom.Indices()
+		for i := 0; i+2 < len(indices); i += 3 {
+			triangle(int(indices[i]), int(indices[i+1]), int(indices[i+2]))
+		}
+	} else {
+		for i := 0; i+2 < count; i += 3 {
+			triangle(i, i+1, i+2)
+		}
+	}
+
+	// Orthogonalizes the tangents to the normals
+	tangents := math32.NewArrayF32(3*count, 3*count)
+	for i := range accum {
+		var n math32.Vector3
+		n.FromArray(normals, 3*i)
+		t := accum[i]
+		t.Sub(n.Clone().MultiplyScalar(n.Dot(&t)))
+		if t.LengthSq() < 1e-12 {
+			// Uses any direction orthogonal to the normal
+			if math32.Abs(n.X) < 0.9 {
+				t.Set(1, 0, 0)
+			} else {
+				t.Set(0, 1, 0)
+			}
+			t.Sub(n.Clone().MultiplyScalar(n.Dot(&t)))
+		}
+		t.Normalize()
+		t.ToArray(tangents, 3*i)
+	}
+	return tangents
+}
+
+// readAttrib returns the specified number of elements of the attribute of the specified type
+// of the specified number of vertices, whatever its format.
+func readAttrib(geom *geometry.Geometry, atype gls.AttribType, count, size int) []float32 {
+
+	vbo := geom.VBO(atype)
+	attrib := vbo.Attrib(atype)
+	values := make([]float32, 4+attrib.NumElements)
+	out := make([]float32, count*size)
+	for i := 0; i < count && i < vbo.Items(); i++ {
+		vbo.ReadAttrib(attrib, i, values)
+		copy(out[i*size:(i+1)*size], values)
+	}
+	return out
+}
+
+// Parameters of the vertex cache optimization.
+const (
+	cacheSize         = 32   // Size of the simulated vertex cache
+	cacheDecayPower   = 1.5  // Decay of the score of vertices with their position in the cache
+	lastTriangleScore = 0.75 // Score of the vertices of the last triangle
+	valenceBoostScale = 2.0  // Boost of the score of vertices with few remaining triangles
+	valenceBoostPower = 0.5
+)
+
+// optimizeTriangles reorders the specified triangle indices to reuse the vertices transformed
+// recently by the GPU, using the linear-speed vertex cache optimization by Tom Forsyth, which
+// reduces the number of vertex shader invocations of large meshes.
+func optimizeTriangles(indices []uint32, vertexCount int) {
+
+	triCount := len(indices) / 3
+	if triCount < 2 {
+		return
+	}
+
+	// Builds the lists of the remaining triangles of each vertex
+	offsets := make([]int, vertexCount+1)
+	for _, v := range indices {
+		if int(v) >= vertexCount {
+			return
+		}
+		offsets[v+1]++
+	}
+	for i := 0; i < vertexCount; i++ {
+		offsets[i+1] += offsets[i]
+	}
+	tris := make([]int, len(indices))
+	remaining := make([]int, vertexCount)
+	for t := 0; t < triCount; t++ {
+		for _, v := range indices[3*t : 3*t+3] {
+			tris[offsets[v]+remaining[v]] = t
+			remaining[v]++
+		}
+	}
+	cachePos := make([]int, vertexCount)
+	scores := make([]float32, vertexCount)
+	for v := range scores {
+		cachePos[v] = -1
+		scores[v] = vertexScore(-1, remaining[v])
+	}
+
+	out := make([]uint32, 0, len(indices))
+	emitted := make([]bool, triCount)
+	cache := make([]uint32, 0, cacheSize+3)
+	next := make([]uint32, 0, cacheSize+3)
+	best := -1
+	cursor := 0
+	for len(out) < 3*triCount {
+		// Without candidates in the cache, continues with the next triangle in the original order
+		if best < 0 {
+			for emitted[cursor] {
+				cursor++
+			}
+			best = cursor
+		}
+		tri := indices[3*best : 3*best+3]
+		emitted[best] = true
+		out = append(out, tri...)
+		for _, v := range tri {
+			list := tris[offsets[v] : offsets[v]+remaining[v]]
+			for i, t := range list {
+				if t == best {
+					list[i] = list[len(list)-1]
+					break
+				}
+			}
+			remaining[v]--
+		}
+
+		// Moves the vertices of the triangle to the front of the cache
+		next = append(next[:0], tri...)
+		for _, v := range cache {
+			if v != tri[0] && v != tri[1] && v != tri[2] {
+				next = append(next, v)
+			}
+		}
+		cache, next = next, cache
+		for i, v := range cache {
+			if i < cacheSize {
+				cachePos[v] = i
+			} else {
+				cachePos[v] = -1
+			}
+			scores[v] = vertexScore(cachePos[v], remaining[v])
+		}
+
+		// Picks the best remaining triangle using the vertices in the cache
+		best = -1
+		bestScore := float32(-1)
+		for _, v := range cache {
+			for _, t := range tris[offsets[v] : offsets[v]+remaining[v]] {
+				score := scores[indices[3*t]] + scores[indices[3*t+1]] + scores[indices[3*t+2]]
+				if score > bestScore {
+					best = t
+					bestScore = score
+				}
+			}
+		}
+		if len(cache) > cacheSize {
+			cache = cache[:cacheSize]
+		}
+	}
+	copy(indices, out)
+}
+
+// vertexScore returns the score of a vertex with the specified position in the cache
+// (-1 if it is not cached) and number of remaining triangles.
+func vertexScore(pos, remaining int) float32 {
+
+	if remaining == 0 {
+		return -1
+	}
+	var score float32
+	if pos >= 0 {
+		if pos < 3 {
+			score = lastTriangleScore
+		} else {
+			score = math32.Pow(1-float32(pos-3)/float32(cacheSize-3), cacheDecayPower)
+		}
+	}
+	return score + valenceBoostScale*math32.Pow(float32(remaining), -valenceBoostPower)
+}
+
+// reorderVertices reorders the vertices of the specified mesh with the specified vertex size
+// in the order they are first used by its triangles, so the GPU fetches them sequentially.
+// Unused vertices are moved to the end.
+func reorderVertices(mesh *Mesh, stride int) {
+
+	count := len(mesh.Vertices) / stride
+	remap := make([]int, count)
+	for i := range remap {
+		remap[i] = -1
+	}
+	for _, v := range mesh.Indices {
+		if int(v) >= count {
+			return
+		}
+	}
+	next := 0
+	for i, v := range mesh.Indices {
+		if remap[v] < 0 {
+			remap[v] = next
+			next++
+		}
+		mesh.Indices[i] = uint32(remap[v])
+	}
+	for v := range remap {
+		if remap[v] < 0 {
+			remap[v] = next
+			next++
+		}
+	}
+	vertices := make([]byte, len(mesh.Vertices))
+	for v, nv := range remap {
+		copy(vertices[nv*stride:(nv+1)*stride], mesh.Vertices[v*stride:(v+1)*stride])
+	}
+	mesh.Vertices = vertices
+}
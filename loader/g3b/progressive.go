@@ -0,0 +1,324 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package g3b
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// Progressive stream constants.
+//
+// A progressive stream starts with a 12 byte header with the magic "G3BP", the format version
+// and the size of the G3B file which follows it. That file stores the scene with the coarsest
+// level of detail of each mesh. It is followed by a table with the number of finer levels of
+// each mesh and the boundaries of the index ranges of its groups and materials at its coarsest
+// level, and by the refinements of the meshes, coarse levels first, up to level 0, which is the
+// original mesh. Each refinement has a 16 byte header with the mesh index, the level, the flags
+// and the size of its body, which stores the vertices, indices, groups and range boundaries of
+// the mesh at that level, optionally compressed with zlib.
+const (
+	ProgressiveMagic      = "G3BP" // Magic identifying progressive streams
+	ProgressiveVersion    = 1      // Current progressive stream version
+	ProgressiveHeaderSize = 12     // Size of the progressive stream header in bytes
+	RefinementHeaderSize  = 16     // Size of the header of each refinement in bytes
+)
+
+// lodReduction is the minimum reduction of the number of triangles of each level of detail.
+const lodReduction = 0.75
+
+// lod is a level of detail of a mesh with the boundaries of its index ranges.
+type lod struct {
+	mesh   Mesh
+	bounds []int
+}
+
+// WriteProgressive writes the file as a progressive stream with up to the specified number
+// of coarser levels of detail of its indexed meshes, each with about a quarter of the triangles
+// of the next finer level. Readers such as Stream show the coarsest levels first and refine
+// the meshes as the stream is received. The embedded file and the refinements are optionally
+// compressed with zlib.
+func (f *File) WriteProgressive(out io.Writer, levels int, compress bool) error {
+
+	// Builds the levels of detail of each mesh, finest first
+	lods := make([][]lod, len(f.Meshes))
+	coarse := *f
+	coarse.Meshes = make([]Mesh, len(f.Meshes))
+	coarse.Nodes = make([]Node, len(f.Nodes))
+	copy(coarse.Nodes, f.Nodes)
+	for i := range f.Meshes {
+		mesh := &f.Meshes[i]
+		lods[i] = []lod{{mesh: *mesh}}
+		if mesh.Indices != nil {
+			lods[i][0].bounds = f.meshBounds(i)
+			lods[i] = append(lods[i], buildLODs(mesh, lods[i][0].bounds, levels)...)
+		}
+		coarsest := &lods[i][len(lods[i])-1]
+		coarse.Meshes[i] = coarsest.mesh
+		if len(lods[i]) > 1 {
+			remapNodeRanges(&coarse, i, lods[i][0].bounds, coarsest.bounds)
+		}
+	}
+
+	// Writes the header and the file with the coarsest levels
+	var buf bytes.Buffer
+	err := coarse.Write(&buf, compress)
+	if err != nil {
+		return err
+	}
+	var header [ProgressiveHeaderSize]byte
+	copy(header[:], ProgressiveMagic)
+	binary.LittleEndian.PutUint32(header[4:], ProgressiveVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(buf.Len()))
+	_, err = out.Write(header[:])
+	if err == nil {
+		_, err = buf.WriteTo(out)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Writes the table of levels
+	w := new(writer)
+	w.u32(uint32(len(lods)))
+	maxLevel := 0
+	for i := range lods {
+		level := len(lods[i]) - 1
+		if level > maxLevel {
+			maxLevel = level
+		}
+		w.u32(uint32(level))
+		w.bounds(lods[i][level].bounds)
+	}
+	_, err = w.buf.WriteTo(out)
+	if err != nil {
+		return err
+	}
+
+	// Writes the refinements, coarse levels first
+	for level := maxLevel - 1; level >= 0; level-- {
+		for i := range lods {
+			if level >= len(lods[i])-1 {
+				continue
+			}
+			err = writeRefinement(out, i, level, &lods[i][level], compress)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeRefinement writes the refinement of the specified mesh to the specified level.
+func writeRefinement(out io.Writer, mesh, level int, l *lod, compress bool) error {
+
+	w := new(writer)
+	w.meshData(&l.mesh)
+	w.bounds(l.bounds)
+	body := w.buf.Bytes()
+	var flags uint32
+	if compress {
+		var zbuf bytes.Buffer
+		zw := zlib.NewWriter(&zbuf)
+		_, err := zw.Write(body)
+		if err == nil {
+			err = zw.Close()
+		}
+		if err != nil {
+			return err
+		}
+		body = zbuf.Bytes()
+		flags = FlagCompressed
+	}
+	var header [RefinementHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[0:], uint32(mesh))
+	binary.LittleEndian.PutUint32(header[4:], uint32(level))
+	binary.LittleEndian.PutUint32(header[8:], flags)
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(body)))
+	_, err := out.Write(header[:])
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(body)
+	return err
+}
+
+// bounds encodes a list of range boundaries.
+func (w *writer) bounds(bounds []int) {
+
+	w.u32(uint32(len(bounds)))
+	for _, b := range bounds {
+		w.u32(uint32(b))
+	}
+}
+
+// buildLODs returns up to the specified number of coarser levels of detail of the specified
+// indexed mesh with the specified range boundaries, finest first. It stops when a level does
+// not remove enough triangles.
+func buildLODs(mesh *Mesh, bounds []int, levels int) []lod {
+
+	vbo := mesh.vbo()
+	stride := vbo.StrideSize()
+	attrib := vbo.Attrib(gls.VertexPosition)
+	if stride == 0 || attrib == nil {
+		return nil
+	}
+	count := len(mesh.Vertices) / stride
+	for _, v := range mesh.Indices {
+		if int(v) >= count {
+			return nil
+		}
+	}
+	positions := make([]math32.Vector3, count)
+	values := make([]float32, 4+attrib.NumElements)
+	var box math32.Box3
+	box.MakeEmpty()
+	for i := range positions {
+		vbo.ReadAttrib(attrib, i, values)
+		positions[i].Set(values[0], values[1], values[2])
+		box.ExpandByPoint(&positions[i])
+	}
+
+	var lods []lod
+	triangles := len(mesh.Indices) / 3
+	cells := math32.Sqrt(float32(triangles) / 2)
+	for level := 1; level <= levels; level++ {
+		cells /= 2
+		if cells < 2 {
+			break
+		}
+		l := simplify(mesh, stride, positions, &box, bounds, cells)
+		reduced := len(l.mesh.Indices) / 3
+		if float32(reduced) > lodReduction*float32(triangles) {
+			break
+		}
+		lods = append(lods, l)
+		triangles = reduced
+	}
+	return lods
+}
+
+// simplify returns a coarser level of detail of the specified indexed mesh, clustering its
+// vertices in a grid with the specified number of cells along the largest side of its
+// bounding box. Each cluster is replaced by its first used vertex and collapsed triangles
+// are removed. The triangles of each index range are simplified separately, and a range
+// keeps its first triangle if all of them collapse.
+func simplify(mesh *Mesh, stride int, positions []math32.Vector3, box *math32.Box3, bounds []int, cells float32) lod {
+
+	var size math32.Vector3
+	size.SubVectors(&box.Max, &box.Min)
+	cell := math32.Max(size.X, math32.Max(size.Y, size.Z)) / cells
+	if cell <= 0 {
+		cell = 1
+	}
+
+	// Maps each vertex to the first used vertex of its cluster
+	clusters := make(map[[3]int32]uint32)
+	remap := make([]uint32, len(positions))
+	for i := range remap {
+		remap[i] = ^uint32(0)
+	}
+	for _, v := range mesh.Indices {
+		if remap[v] != ^uint32(0) {
+			continue
+		}
+		p := &positions[v]
+		key := [3]int32{
+			int32(math32.Floor((p.X - box.Min.X) / cell)),
+			int32(math32.Floor((p.Y - box.Min.Y) / cell)),
+			int32(math32.Floor((p.Z - box.Min.Z) / cell)),
+		}
+		rep, ok := clusters[key]
+		if !ok {
+			rep = v
+			clusters[key] = v
+		}
+		remap[v] = rep
+	}
+
+	// Collapses the triangles of each range
+	var l lod
+	indices := make([]uint32, 0, len(mesh.Indices)/2)
+	l.bounds = append(l.bounds, 0)
+	for i := 1; i < len(bounds); i++ {
+		start, end := bounds[i-1], bounds[i]
+		first := len(indices)
+		for t := start; t+3 <= end; t += 3 {
+			a, b, c := remap[mesh.Indices[t]], remap[mesh.Indices[t+1]], remap[mesh.Indices[t+2]]
+			if a != b && b != c && c != a {
+				indices = append(indices, a, b, c)
+			}
+		}
+		if len(indices) == first && end-start >= 3 {
+			indices = append(indices, mesh.Indices[start:start+3]...)
+		}
+		l.bounds = append(l.bounds, len(indices))
+	}
+
+	// Keeps the used vertices in the order of their first use
+	newIndex := make(map[uint32]uint32)
+	vertices := make([]byte, 0, len(mesh.Vertices)/2)
+	for i, v := range indices {
+		nv, ok := newIndex[v]
+		if !ok {
+			nv = uint32(len(newIndex))
+			newIndex[v] = nv
+			vertices = append(vertices, mesh.Vertices[int(v)*stride:int(v+1)*stride]...)
+		}
+		indices[i] = nv
+	}
+	l.mesh.Attribs = mesh.Attribs
+	l.mesh.Vertices = vertices
+	l.mesh.Indices = math32.ArrayU32(indices)
+	for _, g := range mesh.Groups {
+		start, end := remapBound(g.Start, bounds, l.bounds), remapBound(g.Start+g.Count, bounds, l.bounds)
+		l.mesh.Groups = append(l.mesh.Groups, geometry.Group{Start: start, Count: end - start, Matindex: g.Matindex, Matid: g.Matid})
+	}
+	return l
+}
+
+// remapNodeRanges remaps the material ranges of the nodes of the specified file using the
+// specified mesh from the specified boundaries to the corresponding new boundaries.
+func remapNodeRanges(f *File, mesh int, from, to []int) {
+
+	for i := range f.Nodes {
+		n := &f.Nodes[i]
+		if n.Mesh != mesh {
+			continue
+		}
+		mats := make([]NodeMaterial, len(n.Materials))
+		for j, nm := range n.Materials {
+			if nm.Count > 0 {
+				start := remapBound(nm.Start, from, to)
+				nm.Count = remapBound(nm.Start+nm.Count, from, to) - start
+				nm.Start = start
+			}
+			mats[j] = nm
+		}
+		n.Materials = mats
+	}
+}
+
+// remapBound returns the boundary corresponding to the specified one of the sorted
+// boundaries from in the boundaries to, which have the same length.
+func remapBound(bound int, from, to []int) int {
+
+	i := sort.SearchInts(from, bound)
+	if i >= len(from) || i >= len(to) {
+		if len(to) == 0 {
+			return 0
+		}
+		return to[len(to)-1]
+	}
+	return to[i]
+}
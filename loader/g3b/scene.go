@@ -0,0 +1,200 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package g3b
+
+import (
+	"image"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/texture"
+)
+
+// NewScene creates and returns a node containing the root nodes of the scene of the file.
+// The textures, materials and geometries used by several nodes are shared between them.
+func (f *File) NewScene() *core.Node {
+
+	return NewBuilder(f).scene()
+}
+
+// Builder creates the engine objects described by a file, once for each of them,
+// such as to build scenes whose nodes are described elsewhere.
+type Builder struct {
+	file      *File
+	textures  []*texture.Texture2D
+	materials []material.IMaterial
+	geoms     []*geometry.Geometry
+	nodes     []core.INode
+}
+
+// NewBuilder creates and returns a pointer to a new builder for the specified file,
+// which must be valid (see File.Validate).
+func NewBuilder(f *File) *Builder {
+
+	return &Builder{
+		file:      f,
+		textures:  make([]*texture.Texture2D, len(f.Textures)),
+		materials: make([]material.IMaterial, len(f.Materials)),
+		geoms:     make([]*geometry.Geometry, len(f.Meshes)),
+		nodes:     make([]core.INode, len(f.Nodes)),
+	}
+}
+
+// scene creates and returns a node containing the root nodes of the scene.
+func (b *Builder) scene() *core.Node {
+
+	root := core.NewNode()
+	for i := range b.file.Nodes {
+		desc := &b.file.Nodes[i]
+		var inode core.INode
+		if desc.Mesh >= 0 {
+			inode = b.Mesh(desc.Mesh, desc.Materials)
+		} else {
+			inode = core.NewNode()
+		}
+		node := inode.GetNode()
+		node.SetName(desc.Name)
+		node.SetPositionVec(&desc.Position)
+		node.SetQuaternionQuat(&desc.Quaternion)
+		node.SetScaleVec(&desc.Scale)
+		node.SetVisible(desc.Visible)
+		if desc.Parent < 0 {
+			root.Add(inode)
+		} else {
+			b.nodes[desc.Parent].GetNode().Add(inode)
+		}
+		b.nodes[i] = inode
+	}
+	return root
+}
+
+// Mesh creates and returns a new mesh with the geometry of the mesh with the specified index
+// and the specified materials.
+func (b *Builder) Mesh(idx int, materials []NodeMaterial) *graphic.Mesh {
+
+	mesh := graphic.NewMesh(b.Geometry(idx), nil)
+	for _, nm := range materials {
+		mesh.AddMaterial(b.Material(nm.Material), nm.Start, nm.Count)
+	}
+	return mesh
+}
+
+// Texture returns the texture with the specified index, with a new reference for the caller,
+// or nil if the index is negative.
+func (b *Builder) Texture(idx int) *texture.Texture2D {
+
+	if idx < 0 {
+		return nil
+	}
+	if tex := b.textures[idx]; tex != nil {
+		return tex.Incref()
+	}
+	desc := &b.file.Textures[idx]
+	rgba := &image.RGBA{
+		Pix:    desc.Pixels,
+		Stride: 4 * desc.Width,
+		Rect:   image.Rect(0, 0, desc.Width, desc.Height),
+	}
+	tex := texture.NewTexture2DFromRGBA(rgba)
+	tex.SetSRGB(desc.SRGB)
+	tex.SetFlipY(desc.FlipY)
+	tex.SetRepeat(desc.Repeat[0], desc.Repeat[1])
+	tex.SetOffset(desc.Offset[0], desc.Offset[1])
+	b.textures[idx] = tex
+	return tex
+}
+
+// Material returns the material with the specified index, with a new reference for the caller.
+func (b *Builder) Material(idx int) material.IMaterial {
+
+	if imat := b.materials[idx]; imat != nil {
+		imat.GetMaterial().Incref()
+		return imat
+	}
+	desc := &b.file.Materials[idx]
+	var imat material.IMaterial
+	switch desc.Kind {
+	case KindStandard, KindPhong:
+		var sm *material.Standard
+		if desc.Kind == KindPhong {
+			pm := material.NewPhong(&desc.Diffuse)
+			sm = &pm.Standard
+			imat = pm
+		} else {
+			sm = material.NewStandard(&desc.Diffuse)
+			imat = sm
+		}
+		sm.SetAmbientColor(&desc.Ambient)
+		sm.SetSpecularColor(&desc.Specular)
+		sm.SetEmissiveColor(&desc.Emissive)
+		sm.SetShininess(desc.Shininess)
+		sm.SetOpacity(desc.Opacity)
+		for _, t := range desc.Textures {
+			sm.AddTexture(b.Texture(t))
+		}
+	case KindPhysical:
+		pm := material.NewPhysical()
+		pm.SetBaseColorFactor(&desc.BaseColor)
+		pm.SetEmissiveFactor(&desc.Emissive)
+		pm.SetMetallicFactor(desc.Metallic)
+		pm.SetRoughnessFactor(desc.Roughness)
+		if desc.BaseColorMap >= 0 {
+			pm.SetBaseColorMap(b.Texture(desc.BaseColorMap))
+		}
+		if desc.MetallicRoughnessMap >= 0 {
+			pm.SetMetallicRoughnessMap(b.Texture(desc.MetallicRoughnessMap))
+		}
+		if desc.NormalMap >= 0 {
+			pm.SetNormalMap(b.Texture(desc.NormalMap))
+		}
+		if desc.OcclusionMap >= 0 {
+			pm.SetOcclusionMap(b.Texture(desc.OcclusionMap))
+		}
+		if desc.EmissiveMap >= 0 {
+			pm.SetEmissiveMap(b.Texture(desc.EmissiveMap))
+		}
+		imat = pm
+	default:
+		imat = material.NewBasic()
+	}
+	mat := imat.GetMaterial()
+	mat.SetSide(desc.Side)
+	mat.SetTransparent(desc.Transparent)
+	b.materials[idx] = imat
+	return imat
+}
+
+// Geometry returns the geometry of the mesh with the specified index, with a new reference
+// for the caller. Its VBO and indices reference the data of the file.
+func (b *Builder) Geometry(idx int) *geometry.Geometry {
+
+	if geom := b.geoms[idx]; geom != nil {
+		return geom.Incref()
+	}
+	desc := &b.file.Meshes[idx]
+	geom := geometry.NewGeometry()
+	geom.AddVBO(desc.vbo())
+	if desc.Indices != nil {
+		geom.SetIndices(desc.Indices)
+	}
+	for _, g := range desc.Groups {
+		geom.AddGroup(g.Start, g.Count, g.Matindex)
+	}
+	b.geoms[idx] = geom
+	return geom
+}
+
+// vbo returns a new VBO with the interleaved vertices of the mesh.
+func (m *Mesh) vbo() *gls.VBO {
+
+	vbo := gls.NewVBOBytes(m.Vertices)
+	for _, a := range m.Attribs {
+		vbo.AddTypedAttribFormat(a.Type, a.Name, int32(a.Elements), a.Format)
+	}
+	return vbo
+}
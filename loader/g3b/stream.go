@@ -0,0 +1,436 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package g3b
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/graphic"
+)
+
+// OnRefine is dispatched by a Stream when it refines a mesh. Data is a *RefineEvent.
+const OnRefine = "g3b.OnRefine"
+
+// StreamUploadLimit is the default maximum number of bytes of refinements applied by a Stream per update.
+const StreamUploadLimit = 4 * 1024 * 1024
+
+// StreamMaxBlockSize is the maximum size in bytes of the file with the coarsest levels of a
+// progressive stream and of its refinements, before and after decompression. The blocks are
+// read as they arrive so that a corrupt size does not allocate more memory than received.
+const StreamMaxBlockSize = 1 << 30
+
+// RefineEvent describes the refinement of a mesh of a Stream.
+type RefineEvent struct {
+	Mesh  int // Index of the mesh in the file
+	Level int // New level of detail of the mesh, where 0 is the original mesh
+}
+
+// Stream loads a progressive stream written by File.WriteProgressive, such as from a file
+// or the body of an HTTP response. Its scene is created as soon as the coarsest levels of
+// detail are received, and its meshes are refined in place, sharing their geometries as in
+// File.NewScene, as the finer levels are received in the background. The refinements are
+// applied by Update, up to the upload limit per call, to the meshes with the highest
+// priorities first.
+type Stream struct {
+	core.Dispatcher                            // Embedded event dispatcher
+	builder         *Builder                   // Builder of the scene
+	root            *core.Node                 // Root of the scene
+	meshes          map[*geometry.Geometry]int // Index of the mesh of each geometry
+	users           [][]int                    // Nodes using each mesh
+	levels          []int                      // Current level of each mesh
+	bounds          [][]int                    // Current range boundaries of each mesh
+	boundCounts     []int                      // Number of range boundaries of each mesh
+	priorities      []int                      // Priority of each mesh
+	limit           int                        // Maximum number of bytes applied per update
+	total           int                        // Total number of refinements
+	applied         int                        // Number of applied refinements
+	event           RefineEvent                // Reused data of OnRefine events
+
+	mu      sync.Mutex    // Protects the fields below, which are set by the receiving goroutine
+	pending []*refinement // Received refinements not yet applied
+	done    bool          // Whether the whole stream was received
+	err     error         // Error receiving the stream
+}
+
+// refinement is a received level of detail of a mesh.
+type refinement struct {
+	mesh   int   // Index of the mesh
+	level  int   // Level of detail
+	data   Mesh  // Vertices, indices and groups of the level
+	bounds []int // Range boundaries of the level
+	size   int   // Size of the data in bytes
+}
+
+// NewStream reads the coarsest levels of detail of the progressive stream from the specified
+// reader, creates its scene and starts receiving the finer levels in the background.
+// The reader is read until its end, or until an error, which is then returned by Err.
+func NewStream(r io.Reader) (*Stream, error) {
+
+	// Reads and decodes the file with the coarsest levels
+	var header [ProgressiveHeaderSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != ProgressiveMagic {
+		return nil, fmt.Errorf("not a progressive G3B stream")
+	}
+	version := binary.LittleEndian.Uint32(header[4:])
+	if version != ProgressiveVersion {
+		return nil, fmt.Errorf("unsupported progressive G3B version:%d", version)
+	}
+	data, err := readBlock(r, binary.LittleEndian.Uint32(header[8:]))
+	if err != nil {
+		return nil, err
+	}
+	f, _, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reads the table of levels
+	count, err := readU32(r)
+	if err != nil {
+		return nil, err
+	}
+	if int(count) != len(f.Meshes) {
+		return nil, fmt.Errorf("invalid number of meshes in the table of levels")
+	}
+	s := new(Stream)
+	s.Dispatcher.Initialize()
+	s.limit = StreamUploadLimit
+	s.levels = make([]int, count)
+	s.bounds = make([][]int, count)
+	s.boundCounts = make([]int, count)
+	s.priorities = make([]int, count)
+	for i := range s.levels {
+		level, err := readU32(r)
+		if err != nil {
+			return nil, err
+		}
+		s.levels[i] = int(level)
+		s.total += int(level)
+		s.bounds[i], err = readBounds(r)
+		if err != nil {
+			return nil, err
+		}
+		s.boundCounts[i] = len(s.bounds[i])
+	}
+
+	// Creates the scene
+	s.builder = NewBuilder(f)
+	s.root = s.builder.scene()
+	s.meshes = make(map[*geometry.Geometry]int)
+	s.users = make([][]int, count)
+	for i := range f.Nodes {
+		if mesh := f.Nodes[i].Mesh; mesh >= 0 {
+			s.users[mesh] = append(s.users[mesh], i)
+			s.meshes[s.builder.geoms[mesh]] = mesh
+		}
+	}
+	go s.receive(r)
+	return s, nil
+}
+
+// Scene returns the node containing the root nodes of the scene of the stream.
+func (s *Stream) Scene() *core.Node {
+
+	return s.root
+}
+
+// SetPriority sets the priority of the mesh of the specified node, which is zero by default.
+// The meshes with higher priorities, such as the closest to the camera, are refined first.
+// All the nodes sharing the mesh share its priority.
+func (s *Stream) SetPriority(node core.INode, priority int) {
+
+	if mesh, ok := s.meshIndex(node); ok {
+		s.priorities[mesh] = priority
+	}
+}
+
+// Priority returns the priority of the mesh of the specified node.
+func (s *Stream) Priority(node core.INode) int {
+
+	if mesh, ok := s.meshIndex(node); ok {
+		return s.priorities[mesh]
+	}
+	return 0
+}
+
+// Level returns the current level of detail of the mesh of the specified node, where 0 is the
+// original mesh, or -1 if the node is not a mesh of the stream.
+func (s *Stream) Level(node core.INode) int {
+
+	if mesh, ok := s.meshIndex(node); ok {
+		return s.levels[mesh]
+	}
+	return -1
+}
+
+// SetUploadLimit sets the maximum number of bytes of refinements applied per update.
+// At least one refinement is applied per update, whatever its size.
+func (s *Stream) SetUploadLimit(limit int) {
+
+	s.limit = limit
+}
+
+// UploadLimit returns the maximum number of bytes of refinements applied per update.
+func (s *Stream) UploadLimit() int {
+
+	return s.limit
+}
+
+// Progress returns the number of refinements applied and the total number of refinements.
+func (s *Stream) Progress() (applied, total int) {
+
+	return s.applied, s.total
+}
+
+// Done returns whether the whole stream was received and applied, or receiving it failed.
+func (s *Stream) Done() bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err != nil || (s.done && len(s.pending) == 0)
+}
+
+// Err returns the error which stopped receiving the stream, if any.
+func (s *Stream) Err() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Update applies the received refinements, those of the meshes with the highest priorities
+// and coarsest levels first, up to the upload limit, and dispatches OnRefine for each of them.
+// It must be called from the goroutine using the scene, such as once per frame before rendering.
+func (s *Stream) Update() {
+
+	// Takes the refinements to apply, dropping those superseded by finer levels
+	s.mu.Lock()
+	pending := s.pending[:0]
+	for _, ref := range s.pending {
+		if ref.level < s.levels[ref.mesh] {
+			pending = append(pending, ref)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		r1, r2 := pending[i], pending[j]
+		if p1, p2 := s.priorities[r1.mesh], s.priorities[r2.mesh]; p1 != p2 {
+			return p1 > p2
+		}
+		return r1.level > r2.level
+	})
+	var apply []*refinement
+	size := 0
+	for len(pending) > 0 && (len(apply) == 0 || size+pending[0].size <= s.limit) {
+		size += pending[0].size
+		apply = append(apply, pending[0])
+		pending = pending[1:]
+	}
+	s.pending = append([]*refinement(nil), pending...)
+	s.mu.Unlock()
+
+	for _, ref := range apply {
+		if ref.level >= s.levels[ref.mesh] {
+			continue
+		}
+		s.apply(ref)
+		s.event = RefineEvent{Mesh: ref.mesh, Level: ref.level}
+		s.Dispatch(OnRefine, &s.event)
+	}
+}
+
+// apply replaces the data of the geometry of a mesh by the specified refinement
+// and remaps the material ranges of the nodes using it.
+func (s *Stream) apply(ref *refinement) {
+
+	s.applied += s.levels[ref.mesh] - ref.level
+	from := s.bounds[ref.mesh]
+	s.levels[ref.mesh] = ref.level
+	s.bounds[ref.mesh] = ref.bounds
+	s.builder.file.Meshes[ref.mesh].Vertices = ref.data.Vertices
+	s.builder.file.Meshes[ref.mesh].Indices = ref.data.Indices
+	s.builder.file.Meshes[ref.mesh].Groups = ref.data.Groups
+	geom := s.builder.geoms[ref.mesh]
+	if geom == nil {
+		return
+	}
+	if vbos := geom.VBOs(); len(vbos) > 0 {
+		vbos[0].SetBytes(ref.data.Vertices)
+	}
+	geom.SetIndices(ref.data.Indices)
+	geom.ClearGroups()
+	geom.AddGroupList(ref.data.Groups)
+	for _, n := range s.users[ref.mesh] {
+		mesh := s.builder.nodes[n].(*graphic.Mesh)
+		mats := append([]graphic.GraphicMaterial(nil), mesh.Materials()...)
+		mesh.ClearMaterials()
+		for i := range mats {
+			start, count := mats[i].Range()
+			if count > 0 {
+				end := remapBound(start+count, from, ref.bounds)
+				start = remapBound(start, from, ref.bounds)
+				count = end - start
+			}
+			mesh.AddMaterial(mats[i].IMaterial(), start, count)
+		}
+	}
+}
+
+// meshIndex returns the index of the mesh of the specified node and whether it is a mesh of the stream.
+func (s *Stream) meshIndex(node core.INode) (int, bool) {
+
+	igr, ok := node.(graphic.IGraphic)
+	if !ok {
+		return 0, false
+	}
+	mesh, ok := s.meshes[igr.GetGeometry()]
+	return mesh, ok
+}
+
+// receive reads the refinements from the specified reader until its end or an error.
+func (s *Stream) receive(r io.Reader) {
+
+	err := s.receiveAll(r)
+	s.mu.Lock()
+	if err != nil {
+		s.err = err
+	}
+	s.done = true
+	s.mu.Unlock()
+}
+
+// receiveAll reads and queues the refinements from the specified reader until its end.
+func (s *Stream) receiveAll(r io.Reader) error {
+
+	var header [RefinementHeaderSize]byte
+	for {
+		_, err := io.ReadFull(r, header[:])
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ref := new(refinement)
+		ref.mesh = int(binary.LittleEndian.Uint32(header[0:]))
+		ref.level = int(binary.LittleEndian.Uint32(header[4:]))
+		flags := binary.LittleEndian.Uint32(header[8:])
+		if ref.mesh >= len(s.levels) {
+			return fmt.Errorf("invalid mesh %d of refinement", ref.mesh)
+		}
+		body, err := readBlock(r, binary.LittleEndian.Uint32(header[12:]))
+		if err != nil {
+			return err
+		}
+		if flags&FlagCompressed != 0 {
+			zr, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return err
+			}
+			body, err = ioutil.ReadAll(io.LimitReader(zr, StreamMaxBlockSize+1))
+			if err != nil {
+				return err
+			}
+			if len(body) > StreamMaxBlockSize {
+				return fmt.Errorf("refinement of mesh %d too large", ref.mesh)
+			}
+		}
+
+		// Decodes and validates the refinement
+		rd := &reader{data: body}
+		rd.meshData(&ref.data)
+		n := rd.count(4)
+		ref.bounds = make([]int, n)
+		for i := range ref.bounds {
+			ref.bounds[i] = int(rd.u32())
+		}
+		if rd.err != nil {
+			return rd.err
+		}
+		ref.data.Attribs = s.builder.file.Meshes[ref.mesh].Attribs
+		if err := ref.validate(); err != nil {
+			return err
+		}
+		if len(ref.bounds) != s.boundCounts[ref.mesh] {
+			return fmt.Errorf("invalid range boundaries of mesh %d", ref.mesh)
+		}
+		ref.size = len(body)
+		s.mu.Lock()
+		s.pending = append(s.pending, ref)
+		s.mu.Unlock()
+	}
+}
+
+// validate checks that the indices of the refinement reference its vertices.
+func (ref *refinement) validate() error {
+
+	stride := ref.data.vbo().StrideSize()
+	if stride == 0 {
+		return nil
+	}
+	count := len(ref.data.Vertices) / stride
+	for _, v := range ref.data.Indices {
+		if int(v) >= count {
+			return fmt.Errorf("invalid index of refinement of mesh %d", ref.mesh)
+		}
+	}
+	return nil
+}
+
+// readBlock reads a block of the specified size, which must not exceed StreamMaxBlockSize.
+// The block grows as the data is received instead of being allocated from the size.
+func readBlock(r io.Reader, size uint32) ([]byte, error) {
+
+	if size > StreamMaxBlockSize {
+		return nil, fmt.Errorf("invalid progressive G3B block size:%d", size)
+	}
+	var buf bytes.Buffer
+	_, err := io.CopyN(&buf, r, int64(size))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), err
+}
+
+// readU32 reads an unsigned 32 bit integer.
+func readU32(r io.Reader) (uint32, error) {
+
+	var buf [4]byte
+	_, err := io.ReadFull(r, buf[:])
+	return binary.LittleEndian.Uint32(buf[:]), err
+}
+
+// readBounds reads a list of range boundaries.
+func readBounds(r io.Reader) ([]int, error) {
+
+	n, err := readU32(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > 1<<20 {
+		return nil, fmt.Errorf("invalid number of range boundaries")
+	}
+	bounds := make([]int, n)
+	for i := range bounds {
+		v, err := readU32(r)
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = int(v)
+	}
+	return bounds, nil
+}
@@ -0,0 +1,597 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gltf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// meshoptBufferView describes the compressed data of a buffer view using the
+// EXT_meshopt_compression extension.
+type meshoptBufferView struct {
+	Buffer     int    `json:"buffer"`
+	ByteOffset int    `json:"byteOffset"`
+	ByteLength int    `json:"byteLength"`
+	ByteStride int    `json:"byteStride"`
+	Count      int    `json:"count"`
+	Mode       string `json:"mode"`
+	Filter     string `json:"filter"`
+}
+
+// meshoptExtension returns the meshopt compression extension of the specified buffer view or nil.
+func meshoptExtension(bv *BufferView) interface{} {
+
+	if ext, ok := bv.Extensions[ExtMeshoptCompression]; ok {
+		return ext
+	}
+	return bv.Extensions[KhrMeshoptCompression]
+}
+
+// loadBufferViewMeshopt receives a buffer view and the interface value describing its
+// EXT_meshopt_compression extension and returns the decoded data of the buffer view.
+// The specification of this extension is at:
+// https://github.com/KhronosGroup/glTF/tree/master/extensions/2.0/Vendor/EXT_meshopt_compression
+func (g *GLTF) loadBufferViewMeshopt(bv *BufferView, ext interface{}) ([]byte, error) {
+
+	var desc meshoptBufferView
+	data, err := json.Marshal(ext)
+	if err == nil {
+		err = json.Unmarshal(data, &desc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s extension: %v", ExtMeshoptCompression, err)
+	}
+	buf, err := g.loadBuffer(desc.Buffer)
+	if err != nil {
+		return nil, err
+	}
+	if desc.ByteOffset < 0 || desc.ByteLength < 0 || desc.ByteOffset > len(buf)-desc.ByteLength {
+		return nil, fmt.Errorf("%s data out of buffer bounds", ExtMeshoptCompression)
+	}
+	src := buf[desc.ByteOffset : desc.ByteOffset+desc.ByteLength]
+	if desc.Count < 0 || desc.ByteStride <= 0 || desc.ByteStride > 256 {
+		return nil, fmt.Errorf("invalid %s count or byteStride", ExtMeshoptCompression)
+	}
+	// The decoded data must fit the buffer view, checked without overflowing
+	if desc.Count > bv.ByteLength/desc.ByteStride {
+		return nil, fmt.Errorf("%s decoded data larger than the buffer view", ExtMeshoptCompression)
+	}
+	dst := make([]byte, desc.Count*desc.ByteStride)
+
+	switch desc.Mode {
+	case "ATTRIBUTES":
+		err = meshoptDecodeVertices(dst, desc.Count, desc.ByteStride, src)
+	case "TRIANGLES":
+		err = meshoptDecodeIndices(dst, desc.Count, desc.ByteStride, src)
+	case "INDICES":
+		err = meshoptDecodeIndexSequence(dst, desc.Count, desc.ByteStride, src)
+	default:
+		err = fmt.Errorf("unsupported mode:%s", desc.Mode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ExtMeshoptCompression, err)
+	}
+
+	switch desc.Filter {
+	case "", "NONE":
+	case "OCTAHEDRAL":
+		err = meshoptFilterOct(dst, desc.Count, desc.ByteStride)
+	case "QUATERNION":
+		err = meshoptFilterQuat(dst, desc.Count, desc.ByteStride)
+	case "EXPONENTIAL":
+		err = meshoptFilterExp(dst, desc.Count, desc.ByteStride)
+	default:
+		err = fmt.Errorf("unsupported filter:%s", desc.Filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ExtMeshoptCompression, err)
+	}
+	return dst, nil
+}
+
+// Constants of the meshopt codecs.
+const (
+	meshoptVertexHeader    = 0xa0
+	meshoptIndexHeader     = 0xe0
+	meshoptSequenceHeader  = 0xd0
+	meshoptByteGroupSize   = 16
+	meshoptVertexBlockSize = 8192 // Maximum size in bytes of a block of vertices
+	meshoptVertexBlockMax  = 256  // Maximum number of vertices of a block
+	meshoptTailMaxSize     = 32
+)
+
+var errMeshoptData = fmt.Errorf("invalid or truncated compressed data")
+
+// meshoptDecodeVertices decodes the specified number of vertices of the specified size,
+// compressed with the meshopt vertex codec, into dst.
+// Each byte of the vertices is delta encoded from the previous vertex and the deltas of
+// each byte of a block of vertices are stored in groups of 16 packed to 0, 2, 4 or 8 bits.
+func meshoptDecodeVertices(dst []byte, count, size int, src []byte) error {
+
+	if len(src) < 1+size || src[0]&0xf0 != meshoptVertexHeader {
+		return errMeshoptData
+	}
+	if version := src[0] & 0x0f; version > 0 {
+		return fmt.Errorf("unsupported vertex codec version:%d", version)
+	}
+	tailSize := size
+	if tailSize < meshoptTailMaxSize {
+		tailSize = meshoptTailMaxSize
+	}
+	if len(src) < 1+tailSize {
+		return errMeshoptData
+	}
+
+	// The tail stores the vertex the first block is delta encoded from
+	last := make([]byte, size)
+	copy(last, src[len(src)-size:])
+	data := src[1 : len(src)-tailSize]
+
+	blockSize := (meshoptVertexBlockSize / size) &^ (meshoptByteGroupSize - 1)
+	if blockSize > meshoptVertexBlockMax {
+		blockSize = meshoptVertexBlockMax
+	}
+	deltas := make([]byte, meshoptVertexBlockMax)
+	for offset := 0; offset < count; offset += blockSize {
+		n := count - offset
+		if n > blockSize {
+			n = blockSize
+		}
+		aligned := (n + meshoptByteGroupSize - 1) &^ (meshoptByteGroupSize - 1)
+		block := dst[offset*size : (offset+n)*size]
+		for k := 0; k < size; k++ {
+			var ok bool
+			data, ok = meshoptDecodeBytes(data, deltas[:aligned])
+			if !ok {
+				return errMeshoptData
+			}
+			p := last[k]
+			for i := 0; i < n; i++ {
+				p += meshoptUnzigzag8(deltas[i])
+				block[i*size+k] = p
+			}
+		}
+		copy(last, block[(n-1)*size:])
+	}
+	if len(data) != 0 {
+		return errMeshoptData
+	}
+	return nil
+}
+
+// meshoptDecodeBytes decodes the groups of 16 bytes filling dst and returns the remaining data.
+// A header with 2 bits for each group selects whether its bytes are zero or stored in 2, 4 or 8 bits.
+// Packed values with all bits set are escapes for bytes stored after the packed values of the group.
+func meshoptDecodeBytes(data []byte, dst []byte) ([]byte, bool) {
+
+	groups := len(dst) / meshoptByteGroupSize
+	headerSize := (groups + 3) / 4
+	if len(data) < headerSize {
+		return nil, false
+	}
+	header := data[:headerSize]
+	data = data[headerSize:]
+	for g := 0; g < groups; g++ {
+		out := dst[g*meshoptByteGroupSize : (g+1)*meshoptByteGroupSize]
+		switch (header[g/4] >> uint((g%4)*2)) & 3 {
+		case 0:
+			for i := range out {
+				out[i] = 0
+			}
+		case 1:
+			var ok bool
+			data, ok = meshoptDecodeBits(data, out, 2)
+			if !ok {
+				return nil, false
+			}
+		case 2:
+			var ok bool
+			data, ok = meshoptDecodeBits(data, out, 4)
+			if !ok {
+				return nil, false
+			}
+		case 3:
+			if len(data) < meshoptByteGroupSize {
+				return nil, false
+			}
+			copy(out, data)
+			data = data[meshoptByteGroupSize:]
+		}
+	}
+	return data, true
+}
+
+// meshoptDecodeBits decodes a group of 16 bytes packed with the specified number of bits,
+// most significant bits first, and returns the remaining data.
+func meshoptDecodeBits(data []byte, out []byte, bits uint) ([]byte, bool) {
+
+	packedSize := int(meshoptByteGroupSize * bits / 8)
+	if len(data) < packedSize {
+		return nil, false
+	}
+	packed := data[:packedSize]
+	extra := data[packedSize:]
+	escape := byte(1<<bits - 1)
+	perByte := int(8 / bits)
+	for i := range out {
+		b := packed[i/perByte]
+		v := (b >> (8 - bits - uint(i%perByte)*bits)) & escape
+		if v == escape {
+			if len(extra) == 0 {
+				return nil, false
+			}
+			v = extra[0]
+			extra = extra[1:]
+		}
+		out[i] = v
+	}
+	return extra, true
+}
+
+// meshoptUnzigzag8 decodes a zigzag encoded byte delta.
+func meshoptUnzigzag8(v byte) byte {
+
+	return -(v & 1) ^ (v >> 1)
+}
+
+// meshoptDecodeIndices decodes the specified number of triangle indices of the specified size
+// (2 or 4 bytes), compressed with the meshopt index codec, into dst. Each triangle is encoded
+// by a code referencing recently seen edges and vertices, or new and explicitly stored vertices.
+func meshoptDecodeIndices(dst []byte, count, size int, src []byte) error {
+
+	if count%3 != 0 || (size != 2 && size != 4) {
+		return fmt.Errorf("invalid index count or size")
+	}
+	if len(src) < 1+count/3+16 || src[0]&0xf0 != meshoptIndexHeader {
+		return errMeshoptData
+	}
+	version := src[0] & 0x0f
+	if version > 1 {
+		return fmt.Errorf("unsupported index codec version:%d", version)
+	}
+	fecmax := 15
+	if version >= 1 {
+		fecmax = 13
+	}
+
+	var edgeFifo [16][2]uint32
+	var vertexFifo [16]uint32
+	for i := range vertexFifo {
+		vertexFifo[i] = math.MaxUint32
+		edgeFifo[i] = [2]uint32{math.MaxUint32, math.MaxUint32}
+	}
+	edgeOffset, vertexOffset := 0, 0
+	pushVertex := func(v uint32, cond bool) {
+		vertexFifo[vertexOffset] = v
+		if cond {
+			vertexOffset = (vertexOffset + 1) & 15
+		}
+	}
+	pushEdge := func(a, b uint32) {
+		edgeFifo[edgeOffset] = [2]uint32{a, b}
+		edgeOffset = (edgeOffset + 1) & 15
+	}
+	write := func(i int, a, b, c uint32) {
+		if size == 2 {
+			binary.LittleEndian.PutUint16(dst[2*i:], uint16(a))
+			binary.LittleEndian.PutUint16(dst[2*i+2:], uint16(b))
+			binary.LittleEndian.PutUint16(dst[2*i+4:], uint16(c))
+		} else {
+			binary.LittleEndian.PutUint32(dst[4*i:], a)
+			binary.LittleEndian.PutUint32(dst[4*i+4:], b)
+			binary.LittleEndian.PutUint32(dst[4*i+8:], c)
+		}
+	}
+
+	var next, last uint32
+	codes := src[1 : 1+count/3]
+	safeEnd := len(src) - 16
+	codeaux := src[safeEnd:]
+	pos := 1 + count/3
+	decodeIndex := func() (uint32, bool) {
+		v, ok := meshoptDecodeVByte(src[:safeEnd], &pos)
+		d := (v >> 1) ^ -(v & 1)
+		last += d
+		return last, ok
+	}
+	for i := 0; i < count; i += 3 {
+		if pos > safeEnd {
+			return errMeshoptData
+		}
+		code := codes[i/3]
+		if code < 0xf0 {
+			// Triangle with an edge from the fifo and a vertex from the fifo, new or explicit
+			edge := edgeFifo[(edgeOffset-1-int(code>>4))&15]
+			a, b := edge[0], edge[1]
+			fec := int(code & 15)
+			var c uint32
+			if fec < fecmax {
+				if fec == 0 {
+					c = next
+					next++
+				} else {
+					c = vertexFifo[(vertexOffset-1-fec)&15]
+				}
+				pushVertex(c, fec == 0)
+			} else {
+				if fec != 15 {
+					// 13 and 14 are the previous explicit index minus or plus 1
+					last += uint32(fec - (fec ^ 3))
+					c = last
+				} else {
+					var ok bool
+					if c, ok = decodeIndex(); !ok {
+						return errMeshoptData
+					}
+				}
+				pushVertex(c, true)
+			}
+			write(i, a, b, c)
+			pushEdge(c, b)
+			pushEdge(a, c)
+			continue
+		}
+
+		// Triangle with a new first vertex and two vertices from the fifo, new or explicit
+		var feb, fec int
+		var a, b, c uint32
+		if code < 0xfe {
+			aux := codeaux[code&15]
+			feb = int(aux >> 4)
+			fec = int(aux & 15)
+			a = next
+			next++
+			if feb == 0 {
+				b = next
+				next++
+			} else {
+				b = vertexFifo[(vertexOffset-feb)&15]
+			}
+			if fec == 0 {
+				c = next
+				next++
+			} else {
+				c = vertexFifo[(vertexOffset-fec)&15]
+			}
+		} else {
+			if pos >= safeEnd {
+				return errMeshoptData
+			}
+			aux := src[pos]
+			pos++
+			fea := 0
+			if code != 0xfe {
+				fea = 15
+			}
+			feb = int(aux >> 4)
+			fec = int(aux & 15)
+			if aux == 0 {
+				next = 0
+			}
+			if fea == 0 {
+				a = next
+				next++
+			}
+			if feb == 0 {
+				b = next
+				next++
+			} else {
+				b = vertexFifo[(vertexOffset-feb)&15]
+			}
+			if fec == 0 {
+				c = next
+				next++
+			} else {
+				c = vertexFifo[(vertexOffset-fec)&15]
+			}
+			var ok bool
+			if fea == 15 {
+				if a, ok = decodeIndex(); !ok {
+					return errMeshoptData
+				}
+			}
+			if feb == 15 {
+				if b, ok = decodeIndex(); !ok {
+					return errMeshoptData
+				}
+			}
+			if fec == 15 {
+				if c, ok = decodeIndex(); !ok {
+					return errMeshoptData
+				}
+			}
+		}
+		write(i, a, b, c)
+		pushVertex(a, true)
+		pushVertex(b, feb == 0 || feb == 15)
+		pushVertex(c, fec == 0 || fec == 15)
+		pushEdge(b, a)
+		pushEdge(c, b)
+		pushEdge(a, c)
+	}
+	if pos != safeEnd {
+		return errMeshoptData
+	}
+	return nil
+}
+
+// meshoptDecodeIndexSequence decodes the specified number of indices of the specified size
+// (2 or 4 bytes), compressed with the meshopt index sequence codec, into dst.
+// Each index is a variable length delta from one of the two previous baselines.
+func meshoptDecodeIndexSequence(dst []byte, count, size int, src []byte) error {
+
+	if size != 2 && size != 4 {
+		return fmt.Errorf("invalid index size")
+	}
+	if len(src) < 1+count+4 || src[0]&0xf0 != meshoptSequenceHeader {
+		return errMeshoptData
+	}
+	if version := src[0] & 0x0f; version > 1 {
+		return fmt.Errorf("unsupported index sequence codec version:%d", version)
+	}
+	safeEnd := len(src) - 4
+	pos := 1
+	var last [2]uint32
+	for i := 0; i < count; i++ {
+		if pos >= safeEnd {
+			return errMeshoptData
+		}
+		v, ok := meshoptDecodeVByte(src[:safeEnd], &pos)
+		if !ok {
+			return errMeshoptData
+		}
+		current := v & 1
+		v >>= 1
+		index := last[current] + ((v >> 1) ^ -(v & 1))
+		last[current] = index
+		if size == 2 {
+			binary.LittleEndian.PutUint16(dst[2*i:], uint16(index))
+		} else {
+			binary.LittleEndian.PutUint32(dst[4*i:], index)
+		}
+	}
+	if pos != safeEnd {
+		return errMeshoptData
+	}
+	return nil
+}
+
+// meshoptDecodeVByte decodes a variable length integer with 7 bits per byte
+// at the specified position of the data and advances the position.
+func meshoptDecodeVByte(data []byte, pos *int) (uint32, bool) {
+
+	var result uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		if *pos >= len(data) {
+			return 0, false
+		}
+		b := data[*pos]
+		*pos++
+		result |= uint32(b&127) << shift
+		if b < 128 {
+			break
+		}
+	}
+	return result, true
+}
+
+// meshoptFilterOct decodes normals or tangents stored as octahedral encoded 8 or 16 bit
+// signed integers into normalized integers of the same size. The fourth component is kept.
+func meshoptFilterOct(data []byte, count, stride int) error {
+
+	switch stride {
+	case 4:
+		for i := 0; i < count; i++ {
+			v := data[4*i : 4*i+4]
+			x, y, z := meshoptOct(float32(int8(v[0])), float32(int8(v[1])), float32(int8(v[2])), 127)
+			v[0], v[1], v[2] = byte(int8(x)), byte(int8(y)), byte(int8(z))
+		}
+	case 8:
+		for i := 0; i < count; i++ {
+			v := data[8*i : 8*i+8]
+			x, y, z := meshoptOct(
+				float32(int16(binary.LittleEndian.Uint16(v[0:]))),
+				float32(int16(binary.LittleEndian.Uint16(v[2:]))),
+				float32(int16(binary.LittleEndian.Uint16(v[4:]))), 32767)
+			binary.LittleEndian.PutUint16(v[0:], uint16(int16(x)))
+			binary.LittleEndian.PutUint16(v[2:], uint16(int16(y)))
+			binary.LittleEndian.PutUint16(v[4:], uint16(int16(z)))
+		}
+	default:
+		return fmt.Errorf("invalid byteStride for OCTAHEDRAL filter:%d", stride)
+	}
+	return nil
+}
+
+// meshoptOct returns the unit vector with the specified octahedral coordinates, where z
+// encodes 1, scaled to the specified maximum and rounded.
+func meshoptOct(x, y, one, max float32) (int32, int32, int32) {
+
+	z := one - abs32(x) - abs32(y)
+	t := z
+	if t > 0 {
+		t = 0
+	}
+	if x >= 0 {
+		x += t
+	} else {
+		x -= t
+	}
+	if y >= 0 {
+		y += t
+	} else {
+		y -= t
+	}
+	s := max / float32(math.Sqrt(float64(x*x+y*y+z*z)))
+	return round32(x * s), round32(y * s), round32(z * s)
+}
+
+// meshoptFilterQuat decodes rotations stored as the three smallest components of
+// quaternions in 16 bit signed integers, with the index of the largest component and
+// the scale in the fourth one, into normalized 16 bit integers.
+func meshoptFilterQuat(data []byte, count, stride int) error {
+
+	if stride != 8 {
+		return fmt.Errorf("invalid byteStride for QUATERNION filter:%d", stride)
+	}
+	const scale = 0.70710678 // 1/sqrt(2)
+	for i := 0; i < count; i++ {
+		v := data[8*i : 8*i+8]
+		c3 := int16(binary.LittleEndian.Uint16(v[6:]))
+		ss := scale / float32(int32(c3)|3)
+		x := float32(int16(binary.LittleEndian.Uint16(v[0:]))) * ss
+		y := float32(int16(binary.LittleEndian.Uint16(v[2:]))) * ss
+		z := float32(int16(binary.LittleEndian.Uint16(v[4:]))) * ss
+		ww := 1 - x*x - y*y - z*z
+		if ww < 0 {
+			ww = 0
+		}
+		w := float32(math.Sqrt(float64(ww)))
+		qc := int(c3 & 3)
+		binary.LittleEndian.PutUint16(v[2*((qc+1)&3):], uint16(int16(round32(x*32767))))
+		binary.LittleEndian.PutUint16(v[2*((qc+2)&3):], uint16(int16(round32(y*32767))))
+		binary.LittleEndian.PutUint16(v[2*((qc+3)&3):], uint16(int16(round32(z*32767))))
+		binary.LittleEndian.PutUint16(v[2*qc:], uint16(int16(round32(w*32767))))
+	}
+	return nil
+}
+
+// meshoptFilterExp decodes 32 bit floats stored with a shared exponent in the high 8 bits
+// and a 24 bit signed mantissa.
+func meshoptFilterExp(data []byte, count, stride int) error {
+
+	if stride%4 != 0 {
+		return fmt.Errorf("invalid byteStride for EXPONENTIAL filter:%d", stride)
+	}
+	for i := 0; i < count*stride/4; i++ {
+		v := binary.LittleEndian.Uint32(data[4*i:])
+		m := int32(v<<8) >> 8
+		e := int32(v) >> 24
+		f := float32(m) * math.Float32frombits(uint32(e+127)<<23)
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(f))
+	}
+	return nil
+}
+
+// abs32 returns the absolute value of the specified float.
+func abs32(v float32) float32 {
+
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// round32 returns the specified float rounded half away from zero.
+func round32(v float32) int32 {
+
+	if v >= 0 {
+		return int32(v + 0.5)
+	}
+	return int32(v - 0.5)
+}
@@ -19,6 +19,8 @@ import (
 // glTF Extensions.
 const (
 	KhrDracoMeshCompression           = "KHR_draco_mesh_compression"
+	ExtMeshoptCompression             = "EXT_meshopt_compression"
+	KhrMeshoptCompression             = "KHR_meshopt_compression"
 	KhrMaterialsUnlit                 = "KHR_materials_unlit"
 	KhrMaterialsCommon                = "KHR_materials_common" // TODO this is officially part of glTF 1.0 (remove?)
 	KhrMaterialsPbrSpecularGlossiness = "KHR_materials_pbrSpecularGlossiness"
@@ -46,6 +48,10 @@ type GLTF struct {
 	Extensions         map[string]interface{} // Dictionary object with extension-specific objects. Not required.
 	Extras             interface{}            // Application-specific data. Not required.
 
+	Cache       *material.Cache                  `json:"-"` // Shares textures and materials with other assets if not nil.
+	DecodeDraco DracoDecodeFunc                  `json:"-"` // Hook decoding Draco compressed primitives, which fail to load if nil.
+	ReadFile    func(uri string) ([]byte, error) `json:"-"` // Reads the external resources, such as from a remote server, if not nil.
+
 	path string // File path for resources.
 	data []byte // Binary file Chunk 1 data.
 }
@@ -175,7 +181,7 @@ type Material struct {
 	EmissiveTexture      *TextureInfo           // The emissive map texture. Not required.
 	EmissiveFactor       *[3]float32            // The emissive color of the material. Not required. Default is [0,0,0]
 	AlphaMode            string                 // The alpha rendering mode of the material. Not required. Default is OPAQUE.
-	AlphaCutoff          float32                // The alpha cutoff value of the material. Not required. Default is 0.5.
+	AlphaCutoff          *float32               // The alpha cutoff value of the material. Not required. Default is 0.5.
 	DoubleSided          bool                   // Specifies whether the material is double sided. Not required. Default is false.
 	Extensions           map[string]interface{} // Dictionary object with extension-specific objects. Not required.
 	Extras               interface{}            // Application-specific data. Not required.
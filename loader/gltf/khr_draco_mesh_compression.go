@@ -0,0 +1,87 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gltf
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// DracoMesh is the geometry of a primitive decoded from Draco compressed data.
+type DracoMesh struct {
+	Indices    math32.ArrayU32            // Triangle indices
+	Attributes map[string]math32.ArrayF32 // Vertex attributes keyed by glTF attribute name, with the components of their accessors
+}
+
+// DracoDecodeFunc is the type of the hook decoding the Draco compressed data of a primitive using
+// the KHR_draco_mesh_compression extension (see GLTF.DecodeDraco). The attributes map the glTF
+// attribute names of the primitive to the unique ids of the Draco attributes storing them.
+// The engine does not include a Draco decoder, whose reference implementation is a large C++
+// library: the loader only extracts the compressed data and loads the decoded geometry, so
+// applications loading Draco compressed assets must set a hook, for example binding the library
+// with cgo. Without one, Draco compressed primitives fail to load.
+type DracoDecodeFunc func(data []byte, attributes map[string]int) (*DracoMesh, error)
+
+// dracoPrimitive describes the compressed data of a primitive using the
+// KHR_draco_mesh_compression extension.
+type dracoPrimitive struct {
+	BufferView int            `json:"bufferView"`
+	Attributes map[string]int `json:"attributes"`
+}
+
+// loadDracoPrimitive receives an interface value describing a KHR_draco_mesh_compression extension
+// of a primitive with the specified attribute accessors, decodes it with the DecodeDraco hook of
+// the asset and loads the decoded vertex attributes and indices into the specified geometry.
+// The specification of this extension is at:
+// https://github.com/KhronosGroup/glTF/tree/master/extensions/2.0/Khronos/KHR_draco_mesh_compression
+func (g *GLTF) loadDracoPrimitive(geom *geometry.Geometry, ext interface{}, attributes map[string]int) error {
+
+	if g.DecodeDraco == nil {
+		return fmt.Errorf("%s primitive requires a DecodeDraco hook", KhrDracoMeshCompression)
+	}
+	var desc dracoPrimitive
+	data, err := json.Marshal(ext)
+	if err == nil {
+		err = json.Unmarshal(data, &desc)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s extension: %v", KhrDracoMeshCompression, err)
+	}
+	buf, err := g.loadBufferView(desc.BufferView)
+	if err != nil {
+		return err
+	}
+	mesh, err := g.DecodeDraco(buf, desc.Attributes)
+	if err != nil {
+		return fmt.Errorf("%s: %v", KhrDracoMeshCompression, err)
+	}
+
+	// Uses the accessors of the primitive to validate the decoded attributes
+	for name, aci := range attributes {
+		data, ok := mesh.Attributes[name]
+		if !ok {
+			return fmt.Errorf("%s: attribute %s not decoded", KhrDracoMeshCompression, name)
+		}
+		if aci < 0 || aci >= len(g.Accessors) {
+			return fmt.Errorf("%s: invalid accessor index %d of attribute %s", KhrDracoMeshCompression, aci, name)
+		}
+		accessor := g.Accessors[aci]
+		if len(data) != accessor.Count*TypeSizes[accessor.Type] {
+			return fmt.Errorf("%s: attribute %s has %d values, expected %d", KhrDracoMeshCompression,
+				name, len(data), accessor.Count*TypeSizes[accessor.Type])
+		}
+		vbo := gls.NewVBO(data)
+		g.addAttributeToVBO(vbo, name, 0)
+		geom.AddVBO(vbo)
+	}
+	if len(mesh.Indices) > 0 {
+		geom.SetIndices(mesh.Indices)
+	}
+	return nil
+}
@@ -151,6 +151,8 @@ func readChunk(r io.Reader, chunkType uint32) ([]byte, error) {
 
 // LoadScene creates a parent Node which contains all nodes contained by
 // the specified scene index from the GLTF Scenes array.
+// The parent node is converted from the Y-up meter coordinate system of glTF
+// to the engine coordinate system.
 func (g *GLTF) LoadScene(sceneIdx int) (core.INode, error) {
 
 	// Check if provided scene index is valid
@@ -171,6 +173,7 @@ func (g *GLTF) LoadScene(sceneIdx int) (core.INode, error) {
 		}
 		scene.Add(child)
 	}
+	core.ConvertNode(scene, core.YUpMeters)
 	return scene, nil
 }
 
@@ -456,8 +459,10 @@ func (g *GLTF) LoadMesh(meshIdx int) (core.INode, error) {
 		p := meshData.Primitives[i]
 
 		// Indexed Geometry
+		// Draco compressed primitives have accessors without data
+		draco, isDraco := p.Extensions[KhrDracoMeshCompression]
 		indices := math32.NewArrayU32(0, 0)
-		if p.Indices != nil {
+		if p.Indices != nil && !isDraco {
 			pidx, err := g.loadIndices(*p.Indices)
 			if err != nil {
 				return nil, err
@@ -484,7 +489,15 @@ func (g *GLTF) LoadMesh(meshIdx int) (core.INode, error) {
 		igeom = geometry.NewGeometry()
 		geom := igeom.GetGeometry()
 
-		err = g.loadAttributes(geom, p.Attributes, indices)
+		if isDraco {
+			// Morph targets of compressed primitives would need the indices of the decoded geometry
+			if len(p.Targets) > 0 {
+				return nil, fmt.Errorf("%s primitive with morph targets not supported", KhrDracoMeshCompression)
+			}
+			err = g.loadDracoPrimitive(geom, draco, p.Attributes)
+		} else {
+			err = g.loadAttributes(geom, p.Attributes, indices)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -737,25 +750,48 @@ func (g *GLTF) LoadMaterial(matIdx int) (material.IMaterial, error) {
 // LoadTexture loads the texture specified by its index.
 func (g *GLTF) LoadTexture(texIdx int) (*texture.Texture2D, error) {
 
+	return g.loadTexture(texIdx, "")
+}
+
+// loadTexture loads the texture specified by its index for the specified usage in a material.
+// If the asset has a cache, the texture is shared with the textures loaded for the same usage
+// with the same image data and sampler.
+func (g *GLTF) loadTexture(texIdx int, usage string) (*texture.Texture2D, error) {
+
 	// Check if provided texture index is valid
 	if texIdx < 0 || texIdx >= len(g.Textures) {
 		return nil, fmt.Errorf("invalid texture index")
 	}
 	texData := g.Textures[texIdx]
-	// NOTE: Textures can't be cached because they have their own uniforms
+	// NOTE: Textures can't be cached by index because they have their own uniforms,
+	// so shared textures are also identified by their usage
 	log.Debug("Loading Texture %d", texIdx)
 
-	// Load texture image
-	img, err := g.LoadImage(texData.Source)
-	if err != nil {
-		return nil, err
+	var tex *texture.Texture2D
+	if g.Cache != nil {
+		// Load encoded texture image and share the texture
+		data, err := g.loadImageData(texData.Source)
+		if err != nil {
+			return nil, err
+		}
+		tex, err = g.Cache.LoadTextureData(data, usage+" "+g.samplerKey(texData.Sampler))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Load texture image
+		img, err := g.LoadImage(texData.Source)
+		if err != nil {
+			return nil, err
+		}
+		tex = texture.NewTexture2DFromRGBA(img)
 	}
-	tex := texture.NewTexture2DFromRGBA(img)
 
 	// Get sampler and apply texture parameters
 	if texData.Sampler != nil {
-		err = g.applySampler(*texData.Sampler, tex)
+		err := g.applySampler(*texData.Sampler, tex)
 		if err != nil {
+			tex.Dispose()
 			return nil, err
 		}
 	}
@@ -763,6 +799,25 @@ func (g *GLTF) LoadTexture(texIdx int) (*texture.Texture2D, error) {
 	return tex, nil
 }
 
+// samplerKey returns a description of the parameters of the specified sampler,
+// which identifies the textures sampled in the same way.
+func (g *GLTF) samplerKey(samplerIdx *int) string {
+
+	if samplerIdx == nil || *samplerIdx < 0 || *samplerIdx >= len(g.Samplers) {
+		return ""
+	}
+	sampler := g.Samplers[*samplerIdx]
+	param := func(value *int, def int) int {
+		if value != nil {
+			return *value
+		}
+		return def
+	}
+	return fmt.Sprintf("%d %d %d %d",
+		param(sampler.MagFilter, gls.LINEAR), param(sampler.MinFilter, gls.LINEAR_MIPMAP_LINEAR),
+		param(sampler.WrapS, gls.REPEAT), param(sampler.WrapT, gls.REPEAT))
+}
+
 // applySamplers applies the specified Sampler to the provided texture.
 func (g *GLTF) applySampler(samplerIdx int, tex *texture.Texture2D) error {
 
@@ -820,22 +875,7 @@ func (g *GLTF) LoadImage(imgIdx int) (*image.RGBA, error) {
 	}
 	log.Debug("Loading Image %d", imgIdx)
 
-	var data []byte
-	var err error
-	// If Uri is empty, load image from GLB binary chunk
-	if imgData.Uri == "" {
-		if imgData.BufferView == nil {
-			return nil, fmt.Errorf("image has empty URI and no BufferView")
-		}
-		data, err = g.loadBufferView(*imgData.BufferView)
-	} else if isDataURL(imgData.Uri) {
-		// Checks if image URI is data URL
-		data, err = loadDataURL(imgData.Uri)
-	} else {
-		// Load image data from file
-		data, err = g.loadFileBytes(imgData.Uri)
-	}
-
+	data, err := g.loadImageData(imgIdx)
 	if err != nil {
 		return nil, err
 	}
@@ -860,6 +900,30 @@ func (g *GLTF) LoadImage(imgIdx int) (*image.RGBA, error) {
 	return rgba, nil
 }
 
+// loadImageData loads the encoded data of the image specified by the index of GLTF.Images
+// from the binary chunk file, a data URI or an external file.
+func (g *GLTF) loadImageData(imgIdx int) ([]byte, error) {
+
+	// Check if provided image index is valid
+	if imgIdx < 0 || imgIdx >= len(g.Images) {
+		return nil, fmt.Errorf("invalid image index")
+	}
+	imgData := g.Images[imgIdx]
+
+	// If Uri is empty, load image from GLB binary chunk
+	if imgData.Uri == "" {
+		if imgData.BufferView == nil {
+			return nil, fmt.Errorf("image has empty URI and no BufferView")
+		}
+		return g.loadBufferView(*imgData.BufferView)
+	} else if isDataURL(imgData.Uri) {
+		// Checks if image URI is data URL
+		return loadDataURL(imgData.Uri)
+	}
+	// Load image data from file
+	return g.loadFileBytes(imgData.Uri)
+}
+
 // bytesToArrayU32 converts a byte array to ArrayU32.
 func (g *GLTF) bytesToArrayU32(data []byte, componentType, count int) (math32.ArrayU32, error) {
 
@@ -1043,6 +1107,16 @@ func (g *GLTF) loadBufferView(bvIdx int) ([]byte, error) {
 	}
 	log.Debug("Loading BufferView %d", bvIdx)
 
+	// Decodes compressed buffer views, whose buffer only holds an optional fallback
+	if ext := meshoptExtension(&bvData); ext != nil {
+		bvBytes, err := g.loadBufferViewMeshopt(&bvData, ext)
+		if err != nil {
+			return nil, err
+		}
+		g.BufferViews[bvIdx].cache = bvBytes
+		return bvBytes, nil
+	}
+
 	// Load buffer view buffer
 	buf, err := g.loadBuffer(bvData.Buffer)
 	if err != nil {
@@ -1107,11 +1181,15 @@ func (g *GLTF) loadBuffer(bufIdx int) ([]byte, error) {
 	return data, nil
 }
 
-// loadFileBytes loads the file with specified path as a byte array.
+// loadFileBytes loads the file with specified path as a byte array,
+// using the ReadFile function if it was set.
 func (g *GLTF) loadFileBytes(uri string) ([]byte, error) {
 
 	log.Debug("Loading File: %v", uri)
 
+	if g.ReadFile != nil {
+		return g.ReadFile(uri)
+	}
 	fpath := filepath.Join(g.path, uri)
 	f, err := os.Open(fpath)
 	if err != nil {
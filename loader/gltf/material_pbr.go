@@ -5,6 +5,7 @@ import (
 
 	"github.com/g3n/engine/material"
 	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
 )
 
 func (g *GLTF) loadMaterialPBR(m *Material) (material.IMaterial, error) {
@@ -15,41 +16,13 @@ func (g *GLTF) loadMaterialPBR(m *Material) (material.IMaterial, error) {
 		return nil, fmt.Errorf("PbrMetallicRoughness not supplied")
 	}
 
-	// Create new physically based material
-	pm := material.NewPhysical()
-
-	// Double sided
-	if m.DoubleSided {
-		pm.SetSide(material.SideDouble)
-	} else {
-		pm.SetSide(material.SideFront)
-	}
-
-	var alphaMode string
-	if len(m.AlphaMode) > 0{
-		alphaMode = m.AlphaMode
-	} else {
-		alphaMode = "OPAQUE"
-	}
-
-	if alphaMode == "BLEND" {
-		pm.SetTransparent(true)
-	} else {
-		pm.SetTransparent(false)
-		if alphaMode == "MASK" {
-			// TODO m.AlphaCutoff
-			// pm.SetAlphaCutoff
-		}
-	}
-
 	// BaseColorFactor
 	var baseColorFactor math32.Color4
 	if pbr.BaseColorFactor != nil {
 		baseColorFactor = math32.Color4{pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2], pbr.BaseColorFactor[3]}
 	} else {
-		baseColorFactor = math32.Color4{1,1,1,1}
+		baseColorFactor = math32.Color4{1, 1, 1, 1}
 	}
-	pm.SetBaseColorFactor(&baseColorFactor)
 
 	// MetallicFactor
 	var metallicFactor float32
@@ -62,7 +35,6 @@ func (g *GLTF) loadMaterialPBR(m *Material) (material.IMaterial, error) {
 			metallicFactor = 0
 		}
 	}
-	pm.SetMetallicFactor(metallicFactor)
 
 	// RoughnessFactor
 	var roughnessFactor float32
@@ -71,7 +43,22 @@ func (g *GLTF) loadMaterialPBR(m *Material) (material.IMaterial, error) {
 	} else {
 		roughnessFactor = 1
 	}
-	pm.SetRoughnessFactor(roughnessFactor)
+
+	// AlphaMode and AlphaCutoff
+	var alphaMode string
+	if len(m.AlphaMode) > 0 {
+		alphaMode = m.AlphaMode
+	} else {
+		alphaMode = "OPAQUE"
+	}
+	var alphaCutoff float32
+	if alphaMode == "MASK" {
+		if m.AlphaCutoff != nil {
+			alphaCutoff = *m.AlphaCutoff
+		} else {
+			alphaCutoff = 0.5
+		}
+	}
 
 	// EmissiveFactor
 	var emissiveFactor math32.Color
@@ -81,55 +68,116 @@ func (g *GLTF) loadMaterialPBR(m *Material) (material.IMaterial, error) {
 		if m.EmissiveTexture != nil {
 			emissiveFactor = math32.Color{1, 1, 1}
 		} else {
-			emissiveFactor = math32.Color{0,0,0}
+			emissiveFactor = math32.Color{0, 0, 0}
 		}
 	}
-	pm.SetEmissiveFactor(&emissiveFactor)
 
-	// BaseColorTexture
+	// Textures, whose references are released if a texture fails to load
+	var err error
+	var baseColorTex, metallicRoughnessTex, normalTex, occlusionTex, emissiveTex *texture.Texture2D
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, tex := range []*texture.Texture2D{baseColorTex, metallicRoughnessTex, normalTex, occlusionTex, emissiveTex} {
+			if tex != nil {
+				tex.Dispose()
+			}
+		}
+	}()
 	if pbr.BaseColorTexture != nil {
-		tex, err := g.LoadTexture(pbr.BaseColorTexture.Index)
+		baseColorTex, err = g.loadTexture(pbr.BaseColorTexture.Index, "baseColor")
 		if err != nil {
 			return nil, err
 		}
-		pm.SetBaseColorMap(tex)
 	}
-
-	// MetallicRoughnessTexture
 	if pbr.MetallicRoughnessTexture != nil {
-		tex, err := g.LoadTexture(pbr.MetallicRoughnessTexture.Index)
+		metallicRoughnessTex, err = g.loadTexture(pbr.MetallicRoughnessTexture.Index, "metallicRoughness")
 		if err != nil {
 			return nil, err
 		}
-		pm.SetMetallicRoughnessMap(tex)
 	}
-
-	// NormalTexture
 	if m.NormalTexture != nil {
-		tex, err := g.LoadTexture(m.NormalTexture.Index)
+		normalTex, err = g.loadTexture(m.NormalTexture.Index, "normal")
 		if err != nil {
 			return nil, err
 		}
-		pm.SetNormalMap(tex)
 	}
-
-	// OcclusionTexture
 	if m.OcclusionTexture != nil {
-		tex, err := g.LoadTexture(m.OcclusionTexture.Index)
+		occlusionTex, err = g.loadTexture(m.OcclusionTexture.Index, "occlusion")
 		if err != nil {
 			return nil, err
 		}
-		pm.SetOcclusionMap(tex)
 	}
-
-	// EmissiveTexture
 	if m.EmissiveTexture != nil {
-		tex, err := g.LoadTexture(m.EmissiveTexture.Index)
+		emissiveTex, err = g.loadTexture(m.EmissiveTexture.Index, "emissive")
 		if err != nil {
 			return nil, err
 		}
-		pm.SetEmissiveMap(tex)
 	}
 
+	// Return the shared material with the same parameters and textures if available.
+	// Identical textures are shared, so their pointers identify their content.
+	var key string
+	if g.Cache != nil {
+		key = fmt.Sprintf("gltf pbr %v %v %v %v %v %v %v %p %p %p %p %p", m.DoubleSided, alphaMode, alphaCutoff,
+			baseColorFactor, metallicFactor, roughnessFactor, emissiveFactor,
+			baseColorTex, metallicRoughnessTex, normalTex, occlusionTex, emissiveTex)
+		if imat := g.Cache.Material(key); imat != nil {
+			for _, tex := range []*texture.Texture2D{baseColorTex, metallicRoughnessTex, normalTex, occlusionTex, emissiveTex} {
+				if tex != nil {
+					tex.Dispose()
+				}
+			}
+			return imat, nil
+		}
+	}
+
+	// Create new physically based material
+	pm := material.NewPhysical()
+
+	// Double sided
+	if m.DoubleSided {
+		pm.SetSide(material.SideDouble)
+	} else {
+		pm.SetSide(material.SideFront)
+	}
+
+	if alphaMode == "BLEND" {
+		pm.SetTransparent(true)
+		// Blends the back faces of double sided materials before the front faces
+		pm.SetTwoPass(m.DoubleSided)
+	} else {
+		pm.SetTransparent(false)
+		if alphaMode == "MASK" {
+			pm.SetAlphaCutoff(alphaCutoff)
+		}
+	}
+
+	// Factors and textures
+	pm.SetBaseColorFactor(&baseColorFactor)
+	pm.SetMetallicFactor(metallicFactor)
+	pm.SetRoughnessFactor(roughnessFactor)
+	pm.SetEmissiveFactor(&emissiveFactor)
+
+	if baseColorTex != nil {
+		pm.SetBaseColorMap(baseColorTex)
+	}
+	if metallicRoughnessTex != nil {
+		pm.SetMetallicRoughnessMap(metallicRoughnessTex)
+	}
+	if normalTex != nil {
+		pm.SetNormalMap(normalTex)
+	}
+	if occlusionTex != nil {
+		pm.SetOcclusionMap(occlusionTex)
+	}
+	if emissiveTex != nil {
+		pm.SetEmissiveMap(emissiveTex)
+	}
+
+	if g.Cache != nil {
+		return g.Cache.AddMaterial(key, pm), nil
+	}
 	return pm, nil
 }
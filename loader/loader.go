@@ -0,0 +1,219 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package loader loads scenes from files of any registered format.
+//
+// The formats of the loaders of the engine are registered by default and other packages
+// can register new formats with Register, so they are loaded by Load and discovered by
+// tools such as the asset pipeline without changes to the engine.
+package loader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/loader/collada"
+	"github.com/g3n/engine/loader/g3b"
+	"github.com/g3n/engine/loader/gltf"
+	"github.com/g3n/engine/loader/obj"
+	"github.com/g3n/engine/loader/scene"
+)
+
+// MagicSize is the maximum length of the magic bytes of formats.
+const MagicSize = 16
+
+// Format describes a file format which can be loaded.
+type Format struct {
+	Name       string                                // Name of the format
+	Extensions []string                              // File extensions, such as ".obj"
+	Magic      []string                              // Bytes at the start of the files, identifying the format even without its extension
+	Load       func(path string) (core.INode, error) // Loads the scene of a file
+}
+
+// Registered formats in order of registration
+var formats []*Format
+
+// Register registers (or replaces) a format by name. Formats registered later
+// take precedence over earlier formats for the same extensions.
+func Register(f *Format) {
+
+	for i, old := range formats {
+		if old.Name == f.Name {
+			formats = append(formats[:i], formats[i+1:]...)
+			break
+		}
+	}
+	for _, m := range f.Magic {
+		if len(m) > MagicSize {
+			panic(fmt.Sprintf("loader: magic of format %q longer than %d bytes", f.Name, MagicSize))
+		}
+	}
+	formats = append(formats, f)
+}
+
+// Formats returns the names of the registered formats, sorted.
+func Formats() []string {
+
+	names := make([]string, 0, len(formats))
+	for _, f := range formats {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the registered format with the specified name or nil.
+func Lookup(name string) *Format {
+
+	for _, f := range formats {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ForExtension returns the registered format of the files with the extension
+// of the specified path, or nil if none.
+func ForExtension(path string) *Format {
+
+	ext := strings.ToLower(filepath.Ext(path))
+	for i := len(formats) - 1; i >= 0; i-- {
+		for _, e := range formats[i].Extensions {
+			if strings.ToLower(e) == ext {
+				return formats[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ForMagic returns the registered format whose magic bytes start the specified data, or nil if none.
+func ForMagic(data []byte) *Format {
+
+	for i := len(formats) - 1; i >= 0; i-- {
+		for _, m := range formats[i].Magic {
+			if len(m) > 0 && bytes.HasPrefix(data, []byte(m)) {
+				return formats[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Detect returns the format of the specified file, identified by its magic bytes
+// or else by its extension.
+func Detect(path string) (*Format, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var header [MagicSize]byte
+	n, err := io.ReadFull(f, header[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if format := ForMagic(header[:n]); format != nil {
+		return format, nil
+	}
+	if format := ForExtension(path); format != nil {
+		return format, nil
+	}
+	return nil, fmt.Errorf("unsupported file format:%s", filepath.Base(path))
+}
+
+// Load loads the scene of the specified file with the loader of its format.
+func Load(path string) (core.INode, error) {
+
+	format, err := Detect(path)
+	if err != nil {
+		return nil, err
+	}
+	return format.Load(path)
+}
+
+func init() {
+
+	Register(&Format{
+		Name:       "obj",
+		Extensions: []string{".obj"},
+		Load: func(path string) (core.INode, error) {
+			dec, err := obj.Decode(path, "")
+			if err != nil {
+				return nil, err
+			}
+			return dec.NewGroup()
+		},
+	})
+	Register(&Format{
+		Name:       "collada",
+		Extensions: []string{".dae"},
+		Load: func(path string) (core.INode, error) {
+			dec, err := collada.Decode(path)
+			if err != nil {
+				return nil, err
+			}
+			return dec.NewScene()
+		},
+	})
+	Register(&Format{
+		Name:       "gltf",
+		Extensions: []string{".gltf"},
+		Load: func(path string) (core.INode, error) {
+			g, err := gltf.ParseJSON(path)
+			if err != nil {
+				return nil, err
+			}
+			return loadGLTF(g)
+		},
+	})
+	Register(&Format{
+		Name:       "glb",
+		Extensions: []string{".glb"},
+		Magic:      []string{"glTF"},
+		Load: func(path string) (core.INode, error) {
+			g, err := gltf.ParseBin(path)
+			if err != nil {
+				return nil, err
+			}
+			return loadGLTF(g)
+		},
+	})
+	Register(&Format{
+		Name:       "g3b",
+		Extensions: []string{".g3b"},
+		Magic:      []string{g3b.Magic},
+		Load: func(path string) (core.INode, error) {
+			f, err := g3b.Load(path)
+			if err != nil {
+				return nil, err
+			}
+			return f.NewScene(), nil
+		},
+	})
+	Register(&Format{
+		Name:       "scene",
+		Extensions: []string{".g3ns"},
+		Magic:      []string{scene.Magic},
+		Load:       scene.Load,
+	})
+}
+
+// loadGLTF loads the default scene of the specified glTF document.
+func loadGLTF(g *gltf.GLTF) (core.INode, error) {
+
+	idx := 0
+	if g.Scene != nil {
+		idx = *g.Scene
+	}
+	return g.LoadScene(idx)
+}
@@ -36,6 +36,8 @@ type Decoder struct {
 	Normals       math32.ArrayF32      // vertices normals
 	Uvs           math32.ArrayF32      // vertices texture coordinates
 	Warnings      []string             // warning messages
+	Coords        core.CoordSystem     // coordinate system of the file, converted by NewGroup (default is Y-up meters)
+	Cache         *material.Cache      // shares textures and materials with other models if not nil
 	line          uint                 // current line number
 	objCurrent    *Object              // current object
 	matCurrent    *Material            // current material
@@ -217,6 +219,8 @@ func DecodeReader(objreader, mtlreader io.Reader) (*Decoder, error) {
 // NewGroup creates and returns a group containing as children meshes
 // with all the decoded objects.
 // A group is returned even if there is only one object decoded.
+// The group is converted from the coordinate system of the decoder
+// to the engine coordinate system.
 func (dec *Decoder) NewGroup() (*core.Node, error) {
 
 	group := core.NewNode()
@@ -227,6 +231,7 @@ func (dec *Decoder) NewGroup() (*core.Node, error) {
 		}
 		group.Add(mesh)
 	}
+	core.ConvertNode(group, dec.Coords)
 	return group, nil
 }
 
@@ -257,13 +262,7 @@ func (dec *Decoder) NewMesh(obj *Object) (*graphic.Mesh, error) {
 		}
 
 		// Creates material for mesh
-		mat := material.NewPhong(&matDesc.Diffuse)
-		ambientColor := mat.AmbientColor()
-		mat.SetAmbientColor(ambientColor.Multiply(&matDesc.Ambient))
-		mat.SetSpecularColor(&matDesc.Specular)
-		mat.SetShininess(matDesc.Shininess)
-		// Loads material textures if specified
-		err = dec.loadTex(&mat.Material, matDesc)
+		mat, err := dec.newMaterial(matDesc)
 		if err != nil {
 			return nil, err
 		}
@@ -292,13 +291,7 @@ func (dec *Decoder) NewMesh(obj *Object) (*graphic.Mesh, error) {
 		}
 
 		// Creates material for mesh
-		matGroup := material.NewPhong(&matDesc.Diffuse)
-		ambientColor := matGroup.AmbientColor()
-		matGroup.SetAmbientColor(ambientColor.Multiply(&matDesc.Ambient))
-		matGroup.SetSpecularColor(&matDesc.Specular)
-		matGroup.SetShininess(matDesc.Shininess)
-		// Loads material textures if specified
-		err = dec.loadTex(&matGroup.Material, matDesc)
+		matGroup, err := dec.newMaterial(matDesc)
 		if err != nil {
 			return nil, err
 		}
@@ -368,13 +361,50 @@ func (dec *Decoder) NewGeometry(obj *Object) (*geometry.Geometry, error) {
 	return geom, nil
 }
 
-// loadTex loads textures described in the material descriptor into the
-// specified material
-func (dec *Decoder) loadTex(mat *material.Material, desc *Material) error {
+// newMaterial creates and returns a material with the parameters and textures
+// described in the specified material descriptor, or the shared material with the
+// same parameters and textures if the decoder has a cache.
+func (dec *Decoder) newMaterial(desc *Material) (material.IMaterial, error) {
+
+	// Loads material textures if specified
+	tex, err := dec.loadTex(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identical textures are shared, so their pointers identify their content
+	var key string
+	if dec.Cache != nil {
+		key = fmt.Sprintf("obj %v %v %v %v %p", desc.Diffuse, desc.Ambient, desc.Specular, desc.Shininess, tex)
+		if imat := dec.Cache.Material(key); imat != nil {
+			if tex != nil {
+				tex.Dispose()
+			}
+			return imat, nil
+		}
+	}
+
+	mat := material.NewPhong(&desc.Diffuse)
+	ambientColor := mat.AmbientColor()
+	mat.SetAmbientColor(ambientColor.Multiply(&desc.Ambient))
+	mat.SetSpecularColor(&desc.Specular)
+	mat.SetShininess(desc.Shininess)
+	if tex != nil {
+		mat.AddTexture(tex)
+	}
+	if dec.Cache != nil {
+		return dec.Cache.AddMaterial(key, mat), nil
+	}
+	return mat, nil
+}
+
+// loadTex loads the texture described in the specified material descriptor.
+// Returns nil if the descriptor does not specify a texture.
+func (dec *Decoder) loadTex(desc *Material) (*texture.Texture2D, error) {
 
 	// Checks if material descriptor specified texture
 	if desc.MapKd == "" {
-		return nil
+		return nil, nil
 	}
 
 	// Get texture file path
@@ -388,12 +418,10 @@ func (dec *Decoder) loadTex(mat *material.Material, desc *Material) error {
 	}
 
 	// Try to load texture from image file
-	tex, err := texture.NewTexture2DFromImage(texPath)
-	if err != nil {
-		return err
+	if dec.Cache != nil {
+		return dec.Cache.LoadTexture(texPath, "")
 	}
-	mat.AddTexture(tex)
-	return nil
+	return texture.NewTexture2DFromImage(texPath)
 }
 
 // parse reads the lines from the specified reader and dispatch them
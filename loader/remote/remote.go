@@ -0,0 +1,456 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package remote fetches assets over HTTP and HTTPS into an on-disk cache,
+// so they can be opened by the loaders like local files.
+//
+// Cached files are revalidated with their ETag and modification time, and used as they are
+// when the server reports they did not change, or when it can not be reached. The number of
+// parallel downloads is limited and their progress is reported with events, which are queued
+// and dispatched by Update from the goroutine running the application.
+package remote
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/loader/gltf"
+)
+
+// Events dispatched by a Fetcher. Data is a *FetchEvent.
+const (
+	OnFetchProgress = "remote.OnFetchProgress" // Part of a file was downloaded
+	OnFetchDone     = "remote.OnFetchDone"     // A fetch finished, successfully or not
+)
+
+// metaDir is the subdirectory of the cache directory storing the metadata of the cached files,
+// which are stored in subdirectories named after the schemes of their URLs.
+const metaDir = "meta"
+
+// DefaultMaxParallel is the default maximum number of parallel downloads of a Fetcher.
+const DefaultMaxParallel = 4
+
+// FetchEvent describes the progress of a fetch.
+type FetchEvent struct {
+	URL      string // Fetched URL
+	Path     string // Path of the cached file, when done
+	Received int64  // Number of bytes received
+	Total    int64  // Total number of bytes or -1 if unknown
+	Cached   bool   // Whether the cached file was used without downloading it, when done
+	Err      error  // Error of a failed fetch, when done
+}
+
+// Fetcher fetches remote files into a cache directory. Its methods may be called
+// from several goroutines, except Update.
+type Fetcher struct {
+	core.Dispatcher               // Embedded event dispatcher
+	Client          *http.Client  // Client used for the requests (default is http.DefaultClient)
+	MaxAge          time.Duration // Age of cached files which are used without revalidation (default is 0)
+
+	dir      string                // Cache directory
+	slots    chan struct{}         // Semaphore limiting the parallel downloads
+	mu       sync.Mutex            // Protects the fields below
+	calls    map[string]*fetchCall // Fetches in progress by URL
+	events   []FetchEvent          // Queued done events
+	progress map[string]FetchEvent // Last queued progress event of each URL
+	order    []string              // URLs of the queued progress events in order
+	pending  []asyncDone           // Queued callbacks of asynchronous fetches
+}
+
+// fetchCall is a fetch in progress, shared by the callers fetching the same URL.
+type fetchCall struct {
+	wg   sync.WaitGroup
+	path string
+	err  error
+}
+
+// asyncDone is a queued callback of an asynchronous fetch.
+type asyncDone struct {
+	cb   func(path string, err error)
+	path string
+	err  error
+}
+
+// cacheMeta is the metadata stored along each cached file.
+type cacheMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Fetched      time.Time `json:"fetched"`
+}
+
+// NewFetcher creates and returns a pointer to a new fetcher caching the files in the
+// specified directory, which is created if needed, with up to the specified number of
+// parallel downloads (DefaultMaxParallel if not positive).
+func NewFetcher(cacheDir string, maxParallel int) *Fetcher {
+
+	if maxParallel <= 0 {
+		maxParallel = DefaultMaxParallel
+	}
+	f := new(Fetcher)
+	f.Dispatcher.Initialize()
+	f.dir = cacheDir
+	f.slots = make(chan struct{}, maxParallel)
+	f.calls = make(map[string]*fetchCall)
+	f.progress = make(map[string]FetchEvent)
+	return f
+}
+
+// CacheDir returns the cache directory.
+func (f *Fetcher) CacheDir() string {
+
+	return f.dir
+}
+
+// ClearCache removes all the cached files.
+func (f *Fetcher) ClearCache() error {
+
+	return os.RemoveAll(f.dir)
+}
+
+// Fetch fetches the file with the specified URL into the cache, if needed, and returns the
+// path of the cached file. It blocks until the file is available. Paths and URLs which are
+// not HTTP or HTTPS URLs are returned as they are, so local files can be used the same way.
+// Concurrent fetches of the same URL share a single download.
+func (f *Fetcher) Fetch(rawurl string) (string, error) {
+
+	if !IsRemote(rawurl) {
+		return strings.TrimPrefix(rawurl, "file://"), nil
+	}
+	f.mu.Lock()
+	if call, ok := f.calls[rawurl]; ok {
+		f.mu.Unlock()
+		call.wg.Wait()
+		return call.path, call.err
+	}
+	call := new(fetchCall)
+	call.wg.Add(1)
+	f.calls[rawurl] = call
+	f.mu.Unlock()
+
+	var cached bool
+	call.path, cached, call.err = f.fetch(rawurl)
+	call.wg.Done()
+
+	f.mu.Lock()
+	delete(f.calls, rawurl)
+	ev := FetchEvent{URL: rawurl, Path: call.path, Total: -1, Cached: cached, Err: call.err}
+	if last, ok := f.progress[rawurl]; ok && !cached {
+		ev.Received, ev.Total = last.Received, last.Total
+	}
+	f.events = append(f.events, ev)
+	f.mu.Unlock()
+	return call.path, call.err
+}
+
+// FetchAsync fetches the file with the specified URL in the background and calls the
+// specified function, if not nil, with the path of the cached file or the error,
+// from the next call to Update after the fetch finished.
+func (f *Fetcher) FetchAsync(rawurl string, cb func(path string, err error)) {
+
+	go func() {
+		path, err := f.Fetch(rawurl)
+		if cb == nil {
+			return
+		}
+		f.mu.Lock()
+		f.pending = append(f.pending, asyncDone{cb: cb, path: path, err: err})
+		f.mu.Unlock()
+	}()
+}
+
+// ReadFile fetches the file with the specified URL and returns its content.
+func (f *Fetcher) ReadFile(rawurl string) ([]byte, error) {
+
+	path, err := f.Fetch(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+// Resolver returns a function which fetches and returns the content of the files with URLs
+// relative to the specified base URL, such as the resources referenced by a model.
+// If the base is an HTTP or HTTPS URL, references resolving to other URLs or paths, such as
+// file URLs, are rejected, so remote files can not read local files.
+func (f *Fetcher) Resolver(base string) func(uri string) ([]byte, error) {
+
+	return func(uri string) ([]byte, error) {
+		ref := Resolve(base, uri)
+		if IsRemote(base) && !IsRemote(ref) {
+			return nil, fmt.Errorf("reference %q of %s is not an HTTP or HTTPS URL", uri, base)
+		}
+		return f.ReadFile(ref)
+	}
+}
+
+// GLTF fetches and parses the glTF or GLB model with the specified URL. The buffers and
+// images it references are fetched relative to its URL when it is loaded.
+func (f *Fetcher) GLTF(rawurl string) (*gltf.GLTF, error) {
+
+	data, err := f.ReadFile(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	var g *gltf.GLTF
+	if bytes.HasPrefix(data, []byte("glTF")) {
+		g, err = gltf.ParseBinReader(bytes.NewReader(data), "")
+	} else {
+		g, err = gltf.ParseJSONReader(bytes.NewReader(data), "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	g.ReadFile = f.Resolver(rawurl)
+	return g, nil
+}
+
+// Update dispatches the queued events and calls the callbacks of the finished asynchronous
+// fetches. It must be called from the goroutine running the application, such as once per frame.
+func (f *Fetcher) Update() {
+
+	f.mu.Lock()
+	progress := make([]FetchEvent, 0, len(f.order))
+	for _, u := range f.order {
+		progress = append(progress, f.progress[u])
+	}
+	f.order = f.order[:0]
+	events := f.events
+	f.events = nil
+	pending := f.pending
+	f.pending = nil
+	for _, ev := range events {
+		delete(f.progress, ev.URL)
+	}
+	f.mu.Unlock()
+
+	for i := range progress {
+		f.Dispatch(OnFetchProgress, &progress[i])
+	}
+	for i := range events {
+		f.Dispatch(OnFetchDone, &events[i])
+	}
+	for _, p := range pending {
+		p.cb(p.path, p.err)
+	}
+}
+
+// fetch downloads or revalidates the file with the specified URL and returns the
+// path of the cached file and whether it was used without downloading it.
+func (f *Fetcher) fetch(rawurl string) (string, bool, error) {
+
+	local, err := f.cachePath(rawurl)
+	if err != nil {
+		return "", false, err
+	}
+	meta, hasCache := f.readMeta(local)
+	if hasCache && f.MaxAge > 0 && time.Since(meta.Fetched) < f.MaxAge {
+		return local, true, nil
+	}
+
+	// Waits for a free download slot
+	f.slots <- struct{}{}
+	defer func() { <-f.slots }()
+
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return "", false, err
+	}
+	if hasCache {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Uses the cached file if the server can not be reached
+		if hasCache {
+			return local, true, nil
+		}
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && hasCache:
+		meta.Fetched = time.Now()
+		f.writeMeta(local, meta)
+		return local, true, nil
+	case resp.StatusCode != http.StatusOK:
+		return "", false, fmt.Errorf("fetching %s: %s", rawurl, resp.Status)
+	}
+
+	// Downloads into a temporary file which replaces the cached file when complete
+	err = os.MkdirAll(filepath.Dir(local), 0755)
+	if err != nil {
+		return "", false, err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(local), ".fetch-")
+	if err != nil {
+		return "", false, err
+	}
+	pr := &progressReader{r: resp.Body, f: f, ev: FetchEvent{URL: rawurl, Total: resp.ContentLength}}
+	_, err = io.Copy(tmp, pr)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), local)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", false, err
+	}
+	meta = cacheMeta{
+		URL:          rawurl,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Fetched:      time.Now(),
+	}
+	f.writeMeta(local, meta)
+	return local, false, nil
+}
+
+// cachePath returns the path of the cached file of the specified URL, which mirrors its host
+// and path, so files referencing each other with relative paths are cached next to each other.
+// The query, if any, is hashed into the file name.
+func (f *Fetcher) cachePath(rawurl string) (string, error) {
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	p := path.Clean("/" + u.Path)
+	if strings.HasSuffix(u.Path, "/") || p == "/" {
+		p = path.Join(p, "index")
+	}
+	if u.RawQuery != "" {
+		sum := sha1.Sum([]byte(u.RawQuery))
+		ext := path.Ext(p)
+		p = strings.TrimSuffix(p, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+	}
+	host := strings.Replace(u.Host, ":", "_", -1)
+	return filepath.Join(f.dir, u.Scheme, host, filepath.FromSlash(p)), nil
+}
+
+// progressReader queues progress events while a download is read.
+type progressReader struct {
+	r  io.Reader
+	f  *Fetcher
+	ev FetchEvent
+}
+
+// Read satisfies the io.Reader interface.
+func (pr *progressReader) Read(p []byte) (int, error) {
+
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.ev.Received += int64(n)
+		pr.f.mu.Lock()
+		if !pr.f.queued(pr.ev.URL) {
+			pr.f.order = append(pr.f.order, pr.ev.URL)
+		}
+		pr.f.progress[pr.ev.URL] = pr.ev
+		pr.f.mu.Unlock()
+	}
+	return n, err
+}
+
+// queued returns whether a progress event of the specified URL is queued.
+// It must be called with the mutex locked.
+func (f *Fetcher) queued(rawurl string) bool {
+
+	for _, u := range f.order {
+		if u == rawurl {
+			return true
+		}
+	}
+	return false
+}
+
+// metaPath returns the path of the metadata of the specified cached file, which mirrors its
+// path in a separate directory so it can not collide with the cached files.
+func (f *Fetcher) metaPath(local string) string {
+
+	rel, err := filepath.Rel(f.dir, local)
+	if err != nil {
+		rel = filepath.Base(local)
+	}
+	return filepath.Join(f.dir, metaDir, rel)
+}
+
+// readMeta reads the metadata of the specified cached file and returns whether the file is cached.
+func (f *Fetcher) readMeta(local string) (cacheMeta, bool) {
+
+	var meta cacheMeta
+	if _, err := os.Stat(local); err != nil {
+		return meta, false
+	}
+	data, err := ioutil.ReadFile(f.metaPath(local))
+	if err != nil || json.Unmarshal(data, &meta) != nil {
+		return meta, false
+	}
+	return meta, true
+}
+
+// writeMeta writes the metadata of the specified cached file.
+func (f *Fetcher) writeMeta(local string, meta cacheMeta) {
+
+	data, err := json.Marshal(&meta)
+	if err != nil {
+		return
+	}
+	path := f.metaPath(local)
+	if os.MkdirAll(filepath.Dir(path), 0755) == nil {
+		ioutil.WriteFile(path, data, 0644)
+	}
+}
+
+// IsRemote returns whether the specified string is an HTTP or HTTPS URL.
+func IsRemote(rawurl string) bool {
+
+	return strings.HasPrefix(rawurl, "http://") || strings.HasPrefix(rawurl, "https://")
+}
+
+// Resolve returns the URL or path of the specified reference relative to the specified base
+// URL or path. References which are absolute URLs are returned as they are, including file
+// URLs relative to remote bases, which callers fetching remote files must reject (see Resolver).
+func Resolve(base, ref string) string {
+
+	if !IsRemote(base) {
+		if IsRemote(ref) || filepath.IsAbs(ref) {
+			return ref
+		}
+		return filepath.Join(filepath.Dir(base), ref)
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
@@ -0,0 +1,227 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scene
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/light"
+	"github.com/g3n/engine/math32"
+)
+
+// Built-in node types.
+const (
+	TypeMesh        = "mesh"              // *graphic.Mesh
+	TypeAmbient     = "light.ambient"     // *light.Ambient
+	TypeDirectional = "light.directional" // *light.Directional
+	TypePoint       = "light.point"       // *light.Point
+	TypeSpot        = "light.spot"        // *light.Spot
+	TypeCamera      = "camera"            // *camera.Camera
+)
+
+// Codec creates the nodes of a type and encodes and decodes their type specific data.
+// The transforms, names, tags and visibility of the nodes are saved by the document.
+type Codec struct {
+	New    func() core.INode                           // Creates a new node of the type
+	Encode func(inode core.INode) (interface{}, error) // Returns the data of the node to be encoded as JSON, or nil
+	Decode func(inode core.INode, data []byte) error   // Sets the data of a new node from its JSON encoding
+}
+
+// nodeType is a registered node type.
+type nodeType struct {
+	name  string
+	codec *Codec
+}
+
+// Registered node types by name and by Go type.
+var (
+	types   = make(map[string]*nodeType)
+	goTypes = make(map[reflect.Type]*nodeType)
+)
+
+// Register registers (or replaces) the named node type of the nodes with the same Go type as
+// the specified node, so they are saved with their type specific data and created again with
+// the specified codec when loaded.
+func Register(name string, node core.INode, codec *Codec) {
+
+	t := &nodeType{name: name, codec: codec}
+	if old, ok := types[name]; ok {
+		for gt, ot := range goTypes {
+			if ot == old {
+				delete(goTypes, gt)
+			}
+		}
+	}
+	types[name] = t
+	goTypes[reflect.TypeOf(node)] = t
+}
+
+// Types returns the sorted names of the registered node types.
+func Types() []string {
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// typeOf returns the registered type of the specified node or nil.
+func typeOf(inode core.INode) *nodeType {
+
+	return goTypes[reflect.TypeOf(inode)]
+}
+
+// lightData is the data of lights.
+type lightData struct {
+	Color          math32.Color
+	Intensity      float32
+	LinearDecay    float32 `json:",omitempty"`
+	QuadraticDecay float32 `json:",omitempty"`
+	CutoffAngle    float32 `json:",omitempty"`
+	AngularDecay   float32 `json:",omitempty"`
+}
+
+// cameraData is the data of cameras.
+type cameraData struct {
+	Projection camera.Projection
+	Axis       camera.Axis
+	Aspect     float32
+	Near       float32
+	Far        float32
+	Fov        float32
+	Size       float32
+}
+
+func init() {
+
+	white := math32.NewColor("white")
+	Register(TypeAmbient, (*light.Ambient)(nil), &Codec{
+		New: func() core.INode { return light.NewAmbient(white, 1) },
+		Encode: func(inode core.INode) (interface{}, error) {
+			l := inode.(*light.Ambient)
+			return &lightData{Color: l.Color(), Intensity: l.Intensity()}, nil
+		},
+		Decode: func(inode core.INode, data []byte) error {
+			var ld lightData
+			err := json.Unmarshal(data, &ld)
+			if err != nil {
+				return err
+			}
+			l := inode.(*light.Ambient)
+			l.SetColor(&ld.Color)
+			l.SetIntensity(ld.Intensity)
+			return nil
+		},
+	})
+	Register(TypeDirectional, (*light.Directional)(nil), &Codec{
+		New: func() core.INode { return light.NewDirectional(white, 1) },
+		Encode: func(inode core.INode) (interface{}, error) {
+			l := inode.(*light.Directional)
+			return &lightData{Color: l.Color(), Intensity: l.Intensity()}, nil
+		},
+		Decode: func(inode core.INode, data []byte) error {
+			var ld lightData
+			err := json.Unmarshal(data, &ld)
+			if err != nil {
+				return err
+			}
+			l := inode.(*light.Directional)
+			l.SetColor(&ld.Color)
+			l.SetIntensity(ld.Intensity)
+			return nil
+		},
+	})
+	Register(TypePoint, (*light.Point)(nil), &Codec{
+		New: func() core.INode { return light.NewPoint(white, 1) },
+		Encode: func(inode core.INode) (interface{}, error) {
+			l := inode.(*light.Point)
+			return &lightData{
+				Color:          l.Color(),
+				Intensity:      l.Intensity(),
+				LinearDecay:    l.LinearDecay(),
+				QuadraticDecay: l.QuadraticDecay(),
+			}, nil
+		},
+		Decode: func(inode core.INode, data []byte) error {
+			var ld lightData
+			err := json.Unmarshal(data, &ld)
+			if err != nil {
+				return err
+			}
+			l := inode.(*light.Point)
+			l.SetColor(&ld.Color)
+			l.SetIntensity(ld.Intensity)
+			l.SetLinearDecay(ld.LinearDecay)
+			l.SetQuadraticDecay(ld.QuadraticDecay)
+			return nil
+		},
+	})
+	Register(TypeSpot, (*light.Spot)(nil), &Codec{
+		New: func() core.INode { return light.NewSpot(white, 1) },
+		Encode: func(inode core.INode) (interface{}, error) {
+			l := inode.(*light.Spot)
+			return &lightData{
+				Color:          l.Color(),
+				Intensity:      l.Intensity(),
+				LinearDecay:    l.LinearDecay(),
+				QuadraticDecay: l.QuadraticDecay(),
+				CutoffAngle:    l.CutoffAngle(),
+				AngularDecay:   l.AngularDecay(),
+			}, nil
+		},
+		Decode: func(inode core.INode, data []byte) error {
+			var ld lightData
+			err := json.Unmarshal(data, &ld)
+			if err != nil {
+				return err
+			}
+			l := inode.(*light.Spot)
+			l.SetColor(&ld.Color)
+			l.SetIntensity(ld.Intensity)
+			l.SetLinearDecay(ld.LinearDecay)
+			l.SetQuadraticDecay(ld.QuadraticDecay)
+			l.SetCutoffAngle(ld.CutoffAngle)
+			l.SetAngularDecay(ld.AngularDecay)
+			return nil
+		},
+	})
+	Register(TypeCamera, (*camera.Camera)(nil), &Codec{
+		New: func() core.INode { return camera.New(1) },
+		Encode: func(inode core.INode) (interface{}, error) {
+			c := inode.(*camera.Camera)
+			return &cameraData{
+				Projection: c.Projection(),
+				Axis:       c.Axis(),
+				Aspect:     c.Aspect(),
+				Near:       c.Near(),
+				Far:        c.Far(),
+				Fov:        c.Fov(),
+				Size:       c.Size(),
+			}, nil
+		},
+		Decode: func(inode core.INode, data []byte) error {
+			var cd cameraData
+			err := json.Unmarshal(data, &cd)
+			if err != nil {
+				return err
+			}
+			c := inode.(*camera.Camera)
+			c.SetProjection(cd.Projection)
+			c.SetAxis(cd.Axis)
+			c.SetAspect(cd.Aspect)
+			c.SetNear(cd.Near)
+			c.SetFar(cd.Far)
+			c.SetFov(cd.Fov)
+			c.SetSize(cd.Size)
+			return nil
+		},
+	})
+}
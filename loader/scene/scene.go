@@ -0,0 +1,299 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package scene saves and loads scene graphs, as JSON documents or in a compact binary form.
+//
+// The hierarchy, names, tags, transforms and visibility of the nodes are saved with the
+// geometries, materials and textures of their meshes, and the parameters of their lights and
+// cameras. Other types of nodes are saved with their type specific data if they are registered
+// with Register, or as plain nodes otherwise.
+package scene
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/loader/g3b"
+	"github.com/g3n/engine/math32"
+)
+
+// Binary document constants.
+// A binary document starts with the magic "G3NS" and the format version,
+// followed by the zlib compressed gob encoding of the document.
+const (
+	Magic   = "G3NS" // Magic identifying binary documents
+	Version = 1      // Current document version
+)
+
+// Document describes a scene graph.
+type Document struct {
+	Version int       // Document version
+	Nodes   []Node    // Nodes of the scene, parents before their children, the root first
+	Data    *g3b.File // Textures, materials and geometries of the meshes
+}
+
+// Node describes a node of a document.
+type Node struct {
+	Type       string // Registered type of the node, empty for plain nodes
+	Name       string `json:",omitempty"`
+	LoaderID   string `json:",omitempty"`
+	Parent     int    // Index of the parent node or -1 for the root node
+	Position   math32.Vector3
+	Quaternion math32.Quaternion
+	Scale      math32.Vector3
+	Visible    bool
+	Tags       []string           `json:",omitempty"`
+	Mesh       int                // Index of the geometry of meshes in Data.Meshes or -1
+	Materials  []g3b.NodeMaterial `json:",omitempty"` // Materials of meshes
+	Data       json.RawMessage    `json:",omitempty"` // Type specific data encoded as JSON
+}
+
+// NewDocument creates and returns a pointer to a new document describing the scene graph
+// with the specified root node. The geometries, materials and textures shared by several
+// meshes are described once.
+func NewDocument(root core.INode) (*Document, error) {
+
+	data, err := g3b.Convert(root, &g3b.Options{})
+	if err != nil {
+		return nil, err
+	}
+	d := &Document{Version: Version, Data: data}
+	err = d.addNode(root, -1)
+	if err != nil {
+		return nil, err
+	}
+	data.Nodes = nil
+	return d, nil
+}
+
+// addNode describes the specified node and its children in the order of the converted nodes.
+func (d *Document) addNode(inode core.INode, parent int) error {
+
+	idx := len(d.Nodes)
+	if idx >= len(d.Data.Nodes) {
+		return fmt.Errorf("scene changed while converting it")
+	}
+	conv := &d.Data.Nodes[idx]
+	node := inode.GetNode()
+	desc := Node{
+		Name:       node.Name(),
+		LoaderID:   node.LoaderID(),
+		Parent:     parent,
+		Position:   conv.Position,
+		Quaternion: conv.Quaternion,
+		Scale:      conv.Scale,
+		Visible:    conv.Visible,
+		Tags:       node.Tags(),
+		Mesh:       conv.Mesh,
+		Materials:  conv.Materials,
+	}
+	if _, ok := inode.(*graphic.Mesh); !ok {
+		if t := typeOf(inode); t != nil {
+			desc.Type = t.name
+			data, err := t.codec.Encode(inode)
+			if err != nil {
+				return fmt.Errorf("node %q: %v", node.Name(), err)
+			}
+			if data != nil {
+				desc.Data, err = json.Marshal(data)
+				if err != nil {
+					return fmt.Errorf("node %q: %v", node.Name(), err)
+				}
+			}
+		}
+	} else {
+		desc.Type = TypeMesh
+	}
+	d.Nodes = append(d.Nodes, desc)
+	for _, child := range node.Children() {
+		err := d.addNode(child, idx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Build creates and returns the scene graph described by the document, after validating
+// its data. The geometries, materials and textures shared by several meshes are shared by
+// the created meshes.
+func (d *Document) Build() (core.INode, error) {
+
+	if len(d.Nodes) == 0 || d.Nodes[0].Parent >= 0 {
+		return nil, fmt.Errorf("document has no root node")
+	}
+	data := d.Data
+	if data == nil {
+		data = new(g3b.File)
+	}
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+	b := g3b.NewBuilder(data)
+	nodes := make([]core.INode, len(d.Nodes))
+	for i := range d.Nodes {
+		desc := &d.Nodes[i]
+		if i > 0 && (desc.Parent < 0 || desc.Parent >= i) {
+			return nil, fmt.Errorf("node %d: invalid parent %d", i, desc.Parent)
+		}
+		var inode core.INode
+		switch desc.Type {
+		case "":
+			inode = core.NewNode()
+		case TypeMesh:
+			if desc.Mesh < 0 || desc.Mesh >= len(data.Meshes) {
+				return nil, fmt.Errorf("node %d: invalid mesh %d", i, desc.Mesh)
+			}
+			for _, nm := range desc.Materials {
+				if nm.Material < 0 || nm.Material >= len(data.Materials) {
+					return nil, fmt.Errorf("node %d: invalid material %d", i, nm.Material)
+				}
+			}
+			inode = b.Mesh(desc.Mesh, desc.Materials)
+		default:
+			t, ok := types[desc.Type]
+			if !ok {
+				return nil, fmt.Errorf("node %d: unregistered type %q", i, desc.Type)
+			}
+			inode = t.codec.New()
+			if desc.Data != nil && t.codec.Decode != nil {
+				err := t.codec.Decode(inode, desc.Data)
+				if err != nil {
+					return nil, fmt.Errorf("node %d: %v", i, err)
+				}
+			}
+		}
+		node := inode.GetNode()
+		node.SetName(desc.Name)
+		node.SetLoaderID(desc.LoaderID)
+		node.SetPositionVec(&desc.Position)
+		node.SetQuaternionQuat(&desc.Quaternion)
+		node.SetScaleVec(&desc.Scale)
+		node.SetVisible(desc.Visible)
+		node.AddTag(desc.Tags...)
+		if i > 0 {
+			nodes[desc.Parent].GetNode().Add(inode)
+		}
+		nodes[i] = inode
+	}
+	return nodes[0], nil
+}
+
+// WriteJSON writes the document as indented JSON to the specified writer.
+func (d *Document) WriteJSON(w io.Writer) error {
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(d)
+}
+
+// WriteBinary writes the document in the binary form to the specified writer.
+func (d *Document) WriteBinary(w io.Writer) error {
+
+	var header [8]byte
+	copy(header[:], Magic)
+	binary.LittleEndian.PutUint32(header[4:], Version)
+	_, err := w.Write(header[:])
+	if err != nil {
+		return err
+	}
+	zw := zlib.NewWriter(w)
+	err = gob.NewEncoder(zw).Encode(d)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Read reads a document, in the binary form or as JSON, from the specified reader.
+func Read(r io.Reader) (*Document, error) {
+
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(Magic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	d := new(Document)
+	if bytes.Equal(header, []byte(Magic)) {
+		var header [8]byte
+		_, err = io.ReadFull(br, header[:])
+		if err != nil {
+			return nil, err
+		}
+		version := binary.LittleEndian.Uint32(header[4:])
+		if version != Version {
+			return nil, fmt.Errorf("unsupported document version:%d", version)
+		}
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		err = gob.NewDecoder(zr).Decode(d)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	err = json.NewDecoder(br).Decode(d)
+	if err != nil {
+		return nil, err
+	}
+	if d.Version != Version {
+		return nil, fmt.Errorf("unsupported document version:%d", d.Version)
+	}
+	return d, nil
+}
+
+// Save saves the scene graph with the specified root node to the specified file,
+// as JSON if its extension is ".json" or in the binary form otherwise.
+func Save(path string, root core.INode) error {
+
+	d, err := NewDocument(root)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = d.WriteJSON(w)
+	} else {
+		err = d.WriteBinary(w)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Load loads and builds the scene graph saved in the specified file.
+func Load(path string) (core.INode, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := Read(f)
+	if err != nil {
+		return nil, err
+	}
+	return d.Build()
+}
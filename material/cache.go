@@ -0,0 +1,184 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"sync"
+
+	"github.com/g3n/engine/texture"
+)
+
+// Cache shares the textures and materials with identical content between the models
+// loaded with it, so that a texture or material referenced by many model files, as in
+// scenes assembled from asset kits, is created and transferred to OpenGL only once.
+// Textures are identified by the hash of their encoded image data and materials by
+// a description of their parameters built by the loaders.
+//
+// The cache keeps a reference to each of its textures and materials until it is cleared.
+// Shared textures and materials must not be modified, as the changes affect all the models
+// using them. A cache can be used by several goroutines loading models concurrently.
+// The reference counts of textures and materials are not atomic, so models using shared
+// textures and materials must not be disposed while other goroutines load models with
+// the cache, and Clear must not be called concurrently with disposing them.
+type Cache struct {
+	mu        sync.Mutex
+	textures  map[string]*texture.Texture2D
+	materials map[string]IMaterial
+	hits      int
+}
+
+// NewCache creates and returns a pointer to a new empty cache.
+func NewCache() *Cache {
+
+	c := new(Cache)
+	c.textures = make(map[string]*texture.Texture2D)
+	c.materials = make(map[string]IMaterial)
+	return c
+}
+
+// ContentKey returns a key identifying the concatenation of the specified data, such as the
+// encoded data of an image and the description of its sampler, by its SHA-256 hash.
+func ContentKey(data ...[]byte) string {
+
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Texture returns the texture stored with the specified key, with a new reference
+// for the caller, or nil if there is none.
+func (c *Cache) Texture(key string) *texture.Texture2D {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tex := c.textures[key]
+	if tex == nil {
+		return nil
+	}
+	c.hits++
+	return tex.Incref()
+}
+
+// AddTexture stores the specified texture with the specified key and returns it.
+// If a texture was stored with the same key in the meantime, the specified texture
+// is disposed and the stored one is returned instead, with a new reference for the caller.
+func (c *Cache) AddTexture(key string, tex *texture.Texture2D) *texture.Texture2D {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev := c.textures[key]; prev != nil {
+		tex.Dispose()
+		c.hits++
+		return prev.Incref()
+	}
+	c.textures[key] = tex.Incref()
+	return tex
+}
+
+// LoadTexture returns a texture with the image of the specified file, shared with the textures
+// previously loaded with the same image data and parameters. The parameters describe how the
+// loader sets up the texture, such as its sampler and usage, so textures set up differently
+// are not shared. The returned texture holds a reference for the caller.
+func (c *Cache) LoadTexture(imgfile string, params string) (*texture.Texture2D, error) {
+
+	data, err := ioutil.ReadFile(imgfile)
+	if err != nil {
+		return nil, err
+	}
+	return c.loadTexture(data, params, func(t *texture.Texture2D) error {
+		return t.SetImage(imgfile)
+	})
+}
+
+// LoadTextureData returns a texture with the image decoded from the specified encoded data,
+// such as an image embedded in a model file, shared as in LoadTexture.
+func (c *Cache) LoadTextureData(data []byte, params string) (*texture.Texture2D, error) {
+
+	return c.loadTexture(data, params, nil)
+}
+
+// loadTexture returns the shared texture for the specified encoded image data and parameters,
+// creating it with the specified fetcher of its released data if necessary.
+func (c *Cache) loadTexture(data []byte, params string, fetcher func(t *texture.Texture2D) error) (*texture.Texture2D, error) {
+
+	key := ContentKey(data, []byte(params))
+	if tex := c.Texture(key); tex != nil {
+		return tex, nil
+	}
+	rgba, err := texture.DecodeImageReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tex := texture.NewTexture2DFromRGBA(rgba)
+	if fetcher != nil {
+		tex.SetFetcher(fetcher)
+	}
+	return c.AddTexture(key, tex), nil
+}
+
+// Material returns the material stored with the specified key, with a new reference
+// for the caller, or nil if there is none.
+func (c *Cache) Material(key string) IMaterial {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	imat := c.materials[key]
+	if imat == nil {
+		return nil
+	}
+	c.hits++
+	imat.GetMaterial().Incref()
+	return imat
+}
+
+// AddMaterial stores the specified material with the specified key and returns it.
+// If a material was stored with the same key in the meantime, the specified material
+// is disposed and the stored one is returned instead, with a new reference for the caller.
+func (c *Cache) AddMaterial(key string, imat IMaterial) IMaterial {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev := c.materials[key]; prev != nil {
+		imat.Dispose()
+		c.hits++
+		prev.GetMaterial().Incref()
+		return prev
+	}
+	imat.GetMaterial().Incref()
+	c.materials[key] = imat
+	return imat
+}
+
+// Stats returns the number of textures and materials stored in the cache and the number
+// of times one of them was shared instead of being created again.
+func (c *Cache) Stats() (textures, materials, hits int) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.textures), len(c.materials), c.hits
+}
+
+// Clear releases the references of the cache to its textures and materials and empties it.
+// The textures and materials still used by models remain valid.
+func (c *Cache) Clear() {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, imat := range c.materials {
+		imat.Dispose()
+	}
+	for _, tex := range c.textures {
+		tex.Dispose()
+	}
+	c.materials = make(map[string]IMaterial)
+	c.textures = make(map[string]*texture.Texture2D)
+	c.hits = 0
+}
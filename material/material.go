@@ -8,6 +8,7 @@ package material
 
 import (
 	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
 	"github.com/g3n/engine/texture"
 )
 
@@ -61,6 +62,8 @@ type Material struct {
 	shader        string            // Shader name
 	shaderUnique  bool              // shader has only one instance (does not depend on lights or textures)
 	ShaderDefines gls.ShaderDefines // shader defines
+	Uniforms      gls.Uniforms      // custom uniforms transferred when rendering with the material
+	userData      interface{}       // generic user data
 
 	uselights   UseLights            // Which light types to consider
 	sidevis     Side                 // Face side(s) visibility
@@ -73,17 +76,24 @@ type Material struct {
 	polyOffsetFactor float32 // polygon offset factor
 	polyOffsetUnits  float32 // polygon offset units
 
-	depthMask bool   // Enable writing into the depth buffer
-	depthTest bool   // Enable depth buffer test
-	depthFunc uint32 // Active depth test function
-
-	// Equations used for custom blending (when blending=BlendingCustom) // TODO implement methods
-	blendRGB      uint32 // separate blend equation for RGB
-	blendAlpha    uint32 // separate blend equation for Alpha
-	blendSrcRGB   uint32 // separate blend func source RGB
-	blendDstRGB   uint32 // separate blend func dest RGB
-	blendSrcAlpha uint32 // separate blend func source Alpha
-	blendDstAlpha uint32 // separate blend func dest Alpha
+	depthMask bool    // Enable writing into the depth buffer
+	depthTest bool    // Enable depth buffer test
+	depthFunc uint32  // Active depth test function
+	colorMask [4]bool // Enable writing each color component into the color buffer
+
+	// Equations used for custom blending (when blending=BlendingCustom)
+	blendRGB      uint32        // separate blend equation for RGB
+	blendAlpha    uint32        // separate blend equation for Alpha
+	blendSrcRGB   uint32        // separate blend func source RGB
+	blendDstRGB   uint32        // separate blend func dest RGB
+	blendSrcAlpha uint32        // separate blend func source Alpha
+	blendDstAlpha uint32        // separate blend func dest Alpha
+	blendColor    math32.Color4 // constant blend color
+
+	// Transparency and coverage
+	twoPass         bool   // Render double sided transparent faces back then front
+	facePass        uint32 // Faces rendered in the current pass of a two pass material (0 for both)
+	alphaToCoverage bool   // Convert the fragment alpha to a multisample coverage mask
 }
 
 // NewMaterial creates and returns a pointer to a new Material.
@@ -108,10 +118,22 @@ func (mat *Material) Init() *Material {
 	mat.lineWidth = 1.0
 	mat.polyOffsetFactor = 0
 	mat.polyOffsetUnits = 0
+	mat.colorMask = [4]bool{true, true, true, true}
+	mat.blendRGB = gls.FUNC_ADD
+	mat.blendAlpha = gls.FUNC_ADD
+	mat.blendSrcRGB = gls.ONE
+	mat.blendDstRGB = gls.ZERO
+	mat.blendSrcAlpha = gls.ONE
+	mat.blendDstAlpha = gls.ZERO
+	mat.blendColor = math32.Color4{}
+	mat.twoPass = false
+	mat.facePass = 0
+	mat.alphaToCoverage = false
 	mat.textures = make([]*texture.Texture2D, 0)
 
 	// Setup shader defines and add default values
 	mat.ShaderDefines = *gls.NewShaderDefines()
+	mat.Uniforms = gls.Uniforms{}
 
 	return mat
 }
@@ -149,6 +171,18 @@ func (mat *Material) Dispose() {
 	mat.Init()
 }
 
+// SetUserData sets the generic user data associated to the material.
+func (mat *Material) SetUserData(data interface{}) {
+
+	mat.userData = data
+}
+
+// UserData returns the generic user data associated to the material.
+func (mat *Material) UserData() interface{} {
+
+	return mat.userData
+}
+
 // SetShader sets the name of the shader program for this material
 func (mat *Material) SetShader(sname string) {
 
@@ -225,37 +259,190 @@ func (mat *Material) Wireframe() bool {
 	return mat.wireframe
 }
 
+// SetTwoPass sets whether a transparent double sided material is rendered in two passes,
+// first its back faces and then its front faces, so the faces of closed or overlapping
+// transparent surfaces, such as glass objects, are blended in the right order.
+func (mat *Material) SetTwoPass(state bool) {
+
+	mat.twoPass = state
+}
+
+// TwoPass returns whether a transparent double sided material is rendered in two passes.
+func (mat *Material) TwoPass() bool {
+
+	return mat.twoPass
+}
+
+// SetFacePass restricts the faces rendered by a double sided material to the back faces
+// (gls.BACK) or to the front faces (gls.FRONT). Zero renders both faces.
+// It is used by the renderer to render the passes of two pass materials.
+func (mat *Material) SetFacePass(face uint32) {
+
+	mat.facePass = face
+}
+
+// SetAlphaToCoverage sets whether the fragment alpha is converted to a multisample coverage
+// mask, so cutout materials such as foliage get smooth edges without blending and sorting.
+// Such materials should not be transparent, and it requires a multisampled framebuffer.
+func (mat *Material) SetAlphaToCoverage(state bool) {
+
+	mat.alphaToCoverage = state
+}
+
+// AlphaToCoverage returns whether the fragment alpha is converted to a multisample coverage mask.
+func (mat *Material) AlphaToCoverage() bool {
+
+	return mat.alphaToCoverage
+}
+
+// SetDepthMask sets whether the material writes into the depth buffer.
 func (mat *Material) SetDepthMask(state bool) {
 
 	mat.depthMask = state
 }
 
+// DepthMask returns whether the material writes into the depth buffer.
+func (mat *Material) DepthMask() bool {
+
+	return mat.depthMask
+}
+
+// SetDepthTest sets whether the material is tested against the depth buffer.
 func (mat *Material) SetDepthTest(state bool) {
 
 	mat.depthTest = state
 }
 
+// DepthTest returns whether the material is tested against the depth buffer.
+func (mat *Material) DepthTest() bool {
+
+	return mat.depthTest
+}
+
+// SetDepthFunc sets the function used to compare the material depth with the depth buffer
+// (gls.LEQUAL by default). Invalid functions are ignored.
 func (mat *Material) SetDepthFunc(state uint32) {
 
+	if !gls.ValidCompareFunc(state) {
+		log.Error("SetDepthFunc: invalid depth function: %#x", state)
+		return
+	}
 	mat.depthFunc = state
 }
 
+// DepthFunc returns the function used to compare the material depth with the depth buffer.
+func (mat *Material) DepthFunc() uint32 {
+
+	return mat.depthFunc
+}
+
+// SetColorMask sets whether the material writes each of the color components into the
+// color buffer. Disabling all of them renders only into the depth and stencil buffers.
+func (mat *Material) SetColorMask(r, g, b, a bool) {
+
+	mat.colorMask = [4]bool{r, g, b, a}
+}
+
+// ColorMask returns whether the material writes each of the color components into the color buffer.
+func (mat *Material) ColorMask() (r, g, b, a bool) {
+
+	return mat.colorMask[0], mat.colorMask[1], mat.colorMask[2], mat.colorMask[3]
+}
+
+// SetBlending sets the blending mode of the material.
 func (mat *Material) SetBlending(blending Blending) {
 
 	mat.blending = blending
 }
 
+// Blending returns the blending mode of the material.
+func (mat *Material) Blending() Blending {
+
+	return mat.blending
+}
+
+// SetBlendEquation sets the blend equations for the RGB and alpha components
+// and sets the blending mode to BlendingCustom. Invalid equations are ignored.
+func (mat *Material) SetBlendEquation(modeRGB, modeAlpha uint32) {
+
+	if !gls.ValidBlendEquation(modeRGB) || !gls.ValidBlendEquation(modeAlpha) {
+		log.Error("SetBlendEquation: invalid blend equation: %#x, %#x", modeRGB, modeAlpha)
+		return
+	}
+	mat.blendRGB = modeRGB
+	mat.blendAlpha = modeAlpha
+	mat.blending = BlendingCustom
+}
+
+// BlendEquation returns the custom blend equations for the RGB and alpha components.
+func (mat *Material) BlendEquation() (modeRGB, modeAlpha uint32) {
+
+	return mat.blendRGB, mat.blendAlpha
+}
+
+// SetBlendFunc sets the source and destination blend factors for the RGB and alpha
+// components and sets the blending mode to BlendingCustom. Invalid factors are ignored.
+func (mat *Material) SetBlendFunc(srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+
+	if !gls.ValidBlendFactor(srcRGB) || !gls.ValidBlendFactor(dstRGB) ||
+		!gls.ValidBlendFactor(srcAlpha) || !gls.ValidBlendFactor(dstAlpha) ||
+		dstRGB == gls.SRC_ALPHA_SATURATE || dstAlpha == gls.SRC_ALPHA_SATURATE {
+		log.Error("SetBlendFunc: invalid blend factors: %#x, %#x, %#x, %#x", srcRGB, dstRGB, srcAlpha, dstAlpha)
+		return
+	}
+	mat.blendSrcRGB = srcRGB
+	mat.blendDstRGB = dstRGB
+	mat.blendSrcAlpha = srcAlpha
+	mat.blendDstAlpha = dstAlpha
+	mat.blending = BlendingCustom
+}
+
+// BlendFunc returns the custom source and destination blend factors for the RGB and alpha components.
+func (mat *Material) BlendFunc() (srcRGB, dstRGB, srcAlpha, dstAlpha uint32) {
+
+	return mat.blendSrcRGB, mat.blendDstRGB, mat.blendSrcAlpha, mat.blendDstAlpha
+}
+
+// SetBlendColor sets the constant color used by the CONSTANT_COLOR and
+// CONSTANT_ALPHA blend factors of the custom blending mode.
+func (mat *Material) SetBlendColor(color *math32.Color4) {
+
+	mat.blendColor = *color
+}
+
+// BlendColor returns the constant color used by the custom blending mode.
+func (mat *Material) BlendColor() math32.Color4 {
+
+	return mat.blendColor
+}
+
+// SetLineWidth sets the width of lines and of the mesh wireframe.
 func (mat *Material) SetLineWidth(width float32) {
 
 	mat.lineWidth = width
 }
 
+// LineWidth returns the width of lines and of the mesh wireframe.
+func (mat *Material) LineWidth() float32 {
+
+	return mat.lineWidth
+}
+
+// SetPolygonOffset sets the factor, scaled by the depth slope of each polygon, and the
+// units used to offset the depth of the material. Negative values move the polygons
+// towards the camera, so decals drawn over coplanar surfaces do not z-fight.
 func (mat *Material) SetPolygonOffset(factor, units float32) {
 
 	mat.polyOffsetFactor = factor
 	mat.polyOffsetUnits = units
 }
 
+// PolygonOffset returns the polygon offset factor and units of the material.
+func (mat *Material) PolygonOffset() (factor, units float32) {
+
+	return mat.polyOffsetFactor, mat.polyOffsetUnits
+}
+
 // RenderSetup is called by the renderer before drawing objects with this material.
 func (mat *Material) RenderSetup(gs *gls.GLS) {
 
@@ -263,15 +450,33 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 	switch mat.sidevis {
 	case SideFront:
 		gs.Enable(gls.CULL_FACE)
+		gs.CullFace(gls.BACK)
 		gs.FrontFace(gls.CCW)
 	case SideBack:
 		gs.Enable(gls.CULL_FACE)
+		gs.CullFace(gls.BACK)
 		gs.FrontFace(gls.CW)
 	case SideDouble:
-		gs.Disable(gls.CULL_FACE)
+		// Culls the faces not rendered in the current pass
+		switch mat.facePass {
+		case gls.BACK:
+			gs.Enable(gls.CULL_FACE)
+			gs.CullFace(gls.FRONT)
+		case gls.FRONT:
+			gs.Enable(gls.CULL_FACE)
+			gs.CullFace(gls.BACK)
+		default:
+			gs.Disable(gls.CULL_FACE)
+		}
 		gs.FrontFace(gls.CCW)
 	}
 
+	if mat.alphaToCoverage {
+		gs.Enable(gls.SAMPLE_ALPHA_TO_COVERAGE)
+	} else {
+		gs.Disable(gls.SAMPLE_ALPHA_TO_COVERAGE)
+	}
+
 	if mat.depthTest {
 		gs.Enable(gls.DEPTH_TEST)
 	} else {
@@ -279,6 +484,7 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 	}
 	gs.DepthMask(mat.depthMask)
 	gs.DepthFunc(mat.depthFunc)
+	gs.ColorMask(mat.colorMask[0], mat.colorMask[1], mat.colorMask[2], mat.colorMask[3])
 
 	if mat.wireframe {
 		gs.PolygonMode(gls.FRONT_AND_BACK, gls.LINE)
@@ -315,8 +521,10 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 		gs.BlendFunc(gls.ZERO, gls.SRC_COLOR)
 		break
 	case BlendingCustom:
+		gs.Enable(gls.BLEND)
 		gs.BlendEquationSeparate(mat.blendRGB, mat.blendAlpha)
 		gs.BlendFuncSeparate(mat.blendSrcRGB, mat.blendDstRGB, mat.blendSrcAlpha, mat.blendDstAlpha)
+		gs.BlendColor(mat.blendColor.R, mat.blendColor.G, mat.blendColor.B, mat.blendColor.A)
 		break
 	default:
 		panic("Invalid blending")
@@ -324,13 +532,21 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 
 	// Render textures
 	// Keep track of counts of unique sampler names to correctly index sampler arrays
+	arrays := mat.TextureArrays()
 	samplerCounts := make(map[string]int)
 	for slotIdx, tex := range mat.textures {
 		samplerName, _ := tex.GetUniformNames()
 		uniIdx, _ := samplerCounts[samplerName]
-		tex.RenderSetup(gs, slotIdx, uniIdx)
+		if arrays {
+			tex.RenderSetupArray(gs, slotIdx, uniIdx)
+		} else {
+			tex.RenderSetup(gs, slotIdx, uniIdx)
+		}
 		samplerCounts[samplerName] = uniIdx + 1
 	}
+
+	// Transfer custom uniforms
+	mat.Uniforms.Transfer(gs)
 }
 
 // AddTexture adds the specified Texture2d to the material
@@ -369,3 +585,72 @@ func (mat *Material) TextureCount() int {
 
 	return len(mat.textures)
 }
+
+// PackTextures packs all the textures of the material into texture arrays of the specified
+// residency manager, so the material can be rendered without binding its textures when
+// other materials use the same arrays. It returns false, without packing any texture,
+// if the material shader does not support texture arrays or any texture cannot be packed.
+func (mat *Material) PackTextures(rm *texture.Residency) bool {
+
+	if len(mat.textures) == 0 || !textureArrayShaders[mat.shader] {
+		return false
+	}
+	for _, tex := range mat.textures {
+		if !rm.Packable(tex) {
+			return false
+		}
+	}
+	for _, tex := range mat.textures {
+		rm.Add(tex)
+	}
+	return mat.TextureArrays()
+}
+
+// UnpackTextures removes the textures of the material from the texture arrays
+// of the specified residency manager. Other materials sharing the textures
+// go back to binding them individually.
+func (mat *Material) UnpackTextures(rm *texture.Residency) {
+
+	for _, tex := range mat.textures {
+		rm.Remove(tex)
+	}
+}
+
+// TextureArrays returns if the material is rendered using texture arrays,
+// which happens when its shader supports them and all its textures are in arrays.
+func (mat *Material) TextureArrays() bool {
+
+	if len(mat.textures) == 0 || !textureArrayShaders[mat.shader] {
+		return false
+	}
+	for _, tex := range mat.textures {
+		if a, _ := tex.Array(); a == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// RegisterTextureArrayShader registers the name of a custom shader which supports
+// texture arrays, so materials using it can pack their textures. The shader must
+// sample the material textures with the MatTexSample macro of the "material" chunk.
+func RegisterTextureArrayShader(name string) {
+
+	textureArrayShaders[name] = true
+}
+
+// Shaders which support texture arrays through the "material" chunk
+var textureArrayShaders = map[string]bool{
+	"standard": true,
+	"phong":    true,
+	"point":    true,
+	"sprite":   true,
+	"sdftext":  true,
+}
+
+// Textures returns the textures of the material.
+// The returned slice must not be modified.
+func (mat *Material) Textures() []*texture.Texture2D {
+
+	return mat.textures
+}
@@ -24,6 +24,7 @@ type Physical struct {
 		emissiveFactor  math32.Color4
 		metallicFactor  float32
 		roughnessFactor float32
+		alphaCutoff     float32
 	}
 }
 
@@ -84,12 +85,13 @@ func (m *Physical) SetEmissiveFactor(c *math32.Color) *Physical {
 	return m
 }
 
-// SetBaseColorMap sets this material optional texture base color.
+// SetBaseColorMap sets this material optional texture base color, which is made sRGB.
 // Returns pointer to this updated material.
 func (m *Physical) SetBaseColorMap(tex *texture.Texture2D) *Physical {
 
 	m.baseColorTex = tex
 	if m.baseColorTex != nil {
+		m.baseColorTex.SetSRGB(true)
 		m.baseColorTex.SetUniformNames("uBaseColorSampler", "uBaseColorTexParams")
 		m.ShaderDefines.Set("HAS_BASECOLORMAP", "")
 		m.AddTexture(m.baseColorTex)
@@ -100,12 +102,13 @@ func (m *Physical) SetBaseColorMap(tex *texture.Texture2D) *Physical {
 	return m
 }
 
-// SetMetallicRoughnessMap sets this material optional metallic-roughness texture.
+// SetMetallicRoughnessMap sets this material optional metallic-roughness texture, which is made linear.
 // Returns pointer to this updated material.
 func (m *Physical) SetMetallicRoughnessMap(tex *texture.Texture2D) *Physical {
 
 	m.metallicRoughnessTex = tex
 	if m.metallicRoughnessTex != nil {
+		m.metallicRoughnessTex.SetSRGB(false)
 		m.metallicRoughnessTex.SetUniformNames("uMetallicRoughnessSampler", "uMetallicRoughnessTexParams")
 		m.ShaderDefines.Set("HAS_METALROUGHNESSMAP", "")
 		m.AddTexture(m.metallicRoughnessTex)
@@ -116,13 +119,14 @@ func (m *Physical) SetMetallicRoughnessMap(tex *texture.Texture2D) *Physical {
 	return m
 }
 
-// SetNormalMap sets this material optional normal texture.
+// SetNormalMap sets this material optional normal texture, which is made linear.
 // Returns pointer to this updated material.
 // TODO add SetNormalMap (and SetSpecularMap) to StandardMaterial.
 func (m *Physical) SetNormalMap(tex *texture.Texture2D) *Physical {
 
 	m.normalTex = tex
 	if m.normalTex != nil {
+		m.normalTex.SetSRGB(false)
 		m.normalTex.SetUniformNames("uNormalSampler", "uNormalTexParams")
 		m.ShaderDefines.Set("HAS_NORMALMAP", "")
 		m.AddTexture(m.normalTex)
@@ -133,12 +137,13 @@ func (m *Physical) SetNormalMap(tex *texture.Texture2D) *Physical {
 	return m
 }
 
-// SetOcclusionMap sets this material optional occlusion texture.
+// SetOcclusionMap sets this material optional occlusion texture, which is made linear.
 // Returns pointer to this updated material.
 func (m *Physical) SetOcclusionMap(tex *texture.Texture2D) *Physical {
 
 	m.occlusionTex = tex
 	if m.occlusionTex != nil {
+		m.occlusionTex.SetSRGB(false)
 		m.occlusionTex.SetUniformNames("uOcclusionSampler", "uOcclusionTexParams")
 		m.ShaderDefines.Set("HAS_OCCLUSIONMAP", "")
 		m.AddTexture(m.occlusionTex)
@@ -149,12 +154,13 @@ func (m *Physical) SetOcclusionMap(tex *texture.Texture2D) *Physical {
 	return m
 }
 
-// SetEmissiveMap sets this material optional emissive texture.
+// SetEmissiveMap sets this material optional emissive texture, which is made sRGB.
 // Returns pointer to this updated material.
 func (m *Physical) SetEmissiveMap(tex *texture.Texture2D) *Physical {
 
 	m.emissiveTex = tex
 	if m.emissiveTex != nil {
+		m.emissiveTex.SetSRGB(true)
 		m.emissiveTex.SetUniformNames("uEmissiveSampler", "uEmissiveTexParams")
 		m.ShaderDefines.Set("HAS_EMISSIVEMAP", "")
 		m.AddTexture(m.emissiveTex)
@@ -165,6 +171,81 @@ func (m *Physical) SetEmissiveMap(tex *texture.Texture2D) *Physical {
 	return m
 }
 
+// SetAlphaCutoff sets the base color opacity below which fragments are discarded,
+// rendering the material fully opaque or fully transparent as the alpha mask mode of glTF.
+// Its default value is 0, which disables the cutoff.
+// Returns pointer to this updated material.
+func (m *Physical) SetAlphaCutoff(cutoff float32) *Physical {
+
+	m.udata.alphaCutoff = cutoff
+	if cutoff > 0 {
+		m.ShaderDefines.Set("ALPHA_MASK", "")
+	} else {
+		m.ShaderDefines.Unset("ALPHA_MASK")
+	}
+	return m
+}
+
+// AlphaCutoff returns the base color opacity below which fragments are discarded.
+func (m *Physical) AlphaCutoff() float32 {
+
+	return m.udata.alphaCutoff
+}
+
+// BaseColorFactor returns this material base color.
+func (m *Physical) BaseColorFactor() math32.Color4 {
+
+	return m.udata.baseColorFactor
+}
+
+// MetallicFactor returns this material metallic factor.
+func (m *Physical) MetallicFactor() float32 {
+
+	return m.udata.metallicFactor
+}
+
+// RoughnessFactor returns this material roughness factor.
+func (m *Physical) RoughnessFactor() float32 {
+
+	return m.udata.roughnessFactor
+}
+
+// EmissiveFactor returns the emissive color of the material.
+func (m *Physical) EmissiveFactor() math32.Color {
+
+	return math32.Color{R: m.udata.emissiveFactor.R, G: m.udata.emissiveFactor.G, B: m.udata.emissiveFactor.B}
+}
+
+// BaseColorMap returns this material base color texture or nil.
+func (m *Physical) BaseColorMap() *texture.Texture2D {
+
+	return m.baseColorTex
+}
+
+// MetallicRoughnessMap returns this material metallic-roughness texture or nil.
+func (m *Physical) MetallicRoughnessMap() *texture.Texture2D {
+
+	return m.metallicRoughnessTex
+}
+
+// NormalMap returns this material normal texture or nil.
+func (m *Physical) NormalMap() *texture.Texture2D {
+
+	return m.normalTex
+}
+
+// OcclusionMap returns this material occlusion texture or nil.
+func (m *Physical) OcclusionMap() *texture.Texture2D {
+
+	return m.occlusionTex
+}
+
+// EmissiveMap returns this material emissive texture or nil.
+func (m *Physical) EmissiveMap() *texture.Texture2D {
+
+	return m.emissiveTex
+}
+
 // RenderSetup transfer this material uniforms and textures to the shader
 func (m *Physical) RenderSetup(gl *gls.GLS) {
 
@@ -0,0 +1,55 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/g3n/engine/math32"
+)
+
+// Registered material types by name
+var materialTypes = map[string]func() IMaterial{}
+
+// RegisterType registers (or replaces) a named material type with the function creating
+// its materials with default parameters, so they can be created by name, such as by
+// loaders and editors. The shaders of custom types are registered with the shaders package.
+func RegisterType(name string, factory func() IMaterial) {
+
+	materialTypes[name] = factory
+}
+
+// NewOfType creates and returns a material of the registered type with the specified name.
+func NewOfType(name string) (IMaterial, error) {
+
+	factory, ok := materialTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unregistered material type:%s", name)
+	}
+	return factory(), nil
+}
+
+// Types returns the sorted names of the registered material types.
+func Types() []string {
+
+	names := make([]string, 0, len(materialTypes))
+	for name := range materialTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+
+	white := math32.Color{R: 1, G: 1, B: 1}
+	RegisterType("basic", func() IMaterial { return NewBasic() })
+	RegisterType("standard", func() IMaterial { return NewStandard(&white) })
+	RegisterType("phong", func() IMaterial { return NewPhong(&white) })
+	RegisterType("physical", func() IMaterial { return NewPhysical() })
+	RegisterType("point", func() IMaterial { return NewPoint(&white) })
+	RegisterType("sdftext", func() IMaterial { return NewSDFText(&white) })
+}
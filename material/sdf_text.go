@@ -0,0 +1,40 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"github.com/g3n/engine/math32"
+)
+
+// SDFText material renders text with the signed distance fields of a text.SDFAtlas, whose image
+// must be added as the first texture. Edges are antialiased at any scale and the glyphs
+// can have an outline. It is transparent and double sided by default.
+type SDFText struct {
+	Standard // Embedded standard material
+}
+
+// NewSDFText creates and returns a pointer to a new text material with the specified color.
+func NewSDFText(color *math32.Color) *SDFText {
+
+	mt := new(SDFText)
+	mt.Standard.Init("sdftext", color)
+	mt.SetTransparent(true)
+	mt.SetSide(SideDouble)
+	return mt
+}
+
+// SetOutline sets the color and width of the outline of the glyphs. The width is a fraction
+// of the atlas spread, from 0, the default, which disables the outline, to 1.
+func (mt *SDFText) SetOutline(color *math32.Color, width float32) {
+
+	mt.udata.emissive = *color
+	mt.udata.psize = math32.Clamp(width, 0, 1) / 2
+}
+
+// Outline returns the color and width of the outline of the glyphs.
+func (mt *SDFText) Outline() (math32.Color, float32) {
+
+	return mt.udata.emissive, mt.udata.psize * 2
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package math32
+
+import (
+	"testing"
+)
+
+// Benchmarks of hot math paths, comparing the allocating and in-place variants.
+// Run with "go test -bench . -benchmem" to see the allocations per operation.
+
+func BenchmarkVector3SetFromQuaternion(b *testing.B) {
+
+	b.ReportAllocs()
+	var q Quaternion
+	q.SetFromAxisAngle(&Vector3{X: 0, Y: 1, Z: 0}, 0.5)
+	var v Vector3
+	for i := 0; i < b.N; i++ {
+		v.SetFromQuaternion(&q)
+	}
+}
+
+func BenchmarkVector3RandomTangents(b *testing.B) {
+
+	b.ReportAllocs()
+	v := Vector3{X: 0.3, Y: 0.8, Z: 0.1}
+	for i := 0; i < b.N; i++ {
+		v.RandomTangents()
+	}
+}
+
+func BenchmarkVector3Tangents(b *testing.B) {
+
+	b.ReportAllocs()
+	v := Vector3{X: 0.3, Y: 0.8, Z: 0.1}
+	var t1, t2 Vector3
+	for i := 0; i < b.N; i++ {
+		v.Tangents(&t1, &t2)
+	}
+}
+
+func BenchmarkVector3CloneChain(b *testing.B) {
+
+	b.ReportAllocs()
+	target := Vector3{X: 1, Y: 2, Z: 3}
+	offset := Vector3{X: 0, Y: 0, Z: 10}
+	var sink *Vector3
+	for i := 0; i < b.N; i++ {
+		sink = target.Clone().Add(&offset)
+	}
+	_ = sink
+}
+
+func BenchmarkVector3AddVectors(b *testing.B) {
+
+	b.ReportAllocs()
+	target := Vector3{X: 1, Y: 2, Z: 3}
+	offset := Vector3{X: 0, Y: 0, Z: 10}
+	var v Vector3
+	for i := 0; i < b.N; i++ {
+		v.AddVectors(&target, &offset)
+	}
+}
+
+func BenchmarkNewFrustumFromMatrix(b *testing.B) {
+
+	b.ReportAllocs()
+	var m Matrix4
+	m.MakePerspective(60, 1.5, 0.1, 100)
+	var sink *Frustum
+	for i := 0; i < b.N; i++ {
+		sink = NewFrustumFromMatrix(&m)
+	}
+	_ = sink
+}
+
+func BenchmarkFrustumSetFromMatrix(b *testing.B) {
+
+	b.ReportAllocs()
+	var m Matrix4
+	m.MakePerspective(60, 1.5, 0.1, 100)
+	var f Frustum
+	for i := 0; i < b.N; i++ {
+		f.SetFromMatrix(&m)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package math32
+
+// Colors picked in editors and stored in images are usually sRGB encoded, which spends
+// more precision on dark tones, while lighting and blending must be computed with linear
+// values proportional to light intensity. These functions convert between both encodings
+// with the exact piecewise sRGB transfer function.
+
+// SRGBToLinear returns the linear value of the specified sRGB encoded color component (0 to 1).
+func SRGBToLinear(v float32) float32 {
+
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return Pow((v+0.055)/1.055, 2.4)
+}
+
+// LinearToSRGB returns the sRGB encoded value of the specified linear color component (0 to 1).
+func LinearToSRGB(v float32) float32 {
+
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*Pow(v, 1/2.4) - 0.055
+}
+
+// ToLinear converts this color from sRGB encoding to linear values.
+// Returns the pointer to this updated color.
+func (c *Color) ToLinear() *Color {
+
+	c.R = SRGBToLinear(c.R)
+	c.G = SRGBToLinear(c.G)
+	c.B = SRGBToLinear(c.B)
+	return c
+}
+
+// ToSRGB converts this color from linear values to sRGB encoding.
+// Returns the pointer to this updated color.
+func (c *Color) ToSRGB() *Color {
+
+	c.R = LinearToSRGB(c.R)
+	c.G = LinearToSRGB(c.G)
+	c.B = LinearToSRGB(c.B)
+	return c
+}
+
+// ToLinear converts this color from sRGB encoding to linear values.
+// The alpha component, which is always linear, is not changed.
+// Returns the pointer to this updated color.
+func (c *Color4) ToLinear() *Color4 {
+
+	c.R = SRGBToLinear(c.R)
+	c.G = SRGBToLinear(c.G)
+	c.B = SRGBToLinear(c.B)
+	return c
+}
+
+// ToSRGB converts this color from linear values to sRGB encoding.
+// The alpha component, which is always linear, is not changed.
+// Returns the pointer to this updated color.
+func (c *Color4) ToSRGB() *Color4 {
+
+	c.R = LinearToSRGB(c.R)
+	c.G = LinearToSRGB(c.G)
+	c.B = LinearToSRGB(c.B)
+	return c
+}
+
+// SetHSV sets this color from the specified hue, saturation and value, all from 0 to 1.
+// The hue wraps around, so 0 and 1 are both red.
+// Returns the pointer to this updated color.
+func (c *Color) SetHSV(h, s, v float32) *Color {
+
+	h = (h - Floor(h)) * 6
+	f := h - Floor(h)
+	p := v * (1 - s)
+	q := v * (1 - s*f)
+	t := v * (1 - s*(1-f))
+	switch int(h) % 6 {
+	case 0:
+		return c.Set(v, t, p)
+	case 1:
+		return c.Set(q, v, p)
+	case 2:
+		return c.Set(p, v, t)
+	case 3:
+		return c.Set(p, q, v)
+	case 4:
+		return c.Set(t, p, v)
+	default:
+		return c.Set(v, p, q)
+	}
+}
+
+// HSV returns the hue, saturation and value of this color, all from 0 to 1.
+func (c *Color) HSV() (h, s, v float32) {
+
+	max, min, h := c.hue()
+	v = max
+	if max > 0 {
+		s = (max - min) / max
+	}
+	return h, s, v
+}
+
+// SetHSL sets this color from the specified hue, saturation and lightness, all from 0 to 1.
+// The hue wraps around, so 0 and 1 are both red.
+// Returns the pointer to this updated color.
+func (c *Color) SetHSL(h, s, l float32) *Color {
+
+	// Converts to the equivalent HSV color
+	v := l + s*Min(l, 1-l)
+	sv := float32(0)
+	if v > 0 {
+		sv = 2 * (1 - l/v)
+	}
+	return c.SetHSV(h, sv, v)
+}
+
+// HSL returns the hue, saturation and lightness of this color, all from 0 to 1.
+func (c *Color) HSL() (h, s, l float32) {
+
+	max, min, h := c.hue()
+	l = (max + min) / 2
+	if l > 0 && l < 1 {
+		s = (max - min) / (1 - Abs(2*l-1))
+	}
+	return h, s, l
+}
+
+// hue returns the largest and smallest components of this color and its hue from 0 to 1.
+func (c *Color) hue() (max, min, h float32) {
+
+	max = Max(c.R, Max(c.G, c.B))
+	min = Min(c.R, Min(c.G, c.B))
+	d := max - min
+	if d <= 0 {
+		return max, min, 0
+	}
+	switch max {
+	case c.R:
+		h = (c.G - c.B) / d
+		if h < 0 {
+			h += 6
+		}
+	case c.G:
+		h = (c.B-c.R)/d + 2
+	default:
+		h = (c.R-c.G)/d + 4
+	}
+	return max, min, h / 6
+}
+
+// SetKelvin sets this color to the sRGB color of a black body at the specified temperature
+// in Kelvin, from 1000 (candle light) to 40000 (blue sky), such as 2700 for an incandescent
+// bulb, 5500 for sunlight or 6500 for an overcast sky. The color is normalized so its largest
+// component is 1, to be scaled by the intensity of the light.
+// Returns the pointer to this updated color.
+func (c *Color) SetKelvin(kelvin float32) *Color {
+
+	// Curve fit of the black body colors by Tanner Helland, in units of 0 to 255
+	t := Clamp(kelvin, 1000, 40000) / 100
+	var r, g, b float32
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*Log(t-10) - 305.0447927307
+	}
+	return c.Set(Clamp(r, 0, 255)/255, Clamp(g, 0, 255)/255, Clamp(b, 0, 255)/255)
+}
+
+// Oklab returns the lightness (L) and the green-red (a) and blue-yellow (b) components of this
+// sRGB encoded color in the Oklab perceptual color space, where equal distances look like equal
+// color differences.
+func (c *Color) Oklab() (L, a, b float32) {
+
+	r := SRGBToLinear(c.R)
+	g := SRGBToLinear(c.G)
+	bl := SRGBToLinear(c.B)
+	lc := Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl)
+	mc := Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl)
+	sc := Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl)
+	L = 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc
+	a = 1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc
+	b = 0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+	return
+}
+
+// SetOklab sets this color to the sRGB encoded color with the specified Oklab components.
+// Colors outside of the sRGB gamut are clamped.
+// Returns the pointer to this updated color.
+func (c *Color) SetOklab(L, a, b float32) *Color {
+
+	lc := L + 0.3963377774*a + 0.2158037573*b
+	mc := L - 0.1055613458*a - 0.0638541728*b
+	sc := L - 0.0894841775*a - 1.2914855480*b
+	l := lc * lc * lc
+	m := mc * mc * mc
+	s := sc * sc * sc
+	c.R = LinearToSRGB(Clamp(4.0767416621*l-3.3077115913*m+0.2309699292*s, 0, 1))
+	c.G = LinearToSRGB(Clamp(-1.2684380046*l+2.6097574011*m-0.3413193965*s, 0, 1))
+	c.B = LinearToSRGB(Clamp(-0.0041960863*l-0.7034186147*m+1.7076147010*s, 0, 1))
+	return c
+}
+
+// LerpOklab sets this color as the interpolation of itself with the specified color for the
+// specified alpha in the Oklab color space. Unlike Lerp, the lightness changes evenly and
+// the intermediate colors do not turn gray or dark, which suits gradients and color ramps.
+// Returns pointer to this updated color
+func (c *Color) LerpOklab(color *Color, alpha float32) *Color {
+
+	l1, a1, b1 := c.Oklab()
+	l2, a2, b2 := color.Oklab()
+	return c.SetOklab(l1+(l2-l1)*alpha, a1+(a2-a1)*alpha, b1+(b2-b1)*alpha)
+}
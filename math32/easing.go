@@ -0,0 +1,65 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package math32
+
+// EasingFunc maps the linear progress of a transition, from 0 to 1, to its eased progress,
+// which also starts at 0 and ends at 1.
+type EasingFunc func(t float32) float32
+
+// EaseLinear does not ease.
+func EaseLinear(t float32) float32 {
+
+	return t
+}
+
+// EaseInQuad accelerates from zero velocity.
+func EaseInQuad(t float32) float32 {
+
+	return t * t
+}
+
+// EaseOutQuad decelerates to zero velocity.
+func EaseOutQuad(t float32) float32 {
+
+	return t * (2 - t)
+}
+
+// EaseInOutQuad accelerates until halfway and then decelerates.
+func EaseInOutQuad(t float32) float32 {
+
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+// EaseInCubic accelerates from zero velocity.
+func EaseInCubic(t float32) float32 {
+
+	return t * t * t
+}
+
+// EaseOutCubic decelerates to zero velocity.
+func EaseOutCubic(t float32) float32 {
+
+	t--
+	return t*t*t + 1
+}
+
+// EaseInOutCubic accelerates until halfway and then decelerates.
+func EaseInOutCubic(t float32) float32 {
+
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2*t - 2
+	return t*t*t/2 + 1
+}
+
+// EaseSmoothstep is the smoothstep polynomial, which starts and ends with zero velocity.
+func EaseSmoothstep(t float32) float32 {
+
+	return t * t * (3 - 2*t)
+}
@@ -4,15 +4,15 @@
 
 package math32
 
-// Frustum represents a frustum
+// Frustum represents a frustum.
+// The zero value can be set with SetFromMatrix without allocating.
 type Frustum struct {
-	planes []Plane
+	planes [6]Plane
 }
 
 // NewFrustumFromMatrix creates and returns a Frustum based on the provided matrix
 func NewFrustumFromMatrix(m *Matrix4) *Frustum {
 	f := new(Frustum)
-	f.planes = make([]Plane, 6)
 	f.SetFromMatrix(m)
 	return f
 }
@@ -21,7 +21,6 @@ func NewFrustumFromMatrix(m *Matrix4) *Frustum {
 func NewFrustum(p0, p1, p2, p3, p4, p5 *Plane) *Frustum {
 
 	f := new(Frustum)
-	f.planes = make([]Plane, 6)
 	f.Set(p0, p1, p2, p3, p4, p5)
 	return f
 }
@@ -62,7 +61,7 @@ func (f *Frustum) Copy(frustum *Frustum) *Frustum {
 // SetFromMatrix sets the frustum's planes based on the specified Matrix4
 func (f *Frustum) SetFromMatrix(m *Matrix4) *Frustum {
 
-	planes := f.planes
+	planes := &f.planes
 	me0 := m[0]
 	me1 := m[1]
 	me2 := m[2]
@@ -102,7 +101,7 @@ func (this *Frustum) IntersectsObject(geometry *core.Geometry) bool {
 // IntersectsSphere determines whether the specified sphere is intersecting the frustum
 func (f *Frustum) IntersectsSphere(sphere *Sphere) bool {
 
-	planes := f.planes
+	planes := &f.planes
 	negRadius := -sphere.Radius
 
 	for i := 0; i < 6; i++ {
@@ -78,10 +78,18 @@ func Ceil(v float32) float32 {
 	return float32(math.Ceil(float64(v)))
 }
 
+func Cbrt(v float32) float32 {
+	return float32(math.Cbrt(float64(v)))
+}
+
 func Cos(v float32) float32 {
 	return float32(math.Cos(float64(v)))
 }
 
+func Exp(v float32) float32 {
+	return float32(math.Exp(float64(v)))
+}
+
 func Floor(v float32) float32 {
 	return float32(math.Floor(float64(v)))
 }
@@ -106,6 +114,10 @@ func Sqrt(v float32) float32 {
 	return float32(math.Sqrt(float64(v)))
 }
 
+func Log(v float32) float32 {
+	return float32(math.Log(float64(v)))
+}
+
 func Max(a, b float32) float32 {
 	return float32(math.Max(float64(a), float64(b)))
 }
@@ -0,0 +1,34 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package math32
+
+// Noise1 returns the one dimensional Perlin gradient noise value at x
+// for the specified seed. The result is smooth, between -1 and 1,
+// and zero at integer values of x.
+func Noise1(x float32, seed uint32) float32 {
+
+	i := Floor(x)
+	f := x - i
+	i0 := int32(i)
+	g0 := gradient(uint32(i0), seed)
+	g1 := gradient(uint32(i0+1), seed)
+	// Quintic fade curve
+	u := f * f * f * (f*(f*6-15) + 10)
+	n0 := g0 * f
+	n1 := g1 * (f - 1)
+	return 2 * (n0 + (n1-n0)*u)
+}
+
+// gradient returns a pseudo random gradient between -1 and 1 for the specified lattice point and seed.
+func gradient(i, seed uint32) float32 {
+
+	h := i*0x27d4eb2d ^ seed*0x165667b1
+	h ^= h >> 15
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return float32(h)/float32(0xffffffff)*2 - 1
+}
@@ -0,0 +1,149 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package math32
+
+import (
+	"sort"
+	"strings"
+)
+
+// Palette is a list of distinct colors for categorical data, such as the series of a chart
+// or the labels of a segmentation, shared by the gui themes and visualization code.
+type Palette []Color
+
+// At returns the color of the palette for the specified index, repeating the palette
+// if there are more categories than colors. Returns black for an empty palette.
+func (p Palette) At(i int) Color {
+
+	if len(p) == 0 {
+		return Color{}
+	}
+	i %= len(p)
+	if i < 0 {
+		i += len(p)
+	}
+	return p[i]
+}
+
+// Colormap maps values from 0 to 1 to colors interpolated between evenly spaced stops,
+// for continuous data such as heat maps, scalar fields and depth visualization.
+type Colormap []Color
+
+// At returns the color of the colormap for the specified value from 0 to 1.
+// Values outside of this range are clamped. Returns black for an empty colormap.
+func (cm Colormap) At(t float32) Color {
+
+	if len(cm) == 0 {
+		return Color{}
+	}
+	if len(cm) == 1 || t <= 0 || IsNaN(t) {
+		return cm[0]
+	}
+	if t >= 1 {
+		return cm[len(cm)-1]
+	}
+	f := t * float32(len(cm)-1)
+	i := int(f)
+	c := cm[i]
+	return *c.Lerp(&cm[i+1], f-float32(i))
+}
+
+// AtRange returns the color of the colormap for the specified value in the range from min to max.
+func (cm Colormap) AtRange(v, min, max float32) Color {
+
+	if max == min {
+		return cm.At(0)
+	}
+	return cm.At((v - min) / (max - min))
+}
+
+// Reversed returns a new colormap with the stops of this one in reverse order.
+func (cm Colormap) Reversed() Colormap {
+
+	rev := make(Colormap, len(cm))
+	for i, c := range cm {
+		rev[len(cm)-1-i] = c
+	}
+	return rev
+}
+
+// PaletteName returns the palette with the specified name (case insensitive) and whether it exists.
+// The palette is shared and must not be modified.
+func PaletteName(name string) (Palette, bool) {
+
+	p, ok := mapPalettes[strings.ToLower(name)]
+	return p, ok
+}
+
+// PaletteNames returns the sorted names of the available palettes.
+func PaletteNames() []string {
+
+	names := make([]string, 0, len(mapPalettes))
+	for name := range mapPalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ColormapName returns the colormap with the specified name (case insensitive) and whether it exists.
+// The colormap is shared and must not be modified.
+func ColormapName(name string) (Colormap, bool) {
+
+	cm, ok := mapColormaps[strings.ToLower(name)]
+	return cm, ok
+}
+
+// ColormapNames returns the sorted names of the available colormaps.
+func ColormapNames() []string {
+
+	names := make([]string, 0, len(mapColormaps))
+	for name := range mapColormaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colorsHex returns the colors for the specified hex values.
+func colorsHex(values ...uint) []Color {
+
+	colors := make([]Color, len(values))
+	for i, v := range values {
+		colors[i].SetHex(v)
+	}
+	return colors
+}
+
+// mapPalettes maps names to the categorical palettes
+var mapPalettes = map[string]Palette{
+	// Default categorical palette of matplotlib and d3
+	"category10": colorsHex(0x1f77b4, 0xff7f0e, 0x2ca02c, 0xd62728, 0x9467bd,
+		0x8c564b, 0xe377c2, 0x7f7f7f, 0xbcbd22, 0x17becf),
+	// Palette distinguishable with color vision deficiencies, by Okabe and Ito
+	"okabeito": colorsHex(0xe69f00, 0x56b4e9, 0x009e73, 0xf0e442, 0x0072b2,
+		0xd55e00, 0xcc79a7, 0x000000),
+	// ColorBrewer palettes
+	"dark2": colorsHex(0x1b9e77, 0xd95f02, 0x7570b3, 0xe7298a, 0x66a61e,
+		0xe6ab02, 0xa6761d, 0x666666),
+	"pastel1": colorsHex(0xfbb4ae, 0xb3cde3, 0xccebc5, 0xdecbe4, 0xfed9a6,
+		0xffffcc, 0xe5d8bd, 0xfddaec, 0xf2f2f2),
+}
+
+// mapColormaps maps names to the continuous colormaps
+var mapColormaps = map[string]Colormap{
+	// Perceptually uniform colormaps of matplotlib, sampled at 10 stops
+	"viridis": colorsHex(0x440154, 0x482878, 0x3e4a89, 0x31688e, 0x26828e,
+		0x1f9e89, 0x35b779, 0x6dcd59, 0xb4de2c, 0xfde725),
+	"magma": colorsHex(0x000004, 0x180f3e, 0x451077, 0x721f81, 0x9f2f7f,
+		0xcd4071, 0xf1605d, 0xfd9567, 0xfec98d, 0xfcfdbf),
+	"inferno": colorsHex(0x000004, 0x1b0c42, 0x4b0c6b, 0x781c6d, 0xa52c60,
+		0xcf4446, 0xed6925, 0xfb9a06, 0xf7d03c, 0xfcffa4),
+	"plasma": colorsHex(0x0d0887, 0x47039f, 0x7301a8, 0x9c179e, 0xbd3786,
+		0xd8576b, 0xed7953, 0xfa9e3b, 0xfdc926, 0xf0f921),
+	// Diverging colormap by Kenneth Moreland
+	"coolwarm":  colorsHex(0x3b4cc0, 0x7b9ff9, 0xc0d4f5, 0xf2cbb7, 0xee8468, 0xb40426),
+	"grayscale": colorsHex(0x000000, 0xffffff),
+}
@@ -4,27 +4,341 @@
 
 package math32
 
-import (
-//"math"
+// SplineType is the kind of curve of a Spline.
+type SplineType int
+
+// The kinds of spline curves.
+const (
+	// CatmullRom is a centripetal Catmull-Rom curve passing through all the points,
+	// which does not form cusps or self intersections within a segment.
+	CatmullRom = SplineType(iota)
+	// Bezier is a sequence of cubic Bezier curves whose points are an anchor followed by two
+	// control points for each segment and a final anchor, so the curve passes through every
+	// third point. Closed Bezier splines end at the first anchor and have no final anchor.
+	Bezier
 )
 
+// Number of samples of each segment of the arc length table
+const splineSamples = 16
+
+// Spline is a smooth 3D curve through or near a list of points, such as the path of a camera,
+// a road or a pipe. The curve parameter t goes from 0 to 1 along the whole curve, divided
+// evenly between its segments, so it does not move at constant speed; the arc length
+// methods map distances along the curve to parameters.
 type Spline struct {
-	points []Vector3
+	points  []Vector3  // Points of the curve
+	kind    SplineType // Kind of curve
+	closed  bool       // Whether the curve is a loop
+	lengths []float32  // Cumulative arc lengths at evenly spaced parameters, computed when needed
 }
 
+// NewSpline creates and returns a pointer to a new open centripetal Catmull-Rom spline
+// passing through a copy of the specified points.
 func NewSpline(points []Vector3) *Spline {
 
-	this := new(Spline)
-	this.points = make([]Vector3, len(points))
-	copy(this.points, points)
-	return this
+	s := new(Spline)
+	s.kind = CatmullRom
+	s.SetPoints(points)
+	return s
+}
+
+// NewBezierSpline creates and returns a pointer to a new open cubic Bezier spline with a copy
+// of the specified anchors and control points. Extra points which do not complete a segment
+// are ignored.
+func NewBezierSpline(points []Vector3) *Spline {
+
+	s := new(Spline)
+	s.kind = Bezier
+	s.SetPoints(points)
+	return s
+}
+
+// InitFromArray sets the points of the spline from an array of X, Y, Z coordinates.
+func (s *Spline) InitFromArray(a []float32) {
+
+	points := make([]Vector3, len(a)/3)
+	for i := range points {
+		points[i].FromArray(a, 3*i)
+	}
+	s.points = points
+	s.lengths = nil
+}
+
+// SetPoints sets the points of the spline to a copy of the specified points.
+func (s *Spline) SetPoints(points []Vector3) {
+
+	s.points = append(s.points[:0:0], points...)
+	s.lengths = nil
+}
+
+// Points returns the points of the spline, which should not be modified.
+func (s *Spline) Points() []Vector3 {
+
+	return s.points
+}
+
+// Type returns the kind of curve of the spline.
+func (s *Spline) Type() SplineType {
+
+	return s.kind
+}
+
+// SetClosed sets whether the spline is a loop from its last point back to its first.
+func (s *Spline) SetClosed(closed bool) {
+
+	s.closed = closed
+	s.lengths = nil
+}
+
+// Closed returns whether the spline is a loop.
+func (s *Spline) Closed() bool {
+
+	return s.closed
+}
+
+// Segments returns the number of segments of the curve.
+func (s *Spline) Segments() int {
+
+	n := len(s.points)
+	if s.kind == Bezier {
+		if s.closed {
+			return n / 3
+		}
+		if n < 4 {
+			return 0
+		}
+		return (n - 1) / 3
+	}
+	if n < 2 {
+		return 0
+	}
+	if s.closed {
+		return n
+	}
+	return n - 1
+}
+
+// Knots returns the number of points the curve passes through, which are the points of a
+// Catmull-Rom spline and the anchors of a Bezier spline. Knot i is at the parameter KnotParam(i).
+func (s *Spline) Knots() int {
+
+	if s.closed {
+		return s.Segments()
+	}
+	if s.Segments() == 0 {
+		return 0
+	}
+	return s.Segments() + 1
+}
+
+// KnotParam returns the curve parameter of the knot with the specified index.
+func (s *Spline) KnotParam(i int) float32 {
+
+	segs := s.Segments()
+	if segs == 0 {
+		return 0
+	}
+	return float32(i) / float32(segs)
+}
+
+// Point returns the point of the curve at the specified parameter, from 0 to 1.
+func (s *Spline) Point(t float32, optionalTarget *Vector3) *Vector3 {
+
+	var result *Vector3
+	if optionalTarget == nil {
+		result = NewVec3()
+	} else {
+		result = optionalTarget
+	}
+	var b [4]Vector3
+	seg, u := s.segment(t)
+	if seg < 0 {
+		if len(s.points) > 0 {
+			return result.Copy(&s.points[0])
+		}
+		return result.Zero()
+	}
+	s.controls(seg, &b)
+	v := 1 - u
+	b0, b1, b2, b3 := v*v*v, 3*v*v*u, 3*v*u*u, u*u*u
+	return result.Set(
+		b0*b[0].X+b1*b[1].X+b2*b[2].X+b3*b[3].X,
+		b0*b[0].Y+b1*b[1].Y+b2*b[2].Y+b3*b[3].Y,
+		b0*b[0].Z+b1*b[1].Z+b2*b[2].Z+b3*b[3].Z,
+	)
+}
+
+// Tangent returns the unit direction of the curve at the specified parameter, from 0 to 1.
+func (s *Spline) Tangent(t float32, optionalTarget *Vector3) *Vector3 {
+
+	var result *Vector3
+	if optionalTarget == nil {
+		result = NewVec3()
+	} else {
+		result = optionalTarget
+	}
+	var b [4]Vector3
+	seg, u := s.segment(t)
+	if seg < 0 {
+		return result.Zero()
+	}
+	s.controls(seg, &b)
+	v := 1 - u
+	d0, d1, d2 := v*v, 2*v*u, u*u
+	result.Set(
+		d0*(b[1].X-b[0].X)+d1*(b[2].X-b[1].X)+d2*(b[3].X-b[2].X),
+		d0*(b[1].Y-b[0].Y)+d1*(b[2].Y-b[1].Y)+d2*(b[3].Y-b[2].Y),
+		d0*(b[1].Z-b[0].Z)+d1*(b[2].Z-b[1].Z)+d2*(b[3].Z-b[2].Z),
+	)
+	if result.LengthSq() == 0 {
+		// Coincident control points at the ends of a segment
+		result.SubVectors(&b[3], &b[0])
+	}
+	return result.Normalize()
+}
+
+// Length returns the approximate length of the curve.
+func (s *Spline) Length() float32 {
+
+	s.updateLengths()
+	return s.lengths[len(s.lengths)-1]
+}
+
+// ParamAtDistance returns the curve parameter at the specified distance along the curve,
+// clamped to the length of the curve, or wrapped around for closed curves.
+func (s *Spline) ParamAtDistance(distance float32) float32 {
+
+	s.updateLengths()
+	n := len(s.lengths) - 1
+	length := s.lengths[n]
+	if n == 0 || length == 0 {
+		return 0
+	}
+	if s.closed {
+		distance = Mod(distance, length)
+		if distance < 0 {
+			distance += length
+		}
+	}
+	distance = Clamp(distance, 0, length)
+
+	// Binary search of the sample interval with the distance
+	lo, hi := 0, n
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if s.lengths[mid] < distance {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	span := s.lengths[hi] - s.lengths[lo]
+	frac := float32(0)
+	if span > 0 {
+		frac = (distance - s.lengths[lo]) / span
+	}
+	return (float32(lo) + frac) / float32(n)
+}
+
+// DistanceAtParam returns the distance along the curve at the specified parameter, from 0 to 1.
+func (s *Spline) DistanceAtParam(t float32) float32 {
+
+	s.updateLengths()
+	n := len(s.lengths) - 1
+	x := Clamp(t, 0, 1) * float32(n)
+	i := int(x)
+	if i >= n {
+		return s.lengths[n]
+	}
+	return s.lengths[i] + (x-float32(i))*(s.lengths[i+1]-s.lengths[i])
+}
+
+// PointAtDistance returns the point at the specified distance along the curve.
+func (s *Spline) PointAtDistance(distance float32, optionalTarget *Vector3) *Vector3 {
+
+	return s.Point(s.ParamAtDistance(distance), optionalTarget)
+}
+
+// segment returns the index of the segment at the specified curve parameter and
+// the local parameter within the segment, or -1 if the curve has no segments.
+func (s *Spline) segment(t float32) (int, float32) {
+
+	segs := s.Segments()
+	if segs == 0 {
+		return -1, 0
+	}
+	if s.closed {
+		t -= Floor(t)
+	}
+	x := Clamp(t, 0, 1) * float32(segs)
+	seg := int(x)
+	if seg >= segs {
+		return segs - 1, 1
+	}
+	return seg, x - float32(seg)
+}
+
+// controls sets the Bezier control points of the segment with the specified index.
+func (s *Spline) controls(seg int, b *[4]Vector3) {
+
+	n := len(s.points)
+	if s.kind == Bezier {
+		for i := 0; i < 4; i++ {
+			b[i] = s.points[(3*seg+i)%n]
+		}
+		return
+	}
+
+	// Centripetal Catmull-Rom segment between p1 and p2, converted to its Bezier form.
+	// The missing neighbors at the ends of open curves are reflections.
+	p1 := s.points[seg]
+	p2 := s.points[(seg+1)%n]
+	var p0, p3 Vector3
+	if seg > 0 || s.closed {
+		p0 = s.points[(seg-1+n)%n]
+	} else {
+		p0.Copy(&p1).MultiplyScalar(2).Sub(&p2)
+	}
+	if seg+2 < n || s.closed {
+		p3 = s.points[(seg+2)%n]
+	} else {
+		p3.Copy(&p2).MultiplyScalar(2).Sub(&p1)
+	}
+	const eps = 1e-4
+	d01 := Max(Sqrt(p0.DistanceTo(&p1)), eps)
+	d12 := Max(Sqrt(p1.DistanceTo(&p2)), eps)
+	d23 := Max(Sqrt(p2.DistanceTo(&p3)), eps)
+
+	// Tangents of the segment scaled to its parameter interval
+	var m1, m2, tmp Vector3
+	m1.SubVectors(&p1, &p0).MultiplyScalar(1 / d01)
+	m1.Sub(tmp.SubVectors(&p2, &p0).MultiplyScalar(1 / (d01 + d12)))
+	m1.Add(tmp.SubVectors(&p2, &p1).MultiplyScalar(1 / d12))
+	m1.MultiplyScalar(d12)
+	m2.SubVectors(&p2, &p1).MultiplyScalar(1 / d12)
+	m2.Sub(tmp.SubVectors(&p3, &p1).MultiplyScalar(1 / (d12 + d23)))
+	m2.Add(tmp.SubVectors(&p3, &p2).MultiplyScalar(1 / d23))
+	m2.MultiplyScalar(d12)
+
+	b[0] = p1
+	b[1].Copy(&m1).MultiplyScalar(1.0 / 3).Add(&p1)
+	b[2].Copy(&m2).MultiplyScalar(-1.0 / 3).Add(&p2)
+	b[3] = p2
 }
 
-func (this *Spline) InitFromArray(a []float32) {
+// updateLengths computes the arc length table if necessary.
+func (s *Spline) updateLengths() {
 
-	// PEND array of what ?
-	//this.points = [];
-	//for ( var i = 0; i < a.length; i ++ ) {
-	//    this.points[ i ] = { x: a[ i ][ 0 ], y: a[ i ][ 1 ], z: a[ i ][ 2 ] };
-	//}
+	if s.lengths != nil {
+		return
+	}
+	n := s.Segments() * splineSamples
+	s.lengths = make([]float32, n+1)
+	var prev, p Vector3
+	s.Point(0, &prev)
+	for i := 1; i <= n; i++ {
+		s.Point(float32(i)/float32(n), &p)
+		s.lengths[i] = s.lengths[i-1] + p.DistanceTo(&prev)
+		prev = p
+	}
 }
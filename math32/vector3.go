@@ -633,38 +633,42 @@ func (v *Vector3) SetFromRotationMatrix(m *Matrix4) *Vector3 {
 // Returns the pointer to this updated vector.
 func (v *Vector3) SetFromQuaternion(q *Quaternion) *Vector3 {
 
-	matrix := NewMatrix4()
+	var matrix Matrix4
 	matrix.MakeRotationFromQuaternion(q)
-	v.SetFromRotationMatrix(matrix)
+	v.SetFromRotationMatrix(&matrix)
 	return v
 }
 
 // RandomTangents computes and returns two arbitrary tangents to the vector.
 func (v *Vector3) RandomTangents() (*Vector3, *Vector3) {
 
-	t1 := NewVector3(0,0,0)
-	t2 := NewVector3(0,0,0)
+	t1 := NewVec3()
+	t2 := NewVec3()
+	v.Tangents(t1, t2)
+	return t1, t2
+}
+
+// Tangents sets t1 and t2 to two arbitrary tangents to the vector, perpendicular
+// to each other, without allocating.
+func (v *Vector3) Tangents(t1, t2 *Vector3) {
+
 	length := v.Length()
 	if length > 0 {
-		n := NewVector3(v.X/length, v.Y/length, v.Z/length)
-		randVec := NewVector3(0,0,0)
+		n := Vector3{X: v.X / length, Y: v.Y / length, Z: v.Z / length}
+		var randVec Vector3
 		if Abs(n.X) < 0.9 {
 			randVec.SetX(1)
-			t1.CrossVectors(n, randVec)
 		} else if Abs(n.Y) < 0.9 {
 			randVec.SetY(1)
-			t1.CrossVectors(n, randVec)
 		} else {
 			randVec.SetZ(1)
-			t1.CrossVectors(n, randVec)
 		}
-		t2.CrossVectors(n, t1)
+		t1.CrossVectors(&n, &randVec)
+		t2.CrossVectors(&n, t1)
 	} else {
-		t1.SetX(1)
-		t2.SetY(1)
+		t1.Set(1, 0, 0)
+		t2.Set(0, 1, 0)
 	}
-
-	return t1, t2
 }
 
 // TODO: implement similar methods for Vector2 and Vector4
@@ -0,0 +1,301 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+)
+
+// Command is a recorded rendering command executed by the render thread.
+type Command func(gs *gls.GLS) error
+
+// CommandBuffer is a list of rendering commands recorded for a frame.
+// It is filled by the simulation goroutine and executed by a RenderThread.
+type CommandBuffer struct {
+	cmds     []Command // Recorded commands
+	releases []func()  // Called once the commands are executed or discarded
+}
+
+// Add appends the specified command to the buffer.
+func (cb *CommandBuffer) Add(cmd Command) {
+
+	cb.cmds = append(cb.cmds, cmd)
+}
+
+// Len returns the number of commands in the buffer.
+func (cb *CommandBuffer) Len() int {
+
+	return len(cb.cmds)
+}
+
+// OnRelease adds a function called once the commands of the buffer are executed,
+// or discarded by Reset without being executed. It releases the resources held by the
+// recorded commands, such as the frames recorded by a renderer.
+func (cb *CommandBuffer) OnRelease(f func()) {
+
+	cb.releases = append(cb.releases, f)
+}
+
+// Reset removes all the commands from the buffer keeping its storage.
+// Commands which were not executed are discarded, releasing their resources,
+// so a recorded buffer which will not be executed must be reset.
+func (cb *CommandBuffer) Reset() {
+
+	cb.release()
+	for i := range cb.cmds {
+		cb.cmds[i] = nil
+	}
+	cb.cmds = cb.cmds[:0]
+}
+
+// release calls and removes the release functions of the buffer.
+func (cb *CommandBuffer) release() {
+
+	for i, f := range cb.releases {
+		f()
+		cb.releases[i] = nil
+	}
+	cb.releases = cb.releases[:0]
+}
+
+// Clear appends a command which clears the specified buffers (gls.COLOR_BUFFER_BIT etc).
+func (cb *CommandBuffer) Clear(mask uint) {
+
+	cb.Add(func(gs *gls.GLS) error {
+		gs.Clear(mask)
+		return nil
+	})
+}
+
+// Viewport appends a command which sets the viewport.
+func (cb *CommandBuffer) Viewport(x, y, width, height int32) {
+
+	cb.Add(func(gs *gls.GLS) error {
+		gs.Viewport(x, y, width, height)
+		return nil
+	})
+}
+
+// Execute executes all the commands of the buffer in order and returns the first error.
+// The resources of the commands are then released.
+// It must be called by the goroutine owning the OpenGL context.
+func (cb *CommandBuffer) Execute(gs *gls.GLS) error {
+
+	var first error
+	for _, cmd := range cb.cmds {
+		if err := cmd(gs); err != nil && first == nil {
+			first = err
+		}
+	}
+	cb.release()
+	return first
+}
+
+// SetFramesInFlight sets the maximum number of frames recorded by Record which are waiting
+// for execution or executing, usually the number of frames in flight of the render thread.
+// The default is one frame. It must not be called while recorded frames are in flight.
+func (r *Renderer) SetFramesInFlight(n int) {
+
+	if n < 1 {
+		n = 1
+	}
+	r.frames = make(chan *frame, n)
+	for i := 0; i < n; i++ {
+		r.frames <- new(frame)
+	}
+}
+
+// FramesInFlight returns the maximum number of recorded frames in flight.
+func (r *Renderer) FramesInFlight() int {
+
+	if r.frames == nil {
+		return 1
+	}
+	return cap(r.frames)
+}
+
+// Record traverses, culls and sorts the specified scene on the calling goroutine and
+// appends to the buffer a command which renders it, so the OpenGL submission can be
+// executed later by a render thread.
+//
+// The lists of the rendered objects and the model matrices of the graphics are saved with
+// the frame, so the transforms of meshes and other plain graphics can be changed as soon as
+// Record returns. The rest of the scene is read when the frame is executed: materials,
+// geometries and textures, lights, sprites, rigged meshes and their bones, GUI panels and the
+// other rendered nodes, such as audio players. These must not be modified while frames
+// recorded from them are in flight, for example by applying their changes between
+// RenderThread.Wait and Record.
+//
+// If the maximum number of frames recorded by this renderer are in flight (see SetFramesInFlight),
+// Record waits until one of them is executed or discarded by resetting its buffer.
+func (r *Renderer) Record(cb *CommandBuffer, scene core.INode, cam camera.ICamera) {
+
+	if r.frames == nil {
+		r.SetFramesInFlight(1)
+	}
+	frames := r.frames
+	f := <-frames
+	r.frame = f
+	r.prepare(scene, cam)
+	r.frame = &r.immediate
+	cb.Add(func(gs *gls.GLS) error {
+		return r.submit(f)
+	})
+	cb.OnRelease(func() { frames <- f })
+}
+
+// RenderThread is a goroutine dedicated to the execution of command buffers.
+// The OpenGL context must be made current on it, and only it may issue OpenGL commands
+// while it runs. Up to the specified number of recorded frames can wait for execution,
+// so with two frames in flight a renderer records a third frame while the render
+// thread executes the first one (triple buffering), if the renderer allows as many frames
+// in flight (see Renderer.SetFramesInFlight).
+//
+// A typical frame loop using a render thread:
+//
+//	rt := renderer.NewRenderThread(gs, 2, func() { win.MakeContextCurrent() })
+//	rend.SetFramesInFlight(2)
+//	for !win.ShouldClose() {
+//		update(dt) // moves meshes while previous frames are executed (see Renderer.Record)
+//		cb := rt.Buffer()
+//		rend.Record(cb, scene, cam)
+//		cb.Add(func(gs *gls.GLS) error { win.SwapBuffers(); return nil })
+//		rt.Submit(cb)
+//		win.PollEvents()
+//	}
+//	rt.Stop()
+type RenderThread struct {
+	gs     *gls.GLS            // Reference to OpenGL state
+	frames chan *CommandBuffer // Frames waiting for execution
+	calls  chan func()         // Synchronous calls
+	free   chan *CommandBuffer // Executed buffers ready to be recorded again
+	done   chan struct{}       // Closed when the thread stops
+	mutex  sync.Mutex          // Protects err and queued
+	err    error               // First error returned by a command
+	idle   *sync.Cond          // Signaled when a frame is executed
+	queued int                 // Number of frames submitted and not yet executed
+}
+
+// NewRenderThread starts and returns a pointer to a new render thread which executes at most
+// the specified number of frames ahead of the simulation. The specified function is called
+// on the new thread before any command, to make the OpenGL context current on it; the
+// caller must have released the context from its own thread before.
+func NewRenderThread(gs *gls.GLS, framesInFlight int, makeCurrent func()) *RenderThread {
+
+	if framesInFlight < 1 {
+		framesInFlight = 1
+	}
+	rt := new(RenderThread)
+	rt.gs = gs
+	rt.frames = make(chan *CommandBuffer, framesInFlight)
+	rt.calls = make(chan func())
+	rt.free = make(chan *CommandBuffer, framesInFlight+1)
+	rt.done = make(chan struct{})
+	rt.idle = sync.NewCond(&rt.mutex)
+	for i := 0; i < framesInFlight+1; i++ {
+		rt.free <- new(CommandBuffer)
+	}
+	go rt.run(makeCurrent)
+	return rt
+}
+
+// Buffer returns an empty command buffer to record the next frame,
+// waiting for the render thread to execute a frame if all buffers are in use.
+func (rt *RenderThread) Buffer() *CommandBuffer {
+
+	return <-rt.free
+}
+
+// Discard resets the specified command buffer, obtained by Buffer, without executing it,
+// releasing the resources of its commands, and makes it available to record a next frame.
+func (rt *RenderThread) Discard(cb *CommandBuffer) {
+
+	cb.Reset()
+	rt.free <- cb
+}
+
+// Submit queues the specified command buffer for execution.
+// It blocks while the maximum number of frames is already waiting.
+func (rt *RenderThread) Submit(cb *CommandBuffer) {
+
+	rt.mutex.Lock()
+	rt.queued++
+	rt.mutex.Unlock()
+	rt.frames <- cb
+}
+
+// Call executes the specified function on the render thread, between frames,
+// and waits for it to return. It is used to create or destroy OpenGL resources.
+func (rt *RenderThread) Call(f func(gs *gls.GLS)) {
+
+	wait := make(chan struct{})
+	rt.calls <- func() {
+		f(rt.gs)
+		close(wait)
+	}
+	<-wait
+}
+
+// Wait waits until all the submitted frames are executed and returns
+// the first error returned by a command, if any, clearing it.
+func (rt *RenderThread) Wait() error {
+
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+	for rt.queued > 0 {
+		rt.idle.Wait()
+	}
+	err := rt.err
+	rt.err = nil
+	return err
+}
+
+// Stop waits until all the submitted frames are executed and stops the render thread.
+// The OpenGL context remains current on the stopped thread's OS thread until it is
+// released by a command, so it is usually released with a last Call.
+func (rt *RenderThread) Stop() error {
+
+	err := rt.Wait()
+	close(rt.frames)
+	<-rt.done
+	return err
+}
+
+// run is the body of the render thread.
+func (rt *RenderThread) run(makeCurrent func()) {
+
+	// OpenGL contexts are bound to OS threads
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(rt.done)
+	if makeCurrent != nil {
+		makeCurrent()
+	}
+	for {
+		select {
+		case f := <-rt.calls:
+			f()
+		case cb, ok := <-rt.frames:
+			if !ok {
+				return
+			}
+			err := cb.Execute(rt.gs)
+			cb.Reset()
+			rt.free <- cb
+			rt.mutex.Lock()
+			if err != nil && rt.err == nil {
+				rt.err = err
+			}
+			rt.queued--
+			rt.idle.Broadcast()
+			rt.mutex.Unlock()
+		}
+	}
+}
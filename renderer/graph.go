@@ -0,0 +1,528 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/texture"
+)
+
+// Backbuffer is the name of the render graph resource representing the window framebuffer.
+// Passes writing to it are never culled.
+const Backbuffer = "backbuffer"
+
+// TargetDesc describes a transient render target of a render graph.
+type TargetDesc struct {
+	Width  int     // Width in pixels (0 to use Scale)
+	Height int     // Height in pixels (0 to use Scale)
+	Scale  float32 // Size relative to the viewport when Width or Height is 0 (0 means 1)
+	Format int32   // Internal format of the color texture (0 means gls.RGBA8, stored as sRGB by the linear color pipeline)
+	Depth  bool    // Whether the target has a depth/stencil buffer
+}
+
+// RenderGraph schedules a set of render passes which declare the resources they read and write.
+// Each frame, Execute orders the passes so that every pass runs after the passes writing the
+// resources it reads, culls the passes whose outputs are not used by the backbuffer or by a pass
+// with side effects, and allocates the transient render targets only for the passes using them,
+// reusing the targets of resources which are no longer needed in the same frame.
+// This allows passes such as shadows, probes and post-processing effects to be composed
+// without the renderer knowing about them.
+// The graph is opt-in: Renderer.Render does not route its built-in passes through it,
+// and applications execute a graph alongside or instead of it.
+type RenderGraph struct {
+	gs        *gls.GLS                  // Reference to OpenGL state
+	resources map[string]*graphResource // Resources by name
+	passes    []*RenderPass             // Passes in declaration order
+	order     []*RenderPass             // Live passes in execution order
+	compiled  bool                      // Whether order is up to date
+	pool      []*graphTarget            // Allocated render targets
+}
+
+// RenderPass is a pass of a render graph.
+type RenderPass struct {
+	name       string                   // Pass name
+	reads      []string                 // Names of the resources read
+	write      string                   // Name of the render target written or empty
+	sideEffect bool                     // Whether the pass is never culled
+	exec       func(*PassContext) error // Function which executes the pass
+	live       bool                     // Whether the pass was not culled
+}
+
+// PassBuilder is used by the setup function of a pass to declare its inputs and outputs.
+type PassBuilder struct {
+	pass *RenderPass
+}
+
+// PassContext is passed to the function executing a pass.
+// The render target written by the pass is bound and the viewport covers it.
+type PassContext struct {
+	GS     *gls.GLS    // Reference to OpenGL state
+	Pass   *RenderPass // Pass being executed
+	Width  int         // Width of the render target in pixels
+	Height int         // Height of the render target in pixels
+	graph  *RenderGraph
+}
+
+// graphResource is a resource of a render graph.
+type graphResource struct {
+	desc     TargetDesc         // Description of transient targets
+	imported *texture.Texture2D // External texture (read only) or nil
+	target   *graphTarget       // Target allocated during execution
+	first    int                // Index of the first live pass using the resource
+	last     int                // Index of the last live pass using the resource
+}
+
+// graphTarget is a framebuffer with a color texture and an optional depth/stencil buffer.
+type graphTarget struct {
+	fbo    uint32             // Framebuffer object
+	depth  uint32             // Depth/stencil renderbuffer or 0
+	tex    *texture.Texture2D // Color texture
+	width  int                // Width in pixels
+	height int                // Height in pixels
+	format int32              // Internal format of the color texture
+	busy   bool               // Whether assigned to a resource
+	used   bool               // Whether used in the current frame
+}
+
+// NewRenderGraph creates and returns a pointer to a new empty render graph.
+func NewRenderGraph(gs *gls.GLS) *RenderGraph {
+
+	g := new(RenderGraph)
+	g.gs = gs
+	g.resources = make(map[string]*graphResource)
+	return g
+}
+
+// CreateTarget declares a transient render target with the specified name and description.
+func (g *RenderGraph) CreateTarget(name string, desc TargetDesc) error {
+
+	if name == Backbuffer || g.resources[name] != nil {
+		return fmt.Errorf("render graph resource %q already exists", name)
+	}
+	g.resources[name] = &graphResource{desc: desc}
+	g.compiled = false
+	return nil
+}
+
+// ImportTexture declares an external texture which can be read by passes, such as the
+// texture of a GuiTarget or of a video. Imported textures cannot be written by passes.
+func (g *RenderGraph) ImportTexture(name string, tex *texture.Texture2D) error {
+
+	if name == Backbuffer || g.resources[name] != nil {
+		return fmt.Errorf("render graph resource %q already exists", name)
+	}
+	g.resources[name] = &graphResource{imported: tex}
+	g.compiled = false
+	return nil
+}
+
+// AddPass adds a pass with the specified name to the graph and returns a pointer to it.
+// The setup function is called immediately to declare the resources used by the pass
+// and the exec function is called every frame the pass is executed.
+func (g *RenderGraph) AddPass(name string, setup func(b *PassBuilder), exec func(ctx *PassContext) error) *RenderPass {
+
+	pass := &RenderPass{name: name, exec: exec}
+	if setup != nil {
+		setup(&PassBuilder{pass})
+	}
+	g.passes = append(g.passes, pass)
+	g.compiled = false
+	return pass
+}
+
+// RemovePass removes the pass with the specified name and returns whether it was found.
+func (g *RenderGraph) RemovePass(name string) bool {
+
+	for i, pass := range g.passes {
+		if pass.name == name {
+			copy(g.passes[i:], g.passes[i+1:])
+			g.passes[len(g.passes)-1] = nil
+			g.passes = g.passes[:len(g.passes)-1]
+			g.compiled = false
+			return true
+		}
+	}
+	return false
+}
+
+// Read declares that the pass reads the specified resource.
+func (b *PassBuilder) Read(name string) *PassBuilder {
+
+	b.pass.reads = append(b.pass.reads, name)
+	return b
+}
+
+// Write declares the render target the pass renders into (Backbuffer for the window).
+// A pass writes at most one render target.
+func (b *PassBuilder) Write(name string) *PassBuilder {
+
+	b.pass.write = name
+	return b
+}
+
+// SideEffect declares that the pass has effects outside the graph and must never be culled.
+func (b *PassBuilder) SideEffect() *PassBuilder {
+
+	b.pass.sideEffect = true
+	return b
+}
+
+// Name returns the name of the pass.
+func (p *RenderPass) Name() string {
+
+	return p.name
+}
+
+// Live returns whether the pass was scheduled in the last compilation of the graph.
+func (p *RenderPass) Live() bool {
+
+	return p.live
+}
+
+// Texture returns the color texture of the specified resource read by the pass.
+func (ctx *PassContext) Texture(name string) *texture.Texture2D {
+
+	res := ctx.graph.resources[name]
+	if res == nil {
+		return nil
+	}
+	if res.imported != nil {
+		return res.imported
+	}
+	if res.target == nil {
+		return nil
+	}
+	return res.target.tex
+}
+
+// Clear clears the color, depth and stencil buffers of the render target written by the pass
+// with the specified color. The OpenGL clear color is changed.
+func (ctx *PassContext) Clear(r, g, b, a float32) {
+
+	ctx.GS.ClearColor(r, g, b, a)
+	ctx.GS.Clear(gls.COLOR_BUFFER_BIT | gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT)
+}
+
+// Compile validates the graph, orders its passes and culls the unused ones.
+// It is called by Execute when the graph was changed.
+func (g *RenderGraph) Compile() error {
+
+	g.compiled = false
+	g.order = g.order[:0]
+
+	// Validates resources and finds the writers of each resource
+	writers := make(map[string][]int)
+	for i, pass := range g.passes {
+		pass.live = false
+		for _, name := range pass.reads {
+			if name == Backbuffer {
+				return fmt.Errorf("render graph pass %q cannot read the backbuffer", pass.name)
+			}
+			if g.resources[name] == nil {
+				return fmt.Errorf("render graph pass %q reads unknown resource %q", pass.name, name)
+			}
+		}
+		if pass.write == "" {
+			continue
+		}
+		if pass.write != Backbuffer {
+			res := g.resources[pass.write]
+			if res == nil {
+				return fmt.Errorf("render graph pass %q writes unknown resource %q", pass.name, pass.write)
+			}
+			if res.imported != nil {
+				return fmt.Errorf("render graph pass %q writes imported texture %q", pass.name, pass.write)
+			}
+		}
+		writers[pass.write] = append(writers[pass.write], i)
+	}
+
+	// Builds the dependencies: readers depend on all the writers of a resource and
+	// successive writers of the same resource depend on the previous ones.
+	deps := make([][]int, len(g.passes))
+	for i, pass := range g.passes {
+		for _, name := range pass.reads {
+			for _, w := range writers[name] {
+				if w != i {
+					deps[i] = append(deps[i], w)
+				}
+			}
+		}
+		if pass.write != "" {
+			for _, w := range writers[pass.write] {
+				if w < i {
+					deps[i] = append(deps[i], w)
+				}
+			}
+		}
+	}
+
+	// Marks the passes contributing to the backbuffer or with side effects
+	var mark func(i int)
+	mark = func(i int) {
+		if g.passes[i].live {
+			return
+		}
+		g.passes[i].live = true
+		for _, d := range deps[i] {
+			mark(d)
+		}
+	}
+	for i, pass := range g.passes {
+		if pass.sideEffect || pass.write == Backbuffer {
+			mark(i)
+		}
+	}
+
+	// Orders the live passes topologically, keeping the declaration order when possible
+	done := make([]bool, len(g.passes))
+	for {
+		progress := false
+		for i, pass := range g.passes {
+			if done[i] || !pass.live {
+				continue
+			}
+			ready := true
+			for _, d := range deps[i] {
+				if !done[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[i] = true
+				g.order = append(g.order, pass)
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	for i, pass := range g.passes {
+		if pass.live && !done[i] {
+			return fmt.Errorf("render graph pass %q is part of a dependency cycle", pass.name)
+		}
+	}
+
+	// Computes the lifetime of each transient resource
+	for _, res := range g.resources {
+		res.first = -1
+		res.last = -1
+	}
+	use := func(name string, idx int) {
+		res := g.resources[name]
+		if res == nil || res.imported != nil {
+			return
+		}
+		if res.first < 0 {
+			res.first = idx
+		}
+		res.last = idx
+	}
+	for idx, pass := range g.order {
+		for _, name := range pass.reads {
+			use(name, idx)
+		}
+		use(pass.write, idx)
+	}
+	g.compiled = true
+	return nil
+}
+
+// Passes returns the names of the passes in execution order, compiling the graph if necessary.
+func (g *RenderGraph) Passes() ([]string, error) {
+
+	if !g.compiled {
+		if err := g.Compile(); err != nil {
+			return nil, err
+		}
+	}
+	names := make([]string, len(g.order))
+	for i, pass := range g.order {
+		names[i] = pass.name
+	}
+	return names, nil
+}
+
+// Execute executes the live passes of the graph, compiling it if necessary.
+// Transient targets with relative sizes are sized from the current viewport,
+// which is restored with the window framebuffer after the passes are executed.
+func (g *RenderGraph) Execute() error {
+
+	if !g.compiled {
+		if err := g.Compile(); err != nil {
+			return err
+		}
+	}
+	vx, vy, vw, vh := g.gs.GetViewport()
+	for _, t := range g.pool {
+		t.used = false
+		t.busy = false
+	}
+
+	var err error
+passes:
+	for idx, pass := range g.order {
+		// Allocates the targets of the resources first used by this pass
+		for name, res := range g.resources {
+			if res.first == idx {
+				if res.target, err = g.acquire(res.desc, int(vw), int(vh)); err != nil {
+					err = fmt.Errorf("render graph resource %q: %v", name, err)
+					break passes
+				}
+			}
+		}
+
+		ctx := PassContext{GS: g.gs, Pass: pass, graph: g}
+		if pass.write == Backbuffer {
+			g.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+			g.gs.Viewport(vx, vy, vw, vh)
+			ctx.Width, ctx.Height = int(vw), int(vh)
+		} else if pass.write != "" {
+			t := g.resources[pass.write].target
+			g.gs.BindFramebuffer(gls.FRAMEBUFFER, t.fbo)
+			g.gs.Viewport(0, 0, int32(t.width), int32(t.height))
+			ctx.Width, ctx.Height = t.width, t.height
+		}
+		err = pass.exec(&ctx)
+
+		// Releases the targets of the resources last used by this pass
+		for _, res := range g.resources {
+			if res.last == idx && res.target != nil {
+				res.target.busy = false
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("render graph pass %q: %v", pass.name, err)
+			break
+		}
+	}
+	g.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	g.gs.Viewport(vx, vy, vw, vh)
+
+	// Releases the targets not used in this frame
+	pool := g.pool[:0]
+	for _, t := range g.pool {
+		if t.used {
+			pool = append(pool, t)
+		} else {
+			t.dispose(g.gs)
+		}
+	}
+	g.pool = pool
+	for _, res := range g.resources {
+		res.target = nil
+	}
+	return err
+}
+
+// Dispose releases the OpenGL resources of the render targets allocated by the graph.
+func (g *RenderGraph) Dispose() {
+
+	for _, t := range g.pool {
+		t.dispose(g.gs)
+	}
+	g.pool = nil
+}
+
+// acquire returns a free pooled render target matching the specified description
+// or creates a new one.
+func (g *RenderGraph) acquire(desc TargetDesc, vw, vh int) (*graphTarget, error) {
+
+	width, height := desc.Width, desc.Height
+	if width == 0 || height == 0 {
+		scale := desc.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		width = int(float32(vw)*scale + 0.5)
+		height = int(float32(vh)*scale + 0.5)
+	}
+	format := desc.Format
+	if format == 0 {
+		format = gls.RGBA8
+	}
+	for _, t := range g.pool {
+		if !t.busy && t.width == width && t.height == height && t.format == format && (t.depth != 0) == desc.Depth {
+			t.busy = true
+			t.used = true
+			return t, nil
+		}
+	}
+	t, err := newGraphTarget(g.gs, width, height, format, desc.Depth)
+	if err != nil {
+		return nil, err
+	}
+	t.busy = true
+	t.used = true
+	g.pool = append(g.pool, t)
+	return t, nil
+}
+
+// newGraphTarget creates and returns a pointer to a new render target.
+func newGraphTarget(gs *gls.GLS, width, height int, format int32, depth bool) (*graphTarget, error) {
+
+	t := &graphTarget{width: width, height: height, format: format}
+	if format == gls.RGBA8 {
+		t.tex = texture.NewTexture2DFromData(width, height, gls.RGBA, gls.UNSIGNED_BYTE, int(format), nil)
+		// Keeps the precision of dark linear colors in 8 bits
+		t.tex.SetSRGB(true)
+	} else {
+		t.tex = texture.NewTexture2DFromData(width, height, gls.RGBA, gls.FLOAT, int(format), nil)
+	}
+	t.tex.SetMagFilter(gls.LINEAR)
+	t.tex.SetMinFilter(gls.LINEAR)
+	t.tex.SetFlipY(false)
+
+	t.fbo = gs.GenFramebuffer()
+	gs.BindFramebuffer(gls.FRAMEBUFFER, t.fbo)
+	gs.FramebufferTexture2D(gls.FRAMEBUFFER, gls.COLOR_ATTACHMENT0, gls.TEXTURE_2D, t.tex.Handle(gs), 0)
+	if depth {
+		t.depth = gs.GenRenderbuffer()
+		gs.BindRenderbuffer(gls.RENDERBUFFER, t.depth)
+		gs.RenderbufferStorage(gls.RENDERBUFFER, gls.DEPTH24_STENCIL8, int32(width), int32(height))
+		gs.FramebufferRenderbuffer(gls.FRAMEBUFFER, gls.DEPTH_STENCIL_ATTACHMENT, gls.RENDERBUFFER, t.depth)
+		gs.BindRenderbuffer(gls.RENDERBUFFER, 0)
+	}
+	status := gs.CheckFramebufferStatus(gls.FRAMEBUFFER)
+	gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	if status != gls.FRAMEBUFFER_COMPLETE {
+		t.dispose(gs)
+		return nil, fmt.Errorf("incomplete framebuffer: status 0x%X", status)
+	}
+	return t, nil
+}
+
+// dispose releases the OpenGL resources of the render target.
+func (t *graphTarget) dispose(gs *gls.GLS) {
+
+	if t.depth != 0 {
+		gs.DeleteRenderbuffers(t.depth)
+	}
+	gs.DeleteFramebuffers(t.fbo)
+	t.tex.Dispose()
+}
+
+// AddScenePass adds to the specified graph a pass which clears the specified target
+// and renders the specified scene into it with this renderer.
+// When rendering to the Backbuffer the target is not cleared.
+func (r *Renderer) AddScenePass(g *RenderGraph, name string, scene core.INode, cam camera.ICamera, target string) *RenderPass {
+
+	return g.AddPass(name,
+		func(b *PassBuilder) { b.Write(target) },
+		func(ctx *PassContext) error {
+			if target != Backbuffer {
+				ctx.Clear(0, 0, 0, 0)
+			}
+			return r.Render(scene, cam)
+		},
+	)
+}
@@ -0,0 +1,147 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/texture"
+)
+
+// GuiTarget is an offscreen render target for GUI panels.
+// A GUI root, or any sub-root panel, is rendered into the target texture at a
+// resolution independent of the window. The texture can then be composited into
+// the window with a gui.TextureView (scaled, rotated or with a custom shader),
+// or used by the material of a mesh to show a working GUI on an in-world screen.
+// The texture rows are ordered like image textures, with the top of the GUI first.
+type GuiTarget struct {
+	gs     *gls.GLS           // Reference to OpenGL state
+	tex    *texture.Texture2D // Color texture
+	fbo    uint32             // Framebuffer object
+	depth  uint32             // Depth/stencil renderbuffer
+	width  int                // Texture width in pixels
+	height int                // Texture height in pixels
+	gw     float32            // Width of the GUI area in GUI pixels
+	gh     float32            // Height of the GUI area in GUI pixels
+	cam    *camera.Camera     // Camera required by the renderer (not used by panels)
+	clear  [4]float32         // Clear color
+}
+
+// NewGuiTarget creates and returns a pointer to a new GUI render target
+// with a texture of the specified size in pixels.
+// The GUI area rendered initially has the same size in GUI pixels.
+func NewGuiTarget(gs *gls.GLS, width, height int) (*GuiTarget, error) {
+
+	t := new(GuiTarget)
+	t.gs = gs
+	t.width = width
+	t.height = height
+	t.gw = float32(width)
+	t.gh = float32(height)
+	t.cam = camera.New(1)
+
+	t.tex = texture.NewTexture2DFromData(width, height, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8, make([]byte, width*height*4))
+	t.tex.SetMagFilter(gls.LINEAR)
+	t.tex.SetMinFilter(gls.LINEAR)
+
+	t.fbo = gs.GenFramebuffer()
+	gs.BindFramebuffer(gls.FRAMEBUFFER, t.fbo)
+	gs.FramebufferTexture2D(gls.FRAMEBUFFER, gls.COLOR_ATTACHMENT0, gls.TEXTURE_2D, t.tex.Handle(gs), 0)
+
+	t.depth = gs.GenRenderbuffer()
+	gs.BindRenderbuffer(gls.RENDERBUFFER, t.depth)
+	gs.RenderbufferStorage(gls.RENDERBUFFER, gls.DEPTH24_STENCIL8, int32(width), int32(height))
+	gs.FramebufferRenderbuffer(gls.FRAMEBUFFER, gls.DEPTH_STENCIL_ATTACHMENT, gls.RENDERBUFFER, t.depth)
+
+	status := gs.CheckFramebufferStatus(gls.FRAMEBUFFER)
+	gs.BindRenderbuffer(gls.RENDERBUFFER, 0)
+	gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	if status != gls.FRAMEBUFFER_COMPLETE {
+		t.Dispose()
+		return nil, fmt.Errorf("incomplete framebuffer: status 0x%X", status)
+	}
+	return t, nil
+}
+
+// Texture returns the texture the GUI is rendered into.
+func (t *GuiTarget) Texture() *texture.Texture2D {
+
+	return t.tex
+}
+
+// Size returns the size of the texture in pixels.
+func (t *GuiTarget) Size() (width, height int) {
+
+	return t.width, t.height
+}
+
+// SetGuiSize sets the size in GUI pixels of the area rendered into the texture.
+// A texture larger than the GUI area super-samples the GUI.
+func (t *GuiTarget) SetGuiSize(width, height float32) {
+
+	t.gw = width
+	t.gh = height
+}
+
+// GuiSize returns the size in GUI pixels of the area rendered into the texture.
+func (t *GuiTarget) GuiSize() (width, height float32) {
+
+	return t.gw, t.gh
+}
+
+// SetClearColor sets the color the texture is cleared to before rendering (default transparent).
+// Render sets the OpenGL clear color, so applications must set their own clear color
+// again before clearing the window if it was rendered before.
+func (t *GuiTarget) SetClearColor(r, g, b, a float32) {
+
+	t.clear = [4]float32{r, g, b, a}
+}
+
+// Render renders the specified GUI root into the texture using the specified renderer.
+// If the root is a panel, its top left corner is rendered at the top left corner of
+// the texture, so sub-roots which are part of a larger GUI can be rendered alone.
+func (t *GuiTarget) Render(r *Renderer, root core.INode) error {
+
+	// Maps the GUI area to the whole texture
+	rp := &gui.RenderParams{
+		ScaleX: float64(t.width) / float64(t.gw),
+		ScaleY: float64(t.height) / float64(t.gh),
+		FlipY:  true,
+	}
+	if ipan, ok := root.(gui.IPanel); ok {
+		root.UpdateMatrixWorld()
+		pos := ipan.GetPanel().Pospix()
+		rp.OriginX = pos.X
+		rp.OriginY = pos.Y
+	}
+	gm := gui.Manager()
+	prev := gm.RenderParams()
+	gm.SetRenderParams(rp)
+
+	vx, vy, vw, vh := t.gs.GetViewport()
+	t.gs.BindFramebuffer(gls.FRAMEBUFFER, t.fbo)
+	t.gs.Viewport(0, 0, int32(t.width), int32(t.height))
+	t.gs.ClearColor(t.clear[0], t.clear[1], t.clear[2], t.clear[3])
+	t.gs.Clear(gls.COLOR_BUFFER_BIT | gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT)
+
+	err := r.Render(root, t.cam)
+
+	t.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	t.gs.Viewport(vx, vy, vw, vh)
+	gm.SetRenderParams(prev)
+	return err
+}
+
+// Dispose releases the OpenGL resources used by the target.
+func (t *GuiTarget) Dispose() {
+
+	t.gs.DeleteRenderbuffers(t.depth)
+	t.gs.DeleteFramebuffers(t.fbo)
+	t.tex.Dispose()
+}
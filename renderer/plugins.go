@@ -0,0 +1,46 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PassFactory adds a pass to the specified render graph, rendering with the specified
+// renderer, and returns it.
+type PassFactory func(r *Renderer, g *RenderGraph) (*RenderPass, error)
+
+// Registered pass factories by name
+var passFactories = map[string]PassFactory{}
+
+// RegisterPass registers (or replaces) a named pass factory, so packages can provide render
+// passes, such as post-processing effects, which applications add to their render graphs by name.
+func RegisterPass(name string, factory PassFactory) {
+
+	passFactories[name] = factory
+}
+
+// RegisteredPasses returns the sorted names of the registered passes.
+func RegisteredPasses() []string {
+
+	names := make([]string, 0, len(passFactories))
+	for name := range passFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddRegisteredPass adds the registered pass with the specified name to the specified
+// render graph and returns it.
+func (r *Renderer) AddRegisteredPass(g *RenderGraph, name string) (*RenderPass, error) {
+
+	factory, ok := passFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unregistered render pass:%s", name)
+	}
+	return factory(r, g)
+}
@@ -13,29 +13,53 @@ import (
 	"github.com/g3n/engine/light"
 	"github.com/g3n/engine/material"
 	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/spatial"
+	"github.com/g3n/engine/texture"
 	"sort"
+	"sync"
 )
 
 // Renderer renders a scene containing 3D objects and/or 2D GUI elements.
 type Renderer struct {
-	Shaman                      // Embedded shader manager
-	gs          *gls.GLS        // Reference to OpenGL state
-	rinfo       core.RenderInfo // Preallocated Render info
-	specs       ShaderSpecs     // Preallocated Shader specs
-	sortObjects bool            // Flag indicating whether objects should be sorted before rendering
-	stats       Stats           // Renderer statistics
+	Shaman                         // Embedded shader manager
+	gs          *gls.GLS           // Reference to OpenGL state
+	specs       ShaderSpecs        // Preallocated Shader specs
+	sortObjects bool               // Flag indicating whether objects should be sorted before rendering
+	sortState   bool               // Flag indicating whether opaque objects should be sorted by state
+	stats       Stats              // Statistics of the last rendered frame
+	statsMutex  sync.Mutex         // Protects stats
+	lastMat     *material.Material // Material of the last rendered graphic material
+	sorter      stateSorter        // Preallocated state sorter
+	streamer    *texture.Streamer  // Texture streamer or nil
+	placeholder material.IMaterial // Material rendered while shader programs are built or nil
+	index       *spatial.Index     // Spatial index culling its graphics or nil
 
 	// Populated each frame
-	ambLights    []*light.Ambient           // Ambient lights in the scene
-	dirLights    []*light.Directional       // Directional lights in the scene
-	pointLights  []*light.Point             // Point lights in the scene
-	spotLights   []*light.Spot              // Spot lights in the scene
-	others       []core.INode               // Other nodes (audio, players, etc)
-	graphics     []*graphic.Graphic         // Graphics to be rendered
-	grmatsOpaque []*graphic.GraphicMaterial // Opaque graphic materials to be rendered
-	grmatsTransp []*graphic.GraphicMaterial // Transparent graphic materials to be rendered
-	zLayers      map[int][]gui.IPanel       // All IPanels to be rendered organized by Z-layer
-	zLayerKeys   []int                      // Z-layers being used (initially in no particular order, sorted later)
+	frame      *frame               // Frame being prepared
+	immediate  frame                // Frame rendered by Render
+	frames     chan *frame          // Frames which can be recorded, limiting the frames in flight
+	zLayers    map[int][]gui.IPanel // All IPanels to be rendered organized by Z-layer
+	zLayerKeys []int                // Z-layers being used (initially in no particular order, sorted later)
+	frustum    math32.Frustum       // Camera frustum used for culling
+}
+
+// frame contains the lists and matrices of a frame built by prepare and rendered by submit.
+// The model matrices of the graphics are saved with the frame, so a frame can be prepared
+// while the previous frames are rendered.
+type frame struct {
+	rinfo        core.RenderInfo              // Render info of the frame
+	ambLights    []*light.Ambient             // Ambient lights in the scene
+	dirLights    []*light.Directional         // Directional lights in the scene
+	pointLights  []*light.Point               // Point lights in the scene
+	spotLights   []*light.Spot                // Spot lights in the scene
+	others       []core.INode                 // Other nodes (audio, players, etc)
+	graphics     []*graphic.Graphic           // Graphics to be rendered
+	models       []math32.Matrix4             // Model matrices of the graphics to be rendered
+	spheres      []math32.Sphere              // World bounding spheres of the graphics, if streaming textures
+	depths       map[*graphic.Graphic]float32 // Z positions of the graphics relative to the camera
+	grmatsOpaque []*graphic.GraphicMaterial   // Opaque graphic materials to be rendered
+	grmatsTransp []*graphic.GraphicMaterial   // Transparent graphic materials to be rendered
+	stats        Stats                        // Statistics of the frame
 }
 
 // Stats describes how many objects of each type are being rendered.
@@ -45,6 +69,9 @@ type Stats struct {
 	Lights      int // Number of lights rendered
 	Panels      int // Number of GUI panels rendered
 	Others      int // Number of other objects rendered
+	Programs    int // Number of shader program changes
+	Materials   int // Number of material changes
+	Pending     int // Number of graphic materials whose shader programs are being built
 }
 
 // NewRenderer creates and returns a pointer to a new Renderer.
@@ -54,15 +81,10 @@ func NewRenderer(gs *gls.GLS) *Renderer {
 	r.gs = gs
 	r.Shaman.Init(gs)
 	r.sortObjects = true
+	r.sortState = true
+	r.sorter.init()
 
-	r.ambLights = make([]*light.Ambient, 0)
-	r.dirLights = make([]*light.Directional, 0)
-	r.pointLights = make([]*light.Point, 0)
-	r.spotLights = make([]*light.Spot, 0)
-	r.others = make([]core.INode, 0)
-	r.graphics = make([]*graphic.Graphic, 0)
-	r.grmatsOpaque = make([]*graphic.GraphicMaterial, 0)
-	r.grmatsTransp = make([]*graphic.GraphicMaterial, 0)
+	r.frame = &r.immediate
 	r.zLayers = make(map[int][]gui.IPanel)
 	r.zLayers[0] = make([]gui.IPanel, 0)
 	r.zLayerKeys = append(r.zLayerKeys, 0)
@@ -74,6 +96,8 @@ func NewRenderer(gs *gls.GLS) *Renderer {
 // Should be called after the frame was rendered.
 func (r *Renderer) Stats() Stats {
 
+	r.statsMutex.Lock()
+	defer r.statsMutex.Unlock()
 	return r.stats
 }
 
@@ -89,57 +113,170 @@ func (r *Renderer) ObjectSorting() bool {
 	return r.sortObjects
 }
 
+// SetStateSorting sets whether opaque objects are sorted by shader, material and texture
+// before rendering, minimizing OpenGL state changes. Objects using the same state are
+// still rendered front to back. It has effect only if object sorting is enabled.
+// The Programs and Materials statistics can be compared with and without state sorting.
+func (r *Renderer) SetStateSorting(sort bool) {
+
+	r.sortState = sort
+}
+
+// StateSorting returns whether opaque objects are sorted by state before rendering.
+func (r *Renderer) StateSorting() bool {
+
+	return r.sortState
+}
+
+// SetTextureStreamer sets the texture streamer which manages the mip levels of the
+// streamed textures. The renderer reports the screen-space footprints of the textures
+// of the visible graphics to the streamer and updates it before rendering each frame.
+// The streamer can be shared by several renderers using the same OpenGL context.
+func (r *Renderer) SetTextureStreamer(s *texture.Streamer) {
+
+	r.streamer = s
+}
+
+// TextureStreamer returns the texture streamer of the renderer or nil.
+func (r *Renderer) TextureStreamer() *texture.Streamer {
+
+	return r.streamer
+}
+
+// SetPlaceholder sets the material used to render the graphic materials whose shader
+// programs are being built asynchronously (see Shaman.SetAsync). If nil, the default,
+// these graphic materials are not rendered until their programs are built.
+// The program of the placeholder material itself is built synchronously.
+func (r *Renderer) SetPlaceholder(imat material.IMaterial) {
+
+	r.placeholder = imat
+}
+
+// Placeholder returns the material used to render the graphic
+// materials whose shader programs are being built.
+func (r *Renderer) Placeholder() material.IMaterial {
+
+	return r.placeholder
+}
+
+// WarmUp builds the shader programs needed to render all the graphics and panels of the
+// specified scene, visible or not, with its current lights. It returns the number of new
+// programs. If asynchronous builds are enabled it only starts the builds, and a loading
+// screen can be rendered until Shaman.Poll returns zero. It issues OpenGL commands and
+// must not be called while a frame recorded by this renderer is in flight.
+func (r *Renderer) WarmUp(scene core.INode) (int, error) {
+
+	scene.UpdateMatrixWorld()
+	r.frame = &r.immediate
+	r.clear()
+	r.classifyAndCull(scene, nil, 0)
+	r.setLightCounts(r.frame)
+
+	var count int
+	warmUp := func(grmat *graphic.GraphicMaterial) error {
+		r.setSpecs(grmat.IMaterial().GetMaterial(), grmat.IGraphic())
+		started, err := r.Shaman.WarmUp(&r.specs)
+		if started {
+			count++
+		}
+		return err
+	}
+	for _, gr := range r.frame.graphics {
+		materials := gr.Materials()
+		for i := range materials {
+			if err := warmUp(&materials[i]); err != nil {
+				return count, err
+			}
+		}
+	}
+	for _, panels := range r.zLayers {
+		for _, ipan := range panels {
+			materials := ipan.GetGraphic().Materials()
+			for i := range materials {
+				if err := warmUp(&materials[i]); err != nil {
+					return count, err
+				}
+			}
+		}
+	}
+	r.clear()
+	return count, nil
+}
+
+// SetSpatialIndex sets the spatial index used to cull the graphics it contains, or nil.
+// The index is updated before culling each frame. Graphics not in the index are culled
+// individually.
+func (r *Renderer) SetSpatialIndex(index *spatial.Index) {
+
+	r.index = index
+}
+
+// SpatialIndex returns the spatial index used to cull graphics or nil.
+func (r *Renderer) SpatialIndex() *spatial.Index {
+
+	return r.index
+}
+
 // Render renders the specified scene using the specified camera. Returns an an error.
 func (r *Renderer) Render(scene core.INode, cam camera.ICamera) error {
 
+	r.frame = &r.immediate
+	r.prepare(scene, cam)
+	return r.submit(r.frame)
+}
+
+// prepare traverses, culls and sorts the specified scene into the frame being prepared, saving
+// the model matrices of its graphics and the lists of lights and graphic materials rendered by
+// submit. It does not issue OpenGL commands.
+func (r *Renderer) prepare(scene core.INode, cam camera.ICamera) {
+
+	f := r.frame
+
 	// Updates world matrices of all scene nodes
 	scene.UpdateMatrixWorld()
 
 	// Build RenderInfo
-	cam.ViewMatrix(&r.rinfo.ViewMatrix)
-	cam.ProjMatrix(&r.rinfo.ProjMatrix)
+	cam.ViewMatrix(&f.rinfo.ViewMatrix)
+	cam.ProjMatrix(&f.rinfo.ProjMatrix)
 
 	// Clear stats and scene arrays
-	r.stats = Stats{}
-	r.ambLights = r.ambLights[0:0]
-	r.dirLights = r.dirLights[0:0]
-	r.pointLights = r.pointLights[0:0]
-	r.spotLights = r.spotLights[0:0]
-	r.others = r.others[0:0]
-	r.graphics = r.graphics[0:0]
-	r.grmatsOpaque = r.grmatsOpaque[0:0]
-	r.grmatsTransp = r.grmatsTransp[0:0]
-	r.zLayers = make(map[int][]gui.IPanel)
-	r.zLayers[0] = make([]gui.IPanel, 0)
-	r.zLayerKeys = r.zLayerKeys[0:1]
-	r.zLayerKeys[0] = 0
+	r.clear()
 
 	// Prepare for frustum culling
 	var proj math32.Matrix4
-	proj.MultiplyMatrices(&r.rinfo.ProjMatrix, &r.rinfo.ViewMatrix)
-	frustum := math32.NewFrustumFromMatrix(&proj)
+	proj.MultiplyMatrices(&f.rinfo.ProjMatrix, &f.rinfo.ViewMatrix)
+	r.frustum.SetFromMatrix(&proj)
+	if r.index != nil {
+		r.index.Update()
+		r.index.Cull(&r.frustum)
+	}
 
 	// Classify scene and all scene nodes, culling renderable IGraphics which are fully outside of the camera frustum
-	r.classifyAndCull(scene, frustum, 0)
-
-	// Set light counts in shader specs
-	r.specs.AmbientLightsMax = len(r.ambLights)
-	r.specs.DirLightsMax = len(r.dirLights)
-	r.specs.PointLightsMax = len(r.pointLights)
-	r.specs.SpotLightsMax = len(r.spotLights)
-
-	// Pre-calculate MV and MVP matrices and compile initial lists of opaque and transparent graphic materials
-	for _, gr := range r.graphics {
-		// Calculate MV and MVP matrices for all non-GUI graphics to be rendered
-		gr.CalculateMatrices(r.gs, &r.rinfo)
+	r.classifyAndCull(scene, &r.frustum, 0)
+
+	// Save the model matrices and depths of the graphics and compile initial lists of opaque and transparent graphic materials
+	for _, gr := range f.graphics {
+		mm := gr.MatrixWorld()
+		f.models = append(f.models, mm)
+		var mvm math32.Matrix4
+		mvm.MultiplyMatrices(&f.rinfo.ViewMatrix, &mm)
+		pos := gr.Position()
+		pos.ApplyMatrix4(&mvm)
+		f.depths[gr] = pos.Z
+		if r.streamer != nil {
+			var sphere math32.Sphere
+			bb := gr.WorldBoundingBox()
+			bb.GetBoundingSphere(&sphere)
+			f.spheres = append(f.spheres, sphere)
+		}
 		// Append all graphic materials of this graphic to lists of graphic materials to be rendered
 		materials := gr.Materials()
 		for i := range materials {
-			r.stats.GraphicMats++
+			f.stats.GraphicMats++
 			if materials[i].IMaterial().GetMaterial().Transparent() {
-				r.grmatsTransp = append(r.grmatsTransp, &materials[i])
+				f.grmatsTransp = append(f.grmatsTransp, &materials[i])
 			} else {
-				r.grmatsOpaque = append(r.grmatsOpaque, &materials[i])
+				f.grmatsOpaque = append(f.grmatsOpaque, &materials[i])
 			}
 		}
 	}
@@ -147,8 +284,12 @@ func (r *Renderer) Render(scene core.INode, cam camera.ICamera) error {
 	// TODO: If both GraphicMaterials belong to same Graphic we might want to keep their relative order...
 	// Z-sort graphic materials back to front
 	if r.sortObjects {
-		zSort(r.grmatsOpaque)
-		zSort(r.grmatsTransp)
+		if r.sortState {
+			r.sorter.sort(f.grmatsOpaque, f.depths)
+		} else {
+			zSort(f.grmatsOpaque, f.depths)
+		}
+		zSort(f.grmatsTransp, f.depths)
 	}
 
 	// Sort zLayers back to front
@@ -156,53 +297,137 @@ func (r *Renderer) Render(scene core.INode, cam camera.ICamera) error {
 
 	// Iterate over all panels from back to front, setting Z and adding graphic materials to grmatsTransp/grmatsOpaque
 	const deltaZ = 0.00001
-	panZ := float32(-1 + float32(r.stats.Panels)*deltaZ)
+	panZ := float32(-1 + float32(f.stats.Panels)*deltaZ)
 	for _, k := range r.zLayerKeys {
 		for _, ipan := range r.zLayers[k] {
-			// Set panel Z
-			ipan.SetPositionZ(panZ)
+			// Set panel Z, only if changed as frames in flight may be reading it
+			if ipan.GetNode().Position().Z != panZ {
+				ipan.SetPositionZ(panZ)
+			}
 			panZ -= deltaZ
 			// Append the panel's graphic material to lists of graphic materials to be rendered
-			mat := ipan.GetGraphic().Materials()[0]
+			mat := &ipan.GetGraphic().Materials()[0]
 			if mat.IMaterial().GetMaterial().Transparent() {
-				r.grmatsTransp = append(r.grmatsTransp, &mat)
+				f.grmatsTransp = append(f.grmatsTransp, mat)
 			} else {
-				r.grmatsOpaque = append(r.grmatsOpaque, &mat)
+				f.grmatsOpaque = append(f.grmatsOpaque, mat)
 			}
 		}
 	}
+}
+
+// clear clears the statistics and the lists of the frame being prepared.
+func (r *Renderer) clear() {
+
+	f := r.frame
+	f.stats = Stats{}
+	f.ambLights = f.ambLights[0:0]
+	f.dirLights = f.dirLights[0:0]
+	f.pointLights = f.pointLights[0:0]
+	f.spotLights = f.spotLights[0:0]
+	f.others = f.others[0:0]
+	f.graphics = f.graphics[0:0]
+	f.models = f.models[0:0]
+	f.spheres = f.spheres[0:0]
+	f.grmatsOpaque = f.grmatsOpaque[0:0]
+	f.grmatsTransp = f.grmatsTransp[0:0]
+	if f.depths == nil {
+		f.depths = make(map[*graphic.Graphic]float32)
+	}
+	for gr := range f.depths {
+		delete(f.depths, gr)
+	}
+	// Keeps the panel lists of the Z-layers used in the last frame, removing the unused ones
+	keys := r.zLayerKeys[:0]
+	for _, k := range r.zLayerKeys {
+		panels := r.zLayers[k]
+		if len(panels) == 0 && k != 0 {
+			delete(r.zLayers, k)
+			continue
+		}
+		for i := range panels {
+			panels[i] = nil
+		}
+		r.zLayers[k] = panels[:0]
+		keys = append(keys, k)
+	}
+	r.zLayerKeys = keys
+}
+
+// setLightCounts sets the number of lights of each type of the specified frame in the shader specs.
+func (r *Renderer) setLightCounts(f *frame) {
+
+	r.specs.AmbientLightsMax = len(f.ambLights)
+	r.specs.DirLightsMax = len(f.dirLights)
+	r.specs.PointLightsMax = len(f.pointLights)
+	r.specs.SpotLightsMax = len(f.spotLights)
+}
+
+// submit issues the OpenGL commands to render the specified frame built by prepare.
+func (r *Renderer) submit(f *frame) error {
+
+	defer func() {
+		r.statsMutex.Lock()
+		r.stats = f.stats
+		r.statsMutex.Unlock()
+	}()
+	r.lastMat = nil
+	r.setLightCounts(f)
+	// Encode the linear output to sRGB in sRGB capable framebuffers
+	if r.gs.LinearColors() {
+		r.gs.Enable(gls.FRAMEBUFFER_SRGB)
+	}
+
+	// Finish the shader programs built asynchronously
+	if r.Shaman.Pending() > 0 {
+		r.Shaman.Poll()
+	}
+
+	// Set the matrices of the graphics from the model matrices of the frame
+	for i, gr := range f.graphics {
+		gr.SetMatrices(&f.models[i], &f.rinfo)
+		if r.streamer != nil {
+			r.requestTextures(f, gr, &f.spheres[i])
+		}
+	}
+
+	// Stream in the texture levels requested for the frame
+	if r.streamer != nil {
+		r.streamer.Update(r.gs)
+	}
 
 	// Render opaque objects front to back
-	for i := len(r.grmatsOpaque) - 1; i >= 0; i-- {
-		err := r.renderGraphicMaterial(r.grmatsOpaque[i])
+	for i := len(f.grmatsOpaque) - 1; i >= 0; i-- {
+		err := r.renderGraphicMaterial(f, f.grmatsOpaque[i])
 		if err != nil {
 			return err
 		}
 	}
 
 	// Render transparent objects back to front
-	for _, grmat := range r.grmatsTransp {
-		err := r.renderGraphicMaterial(grmat)
+	for _, grmat := range f.grmatsTransp {
+		err := r.renderTransparent(f, grmat)
 		if err != nil {
 			return err
 		}
 	}
 
 	// Render other nodes (audio players, etc)
-	for _, inode := range r.others {
+	for _, inode := range f.others {
 		inode.Render(r.gs)
 	}
 
-	// Enable depth mask so that clearing the depth buffer works
+	// Enable depth and color masks so that clearing the buffers works
 	r.gs.DepthMask(true)
-	// TODO enable color mask, stencil mask?
+	r.gs.ColorMask(true, true, true, true)
+	// TODO enable stencil mask?
 	// TODO clear the buffers for the user, and set the appropriate masks to true before clearing
 
 	return nil
 }
 
 // classifyAndCull classifies the provided INode and all of its descendents.
-// It ignores (culls) renderable IGraphics which are fully outside of the specified frustum.
+// It ignores (culls) renderable IGraphics which are fully outside of the specified frustum, if not nil.
 func (r *Renderer) classifyAndCull(inode core.INode, frustum *math32.Frustum, zLayer int) {
 
 	// Ignore invisible nodes and their descendants
@@ -220,24 +445,30 @@ func (r *Renderer) classifyAndCull(inode core.INode, frustum *math32.Frustum, zL
 				r.zLayers[zLayer] = make([]gui.IPanel, 0)
 			}
 			r.zLayers[zLayer] = append(r.zLayers[zLayer], ipan)
-			r.stats.Panels++
+			r.frame.stats.Panels++
 		}
 		// Check if node is an IGraphic
 	} else if igr, ok := inode.(graphic.IGraphic); ok {
 		if igr.Renderable() {
 			gr := igr.GetGraphic()
 			// Frustum culling
-			if igr.Cullable() {
-				mw := gr.MatrixWorld()
-				bb := igr.GetGeometry().BoundingBox()
-				bb.ApplyMatrix4(&mw)
-				if frustum.IntersectsBox(&bb) {
+			if igr.Cullable() && frustum != nil {
+				// Graphics of the spatial index were culled with it
+				inside, indexed := false, false
+				if r.index != nil {
+					inside, indexed = r.index.InFrustum(igr)
+				}
+				if !indexed {
+					bb := gr.WorldBoundingBox()
+					inside = frustum.IntersectsBox(&bb)
+				}
+				if inside {
 					// Append graphic to list of graphics to be rendered
-					r.graphics = append(r.graphics, gr)
+					r.frame.graphics = append(r.frame.graphics, gr)
 				}
 			} else {
 				// Append graphic to list of graphics to be rendered
-				r.graphics = append(r.graphics, gr)
+				r.frame.graphics = append(r.frame.graphics, gr)
 			}
 		}
 		// Node is not a Graphic
@@ -246,20 +477,20 @@ func (r *Renderer) classifyAndCull(inode core.INode, frustum *math32.Frustum, zL
 		if il, ok := inode.(light.ILight); ok {
 			switch l := il.(type) {
 			case *light.Ambient:
-				r.ambLights = append(r.ambLights, l)
+				r.frame.ambLights = append(r.frame.ambLights, l)
 			case *light.Directional:
-				r.dirLights = append(r.dirLights, l)
+				r.frame.dirLights = append(r.frame.dirLights, l)
 			case *light.Point:
-				r.pointLights = append(r.pointLights, l)
+				r.frame.pointLights = append(r.frame.pointLights, l)
 			case *light.Spot:
-				r.spotLights = append(r.spotLights, l)
+				r.frame.spotLights = append(r.frame.spotLights, l)
 			default:
 				panic("Invalid light type")
 			}
 			// Other nodes
 		} else {
-			r.others = append(r.others, inode)
-			r.stats.Others++
+			r.frame.others = append(r.frame.others, inode)
+			r.frame.stats.Others++
 		}
 	}
 	// Classify children
@@ -268,9 +499,35 @@ func (r *Renderer) classifyAndCull(inode core.INode, frustum *math32.Frustum, zL
 	}
 }
 
+// requestTextures reports the screen-space footprints of the textures of the specified
+// graphic of the specified frame to the texture streamer, estimated from the projection
+// of its specified world bounding sphere.
+func (r *Renderer) requestTextures(f *frame, gr *graphic.Graphic, sphere *math32.Sphere) {
+
+	center := sphere.Center
+	center.ApplyMatrix4(&f.rinfo.ViewMatrix)
+
+	// Projected diameter of the bounding sphere in pixels
+	_, _, _, height := r.gs.GetViewport()
+	w := float32(1)
+	if f.rinfo.ProjMatrix[15] == 0 {
+		// Perspective projection: the camera may be inside the sphere
+		w = math32.Max(-center.Z, sphere.Radius)
+	}
+	pixels := sphere.Radius * f.rinfo.ProjMatrix[5] * float32(height) / w
+
+	materials := gr.Materials()
+	for i := range materials {
+		for _, tex := range materials[i].IMaterial().GetMaterial().Textures() {
+			rx, ry := tex.Repeat()
+			r.streamer.Request(tex, pixels*math32.Max(rx, ry))
+		}
+	}
+}
+
 // zSort sorts a list of graphic materials based on the user-specified render order
-// then based on their Z position relative to the camera, back to front.
-func zSort(grmats []*graphic.GraphicMaterial) {
+// then based on the Z positions of their graphics relative to the camera, back to front.
+func zSort(grmats []*graphic.GraphicMaterial, depths map[*graphic.Graphic]float32) {
 
 	sort.Slice(grmats, func(i, j int) bool {
 		gr1 := grmats[i].IGraphic().GetGraphic()
@@ -281,71 +538,110 @@ func zSort(grmats []*graphic.GraphicMaterial) {
 		if rO1 != rO2 {
 			return rO1 < rO2
 		}
-		mvm1 := gr1.ModelViewMatrix()
-		mvm2 := gr2.ModelViewMatrix()
-		g1pos := gr1.Position()
-		g2pos := gr2.Position()
-		g1pos.ApplyMatrix4(mvm1)
-		g2pos.ApplyMatrix4(mvm2)
-		return g1pos.Z < g2pos.Z
+		return depths[gr1] < depths[gr2]
 	})
 }
 
-// renderGraphicMaterial renders the specified graphic material.
-func (r *Renderer) renderGraphicMaterial(grmat *graphic.GraphicMaterial) error {
-
-	mat := grmat.IMaterial().GetMaterial()
-	geom := grmat.IGraphic().GetGeometry()
-	gr := grmat.IGraphic().GetGraphic()
+// renderGraphicMaterial renders the specified graphic material of the specified frame.
+func (r *Renderer) renderGraphicMaterial(f *frame, grmat *graphic.GraphicMaterial) error {
 
-	// Add defines from material, geometry and graphic
-	r.specs.Defines = *gls.NewShaderDefines()
-	r.specs.Defines.Add(&mat.ShaderDefines)
-	r.specs.Defines.Add(&geom.ShaderDefines)
-	r.specs.Defines.Add(&gr.ShaderDefines)
-
-	// Set the shader specs for this material and set shader program
-	r.specs.Name = mat.Shader()
-	r.specs.ShaderUnique = mat.ShaderUnique()
-	r.specs.UseLights = mat.UseLights()
-	r.specs.MatTexturesMax = mat.TextureCount()
+	imat := grmat.IMaterial()
+	mat := imat.GetMaterial()
+	r.setSpecs(mat, grmat.IGraphic())
 
 	// Set active program and apply shader specs
-	_, err := r.Shaman.SetProgram(&r.specs)
+	changed, err := r.Shaman.SetProgram(&r.specs)
+	if err == ErrProgramPending {
+		// Render the placeholder material, if any, until the program is built
+		f.stats.Pending++
+		if r.placeholder == nil {
+			return nil
+		}
+		imat = r.placeholder
+		mat = imat.GetMaterial()
+		r.setSpecs(mat, grmat.IGraphic())
+		changed, err = r.Shaman.setProgram(&r.specs, false)
+	}
 	if err != nil {
 		return err
 	}
+	if changed {
+		f.stats.Programs++
+	}
+	if mat != r.lastMat {
+		f.stats.Materials++
+		r.lastMat = mat
+	}
 
 	// Set up lights (transfer lights' uniforms)
 	if r.specs.UseLights != material.UseLightNone {
 		if r.specs.UseLights&material.UseLightAmbient != 0 {
-			for idx, l := range r.ambLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
+			for idx, l := range f.ambLights {
+				l.RenderSetup(r.gs, &f.rinfo, idx)
+				f.stats.Lights++
 			}
 		}
 		if r.specs.UseLights&material.UseLightDirectional != 0 {
-			for idx, l := range r.dirLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
+			for idx, l := range f.dirLights {
+				l.RenderSetup(r.gs, &f.rinfo, idx)
+				f.stats.Lights++
 			}
 		}
 		if r.specs.UseLights&material.UseLightPoint != 0 {
-			for idx, l := range r.pointLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
+			for idx, l := range f.pointLights {
+				l.RenderSetup(r.gs, &f.rinfo, idx)
+				f.stats.Lights++
 			}
 		}
 		if r.specs.UseLights&material.UseLightSpot != 0 {
-			for idx, l := range r.spotLights {
-				l.RenderSetup(r.gs, &r.rinfo, idx)
-				r.stats.Lights++
+			for idx, l := range f.spotLights {
+				l.RenderSetup(r.gs, &f.rinfo, idx)
+				f.stats.Lights++
 			}
 		}
 	}
 
 	// Render this graphic material
-	grmat.Render(r.gs, &r.rinfo)
+	grmat.RenderMaterial(r.gs, &f.rinfo, imat)
 
 	return nil
 }
+
+// renderTransparent renders a transparent graphic material.
+// Double sided two pass materials are rendered back faces first.
+func (r *Renderer) renderTransparent(f *frame, grmat *graphic.GraphicMaterial) error {
+
+	mat := grmat.IMaterial().GetMaterial()
+	if !mat.TwoPass() || mat.Side() != material.SideDouble {
+		return r.renderGraphicMaterial(f, grmat)
+	}
+	defer mat.SetFacePass(0)
+	mat.SetFacePass(gls.BACK)
+	if err := r.renderGraphicMaterial(f, grmat); err != nil {
+		return err
+	}
+	mat.SetFacePass(gls.FRONT)
+	return r.renderGraphicMaterial(f, grmat)
+}
+
+// setSpecs sets the shader specs to render the specified material with the specified graphic.
+func (r *Renderer) setSpecs(mat *material.Material, igr graphic.IGraphic) {
+
+	// Add defines from material, geometry and graphic
+	r.specs.Defines = *gls.NewShaderDefines()
+	r.specs.Defines.Add(&mat.ShaderDefines)
+	r.specs.Defines.Add(&igr.GetGeometry().ShaderDefines)
+	r.specs.Defines.Add(&igr.GetGraphic().ShaderDefines)
+	if mat.TextureArrays() {
+		r.specs.Defines.Set("MAT_TEXTURE_ARRAYS", "1")
+	}
+	if r.gs.LinearColors() {
+		r.specs.Defines.Set("LINEAR_COLORS", "1")
+	}
+
+	// Set the shader specs for this material
+	r.specs.Name = mat.Shader()
+	r.specs.ShaderUnique = mat.ShaderUnique()
+	r.specs.UseLights = mat.UseLights()
+	r.specs.MatTexturesMax = mat.TextureCount()
+}
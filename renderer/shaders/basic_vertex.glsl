@@ -11,7 +11,12 @@ out vec3 Color;
 
 void main() {
 
+#ifdef LINEAR_COLORS
+    // Vertex colors are specified in sRGB
+    Color = mix(VertexColor / 12.92, pow((VertexColor + 0.055) / 1.055, vec3(2.4)), step(vec3(0.04045), VertexColor));
+#else
     Color = VertexColor;
+#endif
     gl_Position = MVP * vec4(VertexPosition, 1.0);
 }
 
@@ -0,0 +1,75 @@
+//
+// Vertex shader for instanced foliage
+//
+#include <attributes>
+
+// Per instance attributes
+in vec4 InstancePosition;   // Position and scale
+in vec4 InstanceRotation;   // Rotation quaternion
+
+// Model uniforms
+uniform mat4 ModelMatrix;
+uniform mat4 ModelViewMatrix;
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+uniform mat3 WindMatrix;    // Transforms world directions to the model space
+uniform vec4 Foliage;       // Fade start distance, fade end distance, wind bend and alpha cutoff
+
+#include <lights>
+#include <material>
+#include <phong_model>
+#include <wind>
+
+// Outputs for the fragment shader.
+out vec3 ColorFrontAmbdiff;
+out vec3 ColorFrontSpec;
+out vec3 ColorBackAmbdiff;
+out vec3 ColorBackSpec;
+out vec2 FragTexcoord;
+
+// Rotates a vector by a unit quaternion.
+vec3 rotate(vec4 q, vec3 v) {
+
+    return v + 2.0 * cross(q.xyz, cross(q.xyz, v) + q.w * v);
+}
+
+void main() {
+
+    // Random value of the instance, derived from its position
+    float rnd = fract(sin(dot(InstancePosition.xz, vec2(12.9898, 78.233))) * 43758.5453);
+
+    // The density falls off with the distance from the camera and the instances
+    // whose random value is above it shrink and disappear
+    float scale = InstancePosition.w;
+    if (Foliage.y > Foliage.x) {
+        vec4 origin = ModelViewMatrix * vec4(InstancePosition.xyz, 1.0);
+        float density = 1.0 - smoothstep(Foliage.x, Foliage.y, length(origin.xyz));
+        scale *= clamp((density - 0.9 * rnd) * 10.0, 0.0, 1.0);
+    }
+    vec3 position = InstancePosition.xyz + rotate(InstanceRotation, VertexPosition * scale);
+    vec3 normal = rotate(InstanceRotation, VertexNormal);
+
+    // The wind bends the vertices above the origin of the instance with the square of their height,
+    // with a flutter whose phase is random per instance
+    float height = max(VertexPosition.y * scale, 0.0);
+    vec3 world = (ModelMatrix * vec4(position, 1.0)).xyz;
+    float flutter = 1.0 + 0.15 * sin(WindTime * 2.7 + rnd * 6.2831853);
+    position += WindMatrix * windVelocity(world) * (Foliage.z * height * height * flutter);
+
+    // Calculates the vertex colors using the Phong model for the front and back faces
+    vec4 Position = ModelViewMatrix * vec4(position, 1.0);
+    vec3 Normal = normalize(NormalMatrix * normal);
+    vec3 camDir = normalize(-Position.xyz);
+    phongModel(Position,  Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
+    phongModel(Position, -Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
+
+    vec2 texcoord = VertexTexcoord;
+#if MAT_TEXTURES > 0
+    // Flips texture coordinate Y if requested.
+    if (MatTexFlipY(0)) {
+        texcoord.y = 1.0 - texcoord.y;
+    }
+#endif
+    FragTexcoord = texcoord;
+    gl_Position = MVP * vec4(position, 1.0);
+}
@@ -0,0 +1,59 @@
+//
+// Fragment shader for the infinite reference grid
+//
+
+precision highp float;
+
+// Input uniforms
+uniform vec3 CameraPos;    // Camera position in the grid local space
+uniform vec4 GridParams;   // Cell size, cells per major line, fade distance and opacity
+uniform vec3 GridColor[4]; // Minor lines, major lines, X axis and Z axis colors
+
+// Inputs from vertex shader
+in vec3 GridPos;
+
+// Output
+out vec4 FragColor;
+
+// Returns the coverage of the lines of a grid with unit cells at the specified coordinates,
+// about one pixel wide regardless of the distance.
+float gridLines(vec2 coord) {
+
+    vec2 d = fwidth(coord);
+    vec2 g = abs(fract(coord - 0.5) - 0.5) / d;
+    return 1.0 - min(min(g.x, g.y), 1.0);
+}
+
+// Returns the coverage of a line along the axis where the specified coordinate is zero.
+float axisLine(float coord) {
+
+    return 1.0 - min(abs(coord) / fwidth(coord), 1.0);
+}
+
+void main() {
+
+    vec2 coord = GridPos.xz / GridParams.x;
+
+    // Minor lines fade out before their cells get too small to be distinguished
+    vec2 d = fwidth(coord);
+    float minor = gridLines(coord) * (1.0 - smoothstep(0.25, 0.5, max(d.x, d.y))) * 0.5;
+    float major = gridLines(coord / GridParams.y);
+
+    vec3 color = mix(GridColor[0], GridColor[1], major);
+    float alpha = max(minor, major);
+
+    // The X axis is the line where z is zero and the Z axis where x is zero
+    float axisX = axisLine(GridPos.z);
+    float axisZ = axisLine(GridPos.x);
+    color = mix(color, GridColor[2], axisX);
+    color = mix(color, GridColor[3], axisZ);
+    alpha = max(alpha, max(axisX, axisZ));
+
+    // Fades with the distance from the camera
+    float dist = length(GridPos - CameraPos);
+    alpha *= (1.0 - smoothstep(GridParams.z * 0.5, GridParams.z, dist)) * GridParams.w;
+    if (alpha <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(color, alpha);
+}
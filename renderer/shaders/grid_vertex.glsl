@@ -0,0 +1,21 @@
+//
+// Vertex shader for the infinite reference grid
+//
+
+#include <attributes>
+
+// Input uniforms
+uniform mat4 MVP;
+uniform vec3 CameraPos;  // Camera position in the grid local space
+uniform vec4 GridParams; // Cell size, cells per major line, fade distance and opacity
+
+// Outputs for fragment shader
+out vec3 GridPos;
+
+void main() {
+
+    // Scales the unit quad to the fade distance and centers it below the camera
+    vec3 position = vec3(VertexPosition.x * GridParams.z + CameraPos.x, 0.0, VertexPosition.z * GridParams.z + CameraPos.z);
+    GridPos = position;
+    gl_Position = MVP * vec4(position, 1.0);
+}
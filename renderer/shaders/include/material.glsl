@@ -15,8 +15,19 @@ uniform vec3 Material[6];
 #define MatPointRotationZ   Material[5].x
 
 #if MAT_TEXTURES > 0
+    #ifdef MAT_TEXTURE_ARRAYS
+    #ifdef GL_ES
+    precision highp sampler2DArray;
+    #endif
+    // Texture array sampler array and layer of each texture
+    uniform sampler2DArray MatTexture[MAT_TEXTURES];
+    uniform float MatTexLayer[MAT_TEXTURES];
+    #define MatTexSample(a, uv)	texture(MatTexture[a], vec3(uv, MatTexLayer[a]))
+    #else
     // Texture unit sampler array
     uniform sampler2D MatTexture[MAT_TEXTURES];
+    #define MatTexSample(a, uv)	texture(MatTexture[a], uv)
+    #endif
     // Texture parameters (3*vec2 per texture)
     uniform vec2 MatTexinfo[3*MAT_TEXTURES];
     // Macros to access elements inside the MatTexinfo array
@@ -32,7 +43,7 @@ uniform vec3 Material[6];
 // vec4 texMixed
 vec4 MIX_TEXTURE(vec4 texMixed, vec2 FragTexcoord, int i) {
     if (MatTexVisible(i)) {
-        vec4 texColor = texture(MatTexture[i], FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
+        vec4 texColor = MatTexSample(i, FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
         if (i == 0) {
             texMixed = texColor;
         } else {
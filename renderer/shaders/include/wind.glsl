@@ -0,0 +1,21 @@
+//
+// Wind uniforms and velocity, matching environment.Wind
+//
+uniform vec4 Wind[2];
+
+#define WindDirection       Wind[0].xyz
+#define WindStrength        Wind[0].w
+#define WindGustiness       Wind[1].x
+#define WindGustFrequency   Wind[1].y
+#define WindGustScale       Wind[1].z
+#define WindTime            Wind[1].w
+
+// Returns the wind velocity at the specified world position.
+// Gusts travel in the wind direction with the wind time.
+vec3 windVelocity(vec3 worldPos) {
+
+    float phase = dot(worldPos, WindDirection) * WindGustScale;
+    float t = WindTime * WindGustFrequency * 6.2831853;
+    float gust = 0.5 + 0.25 * sin(t - phase) + 0.25 * sin(2.3 * t - 1.7 * phase + 1.3);
+    return WindDirection * WindStrength * (1.0 + WindGustiness * (2.0 * gust - 1.0));
+}
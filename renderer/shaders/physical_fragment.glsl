@@ -51,6 +51,7 @@ uniform vec4 Material[3];
 #define uEmissiveColor      Material[1]
 #define uMetallicFactor     Material[2].x
 #define uRoughnessFactor    Material[2].y
+#define uAlphaCutoff        Material[2].z
 
 #include <lights>
 
@@ -90,6 +91,10 @@ const float M_PI = 3.141592653589793;
 const float c_MinRoughness = 0.04;
 
 vec4 SRGBtoLINEAR(vec4 srgbIn) {
+#ifdef LINEAR_COLORS
+    // sRGB textures are decoded when sampled
+    return srgbIn;
+#else
 //#ifdef MANUAL_SRGB
 //    #ifdef SRGB_FAST_APPROXIMATION
 //        vec3 linOut = pow(srgbIn.xyz,vec3(2.2));
@@ -101,6 +106,7 @@ vec4 SRGBtoLINEAR(vec4 srgbIn) {
 //#else //MANUAL_SRGB
 //    return srgbIn;
 //#endif //MANUAL_SRGB
+#endif
 }
 
 // Find the normal for this fragment, pulling either from a predefined normal map
@@ -268,6 +274,11 @@ void main() {
 #else
     vec4 baseColor = uBaseColor;
 #endif
+#ifdef ALPHA_MASK
+    if (baseColor.a < uAlphaCutoff) {
+        discard;
+    }
+#endif
 
     vec3 f0 = vec3(0.04);
     vec3 diffuseColor = baseColor.rgb * (vec3(1.0) - f0);
@@ -409,7 +420,12 @@ void main() {
 //    color = vec3(metallic);
 
     // Final fragment color
+#ifdef LINEAR_COLORS
+    // The sRGB framebuffer encodes the output
+    FragColor = vec4(color, baseColor.a);
+#else
     FragColor = vec4(pow(color,vec3(1.0/2.2)), baseColor.a);
+#endif
 }
 
 
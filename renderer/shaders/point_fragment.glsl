@@ -9,7 +9,7 @@ precision highp float;
 vec4 MIX_POINT_TEXTURE(vec4 texMixed, mat2 rotation, int i) {                                                           \
     if (MatTexVisible(i)) {                                                                                      \
         vec2 pt = gl_PointCoord - vec2(0.5);                                                                     \
-        vec4 texColor = texture(MatTexture[i], (rotation * pt + vec2(0.5)) * MatTexRepeat(i) + MatTexOffset(i)); \
+        vec4 texColor = MatTexSample(i, (rotation * pt + vec2(0.5)) * MatTexRepeat(i) + MatTexOffset(i));        \
         if (i == 0) {                                                                                            \
             texMixed = texColor;                                                                                 \
         } else {                                                                                                 \
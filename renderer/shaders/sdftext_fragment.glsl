@@ -0,0 +1,41 @@
+//
+// Fragment shader for signed distance field text
+//
+
+precision highp float;
+
+#include <material>
+
+// The outline uses the emissive color and its width, as a distance, the point size
+#define MatOutlineColor     MatEmissiveColor
+#define MatOutlineWidth     MatPointSize
+
+// Inputs from vertex shader
+in vec3 Color;
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    // Distance to the glyph outline stored in the first texture, 0.5 on the outline
+    float dist = 1.0;
+#if MAT_TEXTURES>0
+    dist = MatTexSample(0, FragTexcoord).a;
+#endif
+
+    // Antialiases the edges over about a pixel at any scale
+    float smoothing = max(fwidth(dist) * 0.75, 1e-4);
+    float alpha = smoothstep(0.5 - smoothing, 0.5 + smoothing, dist);
+    vec3 color = Color;
+    if (MatOutlineWidth > 0.0) {
+        float edge = 0.5 - MatOutlineWidth;
+        color = mix(MatOutlineColor, Color, alpha);
+        alpha = smoothstep(edge - smoothing, edge + smoothing, dist);
+    }
+    if (alpha <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(color, alpha * MatOpacity);
+}
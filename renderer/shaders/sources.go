@@ -117,8 +117,19 @@ uniform vec3 Material[6];
 #define MatPointRotationZ   Material[5].x
 
 #if MAT_TEXTURES > 0
+    #ifdef MAT_TEXTURE_ARRAYS
+    #ifdef GL_ES
+    precision highp sampler2DArray;
+    #endif
+    // Texture array sampler array and layer of each texture
+    uniform sampler2DArray MatTexture[MAT_TEXTURES];
+    uniform float MatTexLayer[MAT_TEXTURES];
+    #define MatTexSample(a, uv)	texture(MatTexture[a], vec3(uv, MatTexLayer[a]))
+    #else
     // Texture unit sampler array
     uniform sampler2D MatTexture[MAT_TEXTURES];
+    #define MatTexSample(a, uv)	texture(MatTexture[a], uv)
+    #endif
     // Texture parameters (3*vec2 per texture)
     uniform vec2 MatTexinfo[3*MAT_TEXTURES];
     // Macros to access elements inside the MatTexinfo array
@@ -134,7 +145,7 @@ uniform vec3 Material[6];
 // vec4 texMixed
 vec4 MIX_TEXTURE(vec4 texMixed, vec2 FragTexcoord, int i) {
     if (MatTexVisible(i)) {
-        vec4 texColor = texture(MatTexture[i], FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
+        vec4 texColor = MatTexSample(i, FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
         if (i == 0) {
             texMixed = texColor;
         } else {
@@ -269,6 +280,29 @@ void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 m
 }
 `
 
+const include_wind_source = `//
+// Wind uniforms and velocity, matching environment.Wind
+//
+uniform vec4 Wind[2];
+
+#define WindDirection       Wind[0].xyz
+#define WindStrength        Wind[0].w
+#define WindGustiness       Wind[1].x
+#define WindGustFrequency   Wind[1].y
+#define WindGustScale       Wind[1].z
+#define WindTime            Wind[1].w
+
+// Returns the wind velocity at the specified world position.
+// Gusts travel in the wind direction with the wind time.
+vec3 windVelocity(vec3 worldPos) {
+
+    float phase = dot(worldPos, WindDirection) * WindGustScale;
+    float t = WindTime * WindGustFrequency * 6.2831853;
+    float gust = 0.5 + 0.25 * sin(t - phase) + 0.25 * sin(2.3 * t - 1.7 * phase + 1.3);
+    return WindDirection * WindStrength * (1.0 + WindGustiness * (2.0 * gust - 1.0));
+}
+`
+
 const basic_fragment_source = `//
 // Fragment Shader template
 //
@@ -298,13 +332,232 @@ out vec3 Color;
 
 void main() {
 
+#ifdef LINEAR_COLORS
+    // Vertex colors are specified in sRGB
+    Color = mix(VertexColor / 12.92, pow((VertexColor + 0.055) / 1.055, vec3(2.4)), step(vec3(0.04045), VertexColor));
+#else
     Color = VertexColor;
+#endif
     gl_Position = MVP * vec4(VertexPosition, 1.0);
 }
 
 
 `
 
+const foliage_fragment_source = `//
+// Fragment shader for instanced foliage
+//
+
+precision highp float;
+
+#include <material>
+
+uniform vec4 Foliage;       // Fade start distance, fade end distance, wind bend and alpha cutoff
+
+// Inputs from Vertex shader
+in vec3 ColorFrontAmbdiff;
+in vec3 ColorFrontSpec;
+in vec3 ColorBackAmbdiff;
+in vec3 ColorBackSpec;
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    // Mix material color with textures colors
+    vec4 texMixed = vec4(1);
+    #if MAT_TEXTURES==1
+        texMixed = MIX_TEXTURE(texMixed, FragTexcoord, 0);
+    #elif MAT_TEXTURES==2
+        texMixed = MIX_TEXTURE(texMixed, FragTexcoord, 0);
+        texMixed = MIX_TEXTURE(texMixed, FragTexcoord, 1);
+    #elif MAT_TEXTURES==3
+        texMixed = MIX_TEXTURE(texMixed, FragTexcoord, 0);
+        texMixed = MIX_TEXTURE(texMixed, FragTexcoord, 1);
+        texMixed = MIX_TEXTURE(texMixed, FragTexcoord, 2);
+    #endif
+
+    // Cut out the transparent parts of leaves and grass cards
+    if (texMixed.a < Foliage.w) {
+        discard;
+    }
+
+    vec4 colorAmbDiff;
+    vec4 colorSpec;
+    if (gl_FrontFacing) {
+        colorAmbDiff = vec4(ColorFrontAmbdiff, MatOpacity);
+        colorSpec = vec4(ColorFrontSpec, 0);
+    } else {
+        colorAmbDiff = vec4(ColorBackAmbdiff, MatOpacity);
+        colorSpec = vec4(ColorBackSpec, 0);
+    }
+    FragColor = min(colorAmbDiff * texMixed + colorSpec, vec4(1));
+}
+`
+
+const foliage_vertex_source = `//
+// Vertex shader for instanced foliage
+//
+#include <attributes>
+
+// Per instance attributes
+in vec4 InstancePosition;   // Position and scale
+in vec4 InstanceRotation;   // Rotation quaternion
+
+// Model uniforms
+uniform mat4 ModelMatrix;
+uniform mat4 ModelViewMatrix;
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+uniform mat3 WindMatrix;    // Transforms world directions to the model space
+uniform vec4 Foliage;       // Fade start distance, fade end distance, wind bend and alpha cutoff
+
+#include <lights>
+#include <material>
+#include <phong_model>
+#include <wind>
+
+// Outputs for the fragment shader.
+out vec3 ColorFrontAmbdiff;
+out vec3 ColorFrontSpec;
+out vec3 ColorBackAmbdiff;
+out vec3 ColorBackSpec;
+out vec2 FragTexcoord;
+
+// Rotates a vector by a unit quaternion.
+vec3 rotate(vec4 q, vec3 v) {
+
+    return v + 2.0 * cross(q.xyz, cross(q.xyz, v) + q.w * v);
+}
+
+void main() {
+
+    // Random value of the instance, derived from its position
+    float rnd = fract(sin(dot(InstancePosition.xz, vec2(12.9898, 78.233))) * 43758.5453);
+
+    // The density falls off with the distance from the camera and the instances
+    // whose random value is above it shrink and disappear
+    float scale = InstancePosition.w;
+    if (Foliage.y > Foliage.x) {
+        vec4 origin = ModelViewMatrix * vec4(InstancePosition.xyz, 1.0);
+        float density = 1.0 - smoothstep(Foliage.x, Foliage.y, length(origin.xyz));
+        scale *= clamp((density - 0.9 * rnd) * 10.0, 0.0, 1.0);
+    }
+    vec3 position = InstancePosition.xyz + rotate(InstanceRotation, VertexPosition * scale);
+    vec3 normal = rotate(InstanceRotation, VertexNormal);
+
+    // The wind bends the vertices above the origin of the instance with the square of their height,
+    // with a flutter whose phase is random per instance
+    float height = max(VertexPosition.y * scale, 0.0);
+    vec3 world = (ModelMatrix * vec4(position, 1.0)).xyz;
+    float flutter = 1.0 + 0.15 * sin(WindTime * 2.7 + rnd * 6.2831853);
+    position += WindMatrix * windVelocity(world) * (Foliage.z * height * height * flutter);
+
+    // Calculates the vertex colors using the Phong model for the front and back faces
+    vec4 Position = ModelViewMatrix * vec4(position, 1.0);
+    vec3 Normal = normalize(NormalMatrix * normal);
+    vec3 camDir = normalize(-Position.xyz);
+    phongModel(Position,  Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
+    phongModel(Position, -Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
+
+    vec2 texcoord = VertexTexcoord;
+#if MAT_TEXTURES > 0
+    // Flips texture coordinate Y if requested.
+    if (MatTexFlipY(0)) {
+        texcoord.y = 1.0 - texcoord.y;
+    }
+#endif
+    FragTexcoord = texcoord;
+    gl_Position = MVP * vec4(position, 1.0);
+}
+`
+
+const grid_fragment_source = `//
+// Fragment shader for the infinite reference grid
+//
+
+precision highp float;
+
+// Input uniforms
+uniform vec3 CameraPos;    // Camera position in the grid local space
+uniform vec4 GridParams;   // Cell size, cells per major line, fade distance and opacity
+uniform vec3 GridColor[4]; // Minor lines, major lines, X axis and Z axis colors
+
+// Inputs from vertex shader
+in vec3 GridPos;
+
+// Output
+out vec4 FragColor;
+
+// Returns the coverage of the lines of a grid with unit cells at the specified coordinates,
+// about one pixel wide regardless of the distance.
+float gridLines(vec2 coord) {
+
+    vec2 d = fwidth(coord);
+    vec2 g = abs(fract(coord - 0.5) - 0.5) / d;
+    return 1.0 - min(min(g.x, g.y), 1.0);
+}
+
+// Returns the coverage of a line along the axis where the specified coordinate is zero.
+float axisLine(float coord) {
+
+    return 1.0 - min(abs(coord) / fwidth(coord), 1.0);
+}
+
+void main() {
+
+    vec2 coord = GridPos.xz / GridParams.x;
+
+    // Minor lines fade out before their cells get too small to be distinguished
+    vec2 d = fwidth(coord);
+    float minor = gridLines(coord) * (1.0 - smoothstep(0.25, 0.5, max(d.x, d.y))) * 0.5;
+    float major = gridLines(coord / GridParams.y);
+
+    vec3 color = mix(GridColor[0], GridColor[1], major);
+    float alpha = max(minor, major);
+
+    // The X axis is the line where z is zero and the Z axis where x is zero
+    float axisX = axisLine(GridPos.z);
+    float axisZ = axisLine(GridPos.x);
+    color = mix(color, GridColor[2], axisX);
+    color = mix(color, GridColor[3], axisZ);
+    alpha = max(alpha, max(axisX, axisZ));
+
+    // Fades with the distance from the camera
+    float dist = length(GridPos - CameraPos);
+    alpha *= (1.0 - smoothstep(GridParams.z * 0.5, GridParams.z, dist)) * GridParams.w;
+    if (alpha <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(color, alpha);
+}
+`
+
+const grid_vertex_source = `//
+// Vertex shader for the infinite reference grid
+//
+
+#include <attributes>
+
+// Input uniforms
+uniform mat4 MVP;
+uniform vec3 CameraPos;  // Camera position in the grid local space
+uniform vec4 GridParams; // Cell size, cells per major line, fade distance and opacity
+
+// Outputs for fragment shader
+out vec3 GridPos;
+
+void main() {
+
+    // Scales the unit quad to the fade distance and centers it below the camera
+    vec3 position = vec3(VertexPosition.x * GridParams.z + CameraPos.x, 0.0, VertexPosition.z * GridParams.z + CameraPos.z);
+    GridPos = position;
+    gl_Position = MVP * vec4(position, 1.0);
+}
+`
+
 const panel_fragment_source = `//
 // Fragment Shader template
 //
@@ -617,6 +870,7 @@ uniform vec4 Material[3];
 #define uEmissiveColor      Material[1]
 #define uMetallicFactor     Material[2].x
 #define uRoughnessFactor    Material[2].y
+#define uAlphaCutoff        Material[2].z
 
 #include <lights>
 
@@ -656,6 +910,10 @@ const float M_PI = 3.141592653589793;
 const float c_MinRoughness = 0.04;
 
 vec4 SRGBtoLINEAR(vec4 srgbIn) {
+#ifdef LINEAR_COLORS
+    // sRGB textures are decoded when sampled
+    return srgbIn;
+#else
 //#ifdef MANUAL_SRGB
 //    #ifdef SRGB_FAST_APPROXIMATION
 //        vec3 linOut = pow(srgbIn.xyz,vec3(2.2));
@@ -667,6 +925,7 @@ vec4 SRGBtoLINEAR(vec4 srgbIn) {
 //#else //MANUAL_SRGB
 //    return srgbIn;
 //#endif //MANUAL_SRGB
+#endif
 }
 
 // Find the normal for this fragment, pulling either from a predefined normal map
@@ -834,6 +1093,11 @@ void main() {
 #else
     vec4 baseColor = uBaseColor;
 #endif
+#ifdef ALPHA_MASK
+    if (baseColor.a < uAlphaCutoff) {
+        discard;
+    }
+#endif
 
     vec3 f0 = vec3(0.04);
     vec3 diffuseColor = baseColor.rgb * (vec3(1.0) - f0);
@@ -975,7 +1239,12 @@ void main() {
 //    color = vec3(metallic);
 
     // Final fragment color
+#ifdef LINEAR_COLORS
+    // The sRGB framebuffer encodes the output
+    FragColor = vec4(color, baseColor.a);
+#else
     FragColor = vec4(pow(color,vec3(1.0/2.2)), baseColor.a);
+#endif
 }
 
 
@@ -1039,7 +1308,7 @@ const point_fragment_source = `precision highp float;
 vec4 MIX_POINT_TEXTURE(vec4 texMixed, mat2 rotation, int i) {                                                           \
     if (MatTexVisible(i)) {                                                                                      \
         vec2 pt = gl_PointCoord - vec2(0.5);                                                                     \
-        vec4 texColor = texture(MatTexture[i], (rotation * pt + vec2(0.5)) * MatTexRepeat(i) + MatTexOffset(i)); \
+        vec4 texColor = MatTexSample(i, (rotation * pt + vec2(0.5)) * MatTexRepeat(i) + MatTexOffset(i));        \
         if (i == 0) {                                                                                            \
             texMixed = texColor;                                                                                 \
         } else {                                                                                                 \
@@ -1110,6 +1379,83 @@ void main() {
 
 `
 
+const sdftext_fragment_source = `//
+// Fragment shader for signed distance field text
+//
+
+precision highp float;
+
+#include <material>
+
+// The outline uses the emissive color and its width, as a distance, the point size
+#define MatOutlineColor     MatEmissiveColor
+#define MatOutlineWidth     MatPointSize
+
+// Inputs from vertex shader
+in vec3 Color;
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+
+void main() {
+
+    // Distance to the glyph outline stored in the first texture, 0.5 on the outline
+    float dist = 1.0;
+#if MAT_TEXTURES>0
+    dist = MatTexSample(0, FragTexcoord).a;
+#endif
+
+    // Antialiases the edges over about a pixel at any scale
+    float smoothing = max(fwidth(dist) * 0.75, 1e-4);
+    float alpha = smoothstep(0.5 - smoothing, 0.5 + smoothing, dist);
+    vec3 color = Color;
+    if (MatOutlineWidth > 0.0) {
+        float edge = 0.5 - MatOutlineWidth;
+        color = mix(MatOutlineColor, Color, alpha);
+        alpha = smoothstep(edge - smoothing, edge + smoothing, dist);
+    }
+    if (alpha <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(color, alpha * MatOpacity);
+}
+`
+
+const sdftext_vertex_source = `//
+// Vertex shader for signed distance field text
+//
+
+#include <attributes>
+
+// Input uniforms
+uniform mat4 MVP;
+
+#include <material>
+
+// Outputs for fragment shader
+out vec3 Color;
+out vec2 FragTexcoord;
+
+void main() {
+
+    // Applies transformation to vertex position
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+
+    // Outputs color
+    Color = MatDiffuseColor;
+
+    // Flips texture coordinate Y if requested.
+    vec2 texcoord = VertexTexcoord;
+#if MAT_TEXTURES>0
+    if (MatTexFlipY(0)) {
+        texcoord.y = 1.0 - texcoord.y;
+    }
+#endif
+    FragTexcoord = texcoord;
+}
+`
+
 const sprite_fragment_source = `//
 // Fragment shader for sprite
 //
@@ -1131,7 +1477,7 @@ void main() {
     vec4 texCombined = vec4(1);
 #if MAT_TEXTURES>0
     for (int i = 0; i < {{.MatTexturesMax}}; i++) {
-        vec4 texcolor = texture(MatTexture[i], FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
+        vec4 texcolor = MatTexSample(i, FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
         if (i == 0) {
             texCombined = texcolor;
         } else {
@@ -1300,6 +1646,7 @@ var includeMap = map[string]string{
 	"morphtarget_vertex_declaration":  include_morphtarget_vertex_declaration_source,
 	"morphtarget_vertex_declaration2": include_morphtarget_vertex_declaration2_source,
 	"phong_model":                     include_phong_model_source,
+	"wind":                            include_wind_source,
 }
 
 // Maps shader name with its source code
@@ -1307,6 +1654,10 @@ var shaderMap = map[string]string{
 
 	"basic_fragment":    basic_fragment_source,
 	"basic_vertex":      basic_vertex_source,
+	"foliage_fragment":  foliage_fragment_source,
+	"foliage_vertex":    foliage_vertex_source,
+	"grid_fragment":     grid_fragment_source,
+	"grid_vertex":       grid_vertex_source,
 	"panel_fragment":    panel_fragment_source,
 	"panel_vertex":      panel_vertex_source,
 	"phong_fragment":    phong_fragment_source,
@@ -1315,6 +1666,8 @@ var shaderMap = map[string]string{
 	"physical_vertex":   physical_vertex_source,
 	"point_fragment":    point_fragment_source,
 	"point_vertex":      point_vertex_source,
+	"sdftext_fragment":  sdftext_fragment_source,
+	"sdftext_vertex":    sdftext_vertex_source,
 	"sprite_fragment":   sprite_fragment_source,
 	"sprite_vertex":     sprite_vertex_source,
 	"standard_fragment": standard_fragment_source,
@@ -1325,10 +1678,13 @@ var shaderMap = map[string]string{
 var programMap = map[string]ProgramInfo{
 
 	"basic":    {"basic_vertex", "basic_fragment", ""},
+	"foliage":  {"foliage_vertex", "foliage_fragment", ""},
+	"grid":     {"grid_vertex", "grid_fragment", ""},
 	"panel":    {"panel_vertex", "panel_fragment", ""},
 	"phong":    {"phong_vertex", "phong_fragment", ""},
 	"physical": {"physical_vertex", "physical_fragment", ""},
 	"point":    {"point_vertex", "point_fragment", ""},
+	"sdftext":  {"sdftext_vertex", "sdftext_fragment", ""},
 	"sprite":   {"sprite_vertex", "sprite_fragment", ""},
 	"standard": {"standard_vertex", "standard_fragment", ""},
 }
@@ -19,7 +19,7 @@ void main() {
     vec4 texCombined = vec4(1);
 #if MAT_TEXTURES>0
     for (int i = 0; i < {{.MatTexturesMax}}; i++) {
-        vec4 texcolor = texture(MatTexture[i], FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
+        vec4 texcolor = MatTexSample(i, FragTexcoord * MatTexRepeat(i) + MatTexOffset(i));
         if (i == 0) {
             texCombined = texcolor;
         } else {
@@ -5,6 +5,7 @@
 package renderer
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -20,6 +21,10 @@ var rexInclude *regexp.Regexp
 
 const indexParameter = "{i}"
 
+// ErrProgramPending is returned by Shaman.SetProgram, when asynchronous compilation is
+// enabled, if the program satisfying the specs is still being built.
+var ErrProgramPending = errors.New("shader program pending")
+
 func init() {
 
 	rexInclude = regexp.MustCompile(`#include\s+<(.*)>\s*(?:\[(.*)]|)`)
@@ -52,7 +57,17 @@ type Shaman struct {
 	shadersm map[string]string              // maps shader name to its template
 	proginfo map[string]shaders.ProgramInfo // maps name of the program to ProgramInfo
 	programs []ProgSpecs                    // list of compiled programs with specs
+	pending  []ProgSpecs                    // list of programs being built with specs
+	failed   []progError                    // list of programs which failed to build, until sources change
 	specs    ShaderSpecs                    // Current shader specs
+	async    bool                           // build programs asynchronously
+}
+
+// progError keeps the error of a program which failed to build, so it is not rebuilt
+// every frame until the sources it may depend on change.
+type progError struct {
+	specs ShaderSpecs // specs of the program
+	err   error       // build error
 }
 
 // NewShaman creates and returns a pointer to a new shader manager
@@ -94,12 +109,14 @@ func (sm *Shaman) AddDefaultShaders() error {
 func (sm *Shaman) AddChunk(name, source string) {
 
 	sm.includes[name] = source
+	sm.failed = nil
 }
 
 // AddShader adds a shader program with the specified name and source code
 func (sm *Shaman) AddShader(name, source string) {
 
 	sm.shadersm[name] = source
+	sm.failed = nil
 }
 
 // AddProgram adds a program with the specified name and associated vertex
@@ -115,48 +132,74 @@ func (sm *Shaman) AddProgram(name, vertexName, fragName string, others ...string
 		Fragment: fragName,
 		Geometry: geomName,
 	}
+	sm.failed = nil
+}
+
+// SetAsync sets whether new shader programs are built asynchronously. When enabled,
+// SetProgram starts building the programs it does not find and returns ErrProgramPending
+// until Poll finds them built, so the application does not stall while shaders compile.
+// Builds run in parallel in the driver if it supports parallel shader compilation.
+func (sm *Shaman) SetAsync(async bool) {
+
+	sm.async = async
+}
+
+// Async returns whether new shader programs are built asynchronously.
+func (sm *Shaman) Async() bool {
+
+	return sm.async
 }
 
 // SetProgram sets the shader program to satisfy the specified specs.
 // Returns an indication if the current shader has changed and a possible error
-// when creating a new shader program.
+// when creating a new shader program. A program which failed to build is not rebuilt,
+// and its error is returned, until a chunk, shader or program is added or changed.
+// If asynchronous builds are enabled and the
+// program is not built yet, it returns ErrProgramPending and the current program
+// is not changed.
 // Receives a copy of the specs because it changes the fields which specify the
 // number of lights depending on the UseLights flags.
 func (sm *Shaman) SetProgram(s *ShaderSpecs) (bool, error) {
 
-	// Checks material use lights bit mask
+	return sm.setProgram(s, sm.async)
+}
+
+// setProgram sets the shader program to satisfy the specified specs,
+// starting its build without waiting for it if async is true.
+func (sm *Shaman) setProgram(s *ShaderSpecs, async bool) (bool, error) {
+
 	var specs ShaderSpecs
-	specs.copy(s)
-	if (specs.UseLights & material.UseLightAmbient) == 0 {
-		specs.AmbientLightsMax = 0
-	}
-	if (specs.UseLights & material.UseLightDirectional) == 0 {
-		specs.DirLightsMax = 0
-	}
-	if (specs.UseLights & material.UseLightPoint) == 0 {
-		specs.PointLightsMax = 0
-	}
-	if (specs.UseLights & material.UseLightSpot) == 0 {
-		specs.SpotLightsMax = 0
-	}
+	specs.lights(s)
 
 	// If current shader specs are the same as the specified specs, nothing to do.
 	if sm.specs.equals(&specs) {
 		return false, nil
 	}
 
+	// Search for program being built or which failed to build
+	if err := sm.status(&specs, async); err != nil {
+		return false, err
+	}
+
 	// Search for compiled program with the specified specs
-	for _, pinfo := range sm.programs {
-		if pinfo.specs.equals(&specs) {
-			sm.gs.UseProgram(pinfo.program)
-			sm.specs = specs
-			return true, nil
-		}
+	if prog := sm.find(&specs); prog != nil {
+		sm.gs.UseProgram(prog)
+		sm.specs = specs
+		return true, nil
 	}
 
 	// Generates new program with the specified specs
+	if async {
+		err := sm.start(&specs)
+		if err != nil {
+			sm.fail(&specs, err)
+			return false, err
+		}
+		return false, ErrProgramPending
+	}
 	prog, err := sm.GenProgram(&specs)
 	if err != nil {
+		sm.fail(&specs, err)
 		return false, err
 	}
 	log.Debug("Created new shader:%v", specs.Name)
@@ -168,9 +211,153 @@ func (sm *Shaman) SetProgram(s *ShaderSpecs) (bool, error) {
 	return true, nil
 }
 
+// WarmUp builds the program satisfying the specified specs if it was not built yet,
+// without changing the current program. Returns true if a new build was started.
+// If asynchronous builds are enabled it does not wait for the build (see Poll and Pending).
+func (sm *Shaman) WarmUp(s *ShaderSpecs) (bool, error) {
+
+	var specs ShaderSpecs
+	specs.lights(s)
+	if sm.find(&specs) != nil {
+		return false, nil
+	}
+	for _, pinfo := range sm.pending {
+		if pinfo.specs.equals(&specs) {
+			return false, nil
+		}
+	}
+	for _, perr := range sm.failed {
+		if perr.specs.equals(&specs) {
+			return false, perr.err
+		}
+	}
+	if sm.async {
+		err := sm.start(&specs)
+		if err != nil {
+			sm.fail(&specs, err)
+			return false, err
+		}
+		return true, nil
+	}
+	prog, err := sm.GenProgram(&specs)
+	if err != nil {
+		sm.fail(&specs, err)
+		return false, err
+	}
+	log.Debug("Created new shader:%v", specs.Name)
+	sm.programs = append(sm.programs, ProgSpecs{prog, specs})
+	return true, nil
+}
+
+// Poll finishes the asynchronous builds which completed, making their programs available
+// to SetProgram. Build errors are returned by the SetProgram calls for the same specs.
+// It is called by the renderer before rendering each frame and returns the number of
+// programs still being built.
+func (sm *Shaman) Poll() int {
+
+	pending := sm.pending[:0]
+	for _, pinfo := range sm.pending {
+		if !pinfo.program.Ready() {
+			pending = append(pending, pinfo)
+			continue
+		}
+		err := pinfo.program.Finish()
+		if err != nil {
+			log.Error("Error building shader:%v: %v", pinfo.specs.Name, err)
+			sm.fail(&pinfo.specs, err)
+			continue
+		}
+		log.Debug("Created new shader:%v", pinfo.specs.Name)
+		sm.programs = append(sm.programs, pinfo)
+	}
+	for i := len(pending); i < len(sm.pending); i++ {
+		sm.pending[i] = ProgSpecs{}
+	}
+	sm.pending = pending
+	return len(sm.pending)
+}
+
+// Pending returns the number of programs being built asynchronously.
+func (sm *Shaman) Pending() int {
+
+	return len(sm.pending)
+}
+
+// find returns the compiled program with the specified specs or nil.
+func (sm *Shaman) find(specs *ShaderSpecs) *gls.Program {
+
+	for _, pinfo := range sm.programs {
+		if pinfo.specs.equals(specs) {
+			return pinfo.program
+		}
+	}
+	return nil
+}
+
+// status checks the asynchronous build of the program with the specified specs.
+// It returns ErrProgramPending if it is being built or its error if it failed.
+// If async is false it waits for the pending build to finish.
+func (sm *Shaman) status(specs *ShaderSpecs, async bool) error {
+
+	for _, perr := range sm.failed {
+		if perr.specs.equals(specs) {
+			return perr.err
+		}
+	}
+	for i, pinfo := range sm.pending {
+		if !pinfo.specs.equals(specs) {
+			continue
+		}
+		if async {
+			return ErrProgramPending
+		}
+		sm.pending = append(sm.pending[:i], sm.pending[i+1:]...)
+		err := pinfo.program.Finish()
+		if err != nil {
+			sm.fail(specs, err)
+			return err
+		}
+		sm.programs = append(sm.programs, pinfo)
+		return nil
+	}
+	return nil
+}
+
+// fail keeps the error of the program with the specified specs which failed to build.
+func (sm *Shaman) fail(specs *ShaderSpecs, err error) {
+
+	sm.failed = append(sm.failed, progError{*specs, err})
+}
+
+// start starts building the program with the specified specs and adds it to the pending list.
+func (sm *Shaman) start(specs *ShaderSpecs) error {
+
+	prog, err := sm.startProgram(specs)
+	if err != nil {
+		return err
+	}
+	sm.pending = append(sm.pending, ProgSpecs{prog, *specs})
+	return nil
+}
+
 // GenProgram generates shader program from the specified specs
 func (sm *Shaman) GenProgram(specs *ShaderSpecs) (*gls.Program, error) {
 
+	prog, err := sm.startProgram(specs)
+	if err != nil {
+		return nil, err
+	}
+	err = prog.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return prog, nil
+}
+
+// startProgram generates the shader program from the specified specs, starting its build
+// without waiting for the results.
+func (sm *Shaman) startProgram(specs *ShaderSpecs) (*gls.Program, error) {
+
 	// Get info for the specified shader program
 	progInfo, ok := sm.proginfo[specs.Name]
 	if !ok {
@@ -236,7 +423,7 @@ func (sm *Shaman) GenProgram(specs *ShaderSpecs) (*gls.Program, error) {
 	if progInfo.Geometry != "" {
 		prog.AddShader(gls.GEOMETRY_SHADER, geomSource)
 	}
-	err = prog.Build()
+	err = prog.Start()
 	if err != nil {
 		return nil, err
 	}
@@ -331,6 +518,25 @@ func (ss *ShaderSpecs) copy(other *ShaderSpecs) {
 	}
 }
 
+// lights copies other spec into this, clearing the number of lights of
+// the types not used according to the UseLights flags.
+func (ss *ShaderSpecs) lights(other *ShaderSpecs) {
+
+	ss.copy(other)
+	if (ss.UseLights & material.UseLightAmbient) == 0 {
+		ss.AmbientLightsMax = 0
+	}
+	if (ss.UseLights & material.UseLightDirectional) == 0 {
+		ss.DirLightsMax = 0
+	}
+	if (ss.UseLights & material.UseLightPoint) == 0 {
+		ss.PointLightsMax = 0
+	}
+	if (ss.UseLights & material.UseLightSpot) == 0 {
+		ss.SpotLightsMax = 0
+	}
+}
+
 // equals compares two ShaderSpecs and returns true if they are effectively equal.
 func (ss *ShaderSpecs) equals(other *ShaderSpecs) bool {
 
@@ -0,0 +1,144 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"sort"
+
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+)
+
+// stateKey is the key used to sort opaque graphic materials to minimize state changes.
+type stateKey struct {
+	order    int     // User render order
+	shader   int     // Identifier of the shader program
+	material int     // Identifier of the material
+	texture  int     // Identifier of the first texture of the material
+	z        float32 // Z position relative to the camera
+}
+
+// shaderKey identifies the shader programs used by materials.
+type shaderKey struct {
+	name     string // Shader name
+	textures int    // Number of material textures
+}
+
+// stateSorter sorts opaque graphic materials by render order, shader program,
+// material and texture and then by their Z position relative to the camera.
+// Identifiers are assigned in the order the states are found, so the order of
+// the groups is stable from frame to frame.
+type stateSorter struct {
+	grmats   []*graphic.GraphicMaterial // Graphic materials being sorted
+	keys     []stateKey                 // Keys of the graphic materials
+	shaders  map[shaderKey]int          // Shader identifiers
+	mats     map[*material.Material]int // Material identifiers
+	textures map[interface{}]int        // Texture or texture array identifiers
+}
+
+// init initializes the sorter.
+func (ss *stateSorter) init() {
+
+	ss.shaders = make(map[shaderKey]int)
+	ss.mats = make(map[*material.Material]int)
+	ss.textures = make(map[interface{}]int)
+}
+
+// sort sorts the specified graphic materials in place, with the specified Z positions of their
+// graphics relative to the camera. As for zSort, objects using the same state are sorted back to front.
+func (ss *stateSorter) sort(grmats []*graphic.GraphicMaterial, depths map[*graphic.Graphic]float32) {
+
+	for k := range ss.shaders {
+		delete(ss.shaders, k)
+	}
+	for k := range ss.mats {
+		delete(ss.mats, k)
+	}
+	for k := range ss.textures {
+		delete(ss.textures, k)
+	}
+	ss.grmats = grmats
+	ss.keys = ss.keys[:0]
+	for _, grmat := range grmats {
+		ss.keys = append(ss.keys, ss.key(grmat, depths))
+	}
+	sort.Sort(ss)
+	ss.grmats = nil
+}
+
+// key returns the sort key of the specified graphic material.
+func (ss *stateSorter) key(grmat *graphic.GraphicMaterial, depths map[*graphic.Graphic]float32) stateKey {
+
+	gr := grmat.IGraphic().GetGraphic()
+	mat := grmat.IMaterial().GetMaterial()
+	var key stateKey
+	key.order = gr.RenderOrder()
+	key.shader = ss.id(shaderKey{mat.Shader(), mat.TextureCount()})
+	mid, ok := ss.mats[mat]
+	if !ok {
+		mid = len(ss.mats)
+		ss.mats[mat] = mid
+	}
+	key.material = mid
+	key.texture = -1
+	if textures := mat.Textures(); len(textures) > 0 {
+		// Textures packed into the same array share the binding
+		var tk interface{} = textures[0]
+		if a, _ := textures[0].Array(); a != nil && mat.TextureArrays() {
+			tk = a
+		}
+		tid, ok := ss.textures[tk]
+		if !ok {
+			tid = len(ss.textures)
+			ss.textures[tk] = tid
+		}
+		key.texture = tid
+	}
+	key.z = depths[gr]
+	return key
+}
+
+// id returns the identifier of the specified shader.
+func (ss *stateSorter) id(sk shaderKey) int {
+
+	id, ok := ss.shaders[sk]
+	if !ok {
+		id = len(ss.shaders)
+		ss.shaders[sk] = id
+	}
+	return id
+}
+
+// Len returns the number of graphic materials being sorted (sort.Interface).
+func (ss *stateSorter) Len() int {
+
+	return len(ss.grmats)
+}
+
+// Swap swaps two graphic materials and their keys (sort.Interface).
+func (ss *stateSorter) Swap(i, j int) {
+
+	ss.grmats[i], ss.grmats[j] = ss.grmats[j], ss.grmats[i]
+	ss.keys[i], ss.keys[j] = ss.keys[j], ss.keys[i]
+}
+
+// Less compares the keys of two graphic materials (sort.Interface).
+func (ss *stateSorter) Less(i, j int) bool {
+
+	k1, k2 := &ss.keys[i], &ss.keys[j]
+	if k1.order != k2.order {
+		return k1.order < k2.order
+	}
+	if k1.shader != k2.shader {
+		return k1.shader < k2.shader
+	}
+	if k1.material != k2.material {
+		return k1.material < k2.material
+	}
+	if k1.texture != k2.texture {
+		return k1.texture < k2.texture
+	}
+	return k1.z < k2.z
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+)
+
+// RenderStereo renders the specified scene side by side for the eyes of the specified
+// stereo rig, the left eye into the left half of the current OpenGL viewport and the right
+// eye into the right half, as expected by 3D TVs and phone headsets. The aspect ratio of
+// the center camera of the rig is set to the aspect ratio of each half.
+// The OpenGL viewport is restored afterwards. Rendering stops at the first error.
+func (r *Renderer) RenderStereo(scene core.INode, rig *camera.StereoRig) error {
+
+	vx, vy, vw, vh := r.gs.GetViewport()
+	defer r.gs.Viewport(vx, vy, vw, vh)
+
+	half := vw / 2
+	if half <= 0 || vh <= 0 {
+		return nil
+	}
+	cam := rig.Camera()
+	aspect := float32(half) / float32(vh)
+	if cam.Aspect() != aspect {
+		cam.SetAspect(aspect)
+	}
+	r.gs.Viewport(vx, vy, half, vh)
+	if err := r.Render(scene, rig.Eye(camera.LeftEye)); err != nil {
+		return err
+	}
+	r.gs.Viewport(vx+half, vy, vw-half, vh)
+	return r.Render(scene, rig.Eye(camera.RightEye))
+}
+
+// AddStereoPasses adds to the specified graph two passes, named after the specified name
+// with the ".left" and ".right" suffixes, which clear the specified targets and render the
+// specified scene into them for the left and right eyes of the specified stereo rig.
+// The targets can then be submitted to a headset or composed by another pass.
+// The aspect ratio of the center camera of the rig is set to the aspect ratio of the targets.
+func (r *Renderer) AddStereoPasses(g *RenderGraph, name string, scene core.INode, rig *camera.StereoRig, leftTarget, rightTarget string) (left, right *RenderPass) {
+
+	add := func(suffix string, eye camera.Eye, target string) *RenderPass {
+		return g.AddPass(name+suffix,
+			func(b *PassBuilder) { b.Write(target) },
+			func(ctx *PassContext) error {
+				if ctx.Width > 0 && ctx.Height > 0 {
+					cam := rig.Camera()
+					aspect := float32(ctx.Width) / float32(ctx.Height)
+					if cam.Aspect() != aspect {
+						cam.SetAspect(aspect)
+					}
+				}
+				if target != Backbuffer {
+					ctx.Clear(0, 0, 0, 0)
+				}
+				return r.Render(scene, rig.Eye(eye))
+			},
+		)
+	}
+	left = add(".left", camera.LeftEye, leftTarget)
+	right = add(".right", camera.RightEye, rightTarget)
+	return left, right
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+)
+
+// RenderViewports renders the specified scene once for each enabled viewport, with the
+// camera of the viewport, into its rectangle of the current OpenGL viewport, which is
+// usually the whole window. The aspect ratio of each camera is updated to its rectangle,
+// and the rendering is scissored to it, with its depth and stencil buffers and, if the
+// viewport has a background, its color cleared first. A background changes the OpenGL
+// clear color, so applications clearing the window each frame should set theirs again.
+// The OpenGL viewport is restored afterwards, so the GUI can then be rendered over the
+// whole window. Rendering stops at the first error.
+func (r *Renderer) RenderViewports(scene core.INode, viewports []*camera.CameraViewport) error {
+
+	vx, vy, vw, vh := r.gs.GetViewport()
+	defer func() {
+		r.gs.Disable(gls.SCISSOR_TEST)
+		r.gs.Viewport(vx, vy, vw, vh)
+	}()
+	r.gs.Enable(gls.SCISSOR_TEST)
+	for _, vp := range viewports {
+		if vp == nil || vp.Disabled || vp.Camera == nil {
+			continue
+		}
+		x, y, w, h := vp.Rect(int(vw), int(vh))
+		if w <= 0 || h <= 0 {
+			continue
+		}
+		x += int(vx)
+		y += int(vy)
+		r.gs.Viewport(int32(x), int32(y), int32(w), int32(h))
+		r.gs.Scissor(int32(x), int32(y), uint32(w), uint32(h))
+		vp.UpdateAspect(int(vw), int(vh))
+
+		mask := uint(gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT)
+		if bg := vp.Background; bg != nil {
+			r.gs.ClearColor(bg.R, bg.G, bg.B, bg.A)
+			mask |= gls.COLOR_BUFFER_BIT
+		}
+		r.gs.Clear(mask)
+
+		if err := r.Render(scene, vp.Camera); err != nil {
+			return err
+		}
+	}
+	return nil
+}
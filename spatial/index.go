@@ -0,0 +1,369 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package spatial implements a spatial index of the graphics of a scene.
+//
+// The index is a bounding volume hierarchy: a balanced binary tree of the world bounding
+// boxes of the graphics, enlarged by a margin so graphics moving a little do not change
+// the tree. It answers frustum, box, ray and nearest neighbor queries in logarithmic time,
+// and is updated incrementally by Update, which only reinserts the graphics which moved
+// out of their enlarged boxes.
+//
+// The renderer culls the graphics of an index with it (see Renderer.SetSpatialIndex) and
+// the raycaster of the collision package picks them with it (see Raycaster.IntersectIndex):
+//
+//	idx := spatial.NewIndex()
+//	idx.InsertTree(scene)
+//	rend.SetSpatialIndex(idx)
+package spatial
+
+import (
+	"sort"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/math32"
+)
+
+// DefaultMargin is the default margin of the boxes of the graphics in the tree.
+const DefaultMargin = 0.1
+
+// null is the index of no tree node.
+const null = -1
+
+// Index is a spatial index of graphics.
+// The world matrices of the graphics must be updated before they are inserted and updated.
+type Index struct {
+	Margin float32 // Margin added around the boxes of the graphics, in world units
+
+	nodes  []treeNode                 // Tree nodes, unused ones linked by their parent
+	root   int32                      // Root tree node or null
+	free   int32                      // First unused tree node or null
+	leaves map[*graphic.Graphic]int32 // Leaf of each graphic
+	mark   uint64                     // Stamp of the last frustum culling
+}
+
+// treeNode is a node of the tree. Leaves have graphics and no children.
+type treeNode struct {
+	box    math32.Box3      // Enlarged box of the graphic of leaves, union of the boxes of the children otherwise
+	parent int32            // Parent node, or next unused node
+	left   int32            // Left child or null for leaves
+	right  int32            // Right child or null for leaves
+	height int32            // Height of the subtree, zero for leaves
+	igr    graphic.IGraphic // Graphic of leaves
+	mark   uint64           // Stamp of the last frustum culling the leaf is inside of
+}
+
+// NewIndex creates and returns a pointer to a new empty index.
+func NewIndex() *Index {
+
+	x := new(Index)
+	x.Margin = DefaultMargin
+	x.root = null
+	x.free = null
+	x.leaves = make(map[*graphic.Graphic]int32)
+	return x
+}
+
+// Len returns the number of graphics in the index.
+func (x *Index) Len() int {
+
+	return len(x.leaves)
+}
+
+// Has returns whether the specified graphic is in the index.
+func (x *Index) Has(igr graphic.IGraphic) bool {
+
+	_, ok := x.leaves[igr.GetGraphic()]
+	return ok
+}
+
+// Bounds returns the box containing all the graphics of the index, enlarged by the margin.
+func (x *Index) Bounds() math32.Box3 {
+
+	if x.root == null {
+		return math32.Box3{}
+	}
+	return x.nodes[x.root].box
+}
+
+// Insert inserts the specified graphic in the index, if not already in it.
+func (x *Index) Insert(igr graphic.IGraphic) {
+
+	gr := igr.GetGraphic()
+	if _, ok := x.leaves[gr]; ok {
+		return
+	}
+	leaf := x.allocate()
+	n := &x.nodes[leaf]
+	n.igr = igr
+	n.box = x.fatBox(gr)
+	x.leaves[gr] = leaf
+	x.insertLeaf(leaf)
+}
+
+// Remove removes the specified graphic from the index and returns whether it was found.
+func (x *Index) Remove(igr graphic.IGraphic) bool {
+
+	gr := igr.GetGraphic()
+	leaf, ok := x.leaves[gr]
+	if !ok {
+		return false
+	}
+	delete(x.leaves, gr)
+	x.removeLeaf(leaf)
+	x.release(leaf)
+	return true
+}
+
+// InsertTree inserts the cullable graphics of the specified node and its descendants,
+// except GUI panels.
+func (x *Index) InsertTree(inode core.INode) {
+
+	inode.GetNode().Walk(func(inode core.INode) bool {
+		if _, ok := inode.(gui.IPanel); ok {
+			return false
+		}
+		if igr, ok := inode.(graphic.IGraphic); ok && igr.Cullable() {
+			x.Insert(igr)
+		}
+		return true
+	})
+}
+
+// RemoveTree removes the graphics of the specified node and its descendants.
+func (x *Index) RemoveTree(inode core.INode) {
+
+	inode.GetNode().Walk(func(inode core.INode) bool {
+		if igr, ok := inode.(graphic.IGraphic); ok {
+			x.Remove(igr)
+		}
+		return true
+	})
+}
+
+// Clear removes all the graphics from the index.
+func (x *Index) Clear() {
+
+	x.nodes = x.nodes[:0]
+	x.root = null
+	x.free = null
+	x.leaves = make(map[*graphic.Graphic]int32)
+}
+
+// Update reinserts the graphics whose world bounding boxes moved out of their boxes
+// in the tree, and returns their number.
+func (x *Index) Update() int {
+
+	moved := 0
+	for gr, leaf := range x.leaves {
+		bb := gr.WorldBoundingBox()
+		if x.nodes[leaf].box.ContainsBox(&bb) {
+			continue
+		}
+		x.removeLeaf(leaf)
+		x.nodes[leaf].box = x.fatBox(gr)
+		x.insertLeaf(leaf)
+		moved++
+	}
+	return moved
+}
+
+// QueryBox appends to the specified slice the graphics whose boxes intersect the
+// specified box and returns the resulting slice.
+func (x *Index) QueryBox(box *math32.Box3, found []graphic.IGraphic) []graphic.IGraphic {
+
+	x.query(func(b *math32.Box3) bool { return b.IsIntersectionBox(box) }, func(leaf int32) {
+		found = append(found, x.nodes[leaf].igr)
+	})
+	return found
+}
+
+// QueryFrustum appends to the specified slice the graphics whose boxes intersect the
+// specified frustum and returns the resulting slice.
+func (x *Index) QueryFrustum(frustum *math32.Frustum, found []graphic.IGraphic) []graphic.IGraphic {
+
+	x.query(frustum.IntersectsBox, func(leaf int32) {
+		found = append(found, x.nodes[leaf].igr)
+	})
+	return found
+}
+
+// Cull marks the graphics whose boxes intersect the specified frustum, which are then
+// reported inside of it by InFrustum until the next culling.
+func (x *Index) Cull(frustum *math32.Frustum) {
+
+	x.mark++
+	x.query(frustum.IntersectsBox, func(leaf int32) {
+		x.nodes[leaf].mark = x.mark
+	})
+}
+
+// InFrustum returns whether the specified graphic was inside the frustum of the last culling,
+// and whether it is in the index.
+func (x *Index) InFrustum(igr graphic.IGraphic) (in, ok bool) {
+
+	leaf, ok := x.leaves[igr.GetGraphic()]
+	if !ok {
+		return false, false
+	}
+	return x.nodes[leaf].mark == x.mark, true
+}
+
+// QueryRay appends to the specified slice the graphics whose boxes are intersected by the
+// specified ray closer than the specified distance from its origin, and returns the resulting
+// slice. The graphics are sorted by the distance of the intersections with their boxes.
+func (x *Index) QueryRay(ray *math32.Ray, far float32, found []graphic.IGraphic) []graphic.IGraphic {
+
+	type hit struct {
+		igr  graphic.IGraphic
+		dist float32
+	}
+	var hits []hit
+	origin := ray.Origin()
+	var point math32.Vector3
+	x.query(func(b *math32.Box3) bool {
+		if b.ContainsPoint(&origin) {
+			return true
+		}
+		return ray.IntersectBox(b, &point) != nil && point.DistanceTo(&origin) <= far
+	}, func(leaf int32) {
+		n := &x.nodes[leaf]
+		var dist float32
+		if !n.box.ContainsPoint(&origin) {
+			ray.IntersectBox(&n.box, &point)
+			dist = point.DistanceTo(&origin)
+		}
+		hits = append(hits, hit{igr: n.igr, dist: dist})
+	})
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].dist < hits[j].dist })
+	for _, h := range hits {
+		found = append(found, h.igr)
+	}
+	return found
+}
+
+// Nearest returns the graphic whose box is the nearest to the specified point and the
+// distance to its box, zero if the point is inside of it, or nil if the index is empty.
+func (x *Index) Nearest(point *math32.Vector3) (graphic.IGraphic, float32) {
+
+	found := x.NearestN(point, 1, nil)
+	if len(found) == 0 {
+		return nil, math32.Inf(1)
+	}
+	bb := found[0].GetGraphic().WorldBoundingBox()
+	return found[0], bb.DistanceToPoint(point)
+}
+
+// NearestN appends to the specified slice the specified number of graphics whose boxes are
+// the nearest to the specified point, sorted by distance, and returns the resulting slice.
+func (x *Index) NearestN(point *math32.Vector3, count int, found []graphic.IGraphic) []graphic.IGraphic {
+
+	if x.root == null || count <= 0 {
+		return found
+	}
+	type hit struct {
+		leaf int32
+		dist float32
+	}
+	best := make([]hit, 0, count)
+	// worst returns the distance beyond which subtrees are not searched
+	worst := func() float32 {
+		if len(best) < count {
+			return math32.Inf(1)
+		}
+		return best[len(best)-1].dist
+	}
+	var search func(id int32)
+	search = func(id int32) {
+		n := &x.nodes[id]
+		if n.left == null {
+			bb := n.igr.GetGraphic().WorldBoundingBox()
+			dist := bb.DistanceToPoint(point)
+			if dist >= worst() {
+				return
+			}
+			i := sort.Search(len(best), func(i int) bool { return best[i].dist > dist })
+			if len(best) < count {
+				best = append(best, hit{})
+			}
+			copy(best[i+1:], best[i:])
+			best[i] = hit{leaf: id, dist: dist}
+			return
+		}
+		// Search the nearest child first
+		first, second := n.left, n.right
+		d1 := x.nodes[first].box.DistanceToPoint(point)
+		d2 := x.nodes[second].box.DistanceToPoint(point)
+		if d2 < d1 {
+			first, second = second, first
+			d1, d2 = d2, d1
+		}
+		if d1 < worst() {
+			search(first)
+		}
+		if d2 < worst() {
+			search(second)
+		}
+	}
+	search(x.root)
+	for _, h := range best {
+		found = append(found, x.nodes[h.leaf].igr)
+	}
+	return found
+}
+
+// query calls the specified visit function for the leaves whose boxes, and the boxes of
+// their ancestors, satisfy the specified test.
+func (x *Index) query(test func(b *math32.Box3) bool, visit func(leaf int32)) {
+
+	if x.root == null {
+		return
+	}
+	stack := make([]int32, 0, 64)
+	stack = append(stack, x.root)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n := &x.nodes[id]
+		if !test(&n.box) {
+			continue
+		}
+		if n.left == null {
+			visit(id)
+			continue
+		}
+		stack = append(stack, n.left, n.right)
+	}
+}
+
+// fatBox returns the world bounding box of the specified graphic enlarged by the margin.
+func (x *Index) fatBox(gr *graphic.Graphic) math32.Box3 {
+
+	bb := gr.WorldBoundingBox()
+	bb.ExpandByScalar(x.Margin)
+	return bb
+}
+
+// allocate returns an unused tree node.
+func (x *Index) allocate() int32 {
+
+	if x.free == null {
+		x.nodes = append(x.nodes, treeNode{})
+		x.free = int32(len(x.nodes) - 1)
+		x.nodes[x.free].parent = null
+	}
+	id := x.free
+	x.free = x.nodes[id].parent
+	x.nodes[id] = treeNode{parent: null, left: null, right: null}
+	return id
+}
+
+// release makes the specified tree node unused.
+func (x *Index) release(id int32) {
+
+	x.nodes[id] = treeNode{parent: x.free, left: null, right: null}
+	x.free = id
+}
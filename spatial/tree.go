@@ -0,0 +1,212 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spatial
+
+import (
+	"github.com/g3n/engine/math32"
+)
+
+// insertLeaf inserts the specified leaf in the tree, as the sibling of the tree node
+// minimizing the increase of the surface area of the tree.
+func (x *Index) insertLeaf(leaf int32) {
+
+	if x.root == null {
+		x.root = leaf
+		x.nodes[leaf].parent = null
+		return
+	}
+
+	// Find the best sibling descending from the root
+	box := x.nodes[leaf].box
+	id := x.root
+	for x.nodes[id].left != null {
+		n := &x.nodes[id]
+		area := surfaceArea(&n.box)
+		combined := union(&n.box, &box)
+		combinedArea := surfaceArea(&combined)
+		// Cost of making the leaf and this node siblings
+		cost := 2 * combinedArea
+		// Minimum cost of pushing the leaf further down
+		inherited := 2 * (combinedArea - area)
+		costLeft := x.descendCost(n.left, &box) + inherited
+		costRight := x.descendCost(n.right, &box) + inherited
+		if cost < costLeft && cost < costRight {
+			break
+		}
+		if costLeft < costRight {
+			id = n.left
+		} else {
+			id = n.right
+		}
+	}
+	sibling := id
+
+	// Create the new parent of the leaf and its sibling
+	oldParent := x.nodes[sibling].parent
+	parent := x.allocate()
+	p := &x.nodes[parent]
+	p.parent = oldParent
+	p.box = union(&box, &x.nodes[sibling].box)
+	p.height = x.nodes[sibling].height + 1
+	p.left = sibling
+	p.right = leaf
+	x.nodes[sibling].parent = parent
+	x.nodes[leaf].parent = parent
+	if oldParent == null {
+		x.root = parent
+	} else if x.nodes[oldParent].left == sibling {
+		x.nodes[oldParent].left = parent
+	} else {
+		x.nodes[oldParent].right = parent
+	}
+
+	// Refit and balance the ancestors
+	x.refit(parent)
+}
+
+// descendCost returns the cost of inserting the specified box under the specified tree node.
+func (x *Index) descendCost(id int32, box *math32.Box3) float32 {
+
+	n := &x.nodes[id]
+	combined := union(&n.box, box)
+	if n.left == null {
+		return surfaceArea(&combined)
+	}
+	return surfaceArea(&combined) - surfaceArea(&n.box)
+}
+
+// removeLeaf removes the specified leaf from the tree, replacing its parent by its sibling.
+// The leaf is kept allocated.
+func (x *Index) removeLeaf(leaf int32) {
+
+	if leaf == x.root {
+		x.root = null
+		return
+	}
+	parent := x.nodes[leaf].parent
+	grandParent := x.nodes[parent].parent
+	sibling := x.nodes[parent].left
+	if sibling == leaf {
+		sibling = x.nodes[parent].right
+	}
+	if grandParent == null {
+		x.root = sibling
+		x.nodes[sibling].parent = null
+		x.release(parent)
+		return
+	}
+	if x.nodes[grandParent].left == parent {
+		x.nodes[grandParent].left = sibling
+	} else {
+		x.nodes[grandParent].right = sibling
+	}
+	x.nodes[sibling].parent = grandParent
+	x.release(parent)
+	x.refit(grandParent)
+}
+
+// refit balances and recalculates the boxes and heights of the specified tree node and its ancestors.
+func (x *Index) refit(id int32) {
+
+	for id != null {
+		id = x.balance(id)
+		n := &x.nodes[id]
+		l := &x.nodes[n.left]
+		r := &x.nodes[n.right]
+		n.box = union(&l.box, &r.box)
+		n.height = 1 + maxHeight(l.height, r.height)
+		id = n.parent
+	}
+}
+
+// balance rotates the specified tree node if its subtrees are unbalanced
+// and returns the tree node taking its place.
+func (x *Index) balance(a int32) int32 {
+
+	na := &x.nodes[a]
+	if na.left == null || na.height < 2 {
+		return a
+	}
+	b, c := na.left, na.right
+	diff := x.nodes[c].height - x.nodes[b].height
+	if diff > 1 {
+		return x.rotate(a, c, b)
+	}
+	if diff < -1 {
+		return x.rotate(a, b, c)
+	}
+	return a
+}
+
+// rotate promotes the specified higher child of the specified tree node,
+// which takes the place of the tree node, and returns it.
+func (x *Index) rotate(a, high, low int32) int32 {
+
+	na := &x.nodes[a]
+	nh := &x.nodes[high]
+	f, g := nh.left, nh.right
+
+	// The higher child takes the place of a
+	nh.left = a
+	nh.parent = na.parent
+	na.parent = high
+	if nh.parent == null {
+		x.root = high
+	} else if x.nodes[nh.parent].left == a {
+		x.nodes[nh.parent].left = high
+	} else {
+		x.nodes[nh.parent].right = high
+	}
+
+	// The higher grandchild stays under the promoted child, the other one replaces it under a
+	if x.nodes[f].height < x.nodes[g].height {
+		f, g = g, f
+	}
+	nh.right = f
+	x.nodes[g].parent = a
+	if na.left == high {
+		na.left = g
+	} else {
+		na.right = g
+	}
+	nl := &x.nodes[low]
+	ng := &x.nodes[g]
+	na.box = union(&nl.box, &ng.box)
+	na.height = 1 + maxHeight(nl.height, ng.height)
+
+	// The demoted node is still unbalanced if low was more than one level lower than g,
+	// such as after a leaf was inserted next to a high subtree
+	na = &x.nodes[x.balance(a)]
+	nf := &x.nodes[f]
+	nh.box = union(&na.box, &nf.box)
+	nh.height = 1 + maxHeight(na.height, nf.height)
+	return high
+}
+
+// union returns the union of the specified boxes.
+func union(a, b *math32.Box3) math32.Box3 {
+
+	u := *a
+	u.Union(b)
+	return u
+}
+
+// surfaceArea returns the surface area of the specified box.
+func surfaceArea(b *math32.Box3) float32 {
+
+	dx := b.Max.X - b.Min.X
+	dy := b.Max.Y - b.Min.Y
+	dz := b.Max.Z - b.Min.Z
+	return 2 * (dx*dy + dy*dz + dz*dx)
+}
+
+// maxHeight returns the maximum of the specified heights.
+func maxHeight(a, b int32) int32 {
+
+	if a > b {
+		return a
+	}
+	return b
+}
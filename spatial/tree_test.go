@@ -0,0 +1,257 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spatial
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// TestIndexRandom checks random insertions, removals, moves and queries against a brute-force scan.
+func TestIndexRandom(t *testing.T) {
+
+	rnd := rand.New(rand.NewSource(1))
+	randVec := func(scale float32) math32.Vector3 {
+		return math32.Vector3{
+			X: (rnd.Float32()*2 - 1) * scale,
+			Y: (rnd.Float32()*2 - 1) * scale,
+			Z: (rnd.Float32()*2 - 1) * scale,
+		}
+	}
+	geom := geometry.NewBox(1, 2, 3)
+	mat := material.NewStandard(&math32.Color{R: 1, G: 1, B: 1})
+
+	// Without margin the boxes of the tree are the world bounding boxes
+	x := NewIndex()
+	x.Margin = 0
+	var in, out []*graphic.Mesh
+	var proj, view, vp math32.Matrix4
+	proj.MakeOrthographic(-20, 20, 20, -20, 0.1, 100)
+
+	for step := 0; step < 3000; step++ {
+		switch op := rnd.Intn(10); {
+		case op < 4 || len(in) == 0:
+			var m *graphic.Mesh
+			if len(out) > 0 && rnd.Intn(2) == 0 {
+				m, out = out[len(out)-1], out[:len(out)-1]
+			} else {
+				m = graphic.NewMesh(geom, mat)
+			}
+			p := randVec(50)
+			m.SetPositionVec(&p)
+			m.SetScale(0.5+rnd.Float32()*2, 0.5+rnd.Float32()*2, 0.5+rnd.Float32()*2)
+			m.UpdateMatrixWorld()
+			x.Insert(m)
+			in = append(in, m)
+		case op < 6:
+			i := rnd.Intn(len(in))
+			m := in[i]
+			in = append(in[:i], in[i+1:]...)
+			out = append(out, m)
+			if !x.Remove(m) || x.Has(m) {
+				t.Fatalf("step %d: graphic was not removed", step)
+			}
+			if x.Remove(m) {
+				t.Fatalf("step %d: graphic was removed twice", step)
+			}
+		case op < 7:
+			moved := 0
+			for _, m := range in {
+				if rnd.Intn(4) == 0 {
+					p := randVec(2)
+					pos := m.Position()
+					p.Add(&pos)
+					m.SetPositionVec(&p)
+					m.UpdateMatrixWorld()
+					moved++
+				}
+			}
+			if n := x.Update(); n != moved {
+				t.Fatalf("step %d: Update reinserted %d graphics instead of %d", step, n, moved)
+			}
+		default:
+			checkQueries(t, step, x, in, randVec)
+			eye := randVec(50)
+			target := randVec(50)
+			view.LookAt(&eye, &target, &math32.Vector3{Y: 1})
+			view.SetPosition(&eye)
+			var inv math32.Matrix4
+			inv.GetInverse(&view)
+			vp.MultiplyMatrices(&proj, &inv)
+			checkFrustum(t, step, x, in, math32.NewFrustumFromMatrix(&vp))
+		}
+		if x.Len() != len(in) {
+			t.Fatalf("step %d: index has %d graphics instead of %d", step, x.Len(), len(in))
+		}
+		checkTree(t, step, x)
+	}
+}
+
+// checkTree checks the links, heights, balance and boxes of the tree nodes.
+func checkTree(t *testing.T, step int, x *Index) {
+
+	if x.root == null {
+		if len(x.leaves) != 0 {
+			t.Fatalf("step %d: empty tree with %d leaves", step, len(x.leaves))
+		}
+		return
+	}
+	if x.nodes[x.root].parent != null {
+		t.Fatalf("step %d: root has a parent", step)
+	}
+	leaves := 0
+	var check func(id int32) int32
+	check = func(id int32) int32 {
+		n := &x.nodes[id]
+		if n.left == null {
+			if n.right != null || n.height != 0 {
+				t.Fatalf("step %d: leaf %d has a right child or height %d", step, id, n.height)
+			}
+			if x.leaves[n.igr.GetGraphic()] != id {
+				t.Fatalf("step %d: leaf %d is not the leaf of its graphic", step, id)
+			}
+			bb := n.igr.GetGraphic().WorldBoundingBox()
+			if !n.box.ContainsBox(&bb) {
+				t.Fatalf("step %d: leaf %d does not contain its graphic", step, id)
+			}
+			leaves++
+			return 0
+		}
+		for _, c := range []int32{n.left, n.right} {
+			if x.nodes[c].parent != id {
+				t.Fatalf("step %d: child %d is not linked to its parent %d", step, c, id)
+			}
+			if !n.box.ContainsBox(&x.nodes[c].box) {
+				t.Fatalf("step %d: node %d does not contain its child %d", step, id, c)
+			}
+		}
+		hl := check(n.left)
+		hr := check(n.right)
+		if hl-hr > 1 || hr-hl > 1 {
+			t.Fatalf("step %d: node %d is unbalanced, heights %d and %d", step, id, hl, hr)
+		}
+		if n.height != 1+maxHeight(hl, hr) {
+			t.Fatalf("step %d: node %d has height %d instead of %d", step, id, n.height, 1+maxHeight(hl, hr))
+		}
+		return n.height
+	}
+	check(x.root)
+	if leaves != len(x.leaves) {
+		t.Fatalf("step %d: tree has %d leaves instead of %d", step, leaves, len(x.leaves))
+	}
+}
+
+// checkQueries checks random box, ray and nearest queries against a brute-force scan.
+func checkQueries(t *testing.T, step int, x *Index, in []*graphic.Mesh, randVec func(float32) math32.Vector3) {
+
+	// Box query
+	min := randVec(50)
+	size := randVec(10)
+	max := math32.Vector3{X: min.X + math32.Abs(size.X), Y: min.Y + math32.Abs(size.Y), Z: min.Z + math32.Abs(size.Z)}
+	box := math32.NewBox3(&min, &max)
+	var want []*graphic.Mesh
+	for _, m := range in {
+		bb := m.WorldBoundingBox()
+		if bb.IsIntersectionBox(box) {
+			want = append(want, m)
+		}
+	}
+	sameGraphics(t, step, "box", x.QueryBox(box, nil), want)
+
+	// Ray query
+	origin := randVec(50)
+	dir := randVec(1)
+	dir.Normalize()
+	ray := math32.NewRay(&origin, &dir)
+	const far = 30
+	want = want[:0]
+	dists := make(map[*graphic.Mesh]float32)
+	var point math32.Vector3
+	for _, m := range in {
+		bb := m.WorldBoundingBox()
+		if bb.ContainsPoint(&origin) {
+			want = append(want, m)
+			dists[m] = 0
+		} else if ray.IntersectBox(&bb, &point) != nil && point.DistanceTo(&origin) <= far {
+			want = append(want, m)
+			dists[m] = point.DistanceTo(&origin)
+		}
+	}
+	found := x.QueryRay(ray, far, nil)
+	sameGraphics(t, step, "ray", found, want)
+	for i := 1; i < len(found); i++ {
+		if dists[found[i].(*graphic.Mesh)] < dists[found[i-1].(*graphic.Mesh)] {
+			t.Fatalf("step %d: ray query results are not sorted by distance", step)
+		}
+	}
+
+	// Nearest queries, compared by distance as several graphics may be at the same one
+	p := randVec(60)
+	var all []float32
+	for _, m := range in {
+		bb := m.WorldBoundingBox()
+		all = append(all, bb.DistanceToPoint(&p))
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	nearest, dist := x.Nearest(&p)
+	if nearest == nil || dist != all[0] {
+		t.Fatalf("step %d: nearest graphic is at %v instead of %v", step, dist, all[0])
+	}
+	const count = 5
+	found = x.NearestN(&p, count, nil)
+	if len(all) > count {
+		all = all[:count]
+	}
+	if len(found) != len(all) {
+		t.Fatalf("step %d: found %d nearest graphics instead of %d", step, len(found), len(all))
+	}
+	for i, igr := range found {
+		bb := igr.GetGraphic().WorldBoundingBox()
+		if d := bb.DistanceToPoint(&p); d != all[i] {
+			t.Fatalf("step %d: nearest graphic %d is at %v instead of %v", step, i, d, all[i])
+		}
+	}
+}
+
+// checkFrustum checks frustum queries and culling against a brute-force scan.
+func checkFrustum(t *testing.T, step int, x *Index, in []*graphic.Mesh, frustum *math32.Frustum) {
+
+	var want []*graphic.Mesh
+	x.Cull(frustum)
+	for _, m := range in {
+		bb := m.WorldBoundingBox()
+		inside := frustum.IntersectsBox(&bb)
+		if inside {
+			want = append(want, m)
+		}
+		if got, ok := x.InFrustum(m); !ok || got != inside {
+			t.Fatalf("step %d: graphic in frustum %v instead of %v", step, got, inside)
+		}
+	}
+	sameGraphics(t, step, "frustum", x.QueryFrustum(frustum, nil), want)
+}
+
+// sameGraphics checks that the specified query results are the expected graphics, in any order.
+func sameGraphics(t *testing.T, step int, query string, found []graphic.IGraphic, want []*graphic.Mesh) {
+
+	if len(found) != len(want) {
+		t.Fatalf("step %d: %s query found %d graphics instead of %d", step, query, len(found), len(want))
+	}
+	set := make(map[graphic.IGraphic]bool)
+	for _, igr := range found {
+		set[igr] = true
+	}
+	for _, m := range want {
+		if !set[m] {
+			t.Fatalf("step %d: %s query did not find a graphic", step, query)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/png" // Registers the PNG decoder for the font pages
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/g3n/engine/math32"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// BitmapFont is a prebuilt bitmap font atlas in the BMFont (AngelCode) format, as exported by
+// tools such as BMFont, Hiero and msdf-bmfont, in text or XML form. It can be used by the gui
+// widgets through the Font it creates and by 3D text through an SDFAtlas.
+type BitmapFont struct {
+	Face       string              // Name of the font face
+	Size       int                 // Size of the font in pixels per em
+	LineHeight int                 // Distance between baselines in pixels
+	Base       int                 // Distance from the top of a line to its baseline in pixels
+	Pages      []*image.RGBA       // Page images with the glyphs
+	Chars      map[rune]BitmapChar // Glyphs of the font
+	Kernings   map[[2]rune]int     // Kerning adjustments in pixels between pairs of runes
+	pageFiles  []string            // Page image file names
+}
+
+// BitmapChar describes the location of a glyph in a BitmapFont page and its metrics in pixels.
+type BitmapChar struct {
+	X, Y          int // Top left corner of the glyph in the page image
+	Width, Height int // Size of the glyph in the page image
+	XOffset       int // Horizontal offset from the pen position to the glyph image
+	YOffset       int // Vertical offset from the top of the line to the glyph image
+	XAdvance      int // Horizontal advance of the pen
+	Page          int // Index of the page with the glyph
+}
+
+// LoadBitmapFont loads a BMFont descriptor file (.fnt) in text or XML form
+// and its page images, which must be in the same directory.
+func LoadBitmapFont(fntFile string) (*BitmapFont, error) {
+
+	data, err := ioutil.ReadFile(fntFile)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(fntFile)
+	return NewBitmapFontFromData(data, func(file string) (image.Image, error) {
+		f, err := os.Open(filepath.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		return img, err
+	})
+}
+
+// NewBitmapFontFromData creates and returns a bitmap font from the specified BMFont descriptor
+// data, in text or XML form, calling loadPage to get the image of each page file.
+func NewBitmapFontFromData(data []byte, loadPage func(file string) (image.Image, error)) (*BitmapFont, error) {
+
+	bf := new(BitmapFont)
+	bf.Chars = make(map[rune]BitmapChar)
+	bf.Kernings = make(map[[2]rune]int)
+
+	var err error
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("BMF")):
+		return nil, fmt.Errorf("binary BMFont files are not supported")
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		err = bf.decodeXML(trimmed)
+	default:
+		err = bf.decodeText(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bf.Size == 0 {
+		bf.Size = bf.LineHeight
+	}
+
+	// Loads the pages, storing the glyphs of grayscale pages in the alpha channel
+	for _, file := range bf.pageFiles {
+		img, err := loadPage(file)
+		if err != nil {
+			return nil, err
+		}
+		rgba := image.NewRGBA(img.Bounds())
+		if gray, ok := img.(*image.Gray); ok {
+			for i, v := range gray.Pix {
+				copy(rgba.Pix[4*i:], []uint8{0xFF, 0xFF, 0xFF, v})
+			}
+		} else {
+			draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		}
+		bf.Pages = append(bf.Pages, rgba)
+	}
+	for r, c := range bf.Chars {
+		if c.Page < 0 || c.Page >= len(bf.Pages) {
+			return nil, fmt.Errorf("glyph %q in invalid page %d", r, c.Page)
+		}
+	}
+	return bf, nil
+}
+
+// Kern returns the kerning adjustment between the specified runes in pixels.
+func (bf *BitmapFont) Kern(r0, r1 rune) int {
+
+	return bf.Kernings[[2]rune{r0, r1}]
+}
+
+// Font creates and returns a font which draws text with the glyphs of the bitmap font,
+// to be used by gui widgets and canvases. The size of bitmap fonts is fixed, so the point
+// size, DPI and hinting attributes of the font are ignored.
+func (bf *BitmapFont) Font() *Font {
+
+	f := new(Font)
+	f.face = &bitmapFace{bf}
+	f.attrib = FontAttributes{PointSize: float64(bf.Size), DPI: 72, LineSpacing: 1.0}
+	f.SetColor(&math32.Color4{R: 0, G: 0, B: 0, A: 1})
+	return f
+}
+
+// SDFAtlas creates and returns an atlas with the glyphs of the bitmap font, to be rendered
+// by an SDFText. The glyphs must be in a single page. Fonts exported as distance fields,
+// with spread pixels on each side of the outlines, are rendered sharply at any scale.
+// Plain bitmap fonts are rendered with their alpha thresholded at one half.
+func (bf *BitmapFont) SDFAtlas(spread int) (*SDFAtlas, error) {
+
+	if len(bf.Pages) != 1 {
+		return nil, fmt.Errorf("SDF atlas needs a font with a single page, not %d", len(bf.Pages))
+	}
+	page := bf.Pages[0]
+	a := new(SDFAtlas)
+	a.Image = page
+	a.Size = float64(bf.Size)
+	a.Spread = spread
+	s := float32(bf.Size)
+	a.Ascent = float32(bf.Base) / s
+	a.Descent = float32(bf.LineHeight-bf.Base) / s
+	a.LineHeight = float32(bf.LineHeight) / s
+	a.kern = func(r0, r1 rune) float32 { return float32(bf.Kern(r0, r1)) / s }
+
+	a.Glyphs = make(map[rune]*SDFGlyph, len(bf.Chars))
+	w := float32(page.Rect.Dx())
+	h := float32(page.Rect.Dy())
+	for r, c := range bf.Chars {
+		g := &SDFGlyph{Advance: float32(c.XAdvance) / s}
+		if c.Width > 0 && c.Height > 0 {
+			g.Min = math32.Vector2{X: float32(c.XOffset) / s, Y: float32(bf.Base-c.YOffset-c.Height) / s}
+			g.Max = math32.Vector2{X: float32(c.XOffset+c.Width) / s, Y: float32(bf.Base-c.YOffset) / s}
+			g.UVMin = math32.Vector2{X: float32(c.X) / w, Y: 1 - float32(c.Y+c.Height)/h}
+			g.UVMax = math32.Vector2{X: float32(c.X+c.Width) / w, Y: 1 - float32(c.Y)/h}
+		}
+		a.Glyphs[r] = g
+	}
+	return a, nil
+}
+
+// decodeText decodes a BMFont descriptor in text form.
+func (bf *BitmapFont) decodeText(data []byte) error {
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		tag, attrs := bmfontFields(scanner.Text())
+		if err := bf.decodeTag(tag, attrs); err != nil {
+			return fmt.Errorf("line %d: %v", n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// decodeXML decodes a BMFont descriptor in XML form.
+func (bf *BitmapFont) decodeXML(data []byte) error {
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		attrs := make(map[string]string, len(start.Attr))
+		for _, a := range start.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+		if err := bf.decodeTag(start.Name.Local, attrs); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeTag decodes the attributes of a BMFont tag, ignoring unknown tags.
+func (bf *BitmapFont) decodeTag(tag string, attrs map[string]string) error {
+
+	var err error
+	atoi := func(key string) int {
+		v, e := strconv.Atoi(attrs[key])
+		if e != nil && err == nil {
+			err = fmt.Errorf("invalid %s %s: %q", tag, key, attrs[key])
+		}
+		return v
+	}
+	switch tag {
+	case "info":
+		bf.Face = attrs["face"]
+		if size := atoi("size"); size < 0 {
+			bf.Size = -size
+		} else {
+			bf.Size = size
+		}
+	case "common":
+		bf.LineHeight = atoi("lineHeight")
+		bf.Base = atoi("base")
+	case "page":
+		id := atoi("id")
+		if err == nil && (id < 0 || id >= 256) {
+			err = fmt.Errorf("invalid page id %d", id)
+		}
+		if err != nil {
+			return err
+		}
+		for len(bf.pageFiles) <= id {
+			bf.pageFiles = append(bf.pageFiles, "")
+		}
+		bf.pageFiles[id] = attrs["file"]
+	case "char":
+		c := BitmapChar{
+			X: atoi("x"), Y: atoi("y"), Width: atoi("width"), Height: atoi("height"),
+			XOffset: atoi("xoffset"), YOffset: atoi("yoffset"), XAdvance: atoi("xadvance"),
+		}
+		if _, ok := attrs["page"]; ok {
+			c.Page = atoi("page")
+		}
+		bf.Chars[rune(atoi("id"))] = c
+	case "kerning":
+		bf.Kernings[[2]rune{rune(atoi("first")), rune(atoi("second"))}] = atoi("amount")
+	}
+	return err
+}
+
+// bmfontFields splits a line of a BMFont text descriptor into its tag and attributes.
+func bmfontFields(line string) (string, map[string]string) {
+
+	line = strings.TrimSpace(line)
+	end := strings.IndexAny(line, " \t")
+	if end < 0 {
+		return line, nil
+	}
+	tag := line[:end]
+	attrs := make(map[string]string)
+	rest := line[end:]
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return tag, attrs
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.IndexByte(rest[1:], '"') + 1
+			if end <= 0 {
+				end = len(rest)
+			}
+			value = rest[1:end]
+			if end < len(rest) {
+				end++
+			}
+			rest = rest[end:]
+		} else {
+			sep := strings.IndexAny(rest, " \t")
+			if sep < 0 {
+				sep = len(rest)
+			}
+			value = rest[:sep]
+			rest = rest[sep:]
+		}
+		attrs[key] = value
+	}
+}
+
+// bitmapFace is a font face which draws the glyphs of a BitmapFont.
+type bitmapFace struct {
+	bf *BitmapFont
+}
+
+// Close satisfies the font.Face interface.
+func (bf *bitmapFace) Close() error {
+
+	return nil
+}
+
+// Glyph returns the page image of the glyph of the specified rune as the
+// mask to draw it with the pen at the specified dot position.
+func (bf *bitmapFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+
+	c, ok := bf.bf.Chars[r]
+	if !ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	x := dot.X.Round() + c.XOffset
+	y := dot.Y.Round() - bf.bf.Base + c.YOffset
+	dr := image.Rect(x, y, x+c.Width, y+c.Height)
+	return dr, bf.bf.Pages[c.Page], image.Pt(c.X, c.Y), fixed.I(c.XAdvance), true
+}
+
+// GlyphBounds returns the bounds of the glyph of the specified rune relative to the pen position.
+func (bf *bitmapFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+
+	c, ok := bf.bf.Chars[r]
+	if !ok {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	top := c.YOffset - bf.bf.Base
+	bounds := fixed.R(c.XOffset, top, c.XOffset+c.Width, top+c.Height)
+	return bounds, fixed.I(c.XAdvance), true
+}
+
+// GlyphAdvance returns the advance of the glyph of the specified rune.
+func (bf *bitmapFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+
+	c, ok := bf.bf.Chars[r]
+	return fixed.I(c.XAdvance), ok
+}
+
+// Kern returns the kerning adjustment between the specified runes.
+func (bf *bitmapFace) Kern(r0, r1 rune) fixed.Int26_6 {
+
+	return fixed.I(bf.bf.Kern(r0, r1))
+}
+
+// Metrics returns the metrics of the face.
+func (bf *bitmapFace) Metrics() font.Metrics {
+
+	return font.Metrics{
+		Height:  fixed.I(bf.bf.LineHeight),
+		Ascent:  fixed.I(bf.bf.Base),
+		Descent: fixed.I(bf.bf.LineHeight - bf.bf.Base),
+	}
+}
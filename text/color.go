@@ -0,0 +1,610 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/golang/freetype/truetype"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// colorFont contains the tables of a color font used to draw its color glyphs: layered
+// glyphs (COLR and CPAL tables) and embedded bitmaps (CBLC and CBDT tables), such as emoji.
+type colorFont struct {
+	upem      float32                         // Font units per em
+	ascent    int16                           // Ascent in font units
+	descent   int16                           // Descent in font units, negative below the baseline
+	hmtx      []byte                          // Horizontal metrics table
+	nHMetrics int                             // Number of advances in the horizontal metrics table
+	cmap      func(r rune) uint16             // Maps runes to glyph indices
+	layers    map[uint16][]colorLayer         // Layers of the COLR glyphs
+	palette   []color.RGBA                    // First CPAL palette
+	strikes   []colorStrike                   // Bitmap strikes of the CBLC table
+	cblc      []byte                          // Bitmap location table
+	cbdt      []byte                          // Bitmap data table
+	bitmaps   map[colorBitmapKey]*colorBitmap // Decoded bitmaps cache
+}
+
+// colorLayer is a layer of a COLR glyph: another glyph of the font filled with a palette color.
+type colorLayer struct {
+	glyph   uint16
+	palette uint16 // Index of the color in the palette or 0xFFFF for the text color
+}
+
+// colorStrike is a set of bitmaps with the same size in a CBLC table.
+type colorStrike struct {
+	ppem  int // Pixels per em of the bitmaps
+	array int // Offset of the index subtable array in the CBLC table
+	count int // Number of index subtables
+}
+
+// colorBitmapKey identifies a decoded bitmap glyph.
+type colorBitmapKey struct {
+	strike int
+	glyph  uint16
+}
+
+// colorBitmap is a decoded bitmap glyph and its metrics in strike pixels.
+type colorBitmap struct {
+	img                image.Image
+	bearingX, bearingY int
+}
+
+// parseColorFont parses the tables of the specified font data needed to draw color glyphs.
+// It returns nil if the font has no color glyphs or its tables are invalid.
+func parseColorFont(data []byte) *colorFont {
+
+	tables := sfntTables(data)
+	if tables == nil || tables["head"] == nil || tables["hhea"] == nil || tables["cmap"] == nil {
+		return nil
+	}
+	if (tables["COLR"] == nil || tables["CPAL"] == nil) && (tables["CBLC"] == nil || tables["CBDT"] == nil) {
+		return nil
+	}
+	cf := new(colorFont)
+	if err := cf.parse(tables); err != nil {
+		return nil
+	}
+	return cf
+}
+
+// errColorFont is returned when parsing invalid color font tables.
+var errColorFont = errors.New("invalid color font table")
+
+// parse parses the specified font tables.
+func (cf *colorFont) parse(tables map[string][]byte) error {
+
+	head := tables["head"]
+	hhea := tables["hhea"]
+	if len(head) < 20 || len(hhea) < 36 {
+		return errColorFont
+	}
+	cf.upem = float32(u16(head, 18))
+	cf.ascent = int16(u16(hhea, 4))
+	cf.descent = int16(u16(hhea, 6))
+	cf.nHMetrics = int(u16(hhea, 34))
+	cf.hmtx = tables["hmtx"]
+	if cf.upem == 0 || len(cf.hmtx) < 4*cf.nHMetrics {
+		return errColorFont
+	}
+	cf.cmap = parseCmap(tables["cmap"])
+	if cf.cmap == nil {
+		return errColorFont
+	}
+
+	// Layered glyphs
+	if colr, cpal := tables["COLR"], tables["CPAL"]; len(colr) >= 14 && len(cpal) >= 14 {
+		nbase := int(u16(colr, 2))
+		baseOffset := int(u32(colr, 4))
+		layerOffset := int(u32(colr, 8))
+		nlayers := int(u16(colr, 12))
+		if baseOffset+6*nbase > len(colr) || layerOffset+4*nlayers > len(colr) {
+			return errColorFont
+		}
+		cf.layers = make(map[uint16][]colorLayer, nbase)
+		for i := 0; i < nbase; i++ {
+			rec := colr[baseOffset+6*i:]
+			first := int(u16(rec, 2))
+			count := int(u16(rec, 4))
+			if first+count > nlayers {
+				return errColorFont
+			}
+			layers := make([]colorLayer, count)
+			for j := range layers {
+				l := colr[layerOffset+4*(first+j):]
+				layers[j] = colorLayer{u16(l, 0), u16(l, 2)}
+			}
+			cf.layers[u16(rec, 0)] = layers
+		}
+		nentries := int(u16(cpal, 2))
+		recordsOffset := int(u32(cpal, 8))
+		firstIndex := int(u16(cpal, 12))
+		if recordsOffset+4*(firstIndex+nentries) > len(cpal) {
+			return errColorFont
+		}
+		cf.palette = make([]color.RGBA, nentries)
+		for i := range cf.palette {
+			c := cpal[recordsOffset+4*(firstIndex+i):]
+			// Stored as BGRA, not premultiplied
+			a := uint32(c[3])
+			cf.palette[i] = color.RGBA{uint8(uint32(c[2]) * a / 0xFF), uint8(uint32(c[1]) * a / 0xFF), uint8(uint32(c[0]) * a / 0xFF), c[3]}
+		}
+	}
+
+	// Bitmap glyphs
+	if cblc, cbdt := tables["CBLC"], tables["CBDT"]; len(cblc) >= 8 {
+		nsizes := int(u32(cblc, 4))
+		if 8+48*nsizes > len(cblc) {
+			return errColorFont
+		}
+		cf.cblc = cblc
+		cf.cbdt = cbdt
+		cf.bitmaps = make(map[colorBitmapKey]*colorBitmap)
+		for i := 0; i < nsizes; i++ {
+			size := cblc[8+48*i:]
+			strike := colorStrike{ppem: int(size[45]), array: int(u32(size, 0)), count: int(u32(size, 8))}
+			if strike.array+8*strike.count > len(cblc) {
+				return errColorFont
+			}
+			cf.strikes = append(cf.strikes, strike)
+		}
+	}
+	return nil
+}
+
+// hasGlyph returns if the font has a color glyph for the specified rune.
+func (cf *colorFont) hasGlyph(r rune) bool {
+
+	g := cf.cmap(r)
+	if g == 0 {
+		return false
+	}
+	if _, ok := cf.layers[g]; ok {
+		return true
+	}
+	return len(cf.strikes) > 0
+}
+
+// advance returns the advance of the specified glyph in font units.
+func (cf *colorFont) advance(g uint16) int {
+
+	if cf.nHMetrics == 0 {
+		return 0
+	}
+	if int(g) >= cf.nHMetrics {
+		g = uint16(cf.nHMetrics - 1)
+	}
+	return int(u16(cf.hmtx, 4*int(g)))
+}
+
+// draw draws the color glyph of the specified rune on dst with the pen at dot, with size pixels
+// per em, using fg for the layers with the text color. The glyph outlines of layered glyphs are
+// read from ttf. It returns the advance of the glyph and false if there is no color glyph for the rune.
+func (cf *colorFont) draw(dst draw.Image, dot fixed.Point26_6, r rune, size float32, fg image.Image, ttf *truetype.Font) (fixed.Int26_6, bool) {
+
+	g := cf.cmap(r)
+	if g == 0 {
+		return 0, false
+	}
+	advance := fixed.Int26_6(float32(cf.advance(g)) * size / cf.upem * 64)
+	x := float32(dot.X) / 64
+	y := float32(dot.Y) / 64
+
+	// Layered glyph, drawn with the outlines of the layer glyphs
+	if layers, ok := cf.layers[g]; ok && ttf != nil {
+		if dst == nil {
+			return advance, true
+		}
+		scale := fixed.Int26_6(ttf.FUnitsPerEm() << 6)
+		var gb truetype.GlyphBuf
+		for _, layer := range layers {
+			if err := gb.Load(ttf, scale, truetype.Index(layer.glyph), font.HintingNone); err != nil {
+				continue
+			}
+			var src image.Image = fg
+			if int(layer.palette) < len(cf.palette) {
+				src = image.NewUniform(cf.palette[layer.palette])
+			}
+			var contours [][]float32
+			minX, minY := float32(1e9), float32(1e9)
+			maxX, maxY := float32(-1e9), float32(-1e9)
+			start := 0
+			for _, end := range gb.Ends {
+				c := flattenContour(gb.Points[start:end], float32(scale), 4)
+				start = end
+				pts := make([]float32, 0, 2*len(c))
+				for _, p := range c {
+					px, py := x+p.X*size, y-p.Y*size
+					pts = append(pts, px, py)
+					minX, minY = min32(minX, px), min32(minY, py)
+					maxX, maxY = max32(maxX, px), max32(maxY, py)
+				}
+				contours = append(contours, pts)
+			}
+			if len(contours) == 0 {
+				continue
+			}
+			rect := image.Rect(int(minX), int(minY), int(maxX)+2, int(maxY)+2)
+			z := vector.NewRasterizer(rect.Dx(), rect.Dy())
+			ox, oy := float32(rect.Min.X), float32(rect.Min.Y)
+			for _, pts := range contours {
+				z.MoveTo(pts[0]-ox, pts[1]-oy)
+				for i := 2; i < len(pts); i += 2 {
+					z.LineTo(pts[i]-ox, pts[i+1]-oy)
+				}
+				z.ClosePath()
+			}
+			z.Draw(dst, rect, src, image.Point{})
+		}
+		return advance, true
+	}
+
+	// Bitmap glyph from the strike closest to the size
+	if dst == nil {
+		return advance, len(cf.strikes) > 0
+	}
+	bm, ppem := cf.bitmap(g, size)
+	if bm == nil {
+		return 0, false
+	}
+	s := size / float32(ppem)
+	b := bm.img.Bounds()
+	x0 := int(x + float32(bm.bearingX)*s + 0.5)
+	y0 := int(y - float32(bm.bearingY)*s + 0.5)
+	rect := image.Rect(x0, y0, x0+int(float32(b.Dx())*s+0.5), y0+int(float32(b.Dy())*s+0.5))
+	xdraw.BiLinear.Scale(dst, rect, bm.img, b, draw.Over, nil)
+	return advance, true
+}
+
+// bitmap returns the decoded bitmap of the specified glyph from the strike closest to
+// the specified size and the size of the strike in pixels per em.
+func (cf *colorFont) bitmap(g uint16, size float32) (*colorBitmap, int) {
+
+	// Chooses the smallest strike not smaller than the size or else the largest one
+	best := -1
+	for i, s := range cf.strikes {
+		if best < 0 {
+			best = i
+			continue
+		}
+		bp := cf.strikes[best].ppem
+		if (float32(s.ppem) >= size && (float32(bp) < size || s.ppem < bp)) || (float32(bp) < size && s.ppem > bp) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, 0
+	}
+	key := colorBitmapKey{best, g}
+	if bm, ok := cf.bitmaps[key]; ok {
+		return bm, cf.strikes[best].ppem
+	}
+	bm := cf.decodeBitmap(&cf.strikes[best], g)
+	cf.bitmaps[key] = bm
+	return bm, cf.strikes[best].ppem
+}
+
+// decodeBitmap locates the specified glyph in the index subtables of the strike
+// and decodes its PNG image. It returns nil if the glyph is not found or invalid.
+func (cf *colorFont) decodeBitmap(s *colorStrike, g uint16) *colorBitmap {
+
+	cblc := cf.cblc
+	for i := 0; i < s.count; i++ {
+		entry := s.array + 8*i
+		first, last := u16(cblc, entry), u16(cblc, entry+2)
+		if g < first || g > last {
+			continue
+		}
+		sub := s.array + int(u32(cblc, entry+4))
+		if sub+8 > len(cblc) {
+			return nil
+		}
+		indexFormat := u16(cblc, sub)
+		imageFormat := u16(cblc, sub+2)
+		imageOffset := int(u32(cblc, sub+4))
+		body := sub + 8
+		var start, end int
+		var metrics []byte
+		switch indexFormat {
+		case 1:
+			i := body + 4*int(g-first)
+			if i+8 > len(cblc) {
+				return nil
+			}
+			start, end = int(u32(cblc, i)), int(u32(cblc, i+4))
+		case 3:
+			i := body + 2*int(g-first)
+			if i+4 > len(cblc) {
+				return nil
+			}
+			start, end = int(u16(cblc, i)), int(u16(cblc, i+2))
+		case 2:
+			if body+12 > len(cblc) {
+				return nil
+			}
+			size := int(u32(cblc, body))
+			metrics = cblc[body+4 : body+12]
+			start = size * int(g-first)
+			end = start + size
+		case 4, 5:
+			found := false
+			if indexFormat == 4 {
+				n := int(u32(cblc, body))
+				for i := 0; i < n && body+4+4*i+8 <= len(cblc); i++ {
+					rec := body + 4 + 4*i
+					if u16(cblc, rec) == g {
+						start, end = int(u16(cblc, rec+2)), int(u16(cblc, rec+6))
+						found = true
+						break
+					}
+				}
+			} else if body+16 <= len(cblc) {
+				size := int(u32(cblc, body))
+				metrics = cblc[body+4 : body+12]
+				n := int(u32(cblc, body+12))
+				for i := 0; i < n && body+16+2*i+2 <= len(cblc); i++ {
+					if u16(cblc, body+16+2*i) == g {
+						start = size * i
+						end = start + size
+						found = true
+						break
+					}
+				}
+			}
+			if !found {
+				return nil
+			}
+		default:
+			return nil
+		}
+		if start >= end || imageOffset+end > len(cf.cbdt) {
+			return nil
+		}
+		return decodeBitmapData(cf.cbdt[imageOffset+start:imageOffset+end], imageFormat, metrics)
+	}
+	return nil
+}
+
+// decodeBitmapData decodes the specified CBDT glyph data in the specified image format,
+// which may use the big glyph metrics of the index subtable.
+func decodeBitmapData(data []byte, format uint16, metrics []byte) *colorBitmap {
+
+	bm := new(colorBitmap)
+	switch format {
+	case 17: // Small metrics and PNG data
+		if len(data) < 9 {
+			return nil
+		}
+		bm.bearingX, bm.bearingY = int(int8(data[2])), int(int8(data[3]))
+		data = data[9:]
+	case 18: // Big metrics and PNG data
+		if len(data) < 12 {
+			return nil
+		}
+		bm.bearingX, bm.bearingY = int(int8(data[2])), int(int8(data[3]))
+		data = data[12:]
+	case 19: // PNG data with the metrics of the index subtable
+		if len(data) < 4 || len(metrics) < 4 {
+			return nil
+		}
+		bm.bearingX, bm.bearingY = int(int8(metrics[2])), int(int8(metrics[3]))
+		data = data[4:]
+	default:
+		return nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	bm.img = img
+	return bm
+}
+
+// sfntTables returns the tables of the specified TrueType or OpenType font data by their tags.
+func sfntTables(data []byte) map[string][]byte {
+
+	if len(data) < 12 {
+		return nil
+	}
+	n := int(u16(data, 4))
+	if 12+16*n > len(data) {
+		return nil
+	}
+	tables := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		offset, length := u32(rec, 8), u32(rec, 12)
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil
+		}
+		tables[string(rec[:4])] = data[offset : offset+length]
+	}
+	return tables
+}
+
+// parseCmap returns a function mapping runes to glyph indices using the Unicode subtable
+// of the specified cmap table, in format 4 or 12, or nil if there is none.
+func parseCmap(cmap []byte) func(r rune) uint16 {
+
+	if len(cmap) < 4 {
+		return nil
+	}
+	n := int(u16(cmap, 2))
+	var best []byte
+	for i := 0; i < n && 4+8*i+8 <= len(cmap); i++ {
+		rec := cmap[4+8*i:]
+		platform, encoding, offset := u16(rec, 0), u16(rec, 2), int(u32(rec, 4))
+		if offset+4 > len(cmap) || (platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10))) {
+			continue
+		}
+		sub := cmap[offset:]
+		format := u16(sub, 0)
+		if format == 12 || (format == 4 && best == nil) {
+			best = sub
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	if u16(best, 0) == 12 {
+		if len(best) < 16 {
+			return nil
+		}
+		ngroups := int(u32(best, 12))
+		if 16+12*ngroups > len(best) {
+			return nil
+		}
+		groups := best[16:]
+		return func(r rune) uint16 {
+			lo, hi := 0, ngroups
+			for lo < hi {
+				m := (lo + hi) / 2
+				g := groups[12*m:]
+				start, end := rune(u32(g, 0)), rune(u32(g, 4))
+				if r < start {
+					hi = m
+				} else if r > end {
+					lo = m + 1
+				} else {
+					return uint16(u32(g, 8) + uint32(r-start))
+				}
+			}
+			return 0
+		}
+	}
+	if len(best) < 14 {
+		return nil
+	}
+	nsegs := int(u16(best, 6)) / 2
+	if 16+8*nsegs > len(best) {
+		return nil
+	}
+	ends := best[14:]
+	starts := best[16+2*nsegs:]
+	deltas := best[16+4*nsegs:]
+	rangeOffsets := best[16+6*nsegs:]
+	return func(r rune) uint16 {
+		if r > 0xFFFF {
+			return 0
+		}
+		c := uint16(r)
+		for i := 0; i < nsegs; i++ {
+			if c > u16(ends, 2*i) {
+				continue
+			}
+			if c < u16(starts, 2*i) {
+				return 0
+			}
+			ro := u16(rangeOffsets, 2*i)
+			if ro == 0 {
+				return c + u16(deltas, 2*i)
+			}
+			idx := 16 + 6*nsegs + 2*i + int(ro) + 2*int(c-u16(starts, 2*i))
+			if idx+2 > len(best) {
+				return 0
+			}
+			if g := u16(best, idx); g != 0 {
+				return g + u16(deltas, 2*i)
+			}
+			return 0
+		}
+		return 0
+	}
+}
+
+// colorFace is the font face of fonts which only have bitmap color glyphs
+// and no outlines. It provides the metrics of the font at its current size.
+type colorFace struct {
+	f *Font
+}
+
+// Close satisfies the font.Face interface.
+func (cf *colorFace) Close() error {
+
+	return nil
+}
+
+// Glyph returns no mask as color glyphs are drawn by the font.
+func (cf *colorFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+
+	return image.Rectangle{}, nil, image.Point{}, 0, false
+}
+
+// GlyphBounds returns the bounds of the glyph of the specified rune, using the font ascent and descent.
+func (cf *colorFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+
+	adv, ok := cf.GlyphAdvance(r)
+	m := cf.Metrics()
+	return fixed.Rectangle26_6{Min: fixed.Point26_6{Y: -m.Ascent}, Max: fixed.Point26_6{X: adv, Y: m.Descent}}, adv, ok
+}
+
+// GlyphAdvance returns the advance of the glyph of the specified rune.
+func (cf *colorFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+
+	c := cf.f.color
+	g := c.cmap(r)
+	return cf.scale(c.advance(g)), g != 0
+}
+
+// Kern returns zero as color fonts have no kerning.
+func (cf *colorFace) Kern(r0, r1 rune) fixed.Int26_6 {
+
+	return 0
+}
+
+// Metrics returns the metrics of the face.
+func (cf *colorFace) Metrics() font.Metrics {
+
+	c := cf.f.color
+	ascent := cf.scale(int(c.ascent))
+	descent := cf.scale(-int(c.descent))
+	return font.Metrics{Height: ascent + descent, Ascent: ascent, Descent: descent}
+}
+
+// scale converts the specified value in font units to the current size of the font.
+func (cf *colorFace) scale(v int) fixed.Int26_6 {
+
+	return fixed.Int26_6(float32(v) * cf.f.pixelSize() / cf.f.color.upem * 64)
+}
+
+// u16 returns the big endian 16 bit value at the specified offset.
+func u16(b []byte, i int) uint16 {
+
+	return binary.BigEndian.Uint16(b[i:])
+}
+
+// u32 returns the big endian 32 bit value at the specified offset.
+func u32(b []byte, i int) uint32 {
+
+	return binary.BigEndian.Uint32(b[i:])
+}
+
+// min32 returns the smaller of the specified values.
+func min32(a, b float32) float32 {
+
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// max32 returns the larger of the specified values.
+func max32(a, b float32) float32 {
+
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -16,7 +16,7 @@ import (
 	"strings"
 )
 
-// Font represents a TrueType font face.
+// Font represents a TrueType font face, or a bitmap font face created by BitmapFont.Font.
 // Attributes must be set prior to drawing.
 type Font struct {
 	ttf     *truetype.Font // The TrueType font
@@ -25,6 +25,9 @@ type Font struct {
 	fg      *image.Uniform // Text color cache
 	bg      *image.Uniform // Background color cache
 	changed bool           // Whether attributes have changed and the font face needs to be recreated
+
+	color     *colorFont // Color glyph tables, if the font has color glyphs
+	fallbacks []*Font    // Fonts used to draw the runes without glyphs in this font
 }
 
 // FontAttributes contains tunable attributes of a font.
@@ -54,16 +57,19 @@ func NewFont(ttfFile string) (*Font, error) {
 }
 
 // NewFontFromData creates and returns a new font object from the specified TTF data.
+// Color glyphs, such as emoji, in COLR/CPAL or CBLC/CBDT tables are drawn in color,
+// and fonts with only bitmap color glyphs are also supported.
 func NewFontFromData(fontData []byte) (*Font, error) {
 
 	// Parses the font data
 	ttf, err := truetype.Parse(fontData)
-	if err != nil {
+	cf := parseColorFont(fontData)
+	if err != nil && (cf == nil || len(cf.strikes) == 0) {
 		return nil, err
 	}
 
 	f := new(Font)
-	f.ttf = ttf
+	f.color = cf
 
 	// Initialize with default values
 	f.attrib = FontAttributes{}
@@ -74,6 +80,11 @@ func NewFontFromData(fontData []byte) (*Font, error) {
 	f.SetColor(&math32.Color4{0, 0, 0, 1})
 
 	// Create font face
+	if err != nil {
+		f.face = &colorFace{f}
+		return f, nil
+	}
+	f.ttf = ttf
 	f.face = truetype.NewFace(f.ttf, &truetype.Options{
 		Size:    f.attrib.PointSize,
 		DPI:     f.attrib.DPI,
@@ -156,7 +167,7 @@ func (f *Font) SetAttributes(fa *FontAttributes) {
 // updateFace updates the font face if parameters have changed.
 func (f *Font) updateFace() {
 
-	if f.changed {
+	if f.changed && f.ttf != nil {
 		f.face = truetype.NewFace(f.ttf, &truetype.Options{
 			Size:    f.attrib.PointSize,
 			DPI:     f.attrib.DPI,
@@ -170,11 +181,8 @@ func (f *Font) updateFace() {
 // the specified text. The supplied text string can contain line break escape sequences (\n).
 func (f *Font) MeasureText(text string) (int, int) {
 
-	// Create font drawer
-	f.updateFace()
-	d := &font.Drawer{Dst: nil, Src: f.fg, Face: f.face}
-
 	// Draw text
+	f.updateFace()
 	var width, height int
 	metrics := f.face.Metrics()
 	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
@@ -182,8 +190,7 @@ func (f *Font) MeasureText(text string) (int, int) {
 
 	lines := strings.Split(text, "\n")
 	for i, s := range lines {
-		d.Dot = fixed.P(0, height)
-		lineWidth := d.MeasureString(s).Ceil()
+		lineWidth := f.drawString(nil, fixed.P(0, height), s).X.Ceil()
 		if lineWidth > width {
 			width = lineWidth
 		}
@@ -202,6 +209,84 @@ func (f *Font) Metrics() font.Metrics {
 	return f.face.Metrics()
 }
 
+// AddFallback adds a font used to draw the runes without glyphs in this font, such as emoji
+// from a color font mixed with the text. Fallback fonts are tried in the order they were added
+// and are drawn with the attributes and color of this font.
+func (f *Font) AddFallback(fallback *Font) {
+
+	f.fallbacks = append(f.fallbacks, fallback)
+}
+
+// hasGlyph returns if the font has a glyph for the specified rune.
+func (f *Font) hasGlyph(r rune) bool {
+
+	if f.color != nil && f.color.hasGlyph(r) {
+		return true
+	}
+	if f.ttf != nil {
+		return f.ttf.Index(r) != 0
+	}
+	_, ok := f.face.GlyphAdvance(r)
+	return ok
+}
+
+// fontFor returns the font used to draw the specified rune, which is this font
+// or the first fallback font with a glyph for it.
+func (f *Font) fontFor(r rune) *Font {
+
+	if len(f.fallbacks) == 0 || f.hasGlyph(r) {
+		return f
+	}
+	for _, fb := range f.fallbacks {
+		if fb.hasGlyph(r) {
+			fb.SetAttributes(&f.attrib)
+			fb.updateFace()
+			return fb
+		}
+	}
+	return f
+}
+
+// pixelSize returns the current size of the font in pixels per em.
+func (f *Font) pixelSize() float32 {
+
+	return float32(f.attrib.PointSize * f.attrib.DPI / 72)
+}
+
+// drawString draws the specified line of text on dst with the pen starting at dot and returns
+// the final pen position. Color glyphs are drawn in color and the other glyphs with the text
+// color. If dst is nil the text is only measured.
+func (f *Font) drawString(dst draw.Image, dot fixed.Point26_6, s string) fixed.Point26_6 {
+
+	prev := rune(-1)
+	var prevFont *Font
+	for _, r := range s {
+		gf := f.fontFor(r)
+		if prevFont == gf {
+			dot.X += gf.face.Kern(prev, r)
+		}
+		prev, prevFont = r, gf
+		if gf.color != nil {
+			if advance, ok := gf.color.draw(dst, dot, r, f.pixelSize(), f.fg, gf.ttf); ok {
+				dot.X += advance
+				continue
+			}
+		}
+		if dst == nil {
+			advance, _ := gf.face.GlyphAdvance(r)
+			dot.X += advance
+			continue
+		}
+		dr, mask, maskp, advance, ok := gf.face.Glyph(dot, r)
+		if !ok {
+			continue
+		}
+		draw.DrawMask(dst, dr, f.fg, image.Point{}, mask, maskp, draw.Over)
+		dot.X += advance
+	}
+	return dot
+}
+
 // DrawText draws the specified text on a new, tightly fitting image, and returns a pointer to the image.
 func (f *Font) DrawText(text string) *image.RGBA {
 
@@ -217,7 +302,6 @@ func (f *Font) DrawText(text string) *image.RGBA {
 func (f *Font) DrawTextOnImage(text string, x, y int, dst *image.RGBA) {
 
 	f.updateFace()
-	d := &font.Drawer{Dst: dst, Src: f.fg, Face: f.face}
 
 	// Draw text
 	metrics := f.face.Metrics()
@@ -226,8 +310,7 @@ func (f *Font) DrawTextOnImage(text string, x, y int, dst *image.RGBA) {
 	lineGap := int((f.attrib.LineSpacing - float64(1)) * float64(lineHeight))
 	lines := strings.Split(text, "\n")
 	for i, s := range lines {
-		d.Dot = fixed.P(x, py)
-		d.DrawString(s)
+		f.drawString(dst, fixed.P(x, py), s)
 		py += lineHeight
 		if i > 1 {
 			py += lineGap
@@ -271,9 +354,7 @@ func (c Canvas) DrawText(x, y int, text string, f *Font) {
 // TODO Implement caret as a gui.Panel in gui.Edit
 func (c Canvas) DrawTextCaret(x, y int, text string, f *Font, line, col int) error {
 
-	// Creates drawer
 	f.updateFace()
-	d := &font.Drawer{Dst: c.RGBA, Src: f.fg, Face: f.face}
 
 	// Draw text
 	metrics := f.face.Metrics()
@@ -282,14 +363,13 @@ func (c Canvas) DrawTextCaret(x, y int, text string, f *Font, line, col int) err
 	lineGap := int((f.attrib.LineSpacing - float64(1)) * float64(lineHeight))
 	lines := strings.Split(text, "\n")
 	for l, s := range lines {
-		d.Dot = fixed.P(x, py)
-		d.DrawString(s)
+		dot := f.drawString(c.RGBA, fixed.P(x, py), s)
 		// Checks for caret position
 		if l == line && col <= StrCount(s) {
 			width, _ := f.MeasureText(StrPrefix(s, col))
 			// Draw caret vertical line
 			caretH := int(f.attrib.PointSize) + 2
-			caretY := int(d.Dot.Y>>6) - int(f.attrib.PointSize) + 2
+			caretY := int(dot.Y>>6) - int(f.attrib.PointSize) + 2
 			color := Color4RGBA(&math32.Color4{0, 0, 0, 1}) // Hardcoded to black
 			for j := caretY; j < caretY+caretH; j++ {
 				c.RGBA.Set(x+width, j, color)
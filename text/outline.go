@@ -0,0 +1,161 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"github.com/g3n/engine/math32"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// GlyphOutline returns the closed contours of the outline of the glyph of the specified rune,
+// flattened to polylines, and the glyph advance width. Coordinates are in em units with the
+// origin at the pen position on the baseline and Y pointing up. Each quadratic curve of the
+// outline is split into the specified number of segments. Glyphs without an outline, such
+// as spaces, and the glyphs of bitmap fonts return no contours.
+func (f *Font) GlyphOutline(r rune, segments int) ([][]math32.Vector2, float32) {
+
+	if f.ttf == nil {
+		return nil, 0
+	}
+	if segments < 1 {
+		segments = 1
+	}
+	scale := f.emScale()
+	var gb truetype.GlyphBuf
+	if err := gb.Load(f.ttf, scale, f.ttf.Index(r), font.HintingNone); err != nil {
+		return nil, 0
+	}
+	s := float32(scale)
+	contours := make([][]math32.Vector2, 0, len(gb.Ends))
+	start := 0
+	for _, end := range gb.Ends {
+		c := flattenContour(gb.Points[start:end], s, segments)
+		start = end
+		if len(c) >= 3 {
+			contours = append(contours, c)
+		}
+	}
+	return contours, float32(gb.AdvanceWidth) / s
+}
+
+// Kern returns the kerning adjustment between the specified runes in em units.
+func (f *Font) Kern(r0, r1 rune) float32 {
+
+	if bf, ok := f.face.(*bitmapFace); ok {
+		return float32(bf.bf.Kern(r0, r1)) / float32(bf.bf.Size)
+	}
+	if f.ttf == nil {
+		return 0
+	}
+	scale := f.emScale()
+	return float32(f.ttf.Kern(scale, f.ttf.Index(r0), f.ttf.Index(r1))) / float32(scale)
+}
+
+// EmMetrics returns the ascent and descent of the font in em units, both positive,
+// and the distance between the baselines of consecutive lines considering the line spacing.
+func (f *Font) EmMetrics() (ascent, descent, lineHeight float32) {
+
+	var m font.Metrics
+	var upem float64
+	if bf, ok := f.face.(*bitmapFace); ok {
+		upem = float64(bf.bf.Size)
+		m = bf.Metrics()
+	} else if f.ttf == nil {
+		upem = float64(f.color.upem)
+		m.Ascent = fixed.I(int(f.color.ascent))
+		m.Descent = fixed.I(-int(f.color.descent))
+	} else {
+		upem = float64(f.ttf.FUnitsPerEm())
+		m = truetype.NewFace(f.ttf, &truetype.Options{Size: upem, DPI: 72}).Metrics()
+	}
+	ascent = float32(m.Ascent) / float32(64*upem)
+	descent = float32(m.Descent) / float32(64*upem)
+	lineHeight = (ascent + descent) * float32(f.attrib.LineSpacing)
+	return
+}
+
+// emScale returns the scale used to load glyphs in font units, which keeps their full precision.
+func (f *Font) emScale() fixed.Int26_6 {
+
+	return fixed.Int26_6(f.ttf.FUnitsPerEm() << 6)
+}
+
+// flattenContour converts the specified TrueType contour points, which are on curve points
+// and quadratic Bezier control points, to a polyline scaled by 1/scale.
+func flattenContour(pts []truetype.Point, scale float32, segments int) []math32.Vector2 {
+
+	n := len(pts)
+	if n == 0 {
+		return nil
+	}
+	vec := func(p truetype.Point) math32.Vector2 {
+		return math32.Vector2{X: float32(p.X) / scale, Y: float32(p.Y) / scale}
+	}
+	mid := func(a, b math32.Vector2) math32.Vector2 {
+		return math32.Vector2{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+	}
+	onCurve := func(p truetype.Point) bool { return p.Flags&0x01 != 0 }
+
+	// Starts at an on curve point or, if there is none, between the last and first control points
+	first := -1
+	for i, p := range pts {
+		if onCurve(p) {
+			first = i
+			break
+		}
+	}
+	var start math32.Vector2
+	var seq []truetype.Point
+	if first >= 0 {
+		start = vec(pts[first])
+		seq = append(append(seq, pts[first+1:]...), pts[:first]...)
+	} else {
+		start = mid(vec(pts[n-1]), vec(pts[0]))
+		seq = pts
+	}
+
+	out := []math32.Vector2{start}
+	last := start
+	var ctrl math32.Vector2
+	curve := false
+	lineTo := func(to math32.Vector2) {
+		if curve {
+			for i := 1; i <= segments; i++ {
+				t := float32(i) / float32(segments)
+				u := 1 - t
+				out = append(out, math32.Vector2{
+					X: u*u*last.X + 2*u*t*ctrl.X + t*t*to.X,
+					Y: u*u*last.Y + 2*u*t*ctrl.Y + t*t*to.Y,
+				})
+			}
+		} else if to != last {
+			out = append(out, to)
+		}
+		last = to
+		curve = false
+	}
+	for _, p := range seq {
+		v := vec(p)
+		if onCurve(p) {
+			lineTo(v)
+			continue
+		}
+		// Consecutive control points have an implied on curve point between them
+		if curve {
+			lineTo(mid(ctrl, v))
+		}
+		ctrl = v
+		curve = true
+	}
+	lineTo(start)
+
+	// Removes the closing point
+	if len(out) > 1 && out[len(out)-1] == out[0] {
+		out = out[:len(out)-1]
+	}
+	return out
+}
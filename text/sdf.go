@@ -0,0 +1,197 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/g3n/engine/math32"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// SDFGlyph contains the metrics and texture coordinates of a glyph in a signed distance field atlas.
+type SDFGlyph struct {
+	Advance float32        // Horizontal advance in em units
+	Min     math32.Vector2 // Bottom left corner of the glyph quad relative to the pen position in em units
+	Max     math32.Vector2 // Top right corner of the glyph quad relative to the pen position in em units
+	UVMin   math32.Vector2 // Texture coordinates of the bottom left corner of the glyph quad
+	UVMax   math32.Vector2 // Texture coordinates of the top right corner of the glyph quad
+}
+
+// SDFAtlas is an image with the signed distance fields of a set of glyphs of a font,
+// which can be rendered sharply at any scale and with outlines by the "sdftext" shader.
+// The alpha channel of the image stores the distance to the glyph outline, 0.5 being on the
+// outline, higher values inside the glyph and lower values outside it. Spread pixels
+// away from the outline the distance reaches 1 or 0.
+type SDFAtlas struct {
+	Image      *image.RGBA               // Atlas image
+	Size       float64                   // Size of the glyphs in the image in pixels per em
+	Spread     int                       // Distance range in pixels on each side of the glyph outlines
+	Ascent     float32                   // Font ascent in em units
+	Descent    float32                   // Font descent in em units
+	LineHeight float32                   // Distance between baselines in em units
+	Glyphs     map[rune]*SDFGlyph        // Glyphs in the atlas
+	kern       func(r0, r1 rune) float32 // Returns the kerning between two runes
+}
+
+// NewSDFAtlas creates and returns a pointer to a signed distance field atlas with the glyphs
+// of the specified runes rendered by the specified font at size pixels per em, encoding
+// distances up to spread pixels from the outlines. Sizes of 32 to 64 pixels with a spread
+// of about an eighth of the size give good results for most uses. Only fonts with glyph
+// outlines are rasterized; the atlas of other fonts has no glyphs.
+func NewSDFAtlas(f *Font, runes string, size float64, spread int) *SDFAtlas {
+
+	a := new(SDFAtlas)
+	a.Size = size
+	a.Spread = spread
+	a.Glyphs = make(map[rune]*SDFGlyph)
+	a.kern = f.Kern
+	a.Ascent, a.Descent, a.LineHeight = f.EmMetrics()
+	if f.ttf == nil {
+		a.Image = image.NewRGBA(image.Rect(0, 0, 1, 1))
+		return a
+	}
+
+	// Rasterizes the glyphs
+	face := truetype.NewFace(f.ttf, &truetype.Options{Size: size, DPI: 72, Hinting: font.HintingNone})
+	type raster struct {
+		r      rune
+		field  []uint8
+		w, h   int
+		bounds image.Rectangle
+	}
+	var rasters []raster
+	area, maxWidth := 0, 0
+	for _, r := range runes {
+		if _, ok := a.Glyphs[r]; ok {
+			continue
+		}
+		dr, mask, mp, advance, ok := face.Glyph(fixed.Point26_6{}, r)
+		if !ok {
+			continue
+		}
+		a.Glyphs[r] = &SDFGlyph{Advance: float32(advance) / float32(64*size)}
+		if dr.Empty() {
+			continue
+		}
+		field, w, h := distanceField(mask, mp, dr.Dx(), dr.Dy(), spread)
+		rasters = append(rasters, raster{r, field, w, h, dr})
+		area += w * h
+		if w > maxWidth {
+			maxWidth = w
+		}
+	}
+
+	// Packs the glyphs in rows
+	width := 64
+	for width*width < area*5/4 || width < maxWidth {
+		width *= 2
+	}
+	x, y, rowHeight := 0, 0, 0
+	pos := make([]image.Point, len(rasters))
+	for i, ras := range rasters {
+		if x+ras.w > width {
+			x = 0
+			y += rowHeight
+			rowHeight = 0
+		}
+		pos[i] = image.Pt(x, y)
+		x += ras.w
+		if ras.h > rowHeight {
+			rowHeight = ras.h
+		}
+	}
+	height := y + rowHeight
+	if height == 0 {
+		height = 1
+	}
+
+	// Copies the distance fields to the atlas and sets the glyph quads
+	a.Image = image.NewRGBA(image.Rect(0, 0, width, height))
+	s := float32(size)
+	for i, ras := range rasters {
+		p := pos[i]
+		for j := 0; j < ras.h; j++ {
+			for k := 0; k < ras.w; k++ {
+				a.Image.SetRGBA(p.X+k, p.Y+j, color.RGBA{0xFF, 0xFF, 0xFF, ras.field[j*ras.w+k]})
+			}
+		}
+		g := a.Glyphs[ras.r]
+		g.Min = math32.Vector2{X: float32(ras.bounds.Min.X-spread) / s, Y: float32(-ras.bounds.Max.Y-spread) / s}
+		g.Max = math32.Vector2{X: float32(ras.bounds.Max.X+spread) / s, Y: float32(-ras.bounds.Min.Y+spread) / s}
+		g.UVMin = math32.Vector2{X: float32(p.X) / float32(width), Y: 1 - float32(p.Y+ras.h)/float32(height)}
+		g.UVMax = math32.Vector2{X: float32(p.X+ras.w) / float32(width), Y: 1 - float32(p.Y)/float32(height)}
+	}
+	return a
+}
+
+// Kern returns the kerning adjustment between the specified runes in em units.
+func (a *SDFAtlas) Kern(r0, r1 rune) float32 {
+
+	if a.kern == nil {
+		return 0
+	}
+	return a.kern(r0, r1)
+}
+
+// SetKern sets the function returning the kerning adjustment between runes in em units,
+// such as for atlases restored from baked glyphs.
+func (a *SDFAtlas) SetKern(kern func(r0, r1 rune) float32) {
+
+	a.kern = kern
+}
+
+// distanceField computes the signed distance field of the specified glyph mask, padded
+// by spread pixels on each side, and returns it with its dimensions.
+func distanceField(mask image.Image, mp image.Point, w, h, spread int) ([]uint8, int, int) {
+
+	fw := w + 2*spread
+	fh := h + 2*spread
+	inside := make([]bool, fw*fh)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			_, _, _, alpha := mask.At(mp.X+x, mp.Y+y).RGBA()
+			inside[(y+spread)*fw+x+spread] = alpha >= 0x8000
+		}
+	}
+
+	// For each pixel finds the nearest pixel on the other side of the outline
+	field := make([]uint8, fw*fh)
+	maxDist2 := spread * spread
+	for y := 0; y < fh; y++ {
+		for x := 0; x < fw; x++ {
+			in := inside[y*fw+x]
+			best := maxDist2 + 1
+			for dy := -spread; dy <= spread; dy++ {
+				yy := y + dy
+				if yy < 0 || yy >= fh || dy*dy >= best {
+					continue
+				}
+				for dx := -spread; dx <= spread; dx++ {
+					xx := x + dx
+					d2 := dx*dx + dy*dy
+					if xx < 0 || xx >= fw || d2 >= best {
+						continue
+					}
+					if inside[yy*fw+xx] != in {
+						best = d2
+					}
+				}
+			}
+			// The outline is about half a pixel before the nearest pixel on the other side
+			dist := math.Min(math.Sqrt(float64(best)), float64(spread)) - 0.5
+			if !in {
+				dist = -dist
+			}
+			v := 0.5 + dist/float64(2*spread)
+			field[y*fw+x] = uint8(math.Round(math.Max(0, math.Min(1, v)) * 0xFF))
+		}
+	}
+	return field, fw, fh
+}
@@ -0,0 +1,184 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"github.com/g3n/engine/gls"
+)
+
+// Array is a two-dimensional array texture (GL_TEXTURE_2D_ARRAY) whose layers hold
+// the data of Texture2D objects with the same size, format and sampling parameters.
+// Materials whose textures are all stored in arrays bind the arrays and select the
+// layers with uniforms, so materials sharing arrays do not need to bind textures.
+// Arrays are normally created and filled by a Residency manager.
+type Array struct {
+	gs         *gls.GLS     // Pointer to OpenGL state
+	texname    uint32       // Texture handle
+	width      int32        // Layer width in pixels
+	height     int32        // Layer height in pixels
+	iformat    int32        // Internal format
+	srgb       bool         // Layers are sRGB encoded colors
+	format     uint32       // Format of the pixel data
+	formatType uint32       // Type of the pixel data
+	magFilter  uint32       // Magnification filter
+	minFilter  uint32       // Minification filter
+	wrapS      uint32       // Wrap mode for s coordinate
+	wrapT      uint32       // Wrap mode for t coordinate
+	genMipmap  bool         // Generate mipmaps flag
+	textures   []*Texture2D // Texture stored in each layer or nil if the layer is free
+	pending    []bool       // Layers whose data must be transferred
+	update     bool         // Some layer data must be transferred
+}
+
+// NewArray creates and returns a pointer to a new texture array with the specified
+// number of layers with the size, format and parameters of the specified texture.
+// The texture itself is not added to the array.
+func NewArray(layers int, t *Texture2D) *Array {
+
+	a := new(Array)
+	a.width = t.width
+	a.height = t.height
+	a.iformat = t.iformat
+	a.srgb = t.srgb
+	a.format = t.format
+	a.formatType = t.formatType
+	a.magFilter = t.magFilter
+	a.minFilter = t.minFilter
+	a.wrapS = t.wrapS
+	a.wrapT = t.wrapT
+	a.genMipmap = t.genMipmap
+	a.textures = make([]*Texture2D, layers)
+	a.pending = make([]bool, layers)
+	return a
+}
+
+// Compatible returns if the specified texture has the size, format
+// and sampling parameters of the layers of this array.
+func (a *Array) Compatible(t *Texture2D) bool {
+
+	return t.width == a.width && t.height == a.height &&
+		t.iformat == a.iformat && t.srgb == a.srgb && t.format == a.format && t.formatType == a.formatType &&
+		t.magFilter == a.magFilter && t.minFilter == a.minFilter &&
+		t.wrapS == a.wrapS && t.wrapT == a.wrapT && t.genMipmap == a.genMipmap
+}
+
+// Add stores the specified texture in a free layer of the array and returns the layer index.
+// It returns -1 if the array is full, the texture is not compatible, has no data or is streamed.
+func (a *Array) Add(t *Texture2D) int {
+
+	if t.array != nil || t.stream != nil || t.data == nil || !a.Compatible(t) {
+		return -1
+	}
+	for layer, curr := range a.textures {
+		if curr == nil {
+			a.textures[layer] = t
+			a.pending[layer] = true
+			a.update = true
+			t.array = a
+			t.layer = layer
+			return layer
+		}
+	}
+	return -1
+}
+
+// Remove removes the specified texture from the array, freeing its layer.
+func (a *Array) Remove(t *Texture2D) {
+
+	if t.array != a {
+		return
+	}
+	a.textures[t.layer] = nil
+	a.pending[t.layer] = false
+	t.array = nil
+	t.layer = 0
+}
+
+// Layers returns the number of layers of the array.
+func (a *Array) Layers() int {
+
+	return len(a.textures)
+}
+
+// Count returns the number of layers in use.
+func (a *Array) Count() int {
+
+	count := 0
+	for _, t := range a.textures {
+		if t != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Width returns the width of the layers in pixels.
+func (a *Array) Width() int {
+
+	return int(a.width)
+}
+
+// Height returns the height of the layers in pixels.
+func (a *Array) Height() int {
+
+	return int(a.height)
+}
+
+// Dispose removes all the textures from the array and releases its OpenGL resources.
+func (a *Array) Dispose() {
+
+	for _, t := range a.textures {
+		if t != nil {
+			a.Remove(t)
+		}
+	}
+	if a.gs != nil {
+		a.gs.DeleteTextures(a.texname)
+		a.gs = nil
+	}
+}
+
+// invalidate marks the layer of the specified texture for transfer.
+func (a *Array) invalidate(t *Texture2D) {
+
+	a.pending[t.layer] = true
+	a.update = true
+}
+
+// bind binds the array to the specified texture unit,
+// creating it and transferring the data of its layers if necessary.
+func (a *Array) bind(gs *gls.GLS, slotIdx int) {
+
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + slotIdx))
+
+	// One time initialization: allocates the storage of all layers
+	if a.gs == nil {
+		a.texname = gs.GenTexture()
+		a.gs = gs
+		gs.BindTexture(gls.TEXTURE_2D_ARRAY, a.texname)
+		gs.TexImage3D(gls.TEXTURE_2D_ARRAY, 0, internalFormat(gs, a.iformat, a.srgb), a.width, a.height, int32(len(a.textures)), a.format, a.formatType, nil)
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_MAG_FILTER, int32(a.magFilter))
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_MIN_FILTER, int32(a.minFilter))
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_WRAP_S, int32(a.wrapS))
+		gs.TexParameteri(gls.TEXTURE_2D_ARRAY, gls.TEXTURE_WRAP_T, int32(a.wrapT))
+	}
+	gs.BindTexture(gls.TEXTURE_2D_ARRAY, a.texname)
+	if !a.update {
+		return
+	}
+
+	// Transfers the data of the changed layers
+	for layer, t := range a.textures {
+		if !a.pending[layer] || t == nil {
+			continue
+		}
+		gs.TexSubImage3D(gls.TEXTURE_2D_ARRAY, 0, 0, 0, int32(layer), a.width, a.height, 1, a.format, a.formatType, t.data)
+		a.pending[layer] = false
+	}
+	if a.genMipmap {
+		gs.GenerateMipmap(gls.TEXTURE_2D_ARRAY)
+	}
+	a.update = false
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+// DefaultArrayLayers is the default number of layers of the texture arrays created by a Residency manager.
+const DefaultArrayLayers = 16
+
+// Residency packs textures with the same size, format and sampling parameters into
+// texture arrays, so materials sharing arrays can be rendered without binding textures.
+// Textures are usually added through material.Material.PackTextures, which packs all
+// the textures of a material or none of them.
+// Textures which are not compatible with any array, such as render targets or textures
+// without data, keep their own bindings.
+type Residency struct {
+	layers int      // Number of layers of new arrays
+	arrays []*Array // Arrays created by the manager
+}
+
+// NewResidency creates and returns a pointer to a new texture residency manager
+// which creates texture arrays with the specified number of layers.
+func NewResidency(layers int) *Residency {
+
+	if layers <= 0 {
+		layers = DefaultArrayLayers
+	}
+	rm := new(Residency)
+	rm.layers = layers
+	return rm
+}
+
+// Packable returns if the specified texture can be packed into an array.
+func (rm *Residency) Packable(t *Texture2D) bool {
+
+	return t.array != nil || (t.stream == nil && t.data != nil && t.width > 0 && t.height > 0)
+}
+
+// Add packs the specified texture into a compatible array with a free layer,
+// creating a new array if necessary. It returns false if the texture cannot be packed.
+// Textures already stored in an array are not moved.
+func (rm *Residency) Add(t *Texture2D) bool {
+
+	if t.array != nil {
+		return true
+	}
+	if !rm.Packable(t) {
+		return false
+	}
+	for _, a := range rm.arrays {
+		if a.Add(t) >= 0 {
+			return true
+		}
+	}
+	a := NewArray(rm.layers, t)
+	rm.arrays = append(rm.arrays, a)
+	return a.Add(t) >= 0
+}
+
+// Remove removes the specified texture from its array, if it was packed by this manager.
+func (rm *Residency) Remove(t *Texture2D) {
+
+	for _, a := range rm.arrays {
+		if t.array == a {
+			a.Remove(t)
+			return
+		}
+	}
+}
+
+// Arrays returns the texture arrays created by the manager.
+func (rm *Residency) Arrays() []*Array {
+
+	return rm.arrays
+}
+
+// Compact disposes the arrays which have no textures.
+func (rm *Residency) Compact() {
+
+	arrays := rm.arrays[:0]
+	for _, a := range rm.arrays {
+		if a.Count() == 0 {
+			a.Dispose()
+			continue
+		}
+		arrays = append(arrays, a)
+	}
+	for i := len(arrays); i < len(rm.arrays); i++ {
+		rm.arrays[i] = nil
+	}
+	rm.arrays = arrays
+}
+
+// Dispose removes all the textures from the arrays and releases the arrays.
+// The textures go back to their own bindings.
+func (rm *Residency) Dispose() {
+
+	for _, a := range rm.arrays {
+		a.Dispose()
+	}
+	rm.arrays = nil
+}
@@ -0,0 +1,432 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/g3n/engine/gls"
+)
+
+// StreamMinSize is the maximum width and height in pixels of the finest mip level
+// loaded when a streamed texture is first used.
+const StreamMinSize = 64
+
+// StreamUploadLimit is the default maximum number of bytes uploaded by a Streamer per frame.
+const StreamUploadLimit = 8 * 1024 * 1024
+
+// Streamer manages the mip levels of textures under a GPU memory budget.
+// Streamed textures are first loaded with their coarsest levels only, up to StreamMinSize pixels.
+// Finer levels are streamed in, one level per texture and frame, as the screen-space footprints
+// reported with Request grow. When the budget is exceeded the least recently used textures
+// are evicted back to their coarsest levels. Textures with higher priorities are streamed
+// first and evicted last.
+//
+// The mip levels are generated on the CPU when a texture is added, so only 8 bit RGBA textures
+// with data can be streamed. The renderer reports the footprints of the textures of the visible
+// graphics and calls Update before rendering when its streamer is set.
+type Streamer struct {
+	budget   int64        // Maximum number of bytes of the resident mip levels
+	limit    int64        // Maximum number of bytes uploaded per frame
+	used     int64        // Number of bytes of the resident mip levels
+	uploaded int64        // Number of bytes scheduled for upload in the last update
+	frame    uint64       // Current frame number
+	textures []*Texture2D // Streamed textures
+	pending  []*Texture2D // Preallocated list of textures to stream in
+	victims  []*Texture2D // Preallocated list of eviction candidates
+}
+
+// streamState keeps the streaming state of a texture.
+type streamState struct {
+	streamer  *Streamer // Streamer managing the texture
+	mips      [][]byte  // Mip levels data, starting with the full resolution level
+	minLevel  int       // Finest level loaded when the texture is first used
+	level     int       // Finest level to be resident
+	resident  int       // Finest level uploaded to OpenGL or -1
+	footprint float32   // Largest footprint in pixels requested in the current frame
+	priority  int       // Streaming priority
+	lastUsed  uint64    // Frame the texture was last requested or bound
+}
+
+// NewStreamer creates and returns a pointer to a new texture streamer with the specified
+// budget in bytes for the mip levels of its textures.
+func NewStreamer(budget int64) *Streamer {
+
+	s := new(Streamer)
+	s.budget = budget
+	s.limit = StreamUploadLimit
+	return s
+}
+
+// SetBudget sets the budget in bytes for the mip levels of the streamed textures.
+func (s *Streamer) SetBudget(budget int64) {
+
+	s.budget = budget
+}
+
+// Budget returns the budget in bytes for the mip levels of the streamed textures.
+func (s *Streamer) Budget() int64 {
+
+	return s.budget
+}
+
+// SetUploadLimit sets the maximum number of bytes uploaded per frame.
+// At least one texture level is streamed in per frame, whatever its size.
+func (s *Streamer) SetUploadLimit(limit int64) {
+
+	s.limit = limit
+}
+
+// UploadLimit returns the maximum number of bytes uploaded per frame.
+func (s *Streamer) UploadLimit() int64 {
+
+	return s.limit
+}
+
+// Used returns the number of bytes of the resident mip levels of the streamed textures.
+func (s *Streamer) Used() int64 {
+
+	return s.used
+}
+
+// Uploaded returns the number of bytes scheduled for upload by the last update.
+func (s *Streamer) Uploaded() int64 {
+
+	return s.uploaded
+}
+
+// Textures returns the streamed textures.
+func (s *Streamer) Textures() []*Texture2D {
+
+	return s.textures
+}
+
+// Add starts streaming the mip levels of the specified texture, generating them from its data.
+// Returns an error if the texture does not have 8 bit RGBA data or is packed into a texture array.
+func (s *Streamer) Add(t *Texture2D) error {
+
+	if t.stream != nil {
+		if t.stream.streamer != s {
+			return fmt.Errorf("texture is streamed by another streamer")
+		}
+		return nil
+	}
+	if t.array != nil {
+		return fmt.Errorf("texture is packed into a texture array")
+	}
+	if err := t.Restore(); err != nil {
+		return err
+	}
+	mips, err := buildMips(t)
+	if err != nil {
+		return err
+	}
+	ss := new(streamState)
+	ss.streamer = s
+	ss.resident = -1
+	ss.lastUsed = s.frame
+	ss.setMips(t, mips)
+	t.stream = ss
+	s.textures = append(s.textures, t)
+	s.used += ss.bytes(ss.level)
+
+	// Releases the full resolution texture, if it was already transferred
+	if t.gs != nil {
+		t.gs.DeleteTextures(t.texname)
+		t.gs = nil
+		t.updateParams = true
+	}
+	return nil
+}
+
+// Remove stops streaming the specified texture. Its full resolution data
+// is transferred the next time it is used.
+func (s *Streamer) Remove(t *Texture2D) {
+
+	if t.stream == nil || t.stream.streamer != s {
+		return
+	}
+	for i, curr := range s.textures {
+		if curr == t {
+			copy(s.textures[i:], s.textures[i+1:])
+			s.textures[len(s.textures)-1] = nil
+			s.textures = s.textures[:len(s.textures)-1]
+			break
+		}
+	}
+	s.used -= t.stream.bytes(t.stream.level)
+	t.stream = nil
+	t.updateData = true
+	t.updateParams = true
+}
+
+// SetPriority sets the streaming priority of the specified texture, which is zero by default.
+// Textures with higher priorities are streamed in first and evicted last.
+func (s *Streamer) SetPriority(t *Texture2D, priority int) {
+
+	if t.stream != nil && t.stream.streamer == s {
+		t.stream.priority = priority
+	}
+}
+
+// Priority returns the streaming priority of the specified texture.
+func (s *Streamer) Priority(t *Texture2D) int {
+
+	if t.stream == nil || t.stream.streamer != s {
+		return 0
+	}
+	return t.stream.priority
+}
+
+// Level returns the finest mip level of the specified texture which is (or is about to be)
+// resident, where 0 is the full resolution. It returns -1 if the texture is not streamed.
+func (s *Streamer) Level(t *Texture2D) int {
+
+	if t.stream == nil || t.stream.streamer != s {
+		return -1
+	}
+	return t.stream.level
+}
+
+// Request reports that the specified texture covers about the specified number of pixels
+// along its largest side in the current frame. It is normally called by the renderer.
+func (s *Streamer) Request(t *Texture2D, pixels float32) {
+
+	ss := t.stream
+	if ss == nil || ss.streamer != s {
+		return
+	}
+	if pixels > ss.footprint {
+		ss.footprint = pixels
+	}
+	ss.lastUsed = s.frame
+}
+
+// Update streams in finer mip levels for the textures requested in the current frame and
+// evicts the least recently used textures if the budget is exceeded. The levels are
+// transferred when the textures are next bound. It must be called once per frame,
+// from the goroutine issuing OpenGL commands, before rendering.
+func (s *Streamer) Update(gs *gls.GLS) {
+
+	// Builds the list of textures needing finer levels
+	s.pending = s.pending[:0]
+	for _, t := range s.textures {
+		if t.stream.wanted(t) < t.stream.level {
+			s.pending = append(s.pending, t)
+		}
+	}
+	sort.Slice(s.pending, func(i, j int) bool {
+		ss1, ss2 := s.pending[i].stream, s.pending[j].stream
+		if ss1.priority != ss2.priority {
+			return ss1.priority > ss2.priority
+		}
+		return ss1.footprint > ss2.footprint
+	})
+
+	// Streams in one level per texture while the upload limit allows it
+	s.uploaded = 0
+	for _, t := range s.pending {
+		ss := t.stream
+		size := ss.bytes(ss.level - 1)
+		if s.uploaded > 0 && s.uploaded+size > s.limit {
+			break
+		}
+		delta := size - ss.bytes(ss.level)
+		if s.used+delta > s.budget && !s.evict(gs, t, s.used+delta-s.budget) {
+			continue
+		}
+		ss.level--
+		s.used += delta
+		s.uploaded += size
+	}
+
+	// Starts a new frame
+	for _, t := range s.textures {
+		t.stream.footprint = 0
+	}
+	s.frame++
+}
+
+// evict evicts textures, least recently used first, to free the specified number of bytes
+// for the specified texture. Only textures with lower priorities or not used in the current
+// frame are evicted. Returns false, evicting nothing, if not enough memory can be freed.
+func (s *Streamer) evict(gs *gls.GLS, t *Texture2D, size int64) bool {
+
+	s.victims = s.victims[:0]
+	var freed int64
+	for _, v := range s.textures {
+		ss := v.stream
+		if v == t || ss.level == ss.minLevel {
+			continue
+		}
+		if ss.lastUsed == s.frame && ss.priority >= t.stream.priority {
+			continue
+		}
+		s.victims = append(s.victims, v)
+		freed += ss.bytes(ss.level) - ss.bytes(ss.minLevel)
+	}
+	if freed < size {
+		return false
+	}
+	sort.Slice(s.victims, func(i, j int) bool {
+		ss1, ss2 := s.victims[i].stream, s.victims[j].stream
+		if ss1.priority != ss2.priority {
+			return ss1.priority < ss2.priority
+		}
+		return ss1.lastUsed < ss2.lastUsed
+	})
+	freed = 0
+	for _, v := range s.victims {
+		if freed >= size {
+			break
+		}
+		ss := v.stream
+		freed += ss.bytes(ss.level) - ss.bytes(ss.minLevel)
+		s.used -= ss.bytes(ss.level) - ss.bytes(ss.minLevel)
+		ss.level = ss.minLevel
+		// Releases the OpenGL texture, which is recreated with the coarse levels when next bound
+		if v.gs != nil {
+			gs.DeleteTextures(v.texname)
+			v.gs = nil
+			v.updateParams = true
+			ss.resident = -1
+		}
+	}
+	return true
+}
+
+// refresh regenerates the mip levels of the specified texture after its data changed,
+// removing it from the streamer if its data can no longer be streamed.
+func (s *Streamer) refresh(t *Texture2D) {
+
+	mips, err := buildMips(t)
+	if err != nil {
+		s.Remove(t)
+		return
+	}
+	ss := t.stream
+	s.used -= ss.bytes(ss.level)
+	ss.setMips(t, mips)
+	ss.resident = -1
+	s.used += ss.bytes(ss.level)
+}
+
+// setMips sets the mip levels of the texture, resetting it to its coarse levels.
+func (ss *streamState) setMips(t *Texture2D, mips [][]byte) {
+
+	ss.mips = mips
+	ss.minLevel = len(mips) - 1
+	for level := range mips {
+		w, h := mipSize(t.width, level), mipSize(t.height, level)
+		if w <= StreamMinSize && h <= StreamMinSize {
+			ss.minLevel = level
+			break
+		}
+	}
+	ss.level = ss.minLevel
+}
+
+// wanted returns the mip level matching the footprint requested in the current frame.
+// Textures which were not requested keep their level.
+func (ss *streamState) wanted(t *Texture2D) int {
+
+	if ss.footprint <= 0 {
+		return ss.level
+	}
+	size := t.width
+	if t.height > size {
+		size = t.height
+	}
+	level := int(math.Floor(math.Log2(float64(size) / float64(ss.footprint))))
+	if level < 0 {
+		return 0
+	}
+	if level > ss.minLevel {
+		return ss.minLevel
+	}
+	return level
+}
+
+// bytes returns the number of bytes of the mip levels starting at the specified level.
+func (ss *streamState) bytes(level int) int64 {
+
+	var size int64
+	for _, data := range ss.mips[level:] {
+		size += int64(len(data))
+	}
+	return size
+}
+
+// bind transfers the resident mip levels of the texture, if they changed.
+// The texture must be bound to the TEXTURE_2D target.
+func (ss *streamState) bind(gs *gls.GLS, t *Texture2D) {
+
+	ss.lastUsed = ss.streamer.frame
+	if ss.resident == ss.level {
+		return
+	}
+	// Reallocates the texture with the resident levels
+	for i, data := range ss.mips[ss.level:] {
+		gs.TexImage2D(gls.TEXTURE_2D, int32(i), t.internalFormat(gs),
+			mipSize(t.width, ss.level+i), mipSize(t.height, ss.level+i), t.format, t.formatType, data)
+	}
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MAX_LEVEL, int32(len(ss.mips)-ss.level-1))
+	ss.resident = ss.level
+	t.updateData = false
+}
+
+// buildMips returns the mip levels of the data of the specified texture down
+// to one pixel, averaging blocks of 2x2 pixels.
+func buildMips(t *Texture2D) ([][]byte, error) {
+
+	data, ok := t.data.([]byte)
+	if !ok || t.format != gls.RGBA || t.formatType != gls.UNSIGNED_BYTE {
+		return nil, fmt.Errorf("only 8 bit RGBA textures can be streamed")
+	}
+	if t.width <= 0 || t.height <= 0 || len(data) < int(t.width*t.height*4) {
+		return nil, fmt.Errorf("invalid texture data size")
+	}
+	mips := [][]byte{data}
+	w, h := int(t.width), int(t.height)
+	for w > 1 || h > 1 {
+		nw, nh := int(mipSize(int32(w), 1)), int(mipSize(int32(h), 1))
+		src := mips[len(mips)-1]
+		dst := make([]byte, nw*nh*4)
+		for y := 0; y < nh; y++ {
+			y0 := 2 * y
+			y1 := y0 + 1
+			if y1 >= h {
+				y1 = y0
+			}
+			for x := 0; x < nw; x++ {
+				x0 := 2 * x
+				x1 := x0 + 1
+				if x1 >= w {
+					x1 = x0
+				}
+				for c := 0; c < 4; c++ {
+					sum := int(src[(y0*w+x0)*4+c]) + int(src[(y0*w+x1)*4+c]) +
+						int(src[(y1*w+x0)*4+c]) + int(src[(y1*w+x1)*4+c])
+					dst[(y*nw+x)*4+c] = byte((sum + 2) / 4)
+				}
+			}
+		}
+		mips = append(mips, dst)
+		w, h = nw, nh
+	}
+	return mips, nil
+}
+
+// mipSize returns the size of the specified mip level of a texture dimension.
+func mipSize(size int32, level int) int32 {
+
+	size >>= uint(level)
+	if size < 1 {
+		return 1
+	}
+	return size
+}
@@ -11,6 +11,7 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"os"
 
 	"github.com/g3n/engine/gls"
@@ -18,25 +19,30 @@ import (
 
 // Texture2D represents a texture
 type Texture2D struct {
-	gs           *gls.GLS    // Pointer to OpenGL state
-	refcount     int         // Current number of references
-	texname      uint32      // Texture handle
-	magFilter    uint32      // magnification filter
-	minFilter    uint32      // minification filter
-	wrapS        uint32      // wrap mode for s coordinate
-	wrapT        uint32      // wrap mode for t coordinate
-	iformat      int32       // internal format
-	width        int32       // texture width in pixels
-	height       int32       // texture height in pixels
-	format       uint32      // format of the pixel data
-	formatType   uint32      // type of the pixel data
-	updateData   bool        // texture data needs to be sent
-	updateParams bool        // texture parameters needs to be sent
-	genMipmap    bool        // generate mipmaps flag
-	data         interface{} // array with texture data
-	uniUnit      gls.Uniform // Texture unit uniform location cache
-	uniInfo      gls.Uniform // Texture info uniform location cache
-	udata        struct {    // Combined uniform data in 3 vec2:
+	gs           *gls.GLS     // Pointer to OpenGL state
+	refcount     int          // Current number of references
+	texname      uint32       // Texture handle
+	magFilter    uint32       // magnification filter
+	minFilter    uint32       // minification filter
+	wrapS        uint32       // wrap mode for s coordinate
+	wrapT        uint32       // wrap mode for t coordinate
+	iformat      int32        // internal format
+	srgb         bool         // texels are sRGB encoded colors
+	width        int32        // texture width in pixels
+	height       int32        // texture height in pixels
+	format       uint32       // format of the pixel data
+	formatType   uint32       // type of the pixel data
+	updateData   bool         // texture data needs to be sent
+	updateParams bool         // texture parameters needs to be sent
+	genMipmap    bool         // generate mipmaps flag
+	data         interface{}  // array with texture data
+	uniUnit      gls.Uniform  // Texture unit uniform location cache
+	uniInfo      gls.Uniform  // Texture info uniform location cache
+	uniLayer     gls.Uniform  // Texture array layer uniform location cache
+	array        *Array       // Texture array storing this texture data or nil
+	layer        int          // Layer of the texture array
+	stream       *streamState // Streaming state or nil if the texture is not streamed
+	udata        struct {     // Combined uniform data in 3 vec2:
 		offsetX float32
 		offsetY float32
 		repeatX float32
@@ -44,6 +50,11 @@ type Texture2D struct {
 		flipY   float32
 		visible float32
 	}
+
+	// CPU-side data release
+	releaseData bool                     // Release the data after it is transferred to OpenGL
+	released    bool                     // Data was released
+	fetcher     func(t *Texture2D) error // Restores the released data
 }
 
 func newTexture2D() *Texture2D {
@@ -63,6 +74,7 @@ func newTexture2D() *Texture2D {
 	// Initialize Uniform elements
 	t.uniUnit.Init("MatTexture")
 	t.uniInfo.Init("MatTexinfo")
+	t.uniLayer.Init("MatTexLayer")
 	t.SetOffset(0, 0)
 	t.SetRepeat(1, 1)
 	t.SetFlipY(true)
@@ -71,7 +83,8 @@ func newTexture2D() *Texture2D {
 }
 
 // NewTexture2DFromImage creates and returns a pointer to a new Texture2D
-// using the specified image file as data.
+// using the specified image file as data. The texture is sRGB, as the colors
+// of images are usually sRGB encoded.
 // Supported image formats are: PNG, JPEG and GIF.
 func NewTexture2DFromImage(imgfile string) (*Texture2D, error) {
 
@@ -82,14 +95,20 @@ func NewTexture2DFromImage(imgfile string) (*Texture2D, error) {
 	}
 
 	t := newTexture2D()
+	t.srgb = true
 	t.SetFromRGBA(rgba)
+	t.fetcher = func(t *Texture2D) error {
+		return t.SetImage(imgfile)
+	}
 	return t, nil
 }
 
 // NewTexture2DFromRGBA creates a new texture from a pointer to an RGBA image object.
+// The texture is sRGB, as the colors of images are usually sRGB encoded.
 func NewTexture2DFromRGBA(rgba *image.RGBA) *Texture2D {
 
 	t := newTexture2D()
+	t.srgb = true
 	t.SetFromRGBA(rgba)
 	return t
 }
@@ -121,6 +140,12 @@ func (t *Texture2D) Dispose() {
 		t.refcount--
 		return
 	}
+	if t.array != nil {
+		t.array.Remove(t)
+	}
+	if t.stream != nil {
+		t.stream.streamer.Remove(t)
+	}
 	if t.gs != nil {
 		t.gs.DeleteTextures(t.texname)
 		t.gs = nil
@@ -174,7 +199,99 @@ func (t *Texture2D) SetData(width, height int, format int, formatType, iformat i
 	t.formatType = uint32(formatType)
 	t.iformat = int32(iformat)
 	t.data = data
+	t.released = false
 	t.updateData = true
+	t.updateArray()
+	if t.stream != nil {
+		t.stream.streamer.refresh(t)
+	}
+}
+
+// SetSRGB sets whether the texels are sRGB encoded colors, which are decoded to linear values
+// when sampled if the linear color pipeline of the OpenGL state is enabled. Textures holding
+// colors, such as diffuse and emissive maps, are sRGB, while textures holding other data,
+// such as normal, roughness and occlusion maps, are not.
+func (t *Texture2D) SetSRGB(srgb bool) {
+
+	if srgb == t.srgb {
+		return
+	}
+	t.srgb = srgb
+	// Keeps a pending transfer, which may allocate storage without data
+	t.updateData = t.updateData || t.data != nil
+	t.updateArray()
+}
+
+// SRGB returns whether the texels are sRGB encoded colors.
+func (t *Texture2D) SRGB() bool {
+
+	return t.srgb
+}
+
+// internalFormat returns the internal format used to store the texture with the specified
+// OpenGL state.
+func (t *Texture2D) internalFormat(gs *gls.GLS) int32 {
+
+	return internalFormat(gs, t.iformat, t.srgb)
+}
+
+// internalFormat returns the internal format used to store texels of the specified format
+// with the specified OpenGL state, which is its sRGB equivalent for sRGB texels if the
+// linear color pipeline is enabled.
+func internalFormat(gs *gls.GLS, iformat int32, srgb bool) int32 {
+
+	if !srgb || !gs.LinearColors() {
+		return iformat
+	}
+	switch iformat {
+	case gls.RGBA8, gls.RGBA:
+		return gls.SRGB8_ALPHA8
+	case gls.RGB8, gls.RGB:
+		return gls.SRGB8
+	}
+	return iformat
+}
+
+// SetReleaseData sets whether the CPU-side data of the texture is released after it is
+// transferred to OpenGL, saving memory for large static textures. Textures created from
+// image files can restore their data by decoding the files again; other textures need a
+// fetcher (see SetFetcher). Textures packed into arrays or streamed keep their data.
+func (t *Texture2D) SetReleaseData(release bool) {
+
+	t.releaseData = release
+}
+
+// ReleaseData returns whether the CPU-side data of the texture is released after it is
+// transferred to OpenGL.
+func (t *Texture2D) ReleaseData() bool {
+
+	return t.releaseData
+}
+
+// Released returns whether the CPU-side data of the texture is currently released.
+func (t *Texture2D) Released() bool {
+
+	return t.released
+}
+
+// SetFetcher sets the function which restores the released data of the texture,
+// usually reloading it from its asset source. It must call SetData or SetImage.
+func (t *Texture2D) SetFetcher(fetcher func(t *Texture2D) error) {
+
+	t.fetcher = fetcher
+}
+
+// Restore restores the released CPU-side data of the texture using its fetcher.
+// It does nothing if the data was not released.
+func (t *Texture2D) Restore() error {
+
+	if !t.released {
+		return nil
+	}
+	if t.fetcher == nil {
+		return fmt.Errorf("texture data released without fetcher")
+	}
+	return t.fetcher(t)
 }
 
 // SetVisible sets the visibility state of the texture
@@ -203,6 +320,7 @@ func (t *Texture2D) SetMagFilter(magFilter uint32) {
 
 	t.magFilter = magFilter
 	t.updateParams = true
+	t.updateArray()
 }
 
 // SetMinFilter sets the filter to be applied when the texture element
@@ -211,6 +329,7 @@ func (t *Texture2D) SetMinFilter(minFilter uint32) {
 
 	t.minFilter = minFilter
 	t.updateParams = true
+	t.updateArray()
 }
 
 // SetWrapS set the wrapping mode for texture S coordinate
@@ -219,6 +338,7 @@ func (t *Texture2D) SetWrapS(wrapS uint32) {
 
 	t.wrapS = wrapS
 	t.updateParams = true
+	t.updateArray()
 }
 
 // SetWrapT set the wrapping mode for texture T coordinate
@@ -227,6 +347,7 @@ func (t *Texture2D) SetWrapT(wrapT uint32) {
 
 	t.wrapT = wrapT
 	t.updateParams = true
+	t.updateArray()
 }
 
 // SetRepeat set the repeat factor
@@ -277,6 +398,23 @@ func (t *Texture2D) Height() int {
 	return int(t.height)
 }
 
+// RGBA returns the texture data as an RGBA image sharing its pixels, or nil if the data
+// is not 8 bit RGBA or was released. It is used to export textures.
+func (t *Texture2D) RGBA() *image.RGBA {
+
+	pix, ok := t.data.([]byte)
+	if !ok || t.format != gls.RGBA || t.formatType != gls.UNSIGNED_BYTE || len(pix) < int(4*t.width*t.height) {
+		return nil
+	}
+	return &image.RGBA{Pix: pix, Stride: int(4 * t.width), Rect: image.Rect(0, 0, int(t.width), int(t.height))}
+}
+
+// FlipY returns the state for flipping the Y coordinate.
+func (t *Texture2D) FlipY() bool {
+
+	return t.udata.flipY != 0
+}
+
 // DecodeImage reads and decodes the specified image file into RGBA8.
 // The supported image files are PNG, JPEG and GIF.
 func DecodeImage(imgfile string) (*image.RGBA, error) {
@@ -287,9 +425,15 @@ func DecodeImage(imgfile string) (*image.RGBA, error) {
 		return nil, err
 	}
 	defer file.Close()
+	return DecodeImageReader(file)
+}
+
+// DecodeImageReader decodes the image read from the specified reader into RGBA8.
+// The supported image formats are PNG, JPEG and GIF.
+func DecodeImageReader(r io.Reader) (*image.RGBA, error) {
 
 	// Decodes image
-	img, _, err := image.Decode(file)
+	img, _, err := image.Decode(r)
 	if err != nil {
 		return nil, err
 	}
@@ -303,9 +447,87 @@ func DecodeImage(imgfile string) (*image.RGBA, error) {
 	return rgba, nil
 }
 
+// Handle returns the OpenGL handle of the texture, creating the texture and
+// transferring its data and parameters if necessary.
+// It can be used to attach the texture to a framebuffer to render into it.
+// The texture is left bound to the first texture unit.
+func (t *Texture2D) Handle(gs *gls.GLS) uint32 {
+
+	t.bind(gs, 0)
+	return t.texname
+}
+
 // RenderSetup is called by the material render setup
 func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could have as input - TEXTURE0 (slot) and uni location
 
+	t.bind(gs, slotIdx)
+
+	// Transfer texture unit uniform
+	var location int32
+	if uniIdx == 0 {
+		location = t.uniUnit.Location(gs)
+	} else {
+		location = t.uniUnit.LocationIdx(gs, int32(uniIdx))
+	}
+	gs.Uniform1i(location, int32(slotIdx))
+
+	// Transfer texture info combined uniform
+	const vec2count = 3
+	location = t.uniInfo.LocationIdx(gs, vec2count*int32(uniIdx))
+	gs.Uniform2fv(location, vec2count, &t.udata.offsetX)
+}
+
+// Array returns the texture array storing the data of this texture and
+// the index of its layer. It returns nil if the texture is not in an array.
+func (t *Texture2D) Array() (*Array, int) {
+
+	return t.array, t.layer
+}
+
+// RenderSetupArray is called by the material render setup instead of RenderSetup
+// when the material uses texture arrays. It binds the texture array storing this
+// texture and transfers the index of its layer.
+func (t *Texture2D) RenderSetupArray(gs *gls.GLS, slotIdx, uniIdx int) {
+
+	t.array.bind(gs, slotIdx)
+
+	// Transfer texture unit uniform
+	var location int32
+	if uniIdx == 0 {
+		location = t.uniUnit.Location(gs)
+	} else {
+		location = t.uniUnit.LocationIdx(gs, int32(uniIdx))
+	}
+	gs.Uniform1i(location, int32(slotIdx))
+
+	// Transfer texture layer uniform
+	location = t.uniLayer.LocationIdx(gs, int32(uniIdx))
+	gs.Uniform1f(location, float32(t.layer))
+
+	// Transfer texture info combined uniform
+	const vec2count = 3
+	location = t.uniInfo.LocationIdx(gs, vec2count*int32(uniIdx))
+	gs.Uniform2fv(location, vec2count, &t.udata.offsetX)
+}
+
+// updateArray transfers the texture data to its texture array or removes the
+// texture from the array if it is no longer compatible with it.
+func (t *Texture2D) updateArray() {
+
+	if t.array == nil {
+		return
+	}
+	if t.data != nil && t.array.Compatible(t) {
+		t.array.invalidate(t)
+	} else {
+		t.array.Remove(t)
+	}
+}
+
+// bind binds the texture to the specified texture unit,
+// transferring its data and parameters if necessary.
+func (t *Texture2D) bind(gs *gls.GLS, slotIdx int) {
+
 	// One time initialization
 	if t.gs == nil {
 		t.texname = gs.GenTexture()
@@ -317,16 +539,18 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could hav
 	gs.BindTexture(gls.TEXTURE_2D, t.texname)
 
 	// Transfer texture data to OpenGL if necessary
-	if t.updateData {
+	if t.stream != nil {
+		t.stream.bind(gs, t)
+	} else if t.updateData {
 		gs.TexImage2D(
-			gls.TEXTURE_2D, // texture type
-			0,              // level of detail
-			t.iformat,      // internal format
-			t.width,        // width in texels
-			t.height,       // height in texels
-			t.format,       // format of supplied texture data
-			t.formatType,   // type of external format color component
-			t.data,         // image data
+			gls.TEXTURE_2D,       // texture type
+			0,                    // level of detail
+			t.internalFormat(gs), // internal format
+			t.width,              // width in texels
+			t.height,             // height in texels
+			t.format,             // format of supplied texture data
+			t.formatType,         // type of external format color component
+			t.data,               // image data
 		)
 		// Generates mipmaps if requested
 		if t.genMipmap {
@@ -334,6 +558,11 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could hav
 		}
 		// No data to send
 		t.updateData = false
+		// Release the transferred data if requested
+		if t.releaseData {
+			t.data = nil
+			t.released = true
+		}
 	}
 
 	// Sets texture parameters if needed
@@ -344,18 +573,4 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could hav
 		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_T, int32(t.wrapT))
 		t.updateParams = false
 	}
-
-	// Transfer texture unit uniform
-	var location int32
-	if uniIdx == 0 {
-		location = t.uniUnit.Location(gs)
-	} else {
-		location = t.uniUnit.LocationIdx(gs, int32(uniIdx))
-	}
-	gs.Uniform1i(location, int32(slotIdx))
-
-	// Transfer texture info combined uniform
-	const vec2count = 3
-	location = t.uniInfo.LocationIdx(gs, vec2count*int32(uniIdx))
-	gs.Uniform2fv(location, vec2count, &t.udata.offsetX)
 }
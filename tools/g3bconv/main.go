@@ -0,0 +1,159 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// g3bconv converts OBJ and glTF models to the G3B binary format of the engine,
+// preprocessing their meshes so they load without parsing or converting vertices.
+// Usage:
+// 		g3bconv [options] <input file> [<output file>]
+// The output file defaults to the input file with the ".g3b" extension.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/loader/g3b"
+	"github.com/g3n/engine/loader/gltf"
+	"github.com/g3n/engine/loader/obj"
+)
+
+// Program name and version
+const (
+	PROGNAME = "g3bconv"
+	VMAJOR   = 0
+	VMINOR   = 1
+)
+
+// Command line options
+var (
+	oCompress = flag.Bool("z", false, "Compress the output file (it can not be memory-mapped)")
+	oCompact  = flag.Bool("compact", false, "Store normals, tangents and texture coordinates in compact formats")
+	oTangents = flag.Bool("tangents", true, "Generate tangents")
+	oOptimize = flag.Bool("optimize", true, "Reorder triangles and vertices for the vertex cache")
+	oLevels   = flag.Int("progressive", 0, "Write a progressive stream with up to this number of coarser levels of detail")
+	oMtl      = flag.String("mtl", "", "Material file of OBJ models")
+	oScene    = flag.Int("scene", -1, "Index of the scene of glTF models (default scene if negative)")
+)
+
+func main() {
+
+	// Parse command line parameters
+	flag.Usage = usage
+	flag.Parse()
+	if len(flag.Args()) == 0 {
+		log.Fatal("Input file not supplied")
+	}
+	input := flag.Args()[0]
+	output := strings.TrimSuffix(input, filepath.Ext(input)) + ".g3b"
+	if len(flag.Args()) > 1 {
+		output = flag.Args()[1]
+	}
+
+	// Loads the model
+	start := time.Now()
+	root, err := load(input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	loaded := time.Since(start)
+
+	// Converts and saves it
+	opts := g3b.Options{Tangents: *oTangents, Optimize: *oOptimize}
+	if *oCompact {
+		opts.Layout = gls.CompactLayout
+	}
+	f, err := g3b.Convert(root, &opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *oLevels > 0 {
+		err = saveProgressive(f, output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %d nodes, %d meshes, %d materials, %d textures\n",
+			output, len(f.Nodes), len(f.Meshes), len(f.Materials), len(f.Textures))
+		return
+	}
+	err = f.Save(output, *oCompress)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Measures the loading time of the converted file
+	start = time.Now()
+	f, err = g3b.Open(output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f.NewScene()
+	f.Close()
+	fmt.Printf("%s: %d nodes, %d meshes, %d materials, %d textures\n",
+		output, len(f.Nodes), len(f.Meshes), len(f.Materials), len(f.Textures))
+	fmt.Printf("load time: %v (source: %v)\n", time.Since(start), loaded)
+}
+
+// saveProgressive saves the specified file as a progressive stream.
+func saveProgressive(f *g3b.File, path string) error {
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = f.WriteProgressive(out, *oLevels, *oCompress)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// load loads the scene of the specified OBJ, glTF or GLB file.
+func load(path string) (core.INode, error) {
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".obj":
+		dec, err := obj.Decode(path, *oMtl)
+		if err != nil {
+			return nil, err
+		}
+		return dec.NewGroup()
+	case ".gltf", ".glb":
+		var g *gltf.GLTF
+		var err error
+		if strings.ToLower(filepath.Ext(path)) == ".glb" {
+			g, err = gltf.ParseBin(path)
+		} else {
+			g, err = gltf.ParseJSON(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		scene := *oScene
+		if scene < 0 {
+			scene = 0
+			if g.Scene != nil {
+				scene = *g.Scene
+			}
+		}
+		return g.LoadScene(scene)
+	}
+	return nil, fmt.Errorf("unsupported model format:%s", filepath.Ext(path))
+}
+
+// usage shows the application usage
+func usage() {
+
+	fmt.Fprintf(os.Stderr, "%s v%d.%d\n", PROGNAME, VMAJOR, VMINOR)
+	fmt.Fprintf(os.Stderr, "usage: %s [options] <input file> [<output file>]\n", strings.ToLower(PROGNAME))
+	flag.PrintDefaults()
+	os.Exit(2)
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// g3npipeline preprocesses a directory of source assets into a directory of assets
+// ready to be loaded at runtime, with a manifest describing them (see util/pipeline).
+// Usage:
+// 		g3npipeline [options] <source dir> <output dir>
+// It is normally invoked by "go generate" with a directive such as:
+// 		//go:generate g3npipeline assets-src assets
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/util/pipeline"
+)
+
+// Program name and version
+const (
+	PROGNAME = "g3npipeline"
+	VMAJOR   = 0
+	VMINOR   = 1
+)
+
+// Command line options
+var (
+	oForce       = flag.Bool("f", false, "Process all the assets even if they did not change")
+	oVerbose     = flag.Bool("v", false, "Show the processed assets")
+	oMaxSize     = flag.Int("maxsize", pipeline.DefaultTextureOptions.MaxSize, "Maximum width and height of the images (0 for no limit)")
+	oPowerOfTwo  = flag.Bool("pot", false, "Downscale the images to power of two sizes")
+	oQuality     = flag.Int("jpeg", 0, "Encode the opaque images as JPEG with this quality (0 to encode all images as PNG)")
+	oCompress    = flag.Bool("z", false, "Compress the models (they can not be memory-mapped)")
+	oCompact     = flag.Bool("compact", false, "Store normals, tangents and texture coordinates of the models in compact formats")
+	oProgressive = flag.Int("progressive", 0, "Write the models as progressive streams with up to this number of coarser levels of detail")
+	oAtlasSize   = flag.Int("atlassize", pipeline.DefaultAtlasOptions.MaxSize, "Maximum width and height of the atlases")
+	oPadding     = flag.Int("padding", pipeline.DefaultAtlasOptions.Padding, "Pixels between the images of the atlases")
+	oRunes       = flag.String("runes", "", "Additional runes of the baked fonts")
+	oFontSize    = flag.Float64("fontsize", pipeline.DefaultFontOptions.Size, "Size of the baked glyphs in pixels per em")
+	oSpread      = flag.Int("spread", pipeline.DefaultFontOptions.Spread, "Distance range of the baked glyphs in pixels")
+)
+
+func main() {
+
+	// Parse command line parameters
+	flag.Usage = usage
+	flag.Parse()
+	if len(flag.Args()) != 2 {
+		usage()
+	}
+
+	p := pipeline.NewPipeline(flag.Args()[0], flag.Args()[1])
+	p.Force = *oForce
+	p.Texture.MaxSize = *oMaxSize
+	p.Texture.PowerOfTwo = *oPowerOfTwo
+	p.Texture.Quality = *oQuality
+	p.Model.Compress = *oCompress
+	p.Model.Progressive = *oProgressive
+	if *oCompact {
+		p.Model.Layout = gls.CompactLayout
+	}
+	p.Atlas.MaxSize = *oAtlasSize
+	p.Atlas.Padding = *oPadding
+	p.Font.Runes += *oRunes
+	p.Font.Size = *oFontSize
+	p.Font.Spread = *oSpread
+	if *oVerbose {
+		p.Log = log.Printf
+	}
+	m, err := p.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var size int64
+	for _, asset := range m.Assets {
+		size += asset.Size
+	}
+	fmt.Printf("%s: %d assets, %d bytes\n", flag.Args()[1], len(m.Assets), size)
+}
+
+// usage shows the application usage
+func usage() {
+
+	fmt.Fprintf(os.Stderr, "%s v%d.%d\n", PROGNAME, VMAJOR, VMINOR)
+	fmt.Fprintf(os.Stderr, "usage: %s [options] <source dir> <output dir>\n", strings.ToLower(PROGNAME))
+	flag.PrintDefaults()
+	os.Exit(2)
+}
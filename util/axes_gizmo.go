@@ -0,0 +1,153 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// Corner of the viewport where an AxesGizmo is pinned.
+type Corner int
+
+// The viewport corners.
+const (
+	CornerBottomLeft = Corner(iota)
+	CornerBottomRight
+	CornerTopLeft
+	CornerTopRight
+)
+
+// AxesGizmo shows the orientation of the world axes as seen by the camera, pinned in a
+// corner of the viewport with a fixed size in pixels. The X, Y and Z axes are red, green
+// and blue, as in AxisHelper, and the negative axes are darker. It can be added anywhere
+// in the scene, as only the camera rotation is used, and it is drawn over everything else.
+type AxesGizmo struct {
+	graphic.Graphic             // Embedded graphic
+	corner          Corner      // Viewport corner
+	size            float32     // Length of the axes in pixels
+	margin          float32     // Distance from the viewport borders in pixels
+	uniMVPm         gls.Uniform // Model view projection matrix uniform location cache
+}
+
+// NewAxesGizmo returns a pointer to a new AxesGizmo pinned in the specified corner with
+// axes of the specified length in pixels.
+func NewAxesGizmo(corner Corner, size float32) *AxesGizmo {
+
+	g := new(AxesGizmo)
+	g.corner = corner
+	g.size = size
+	g.margin = 10
+
+	geom := geometry.NewGeometry()
+	positions := math32.NewArrayF32(0, 36)
+	positions.Append(
+		0, 0, 0, 1, 0, 0,
+		0, 0, 0, 0, 1, 0,
+		0, 0, 0, 0, 0, 1,
+		0, 0, 0, -1, 0, 0,
+		0, 0, 0, 0, -1, 0,
+		0, 0, 0, 0, 0, -1,
+	)
+	colors := math32.NewArrayF32(0, 36)
+	colors.Append(
+		1, 0, 0, 1, 0.2, 0.2,
+		0, 1, 0, 0.2, 1, 0.2,
+		0, 0, 1, 0.2, 0.2, 1,
+		0.4, 0, 0, 0.4, 0, 0,
+		0, 0.4, 0, 0, 0.4, 0,
+		0, 0, 0.4, 0, 0, 0.4,
+	)
+	geom.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	geom.AddVBO(gls.NewVBO(colors).AddAttrib(gls.VertexColor))
+
+	// Drawn after all other objects without depth testing
+	mat := material.NewBasic()
+	mat.SetTransparent(true)
+	mat.SetDepthTest(false)
+	mat.SetLineWidth(2)
+
+	g.Graphic.Init(g, geom, gls.LINES)
+	g.AddMaterial(g, mat, 0, 0)
+	g.SetCullable(false)
+	g.SetRenderOrder(1 << 30)
+	g.uniMVPm.Init("MVP")
+	return g
+}
+
+// SetCorner sets the viewport corner where the gizmo is pinned.
+func (g *AxesGizmo) SetCorner(corner Corner) {
+
+	g.corner = corner
+}
+
+// Corner returns the viewport corner where the gizmo is pinned.
+func (g *AxesGizmo) Corner() Corner {
+
+	return g.corner
+}
+
+// SetSize sets the length of the axes in pixels.
+func (g *AxesGizmo) SetSize(size float32) {
+
+	g.size = size
+}
+
+// Size returns the length of the axes in pixels.
+func (g *AxesGizmo) Size() float32 {
+
+	return g.size
+}
+
+// SetMargin sets the distance in pixels between the gizmo and the viewport borders.
+func (g *AxesGizmo) SetMargin(margin float32) {
+
+	g.margin = margin
+}
+
+// Margin returns the distance in pixels between the gizmo and the viewport borders.
+func (g *AxesGizmo) Margin() float32 {
+
+	return g.margin
+}
+
+// RenderSetup is called by the engine before drawing the gizmo.
+func (g *AxesGizmo) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	_, _, width, height := gs.GetViewport()
+	if width <= 0 || height <= 0 {
+		return
+	}
+	w := float32(width)
+	h := float32(height)
+
+	// Center of the gizmo in normalized device coordinates
+	offset := g.size + g.margin
+	cx := -1 + 2*offset/w
+	cy := -1 + 2*offset/h
+	if g.corner == CornerBottomRight || g.corner == CornerTopRight {
+		cx = -cx
+	}
+	if g.corner == CornerTopLeft || g.corner == CornerTopRight {
+		cy = -cy
+	}
+
+	// Rotation of the camera scaled to pixels, with the nearest axes in front
+	v := &rinfo.ViewMatrix
+	sx := 2 * g.size / w
+	sy := 2 * g.size / h
+	var mvpm math32.Matrix4
+	mvpm.Set(
+		v[0]*sx, v[4]*sx, v[8]*sx, cx,
+		v[1]*sy, v[5]*sy, v[9]*sy, cy,
+		-v[2]*0.1, -v[6]*0.1, -v[10]*0.1, 0,
+		0, 0, 0, 1,
+	)
+	gs.UniformMatrix4fv(g.uniMVPm.Location(gs), 1, false, &mvpm[0])
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package determinism
+
+import (
+	"time"
+)
+
+// Clock is a fixed timestep clock.
+// Real elapsed time is accumulated and consumed in steps of exactly the same
+// duration, so the simulation advances identically regardless of frame rate.
+// In lockstep mode real time is ignored and every call to Advance executes a single step.
+type Clock struct {
+	step     time.Duration // Fixed step duration
+	maxSteps int           // Maximum number of steps executed per call to Advance
+	lockstep bool          // Execute exactly one step per Advance, ignoring real time
+	accum    time.Duration // Accumulated time not yet consumed by steps
+	frame    uint64        // Number of steps executed so far
+}
+
+// NewClock creates and returns a pointer to a new Clock with the specified step duration,
+// which must be positive.
+func NewClock(step time.Duration) *Clock {
+
+	if step <= 0 {
+		panic("determinism: clock step must be positive")
+	}
+	c := new(Clock)
+	c.step = step
+	c.maxSteps = 8
+	return c
+}
+
+// Step returns the fixed step duration.
+func (c *Clock) Step() time.Duration {
+
+	return c.step
+}
+
+// StepSeconds returns the fixed step duration in seconds.
+func (c *Clock) StepSeconds() float32 {
+
+	return float32(c.step.Seconds())
+}
+
+// SetMaxSteps sets the maximum number of steps executed by a single call to Advance.
+// Time in excess is discarded to avoid a spiral of death when the simulation is too slow.
+func (c *Clock) SetMaxSteps(n int) {
+
+	c.maxSteps = n
+}
+
+// SetLockstep sets whether the clock ignores real time and executes exactly one step per Advance.
+func (c *Clock) SetLockstep(state bool) {
+
+	c.lockstep = state
+}
+
+// Lockstep returns whether the clock is in lockstep mode.
+func (c *Clock) Lockstep() bool {
+
+	return c.lockstep
+}
+
+// Frame returns the number of steps executed so far.
+func (c *Clock) Frame() uint64 {
+
+	return c.frame
+}
+
+// Alpha returns the fraction of a step remaining in the accumulator,
+// which can be used to interpolate rendering between two simulation states.
+func (c *Clock) Alpha() float32 {
+
+	return float32(c.accum) / float32(c.step)
+}
+
+// Advance accumulates the specified real elapsed time and calls the step
+// function once for each whole step available, passing the frame number.
+// Returns the number of steps executed.
+func (c *Clock) Advance(elapsed time.Duration, stepFunc func(frame uint64)) int {
+
+	if c.lockstep {
+		stepFunc(c.frame)
+		c.frame++
+		return 1
+	}
+	c.accum += elapsed
+	n := 0
+	for c.accum >= c.step {
+		if c.maxSteps > 0 && n >= c.maxSteps {
+			c.accum = 0
+			break
+		}
+		stepFunc(c.frame)
+		c.frame++
+		c.accum -= c.step
+		n++
+	}
+	return n
+}
+
+// Reset resets the frame counter and the accumulated time.
+func (c *Clock) Reset() {
+
+	c.accum = 0
+	c.frame = 0
+}
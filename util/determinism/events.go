@@ -0,0 +1,111 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package determinism
+
+import (
+	"sort"
+
+	"github.com/g3n/engine/core"
+)
+
+// Event is an event posted to an EventQueue.
+type Event struct {
+	Frame    uint64      // Simulation frame in which the event is dispatched
+	Priority int         // Events with lower priority values are dispatched first
+	Seq      uint64      // Posting sequence number, used to break ties
+	Name     string      // Event name
+	Data     interface{} // Event data
+}
+
+// EventQueue queues events and dispatches them at frame boundaries in a
+// well defined order: by frame, then priority, then posting order.
+// Events coming from the window system arrive at arbitrary times between frames;
+// queueing them makes the simulation see them at the same step on every run.
+// Dispatched events are optionally recorded so they can be replayed later.
+type EventQueue struct {
+	pending []Event // Events waiting to be dispatched
+	seq     uint64  // Next sequence number
+	record  bool    // Record dispatched events
+	log     []Event // Recorded events
+}
+
+// NewEventQueue creates and returns a pointer to a new EventQueue.
+func NewEventQueue() *EventQueue {
+
+	q := new(EventQueue)
+	q.pending = make([]Event, 0)
+	return q
+}
+
+// Post queues an event to be dispatched in the specified frame with the specified priority.
+func (q *EventQueue) Post(frame uint64, priority int, name string, data interface{}) {
+
+	q.pending = append(q.pending, Event{Frame: frame, Priority: priority, Seq: q.seq, Name: name, Data: data})
+	q.seq++
+}
+
+// Len returns the number of pending events.
+func (q *EventQueue) Len() int {
+
+	return len(q.pending)
+}
+
+// Flush dispatches, in order, all pending events scheduled up to and including
+// the specified frame. Returns the number of events dispatched.
+func (q *EventQueue) Flush(frame uint64, d core.IDispatcher) int {
+
+	sort.SliceStable(q.pending, func(i, j int) bool {
+		a, b := &q.pending[i], &q.pending[j]
+		if a.Frame != b.Frame {
+			return a.Frame < b.Frame
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.Seq < b.Seq
+	})
+	n := 0
+	for n < len(q.pending) && q.pending[n].Frame <= frame {
+		n++
+	}
+	due := make([]Event, n)
+	copy(due, q.pending[:n])
+	q.pending = q.pending[n:]
+	for _, ev := range due {
+		if q.record {
+			q.log = append(q.log, ev)
+		}
+		d.Dispatch(ev.Name, ev.Data)
+	}
+	return n
+}
+
+// SetRecording sets whether dispatched events are recorded.
+func (q *EventQueue) SetRecording(state bool) {
+
+	q.record = state
+}
+
+// Recorded returns the list of recorded events.
+func (q *EventQueue) Recorded() []Event {
+
+	return q.log
+}
+
+// Replay queues the specified previously recorded events, keeping their frames and order.
+func (q *EventQueue) Replay(events []Event) {
+
+	for _, ev := range events {
+		q.Post(ev.Frame, ev.Priority, ev.Name, ev.Data)
+	}
+}
+
+// Clear discards all pending and recorded events.
+func (q *EventQueue) Clear() {
+
+	q.pending = q.pending[:0]
+	q.log = nil
+	q.seq = 0
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package determinism
+
+import (
+	"math"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// FNV-1a 64 bit parameters
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// IHashable is the interface for objects which can contribute their state to a frame hash.
+type IHashable interface {
+	HashState(h *Hasher)
+}
+
+// Hasher computes a hash over simulation state.
+// Floating point values are hashed by their exact bit patterns,
+// so two states hash equally only if they are bit-for-bit identical.
+type Hasher struct {
+	sum uint64
+}
+
+// NewHasher creates and returns a pointer to a new Hasher.
+func NewHasher() *Hasher {
+
+	h := new(Hasher)
+	h.Reset()
+	return h
+}
+
+// Reset resets the hasher to its initial state.
+func (h *Hasher) Reset() {
+
+	h.sum = fnvOffset
+}
+
+// Sum returns the current hash value.
+func (h *Hasher) Sum() uint64 {
+
+	return h.sum
+}
+
+// WriteUint64 adds an unsigned integer to the hash.
+func (h *Hasher) WriteUint64(v uint64) {
+
+	for i := uint(0); i < 8; i++ {
+		h.sum ^= (v >> (8 * i)) & 0xFF
+		h.sum *= fnvPrime
+	}
+}
+
+// WriteInt adds an integer to the hash.
+func (h *Hasher) WriteInt(v int) {
+
+	h.WriteUint64(uint64(v))
+}
+
+// WriteBool adds a boolean to the hash.
+func (h *Hasher) WriteBool(v bool) {
+
+	if v {
+		h.WriteUint64(1)
+	} else {
+		h.WriteUint64(0)
+	}
+}
+
+// WriteFloat32 adds a float32 to the hash.
+func (h *Hasher) WriteFloat32(v float32) {
+
+	h.WriteUint64(uint64(math.Float32bits(v)))
+}
+
+// WriteString adds a string to the hash.
+func (h *Hasher) WriteString(s string) {
+
+	h.WriteInt(len(s))
+	for i := 0; i < len(s); i++ {
+		h.sum ^= uint64(s[i])
+		h.sum *= fnvPrime
+	}
+}
+
+// WriteVector3 adds a vector to the hash.
+func (h *Hasher) WriteVector3(v *math32.Vector3) {
+
+	h.WriteFloat32(v.X)
+	h.WriteFloat32(v.Y)
+	h.WriteFloat32(v.Z)
+}
+
+// WriteQuaternion adds a quaternion to the hash.
+func (h *Hasher) WriteQuaternion(q *math32.Quaternion) {
+
+	h.WriteFloat32(q.X)
+	h.WriteFloat32(q.Y)
+	h.WriteFloat32(q.Z)
+	h.WriteFloat32(q.W)
+}
+
+// WriteHashable adds the state of the specified object to the hash.
+func (h *Hasher) WriteHashable(obj IHashable) {
+
+	obj.HashState(h)
+}
+
+// WriteNode adds the local transforms and visibility of the specified node
+// and all its descendants, in depth-first order, to the hash.
+// Nodes implementing IHashable also contribute their own state.
+func (h *Hasher) WriteNode(inode core.INode) {
+
+	n := inode.GetNode()
+	h.WriteString(n.Name())
+	h.WriteBool(n.Visible())
+	pos := n.Position()
+	h.WriteVector3(&pos)
+	quat := n.Quaternion()
+	h.WriteQuaternion(&quat)
+	scale := n.Scale()
+	h.WriteVector3(&scale)
+	if hs, ok := inode.(IHashable); ok {
+		hs.HashState(h)
+	}
+	children := n.Children()
+	h.WriteInt(len(children))
+	for _, child := range children {
+		h.WriteNode(child)
+	}
+}
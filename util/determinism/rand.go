@@ -0,0 +1,80 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package determinism
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+)
+
+// RandRegistry manages named random number streams derived from a single seed.
+// Each stream is seeded from the registry seed and its name, so the sequence
+// produced by a stream does not depend on the order in which streams are created
+// or on how much other streams are used.
+type RandRegistry struct {
+	seed    int64                 // Master seed
+	streams map[string]*rand.Rand // Streams by name
+}
+
+// NewRandRegistry creates and returns a pointer to a new RandRegistry with the specified seed.
+func NewRandRegistry(seed int64) *RandRegistry {
+
+	rr := new(RandRegistry)
+	rr.seed = seed
+	rr.streams = make(map[string]*rand.Rand)
+	return rr
+}
+
+// Seed returns the master seed of the registry.
+func (rr *RandRegistry) Seed() int64 {
+
+	return rr.seed
+}
+
+// Get returns the random number stream with the specified name, creating it if necessary.
+func (rr *RandRegistry) Get(name string) *rand.Rand {
+
+	r, ok := rr.streams[name]
+	if !ok {
+		r = rand.New(rand.NewSource(rr.streamSeed(name)))
+		rr.streams[name] = r
+	}
+	return r
+}
+
+// Names returns the sorted names of all existing streams.
+func (rr *RandRegistry) Names() []string {
+
+	names := make([]string, 0, len(rr.streams))
+	for name := range rr.streams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reset reseeds the registry. All existing streams are restarted from their
+// initial state derived from the new seed.
+func (rr *RandRegistry) Reset(seed int64) {
+
+	rr.seed = seed
+	for name, r := range rr.streams {
+		r.Seed(rr.streamSeed(name))
+	}
+}
+
+// streamSeed returns the seed for the stream with the specified name.
+func (rr *RandRegistry) streamSeed(name string) int64 {
+
+	h := fnv.New64a()
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(uint64(rr.seed) >> (8 * uint(i)))
+	}
+	h.Write(b[:])
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
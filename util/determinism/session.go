@@ -0,0 +1,109 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package determinism implements support for deterministic, replayable simulations.
+// It provides a fixed timestep clock, a registry of seeded random number streams,
+// an ordered event queue and per-frame state hashes which can be compared
+// across runs to verify that a replay is bit-for-bit identical.
+package determinism
+
+import (
+	"time"
+
+	"github.com/g3n/engine/core"
+)
+
+// Session ties together the clock, random streams and event queue of a
+// deterministic simulation and keeps the history of frame hashes.
+type Session struct {
+	core.Dispatcher                 // Embedded event dispatcher for queued events
+	Clock           *Clock          // Fixed timestep clock
+	Rand            *RandRegistry   // Seeded random number streams
+	Events          *EventQueue     // Ordered event queue
+	hasher          Hasher          // Reused hasher
+	state           func(h *Hasher) // Function which writes the simulation state to the hasher
+	hashes          []uint64        // Hash of the state at the end of each frame
+}
+
+// NewSession creates and returns a pointer to a new Session with the specified positive step duration and seed.
+// The state function, if not nil, is called after each step to hash the simulation state.
+func NewSession(step time.Duration, seed int64, state func(h *Hasher)) *Session {
+
+	s := new(Session)
+	s.Dispatcher.Initialize()
+	s.Clock = NewClock(step)
+	s.Rand = NewRandRegistry(seed)
+	s.Events = NewEventQueue()
+	s.state = state
+	s.hashes = make([]uint64, 0)
+	return s
+}
+
+// Post queues an event to be dispatched at the start of the next simulation step.
+func (s *Session) Post(priority int, name string, data interface{}) {
+
+	s.Events.Post(s.Clock.Frame(), priority, name, data)
+}
+
+// Update advances the simulation by the specified real elapsed time.
+// For each fixed step, queued events are dispatched in order through the session
+// dispatcher, the step function is called and the frame hash is recorded.
+// Returns the number of steps executed.
+func (s *Session) Update(elapsed time.Duration, stepFunc func(frame uint64, dt float32)) int {
+
+	return s.Clock.Advance(elapsed, func(frame uint64) {
+		s.Events.Flush(frame, s)
+		stepFunc(frame, s.Clock.StepSeconds())
+		s.hashes = append(s.hashes, s.Hash())
+	})
+}
+
+// Hash returns the hash of the current simulation state.
+func (s *Session) Hash() uint64 {
+
+	s.hasher.Reset()
+	if s.state != nil {
+		s.state(&s.hasher)
+	}
+	return s.hasher.Sum()
+}
+
+// FrameHashes returns a copy of the recorded hash of each executed frame.
+func (s *Session) FrameHashes() []uint64 {
+
+	hashes := make([]uint64, len(s.hashes))
+	copy(hashes, s.hashes)
+	return hashes
+}
+
+// Verify compares the recorded frame hashes against the specified reference hashes.
+// Returns the first frame at which they diverge or -1 if they are identical.
+// If one of the histories is a prefix of the other, they diverge at the first frame
+// missing from the shorter one.
+func (s *Session) Verify(reference []uint64) int {
+
+	for i := 0; i < len(s.hashes) && i < len(reference); i++ {
+		if s.hashes[i] != reference[i] {
+			return i
+		}
+	}
+	if len(s.hashes) != len(reference) {
+		if len(s.hashes) < len(reference) {
+			return len(s.hashes)
+		}
+		return len(reference)
+	}
+	return -1
+}
+
+// Reset restarts the session with the specified seed, clearing the clock,
+// the queued and recorded events and the frame hashes.
+// The recorded events should be saved before resetting if they are to be replayed.
+func (s *Session) Reset(seed int64) {
+
+	s.Clock.Reset()
+	s.Rand.Reset(seed)
+	s.Events.Clear()
+	s.hashes = make([]uint64, 0)
+}
@@ -0,0 +1,197 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package environment
+
+import (
+	"sort"
+
+	"github.com/g3n/engine/math32"
+)
+
+// State contains the values of the environment at a time of day.
+type State struct {
+	SunColor         math32.Color       // Color of the sun light
+	SunIntensity     float32            // Intensity of the sun light
+	AmbientColor     math32.Color       // Color of the ambient light
+	AmbientIntensity float32            // Intensity of the ambient light
+	SkyZenith        math32.Color       // Color of the sky overhead
+	SkyHorizon       math32.Color       // Color of the sky at the horizon
+	FogColor         math32.Color       // Color of the fog
+	FogDensity       float32            // Density of the fog
+	Sounds           map[string]float32 // Gains of the ambient sounds by name, absent sounds are silent
+}
+
+// Lerp sets this state to the linear interpolation between itself and
+// the specified state by alpha, from 0 (unchanged) to 1 (the other state).
+func (s *State) Lerp(other *State, alpha float32) *State {
+
+	lerp := func(a, b float32) float32 { return a + (b-a)*alpha }
+	s.SunColor.Lerp(&other.SunColor, alpha)
+	s.SunIntensity = lerp(s.SunIntensity, other.SunIntensity)
+	s.AmbientColor.Lerp(&other.AmbientColor, alpha)
+	s.AmbientIntensity = lerp(s.AmbientIntensity, other.AmbientIntensity)
+	s.SkyZenith.Lerp(&other.SkyZenith, alpha)
+	s.SkyHorizon.Lerp(&other.SkyHorizon, alpha)
+	s.FogColor.Lerp(&other.FogColor, alpha)
+	s.FogDensity = lerp(s.FogDensity, other.FogDensity)
+
+	sounds := make(map[string]float32, len(s.Sounds)+len(other.Sounds))
+	for name, gain := range s.Sounds {
+		sounds[name] = lerp(gain, other.Sounds[name])
+	}
+	for name, gain := range other.Sounds {
+		if _, ok := s.Sounds[name]; !ok {
+			sounds[name] = lerp(0, gain)
+		}
+	}
+	s.Sounds = sounds
+	return s
+}
+
+// Keyframe is the state of the environment at an hour of the day, from 0 to 24.
+type Keyframe struct {
+	Hour  float32
+	State State
+}
+
+// Cycle is a sequence of keyframes over a day which wraps around at midnight.
+// The state between keyframes is interpolated linearly.
+type Cycle struct {
+	keyframes []Keyframe
+}
+
+// NewCycle creates and returns a pointer to a new cycle with the specified keyframes.
+func NewCycle(keyframes ...Keyframe) *Cycle {
+
+	c := new(Cycle)
+	for _, k := range keyframes {
+		c.Add(k)
+	}
+	return c
+}
+
+// DefaultCycle returns a new cycle with the keyframes of a clear day:
+// night at midnight, sunrise at 6, noon at 12 and sunset at 18.
+func DefaultCycle() *Cycle {
+
+	return NewCycle(
+		Keyframe{Hour: 0, State: State{
+			SunColor:         math32.Color{R: 0.3, G: 0.35, B: 0.5},
+			AmbientColor:     math32.Color{R: 0.3, G: 0.35, B: 0.6},
+			AmbientIntensity: 0.3,
+			SkyZenith:        math32.Color{R: 0.01, G: 0.01, B: 0.04},
+			SkyHorizon:       math32.Color{R: 0.04, G: 0.05, B: 0.1},
+			FogColor:         math32.Color{R: 0.04, G: 0.05, B: 0.1},
+			FogDensity:       0.02,
+		}},
+		Keyframe{Hour: 6, State: State{
+			SunColor:         math32.Color{R: 1, G: 0.6, B: 0.35},
+			SunIntensity:     0.5,
+			AmbientColor:     math32.Color{R: 0.8, G: 0.7, B: 0.75},
+			AmbientIntensity: 0.4,
+			SkyZenith:        math32.Color{R: 0.3, G: 0.4, B: 0.7},
+			SkyHorizon:       math32.Color{R: 1, G: 0.6, B: 0.4},
+			FogColor:         math32.Color{R: 0.8, G: 0.6, B: 0.5},
+			FogDensity:       0.015,
+		}},
+		Keyframe{Hour: 12, State: State{
+			SunColor:         math32.Color{R: 1, G: 0.98, B: 0.92},
+			SunIntensity:     1,
+			AmbientColor:     math32.Color{R: 0.7, G: 0.8, B: 1},
+			AmbientIntensity: 0.5,
+			SkyZenith:        math32.Color{R: 0.2, G: 0.45, B: 0.9},
+			SkyHorizon:       math32.Color{R: 0.7, G: 0.85, B: 1},
+			FogColor:         math32.Color{R: 0.75, G: 0.85, B: 1},
+			FogDensity:       0.005,
+		}},
+		Keyframe{Hour: 18, State: State{
+			SunColor:         math32.Color{R: 1, G: 0.5, B: 0.25},
+			SunIntensity:     0.5,
+			AmbientColor:     math32.Color{R: 0.8, G: 0.6, B: 0.6},
+			AmbientIntensity: 0.4,
+			SkyZenith:        math32.Color{R: 0.25, G: 0.3, B: 0.6},
+			SkyHorizon:       math32.Color{R: 1, G: 0.45, B: 0.25},
+			FogColor:         math32.Color{R: 0.8, G: 0.5, B: 0.4},
+			FogDensity:       0.015,
+		}},
+	)
+}
+
+// Add adds a keyframe to the cycle, replacing any keyframe at the same hour.
+// The hour is wrapped to the range from 0 to 24.
+func (c *Cycle) Add(k Keyframe) {
+
+	k.Hour = wrapHour(k.Hour)
+	i := sort.Search(len(c.keyframes), func(i int) bool { return c.keyframes[i].Hour >= k.Hour })
+	if i < len(c.keyframes) && c.keyframes[i].Hour == k.Hour {
+		c.keyframes[i] = k
+		return
+	}
+	c.keyframes = append(c.keyframes, Keyframe{})
+	copy(c.keyframes[i+1:], c.keyframes[i:])
+	c.keyframes[i] = k
+}
+
+// Remove removes the keyframe at the specified hour and returns whether it was found.
+func (c *Cycle) Remove(hour float32) bool {
+
+	hour = wrapHour(hour)
+	for i := range c.keyframes {
+		if c.keyframes[i].Hour == hour {
+			c.keyframes = append(c.keyframes[:i], c.keyframes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Keyframes returns the keyframes of the cycle sorted by hour.
+func (c *Cycle) Keyframes() []Keyframe {
+
+	return c.keyframes
+}
+
+// Sample returns the state at the specified hour, interpolated between the keyframes
+// before and after it, wrapping around midnight. A cycle without keyframes returns the zero state.
+func (c *Cycle) Sample(hour float32) State {
+
+	n := len(c.keyframes)
+	if n == 0 {
+		return State{}
+	}
+	hour = wrapHour(hour)
+	next := sort.Search(n, func(i int) bool { return c.keyframes[i].Hour > hour })
+	prev := next - 1
+	if prev < 0 {
+		prev = n - 1
+	}
+	if next == n {
+		next = 0
+	}
+	k0 := &c.keyframes[prev]
+	k1 := &c.keyframes[next]
+
+	// Hours from the previous keyframe, across midnight if needed
+	span := k1.Hour - k0.Hour
+	if span <= 0 {
+		span += 24
+	}
+	elapsed := hour - k0.Hour
+	if elapsed < 0 {
+		elapsed += 24
+	}
+	s := k0.State
+	return *s.Lerp(&k1.State, elapsed/span)
+}
+
+// wrapHour returns the specified hour wrapped to the range from 0 to 24.
+func wrapHour(hour float32) float32 {
+
+	hour = math32.Mod(hour, 24)
+	if hour < 0 {
+		hour += 24
+	}
+	return hour
+}
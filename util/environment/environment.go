@@ -0,0 +1,302 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package environment coordinates the sun, sky, fog, ambient light and ambient
+// sounds of a scene over a time of day cycle driven by keyframes.
+package environment
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/light"
+	"github.com/g3n/engine/math32"
+)
+
+// OnUpdate is dispatched by an Environment with itself as the event after each update.
+// The engine has no sky or fog of its own, so handlers apply the sky colors and the fog
+// of the State to their sky and fog implementations, such as the clear color.
+const OnUpdate = "environment.OnUpdate"
+
+// Sound is an ambient sound whose gain is set by an Environment, such as an audio.Player.
+type Sound interface {
+	SetGain(gain float32)
+}
+
+// Override is a cycle blended over the base cycle of an Environment with a weight from 0 to 1,
+// for weather or scripted events such as a storm which darkens the afternoon. Only the state
+// is overridden; the sun keeps following the time of day.
+type Override struct {
+	Cycle  *Cycle  // Keyframes of the override
+	weight float32 // Current weight
+	target float32 // Weight being faded to
+	rate   float32 // Weight change per second while fading
+}
+
+// NewOverride creates and returns a pointer to a new override with the specified cycle and weight 0.
+func NewOverride(cycle *Cycle) *Override {
+
+	return &Override{Cycle: cycle}
+}
+
+// SetWeight sets the weight of the override immediately, stopping any fade.
+func (o *Override) SetWeight(weight float32) {
+
+	o.weight = math32.Clamp(weight, 0, 1)
+	o.target = o.weight
+}
+
+// Weight returns the current weight of the override.
+func (o *Override) Weight() float32 {
+
+	return o.weight
+}
+
+// FadeTo changes the weight of the override linearly to the specified weight over the
+// specified number of seconds of real time.
+func (o *Override) FadeTo(weight, seconds float32) {
+
+	o.target = math32.Clamp(weight, 0, 1)
+	if seconds <= 0 {
+		o.weight = o.target
+		return
+	}
+	o.rate = math32.Abs(o.target-o.weight) / seconds
+}
+
+// update advances the fade of the override by the specified number of seconds.
+func (o *Override) update(delta float32) {
+
+	if o.weight < o.target {
+		o.weight = math32.Min(o.weight+o.rate*delta, o.target)
+	} else if o.weight > o.target {
+		o.weight = math32.Max(o.weight-o.rate*delta, o.target)
+	}
+}
+
+// Environment advances the time of day and applies the state sampled from its cycle,
+// blended with its overrides, to a directional sun light, an ambient light and ambient
+// sounds, which are all optional. The sun light is positioned from the time of day:
+// it rises in the +X direction at 6, is highest at noon and sets in the -X direction at 18,
+// tilted towards -Z by the sun tilt angle, and its light fades out below the horizon.
+type Environment struct {
+	core.Dispatcher                    // Embedded event dispatcher
+	cycle           *Cycle             // Base cycle
+	overrides       []*Override        // Overrides blended in order
+	hour            float32            // Current time of day
+	dayLength       float32            // Seconds of real time per day
+	paused          bool               // Whether the time of day is stopped
+	sunTilt         float32            // Angle of the sun path from the zenith in radians
+	sun             *light.Directional // Sun light, if any
+	ambient         *light.Ambient     // Ambient light, if any
+	sounds          map[string]Sound   // Ambient sounds by name
+	wind            *Wind              // Wind shared by everything moved by it
+	state           State              // Last applied state
+	sunDir          math32.Vector3     // Last direction towards the sun
+}
+
+// NewEnvironment creates and returns a pointer to a new environment with the specified
+// base cycle, or the default cycle if nil, at noon, with a day lasting 20 minutes.
+func NewEnvironment(cycle *Cycle) *Environment {
+
+	e := new(Environment)
+	e.Dispatcher.Initialize()
+	if cycle == nil {
+		cycle = DefaultCycle()
+	}
+	e.cycle = cycle
+	e.hour = 12
+	e.dayLength = 20 * 60
+	e.sunTilt = math32.DegToRad(30)
+	e.sounds = make(map[string]Sound)
+	e.wind = NewWind(&math32.Vector3{X: 1}, 0)
+	e.apply()
+	return e
+}
+
+// SetCycle sets the base cycle of the environment.
+func (e *Environment) SetCycle(cycle *Cycle) {
+
+	e.cycle = cycle
+	e.apply()
+}
+
+// Cycle returns the base cycle of the environment.
+func (e *Environment) Cycle() *Cycle {
+
+	return e.cycle
+}
+
+// AddOverride adds an override, which is blended over the base cycle and previously added overrides.
+func (e *Environment) AddOverride(o *Override) {
+
+	e.overrides = append(e.overrides, o)
+}
+
+// RemoveOverride removes the specified override and returns whether it was found.
+func (e *Environment) RemoveOverride(o *Override) bool {
+
+	for i := range e.overrides {
+		if e.overrides[i] == o {
+			e.overrides = append(e.overrides[:i], e.overrides[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetTime sets the time of day in hours, from 0 to 24, and applies its state.
+func (e *Environment) SetTime(hour float32) {
+
+	e.hour = wrapHour(hour)
+	e.apply()
+}
+
+// Time returns the time of day in hours, from 0 to 24.
+func (e *Environment) Time() float32 {
+
+	return e.hour
+}
+
+// SetDayLength sets the duration of a full day in seconds of real time.
+func (e *Environment) SetDayLength(seconds float32) {
+
+	e.dayLength = seconds
+}
+
+// DayLength returns the duration of a full day in seconds of real time.
+func (e *Environment) DayLength() float32 {
+
+	return e.dayLength
+}
+
+// SetPaused sets whether the time of day is stopped. Overrides keep fading while paused.
+func (e *Environment) SetPaused(state bool) {
+
+	e.paused = state
+}
+
+// Paused returns whether the time of day is stopped.
+func (e *Environment) Paused() bool {
+
+	return e.paused
+}
+
+// SetSunTilt sets the angle in degrees between the path of the sun and the zenith,
+// which is about the latitude of the place (default = 30).
+func (e *Environment) SetSunTilt(degrees float32) {
+
+	e.sunTilt = math32.DegToRad(degrees)
+	e.apply()
+}
+
+// SunTilt returns the angle in degrees between the path of the sun and the zenith.
+func (e *Environment) SunTilt() float32 {
+
+	return math32.RadToDeg(e.sunTilt)
+}
+
+// SetSun sets the directional light which is positioned and colored as the sun. It may be nil.
+func (e *Environment) SetSun(sun *light.Directional) {
+
+	e.sun = sun
+	e.apply()
+}
+
+// Sun returns the sun light.
+func (e *Environment) Sun() *light.Directional {
+
+	return e.sun
+}
+
+// SetAmbient sets the ambient light whose color and intensity are set by the environment. It may be nil.
+func (e *Environment) SetAmbient(ambient *light.Ambient) {
+
+	e.ambient = ambient
+	e.apply()
+}
+
+// Ambient returns the ambient light.
+func (e *Environment) Ambient() *light.Ambient {
+
+	return e.ambient
+}
+
+// AddSound adds an ambient sound whose gain is set from the gain with the
+// same name in the state, so it is silent when the state does not have it.
+func (e *Environment) AddSound(name string, sound Sound) {
+
+	e.sounds[name] = sound
+	sound.SetGain(e.state.Sounds[name])
+}
+
+// RemoveSound removes the ambient sound with the specified name.
+func (e *Environment) RemoveSound(name string) {
+
+	delete(e.sounds, name)
+}
+
+// Wind returns the wind of the environment, which is still by default
+// and is advanced by Update even while the time of day is paused.
+func (e *Environment) Wind() *Wind {
+
+	return e.wind
+}
+
+// State returns the last applied state.
+func (e *Environment) State() State {
+
+	return e.state
+}
+
+// SunDirection returns the unit vector pointing towards the sun, which is below the horizon at night.
+func (e *Environment) SunDirection() math32.Vector3 {
+
+	return e.sunDir
+}
+
+// Update should be called in the render loop with the time in seconds since the last
+// call to advance the time of day, the overrides and the wind and apply the resulting state.
+func (e *Environment) Update(delta float32) {
+
+	if !e.paused && e.dayLength > 0 {
+		e.hour = wrapHour(e.hour + 24*delta/e.dayLength)
+	}
+	for _, o := range e.overrides {
+		o.update(delta)
+	}
+	e.wind.Update(delta)
+	e.apply()
+}
+
+// apply computes the state at the current time and applies it to the lights and sounds.
+func (e *Environment) apply() {
+
+	e.state = e.cycle.Sample(e.hour)
+	for _, o := range e.overrides {
+		if o.weight > 0 {
+			s := o.Cycle.Sample(e.hour)
+			e.state.Lerp(&s, o.weight)
+		}
+	}
+
+	// The sun turns around the Z axis, rising at 6 in +X, and its path is tilted towards -Z
+	angle := (e.hour - 6) / 24 * 2 * math32.Pi
+	x := math32.Cos(angle)
+	y := math32.Sin(angle)
+	e.sunDir = math32.Vector3{X: x, Y: y * math32.Cos(e.sunTilt), Z: -y * math32.Sin(e.sunTilt)}
+
+	if e.sun != nil {
+		// The sun light fades out as the sun goes below the horizon
+		e.sun.SetPositionVec(&e.sunDir)
+		e.sun.SetColor(&e.state.SunColor)
+		e.sun.SetIntensity(e.state.SunIntensity * math32.Clamp((e.sunDir.Y+0.02)/0.1, 0, 1))
+	}
+	if e.ambient != nil {
+		e.ambient.SetColor(&e.state.AmbientColor)
+		e.ambient.SetIntensity(e.state.AmbientIntensity)
+	}
+	for name, sound := range e.sounds {
+		sound.SetGain(e.state.Sounds[name])
+	}
+	e.Dispatch(OnUpdate, e)
+}
@@ -0,0 +1,159 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package environment
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// Wind is a wind with a direction, a mean strength and gusts which travel in its direction.
+// The same wind velocity is computed on the CPU by VelocityAt and in shaders by the
+// windVelocity function of the "wind" shader chunk, whose uniforms are transferred by
+// RenderSetup, so everything moved by the wind sways coherently. Wind also satisfies
+// the physics.ForceField interface, pushing bodies with a force proportional to its velocity.
+type Wind struct {
+	uni       gls.Uniform    // Uniform location cache
+	udata     [8]float32     // Direction, strength, gustiness, gust frequency, gust scale and time
+	drag      float32        // Force per unit of wind velocity
+	direction math32.Vector3 // Unit direction
+}
+
+// NewWind creates and returns a pointer to a new wind blowing in the specified direction
+// with the specified mean strength, in units per second.
+func NewWind(direction *math32.Vector3, strength float32) *Wind {
+
+	w := new(Wind)
+	w.uni.Init("Wind")
+	w.SetDirection(direction)
+	w.SetStrength(strength)
+	w.SetGustiness(0.5)
+	w.SetGustFrequency(0.2)
+	w.SetGustWavelength(20)
+	w.drag = 1
+	return w
+}
+
+// SetDirection sets the direction the wind blows to, which is normalized.
+func (w *Wind) SetDirection(direction *math32.Vector3) {
+
+	w.direction = *direction
+	w.direction.Normalize()
+	w.udata[0] = w.direction.X
+	w.udata[1] = w.direction.Y
+	w.udata[2] = w.direction.Z
+}
+
+// Direction returns the unit direction the wind blows to.
+func (w *Wind) Direction() math32.Vector3 {
+
+	return w.direction
+}
+
+// SetStrength sets the mean speed of the wind in units per second.
+func (w *Wind) SetStrength(strength float32) {
+
+	w.udata[3] = strength
+}
+
+// Strength returns the mean speed of the wind in units per second.
+func (w *Wind) Strength() float32 {
+
+	return w.udata[3]
+}
+
+// SetGustiness sets how much the gusts change the wind speed, as a fraction of
+// its strength from 0 (steady wind) to 1 (speeds from 0 to twice the strength).
+func (w *Wind) SetGustiness(gustiness float32) {
+
+	w.udata[4] = math32.Clamp(gustiness, 0, 1)
+}
+
+// Gustiness returns how much the gusts change the wind speed.
+func (w *Wind) Gustiness() float32 {
+
+	return w.udata[4]
+}
+
+// SetGustFrequency sets the number of gusts passing by a point per second.
+func (w *Wind) SetGustFrequency(frequency float32) {
+
+	w.udata[5] = frequency
+}
+
+// GustFrequency returns the number of gusts passing by a point per second.
+func (w *Wind) GustFrequency() float32 {
+
+	return w.udata[5]
+}
+
+// SetGustWavelength sets the distance between consecutive gusts along the wind direction.
+func (w *Wind) SetGustWavelength(wavelength float32) {
+
+	w.udata[6] = 2 * math32.Pi / wavelength
+}
+
+// GustWavelength returns the distance between consecutive gusts along the wind direction.
+func (w *Wind) GustWavelength() float32 {
+
+	return 2 * math32.Pi / w.udata[6]
+}
+
+// SetDrag sets the force applied to physics bodies per unit of wind velocity (default = 1).
+func (w *Wind) SetDrag(drag float32) {
+
+	w.drag = drag
+}
+
+// Drag returns the force applied to physics bodies per unit of wind velocity.
+func (w *Wind) Drag() float32 {
+
+	return w.drag
+}
+
+// SetTime sets the time of the wind in seconds.
+func (w *Wind) SetTime(t float32) {
+
+	w.udata[7] = t
+}
+
+// Time returns the time of the wind in seconds.
+func (w *Wind) Time() float32 {
+
+	return w.udata[7]
+}
+
+// Update advances the time of the wind by the specified number of seconds.
+func (w *Wind) Update(delta float32) {
+
+	w.udata[7] += delta
+}
+
+// VelocityAt returns the wind velocity at the specified world position.
+func (w *Wind) VelocityAt(pos *math32.Vector3) math32.Vector3 {
+
+	phase := pos.Dot(&w.direction) * w.udata[6]
+	t := w.udata[7] * w.udata[5] * 2 * math32.Pi
+	gust := 0.5 + 0.25*math32.Sin(t-phase) + 0.25*math32.Sin(2.3*t-1.7*phase+1.3)
+	v := w.direction
+	v.MultiplyScalar(w.udata[3] * (1 + w.udata[4]*(2*gust-1)))
+	return v
+}
+
+// ForceAt satisfies the physics.ForceField interface and returns the force
+// of the wind at the specified position, its velocity times the drag.
+func (w *Wind) ForceAt(pos *math32.Vector3) math32.Vector3 {
+
+	v := w.VelocityAt(pos)
+	v.MultiplyScalar(w.drag)
+	return v
+}
+
+// RenderSetup transfers the uniforms of the "wind" shader chunk. It should be
+// called by the RenderSetup of the graphics whose shaders use the wind.
+func (w *Wind) RenderSetup(gs *gls.GLS) {
+
+	gs.Uniform4fv(w.uni.Location(gs), 2, &w.udata[0])
+}
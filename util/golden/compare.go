@@ -0,0 +1,112 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golden
+
+import (
+	"image"
+	"image/color"
+)
+
+// Maximum possible squared YIQ distance between two colors.
+const maxYIQDelta = 35215.0
+
+// Tolerance specifies how different two images may be and still be considered equal.
+type Tolerance struct {
+	Threshold float32 // Perceptual color difference above which a pixel differs (0 to 1)
+	MaxRatio  float32 // Maximum ratio of differing pixels (0 to 1)
+}
+
+// DefaultTolerance tolerates small color differences caused by
+// driver rounding but no appreciable number of differing pixels.
+var DefaultTolerance = Tolerance{Threshold: 0.1, MaxRatio: 0.001}
+
+// Result describes the outcome of comparing two images.
+type Result struct {
+	Match     bool        // Whether the images are considered equal
+	Total     int         // Total number of pixels compared
+	Different int         // Number of pixels above the color threshold
+	MaxDelta  float32     // Largest perceptual difference found (0 to 1)
+	Diff      *image.RGBA // Diff image; differing pixels are red over a faded copy of the golden image
+}
+
+// Ratio returns the ratio of differing pixels.
+func (r *Result) Ratio() float32 {
+
+	if r.Total == 0 {
+		return 0
+	}
+	return float32(r.Different) / float32(r.Total)
+}
+
+// Compare compares the actual image against the golden image using a perceptual
+// color metric in YIQ space and returns the result.
+// Images with different sizes never match.
+func Compare(golden, actual image.Image, tol Tolerance) Result {
+
+	var res Result
+	gb := golden.Bounds()
+	ab := actual.Bounds()
+	if gb.Dx() != ab.Dx() || gb.Dy() != ab.Dy() {
+		res.Total = ab.Dx() * ab.Dy()
+		res.Different = res.Total
+		res.MaxDelta = 1
+		return res
+	}
+
+	res.Total = gb.Dx() * gb.Dy()
+	res.Diff = image.NewRGBA(image.Rect(0, 0, gb.Dx(), gb.Dy()))
+	for y := 0; y < gb.Dy(); y++ {
+		for x := 0; x < gb.Dx(); x++ {
+			c1 := color.RGBAModel.Convert(golden.At(gb.Min.X+x, gb.Min.Y+y)).(color.RGBA)
+			c2 := color.RGBAModel.Convert(actual.At(ab.Min.X+x, ab.Min.Y+y)).(color.RGBA)
+			delta := colorDelta(c1, c2)
+			if delta > res.MaxDelta {
+				res.MaxDelta = delta
+			}
+			if delta > tol.Threshold {
+				res.Different++
+				res.Diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				continue
+			}
+			// Faded gray copy of the golden pixel
+			l := uint8(192 + int(luma(c1))/4)
+			res.Diff.SetRGBA(x, y, color.RGBA{l, l, l, 255})
+		}
+	}
+	res.Match = res.Ratio() <= tol.MaxRatio
+	return res
+}
+
+// colorDelta returns the normalized perceptual difference between two colors.
+// Colors are blended over white before comparison so transparency is taken into account.
+func colorDelta(c1, c2 color.RGBA) float32 {
+
+	r1, g1, b1 := blendWhite(c1)
+	r2, g2, b2 := blendWhite(c2)
+	y := rgb2y(r1, g1, b1) - rgb2y(r2, g2, b2)
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+	d := 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+	return float32(d / maxYIQDelta)
+}
+
+func blendWhite(c color.RGBA) (r, g, b float64) {
+
+	a := float64(c.A) / 255
+	r = 255 + (float64(c.R)-255)*a
+	g = 255 + (float64(c.G)-255)*a
+	b = 255 + (float64(c.B)-255)*a
+	return
+}
+
+func luma(c color.RGBA) float64 {
+
+	r, g, b := blendWhite(c)
+	return rgb2y(r, g, b)
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
@@ -0,0 +1,59 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golden
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func fill(w, h int, c color.RGBA) *image.RGBA {
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+
+	gray := color.RGBA{128, 128, 128, 255}
+	a := fill(8, 8, gray)
+
+	// Identical images
+	res := Compare(a, fill(8, 8, gray), DefaultTolerance)
+	if !res.Match || res.Different != 0 || res.MaxDelta != 0 {
+		t.Errorf("identical images: %+v", res)
+	}
+
+	// Slight color difference within threshold
+	res = Compare(a, fill(8, 8, color.RGBA{130, 128, 127, 255}), DefaultTolerance)
+	if !res.Match || res.Different != 0 {
+		t.Errorf("near identical images: %+v", res)
+	}
+
+	// One very different pixel out of 64
+	b := fill(8, 8, gray)
+	b.SetRGBA(3, 4, color.RGBA{255, 0, 0, 255})
+	res = Compare(a, b, DefaultTolerance)
+	if res.Match || res.Different != 1 {
+		t.Errorf("one differing pixel: %+v", res)
+	}
+	if res.Diff.RGBAAt(3, 4) != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("diff pixel not marked: %v", res.Diff.RGBAAt(3, 4))
+	}
+	res = Compare(a, b, Tolerance{Threshold: 0.1, MaxRatio: 0.05})
+	if !res.Match {
+		t.Errorf("one differing pixel within ratio: %+v", res)
+	}
+
+	// Different sizes
+	res = Compare(a, fill(4, 4, gray), DefaultTolerance)
+	if res.Match {
+		t.Errorf("different sizes should not match")
+	}
+}
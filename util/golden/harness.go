@@ -0,0 +1,191 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package golden implements a golden image testing harness.
+// Scenes are rendered to an offscreen target, captured and compared against
+// stored reference images using a perceptual tolerance. When an image does not
+// match, the actual image and a diff image are written next to the golden one.
+//
+// An OpenGL context is still required; it is usually obtained by creating a
+// hidden window before running the checks.
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/renderer"
+	"github.com/g3n/engine/util/logger"
+)
+
+// Package logger
+var log = logger.New("GOLDEN", logger.Default)
+
+// UpdateEnv is the name of the environment variable which, when set to a
+// non-empty value, makes the harness overwrite golden images instead of comparing.
+const UpdateEnv = "G3N_GOLDEN_UPDATE"
+
+// Reporter is the subset of testing.TB used by the harness to report failures.
+type Reporter interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Case describes a scene to be rendered and checked.
+type Case struct {
+	Name   string                              // Name of the golden image (without extension)
+	Width  int                                 // Width of the rendered image
+	Height int                                 // Height of the rendered image
+	Clear  math32.Color4                       // Clear color
+	Scene  func() (core.INode, camera.ICamera) // Builds the scene and the camera to render it with
+}
+
+// Harness renders cases and checks them against golden images stored in a directory.
+type Harness struct {
+	Dir       string             // Directory containing the golden images
+	Tolerance Tolerance          // Comparison tolerance
+	Update    bool               // Overwrite golden images with the rendered ones
+	gs        *gls.GLS           // Reference to OpenGL state
+	rend      *renderer.Renderer // Renderer used for all cases
+}
+
+// NewHarness creates and returns a pointer to a new Harness which stores
+// its golden images in the specified directory.
+func NewHarness(gs *gls.GLS, dir string) *Harness {
+
+	h := new(Harness)
+	h.Dir = dir
+	h.Tolerance = DefaultTolerance
+	h.Update = os.Getenv(UpdateEnv) != ""
+	h.gs = gs
+	h.rend = renderer.NewRenderer(gs)
+	if err := h.rend.AddDefaultShaders(); err != nil {
+		log.Error("AddDefaultShaders:%v", err)
+	}
+	return h
+}
+
+// Render renders the specified case offscreen and returns the captured image.
+func (h *Harness) Render(c *Case) (*image.RGBA, error) {
+
+	target, err := NewTarget(h.gs, c.Width, c.Height)
+	if err != nil {
+		return nil, err
+	}
+	defer target.Dispose()
+
+	scene, cam := c.Scene()
+	target.Bind()
+	defer target.Unbind()
+	h.gs.ClearColor(c.Clear.R, c.Clear.G, c.Clear.B, c.Clear.A)
+	h.gs.Clear(gls.COLOR_BUFFER_BIT | gls.DEPTH_BUFFER_BIT | gls.STENCIL_BUFFER_BIT)
+	if err := h.rend.Render(scene, cam); err != nil {
+		return nil, err
+	}
+	return target.Capture(), nil
+}
+
+// Run renders the specified case and checks the result against its golden image.
+func (h *Harness) Run(r Reporter, c *Case) {
+
+	r.Helper()
+	img, err := h.Render(c)
+	if err != nil {
+		r.Errorf("golden %q: render: %v", c.Name, err)
+		return
+	}
+	h.Check(r, c.Name, img)
+}
+
+// Check compares the specified image against the golden image with the specified name.
+// If the harness is in update mode the image is written as the new golden image.
+// Otherwise a missing golden image is reported as a failure, with the image written
+// as the actual one, and on mismatch the actual and diff images are written next to
+// the golden image and the failure is reported.
+func (h *Harness) Check(r Reporter, name string, img image.Image) Result {
+
+	r.Helper()
+	path := h.path(name, "")
+	if h.Update {
+		if err := writePNG(path, img); err != nil {
+			r.Errorf("golden %q: %v", name, err)
+		}
+		return Result{Match: true}
+	}
+
+	golden, err := readPNG(path)
+	if os.IsNotExist(err) {
+		if err := writePNG(h.path(name, ".actual"), img); err != nil {
+			log.Error("writing actual image:%v", err)
+		}
+		r.Errorf("golden %q: image %q not found (set %s to create it)", name, path, UpdateEnv)
+		return Result{}
+	}
+	if err != nil {
+		r.Errorf("golden %q: %v", name, err)
+		return Result{}
+	}
+
+	res := Compare(golden, img, h.Tolerance)
+	if res.Match {
+		return res
+	}
+	if err := writePNG(h.path(name, ".actual"), img); err != nil {
+		log.Error("writing actual image:%v", err)
+	}
+	if res.Diff != nil {
+		if err := writePNG(h.path(name, ".diff"), res.Diff); err != nil {
+			log.Error("writing diff image:%v", err)
+		}
+	}
+	r.Errorf("golden %q: %d of %d pixels differ (%.3f%%, max delta %.3f)",
+		name, res.Different, res.Total, res.Ratio()*100, res.MaxDelta)
+	return res
+}
+
+// path returns the path of the image with the specified name and suffix.
+func (h *Harness) path(name, suffix string) string {
+
+	return filepath.Join(h.Dir, name+suffix+".png")
+}
+
+// readPNG reads and decodes the PNG image at the specified path.
+func readPNG(path string) (image.Image, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", path, err)
+	}
+	return img, nil
+}
+
+// writePNG encodes the specified image as PNG to the specified path,
+// creating the parent directory if necessary.
+func writePNG(path string, img image.Image) error {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golden
+
+import (
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// reporter records the failures reported by the harness.
+type reporter struct {
+	errors []string
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Errorf(format string, args ...interface{}) {
+
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCheckMissingGolden(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	h := &Harness{Dir: dir, Tolerance: DefaultTolerance}
+	img := fill(8, 8, color.RGBA{128, 128, 128, 255})
+
+	// Missing golden image fails and is not created
+	r := new(reporter)
+	if res := h.Check(r, "missing", img); res.Match || len(r.errors) != 1 {
+		t.Fatalf("missing golden image: %+v %v", res, r.errors)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.png")); !os.IsNotExist(err) {
+		t.Fatalf("missing golden image was created")
+	}
+
+	// Update mode creates it, and it then matches
+	h.Update = true
+	r = new(reporter)
+	if res := h.Check(r, "missing", img); !res.Match || len(r.errors) != 0 {
+		t.Fatalf("update mode: %+v %v", res, r.errors)
+	}
+	h.Update = false
+	if res := h.Check(r, "missing", img); !res.Match || len(r.errors) != 0 {
+		t.Fatalf("created golden image: %+v %v", res, r.errors)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golden
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/g3n/engine/gls"
+)
+
+// Target is an offscreen render target composed of a framebuffer object
+// with a color renderbuffer and a depth renderbuffer.
+// Scenes rendered while the target is bound do not appear in any window,
+// so it can be used with a hidden window or any other headless context.
+type Target struct {
+	gs     *gls.GLS // Reference to OpenGL state
+	width  int      // Width in pixels
+	height int      // Height in pixels
+	fbo    uint32   // Framebuffer object
+	color  uint32   // Color renderbuffer
+	depth  uint32   // Depth/stencil renderbuffer
+	vx     int32    // Saved viewport x
+	vy     int32    // Saved viewport y
+	vw     int32    // Saved viewport width
+	vh     int32    // Saved viewport height
+}
+
+// NewTarget creates and returns a pointer to a new offscreen Target with the specified size.
+func NewTarget(gs *gls.GLS, width, height int) (*Target, error) {
+
+	t := new(Target)
+	t.gs = gs
+	t.width = width
+	t.height = height
+
+	t.fbo = gs.GenFramebuffer()
+	gs.BindFramebuffer(gls.FRAMEBUFFER, t.fbo)
+
+	t.color = gs.GenRenderbuffer()
+	gs.BindRenderbuffer(gls.RENDERBUFFER, t.color)
+	gs.RenderbufferStorage(gls.RENDERBUFFER, gls.RGBA8, int32(width), int32(height))
+	gs.FramebufferRenderbuffer(gls.FRAMEBUFFER, gls.COLOR_ATTACHMENT0, gls.RENDERBUFFER, t.color)
+
+	t.depth = gs.GenRenderbuffer()
+	gs.BindRenderbuffer(gls.RENDERBUFFER, t.depth)
+	gs.RenderbufferStorage(gls.RENDERBUFFER, gls.DEPTH24_STENCIL8, int32(width), int32(height))
+	gs.FramebufferRenderbuffer(gls.FRAMEBUFFER, gls.DEPTH_STENCIL_ATTACHMENT, gls.RENDERBUFFER, t.depth)
+
+	status := gs.CheckFramebufferStatus(gls.FRAMEBUFFER)
+	gs.BindRenderbuffer(gls.RENDERBUFFER, 0)
+	gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	if status != gls.FRAMEBUFFER_COMPLETE {
+		t.Dispose()
+		return nil, fmt.Errorf("incomplete framebuffer: status 0x%X", status)
+	}
+	return t, nil
+}
+
+// Size returns the width and height of the target in pixels.
+func (t *Target) Size() (width, height int) {
+
+	return t.width, t.height
+}
+
+// Bind makes the target the current framebuffer and sets the viewport to cover it.
+// The previous viewport is saved and restored by Unbind.
+func (t *Target) Bind() {
+
+	t.vx, t.vy, t.vw, t.vh = t.gs.GetViewport()
+	t.gs.BindFramebuffer(gls.FRAMEBUFFER, t.fbo)
+	t.gs.Viewport(0, 0, int32(t.width), int32(t.height))
+}
+
+// Unbind restores the default framebuffer and the previous viewport.
+func (t *Target) Unbind() {
+
+	t.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	t.gs.Viewport(t.vx, t.vy, t.vw, t.vh)
+}
+
+// Capture reads the contents of the target and returns it as an image.
+// The target must be bound. Rows are flipped so the image origin is at the top left.
+func (t *Target) Capture() *image.RGBA {
+
+	data := t.gs.ReadPixels(0, 0, t.width, t.height, gls.RGBA, gls.UNSIGNED_BYTE)
+	img := image.NewRGBA(image.Rect(0, 0, t.width, t.height))
+	stride := t.width * 4
+	for y := 0; y < t.height; y++ {
+		src := data[(t.height-1-y)*stride : (t.height-y)*stride]
+		copy(img.Pix[y*img.Stride:], src)
+	}
+	return img
+}
+
+// Dispose releases the OpenGL resources used by the target.
+func (t *Target) Dispose() {
+
+	t.gs.DeleteRenderbuffers(t.color, t.depth)
+	t.gs.DeleteFramebuffers(t.fbo)
+}
@@ -5,6 +5,7 @@
 package util
 
 import (
+	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/geometry"
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/graphic"
@@ -18,20 +19,26 @@ type GridHelper struct {
 }
 
 // NewGridHelper creates and returns a pointer to a new grid help object
-// with the specified size and step.
+// with the specified size and step, on the horizontal plane of the engine coordinate system.
 func NewGridHelper(size, step float32, color *math32.Color) *GridHelper {
 
 	grid := new(GridHelper)
 
 	half := size / 2
 	positions := math32.NewArrayF32(0, 0)
+	zUp := core.EngineCoords().Up == core.ZUp
+	vertex := func(x, z float32) {
+		if zUp {
+			positions.Append(x, -z, 0, color.R, color.G, color.B)
+		} else {
+			positions.Append(x, 0, z, color.R, color.G, color.B)
+		}
+	}
 	for i := -half; i <= half; i += step {
-		positions.Append(
-			-half, 0, i, color.R, color.G, color.B,
-			half, 0, i, color.R, color.G, color.B,
-			i, 0, -half, color.R, color.G, color.B,
-			i, 0, half, color.R, color.G, color.B,
-		)
+		vertex(-half, i)
+		vertex(half, i)
+		vertex(i, -half)
+		vertex(i, half)
 	}
 
 	// Creates geometry
@@ -0,0 +1,160 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// InfiniteGrid is a reference grid on the local XZ plane drawn by a shader, which follows the
+// camera so it seems to extend to the horizon. Its lines are antialiased and about one pixel
+// wide at any distance, every few cells there is a major line, the X and Z axes are colored,
+// and the grid fades out with the distance from the camera. The camera far plane should be
+// beyond the fade distance.
+type InfiniteGrid struct {
+	graphic.Graphic                 // Embedded graphic
+	params          [4]float32      // Cell size, cells per major line, fade distance and opacity
+	colors          [4]math32.Color // Minor lines, major lines, X axis and Z axis colors
+	uniMVPm         gls.Uniform     // Model view projection matrix uniform location cache
+	uniCamera       gls.Uniform     // Camera position uniform location cache
+	uniParams       gls.Uniform     // Grid parameters uniform location cache
+	uniColors       gls.Uniform     // Grid colors uniform location cache
+}
+
+// NewInfiniteGrid returns a pointer to a new InfiniteGrid with the specified cell size,
+// a major line every 10 cells and a fade distance of 100 cells.
+func NewInfiniteGrid(cellSize float32) *InfiniteGrid {
+
+	g := new(InfiniteGrid)
+	g.params = [4]float32{cellSize, 10, 100 * cellSize, 1}
+	g.colors = [4]math32.Color{
+		{R: 0.4, G: 0.4, B: 0.4},
+		{R: 0.6, G: 0.6, B: 0.6},
+		{R: 0.9, G: 0.25, B: 0.25},
+		{R: 0.25, G: 0.45, B: 0.9},
+	}
+
+	// Unit quad on the XZ plane which the shader scales to the fade distance
+	geom := geometry.NewGeometry()
+	positions := math32.NewArrayF32(0, 12)
+	positions.Append(
+		-1, 0, -1,
+		-1, 0, 1,
+		1, 0, 1,
+		1, 0, -1,
+	)
+	indices := math32.NewArrayU32(0, 6)
+	indices.Append(0, 1, 2, 0, 2, 3)
+	geom.SetIndices(indices)
+	geom.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+
+	// The grid is blended over the scene without hiding what is below it
+	mat := material.NewMaterial()
+	mat.SetShader("grid")
+	mat.SetUseLights(material.UseLightNone)
+	mat.SetSide(material.SideDouble)
+	mat.SetTransparent(true)
+	mat.SetDepthMask(false)
+
+	g.Graphic.Init(g, geom, gls.TRIANGLES)
+	g.AddMaterial(g, mat, 0, 0)
+	g.SetCullable(false)
+	g.uniMVPm.Init("MVP")
+	g.uniCamera.Init("CameraPos")
+	g.uniParams.Init("GridParams")
+	g.uniColors.Init("GridColor")
+	return g
+}
+
+// SetCellSize sets the size of the grid cells.
+func (g *InfiniteGrid) SetCellSize(size float32) {
+
+	g.params[0] = size
+}
+
+// CellSize returns the size of the grid cells.
+func (g *InfiniteGrid) CellSize() float32 {
+
+	return g.params[0]
+}
+
+// SetMajorStep sets the number of cells between major lines.
+func (g *InfiniteGrid) SetMajorStep(cells int) {
+
+	if cells < 1 {
+		cells = 1
+	}
+	g.params[1] = float32(cells)
+}
+
+// MajorStep returns the number of cells between major lines.
+func (g *InfiniteGrid) MajorStep() int {
+
+	return int(g.params[1])
+}
+
+// SetFadeDistance sets the distance from the camera where the grid vanishes.
+// It starts fading out at half this distance.
+func (g *InfiniteGrid) SetFadeDistance(dist float32) {
+
+	g.params[2] = dist
+}
+
+// FadeDistance returns the distance from the camera where the grid vanishes.
+func (g *InfiniteGrid) FadeDistance() float32 {
+
+	return g.params[2]
+}
+
+// SetOpacity sets the opacity of the grid, from 0 to 1.
+func (g *InfiniteGrid) SetOpacity(opacity float32) {
+
+	g.params[3] = math32.Clamp(opacity, 0, 1)
+}
+
+// Opacity returns the opacity of the grid.
+func (g *InfiniteGrid) Opacity() float32 {
+
+	return g.params[3]
+}
+
+// SetColors sets the colors of the minor and major lines.
+func (g *InfiniteGrid) SetColors(minor, major *math32.Color) {
+
+	g.colors[0] = *minor
+	g.colors[1] = *major
+}
+
+// SetAxisColors sets the colors of the lines of the X and Z axes.
+func (g *InfiniteGrid) SetAxisColors(x, z *math32.Color) {
+
+	g.colors[2] = *x
+	g.colors[3] = *z
+}
+
+// RenderSetup is called by the engine before drawing the grid.
+func (g *InfiniteGrid) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	// Camera position in the grid local space, where the quad is centered
+	mw := g.MatrixWorld()
+	var mwInv, camera math32.Matrix4
+	mwInv.GetInverse(&mw)
+	camera.GetInverse(&rinfo.ViewMatrix)
+	campos := math32.Vector3{X: camera[12], Y: camera[13], Z: camera[14]}
+	campos.ApplyMatrix4(&mwInv)
+
+	var mvm, mvpm math32.Matrix4
+	mvm.MultiplyMatrices(&rinfo.ViewMatrix, &mw)
+	mvpm.MultiplyMatrices(&rinfo.ProjMatrix, &mvm)
+	gs.UniformMatrix4fv(g.uniMVPm.Location(gs), 1, false, &mvpm[0])
+	gs.Uniform3f(g.uniCamera.Location(gs), campos.X, campos.Y, campos.Z)
+	gs.Uniform4fv(g.uniParams.Location(gs), 1, &g.params[0])
+	gs.Uniform3fv(g.uniColors.Location(gs), 4, &g.colors[0].R)
+}
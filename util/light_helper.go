@@ -0,0 +1,209 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/light"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// LightHelper is the visual representation of the extent of a light, drawn with lines of the
+// light color: a cone for spot lights, a sphere of the lit range for point lights, and an arrow
+// in the direction of the light for directional lights. Ambient lights are not shown.
+// The helper is in world coordinates, so it should be added to the scene root, and can be
+// shown or hidden for each light with SetVisible.
+type LightHelper struct {
+	graphic.Lines
+	light     light.ILight // Target light
+	size      float32      // Size of the markers and length of unbounded extents
+	threshold float32      // Fraction of the light intensity considered the end of its range
+}
+
+// Number of segments of the circles of the light helpers
+const lightHelperSegments = 32
+
+// NewLightHelper creates and returns a pointer to a new helper for the specified light.
+// The size is used for the markers and for the length of extents without a bound,
+// such as the range of lights with no decay.
+func NewLightHelper(l light.ILight, size float32) *LightHelper {
+
+	lh := new(LightHelper)
+	lh.light = l
+	lh.size = size
+	lh.threshold = 0.05
+
+	geom := geometry.NewGeometry()
+	vbo := gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexPosition)
+	// The positions are usually updated every frame
+	vbo.SetUsage(gls.STREAM_DRAW)
+	geom.AddVBO(vbo)
+	geom.AddVBO(gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexColor))
+
+	lh.Lines.Init(geom, material.NewBasic())
+	lh.Update()
+	return lh
+}
+
+// Light returns the light shown by this helper.
+func (lh *LightHelper) Light() light.ILight {
+
+	return lh.light
+}
+
+// SetThreshold sets the fraction of the light intensity below which a point or spot light
+// is considered out of range (default = 0.05).
+func (lh *LightHelper) SetThreshold(threshold float32) {
+
+	lh.threshold = threshold
+}
+
+// Threshold returns the fraction of the light intensity which defines the range of the light.
+func (lh *LightHelper) Threshold() float32 {
+
+	return lh.threshold
+}
+
+// Update should be called in the render loop to update the helper
+// based on the current transform and parameters of the light.
+func (lh *LightHelper) Update() {
+
+	positions := math32.NewArrayF32(0, 0)
+	var color math32.Color
+	var pos, dir math32.Vector3
+
+	switch l := lh.light.(type) {
+	case *light.Point:
+		color = l.Color()
+		l.WorldPosition(&pos)
+		r := lh.lightRange(&color, l.Intensity(), l.LinearDecay(), l.QuadraticDecay())
+		x := math32.Vector3{X: 1}
+		y := math32.Vector3{Y: 1}
+		z := math32.Vector3{Z: 1}
+		appendCircle(&positions, &pos, &x, &y, r)
+		appendCircle(&positions, &pos, &x, &z, r)
+		appendCircle(&positions, &pos, &y, &z, r)
+		appendCross(&positions, &pos, lh.size/4)
+
+	case *light.Spot:
+		color = l.Color()
+		l.WorldPosition(&pos)
+		l.WorldDirection(&dir)
+		dir.Normalize()
+		length := lh.lightRange(&color, l.Intensity(), l.LinearDecay(), l.QuadraticDecay())
+		angle := math32.Clamp(l.CutoffAngle(), 0, 89)
+		radius := length * math32.Tan(math32.DegToRad(angle))
+		var u, v math32.Vector3
+		perpendicular(&dir, &u, &v)
+		center := dir
+		center.MultiplyScalar(length).Add(&pos)
+		appendCircle(&positions, &center, &u, &v, radius)
+		positions.AppendVector3(&pos, &center)
+		for i := 0; i < 4; i++ {
+			a := float32(i) * math32.Pi / 2
+			edge := center
+			edge.Add(u.Clone().MultiplyScalar(radius * math32.Cos(a)))
+			edge.Add(v.Clone().MultiplyScalar(radius * math32.Sin(a)))
+			positions.AppendVector3(&pos, &edge)
+		}
+
+	case *light.Directional:
+		color = l.Color()
+		l.WorldPosition(&pos)
+		// The light comes from its position towards the origin
+		dir = pos
+		dir.Negate()
+		if dir.Length() == 0 {
+			dir = math32.Vector3{Y: -1}
+		}
+		dir.Normalize()
+		var u, v math32.Vector3
+		perpendicular(&dir, &u, &v)
+		appendCircle(&positions, &pos, &u, &v, lh.size/4)
+		tip := dir
+		tip.MultiplyScalar(lh.size).Add(&pos)
+		positions.AppendVector3(&pos, &tip)
+		back := dir
+		back.MultiplyScalar(-lh.size / 5).Add(&tip)
+		for _, side := range []*math32.Vector3{&u, &v} {
+			for _, sign := range []float32{-1, 1} {
+				barb := back
+				barb.Add(side.Clone().MultiplyScalar(sign * lh.size / 10))
+				positions.AppendVector3(&tip, &barb)
+			}
+		}
+	}
+
+	// Vertex colors
+	colors := math32.NewArrayF32(0, positions.Size())
+	for i := 0; i < positions.Size()/3; i++ {
+		colors.AppendColor(&color)
+	}
+
+	geom := lh.GetGeometry()
+	geom.VBO(gls.VertexPosition).SetBuffer(positions)
+	geom.VBO(gls.VertexColor).SetBuffer(colors)
+}
+
+// lightRange returns the distance where the light with the specified color, intensity and decay
+// factors falls below the threshold, or the helper size if the light has no decay.
+func (lh *LightHelper) lightRange(color *math32.Color, intensity, linear, quadratic float32) float32 {
+
+	// The attenuation is 1 / (1 + linear*d + quadratic*d^2)
+	ratio := math32.Max(color.R, math32.Max(color.G, color.B)) * intensity / lh.threshold
+	if ratio <= 1 {
+		return 0
+	}
+	if quadratic > 0 {
+		return (-linear + math32.Sqrt(linear*linear+4*quadratic*(ratio-1))) / (2 * quadratic)
+	}
+	if linear > 0 {
+		return (ratio - 1) / linear
+	}
+	return lh.size
+}
+
+// perpendicular sets u and v to unit vectors perpendicular to the specified unit direction and to each other.
+func perpendicular(dir, u, v *math32.Vector3) {
+
+	up := math32.Vector3{Y: 1}
+	if math32.Abs(dir.Y) > 0.99 {
+		up = math32.Vector3{X: 1}
+	}
+	u.CrossVectors(&up, dir).Normalize()
+	v.CrossVectors(dir, u)
+}
+
+// appendCircle appends the line segments of a circle with the specified center and radius
+// on the plane of the unit vectors u and v.
+func appendCircle(positions *math32.ArrayF32, center, u, v *math32.Vector3, radius float32) {
+
+	var prev math32.Vector3
+	for i := 0; i <= lightHelperSegments; i++ {
+		a := 2 * math32.Pi * float32(i) / lightHelperSegments
+		p := *center
+		p.Add(u.Clone().MultiplyScalar(radius * math32.Cos(a)))
+		p.Add(v.Clone().MultiplyScalar(radius * math32.Sin(a)))
+		if i > 0 {
+			positions.AppendVector3(&prev, &p)
+		}
+		prev = p
+	}
+}
+
+// appendCross appends three line segments of the specified half length crossing at the center.
+func appendCross(positions *math32.ArrayF32, center *math32.Vector3, half float32) {
+
+	for _, axis := range []math32.Vector3{{X: half}, {Y: half}, {Z: half}} {
+		a := *center
+		b := *center
+		a.Sub(&axis)
+		b.Add(&axis)
+		positions.AppendVector3(&a, &b)
+	}
+}
@@ -42,7 +42,10 @@ func NewNormalsHelper(ig graphic.IGraphic, size float32, color *math32.Color, li
 	// Creates this helper geometry
 	geom := geometry.NewGeometry()
 	positions := math32.NewArrayF32(n, n)
-	geom.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	vbo := gls.NewVBO(positions).AddAttrib(gls.VertexPosition)
+	// The positions are usually updated every frame
+	vbo.SetUsage(gls.STREAM_DRAW)
+	geom.AddVBO(vbo)
 
 	// Creates this helper material
 	mat := material.NewStandard(color)
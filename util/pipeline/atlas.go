@@ -0,0 +1,200 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/g3n/engine/math32"
+)
+
+// AtlasOptions specifies how the images of atlas directories are packed.
+type AtlasOptions struct {
+	MaxSize    int  // Maximum width and height of the atlases in pixels
+	Padding    int  // Transparent pixels between the images
+	PowerOfTwo bool // Rounds the size of the atlases up to powers of two
+}
+
+// DefaultAtlasOptions are the default options of the atlases.
+var DefaultAtlasOptions = AtlasOptions{MaxSize: 4096, Padding: 2, PowerOfTwo: true}
+
+// Atlas describes the images packed in an atlas image.
+type Atlas struct {
+	Image   string             `json:"image"`   // Atlas image file relative to the atlas file
+	Width   int                `json:"width"`   // Width of the atlas image in pixels
+	Height  int                `json:"height"`  // Height of the atlas image in pixels
+	Sprites map[string]*Sprite `json:"sprites"` // Images by file name without extension
+}
+
+// Sprite describes the location of an image in an atlas.
+type Sprite struct {
+	X      int            `json:"x"`      // Left of the image in pixels
+	Y      int            `json:"y"`      // Top of the image in pixels
+	Width  int            `json:"width"`  // Width of the image in pixels
+	Height int            `json:"height"` // Height of the image in pixels
+	UVMin  math32.Vector2 `json:"uvMin"`  // Texture coordinates of the bottom left corner of the image
+	UVMax  math32.Vector2 `json:"uvMax"`  // Texture coordinates of the top right corner of the image
+}
+
+// LoadAtlas loads the atlas description file with the specified path.
+// The path of its image is made relative to the current directory.
+func LoadAtlas(fpath string) (*Atlas, error) {
+
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	a := new(Atlas)
+	err = json.Unmarshal(data, a)
+	if err != nil {
+		return nil, err
+	}
+	a.Image = filepath.Join(filepath.Dir(fpath), filepath.FromSlash(a.Image))
+	return a, nil
+}
+
+// processAtlas packs the images of the specified atlas directory into an atlas image
+// and writes its description. Both are named after the directory without its extension.
+func (p *Pipeline) processAtlas(src source, asset *Asset) error {
+
+	// Decodes the images sorted by decreasing height
+	type entry struct {
+		name string
+		rgba *image.RGBA
+	}
+	entries := make([]entry, len(src.files))
+	names := make(map[string]bool)
+	for i, file := range src.files {
+		rgba, err := decodeImage(p.srcPath(file))
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		name := replaceExt(path.Base(file), "")
+		if names[name] {
+			return fmt.Errorf("duplicate image name:%s", name)
+		}
+		names[name] = true
+		entries[i] = entry{name: name, rgba: rgba}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].rgba.Rect.Dy() > entries[j].rgba.Rect.Dy() })
+
+	// Packs the images in rows, increasing the width of the atlas until its height fits
+	sizes := make([]image.Point, len(entries))
+	for i := range entries {
+		sizes[i] = entries[i].rgba.Rect.Size()
+	}
+	width, height, pos, err := p.Atlas.pack(sizes)
+	if err != nil {
+		return err
+	}
+
+	// Copies the images and writes the atlas image and description
+	atlas := &Atlas{Width: width, Height: height, Sprites: make(map[string]*Sprite)}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range entries {
+		e := &entries[i]
+		size := sizes[i]
+		draw.Draw(img, image.Rectangle{Min: pos[i], Max: pos[i].Add(size)}, e.rgba, image.Point{}, draw.Src)
+		atlas.Sprites[e.name] = &Sprite{
+			X:      pos[i].X,
+			Y:      pos[i].Y,
+			Width:  size.X,
+			Height: size.Y,
+			UVMin:  math32.Vector2{X: float32(pos[i].X) / float32(width), Y: 1 - float32(pos[i].Y+size.Y)/float32(height)},
+			UVMax:  math32.Vector2{X: float32(pos[i].X+size.X) / float32(width), Y: 1 - float32(pos[i].Y)/float32(height)},
+		}
+	}
+	base := replaceExt(src.name, "")
+	atlas.Image = path.Base(base) + ".png"
+	err = p.saveImage(base+".png", img)
+	if err != nil {
+		return err
+	}
+	err = p.writeJSON(base+".json", atlas)
+	if err != nil {
+		return err
+	}
+	asset.Files = []string{base + ".json", base + ".png"}
+	asset.Width = width
+	asset.Height = height
+	return nil
+}
+
+// pack packs rectangles with the specified sizes, sorted by decreasing height, in rows and
+// returns the size of the atlas and the positions of the rectangles. The width of the atlas
+// is doubled until its height is not larger than its width, or than the maximum size.
+func (o *AtlasOptions) pack(sizes []image.Point) (int, int, []image.Point, error) {
+
+	area, maxWidth := 0, 1
+	for _, s := range sizes {
+		area += (s.X + o.Padding) * (s.Y + o.Padding)
+		if s.X > maxWidth {
+			maxWidth = s.X
+		}
+	}
+	width := 1
+	for width*width < area || width < maxWidth {
+		width *= 2
+	}
+	pos := make([]image.Point, len(sizes))
+	for ; width <= o.MaxSize; width *= 2 {
+		x, y, rowHeight := 0, 0, 0
+		for i, s := range sizes {
+			if x > 0 && x+s.X > width {
+				x = 0
+				y += rowHeight + o.Padding
+				rowHeight = 0
+			}
+			pos[i] = image.Pt(x, y)
+			x += s.X + o.Padding
+			if s.Y > rowHeight {
+				rowHeight = s.Y
+			}
+		}
+		height := y + rowHeight
+		if height < 1 {
+			height = 1
+		}
+		if o.PowerOfTwo {
+			height = ceilPowerOfTwo(height)
+		}
+		if height <= width || (width*2 > o.MaxSize && height <= o.MaxSize) {
+			if !o.PowerOfTwo {
+				width = packedWidth(sizes, pos)
+			}
+			return width, height, pos, nil
+		}
+	}
+	return 0, 0, nil, fmt.Errorf("images do not fit in %dx%d pixels", o.MaxSize, o.MaxSize)
+}
+
+// packedWidth returns the width of the specified packed rectangles.
+func packedWidth(sizes, pos []image.Point) int {
+
+	width := 1
+	for i := range sizes {
+		if w := pos[i].X + sizes[i].X; w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// ceilPowerOfTwo returns the smallest power of two not less than the specified value.
+func ceilPowerOfTwo(v int) int {
+
+	p := 1
+	for p < v {
+		p *= 2
+	}
+	return p
+}
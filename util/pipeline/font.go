@@ -0,0 +1,144 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/g3n/engine/text"
+)
+
+// FontOptions specifies how fonts are baked.
+type FontOptions struct {
+	Runes  string  // Runes of the baked glyphs
+	Size   float64 // Size of the glyphs in pixels per em
+	Spread int     // Distance range in pixels on each side of the glyph outlines
+}
+
+// DefaultFontOptions are the default options of the fonts, baking the printable ASCII characters.
+var DefaultFontOptions = FontOptions{Runes: asciiRunes(), Size: 48, Spread: 6}
+
+// BakedFont is the description of a font baked into a signed distance field atlas.
+type BakedFont struct {
+	Image      string                  `json:"image"`      // Atlas image file relative to the description file
+	Size       float64                 `json:"size"`       // Size of the glyphs in the image in pixels per em
+	Spread     int                     `json:"spread"`     // Distance range in pixels on each side of the glyph outlines
+	Ascent     float32                 `json:"ascent"`     // Font ascent in em units
+	Descent    float32                 `json:"descent"`    // Font descent in em units
+	LineHeight float32                 `json:"lineHeight"` // Distance between baselines in em units
+	Glyphs     map[rune]*text.SDFGlyph `json:"glyphs"`     // Glyphs in the atlas
+	Kerning    []Kerning               `json:"kerning"`    // Non zero kerning adjustments, sorted by runes
+}
+
+// Kerning is the kerning adjustment between two runes in em units.
+type Kerning struct {
+	R0    rune    `json:"r0"`
+	R1    rune    `json:"r1"`
+	Value float32 `json:"value"`
+}
+
+// LoadFont loads the baked font description file with the specified path and its image,
+// and returns its signed distance field atlas.
+func LoadFont(fpath string) (*text.SDFAtlas, error) {
+
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	var bf BakedFont
+	err = json.Unmarshal(data, &bf)
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeImage(filepath.Join(filepath.Dir(fpath), filepath.FromSlash(bf.Image)))
+	if err != nil {
+		return nil, err
+	}
+	a := &text.SDFAtlas{
+		Image:      img,
+		Size:       bf.Size,
+		Spread:     bf.Spread,
+		Ascent:     bf.Ascent,
+		Descent:    bf.Descent,
+		LineHeight: bf.LineHeight,
+		Glyphs:     bf.Glyphs,
+	}
+	kerning := make(map[[2]rune]float32, len(bf.Kerning))
+	for _, k := range bf.Kerning {
+		kerning[[2]rune{k.R0, k.R1}] = k.Value
+	}
+	a.SetKern(func(r0, r1 rune) float32 { return kerning[[2]rune{r0, r1}] })
+	return a, nil
+}
+
+// fontOptions returns the options of the specified source font.
+func (p *Pipeline) fontOptions(name string) FontOptions {
+
+	if opts, ok := p.Fonts[name]; ok {
+		return opts
+	}
+	return p.Font
+}
+
+// processFont bakes the glyphs of the specified source font into a signed distance field
+// atlas image and writes its description with their metrics and kerning.
+func (p *Pipeline) processFont(src source, asset *Asset) error {
+
+	f, err := text.NewFont(p.srcPath(src.name))
+	if err != nil {
+		return err
+	}
+	opts := p.fontOptions(src.name)
+	a := text.NewSDFAtlas(f, opts.Runes, opts.Size, opts.Spread)
+	base := replaceExt(src.name, "")
+	bf := BakedFont{
+		Image:      path.Base(base) + ".png",
+		Size:       a.Size,
+		Spread:     a.Spread,
+		Ascent:     a.Ascent,
+		Descent:    a.Descent,
+		LineHeight: a.LineHeight,
+		Glyphs:     a.Glyphs,
+	}
+	runes := make([]rune, 0, len(a.Glyphs))
+	for r := range a.Glyphs {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	for _, r0 := range runes {
+		for _, r1 := range runes {
+			if k := a.Kern(r0, r1); k != 0 {
+				bf.Kerning = append(bf.Kerning, Kerning{R0: r0, R1: r1, Value: k})
+			}
+		}
+	}
+
+	err = p.saveImage(base+".png", a.Image)
+	if err != nil {
+		return err
+	}
+	err = p.writeJSON(base+".json", &bf)
+	if err != nil {
+		return err
+	}
+	asset.Files = []string{base + ".json", base + ".png"}
+	asset.Width = a.Image.Rect.Dx()
+	asset.Height = a.Image.Rect.Dy()
+	return nil
+}
+
+// asciiRunes returns the printable ASCII characters.
+func asciiRunes() string {
+
+	runes := make([]rune, 0, 95)
+	for r := ' '; r <= '~'; r++ {
+		runes = append(runes, r)
+	}
+	return string(runes)
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// ManifestName is the name of the manifest file in the destination directory.
+const ManifestName = "manifest.json"
+
+// ManifestVersion is the current manifest version.
+// Assets processed by other versions are processed again.
+const ManifestVersion = 1
+
+// Manifest describes the processed assets of a directory.
+type Manifest struct {
+	Version int     `json:"version"` // Manifest version
+	Assets  []Asset `json:"assets"`  // Assets sorted by name
+}
+
+// Asset describes a processed asset.
+type Asset struct {
+	Name   string   `json:"name"`             // Name of the source file or directory with slashes
+	Kind   string   `json:"kind"`             // Kind of asset (KindTexture, KindModel...)
+	Files  []string `json:"files"`            // Output files relative to the manifest, main file first
+	Size   int64    `json:"size"`             // Total size of the output files in bytes
+	Width  int      `json:"width,omitempty"`  // Width of textures and atlases in pixels
+	Height int      `json:"height,omitempty"` // Height of textures and atlases in pixels
+	Hash   string   `json:"hash"`             // Hash of the sources and options
+}
+
+// LoadManifest loads the manifest with the specified path.
+func LoadManifest(fpath string) (*Manifest, error) {
+
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	m := new(Manifest)
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, err
+	}
+	if m.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version:%d", m.Version)
+	}
+	return m, nil
+}
+
+// Save saves the manifest to the specified path.
+func (m *Manifest) Save(fpath string) error {
+
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fpath, data, 0644)
+}
+
+// Find returns the asset with the specified source name or nil if not found.
+func (m *Manifest) Find(name string) *Asset {
+
+	i := sort.Search(len(m.Assets), func(i int) bool { return m.Assets[i].Name >= name })
+	if i < len(m.Assets) && m.Assets[i].Name == name {
+		return &m.Assets[i]
+	}
+	return nil
+}
+
+// File returns the main output file of the asset with the specified source name,
+// relative to the manifest, or an empty string if not found.
+func (m *Manifest) File(name string) string {
+
+	asset := m.Find(name)
+	if asset == nil || len(asset.Files) == 0 {
+		return ""
+	}
+	return asset.Files[0]
+}
+
+// Kind returns the assets of the specified kind.
+func (m *Manifest) Kind(kind string) []*Asset {
+
+	var assets []*Asset
+	for i := range m.Assets {
+		if m.Assets[i].Kind == kind {
+			assets = append(assets, &m.Assets[i])
+		}
+	}
+	return assets
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pipeline
+
+import (
+	"github.com/g3n/engine/loader"
+	"github.com/g3n/engine/loader/g3b"
+)
+
+// processModel converts the model of the specified source asset to the G3B format.
+func (p *Pipeline) processModel(src source, asset *Asset) error {
+
+	root, err := loader.Load(p.srcPath(src.name))
+	if err != nil {
+		return err
+	}
+	f, err := g3b.Convert(root, &p.Model.Options)
+	if err != nil {
+		return err
+	}
+	name := replaceExt(src.name, ".g3b")
+	out, err := p.create(name)
+	if err != nil {
+		return err
+	}
+	if p.Model.Progressive > 0 {
+		err = f.WriteProgressive(out, p.Model.Progressive, p.Model.Compress)
+	} else {
+		err = f.Write(out, p.Model.Compress)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	asset.Files = []string{name}
+	return nil
+}
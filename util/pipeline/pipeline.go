@@ -0,0 +1,368 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pipeline preprocesses a directory of source assets into a directory of assets
+// ready to be loaded at runtime, and writes a manifest describing them.
+//
+// Images are downscaled and recompressed, models of the formats registered with the loader
+// package are converted to the G3B format, the images of each directory with the ".atlas"
+// extension are packed into an atlas, TrueType and OpenType fonts are baked into signed
+// distance field atlases and other files are copied. Assets whose sources and options did not change since the last run are not
+// processed again. It is meant to be run at build time, such as by the g3npipeline tool
+// invoked by "go generate".
+package pipeline
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/g3n/engine/loader"
+	"github.com/g3n/engine/loader/g3b"
+)
+
+// Asset kinds.
+const (
+	KindTexture = "texture" // Image processed by Pipeline.Texture
+	KindModel   = "model"   // Model converted to the G3B format
+	KindAtlas   = "atlas"   // Images packed into an atlas
+	KindFont    = "font"    // Font baked into a signed distance field atlas
+	KindFile    = "file"    // File copied as it is
+)
+
+// AtlasExt is the extension of the source directories whose images are packed into an atlas.
+const AtlasExt = ".atlas"
+
+// Pipeline preprocesses the assets of a source directory into a destination directory.
+// Its fields set the options of each kind of asset and may be changed before calling Run.
+type Pipeline struct {
+	Src     string                                // Source directory
+	Dst     string                                // Destination directory
+	Texture TextureOptions                        // Options of the images
+	Model   ModelOptions                          // Options of the models
+	Atlas   AtlasOptions                          // Options of the atlases
+	Font    FontOptions                           // Default options of the fonts
+	Fonts   map[string]FontOptions                // Options of specific fonts by source name
+	Ignore  []string                              // Patterns of the source names to skip, as in path.Match
+	Force   bool                                  // Processes all the assets even if they did not change
+	Log     func(format string, v ...interface{}) // Reports the processed assets if not nil
+}
+
+// ModelOptions specifies how models are converted.
+type ModelOptions struct {
+	g3b.Options      // Preprocessing of the meshes
+	Compress    bool // Compresses the files, which then can not be memory-mapped
+	Progressive int  // Writes progressive streams with up to this number of coarser levels of detail if positive
+}
+
+// DefaultModelOptions are the default options of the models.
+var DefaultModelOptions = ModelOptions{Options: g3b.DefaultOptions}
+
+// NewPipeline creates and returns a pointer to a new pipeline processing the assets of
+// the specified source directory into the specified destination directory with the
+// default options.
+func NewPipeline(src, dst string) *Pipeline {
+
+	p := new(Pipeline)
+	p.Src = src
+	p.Dst = dst
+	p.Texture = DefaultTextureOptions
+	p.Model = DefaultModelOptions
+	p.Atlas = DefaultAtlasOptions
+	p.Font = DefaultFontOptions
+	p.Fonts = make(map[string]FontOptions)
+	p.Ignore = []string{".*", "*~"}
+	return p
+}
+
+// Run processes the assets which changed since the last run, removes the outputs of the
+// assets which no longer exist, and writes the manifest, which it also returns.
+// It stops at the first error.
+func (p *Pipeline) Run() (*Manifest, error) {
+
+	sources, err := p.sources()
+	if err != nil {
+		return nil, err
+	}
+	prev, err := LoadManifest(filepath.Join(p.Dst, ManifestName))
+	if err != nil {
+		prev = new(Manifest)
+	}
+
+	m := new(Manifest)
+	m.Version = ManifestVersion
+	outputs := make(map[string]bool)
+	for _, src := range sources {
+		hash, err := p.hash(src)
+		if err != nil {
+			return nil, err
+		}
+		asset := prev.Find(src.name)
+		if p.Force || asset == nil || asset.Hash != hash || !p.exist(asset) {
+			asset, err = p.process(src)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", src.name, err)
+			}
+			asset.Hash = hash
+			p.logf("%s: %s %v", src.name, asset.Kind, asset.Files)
+		}
+		for _, f := range asset.Files {
+			outputs[f] = true
+		}
+		m.Assets = append(m.Assets, *asset)
+	}
+
+	// Removes the outputs of the assets which no longer exist
+	for _, asset := range prev.Assets {
+		for _, f := range asset.Files {
+			if !outputs[f] {
+				os.Remove(filepath.Join(p.Dst, filepath.FromSlash(f)))
+			}
+		}
+	}
+	err = m.Save(filepath.Join(p.Dst, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// source is a source asset.
+type source struct {
+	name  string   // Name relative to the source directory with slashes
+	kind  string   // Kind of asset
+	files []string // Source files relative to the source directory with slashes
+}
+
+// sources returns the source assets sorted by name.
+func (p *Pipeline) sources() ([]source, error) {
+
+	var sources []source
+	err := filepath.Walk(p.Src, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(p.Src, fpath)
+		if err != nil || rel == "." {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if p.ignored(path.Base(name)) || p.ignored(name) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			sources = append(sources, source{name: name, kind: kindOf(name), files: []string{name}})
+			return nil
+		}
+		if strings.ToLower(path.Ext(name)) != AtlasExt {
+			return nil
+		}
+
+		// Collects the images of the atlas directory
+		src := source{name: name, kind: KindAtlas}
+		infos, err := ioutil.ReadDir(fpath)
+		if err != nil {
+			return err
+		}
+		for _, fi := range infos {
+			if !fi.IsDir() && kindOf(fi.Name()) == KindTexture && !p.ignored(fi.Name()) {
+				src.files = append(src.files, name+"/"+fi.Name())
+			}
+		}
+		sources = append(sources, src)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].name < sources[j].name })
+	return sources, nil
+}
+
+// kindOf returns the kind of asset of the specified source file.
+// Models are the files of the formats registered with the loader package.
+func kindOf(name string) string {
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return KindTexture
+	case ".ttf", ".otf":
+		return KindFont
+	}
+	if f := loader.ForExtension(name); f != nil && !copiedFormats[f.Name] {
+		return KindModel
+	}
+	return KindFile
+}
+
+// copiedFormats are the registered model formats which are copied instead of converted.
+var copiedFormats = map[string]bool{"g3b": true, "scene": true}
+
+// ignored returns whether the specified source name matches an ignore pattern.
+func (p *Pipeline) ignored(name string) bool {
+
+	for _, pattern := range p.Ignore {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// process processes the specified source asset and returns its manifest entry.
+func (p *Pipeline) process(src source) (*Asset, error) {
+
+	asset := &Asset{Name: src.name, Kind: src.kind}
+	var err error
+	switch src.kind {
+	case KindTexture:
+		err = p.processTexture(src, asset)
+	case KindModel:
+		err = p.processModel(src, asset)
+	case KindAtlas:
+		err = p.processAtlas(src, asset)
+	case KindFont:
+		err = p.processFont(src, asset)
+	default:
+		err = p.copyFile(src.name, src.name)
+		asset.Files = []string{src.name}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range asset.Files {
+		info, err := os.Stat(p.dstPath(f))
+		if err != nil {
+			return nil, err
+		}
+		asset.Size += info.Size()
+	}
+	return asset, nil
+}
+
+// hash returns the hash of the files and of the options of the specified source asset.
+func (p *Pipeline) hash(src source) (string, error) {
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%d %s\n", ManifestVersion, src.kind)
+	switch src.kind {
+	case KindTexture:
+		fmt.Fprintf(h, "%+v\n", p.Texture)
+	case KindModel:
+		fmt.Fprintf(h, "%+v\n", p.Model)
+	case KindAtlas:
+		fmt.Fprintf(h, "%+v\n", p.Atlas)
+	case KindFont:
+		fmt.Fprintf(h, "%+v\n", p.fontOptions(src.name))
+	}
+	for _, name := range src.files {
+		fmt.Fprintf(h, "%s\n", name)
+		f, err := os.Open(p.srcPath(name))
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// exist returns whether all the output files of the specified asset exist.
+func (p *Pipeline) exist(asset *Asset) bool {
+
+	for _, f := range asset.Files {
+		if _, err := os.Stat(p.dstPath(f)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// copyFile copies the specified source file to the specified destination file.
+func (p *Pipeline) copyFile(srcName, dstName string) error {
+
+	in, err := os.Open(p.srcPath(srcName))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := p.create(dstName)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeJSON writes the specified value as JSON to the specified destination file.
+func (p *Pipeline) writeJSON(name string, v interface{}) error {
+
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+	out, err := p.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// create creates the specified destination file and its directory.
+func (p *Pipeline) create(name string) (*os.File, error) {
+
+	fpath := p.dstPath(name)
+	err := os.MkdirAll(filepath.Dir(fpath), 0755)
+	if err != nil {
+		return nil, err
+	}
+	return os.Create(fpath)
+}
+
+// srcPath returns the path of the specified source file.
+func (p *Pipeline) srcPath(name string) string {
+
+	return filepath.Join(p.Src, filepath.FromSlash(name))
+}
+
+// dstPath returns the path of the specified destination file.
+func (p *Pipeline) dstPath(name string) string {
+
+	return filepath.Join(p.Dst, filepath.FromSlash(name))
+}
+
+// logf reports a message if the log function is set.
+func (p *Pipeline) logf(format string, v ...interface{}) {
+
+	if p.Log != nil {
+		p.Log(format, v...)
+	}
+}
+
+// replaceExt returns the specified name with its extension replaced.
+func replaceExt(name, ext string) string {
+
+	return strings.TrimSuffix(name, path.Ext(name)) + ext
+}
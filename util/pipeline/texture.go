@@ -0,0 +1,174 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pipeline
+
+import (
+	"image"
+	"image/draw"
+	_ "image/gif" // Registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"os"
+)
+
+// TextureOptions specifies how images are processed.
+type TextureOptions struct {
+	MaxSize    int  // Maximum width and height in pixels, 0 for no limit
+	PowerOfTwo bool // Downscales the images to power of two sizes
+	Quality    int  // Quality of the opaque images encoded as JPEG from 1 to 100, 0 to encode all images as PNG
+}
+
+// DefaultTextureOptions are the default options of the images.
+var DefaultTextureOptions = TextureOptions{MaxSize: 4096}
+
+// processTexture downscales and recompresses the image of the specified source asset.
+// Images are encoded as PNG with the best compression, or as JPEG if opaque and the
+// quality is set.
+func (p *Pipeline) processTexture(src source, asset *Asset) error {
+
+	rgba, err := decodeImage(p.srcPath(src.name))
+	if err != nil {
+		return err
+	}
+	rgba = p.Texture.resize(rgba)
+	name := replaceExt(src.name, ".png")
+	if p.Texture.Quality > 0 && opaque(rgba) {
+		name = replaceExt(src.name, ".jpg")
+	}
+	err = p.saveImage(name, rgba)
+	if err != nil {
+		return err
+	}
+	asset.Files = []string{name}
+	asset.Width = rgba.Rect.Dx()
+	asset.Height = rgba.Rect.Dy()
+	return nil
+}
+
+// resize returns the specified image downscaled to the maximum and power of two sizes
+// of the options, or the image itself if it fits.
+func (o *TextureOptions) resize(rgba *image.RGBA) *image.RGBA {
+
+	w, h := rgba.Rect.Dx(), rgba.Rect.Dy()
+	if o.MaxSize > 0 && (w > o.MaxSize || h > o.MaxSize) {
+		if w >= h {
+			w, h = o.MaxSize, h*o.MaxSize/w
+		} else {
+			w, h = w*o.MaxSize/h, o.MaxSize
+		}
+	}
+	if o.PowerOfTwo {
+		w, h = floorPowerOfTwo(w), floorPowerOfTwo(h)
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	if w == rgba.Rect.Dx() && h == rgba.Rect.Dy() {
+		return rgba
+	}
+	return downscale(rgba, w, h)
+}
+
+// downscale returns the specified image downscaled to the specified size with a box filter.
+func downscale(src *image.RGBA, w, h int) *image.RGBA {
+
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, (y+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, (x+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				off := src.PixOffset(src.Rect.Min.X+x0, src.Rect.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[off+c])
+					}
+					off += 4
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			off := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[off+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// floorPowerOfTwo returns the largest power of two not greater than the specified positive value.
+func floorPowerOfTwo(v int) int {
+
+	p := 1
+	for p*2 <= v {
+		p *= 2
+	}
+	return p
+}
+
+// opaque returns whether all the pixels of the specified image are opaque.
+func opaque(rgba *image.RGBA) bool {
+
+	for y := rgba.Rect.Min.Y; y < rgba.Rect.Max.Y; y++ {
+		off := rgba.PixOffset(rgba.Rect.Min.X, y)
+		for x := 0; x < rgba.Rect.Dx(); x++ {
+			if rgba.Pix[off+x*4+3] != 0xFF {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// decodeImage decodes the image file with the specified path into an RGBA image.
+func decodeImage(fpath string) (*image.RGBA, error) {
+
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba, nil
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+// saveImage encodes the specified image into the specified destination file,
+// as JPEG if its extension is ".jpg" or as PNG otherwise.
+func (p *Pipeline) saveImage(name string, rgba *image.RGBA) error {
+
+	out, err := p.create(name)
+	if err != nil {
+		return err
+	}
+	if replaceExt(name, ".jpg") == name {
+		err = jpeg.Encode(out, rgba, &jpeg.Options{Quality: p.Texture.Quality})
+	} else {
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		err = enc.Encode(out, rgba)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/text"
+	"github.com/g3n/engine/texture"
+)
+
+// SkeletonHelper is the visual representation of the bones of a skeleton as wireframe
+// octahedrons going from each bone to its child bones, with an optional label with the
+// name of each bone. Bones without child bones are shown as small crosses.
+// The helper is in world coordinates, so it should be added to the scene root.
+type SkeletonHelper struct {
+	graphic.Lines
+	skeleton *graphic.Skeleton  // Target skeleton
+	color    math32.Color       // Color of the bones
+	labels   []*graphic.SDFText // Labels of the bones, if enabled
+}
+
+// NewSkeletonHelper creates and returns a pointer to a new helper for the specified skeleton.
+func NewSkeletonHelper(skeleton *graphic.Skeleton, color *math32.Color) *SkeletonHelper {
+
+	sh := new(SkeletonHelper)
+	sh.skeleton = skeleton
+	sh.color = *color
+
+	geom := geometry.NewGeometry()
+	vbo := gls.NewVBO(math32.NewArrayF32(0, 0)).AddAttrib(gls.VertexPosition)
+	// The positions are usually updated every frame
+	vbo.SetUsage(gls.STREAM_DRAW)
+	geom.AddVBO(vbo)
+
+	mat := material.NewStandard(color)
+	mat.SetUseLights(material.UseLightNone)
+	mat.SetDepthTest(false)
+	mat.SetTransparent(true)
+
+	sh.Lines.Init(geom, mat)
+	sh.SetCullable(false)
+	sh.Update()
+	return sh
+}
+
+// SetLabels shows the names of the bones with the glyphs of the specified atlas,
+// with size world units per em. A nil atlas removes the labels.
+func (sh *SkeletonHelper) SetLabels(atlas *text.SDFAtlas, size float32) {
+
+	for _, label := range sh.labels {
+		sh.Remove(label)
+		label.Dispose()
+	}
+	sh.labels = nil
+	if atlas == nil {
+		return
+	}
+	mat := material.NewSDFText(&sh.color)
+	mat.SetDepthTest(false)
+	mat.AddTexture(texture.NewTexture2DFromRGBA(atlas.Image))
+	for _, bone := range sh.skeleton.Bones() {
+		label := graphic.NewSDFText(atlas, bone.Name(), size, mat)
+		label.SetAnchor(0.5, 0)
+		label.SetBillboard(true)
+		label.SetCullable(false)
+		sh.labels = append(sh.labels, label)
+		sh.Add(label)
+	}
+	sh.Update()
+}
+
+// Update should be called in the render loop to update the
+// bones based on the current pose of the skeleton.
+func (sh *SkeletonHelper) Update() {
+
+	bones := sh.skeleton.Bones()
+	isBone := make(map[*core.Node]bool, len(bones))
+	for _, bone := range bones {
+		isBone[bone] = true
+	}
+
+	positions := math32.NewArrayF32(0, 0)
+	for i, bone := range bones {
+		var head math32.Vector3
+		bone.WorldPosition(&head)
+		length := float32(0)
+		children := 0
+		for _, ichild := range bone.Children() {
+			child := ichild.GetNode()
+			if !isBone[child] {
+				continue
+			}
+			var tail math32.Vector3
+			child.WorldPosition(&tail)
+			appendBone(&positions, &head, &tail)
+			length = math32.Max(length, head.DistanceTo(&tail))
+			children++
+		}
+		if children == 0 {
+			// The size of leaf bones follows their parent bone
+			if parent := bone.Parent(); parent != nil && isBone[parent.GetNode()] {
+				var phead math32.Vector3
+				parent.GetNode().WorldPosition(&phead)
+				length = phead.DistanceTo(&head)
+			}
+			appendCross(&positions, &head, math32.Max(length, 0.01)/10)
+		}
+		if i < len(sh.labels) {
+			sh.labels[i].SetPositionVec(&head)
+		}
+	}
+	sh.GetGeometry().VBO(gls.VertexPosition).SetBuffer(positions)
+}
+
+// appendBone appends the edges of an octahedral bone going from head to tail,
+// whose widest part is at a tenth of its length.
+func appendBone(positions *math32.ArrayF32, head, tail *math32.Vector3) {
+
+	dir := *tail
+	dir.Sub(head)
+	length := dir.Length()
+	if length == 0 {
+		return
+	}
+	dir.MultiplyScalar(1 / length)
+	var u, v math32.Vector3
+	perpendicular(&dir, &u, &v)
+	center := dir
+	center.MultiplyScalar(length / 10).Add(head)
+	var ring [4]math32.Vector3
+	for i := range ring {
+		a := float32(i) * math32.Pi / 2
+		ring[i] = center
+		ring[i].Add(u.Clone().MultiplyScalar(length / 10 * math32.Cos(a)))
+		ring[i].Add(v.Clone().MultiplyScalar(length / 10 * math32.Sin(a)))
+	}
+	for i := range ring {
+		positions.AppendVector3(head, &ring[i], &ring[i], tail, &ring[i], &ring[(i+1)%4])
+	}
+}
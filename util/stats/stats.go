@@ -14,6 +14,9 @@ type Stats struct {
 	UnilocMiss   int       // Uniform location cache misses per frame
 	Unisets      int       // Uniform sets per frame
 	Drawcalls    int       // Draw calls per frame
+	Bindhits     int       // Redundant object bindings filtered per frame
+	StreamBytes  int       // Bytes written to stream buffers per frame
+	StreamWaits  int       // Waits for stream buffer regions per frame
 	Cgocalls     int       // Cgo calls per frame
 	prevGls      gls.Stats // previous gls statistics
 	prevCgocalls int64     // previous number of cgo calls
@@ -61,6 +64,16 @@ func (s *Stats) Update(d time.Duration) bool {
 	drawcalls := s.Glstats.Drawcalls - s.prevGls.Drawcalls
 	s.Drawcalls = int(float64(drawcalls) / float64(s.frames))
 
+	// Calculates redundant bindings filtered per frame
+	bindhits := s.Glstats.Bindhits - s.prevGls.Bindhits
+	s.Bindhits = int(float64(bindhits) / float64(s.frames))
+
+	// Calculates stream buffer bytes and waits per frame
+	streamBytes := s.Glstats.StreamBytes - s.prevGls.StreamBytes
+	s.StreamBytes = int(float64(streamBytes) / float64(s.frames))
+	streamWaits := s.Glstats.StreamWaits - s.prevGls.StreamWaits
+	s.StreamWaits = int(float64(streamWaits) / float64(s.frames))
+
 	// Calculates number of cgo calls per frame
 	current := runtime.NumCgoCall()
 	cgocalls := current - s.prevCgocalls
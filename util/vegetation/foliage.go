@@ -0,0 +1,212 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vegetation scatters instanced foliage, such as grass, rocks and trees,
+// over terrains and other meshes following density maps and slope and height rules.
+package vegetation
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/util/environment"
+)
+
+// Instance is the placement of an instance of foliage.
+type Instance struct {
+	Position math32.Vector3    // Position of the origin of the geometry
+	Rotation math32.Quaternion // Rotation of the geometry
+	Scale    float32           // Uniform scale of the geometry
+}
+
+// Still wind used by the foliage without wind, so the uniforms of other foliage are not kept
+var noWind = environment.NewWind(&math32.Vector3{X: 1}, 0)
+
+// Foliage is an instanced mesh drawing a geometry, such as grass cards, a rock or a tree,
+// at many instances with the "foliage" shader. The density of the instances falls off with
+// the distance from the camera, and the vertices above the origin of the geometry bend with
+// the wind, in proportion to the square of their height. Transparent parts of the textures
+// below the alpha cutoff are discarded, so grass and leaves do not need sorting.
+// The positions of the instances are in the local space of the foliage, so foliage
+// scattered by Scatter or Paint, which are in world space, is usually added to the scene root.
+type Foliage struct {
+	graphic.InstancedMesh                   // Embedded instanced mesh
+	instances             []Instance        // Instances
+	vbo                   *gls.VBO          // Per instance VBO
+	wind                  *environment.Wind // Wind bending the foliage, if any
+	params                [4]float32        // Fade start, fade end, bend and alpha cutoff
+	uniFoliage            gls.Uniform       // Foliage parameters uniform location cache
+	uniWindMatrix         gls.Uniform       // Wind matrix uniform location cache
+}
+
+// NewFoliage creates and returns a pointer to a new foliage without instances with the
+// specified geometry and material, whose shader is set to "foliage". The per instance
+// VBO is added to the geometry, so the geometry should not be used by other graphics.
+func NewFoliage(igeom geometry.IGeometry, mat *material.Standard) *Foliage {
+
+	f := new(Foliage)
+	f.vbo = gls.NewVBO(math32.NewArrayF32(0, 0)).
+		AddCustomAttrib("InstancePosition", 4).
+		AddCustomAttrib("InstanceRotation", 4)
+	f.vbo.SetDivisor(1)
+	igeom.GetGeometry().AddVBO(f.vbo)
+
+	mat.SetShader("foliage")
+	mat.SetSide(material.SideDouble)
+	f.InstancedMesh.Init(igeom, nil, 0)
+	f.Graphic.AddMaterial(f, mat, 0, 0)
+	f.uniFoliage.Init("Foliage")
+	f.uniWindMatrix.Init("WindMatrix")
+	f.params = [4]float32{0, 0, 0.1, 0.5}
+	return f
+}
+
+// SetInstances replaces the instances of the foliage.
+func (f *Foliage) SetInstances(instances []Instance) {
+
+	f.instances = append(f.instances[:0], instances...)
+	f.update()
+}
+
+// AddInstances adds the specified instances to the foliage.
+func (f *Foliage) AddInstances(instances ...Instance) {
+
+	f.instances = append(f.instances, instances...)
+	f.update()
+}
+
+// Instances returns the instances of the foliage.
+func (f *Foliage) Instances() []Instance {
+
+	return f.instances
+}
+
+// Erase removes the instances within the specified radius of the specified
+// position, in the local space of the foliage, and returns how many were removed.
+func (f *Foliage) Erase(center *math32.Vector3, radius float32) int {
+
+	kept := f.instances[:0]
+	for _, inst := range f.instances {
+		if inst.Position.DistanceToSquared(center) > radius*radius {
+			kept = append(kept, inst)
+		}
+	}
+	removed := len(f.instances) - len(kept)
+	f.instances = kept
+	if removed > 0 {
+		f.update()
+	}
+	return removed
+}
+
+// Clear removes all the instances of the foliage.
+func (f *Foliage) Clear() {
+
+	f.instances = f.instances[:0]
+	f.update()
+}
+
+// SetFade sets the distances from the camera where the density of the instances starts
+// falling off and where no instances are left. If end is not larger than start, all
+// instances are drawn at any distance, which is the default.
+func (f *Foliage) SetFade(start, end float32) {
+
+	f.params[0] = start
+	f.params[1] = end
+}
+
+// Fade returns the distances from the camera where the density of the instances starts
+// falling off and where no instances are left.
+func (f *Foliage) Fade() (start, end float32) {
+
+	return f.params[0], f.params[1]
+}
+
+// SetWind sets the wind bending the foliage, usually the wind of an environment.Environment.
+// A nil wind leaves the foliage still.
+func (f *Foliage) SetWind(wind *environment.Wind) {
+
+	f.wind = wind
+}
+
+// Wind returns the wind bending the foliage.
+func (f *Foliage) Wind() *environment.Wind {
+
+	return f.wind
+}
+
+// SetBend sets how much the foliage bends with the wind (default = 0.1). The displacement of
+// a vertex is the wind velocity times the bend times the square of its height above the origin.
+func (f *Foliage) SetBend(bend float32) {
+
+	f.params[2] = bend
+}
+
+// Bend returns how much the foliage bends with the wind.
+func (f *Foliage) Bend() float32 {
+
+	return f.params[2]
+}
+
+// SetAlphaCutoff sets the texture opacity below which fragments are discarded (default = 0.5).
+func (f *Foliage) SetAlphaCutoff(cutoff float32) {
+
+	f.params[3] = cutoff
+}
+
+// AlphaCutoff returns the texture opacity below which fragments are discarded.
+func (f *Foliage) AlphaCutoff() float32 {
+
+	return f.params[3]
+}
+
+// Clone clones the foliage and satisfies the INode interface.
+// The clone shares the geometry, and so the instances, of the foliage.
+func (f *Foliage) Clone() core.INode {
+
+	clone := new(Foliage)
+	clone.InstancedMesh = *f.InstancedMesh.Clone().(*graphic.InstancedMesh)
+	clone.SetIGraphic(clone)
+	clone.instances = f.instances
+	clone.vbo = f.vbo
+	clone.wind = f.wind
+	clone.params = f.params
+	clone.uniFoliage.Init("Foliage")
+	clone.uniWindMatrix.Init("WindMatrix")
+	return clone
+}
+
+// RenderSetup transfers the mesh matrices and the foliage and wind uniforms.
+func (f *Foliage) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	f.InstancedMesh.RenderSetup(gs, rinfo)
+	gs.Uniform4f(f.uniFoliage.Location(gs), f.params[0], f.params[1], f.params[2], f.params[3])
+
+	// The wind velocity is in world space and the displacement in the model space
+	var wm math32.Matrix3
+	wm.GetNormalMatrix(f.ModelMatrix())
+	wm.Transpose()
+	gs.UniformMatrix3fv(f.uniWindMatrix.Location(gs), 1, false, &wm[0])
+	if f.wind != nil {
+		f.wind.RenderSetup(gs)
+	} else {
+		noWind.RenderSetup(gs)
+	}
+}
+
+// update updates the per instance VBO from the instances.
+func (f *Foliage) update() {
+
+	buf := math32.NewArrayF32(0, 8*len(f.instances))
+	for i := range f.instances {
+		inst := &f.instances[i]
+		buf.Append(inst.Position.X, inst.Position.Y, inst.Position.Z, inst.Scale,
+			inst.Rotation.X, inst.Rotation.Y, inst.Rotation.Z, inst.Rotation.W)
+	}
+	f.vbo.SetBuffer(buf)
+	f.SetInstanceCount(len(f.instances))
+}
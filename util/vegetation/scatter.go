@@ -0,0 +1,234 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vegetation
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/math32"
+)
+
+// DensityMap is the interface for the density of foliage, from 0 to 1,
+// over the texture coordinates of a surface.
+type DensityMap interface {
+	Density(u, v float32) float32
+}
+
+// DensityFunc is a function satisfying the DensityMap interface.
+type DensityFunc func(u, v float32) float32
+
+// Density satisfies the DensityMap interface and returns the result of the function.
+func (f DensityFunc) Density(u, v float32) float32 {
+
+	return f(u, v)
+}
+
+// ImageDensity is a DensityMap whose density is the luminance of an image, such as
+// a painted mask, which covers the texture coordinates as a texture would.
+type ImageDensity struct {
+	img image.Image
+}
+
+// NewImageDensity creates and returns a pointer to a new density map from the specified image.
+func NewImageDensity(img image.Image) *ImageDensity {
+
+	return &ImageDensity{img}
+}
+
+// Density satisfies the DensityMap interface and returns the luminance of the
+// nearest pixel, with v going up from the bottom of the image as texture coordinates.
+func (d *ImageDensity) Density(u, v float32) float32 {
+
+	b := d.img.Bounds()
+	x := b.Min.X + int(math32.Clamp(u, 0, 1)*float32(b.Dx()-1)+0.5)
+	y := b.Min.Y + int(math32.Clamp(1-v, 0, 1)*float32(b.Dy()-1)+0.5)
+	gray := color.Gray16Model.Convert(d.img.At(x, y)).(color.Gray16)
+	return float32(gray.Y) / 0xFFFF
+}
+
+// Rules are the rules followed to scatter instances over a surface.
+// Heights are world Y coordinates and slopes are the angles in degrees between the
+// surface and the horizontal plane, so they are computed after the world transform
+// of the target. Instances are placed where all the rules are satisfied.
+type Rules struct {
+	Density       float32    // Instances per square unit of surface where the density map is 1
+	DensityMap    DensityMap // Density over the texture coordinates of the surface, or nil for a uniform density
+	MinHeight     float32    // Minimum height
+	MaxHeight     float32    // Maximum height
+	MinSlope      float32    // Minimum slope in degrees
+	MaxSlope      float32    // Maximum slope in degrees
+	MinScale      float32    // Minimum random scale of the instances
+	MaxScale      float32    // Maximum random scale of the instances
+	AlignToNormal float32    // Tilt of the instances from upright (0) to the surface normal (1)
+	FixedYaw      bool       // Whether the instances are not randomly rotated around their up axis
+	Seed          int64      // Seed of the random placement, so the same rules give the same instances
+}
+
+// DefaultRules returns rules with the specified density, without height and slope limits,
+// with instances of scale 1, upright and randomly rotated around the vertical.
+func DefaultRules(density float32) *Rules {
+
+	return &Rules{
+		Density:   density,
+		MinHeight: float32(math.Inf(-1)),
+		MaxHeight: float32(math.Inf(1)),
+		MinSlope:  0,
+		MaxSlope:  90,
+		MinScale:  1,
+		MaxScale:  1,
+	}
+}
+
+// Scatter returns instances distributed over the triangles of the specified
+// graphic, such as a terrain, in world space, following the specified rules.
+func Scatter(target graphic.IGraphic, rules *Rules) ([]Instance, error) {
+
+	return scatter(target, rules, nil, 0)
+}
+
+// Paint returns instances distributed following the specified rules over the triangles
+// of the specified graphic which are within the specified radius of a world position,
+// such as the point under the cursor of a brush. The instances are usually added to a
+// Foliage with AddInstances, and Foliage.Erase removes instances like an eraser.
+func Paint(target graphic.IGraphic, rules *Rules, center *math32.Vector3, radius float32) ([]Instance, error) {
+
+	return scatter(target, rules, center, radius)
+}
+
+// scatter returns instances distributed over the surface of the target following the rules,
+// only within the radius of the center if it is not nil.
+func scatter(target graphic.IGraphic, rules *Rules, center *math32.Vector3, radius float32) ([]Instance, error) {
+
+	gr := target.GetGraphic()
+	geom := target.GetGeometry()
+	if gr.Mode() != gls.TRIANGLES && geom.Primitive() == 0 {
+		return nil, errors.New("only triangle meshes can be scattered over")
+	}
+	tris := geom.TriangleIndices()
+	if tris == nil {
+		return nil, errors.New("only triangle meshes can be scattered over")
+	}
+	vbo := geom.VBO(gls.VertexPosition)
+	if vbo == nil {
+		return nil, errors.New("geometry has no vertex positions")
+	}
+	posAttrib := vbo.Attrib(gls.VertexPosition)
+	uvVBO := geom.VBO(gls.VertexTexcoord)
+	if rules.DensityMap != nil && uvVBO == nil {
+		return nil, errors.New("density maps require texture coordinates")
+	}
+
+	gr.UpdateMatrixWorld()
+	mw := gr.MatrixWorld()
+	rng := rand.New(rand.NewSource(rules.Seed))
+	up := math32.Vector3{Y: 1}
+	var values [4]float32
+	var p [3]math32.Vector3
+	var uv [3]math32.Vector2
+	var instances []Instance
+	for t := 0; t+2 < len(tris); t += 3 {
+		// World vertices of the triangle
+		for i := 0; i < 3; i++ {
+			vbo.ReadAttrib(posAttrib, int(tris[t+i]), values[:])
+			p[i] = math32.Vector3{X: values[0], Y: values[1], Z: values[2]}
+			p[i].ApplyMatrix4(&mw)
+		}
+		if center != nil && !triangleNear(&p, center, radius) {
+			continue
+		}
+		e1 := p[1]
+		e1.Sub(&p[0])
+		e2 := p[2]
+		e2.Sub(&p[0])
+		var normal math32.Vector3
+		normal.CrossVectors(&e1, &e2)
+		area := normal.Length() / 2
+		if area == 0 {
+			continue
+		}
+		normal.MultiplyScalar(0.5 / area)
+		slope := math32.RadToDeg(math32.Acos(math32.Clamp(math32.Abs(normal.Y), 0, 1)))
+		if slope < rules.MinSlope || slope > rules.MaxSlope {
+			continue
+		}
+		if normal.Y < 0 {
+			normal.Negate()
+		}
+		if rules.DensityMap != nil {
+			attrib := uvVBO.Attrib(gls.VertexTexcoord)
+			for i := 0; i < 3; i++ {
+				uvVBO.ReadAttrib(attrib, int(tris[t+i]), values[:])
+				uv[i] = math32.Vector2{X: values[0], Y: values[1]}
+			}
+		}
+
+		// The fractional part of the expected number of instances is placed randomly
+		expected := area * rules.Density
+		count := int(expected)
+		if rng.Float32() < expected-float32(count) {
+			count++
+		}
+		for n := 0; n < count; n++ {
+			// Uniform random point of the triangle
+			r1 := math32.Sqrt(rng.Float32())
+			r2 := rng.Float32()
+			a, b, c := 1-r1, r1*(1-r2), r1*r2
+			pos := math32.Vector3{
+				X: a*p[0].X + b*p[1].X + c*p[2].X,
+				Y: a*p[0].Y + b*p[1].Y + c*p[2].Y,
+				Z: a*p[0].Z + b*p[1].Z + c*p[2].Z,
+			}
+			yaw := rng.Float32() * 2 * math32.Pi
+			scale := rules.MinScale + rng.Float32()*(rules.MaxScale-rules.MinScale)
+			keep := rng.Float32()
+			if pos.Y < rules.MinHeight || pos.Y > rules.MaxHeight {
+				continue
+			}
+			if center != nil && pos.DistanceToSquared(center) > radius*radius {
+				continue
+			}
+			if rules.DensityMap != nil {
+				u := a*uv[0].X + b*uv[1].X + c*uv[2].X
+				v := a*uv[0].Y + b*uv[1].Y + c*uv[2].Y
+				if keep >= rules.DensityMap.Density(u, v) {
+					continue
+				}
+			}
+
+			// Rotation around the vertical followed by the tilt towards the normal
+			inst := Instance{Position: pos, Scale: scale}
+			inst.Rotation.SetIdentity()
+			if !rules.FixedYaw {
+				inst.Rotation.SetFromAxisAngle(&up, yaw)
+			}
+			if rules.AlignToNormal > 0 {
+				var tilt, identity math32.Quaternion
+				tilt.SetFromUnitVectors(&up, &normal)
+				identity.SetIdentity()
+				identity.Slerp(&tilt, math32.Clamp(rules.AlignToNormal, 0, 1))
+				inst.Rotation.MultiplyQuaternions(&identity, &inst.Rotation)
+			}
+			instances = append(instances, inst)
+		}
+	}
+	return instances, nil
+}
+
+// triangleNear returns whether the bounding box of a triangle is within the radius of the center.
+func triangleNear(p *[3]math32.Vector3, center *math32.Vector3, radius float32) bool {
+
+	var box math32.Box3
+	box.MakeEmpty()
+	for i := range p {
+		box.ExpandByPoint(&p[i])
+	}
+	return box.DistanceToPoint(center) <= radius
+}
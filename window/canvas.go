@@ -10,8 +10,10 @@ import (
 	"fmt"
 	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
 	_ "image/png"
 	"syscall/js"
+	"time"
 )
 
 // Keycodes
@@ -335,6 +337,13 @@ type WebGlCanvas struct {
 	mouseMove  js.Func
 	mouseWheel js.Func
 	winResize  js.Func
+	paste      js.Func
+
+	clipboard string // Last text copied or pasted
+
+	// Joysticks
+	joyAxes    []float32 // Buffer for polled joystick axes
+	joyButtons []bool    // Buffer for polled joystick buttons
 }
 
 // Init initializes the WebGlCanvas singleton.
@@ -386,6 +395,7 @@ func Init(canvasId string) error {
 		event := args[0]
 		eventCode := event.Get("code").String()
 		w.keyEv.Key = Key(keyMap[eventCode])
+		w.keyEv.Scancode, _ = KeyScancode(w.keyEv.Key)
 		w.keyEv.Mods = getModifiers(event)
 		w.Dispatch(OnKeyDown, &w.keyEv)
 		return nil
@@ -397,12 +407,23 @@ func Init(canvasId string) error {
 		event := args[0]
 		eventCode := event.Get("code").String()
 		w.keyEv.Key = Key(keyMap[eventCode])
+		w.keyEv.Scancode, _ = KeyScancode(w.keyEv.Key)
 		w.keyEv.Mods = getModifiers(event)
 		w.Dispatch(OnKeyUp, &w.keyEv)
 		return nil
 	})
 	js.Global().Call("addEventListener", "keyup", w.keyUp)
 
+	// Keep the text of paste events since the clipboard can only be read asynchronously
+	w.paste = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		data := args[0].Get("clipboardData")
+		if data.Truthy() {
+			w.clipboard = data.Call("getData", "text").String()
+		}
+		return nil
+	})
+	js.Global().Call("addEventListener", "paste", w.paste)
+
 	// Set up mouse down callback to dispatch event
 	w.mouseDown = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		event := args[0]
@@ -474,6 +495,114 @@ func Init(canvasId string) error {
 	return nil
 }
 
+// PollJoysticks polls the state of the gamepads, detecting connections and
+// disconnections and dispatching joystick events. It should be called every frame.
+func (w *WebGlCanvas) PollJoysticks() {
+
+	nav := js.Global().Get("navigator")
+	if !nav.Get("getGamepads").Truthy() {
+		return
+	}
+	gamepads := nav.Call("getGamepads")
+	count := gamepads.Length()
+	// Disconnects joysticks in slots which no longer exist
+	for _, j := range joysticks.list() {
+		if j.Slot >= count {
+			joysticks.disconnect(w, j.Slot)
+		}
+	}
+	for slot := 0; slot < count; slot++ {
+		gp := gamepads.Index(slot)
+		j := joysticks.get(slot)
+		if !gp.Truthy() || !gp.Get("connected").Bool() {
+			if j != nil {
+				joysticks.disconnect(w, slot)
+			}
+			continue
+		}
+		if j == nil {
+			j = &Joystick{Slot: slot, Name: gp.Get("id").String()}
+			j.Gamepad = gp.Get("mapping").String() == "standard"
+			if gp.Get("vibrationActuator").Truthy() {
+				j.Caps |= JoystickRumble
+			}
+			if pose := gp.Get("pose"); pose.Truthy() && pose.Get("hasOrientation").Bool() {
+				j.Caps |= JoystickGyro
+			}
+			j.features = &gamepadFeatures{slot}
+			joysticks.connect(w, j)
+		}
+		axes := gp.Get("axes")
+		w.joyAxes = w.joyAxes[:0]
+		for i := 0; i < axes.Length(); i++ {
+			w.joyAxes = append(w.joyAxes, float32(axes.Index(i).Float()))
+		}
+		buttons := gp.Get("buttons")
+		w.joyButtons = w.joyButtons[:0]
+		for i := 0; i < buttons.Length(); i++ {
+			w.joyButtons = append(w.joyButtons, buttons.Index(i).Get("pressed").Bool())
+		}
+		joysticks.update(w, j, w.joyAxes, w.joyButtons)
+	}
+}
+
+// gamepadFeatures implements the optional joystick features using the Gamepad API.
+type gamepadFeatures struct {
+	slot int // Gamepad index
+}
+
+// gamepad returns the current Gamepad object.
+func (g *gamepadFeatures) gamepad() js.Value {
+
+	return js.Global().Get("navigator").Call("getGamepads").Index(g.slot)
+}
+
+func (g *gamepadFeatures) rumble(low, high float32, duration time.Duration) error {
+
+	gp := g.gamepad()
+	if !gp.Truthy() {
+		return ErrJoystickDisconnected
+	}
+	act := gp.Get("vibrationActuator")
+	if !act.Truthy() {
+		return ErrJoystickUnsupported
+	}
+	if duration <= 0 {
+		if act.Get("reset").Truthy() {
+			act.Call("reset")
+		}
+		return nil
+	}
+	act.Call("playEffect", "dual-rumble", map[string]interface{}{
+		"duration":        duration.Seconds() * 1000,
+		"strongMagnitude": low,
+		"weakMagnitude":   high,
+	})
+	return nil
+}
+
+func (g *gamepadFeatures) gyro() (math32.Vector3, bool) {
+
+	gp := g.gamepad()
+	if !gp.Truthy() || !gp.Get("pose").Truthy() {
+		return math32.Vector3{}, false
+	}
+	av := gp.Get("pose").Get("angularVelocity")
+	if !av.Truthy() {
+		return math32.Vector3{}, false
+	}
+	return math32.Vector3{
+		X: float32(av.Index(0).Float()),
+		Y: float32(av.Index(1).Float()),
+		Z: float32(av.Index(2).Float()),
+	}, true
+}
+
+func (g *gamepadFeatures) battery() (float32, bool, bool) {
+
+	return 0, false, false
+}
+
 // getModifiers extracts a ModifierKey bitmask from a Javascript event object.
 func getModifiers(event js.Value) ModifierKey {
 
@@ -530,6 +659,7 @@ func (w *WebGlCanvas) Destroy() {
 	w.canvas.Set("oncontextmenu", js.Null())
 	js.Global().Call("removeEventListener", "keydown", w.keyDown)
 	js.Global().Call("removeEventListener", "keyup", w.keyUp)
+	js.Global().Call("removeEventListener", "paste", w.paste)
 	w.canvas.Call("removeEventListener", "mousedown", w.mouseDown)
 	w.canvas.Call("removeEventListener", "mouseup", w.mouseUp)
 	w.canvas.Call("removeEventListener", "mousemove", w.mouseMove)
@@ -540,6 +670,7 @@ func (w *WebGlCanvas) Destroy() {
 	w.onCtxMenu.Release()
 	w.keyDown.Release()
 	w.keyUp.Release()
+	w.paste.Release()
 	w.mouseDown.Release()
 	w.mouseUp.Release()
 	w.mouseMove.Release()
@@ -581,6 +712,24 @@ func (w *WebGlCanvas) CreateCursor(imgFile string, xhot, yhot int) (Cursor, erro
 	return 0, nil
 }
 
+// Clipboard returns the text of the last copy or paste.
+// Browsers only allow reading the system clipboard asynchronously, so text copied
+// by other applications is only seen after it is pasted into the page.
+func (w *WebGlCanvas) Clipboard() string {
+
+	return w.clipboard
+}
+
+// SetClipboard sets the text contents of the clipboard.
+func (w *WebGlCanvas) SetClipboard(text string) {
+
+	w.clipboard = text
+	clipboard := js.Global().Get("navigator").Get("clipboard")
+	if clipboard.Truthy() {
+		clipboard.Call("writeText", text)
+	}
+}
+
 // SetCursor sets the window's cursor to a standard one
 func (w *WebGlCanvas) SetCursor(cursor Cursor) {
 
@@ -599,3 +748,10 @@ func (w *WebGlCanvas) DisposeAllCustomCursors() {
 //	// TODO
 //	// Hide cursor etc
 //}
+
+// layoutKeyName returns the name of the printable key in the current keyboard layout.
+// Browsers only provide the layout asynchronously, so the US name is always used.
+func layoutKeyName(key Key, sc Scancode) string {
+
+	return ""
+}
@@ -18,6 +18,7 @@ import (
 	"image"
 	_ "image/png"
 	"os"
+	"time"
 )
 
 // Keycodes
@@ -211,6 +212,10 @@ type GlfwWindow struct {
 	// Cursors
 	cursors       map[Cursor]*glfw.Cursor
 	lastCursorKey Cursor
+
+	// Joysticks
+	joyScan    time.Time // Time of the last scan for connected joysticks
+	joyButtons []bool    // Buffer for polled joystick buttons
 }
 
 // Init initializes the GlfwWindow singleton with the specified width, height, and title.
@@ -241,6 +246,9 @@ func Init(width, height int, title string) error {
 	glfw.WindowHint(glfw.ContextVersionMinor, 3)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.Samples, 8)
+	// Request an sRGB capable framebuffer, which only encodes the output to sRGB
+	// when the renderer enables it for the linear color pipeline
+	glfw.WindowHint(glfw.SRGBCapable, glfw.True)
 	// Set OpenGL forward compatible context only for OSX because it is required for OSX.
 	// When this is set, glLineWidth(width) only accepts width=1.0 and generates an error
 	// for any other values although the spec says it should ignore unsupported widths
@@ -296,6 +304,7 @@ func Init(width, height int, title string) error {
 	// Set up key callback to dispatch event
 	w.SetKeyCallback(func(x *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
 		w.keyEv.Key = Key(key)
+		w.keyEv.Scancode = Scancode(scancode)
 		w.keyEv.Mods = ModifierKey(mods)
 		w.mods = w.keyEv.Mods
 		if action == glfw.Press {
@@ -427,10 +436,46 @@ func (w *GlfwWindow) ScreenResolution(p interface{}) (width, height int) {
 	return vmode.Width, vmode.Height
 }
 
-// PollEvents process events in the event queue
+// PollEvents process events in the event queue and polls the joysticks
 func (w *GlfwWindow) PollEvents() {
 
 	glfw.PollEvents()
+	w.PollJoysticks()
+}
+
+// PollJoysticks polls the state of the joysticks, detecting connections and
+// disconnections and dispatching joystick events. It is called by PollEvents.
+// Disconnected slots are scanned once per second since checking them can be slow.
+func (w *GlfwWindow) PollJoysticks() {
+
+	now := time.Now()
+	scan := now.Sub(w.joyScan) >= time.Second
+	if scan {
+		w.joyScan = now
+	}
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+		slot := int(joy - glfw.Joystick1)
+		j := joysticks.get(slot)
+		if j == nil && !scan {
+			continue
+		}
+		if !glfw.JoystickPresent(joy) {
+			if j != nil {
+				joysticks.disconnect(w, slot)
+			}
+			continue
+		}
+		if j == nil {
+			// Rumble, gyro and battery are not supported by GLFW
+			j = &Joystick{Slot: slot, Name: glfw.GetJoystickName(joy)}
+			joysticks.connect(w, j)
+		}
+		w.joyButtons = w.joyButtons[:0]
+		for _, b := range glfw.GetJoystickButtons(joy) {
+			w.joyButtons = append(w.joyButtons, b != 0)
+		}
+		joysticks.update(w, j, glfw.GetJoystickAxes(joy), w.joyButtons)
+	}
 }
 
 // SetSwapInterval sets the number of screen updates to wait from the time SwapBuffer()
@@ -440,6 +485,14 @@ func (w *GlfwWindow) SetSwapInterval(interval int) {
 	glfw.SwapInterval(interval)
 }
 
+// SetCursorMode sets whether the cursor is normal, hidden over the window, or disabled.
+// A disabled cursor is hidden and captured by the window, and its position is virtual
+// and unlimited, so its motion is not clamped at the edges of the screen.
+func (w *GlfwWindow) SetCursorMode(mode CursorMode) {
+
+	w.Window.SetInputMode(glfw.CursorMode, int(mode))
+}
+
 // SetCursor sets the window's cursor.
 func (w *GlfwWindow) SetCursor(cursor Cursor) {
 
@@ -495,8 +548,35 @@ func (w *GlfwWindow) DisposeAllCustomCursors() {
 	w.lastCursorKey = CursorLast
 }
 
+// Clipboard returns the text contents of the system clipboard.
+func (w *GlfwWindow) Clipboard() string {
+
+	text, err := w.GetClipboardString()
+	if err != nil {
+		return ""
+	}
+	return text
+}
+
+// SetClipboard sets the text contents of the system clipboard.
+func (w *GlfwWindow) SetClipboard(text string) {
+
+	w.SetClipboardString(text)
+}
+
 // Center centers the window on the screen.
 //func (w *GlfwWindow) Center() {
 //
 //	// TODO
 //}
+
+// layoutKeyName returns the name of the printable key with the specified key or scancode
+// in the current keyboard layout, or an empty string if it is not known.
+func layoutKeyName(key Key, sc Scancode) string {
+
+	name := glfw.GetKeyName(glfw.Key(key), int(sc))
+	if len(name) == 1 && name[0] >= 'a' && name[0] <= 'z' {
+		name = string(name[0] - 'a' + 'A')
+	}
+	return name
+}
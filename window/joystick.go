@@ -0,0 +1,213 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package window
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// Joystick event names. See availability per platform below ("x" indicates available).
+// Joystick state is polled once per frame, so events are dispatched from the main loop.
+const ( //                                                 Desktop | Browser |
+	OnJoystickConnect    = "w.OnJoystickConnect"    //    x    |    x    |
+	OnJoystickDisconnect = "w.OnJoystickDisconnect" //    x    |    x    |
+	OnJoystickButtonDown = "w.OnJoystickButtonDown" //    x    |    x    |
+	OnJoystickButtonUp   = "w.OnJoystickButtonUp"   //    x    |    x    |
+)
+
+// JoystickCaps is a bitmask of the optional features supported by a joystick.
+type JoystickCaps int
+
+// Optional joystick features. Availability depends on the device and the platform.
+const ( //                                           Desktop | Browser |
+	JoystickRumble  = JoystickCaps(1 << iota) //         |    x    |
+	JoystickGyro                              //         |    x    |
+	JoystickBattery                           //         |         |
+)
+
+// ErrJoystickUnsupported is returned when a joystick does not support a feature.
+var ErrJoystickUnsupported = errors.New("joystick feature not supported")
+
+// ErrJoystickDisconnected is returned when a joystick is no longer connected.
+var ErrJoystickDisconnected = errors.New("joystick disconnected")
+
+// JoystickEvent describes a joystick event.
+type JoystickEvent struct {
+	Joystick *Joystick
+	Button   int // Button index for button events
+}
+
+// Joystick describes a joystick or gamepad and its state at the last poll.
+type Joystick struct {
+	Slot      int          // Platform slot index, which can be reused after disconnection
+	ID        string       // Identity which stays the same when the device is reconnected
+	Name      string       // Device name reported by the platform
+	Gamepad   bool         // Whether the buttons and axes use the standard gamepad layout
+	Caps      JoystickCaps // Optional features supported
+	Axes      []float32    // Axis values between -1 and 1
+	Buttons   []bool       // Button states
+	connected bool
+	features  joystickFeatures // Platform implementation of optional features, or nil
+}
+
+// joystickFeatures is implemented by the platforms which support optional joystick features.
+type joystickFeatures interface {
+	rumble(low, high float32, duration time.Duration) error
+	gyro() (math32.Vector3, bool)
+	battery() (float32, bool, bool)
+}
+
+// Joysticks returns the connected joysticks sorted by slot.
+func Joysticks() []*Joystick {
+
+	return joysticks.list()
+}
+
+// Connected returns whether the joystick is still connected.
+func (j *Joystick) Connected() bool {
+
+	return j.connected
+}
+
+// Axis returns the value of the specified axis or 0 if it does not exist.
+func (j *Joystick) Axis(i int) float32 {
+
+	if i < 0 || i >= len(j.Axes) {
+		return 0
+	}
+	return j.Axes[i]
+}
+
+// Button returns whether the specified button is pressed.
+func (j *Joystick) Button(i int) bool {
+
+	if i < 0 || i >= len(j.Buttons) {
+		return false
+	}
+	return j.Buttons[i]
+}
+
+// Rumble starts force feedback with the specified low and high frequency motor
+// intensities, between 0 and 1, for the specified duration.
+func (j *Joystick) Rumble(low, high float32, duration time.Duration) error {
+
+	if !j.connected {
+		return ErrJoystickDisconnected
+	}
+	if j.Caps&JoystickRumble == 0 {
+		return ErrJoystickUnsupported
+	}
+	return j.features.rumble(math32.Clamp(low, 0, 1), math32.Clamp(high, 0, 1), duration)
+}
+
+// StopRumble stops force feedback.
+func (j *Joystick) StopRumble() error {
+
+	return j.Rumble(0, 0, 0)
+}
+
+// Gyro returns the angular velocity of the device in radians per second, if available.
+func (j *Joystick) Gyro() (math32.Vector3, bool) {
+
+	if !j.connected || j.Caps&JoystickGyro == 0 {
+		return math32.Vector3{}, false
+	}
+	return j.features.gyro()
+}
+
+// Battery returns the battery level, between 0 and 1, and whether it is charging, if available.
+func (j *Joystick) Battery() (level float32, charging bool, ok bool) {
+
+	if !j.connected || j.Caps&JoystickBattery == 0 {
+		return 0, false, false
+	}
+	return j.features.battery()
+}
+
+// joystickTracker keeps the connected joysticks, assigns their identities
+// and dispatches joystick events from the polled state.
+type joystickTracker struct {
+	slots map[int]*Joystick
+}
+
+// Connected joysticks
+var joysticks = joystickTracker{slots: make(map[int]*Joystick)}
+
+// list returns the connected joysticks sorted by slot.
+func (t *joystickTracker) list() []*Joystick {
+
+	list := make([]*Joystick, 0, len(t.slots))
+	for _, j := range t.slots {
+		list = append(list, j)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Slot < list[b].Slot })
+	return list
+}
+
+// get returns the joystick connected at the specified slot or nil.
+func (t *joystickTracker) get(slot int) *Joystick {
+
+	return t.slots[slot]
+}
+
+// connect registers a joystick connected at the specified slot and dispatches OnJoystickConnect.
+// The identity is the device name followed by the lowest index not used by another
+// connected device with the same name, so a reconnected device gets its identity back.
+func (t *joystickTracker) connect(d core.IDispatcher, j *Joystick) {
+
+	used := make(map[string]bool)
+	for _, o := range t.slots {
+		used[o.ID] = true
+	}
+	for n := 0; ; n++ {
+		id := j.Name + "#" + strconv.Itoa(n)
+		if !used[id] {
+			j.ID = id
+			break
+		}
+	}
+	j.connected = true
+	t.slots[j.Slot] = j
+	d.Dispatch(OnJoystickConnect, &JoystickEvent{Joystick: j})
+}
+
+// disconnect removes the joystick at the specified slot and dispatches OnJoystickDisconnect.
+func (t *joystickTracker) disconnect(d core.IDispatcher, slot int) {
+
+	j := t.slots[slot]
+	if j == nil {
+		return
+	}
+	delete(t.slots, slot)
+	j.connected = false
+	d.Dispatch(OnJoystickDisconnect, &JoystickEvent{Joystick: j})
+}
+
+// update sets the polled state of a joystick and dispatches button events for changed buttons.
+func (t *joystickTracker) update(d core.IDispatcher, j *Joystick, axes []float32, buttons []bool) {
+
+	j.Axes = append(j.Axes[:0], axes...)
+	if len(j.Buttons) < len(buttons) {
+		j.Buttons = append(j.Buttons, make([]bool, len(buttons)-len(j.Buttons))...)
+	}
+	for i, pressed := range buttons {
+		if pressed == j.Buttons[i] {
+			continue
+		}
+		j.Buttons[i] = pressed
+		if pressed {
+			d.Dispatch(OnJoystickButtonDown, &JoystickEvent{Joystick: j, Button: i})
+		} else {
+			d.Dispatch(OnJoystickButtonUp, &JoystickEvent{Joystick: j, Button: i})
+		}
+	}
+	j.Buttons = j.Buttons[:len(buttons)]
+}
@@ -8,8 +8,9 @@ import "github.com/g3n/engine/core"
 
 // KeyState keeps track of the state of all keys.
 type KeyState struct {
-	win    core.IDispatcher
-	states map[Key]bool
+	win       core.IDispatcher
+	states    map[Key]bool
+	scancodes map[Scancode]bool
 }
 
 // NewKeyState returns a new KeyState object.
@@ -140,6 +141,7 @@ func NewKeyState(win core.IDispatcher) *KeyState {
 		KeyRightSuper:   false,
 		KeyMenu:         false,
 	}
+	ks.scancodes = make(map[Scancode]bool)
 
 	// Subscribe to window key events
 	ks.win.SubscribeID(OnKeyUp, &ks, ks.onKey)
@@ -161,6 +163,13 @@ func (ks *KeyState) Pressed(k Key) bool {
 	return ks.states[k]
 }
 
+// ScancodePressed returns whether the key with the specified scancode is currently pressed.
+// Unlike Pressed it does not depend on the keyboard layout.
+func (ks *KeyState) ScancodePressed(sc Scancode) bool {
+
+	return ks.scancodes[sc]
+}
+
 // onKey receives key events and updates the internal map of states.
 func (ks *KeyState) onKey(evname string, ev interface{}) {
 
@@ -168,7 +177,9 @@ func (ks *KeyState) onKey(evname string, ev interface{}) {
 	switch evname {
 	case OnKeyUp:
 		ks.states[kev.Key] = false
+		ks.scancodes[kev.Scancode] = false
 	case OnKeyDown:
 		ks.states[kev.Key] = true
+		ks.scancodes[kev.Scancode] = true
 	}
 }
@@ -0,0 +1,162 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package window
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keys, mouse buttons and modifier keys are encoded as text, for example in JSON,
+// with stable names which do not depend on the numeric codes of the window backend,
+// so saved input bindings work on the desktop and in the browser.
+
+// Stable names of the keys, which are their constant names without the "Key" prefix
+var keyIDs = map[Key]string{
+	KeyUnknown: "Unknown", KeySpace: "Space", KeyApostrophe: "Apostrophe", KeyComma: "Comma",
+	KeyMinus: "Minus", KeyPeriod: "Period", KeySlash: "Slash", Key0: "0", Key1: "1", Key2: "2",
+	Key3: "3", Key4: "4", Key5: "5", Key6: "6", Key7: "7", Key8: "8", Key9: "9",
+	KeySemicolon: "Semicolon", KeyEqual: "Equal", KeyA: "A", KeyB: "B", KeyC: "C", KeyD: "D",
+	KeyE: "E", KeyF: "F", KeyG: "G", KeyH: "H", KeyI: "I", KeyJ: "J", KeyK: "K", KeyL: "L",
+	KeyM: "M", KeyN: "N", KeyO: "O", KeyP: "P", KeyQ: "Q", KeyR: "R", KeyS: "S", KeyT: "T",
+	KeyU: "U", KeyV: "V", KeyW: "W", KeyX: "X", KeyY: "Y", KeyZ: "Z",
+	KeyLeftBracket: "LeftBracket", KeyBackslash: "Backslash", KeyRightBracket: "RightBracket",
+	KeyGraveAccent: "GraveAccent", KeyWorld1: "World1", KeyWorld2: "World2",
+	KeyEscape: "Escape", KeyEnter: "Enter", KeyTab: "Tab", KeyBackspace: "Backspace",
+	KeyInsert: "Insert", KeyDelete: "Delete", KeyRight: "Right", KeyLeft: "Left",
+	KeyDown: "Down", KeyUp: "Up", KeyPageUp: "PageUp", KeyPageDown: "PageDown",
+	KeyHome: "Home", KeyEnd: "End", KeyCapsLock: "CapsLock", KeyScrollLock: "ScrollLock",
+	KeyNumLock: "NumLock", KeyPrintScreen: "PrintScreen", KeyPause: "Pause",
+	KeyF1: "F1", KeyF2: "F2", KeyF3: "F3", KeyF4: "F4", KeyF5: "F5", KeyF6: "F6", KeyF7: "F7",
+	KeyF8: "F8", KeyF9: "F9", KeyF10: "F10", KeyF11: "F11", KeyF12: "F12", KeyF13: "F13",
+	KeyF14: "F14", KeyF15: "F15", KeyF16: "F16", KeyF17: "F17", KeyF18: "F18", KeyF19: "F19",
+	KeyF20: "F20", KeyF21: "F21", KeyF22: "F22", KeyF23: "F23", KeyF24: "F24", KeyF25: "F25",
+	KeyKP0: "KP0", KeyKP1: "KP1", KeyKP2: "KP2", KeyKP3: "KP3", KeyKP4: "KP4", KeyKP5: "KP5",
+	KeyKP6: "KP6", KeyKP7: "KP7", KeyKP8: "KP8", KeyKP9: "KP9", KeyKPDecimal: "KPDecimal",
+	KeyKPDivide: "KPDivide", KeyKPMultiply: "KPMultiply", KeyKPSubtract: "KPSubtract",
+	KeyKPAdd: "KPAdd", KeyKPEnter: "KPEnter", KeyKPEqual: "KPEqual",
+	KeyLeftShift: "LeftShift", KeyLeftControl: "LeftControl", KeyLeftAlt: "LeftAlt",
+	KeyLeftSuper: "LeftSuper", KeyRightShift: "RightShift", KeyRightControl: "RightControl",
+	KeyRightAlt: "RightAlt", KeyRightSuper: "RightSuper", KeyMenu: "Menu",
+}
+
+// Stable names of the mouse buttons
+var mouseButtonIDs = map[MouseButton]string{
+	MouseButtonLeft:   "Left",
+	MouseButtonRight:  "Right",
+	MouseButtonMiddle: "Middle",
+}
+
+// Stable names of the modifier keys, in the order they are encoded
+var modifierIDs = []struct {
+	mod  ModifierKey
+	name string
+}{
+	{ModShift, "Shift"},
+	{ModControl, "Control"},
+	{ModAlt, "Alt"},
+	{ModSuper, "Super"},
+}
+
+// Inverse tables
+var keysByID, mouseButtonsByID = func() (map[string]Key, map[string]MouseButton) {
+
+	keys := make(map[string]Key, len(keyIDs))
+	for k, id := range keyIDs {
+		keys[strings.ToLower(id)] = k
+	}
+	buttons := make(map[string]MouseButton, len(mouseButtonIDs))
+	for b, id := range mouseButtonIDs {
+		buttons[strings.ToLower(id)] = b
+	}
+	return keys, buttons
+}()
+
+// MarshalText satisfies the encoding.TextMarshaler interface and returns the stable name of the key.
+func (k Key) MarshalText() ([]byte, error) {
+
+	id, ok := keyIDs[k]
+	if !ok {
+		return nil, fmt.Errorf("invalid key: %d", int(k))
+	}
+	return []byte(id), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface and sets
+// the key from its stable name, ignoring case.
+func (k *Key) UnmarshalText(text []byte) error {
+
+	key, ok := keysByID[strings.ToLower(string(text))]
+	if !ok {
+		return fmt.Errorf("invalid key name: %q", text)
+	}
+	*k = key
+	return nil
+}
+
+// MarshalText satisfies the encoding.TextMarshaler interface and returns the stable name of the mouse button.
+func (b MouseButton) MarshalText() ([]byte, error) {
+
+	id, ok := mouseButtonIDs[b]
+	if !ok {
+		return nil, fmt.Errorf("invalid mouse button: %d", int(b))
+	}
+	return []byte(id), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface and sets
+// the mouse button from its stable name, ignoring case.
+func (b *MouseButton) UnmarshalText(text []byte) error {
+
+	button, ok := mouseButtonsByID[strings.ToLower(string(text))]
+	if !ok {
+		return fmt.Errorf("invalid mouse button name: %q", text)
+	}
+	*b = button
+	return nil
+}
+
+// MarshalText satisfies the encoding.TextMarshaler interface and returns
+// the stable names of the modifier keys in the set joined by "+", such as "Shift+Control".
+func (m ModifierKey) MarshalText() ([]byte, error) {
+
+	names := make([]string, 0, len(modifierIDs))
+	for _, id := range modifierIDs {
+		if m&id.mod != 0 {
+			names = append(names, id.name)
+			m &^= id.mod
+		}
+	}
+	if m != 0 {
+		return nil, fmt.Errorf("invalid modifier keys: %d", int(m))
+	}
+	return []byte(strings.Join(names, "+")), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface and sets
+// the modifier keys from their stable names joined by "+", ignoring case.
+func (m *ModifierKey) UnmarshalText(text []byte) error {
+
+	var mods ModifierKey
+	for _, name := range strings.Split(string(text), "+") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, id := range modifierIDs {
+			if strings.EqualFold(name, id.name) {
+				mods |= id.mod
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid modifier key name: %q", name)
+		}
+	}
+	*m = mods
+	return nil
+}
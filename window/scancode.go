@@ -0,0 +1,137 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package window
+
+import (
+	"runtime"
+)
+
+// Scancode is the platform specific code of a physical key, which does not depend
+// on the keyboard layout. Bindings by scancode, such as WASD for movement, keep
+// the same key positions on AZERTY, Dvorak or any other layout.
+type Scancode int
+
+// Keys of the main block of the keyboard, whose symbols depend on the layout,
+// in the order of the US layout. Their positions are the PC set 1 scancodes,
+// which are used by Windows and browsers and, offset by 8, by Linux and FreeBSD.
+var pcScancodes = map[Key]Scancode{
+	KeyEscape: 0x01, Key1: 0x02, Key2: 0x03, Key3: 0x04, Key4: 0x05, Key5: 0x06, Key6: 0x07,
+	Key7: 0x08, Key8: 0x09, Key9: 0x0A, Key0: 0x0B, KeyMinus: 0x0C, KeyEqual: 0x0D,
+	KeyBackspace: 0x0E, KeyTab: 0x0F, KeyQ: 0x10, KeyW: 0x11, KeyE: 0x12, KeyR: 0x13,
+	KeyT: 0x14, KeyY: 0x15, KeyU: 0x16, KeyI: 0x17, KeyO: 0x18, KeyP: 0x19,
+	KeyLeftBracket: 0x1A, KeyRightBracket: 0x1B, KeyEnter: 0x1C, KeyLeftControl: 0x1D,
+	KeyA: 0x1E, KeyS: 0x1F, KeyD: 0x20, KeyF: 0x21, KeyG: 0x22, KeyH: 0x23, KeyJ: 0x24,
+	KeyK: 0x25, KeyL: 0x26, KeySemicolon: 0x27, KeyApostrophe: 0x28, KeyGraveAccent: 0x29,
+	KeyLeftShift: 0x2A, KeyBackslash: 0x2B, KeyZ: 0x2C, KeyX: 0x2D, KeyC: 0x2E, KeyV: 0x2F,
+	KeyB: 0x30, KeyN: 0x31, KeyM: 0x32, KeyComma: 0x33, KeyPeriod: 0x34, KeySlash: 0x35,
+	KeyRightShift: 0x36, KeyLeftAlt: 0x38, KeySpace: 0x39, KeyCapsLock: 0x3A,
+}
+
+// Same keys as macOS virtual key codes.
+var macScancodes = map[Key]Scancode{
+	KeyEscape: 0x35, Key1: 0x12, Key2: 0x13, Key3: 0x14, Key4: 0x15, Key5: 0x17, Key6: 0x16,
+	Key7: 0x1A, Key8: 0x1C, Key9: 0x19, Key0: 0x1D, KeyMinus: 0x1B, KeyEqual: 0x18,
+	KeyBackspace: 0x33, KeyTab: 0x30, KeyQ: 0x0C, KeyW: 0x0D, KeyE: 0x0E, KeyR: 0x0F,
+	KeyT: 0x11, KeyY: 0x10, KeyU: 0x20, KeyI: 0x22, KeyO: 0x1F, KeyP: 0x23,
+	KeyLeftBracket: 0x21, KeyRightBracket: 0x1E, KeyEnter: 0x24, KeyLeftControl: 0x3B,
+	KeyA: 0x00, KeyS: 0x01, KeyD: 0x02, KeyF: 0x03, KeyG: 0x05, KeyH: 0x04, KeyJ: 0x26,
+	KeyK: 0x28, KeyL: 0x25, KeySemicolon: 0x29, KeyApostrophe: 0x27, KeyGraveAccent: 0x32,
+	KeyLeftShift: 0x38, KeyBackslash: 0x2A, KeyZ: 0x06, KeyX: 0x07, KeyC: 0x08, KeyV: 0x09,
+	KeyB: 0x0B, KeyN: 0x2D, KeyM: 0x2E, KeyComma: 0x2B, KeyPeriod: 0x2F, KeySlash: 0x2C,
+	KeyRightShift: 0x3C, KeyLeftAlt: 0x3A, KeySpace: 0x31, KeyCapsLock: 0x39,
+}
+
+// Scancode tables for the current platform
+var keyToScancode, scancodeToKey = scancodeTables()
+
+// scancodeTables returns the key to scancode and scancode to key tables of the current platform.
+func scancodeTables() (map[Key]Scancode, map[Scancode]Key) {
+
+	src := pcScancodes
+	offset := Scancode(0)
+	switch runtime.GOOS {
+	case "darwin":
+		src = macScancodes
+	case "linux", "freebsd", "openbsd", "netbsd":
+		offset = 8 // X11 key codes
+	}
+	k2s := make(map[Key]Scancode, len(src))
+	s2k := make(map[Scancode]Key, len(src))
+	for k, s := range src {
+		k2s[k] = s + offset
+		s2k[s+offset] = k
+	}
+	return k2s, s2k
+}
+
+// KeyScancode returns the scancode of the physical key at the position
+// the specified key has on the US layout. Only the keys of the main block, whose
+// symbols depend on the layout, are supported; for other keys it returns false.
+func KeyScancode(key Key) (Scancode, bool) {
+
+	sc, ok := keyToScancode[key]
+	return sc, ok
+}
+
+// ScancodeKey returns the key at the position of the specified scancode on the
+// US layout. It is the inverse of KeyScancode.
+func ScancodeKey(sc Scancode) (Key, bool) {
+
+	key, ok := scancodeToKey[sc]
+	return key, ok
+}
+
+// KeyName returns a name suitable for display of the specified key or, if the key
+// is unknown, scancode. For printable keys the name follows the current keyboard
+// layout when the platform can provide it, so a binding to the scancode of W is
+// shown as "Z" on an AZERTY keyboard.
+func KeyName(key Key, sc Scancode) string {
+
+	if name := layoutKeyName(key, sc); name != "" {
+		return name
+	}
+	if key == KeyUnknown {
+		if k, ok := ScancodeKey(sc); ok {
+			key = k
+		}
+	}
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// ScancodeName returns a name suitable for display of the key with the specified scancode,
+// following the current keyboard layout when the platform can provide it.
+func ScancodeName(sc Scancode) string {
+
+	return KeyName(KeyUnknown, sc)
+}
+
+// Names of keys in the US layout
+var keyNames = map[Key]string{
+	KeySpace: "Space", KeyApostrophe: "'", KeyComma: ",", KeyMinus: "-", KeyPeriod: ".",
+	KeySlash: "/", Key0: "0", Key1: "1", Key2: "2", Key3: "3", Key4: "4", Key5: "5",
+	Key6: "6", Key7: "7", Key8: "8", Key9: "9", KeySemicolon: ";", KeyEqual: "=",
+	KeyA: "A", KeyB: "B", KeyC: "C", KeyD: "D", KeyE: "E", KeyF: "F", KeyG: "G", KeyH: "H",
+	KeyI: "I", KeyJ: "J", KeyK: "K", KeyL: "L", KeyM: "M", KeyN: "N", KeyO: "O", KeyP: "P",
+	KeyQ: "Q", KeyR: "R", KeyS: "S", KeyT: "T", KeyU: "U", KeyV: "V", KeyW: "W", KeyX: "X",
+	KeyY: "Y", KeyZ: "Z", KeyLeftBracket: "[", KeyBackslash: "\\", KeyRightBracket: "]",
+	KeyGraveAccent: "`", KeyEscape: "Escape", KeyEnter: "Enter", KeyTab: "Tab",
+	KeyBackspace: "Backspace", KeyInsert: "Insert", KeyDelete: "Delete", KeyRight: "Right",
+	KeyLeft: "Left", KeyDown: "Down", KeyUp: "Up", KeyPageUp: "Page Up",
+	KeyPageDown: "Page Down", KeyHome: "Home", KeyEnd: "End", KeyCapsLock: "Caps Lock",
+	KeyScrollLock: "Scroll Lock", KeyNumLock: "Num Lock", KeyPrintScreen: "Print Screen",
+	KeyPause: "Pause", KeyF1: "F1", KeyF2: "F2", KeyF3: "F3", KeyF4: "F4", KeyF5: "F5",
+	KeyF6: "F6", KeyF7: "F7", KeyF8: "F8", KeyF9: "F9", KeyF10: "F10", KeyF11: "F11",
+	KeyF12: "F12", KeyKP0: "Keypad 0", KeyKP1: "Keypad 1", KeyKP2: "Keypad 2",
+	KeyKP3: "Keypad 3", KeyKP4: "Keypad 4", KeyKP5: "Keypad 5", KeyKP6: "Keypad 6",
+	KeyKP7: "Keypad 7", KeyKP8: "Keypad 8", KeyKP9: "Keypad 9", KeyKPDecimal: "Keypad .",
+	KeyKPDivide: "Keypad /", KeyKPMultiply: "Keypad *", KeyKPSubtract: "Keypad -",
+	KeyKPAdd: "Keypad +", KeyKPEnter: "Keypad Enter", KeyKPEqual: "Keypad =",
+	KeyLeftShift: "Left Shift", KeyLeftControl: "Left Ctrl", KeyLeftAlt: "Left Alt",
+	KeyLeftSuper: "Left Super", KeyRightShift: "Right Shift", KeyRightControl: "Right Ctrl",
+	KeyRightAlt: "Right Alt", KeyRightSuper: "Right Super", KeyMenu: "Menu",
+}
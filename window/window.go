@@ -34,6 +34,8 @@ type IWindow interface {
 	CreateCursor(imgFile string, xhot, yhot int) (Cursor, error)
 	SetCursor(cursor Cursor)
 	DisposeAllCustomCursors()
+	Clipboard() string
+	SetClipboard(text string)
 	Destroy()
 }
 
@@ -96,8 +98,9 @@ type SizeEvent struct {
 
 // KeyEvent describes a window key event
 type KeyEvent struct {
-	Key  Key
-	Mods ModifierKey
+	Key      Key
+	Scancode Scancode // Layout independent code of the physical key
+	Mods     ModifierKey
 }
 
 // CharEvent describes a window char event